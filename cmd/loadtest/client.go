@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// apiClient calls a gask instance either as the owner or with a user's
+// access token
+type apiClient struct {
+	baseURL       string
+	ownerPassword string
+	http          *http.Client
+}
+
+// apiResponse is the envelope every gask endpoint answers with
+type apiResponse struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Error   string          `json:"error"`
+}
+
+// call sends one request and returns its status and how long it took. An
+// empty token calls as the owner.
+func (c *apiClient) call(method, path, token string, body interface{}) (int, []byte, time.Duration, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token == "" {
+		req.Header.Set("X-Owner-Password", c.ownerPassword)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	started := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, nil, time.Since(started), err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	elapsed := time.Since(started)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil {
+			data = []byte(strconv.Itoa(seconds))
+		}
+	}
+	return resp.StatusCode, data, elapsed, err
+}
+
+// setupCall makes a setup or teardown request, waiting out rate limits,
+// and decodes the data of a successful response into out
+func (c *apiClient) setupCall(method, path, token string, body, out interface{}) error {
+	for attempt := 0; attempt < 30; attempt++ {
+		status, data, _, err := c.call(method, path, token, body)
+		if err != nil {
+			return err
+		}
+
+		if status == http.StatusTooManyRequests {
+			wait := time.Second
+			if seconds, err := strconv.Atoi(string(data)); err == nil {
+				wait = time.Duration(seconds) * time.Second
+			}
+			time.Sleep(wait)
+			continue
+		}
+
+		var resp apiResponse
+		if err := json.Unmarshal(data, &resp); err != nil || status >= 300 {
+			return fmt.Errorf("%s %s returned %d: %s", method, path, status, bytes.TrimSpace(data))
+		}
+		if out == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.Data, out)
+	}
+	return fmt.Errorf("%s %s is still rate limited", method, path)
+}
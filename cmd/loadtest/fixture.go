@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"os"
+	"time"
+)
+
+// testUser is a simulated user and the tasks it works on
+type testUser struct {
+	id      int
+	token   string
+	taskIDs []int
+}
+
+// fixture is the group, admin and users a run creates and removes afterwards
+type fixture struct {
+	groupID int
+	adminID int
+	users   []*testUser
+}
+
+type createdUser struct {
+	User struct {
+		ID int `json:"id"`
+	} `json:"user"`
+}
+
+type createdGroup struct {
+	Group struct {
+		ID int `json:"id"`
+	} `json:"group"`
+}
+
+type createdTask struct {
+	Task struct {
+		ID int `json:"id"`
+	} `json:"task"`
+}
+
+type tokenPair struct {
+	AccessToken string `json:"access_token"`
+}
+
+// setUp creates a group with its own admin and count users with tasks,
+// and signs each user in. The returned fixture holds whatever was created
+// so far, even when an error is returned.
+func setUp(client *apiClient, run string, count, tasksPerUser int) (*fixture, error) {
+	f := &fixture{}
+	password := "loadtest-" + run
+
+	var admin createdUser
+	err := client.setupCall("POST", "/users", "", map[string]interface{}{
+		"full_name": "Load Test Admin " + run,
+		"email":     fmt.Sprintf("loadtest-%s-admin@example.com", run),
+		"password":  password,
+		"role":      "group_admin",
+	}, &admin)
+	if err != nil {
+		return f, err
+	}
+	f.adminID = admin.User.ID
+
+	var group createdGroup
+	err = client.setupCall("POST", "/groups", "", map[string]interface{}{
+		"name":     "Load Test " + run,
+		"admin_id": f.adminID,
+	}, &group)
+	if err != nil {
+		return f, err
+	}
+	f.groupID = group.Group.ID
+
+	rng := mathrand.New(mathrand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < count; i++ {
+		email := fmt.Sprintf("loadtest-%s-%d@example.com", run, i+1)
+
+		var created createdUser
+		err := client.setupCall("POST", "/users", "", map[string]interface{}{
+			"full_name": fmt.Sprintf("Load Test User %d", i+1),
+			"email":     email,
+			"password":  password,
+			"group_ids": []int{f.groupID},
+		}, &created)
+		if err != nil {
+			return f, err
+		}
+		user := &testUser{id: created.User.ID}
+		f.users = append(f.users, user)
+
+		var tokens tokenPair
+		err = client.setupCall("POST", "/auth/token", "", map[string]string{
+			"email":    email,
+			"password": password,
+		}, &tokens)
+		if err != nil {
+			return f, err
+		}
+		user.token = tokens.AccessToken
+
+		for j := 0; j < tasksPerUser; j++ {
+			var task createdTask
+			err := client.setupCall("POST", fmt.Sprintf("/users/%d/tasks", user.id), user.token, newTaskBody(rng, f.groupID), &task)
+			if err != nil {
+				return f, err
+			}
+			user.taskIDs = append(user.taskIDs, task.Task.ID)
+		}
+	}
+
+	return f, nil
+}
+
+// tearDown deletes the users, which takes their tasks with them, and the group
+func (f *fixture) tearDown(client *apiClient) {
+	if len(f.users) == 0 && f.groupID == 0 && f.adminID == 0 {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "🧹 Removing test data...")
+	for _, user := range f.users {
+		if err := client.setupCall("DELETE", fmt.Sprintf("/users/%d", user.id), "", nil, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		}
+	}
+	f.users = nil
+
+	if f.groupID != 0 {
+		if err := client.setupCall("DELETE", fmt.Sprintf("/groups/%d", f.groupID), "", nil, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		}
+		f.groupID = 0
+	}
+	if f.adminID != 0 {
+		if err := client.setupCall("DELETE", fmt.Sprintf("/users/%d", f.adminID), "", nil, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		}
+		f.adminID = 0
+	}
+}
@@ -0,0 +1,116 @@
+// Command loadtest sends a mix of reads, writes and searches to a running
+// gask instance, reports latency percentiles per endpoint and fails when
+// they exceed the budgets in a config file.
+//
+//	go run ./cmd/loadtest -url http://localhost:7890 -owner-password admin1234 \
+//	  -duration 1m -concurrency 20 -budgets loadtest-budgets.json
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	mathrand "math/rand"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:7890", "base URL of the gask instance")
+	ownerPassword := flag.String("owner-password", os.Getenv("OWNER_PASSWORD"), "owner password, used to create and remove the test users")
+	duration := flag.Duration("duration", 30*time.Second, "how long to send traffic")
+	concurrency := flag.Int("concurrency", 10, "number of simulated users sending requests at once")
+	tasksPerUser := flag.Int("tasks", 20, "tasks each simulated user starts with")
+	budgetsFile := flag.String("budgets", "", "JSON file with latency and error budgets")
+	jsonOutput := flag.Bool("json", false, "print the report as JSON")
+	keep := flag.Bool("keep", false, "keep the test group, users and tasks afterwards")
+	seed := flag.Int64("seed", 0, "random seed for a reproducible traffic mix (0 picks one)")
+	flag.Parse()
+
+	if *ownerPassword == "" {
+		log.Fatalf("❌ -owner-password (or OWNER_PASSWORD) is required to set up test users")
+	}
+	if *concurrency < 1 || *duration <= 0 {
+		log.Fatalf("❌ -concurrency must be at least 1 and -duration positive")
+	}
+
+	var budgets *Budgets
+	if *budgetsFile != "" {
+		var err error
+		budgets, err = loadBudgets(*budgetsFile)
+		if err != nil {
+			log.Fatalf("❌ Failed to load budgets: %v", err)
+		}
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	client := &apiClient{
+		baseURL:       *baseURL,
+		ownerPassword: *ownerPassword,
+		http:          &http.Client{Timeout: 30 * time.Second},
+	}
+
+	fmt.Fprintf(os.Stderr, "🔧 Setting up %d test users with %d tasks each...\n", *concurrency, *tasksPerUser)
+	fixture, err := setUp(client, runID(), *concurrency, *tasksPerUser)
+	if err != nil {
+		log.Printf("❌ Setup failed: %v", err)
+		if !*keep {
+			fixture.tearDown(client)
+		}
+		os.Exit(2)
+	}
+
+	fmt.Fprintf(os.Stderr, "🚀 Sending traffic for %v (seed %d)...\n", *duration, *seed)
+	results := newResults()
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i, user := range fixture.users {
+		worker := &worker{
+			client:  client,
+			user:    user,
+			groupID: fixture.groupID,
+			rng:     mathrand.New(mathrand.NewSource(*seed + int64(i))),
+			results: results,
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := results.report(*duration, budgets)
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		report.print(os.Stdout)
+	}
+
+	if !*keep {
+		fixture.tearDown(client)
+	}
+	if !report.WithinBudget {
+		os.Exit(1)
+	}
+}
+
+// runID tells apart the users and groups of concurrent or leftover runs
+func runID() string {
+	buf := make([]byte, 4)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Budget is the most an endpoint may take. Zero fields are not checked.
+type Budget struct {
+	P50Ms        float64 `json:"p50_ms,omitempty"`
+	P95Ms        float64 `json:"p95_ms,omitempty"`
+	P99Ms        float64 `json:"p99_ms,omitempty"`
+	MaxErrorRate float64 `json:"max_error_rate,omitempty"`
+}
+
+// Budgets holds a default budget and overrides per endpoint, e.g.
+//
+//	{"default": {"p95_ms": 200, "max_error_rate": 0.01},
+//	 "endpoints": {"GET /search": {"p95_ms": 400}}}
+type Budgets struct {
+	Default   Budget            `json:"default"`
+	Endpoints map[string]Budget `json:"endpoints"`
+}
+
+func loadBudgets(path string) (*Budgets, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var budgets Budgets
+	if err := json.Unmarshal(data, &budgets); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for endpoint := range budgets.Endpoints {
+		if !knownEndpoint(endpoint) {
+			return nil, fmt.Errorf("%s: no traffic is sent to %q", path, endpoint)
+		}
+	}
+	return &budgets, nil
+}
+
+func knownEndpoint(endpoint string) bool {
+	for _, op := range operations {
+		if op.endpoint == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+// budgetFor fills the fields an endpoint's budget leaves unset from the default
+func (b *Budgets) budgetFor(endpoint string) Budget {
+	budget := b.Endpoints[endpoint]
+	if budget.P50Ms == 0 {
+		budget.P50Ms = b.Default.P50Ms
+	}
+	if budget.P95Ms == 0 {
+		budget.P95Ms = b.Default.P95Ms
+	}
+	if budget.P99Ms == 0 {
+		budget.P99Ms = b.Default.P99Ms
+	}
+	if budget.MaxErrorRate == 0 {
+		budget.MaxErrorRate = b.Default.MaxErrorRate
+	}
+	return budget
+}
+
+// results collects latencies per endpoint from all workers
+type results struct {
+	mu        sync.Mutex
+	endpoints map[string]*endpointResults
+}
+
+type endpointResults struct {
+	latencies []float64
+	errors    int
+	throttled int
+}
+
+func newResults() *results {
+	return &results{endpoints: make(map[string]*endpointResults)}
+}
+
+// record adds one request. Rate-limited requests are counted apart and
+// left out of the latencies, since they never reached the handler.
+func (r *results) record(endpoint string, status int, elapsed time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := r.endpoints[endpoint]
+	if result == nil {
+		result = &endpointResults{}
+		r.endpoints[endpoint] = result
+	}
+
+	switch {
+	case status == http.StatusTooManyRequests:
+		result.throttled++
+		return
+	case err != nil || status >= 400:
+		result.errors++
+	}
+	result.latencies = append(result.latencies, float64(elapsed.Microseconds())/1000)
+}
+
+// EndpointReport is the outcome for one endpoint
+type EndpointReport struct {
+	Endpoint   string   `json:"endpoint"`
+	Requests   int      `json:"requests"`
+	Errors     int      `json:"errors"`
+	Throttled  int      `json:"throttled"`
+	ErrorRate  float64  `json:"error_rate"`
+	P50Ms      float64  `json:"p50_ms"`
+	P95Ms      float64  `json:"p95_ms"`
+	P99Ms      float64  `json:"p99_ms"`
+	MaxMs      float64  `json:"max_ms"`
+	Violations []string `json:"violations,omitempty"`
+}
+
+// Report is the outcome of a run
+type Report struct {
+	Duration      string            `json:"duration"`
+	Requests      int               `json:"requests"`
+	RequestsPerS  float64           `json:"requests_per_second"`
+	Endpoints     []*EndpointReport `json:"endpoints"`
+	BudgetChecked bool              `json:"budget_checked"`
+	WithinBudget  bool              `json:"within_budget"`
+}
+
+func (r *results) report(duration time.Duration, budgets *Budgets) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &Report{
+		Duration:      duration.String(),
+		BudgetChecked: budgets != nil,
+		WithinBudget:  true,
+	}
+
+	for _, op := range operations {
+		result := r.endpoints[op.endpoint]
+		if result == nil {
+			continue
+		}
+
+		latencies := result.latencies
+		sort.Float64s(latencies)
+		endpoint := &EndpointReport{
+			Endpoint:  op.endpoint,
+			Requests:  len(latencies) + result.throttled,
+			Errors:    result.errors,
+			Throttled: result.throttled,
+			P50Ms:     percentile(latencies, 50),
+			P95Ms:     percentile(latencies, 95),
+			P99Ms:     percentile(latencies, 99),
+			MaxMs:     percentile(latencies, 100),
+		}
+		if len(latencies) > 0 {
+			endpoint.ErrorRate = float64(result.errors) / float64(len(latencies))
+		}
+
+		if budgets != nil {
+			endpoint.Violations = checkBudget(endpoint, budgets.budgetFor(op.endpoint))
+			if len(endpoint.Violations) > 0 {
+				report.WithinBudget = false
+			}
+		}
+
+		report.Requests += endpoint.Requests
+		report.Endpoints = append(report.Endpoints, endpoint)
+	}
+
+	if duration > 0 {
+		report.RequestsPerS = float64(report.Requests) / duration.Seconds()
+	}
+	return report
+}
+
+func checkBudget(endpoint *EndpointReport, budget Budget) []string {
+	var violations []string
+	if budget.P50Ms > 0 && endpoint.P50Ms > budget.P50Ms {
+		violations = append(violations, fmt.Sprintf("p50 %.1fms > %.1fms", endpoint.P50Ms, budget.P50Ms))
+	}
+	if budget.P95Ms > 0 && endpoint.P95Ms > budget.P95Ms {
+		violations = append(violations, fmt.Sprintf("p95 %.1fms > %.1fms", endpoint.P95Ms, budget.P95Ms))
+	}
+	if budget.P99Ms > 0 && endpoint.P99Ms > budget.P99Ms {
+		violations = append(violations, fmt.Sprintf("p99 %.1fms > %.1fms", endpoint.P99Ms, budget.P99Ms))
+	}
+	if budget.MaxErrorRate > 0 && endpoint.ErrorRate > budget.MaxErrorRate {
+		violations = append(violations, fmt.Sprintf("errors %.2f%% > %.2f%%", endpoint.ErrorRate*100, budget.MaxErrorRate*100))
+	}
+	return violations
+}
+
+// percentile uses the nearest-rank method on sorted latencies
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func (r *Report) print(out io.Writer) {
+	fmt.Fprintf(out, "\n📊 %d requests in %s (%.1f/s)\n\n", r.Requests, r.Duration, r.RequestsPerS)
+	fmt.Fprintf(out, "%-30s %8s %7s %9s %9s %9s %9s %9s\n", "ENDPOINT", "REQUESTS", "ERRORS", "THROTTLED", "P50 MS", "P95 MS", "P99 MS", "MAX MS")
+	for _, endpoint := range r.Endpoints {
+		fmt.Fprintf(out, "%-30s %8d %7d %9d %9.1f %9.1f %9.1f %9.1f\n",
+			endpoint.Endpoint, endpoint.Requests, endpoint.Errors, endpoint.Throttled,
+			endpoint.P50Ms, endpoint.P95Ms, endpoint.P99Ms, endpoint.MaxMs)
+	}
+	fmt.Fprintln(out)
+
+	throttled := 0
+	for _, endpoint := range r.Endpoints {
+		throttled += endpoint.Throttled
+	}
+	if throttled > 0 {
+		fmt.Fprintf(out, "⚠️  %d requests were rate limited; raise RATE_LIMITS on the instance for a clean measurement\n", throttled)
+	}
+
+	if !r.BudgetChecked {
+		return
+	}
+	if r.WithinBudget {
+		fmt.Fprintln(out, "✅ All endpoints within budget")
+		return
+	}
+	for _, endpoint := range r.Endpoints {
+		if len(endpoint.Violations) > 0 {
+			fmt.Fprintf(out, "❌ %s: %s\n", endpoint.Endpoint, strings.Join(endpoint.Violations, ", "))
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	mathrand "math/rand"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var taskWords = []string{
+	"review", "deploy", "invoice", "report", "migrate", "design", "budget",
+	"client", "release", "backlog", "meeting", "audit", "onboarding", "roadmap",
+}
+
+// operation is one kind of request in the traffic mix. Endpoints are named
+// by their route so results group across IDs.
+type operation struct {
+	endpoint string
+	weight   int
+	send     func(w *worker) (int, []byte, time.Duration, error)
+}
+
+// operations is the traffic mix, weighted towards reads as in normal use
+var operations = []operation{
+	{"GET /users/{id}/tasks", 30, func(w *worker) (int, []byte, time.Duration, error) {
+		return w.client.call("GET", fmt.Sprintf("/users/%d/tasks", w.user.id), w.user.token, nil)
+	}},
+	{"GET /users/{id}/tasks/{tid}", 20, func(w *worker) (int, []byte, time.Duration, error) {
+		return w.client.call("GET", fmt.Sprintf("/users/%d/tasks/%d", w.user.id, w.randomTask()), w.user.token, nil)
+	}},
+	{"GET /tasks/search", 10, func(w *worker) (int, []byte, time.Duration, error) {
+		return w.client.call("GET", "/tasks/search?q="+url.QueryEscape(w.randomWord()), w.user.token, nil)
+	}},
+	{"GET /search", 10, func(w *worker) (int, []byte, time.Duration, error) {
+		return w.client.call("GET", "/search?q="+url.QueryEscape(w.randomWord()), w.user.token, nil)
+	}},
+	{"GET /tasks/stats", 5, func(w *worker) (int, []byte, time.Duration, error) {
+		return w.client.call("GET", "/tasks/stats", w.user.token, nil)
+	}},
+	{"POST /users/{id}/tasks", 15, func(w *worker) (int, []byte, time.Duration, error) {
+		status, data, elapsed, err := w.client.call("POST", fmt.Sprintf("/users/%d/tasks", w.user.id), w.user.token, newTaskBody(w.rng, w.groupID))
+		if err == nil && status == http.StatusCreated {
+			var resp apiResponse
+			var task createdTask
+			if json.Unmarshal(data, &resp) == nil && json.Unmarshal(resp.Data, &task) == nil {
+				w.user.taskIDs = append(w.user.taskIDs, task.Task.ID)
+			}
+		}
+		return status, data, elapsed, err
+	}},
+	{"PUT /users/{id}/tasks/{tid}", 10, func(w *worker) (int, []byte, time.Duration, error) {
+		done := w.rng.Intn(4) == 0
+		return w.client.call("PUT", fmt.Sprintf("/users/%d/tasks/%d", w.user.id, w.randomTask()), w.user.token, map[string]interface{}{
+			"priority": w.rng.Intn(4) + 1,
+			"status":   done,
+		})
+	}},
+}
+
+// worker sends requests as one simulated user until the run ends
+type worker struct {
+	client  *apiClient
+	user    *testUser
+	groupID int
+	rng     *mathrand.Rand
+	results *results
+}
+
+func (w *worker) run(ctx context.Context) {
+	totalWeight := 0
+	for _, op := range operations {
+		totalWeight += op.weight
+	}
+
+	for ctx.Err() == nil {
+		pick := w.rng.Intn(totalWeight)
+		op := operations[0]
+		for _, candidate := range operations {
+			if pick < candidate.weight {
+				op = candidate
+				break
+			}
+			pick -= candidate.weight
+		}
+		if len(w.user.taskIDs) == 0 && op.endpoint != "POST /users/{id}/tasks" && op.endpoint != "GET /users/{id}/tasks" {
+			continue
+		}
+
+		status, _, elapsed, err := op.send(w)
+		// A request cut off by the end of the run says nothing about the server
+		if err != nil && ctx.Err() != nil {
+			return
+		}
+		w.results.record(op.endpoint, status, elapsed, err)
+	}
+}
+
+func (w *worker) randomTask() int {
+	return w.user.taskIDs[w.rng.Intn(len(w.user.taskIDs))]
+}
+
+func (w *worker) randomWord() string {
+	return taskWords[w.rng.Intn(len(taskWords))]
+}
+
+func newTaskBody(rng *mathrand.Rand, groupID int) map[string]interface{} {
+	title := fmt.Sprintf("%s %s", taskWords[rng.Intn(len(taskWords))], taskWords[rng.Intn(len(taskWords))])
+	return map[string]interface{}{
+		"title":           title,
+		"priority":        rng.Intn(4) + 1,
+		"deadline":        time.Now().AddDate(0, 0, rng.Intn(30)+1).Format("2006-01-02"),
+		"information":     "Created by the load test",
+		"estimated_hours": float64(rng.Intn(16) + 1),
+		"group_id":        groupID,
+	}
+}
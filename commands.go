@@ -0,0 +1,500 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"task-manager/config"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+// runCommand handles `gask <command> [flags]` invocations instead of starting the server
+func runCommand(name string, args []string) {
+	switch name {
+	case "seed":
+		runSeedCommand(args)
+	case "user":
+		runUserCommand(args)
+	case "verify":
+		runVerifyCommand(args)
+	case "backup":
+		runBackupCommand(args)
+	case "version", "--version":
+		build := buildInfo()
+		fmt.Printf("gask %s (commit %s, built %s, %s)\n", build["version"], build["commit"], build["build_date"], build["go"])
+	case "help", "-h", "--help":
+		printCommandUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+		printCommandUsage()
+		os.Exit(2)
+	}
+}
+
+func printCommandUsage() {
+	fmt.Println("Usage: gask [command] [flags]")
+	fmt.Println()
+	fmt.Println("Without a command the API server is started.")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  seed    Populate the database with demo groups, users, tasks and leaves")
+	fmt.Println("  user    Manage users directly in the database: list, create, promote,")
+	fmt.Println("          deactivate, activate, reset-password")
+	fmt.Println("  verify  Cross-check Redis and PostgreSQL and optionally repair issues")
+	fmt.Println("  backup  Check snapshots against their manifests: verify")
+	fmt.Println("  version Print the version and commit this binary was built from")
+	fmt.Println()
+	fmt.Println("Run 'gask <command> -h' for command flags.")
+}
+
+func loadCommandConfig() *config.Config {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+	if err := modules.InitLogging(cfg); err != nil {
+		log.Fatalf("❌ Failed to configure logging: %v", err)
+	}
+	return cfg
+}
+
+// initStores connects to Redis and PostgreSQL and loads existing data for commands
+func initStores(cfg *config.Config) {
+	if err := modules.InitRedis(cfg); err != nil {
+		log.Fatalf("❌ Failed to initialize Redis: %v", err)
+	}
+	if err := modules.InitPostgres(cfg); err != nil {
+		log.Fatalf("❌ Failed to initialize PostgreSQL: %v", err)
+	}
+	modules.InitSyncService()
+
+	// Bring Redis up to date first so new IDs do not collide with synced data
+	if err := loadInitialData(); err != nil {
+		log.Fatalf("❌ Failed to load initial data: %v", err)
+	}
+}
+
+func closeStores() {
+	if modules.RedisClient != nil {
+		modules.RedisClient.Close()
+	}
+	if modules.PostgresClient != nil {
+		modules.PostgresClient.Close()
+	}
+}
+
+func runSeedCommand(args []string) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	groups := flags.Int("groups", 3, "number of groups to create")
+	usersPerGroup := flags.Int("users", 5, "users per group, the first one becomes group admin")
+	tasksPerUser := flags.Int("tasks", 8, "tasks per user")
+	password := flags.String("password", "demo1234", "password for every seeded user")
+	randomSeed := flags.Int64("seed", 0, "random seed for reproducible data (0 picks one)")
+	force := flags.Bool("force", false, "allow seeding when ENVIRONMENT is production")
+	flags.Parse(args)
+
+	if *groups < 1 || *usersPerGroup < 1 || *tasksPerUser < 0 {
+		log.Fatalf("❌ -groups and -users must be at least 1 and -tasks cannot be negative")
+	}
+
+	cfg := loadCommandConfig()
+	if cfg.Environment == "production" && !*force {
+		log.Fatalf("❌ Refusing to seed a production environment; set ENVIRONMENT or pass -force")
+	}
+
+	if *randomSeed == 0 {
+		*randomSeed = time.Now().UnixNano()
+	}
+
+	initStores(cfg)
+	defer closeStores()
+
+	fmt.Printf("🌱 Seeding demo data (seed %d)...\n", *randomSeed)
+	result, err := modules.SeedDemoData(modules.SeedOptions{
+		Groups:        *groups,
+		UsersPerGroup: *usersPerGroup,
+		TasksPerUser:  *tasksPerUser,
+		Password:      *password,
+		RandomSeed:    *randomSeed,
+	})
+	if err != nil {
+		log.Fatalf("❌ Seeding failed: %v", err)
+	}
+
+	if err := modules.Syncer.ForceSyncNow(); err != nil {
+		log.Printf("⚠️  Seeded data is in Redis but syncing to PostgreSQL failed: %v", err)
+	}
+
+	fmt.Printf("✅ Created %d groups, %d users, %d tasks and %d leave requests\n",
+		result.Groups, result.Users, result.Tasks, result.Leaves)
+	fmt.Printf("🔑 All seeded users share the password: %s\n", *password)
+}
+
+// runVerifyCommand reports integrity issues and exits non-zero when any
+// remain, so it can run as a deploy check
+func runVerifyCommand(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	fix := flags.Bool("fix", false, "repair the issues that can be repaired safely")
+	flags.Parse(args)
+
+	cfg := loadCommandConfig()
+	// Stores are opened without the initial load, which would already
+	// correct counter drift and hide it from the report
+	if err := modules.InitRedis(cfg); err != nil {
+		log.Fatalf("❌ Failed to initialize Redis: %v", err)
+	}
+	if err := modules.InitPostgres(cfg); err != nil {
+		log.Fatalf("❌ Failed to initialize PostgreSQL: %v", err)
+	}
+	modules.InitSyncService()
+	defer closeStores()
+
+	report, err := modules.VerifyIntegrity(*fix)
+	if err != nil {
+		log.Fatalf("❌ Verification failed: %v", err)
+	}
+
+	fmt.Printf("🔎 Checked %d users, %d groups and %d tasks\n", report.Users, report.Groups, report.Tasks)
+	remaining := 0
+	for _, issue := range report.Issues {
+		mark := "❌"
+		switch {
+		case issue.Fixed:
+			mark = "✅"
+		case issue.Fixable:
+			mark = "🔧"
+		}
+		if !issue.Fixed {
+			remaining++
+		}
+
+		line := fmt.Sprintf("%s %-20s %s", mark, issue.Check, issue.Entity)
+		if issue.ID != 0 {
+			line += fmt.Sprintf(" %d", issue.ID)
+		}
+		fmt.Printf("%s: %s\n", line, issue.Detail)
+		if issue.FixError != "" {
+			fmt.Printf("   fix failed: %s\n", issue.FixError)
+		}
+	}
+
+	if report.Fixed > 0 {
+		if err := modules.Syncer.ForceSyncNow(); err != nil {
+			log.Printf("⚠️  Repairs are in Redis but syncing to PostgreSQL failed: %v", err)
+		}
+		fmt.Printf("✅ Fixed %d issues\n", report.Fixed)
+	}
+	if remaining == 0 {
+		fmt.Println("✅ No integrity issues")
+		return
+	}
+
+	fmt.Printf("⚠️  %d issues remain", remaining)
+	if !*fix {
+		fmt.Print("; run with -fix to repair the ones marked 🔧")
+	}
+	fmt.Println()
+	closeStores()
+	os.Exit(1)
+}
+
+// runBackupCommand checks snapshots in BACKUP_DIR without connecting to any
+// database, exiting non-zero when one fails, e.g. before copying it off-site
+func runBackupCommand(args []string) {
+	if len(args) == 0 || args[0] != "verify" {
+		fmt.Println("Usage: gask backup verify [name...]")
+		fmt.Println()
+		fmt.Println("Checks the named snapshots, or all of them, against their manifests.")
+		os.Exit(2)
+	}
+
+	cfg := loadCommandConfig()
+	if err := modules.InitBackups(cfg); err != nil {
+		log.Fatalf("❌ Failed to configure backups: %v", err)
+	}
+
+	names := args[1:]
+	if len(names) == 0 {
+		backups, err := modules.Backups.List()
+		if err != nil {
+			log.Fatalf("❌ Failed to list snapshots: %v", err)
+		}
+		for _, backup := range backups {
+			names = append(names, backup.Name)
+		}
+	}
+	if len(names) == 0 {
+		fmt.Printf("No snapshots in %s\n", cfg.BackupDir)
+		return
+	}
+
+	failed := 0
+	for _, name := range names {
+		report, err := modules.Backups.Verify(name)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", name, err)
+			failed++
+			continue
+		}
+		if !report.Valid {
+			fmt.Printf("❌ %s\n", name)
+			for _, problem := range report.Problems {
+				fmt.Printf("   %s\n", problem)
+			}
+			failed++
+			continue
+		}
+
+		var rows int64
+		for _, count := range report.Rows {
+			rows += count
+		}
+		note := ""
+		if !report.Manifest {
+			note = " (no manifest, only checked to read)"
+		}
+		fmt.Printf("✅ %s: %d rows%s\n", name, rows, note)
+	}
+
+	if failed > 0 {
+		fmt.Printf("⚠️  %d of %d snapshots failed verification\n", failed, len(names))
+		os.Exit(1)
+	}
+}
+
+// runUserCommand manages users without going through the HTTP API, for break-glass use
+func runUserCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: gask user <list|create|promote|deactivate|activate|reset-password> [flags]")
+		os.Exit(2)
+	}
+
+	action, args := args[0], args[1:]
+	flags := flag.NewFlagSet("user "+action, flag.ExitOnError)
+	id := flags.Int("id", 0, "user ID")
+	email := flags.String("email", "", "user email")
+
+	var name, password, role, groups *string
+	switch action {
+	case "list":
+	case "create":
+		name = flags.String("name", "", "full name")
+		password = flags.String("password", "", "password (generated when empty)")
+		role = flags.String("role", "user", "role: user, group_admin or owner")
+		groups = flags.String("groups", "", "comma-separated group IDs")
+	case "promote":
+		role = flags.String("role", "group_admin", "new role: user, group_admin or owner")
+	case "deactivate", "activate":
+	case "reset-password":
+		password = flags.String("password", "", "new password (generated when empty)")
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown user action: %s\n", action)
+		os.Exit(2)
+	}
+	flags.Parse(args)
+
+	if role != nil && !isValidRole(*role) {
+		log.Fatalf("❌ Invalid role %q. Must be 'user', 'group_admin' or 'owner'", *role)
+	}
+
+	initStores(loadCommandConfig())
+	defer closeStores()
+
+	switch action {
+	case "list":
+		listUsers()
+		return
+	case "create":
+		createUserFromCommand(*email, *name, *password, *role, *groups)
+		return
+	}
+
+	user := findCommandUser(*id, *email)
+
+	var newPassword string
+	switch action {
+	case "promote":
+		user.Role = *role
+		fmt.Printf("✅ %s is now %s\n", user.Email, user.Role)
+	case "deactivate":
+		user.Deactivated = true
+		fmt.Printf("✅ %s deactivated\n", user.Email)
+	case "activate":
+		user.Deactivated = false
+		fmt.Printf("✅ %s activated\n", user.Email)
+	case "reset-password":
+		newPassword = *password
+		if newPassword == "" {
+			newPassword = generatePassword()
+		}
+		checkCommandPassword(newPassword, user)
+		setCommandPassword(user, newPassword)
+		fmt.Printf("✅ Password for %s reset to: %s\n", user.Email, newPassword)
+	}
+
+	user.UpdatedAt = time.Now()
+	saveCommandUser(user)
+	if action == "reset-password" {
+		recordCommandPassword(user, newPassword)
+	}
+
+	// Deactivated users and old passwords must not keep sessions or tokens alive
+	if action == "deactivate" || action == "reset-password" {
+		if err := modules.SignOutUser(user.ID); err != nil {
+			log.Printf("⚠️  Failed to sign out %s: %v", user.Email, err)
+		}
+	}
+}
+
+func isValidRole(role string) bool {
+	return role == "user" || role == "group_admin" || role == "owner"
+}
+
+func findCommandUser(id int, email string) *models.User {
+	var user *models.User
+	var err error
+
+	switch {
+	case id != 0:
+		user, err = modules.RedisClient.GetUser(id)
+	case email != "":
+		user, err = modules.RedisClient.GetUserByEmail(email)
+	default:
+		log.Fatalf("❌ Pass -id or -email to select a user")
+	}
+	if err != nil {
+		log.Fatalf("❌ Failed to find user: %v", err)
+	}
+
+	return user
+}
+
+// saveCommandUser stores the user and syncs it to PostgreSQL right away
+func saveCommandUser(user *models.User) {
+	if err := modules.RedisClient.SaveUser(user); err != nil {
+		log.Fatalf("❌ Failed to save user: %v", err)
+	}
+	modules.RedisClient.MarkDirty("users")
+
+	if err := modules.Syncer.ForceSyncNow(); err != nil {
+		log.Printf("⚠️  Saved to Redis but syncing to PostgreSQL failed: %v", err)
+	}
+}
+
+func listUsers() {
+	users, err := modules.RedisClient.GetAllUsers()
+	if err != nil {
+		log.Fatalf("❌ Failed to list users: %v", err)
+	}
+
+	fmt.Printf("%-6s %-12s %-10s %-32s %s\n", "ID", "ROLE", "STATUS", "EMAIL", "NAME")
+	for _, user := range users {
+		status := "active"
+		if user.Deactivated {
+			status = "inactive"
+		}
+		fmt.Printf("%-6d %-12s %-10s %-32s %s\n", user.ID, user.Role, status, user.Email, user.FullName)
+	}
+}
+
+func createUserFromCommand(email, name, password, role, groups string) {
+	if email == "" || name == "" {
+		log.Fatalf("❌ -email and -name are required")
+	}
+
+	if existing, _ := modules.RedisClient.GetUserByEmail(email); existing != nil {
+		log.Fatalf("❌ User with email %s already exists (ID %d)", email, existing.ID)
+	}
+
+	groupIDs := models.IntSlice{}
+	if groups != "" {
+		for _, part := range strings.Split(groups, ",") {
+			groupID, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				log.Fatalf("❌ Invalid group ID %q", part)
+			}
+			if _, err := modules.RedisClient.GetGroup(groupID); err != nil {
+				log.Fatalf("❌ Group %d not found", groupID)
+			}
+			groupIDs = append(groupIDs, groupID)
+		}
+	}
+
+	generated := password == ""
+	if generated {
+		password = generatePassword()
+	}
+	checkCommandPassword(password, nil)
+
+	userID, err := modules.RedisClient.GetNextUserID()
+	if err != nil {
+		log.Fatalf("❌ Failed to generate user ID: %v", err)
+	}
+
+	user := &models.User{
+		ID:        userID,
+		FullName:  name,
+		Role:      role,
+		GroupIDs:  groupIDs,
+		Email:     email,
+		WorkTimes: make(models.WorkTimes),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	setCommandPassword(user, password)
+	saveCommandUser(user)
+	recordCommandPassword(user, password)
+
+	fmt.Printf("✅ Created %s %s with ID %d\n", user.Role, user.Email, user.ID)
+	if generated {
+		fmt.Printf("🔑 Generated password: %s\n", password)
+	}
+}
+
+// generatePassword returns a random URL-safe password that meets the
+// password policy
+func generatePassword() string {
+	policy := modules.CurrentPasswordPolicy()
+	buf := make([]byte, max(12, (policy.MinLength*3+3)/4))
+	for {
+		if _, err := rand.Read(buf); err != nil {
+			log.Fatalf("❌ Failed to generate password: %v", err)
+		}
+		password := base64.RawURLEncoding.EncodeToString(buf)
+		if policy.Check(password) == nil {
+			return password
+		}
+	}
+}
+
+// checkCommandPassword stops when a password breaks the password policy or,
+// for an existing user, repeats one of their last passwords
+func checkCommandPassword(password string, user *models.User) {
+	policy := modules.CurrentPasswordPolicy()
+	if violation := policy.Check(password); violation != nil {
+		log.Fatalf("❌ %v", violation)
+	}
+	if user != nil && modules.RedisClient.PasswordReused(user, password) {
+		log.Fatalf("❌ %v", &modules.PasswordViolation{Rule: "password_reused", Args: []interface{}{policy.History}})
+	}
+}
+
+// setCommandPassword stores the hash of a password on the user
+func setCommandPassword(user *models.User, password string) {
+	if err := modules.SetPassword(user, password); err != nil {
+		log.Fatalf("❌ Failed to hash password: %v", err)
+	}
+}
+
+func recordCommandPassword(user *models.User, password string) {
+	if err := modules.RedisClient.RecordPassword(user.ID, password); err != nil {
+		log.Printf("⚠️  Failed to record password history: %v", err)
+	}
+}
@@ -14,11 +14,52 @@ type Config struct {
 	AppName     string
 	Environment string
 	LogLevel    string
+	LogFormat   string
+	LogModules  string
+	LogSampling string
+
+	// Access log file, off when empty, rotated by size in megabytes and by age
+	AccessLogFile     string
+	AccessLogFormat   string
+	AccessLogMaxSize  int
+	AccessLogRotate   time.Duration
+	AccessLogMaxFiles int
 
 	// API Server
-	APIPort    int
-	APIHost    string
-	APITimeout time.Duration
+	APIPort          int
+	APIHost          string
+	APITimeout       time.Duration
+	ShutdownTimeout  time.Duration
+	TrustProxy       bool
+	TrustedProxyHops int
+
+	// Connections
+	ReadHeaderTimeout time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	KeepAlive         bool
+	HTTP2             bool
+	H2C               bool
+	TLSCertFile       string
+	TLSKeyFile        string
+
+	// Response Compression
+	Compression        bool
+	CompressionMinSize int
+
+	// IP Rules
+	AdminAllowlist string
+	IPDenylist     string
+
+	// Rate Limiting
+	RateLimits    string
+	RateLimitFile string
+
+	// Requests each user may make per day and per month in the
+	// organization's timezone, 0 for no limit. The owner overrides them per
+	// user through /admin/quotas.
+	APIDailyQuota   int
+	APIMonthlyQuota int
 
 	// Redis
 	RedisHost     string
@@ -38,11 +79,128 @@ type Config struct {
 	OwnerPassword string
 	OwnerEmail    string
 
-	// Sync Service
-	SyncInterval time.Duration
+	// Cookie Sessions
+	SessionCookies bool
+	SessionTTL     time.Duration
+	CookieSecure   bool
+
+	// Bearer Tokens
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Sign-ins unused for SessionIdleTimeout end, 0 for never; a user may
+	// keep MaxSessionsPerUser cookie sessions and token sign-ins at once,
+	// 0 for no limit, and the oldest is signed out beyond that
+	SessionIdleTimeout time.Duration
+	MaxSessionsPerUser int
+
+	// Password policy: at least PasswordMinLength characters drawn from
+	// PasswordMinClasses of lowercase, uppercase, digits and symbols, not a
+	// common password (a built-in list plus PasswordBannedFile) and none of
+	// the user's last PasswordHistory passwords, 0 for no reuse check
+	PasswordMinLength  int
+	PasswordMinClasses int
+	PasswordBannedFile string
+	PasswordHistory    int
+
+	// Sync Service, alerting the owner after SyncAlertAfter failed cycles in a row
+	SyncInterval     time.Duration
+	SyncAlertAfter   int
+	SyncAlertWebhook string
+
+	// How long task saves are held to merge rapid saves of the same task, 0 for never
+	TaskWriteBehind time.Duration
+
+	// How often task counters are recounted to correct drift, 0 for never
+	StatsReconcileInterval time.Duration
+
+	// How often dashboard projections are rebuilt from the tasks to catch
+	// up on missed events, 0 for only on start
+	ProjectionReconcileInterval time.Duration
+
+	// Latency objectives, e.g. "GET /tasks/filter p95<200ms", evaluated over
+	// SLOWindow and alerted to SLOAlertWebhook when set
+	SLOs            string
+	SLOWindow       time.Duration
+	SLOAlertWebhook string
+
+	// Requests are counted per consumer over APIAnalyticsWindow, 0 for never
+	APIAnalyticsWindow time.Duration
+
+	// Requests to webhooks and email APIs go through OutboundProxy when set
+	// and trust OutboundCAFile besides the system CAs. Each attempt has
+	// OutboundTimeout, failures are retried OutboundRetries times, and a
+	// destination failing OutboundBreakerThreshold calls in a row is left
+	// alone for OutboundBreakerCooldown, 0 to never stop calling.
+	OutboundProxy            string
+	OutboundCAFile           string
+	OutboundTimeout          time.Duration
+	OutboundRetries          int
+	OutboundBreakerThreshold int
+	OutboundBreakerCooldown  time.Duration
+
+	// URLs in task descriptions are previewed from their pages' Open Graph
+	// tags, cached for LinkPreviewTTL. Only public addresses are fetched.
+	LinkPreviews   bool
+	LinkPreviewTTL time.Duration
+
+	// Schedules replacing those periodic jobs start with, as
+	// "name=schedule" entries separated by semicolons
+	JobSchedules string
+
+	// PostgreSQL snapshots: written to BackupDir on BackupSchedule, a cron
+	// expression or empty for never, kept BackupRetentionDays and restored
+	// only into the database at BackupStagingDSN
+	BackupDir           string
+	BackupSchedule      string
+	BackupRetentionDays int
+	BackupStagingDSN    string
+
+	// 32 bytes in base64 that snapshots are encrypted with, empty for none
+	BackupEncryptionKey string
+
+	// Task attachments: stored by AttachmentStorage, "local" under
+	// AttachmentDir or "s3" in S3Bucket at S3Endpoint, an S3-compatible
+	// service. Files are limited to AttachmentMaxSizeMB and to the
+	// comma-separated AttachmentTypes, such as image/png or image/*.
+	AttachmentStorage   string
+	AttachmentDir       string
+	AttachmentMaxSizeMB int
+	AttachmentTypes     string
+	S3Endpoint          string
+	S3Region            string
+	S3Bucket            string
+	S3AccessKeyID       string
+	S3SecretAccessKey   string
+
+	// Email
+	EmailProvider      string
+	EmailFrom          string
+	EmailMaxAttempts   int
+	EmailRetryInterval time.Duration
+	SMTPHost           string
+	SMTPPort           int
+	SMTPUsername       string
+	SMTPPassword       string
+	SendGridAPIKey     string
+	SESRegion          string
+	SESAccessKeyID     string
+	SESSecretAccessKey string
+
+	// Non-urgent notifications reaching a user within this window are
+	// emailed together, 0 to email each straight away
+	NotificationBatchWindow time.Duration
+
+	// Inbound email: group aliases live at this domain and the gateway
+	// authenticates its webhook calls with the secret
+	InboundEmailDomain string
+	InboundEmailSecret string
 
 	// Timezone
 	Timezone string
+
+	// Holiday region for users without one, e.g. "us"
+	HolidayRegion string
 }
 
 var AppConfig *Config
@@ -53,10 +211,43 @@ func Load() (*Config, error) {
 		AppName:     getEnv("APP_NAME", "gask"),
 		Environment: getEnv("ENVIRONMENT", "production"),
 		LogLevel:    getEnv("LOG_LEVEL", "info"),
+		LogFormat:   getEnv("LOG_FORMAT", ""),
+		LogModules:  getEnv("LOG_MODULES", ""),
+		LogSampling: getEnv("LOG_SAMPLING", ""),
+
+		AccessLogFile:     getEnv("ACCESS_LOG_FILE", ""),
+		AccessLogFormat:   getEnv("ACCESS_LOG_FORMAT", "clf"),
+		AccessLogMaxSize:  getEnvAsInt("ACCESS_LOG_MAX_SIZE", 100),
+		AccessLogRotate:   getEnvAsDuration("ACCESS_LOG_ROTATE", 24*time.Hour),
+		AccessLogMaxFiles: getEnvAsInt("ACCESS_LOG_MAX_FILES", 7),
 
-		APIHost:    getEnv("API_HOST", "0.0.0.0"),
-		APIPort:    getEnvAsInt("API_PORT", 7890),
-		APITimeout: getEnvAsDuration("API_TIMEOUT", 15*time.Second),
+		APIHost:          getEnv("API_HOST", "0.0.0.0"),
+		APIPort:          getEnvAsInt("API_PORT", 7890),
+		APITimeout:       getEnvAsDuration("API_TIMEOUT", 15*time.Second),
+		ShutdownTimeout:  getEnvAsDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		TrustProxy:       getEnvAsBool("TRUST_PROXY", false),
+		TrustedProxyHops: getEnvAsInt("TRUSTED_PROXY_HOPS", 1),
+
+		ReadHeaderTimeout: getEnvAsDuration("READ_HEADER_TIMEOUT", 5*time.Second),
+		IdleTimeout:       getEnvAsDuration("IDLE_TIMEOUT", 60*time.Second),
+		MaxHeaderBytes:    getEnvAsInt("MAX_HEADER_BYTES", 1<<20),
+		KeepAlive:         getEnvAsBool("KEEP_ALIVE", true),
+		HTTP2:             getEnvAsBool("HTTP2", true),
+		H2C:               getEnvAsBool("H2C", false),
+		TLSCertFile:       getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:        getEnv("TLS_KEY_FILE", ""),
+
+		Compression:        getEnvAsBool("COMPRESSION", true),
+		CompressionMinSize: getEnvAsInt("COMPRESSION_MIN_SIZE", 1024),
+
+		AdminAllowlist: getEnv("ADMIN_ALLOWLIST", ""),
+		IPDenylist:     getEnv("IP_DENYLIST", ""),
+
+		RateLimits:    getEnv("RATE_LIMITS", "default=20/s:40,auth=10/m:10,search=5/s:10,admin=5/s:10,intake=5/m:5"),
+		RateLimitFile: getEnv("RATE_LIMIT_FILE", ""),
+
+		APIDailyQuota:   getEnvAsInt("API_DAILY_QUOTA", 0),
+		APIMonthlyQuota: getEnvAsInt("API_MONTHLY_QUOTA", 0),
 
 		RedisHost:     getEnv("REDIS_HOST", "localhost"),
 		RedisPort:     getEnvAsInt("REDIS_PORT", 6380),
@@ -73,10 +264,118 @@ func Load() (*Config, error) {
 		OwnerPassword: getEnv("OWNER_PASSWORD", "admin1234"),
 		OwnerEmail:    getEnv("OWNER_EMAIL", "admin@gmail.com"),
 
-		SyncInterval: getEnvAsDuration("SYNC_INTERVAL", 15*time.Minute),
-		Timezone:     getEnv("TZ", "Asia/Tehran"),
+		SessionCookies: getEnvAsBool("SESSION_COOKIES", false),
+		SessionTTL:     getEnvAsDuration("SESSION_TTL", 24*time.Hour),
+
+		AccessTokenTTL:  getEnvAsDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvAsDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		SessionIdleTimeout: getEnvAsDuration("SESSION_IDLE_TIMEOUT", 0),
+		MaxSessionsPerUser: getEnvAsInt("MAX_SESSIONS_PER_USER", 0),
+
+		PasswordMinLength:  getEnvAsInt("PASSWORD_MIN_LENGTH", 6),
+		PasswordMinClasses: getEnvAsInt("PASSWORD_MIN_CLASSES", 1),
+		PasswordBannedFile: getEnv("PASSWORD_BANNED_FILE", ""),
+		PasswordHistory:    getEnvAsInt("PASSWORD_HISTORY", 5),
+
+		SyncInterval:     getEnvAsDuration("SYNC_INTERVAL", 15*time.Minute),
+		SyncAlertAfter:   getEnvAsInt("SYNC_ALERT_AFTER", 3),
+		SyncAlertWebhook: getEnv("SYNC_ALERT_WEBHOOK", ""),
+		Timezone:         getEnv("TZ", "Asia/Tehran"),
+
+		TaskWriteBehind:             getEnvAsDuration("TASK_WRITE_BEHIND", 0),
+		StatsReconcileInterval:      getEnvAsDuration("STATS_RECONCILE_INTERVAL", time.Hour),
+		ProjectionReconcileInterval: getEnvAsDuration("PROJECTION_RECONCILE_INTERVAL", time.Hour),
+
+		SLOs:            getEnv("SLOS", ""),
+		SLOWindow:       getEnvAsDuration("SLO_WINDOW", 5*time.Minute),
+		SLOAlertWebhook: getEnv("SLO_ALERT_WEBHOOK", ""),
+
+		APIAnalyticsWindow: getEnvAsDuration("API_ANALYTICS_WINDOW", time.Hour),
+
+		OutboundProxy:            getEnv("OUTBOUND_PROXY", ""),
+		OutboundCAFile:           getEnv("OUTBOUND_CA_FILE", ""),
+		OutboundTimeout:          getEnvAsDuration("OUTBOUND_TIMEOUT", 10*time.Second),
+		OutboundRetries:          getEnvAsInt("OUTBOUND_RETRIES", 2),
+		OutboundBreakerThreshold: getEnvAsInt("OUTBOUND_BREAKER_THRESHOLD", 5),
+		OutboundBreakerCooldown:  getEnvAsDuration("OUTBOUND_BREAKER_COOLDOWN", 30*time.Second),
+
+		LinkPreviews:   getEnvAsBool("LINK_PREVIEWS", true),
+		LinkPreviewTTL: getEnvAsDuration("LINK_PREVIEW_TTL", 24*time.Hour),
+
+		HolidayRegion: getEnv("HOLIDAY_REGION", ""),
+
+		JobSchedules: getEnv("JOB_SCHEDULES", ""),
+
+		BackupDir:           getEnv("BACKUP_DIR", "backups"),
+		BackupSchedule:      getEnv("BACKUP_SCHEDULE", ""),
+		BackupRetentionDays: getEnvAsInt("BACKUP_RETENTION_DAYS", 7),
+		BackupStagingDSN:    getEnv("BACKUP_STAGING_DSN", ""),
+		BackupEncryptionKey: getEnv("BACKUP_ENCRYPTION_KEY", ""),
+
+		AttachmentStorage:   getEnv("ATTACHMENT_STORAGE", "local"),
+		AttachmentDir:       getEnv("ATTACHMENT_DIR", "attachments"),
+		AttachmentMaxSizeMB: getEnvAsInt("ATTACHMENT_MAX_SIZE_MB", 10),
+		AttachmentTypes:     getEnv("ATTACHMENT_TYPES", "image/*,application/pdf,text/plain,text/csv,application/zip"),
+		S3Endpoint:          getEnv("S3_ENDPOINT", ""),
+		S3Region:            getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:            getEnv("S3_BUCKET", ""),
+		S3AccessKeyID:       getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey:   getEnv("S3_SECRET_ACCESS_KEY", ""),
+
+		EmailProvider:      getEnv("EMAIL_PROVIDER", "none"),
+		EmailFrom:          getEnv("EMAIL_FROM", "gask@localhost"),
+		EmailMaxAttempts:   getEnvAsInt("EMAIL_MAX_ATTEMPTS", 5),
+		EmailRetryInterval: getEnvAsDuration("EMAIL_RETRY_INTERVAL", time.Minute),
+		SMTPHost:           getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:           getEnvAsInt("SMTP_PORT", 587),
+		SMTPUsername:       getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:       getEnv("SMTP_PASSWORD", ""),
+		SendGridAPIKey:     getEnv("SENDGRID_API_KEY", ""),
+		SESRegion:          getEnv("SES_REGION", "us-east-1"),
+		SESAccessKeyID:     getEnv("SES_ACCESS_KEY_ID", ""),
+		SESSecretAccessKey: getEnv("SES_SECRET_ACCESS_KEY", ""),
+
+		NotificationBatchWindow: getEnvAsDuration("NOTIFICATION_BATCH_WINDOW", 10*time.Minute),
+
+		InboundEmailDomain: getEnv("INBOUND_EMAIL_DOMAIN", ""),
+		InboundEmailSecret: getEnv("INBOUND_EMAIL_SECRET", ""),
+	}
+
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if config.PasswordMinLength < 1 || config.PasswordMinClasses < 1 || config.PasswordMinClasses > 4 || config.PasswordHistory < 0 {
+		return nil, fmt.Errorf("PASSWORD_MIN_LENGTH must be at least 1, PASSWORD_MIN_CLASSES between 1 and 4 and PASSWORD_HISTORY not negative")
+	}
+
+	if config.TrustedProxyHops < 1 {
+		return nil, fmt.Errorf("TRUSTED_PROXY_HOPS must be at least 1")
+	}
+
+	if config.SessionIdleTimeout < 0 || config.MaxSessionsPerUser < 0 {
+		return nil, fmt.Errorf("SESSION_IDLE_TIMEOUT and MAX_SESSIONS_PER_USER must not be negative")
+	}
+
+	if config.NotificationBatchWindow < 0 {
+		return nil, fmt.Errorf("NOTIFICATION_BATCH_WINDOW must not be negative")
+	}
+
+	if config.OutboundTimeout <= 0 || config.OutboundRetries < 0 || config.OutboundBreakerThreshold < 0 {
+		return nil, fmt.Errorf("OUTBOUND_TIMEOUT must be positive and OUTBOUND_RETRIES and OUTBOUND_BREAKER_THRESHOLD not negative")
+	}
+
+	if config.AttachmentMaxSizeMB < 1 {
+		return nil, fmt.Errorf("ATTACHMENT_MAX_SIZE_MB must be at least 1")
 	}
 
+	if config.LinkPreviewTTL <= 0 {
+		return nil, fmt.Errorf("LINK_PREVIEW_TTL must be positive")
+	}
+
+	// Secure cookies need HTTPS, which local development usually lacks
+	config.CookieSecure = getEnvAsBool("COOKIE_SECURE", config.Environment == "production")
+
 	// Find available API port if configured port is busy
 	if getEnvAsBool("AUTO_PORT_FIND", true) {
 		availablePort, err := findAvailablePort(config.APIPort, config.APIPort+100)
@@ -114,6 +413,19 @@ func (c *Config) GetAPIAddr() string {
 	return fmt.Sprintf("%s:%d", c.APIHost, c.APIPort)
 }
 
+// TLSEnabled reports whether the API server serves HTTPS
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != ""
+}
+
+// APIScheme is "https" when TLS is enabled and "http" otherwise
+func (c *Config) APIScheme() string {
+	if c.TLSEnabled() {
+		return "https"
+	}
+	return "http"
+}
+
 // Helper functions
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -173,10 +485,12 @@ func (c *Config) Print() {
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Printf("  App Name:      %s\n", c.AppName)
 	fmt.Printf("  Environment:   %s\n", c.Environment)
-	fmt.Printf("  API Address:   http://%s\n", c.GetAPIAddr())
+	fmt.Printf("  API Address:   %s://%s\n", c.APIScheme(), c.GetAPIAddr())
 	fmt.Printf("  Redis:         %s\n", c.GetRedisAddr())
 	fmt.Printf("  PostgreSQL:    %s:%d/%s\n", c.PostgresHost, c.PostgresPort, c.PostgresDB)
 	fmt.Printf("  Sync Interval: %v\n", c.SyncInterval)
+	fmt.Printf("  Email:         %s\n", c.EmailProvider)
+	fmt.Printf("  Rate Limits:   %s\n", c.RateLimits)
 	fmt.Printf("  Timezone:      %s\n", c.Timezone)
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 }
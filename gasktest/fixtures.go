@@ -0,0 +1,225 @@
+package gasktest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"task-manager/models"
+	"task-manager/modules"
+)
+
+// UserBuilder creates a user directly in the store. Unset fields get
+// unique defaults and the password "password".
+type UserBuilder struct {
+	srv  *Server
+	user *models.User
+}
+
+// User starts a regular user
+func (s *Server) User() *UserBuilder {
+	n := s.next()
+	return &UserBuilder{srv: s, user: &models.User{
+		FullName:  fmt.Sprintf("Test User %d", n),
+		Role:      "user",
+		Email:     fmt.Sprintf("user%d@example.com", n),
+		Password:  "password",
+		WorkTimes: make(models.WorkTimes),
+	}}
+}
+
+func (b *UserBuilder) Named(name string) *UserBuilder {
+	b.user.FullName = name
+	return b
+}
+
+func (b *UserBuilder) Email(email string) *UserBuilder {
+	b.user.Email = email
+	return b
+}
+
+func (b *UserBuilder) Password(password string) *UserBuilder {
+	b.user.Password = password
+	return b
+}
+
+// Role sets "user" or "group_admin"
+func (b *UserBuilder) Role(role string) *UserBuilder {
+	b.user.Role = role
+	return b
+}
+
+func (b *UserBuilder) InGroups(groupIDs ...int) *UserBuilder {
+	b.user.GroupIDs = append(b.user.GroupIDs, groupIDs...)
+	return b
+}
+
+func (b *UserBuilder) WorkTimes(workTimes models.WorkTimes) *UserBuilder {
+	b.user.WorkTimes = workTimes
+	return b
+}
+
+func (b *UserBuilder) Deactivated() *UserBuilder {
+	b.user.Deactivated = true
+	return b
+}
+
+// Create saves the user and returns it with its plaintext password, ready
+// for Server.Call
+func (b *UserBuilder) Create(t testing.TB) *models.User {
+	t.Helper()
+
+	id, err := modules.RedisClient.GetNextUserID()
+	if err != nil {
+		t.Fatalf("gasktest: user ID: %v", err)
+	}
+	user := *b.user
+	user.ID = id
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+	if err := modules.SetPassword(&user, b.user.Password); err != nil {
+		t.Fatalf("gasktest: hash password: %v", err)
+	}
+
+	if err := modules.RedisClient.SaveUser(&user); err != nil {
+		t.Fatalf("gasktest: save user: %v", err)
+	}
+	modules.RedisClient.MarkDirty("users")
+	user.Password = b.user.Password
+	return &user
+}
+
+// GroupBuilder creates a group directly in the store
+type GroupBuilder struct {
+	srv   *Server
+	group *models.Group
+	admin *models.User
+}
+
+// Group starts a group. Without AdminedBy, Create also creates a group
+// admin for it.
+func (s *Server) Group() *GroupBuilder {
+	n := s.next()
+	return &GroupBuilder{srv: s, group: &models.Group{
+		Name: fmt.Sprintf("Test Group %d", n),
+	}}
+}
+
+func (b *GroupBuilder) Named(name string) *GroupBuilder {
+	b.group.Name = name
+	return b
+}
+
+// Code sets the prefix of the group's task keys, e.g. "OPS"
+func (b *GroupBuilder) Code(code string) *GroupBuilder {
+	b.group.Code = code
+	return b
+}
+
+func (b *GroupBuilder) AdminedBy(admin *models.User) *GroupBuilder {
+	b.admin = admin
+	return b
+}
+
+// Create saves the group and adds its admin to it, as POST /groups does
+func (b *GroupBuilder) Create(t testing.TB) *models.Group {
+	t.Helper()
+
+	admin := b.admin
+	if admin == nil {
+		admin = b.srv.User().Role("group_admin").Create(t)
+	}
+
+	id, err := modules.RedisClient.GetNextGroupID()
+	if err != nil {
+		t.Fatalf("gasktest: group ID: %v", err)
+	}
+	group := *b.group
+	group.ID = id
+	group.AdminID = admin.ID
+	group.CreatedAt = time.Now()
+	group.UpdatedAt = time.Now()
+
+	if err := modules.RedisClient.SaveGroup(&group); err != nil {
+		t.Fatalf("gasktest: save group: %v", err)
+	}
+
+	admin.GroupIDs = append(admin.GroupIDs, id)
+	if err := modules.RedisClient.SaveUser(admin); err != nil {
+		t.Fatalf("gasktest: add admin to group: %v", err)
+	}
+	modules.RedisClient.MarkDirty("groups")
+	modules.RedisClient.MarkDirty("users")
+	return &group
+}
+
+// TaskBuilder creates a task directly in the store
+type TaskBuilder struct {
+	srv  *Server
+	task *models.Task
+}
+
+// Task starts an open task for user in group
+func (s *Server) Task(user *models.User, group *models.Group) *TaskBuilder {
+	n := s.next()
+	return &TaskBuilder{srv: s, task: &models.Task{
+		Title:    fmt.Sprintf("Test Task %d", n),
+		Priority: models.PriorityLow,
+		UserID:   user.ID,
+		GroupID:  group.ID,
+	}}
+}
+
+func (b *TaskBuilder) Titled(title string) *TaskBuilder {
+	b.task.Title = title
+	return b
+}
+
+func (b *TaskBuilder) Priority(priority models.Priority) *TaskBuilder {
+	b.task.Priority = priority
+	return b
+}
+
+// Deadline sets the due date as YYYY-MM-DD
+func (b *TaskBuilder) Deadline(deadline string) *TaskBuilder {
+	b.task.Deadline = deadline
+	return b
+}
+
+func (b *TaskBuilder) Information(information string) *TaskBuilder {
+	b.task.Information = information
+	return b
+}
+
+func (b *TaskBuilder) EstimatedHours(hours float64) *TaskBuilder {
+	b.task.EstimatedHours = hours
+	return b
+}
+
+func (b *TaskBuilder) Done() *TaskBuilder {
+	b.task.Status = true
+	return b
+}
+
+// Create saves the task with a key from its group's code
+func (b *TaskBuilder) Create(t testing.TB) *models.Task {
+	t.Helper()
+
+	id, err := modules.RedisClient.GetNextTaskID()
+	if err != nil {
+		t.Fatalf("gasktest: task ID: %v", err)
+	}
+	task := *b.task
+	task.ID = id
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = time.Now()
+
+	if err := modules.RedisClient.AssignTaskKey(&task); err != nil {
+		t.Fatalf("gasktest: task key: %v", err)
+	}
+	if err := modules.RedisClient.SaveTask(&task); err != nil {
+		t.Fatalf("gasktest: save task: %v", err)
+	}
+	modules.RedisClient.MarkDirty("tasks")
+	return &task
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"task-manager/modules"
+	"time"
+)
+
+// defaultTopEndpoints and defaultAnalyticsConsumers bound the analytics
+// response unless top and limit ask otherwise
+const (
+	defaultTopEndpoints       = 5
+	defaultAnalyticsConsumers = 50
+)
+
+// APIAnalyticsHandler handles GET /admin/analytics/api: request counts, error
+// rates and top endpoints per consumer over ?window= (the whole analytics
+// window by default), optionally for one ?consumer= such as user:7
+func APIAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		respondWithError(w, "Only owner can view API analytics", http.StatusForbidden)
+		return
+	}
+	if !modules.APIUsage.Enabled() {
+		respondWithError(w, "API analytics are turned off", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	window := modules.APIUsage.Window()
+	if value := query.Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 || parsed > window {
+			respondWithError(w, "window must be a duration up to "+window.String(), http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+	top, limit := defaultTopEndpoints, defaultAnalyticsConsumers
+	if topStr := query.Get("top"); topStr != "" {
+		parsed, err := strconv.Atoi(topStr)
+		if err != nil || parsed < 1 {
+			respondWithError(w, "top must be a positive number", http.StatusBadRequest)
+			return
+		}
+		top = parsed
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			respondWithError(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	consumers := modules.APIUsage.Usage(window, query.Get("consumer"), top)
+	total := len(consumers)
+	if len(consumers) > limit {
+		consumers = consumers[:limit]
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"window":          window.String(),
+		"consumers":       consumers,
+		"consumers_total": total,
+	})
+}
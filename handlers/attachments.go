@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+	"unicode"
+)
+
+const (
+	// maxAttachmentNameLength caps stored filenames, in characters
+	maxAttachmentNameLength = 255
+
+	// attachmentFormOverhead allows for the multipart framing around a file
+	attachmentFormOverhead = 64 << 10
+)
+
+// handleTaskAttachments serves a task's attachments. Everyone who may see
+// the task lists and downloads them; those who may modify it upload them.
+func handleTaskAttachments(w http.ResponseWriter, r *http.Request, task *models.Task, remainingParts []string) {
+	authCtx := modules.GetAuthContext(r)
+
+	// Tasks the caller may not see are reported as missing
+	if !modules.CanViewTask(authCtx, task) {
+		respondWithError(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != "GET" && !modules.CanModifyTask(authCtx, task) {
+		respondWithError(w, "Insufficient permissions to modify this task", http.StatusForbidden)
+		return
+	}
+
+	if len(remainingParts) == 0 {
+		// .../attachments
+		switch r.Method {
+		case "GET":
+			getTaskAttachments(w, r, task)
+		case "POST":
+			uploadTaskAttachment(w, r, task)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if len(remainingParts) != 1 {
+		http.Error(w, "Invalid attachment sub-path", http.StatusBadRequest)
+		return
+	}
+
+	attachmentID, err := strconv.Atoi(remainingParts[0])
+	if err != nil {
+		http.Error(w, "Invalid attachment ID", http.StatusBadRequest)
+		return
+	}
+
+	attachment, err := modules.RedisClient.GetAttachment(attachmentID)
+	if err != nil || attachment.TaskID != task.ID {
+		respondWithError(w, "Attachment not found", http.StatusNotFound)
+		return
+	}
+
+	// .../attachments/{aid}
+	switch r.Method {
+	case "GET":
+		downloadTaskAttachment(w, r, attachment)
+	case "DELETE":
+		deleteTaskAttachment(w, r, task, attachment)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func getTaskAttachments(w http.ResponseWriter, r *http.Request, task *models.Task) {
+	attachments, err := modules.RedisClient.GetTaskAttachments(task.ID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get attachments")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"task_id":     task.ID,
+		"attachments": attachments,
+		"count":       len(attachments),
+	})
+}
+
+// uploadTaskAttachment stores the "file" part of a multipart form. Its type
+// is the part's Content-Type, or the filename's when the client sent none.
+func uploadTaskAttachment(w http.ResponseWriter, r *http.Request, task *models.Task) {
+	maxSize := modules.Attachments.MaxSize()
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+attachmentFormOverhead)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, fmt.Sprintf("Files may be at most %d MB", maxSize>>20), http.StatusRequestEntityTooLarge)
+			return
+		}
+		respondWithError(w, "Missing file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxSize {
+		respondWithError(w, fmt.Sprintf("Files may be at most %d MB", maxSize>>20), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	filename := cleanAttachmentName(header.Filename)
+	contentType := attachmentType(header.Header.Get("Content-Type"), filename)
+
+	v := newValidator()
+	v.required(filename, "file")
+	v.check(modules.Attachments.AllowsType(contentType), "file", "attachment_type", contentType, modules.Attachments.Types())
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	if groupFrozen(w, r, task.GroupID) {
+		return
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondWithError(w, "Failed to read file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	attachmentID, err := modules.RedisClient.GetNextAttachmentID()
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to create attachment")
+		return
+	}
+
+	attachment := &models.Attachment{
+		ID:          attachmentID,
+		TaskID:      task.ID,
+		Filename:    filename,
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+		CreatedAt:   time.Now(),
+	}
+	if authCtx := modules.GetAuthContext(r); authCtx.User != nil {
+		attachment.UploadedBy = authCtx.User.ID
+	}
+
+	if err := modules.Attachments.Store(r.Context(), attachment, data); err != nil {
+		handlerLog.ErrorContext(r.Context(), "❌ Failed to store attachment", "task_id", task.ID,
+			"storage", modules.Attachments.Backend(), "error", err)
+		respondWithError(w, "Failed to store file", http.StatusBadGateway)
+		return
+	}
+	if err := modules.RedisClient.SaveAttachment(attachment); err != nil {
+		modules.Attachments.Remove(r.Context(), attachment)
+		respondWithDomainError(w, r, err, "Failed to save attachment")
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("attachments")
+
+	modules.Events.PublishTask(r.Context(), "attachment.created", task, attachment)
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":    "Attachment uploaded",
+		"attachment": attachment,
+	}, http.StatusCreated)
+}
+
+// cleanAttachmentName keeps the last element of an uploaded filename,
+// without control characters and within maxAttachmentNameLength
+func cleanAttachmentName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+	if name == "." || name == "/" {
+		return ""
+	}
+	if runes := []rune(name); len(runes) > maxAttachmentNameLength {
+		name = string(runes[:maxAttachmentNameLength])
+	}
+	return name
+}
+
+// attachmentType is the MIME type of an upload without parameters: the type
+// the client declared, or else the one its filename's extension suggests
+func attachmentType(declared, filename string) string {
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil && mediaType != "application/octet-stream" {
+		return mediaType
+	}
+	if mediaType, _, err := mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(filename))); err == nil {
+		return mediaType
+	}
+	return "application/octet-stream"
+}
+
+// downloadTaskAttachment sends the file. It is always offered as a download
+// and never sniffed, so uploaded pages cannot run on this origin.
+func downloadTaskAttachment(w http.ResponseWriter, r *http.Request, attachment *models.Attachment) {
+	file, err := modules.Attachments.Open(r.Context(), attachment)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to read file")
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.SizeBytes, 10))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	io.Copy(w, file)
+}
+
+// deleteTaskAttachment removes an attachment and its file; uploaders remove
+// their own, and the task's group admins and the owner remove any
+func deleteTaskAttachment(w http.ResponseWriter, r *http.Request, task *models.Task, attachment *models.Attachment) {
+	authCtx := modules.GetAuthContext(r)
+	isUploader := authCtx.User != nil && authCtx.User.ID == attachment.UploadedBy
+	if !isUploader && !administersGroup(authCtx, task.GroupID) {
+		respondWithError(w, "You can only delete attachments you uploaded", http.StatusForbidden)
+		return
+	}
+
+	if groupFrozen(w, r, task.GroupID) {
+		return
+	}
+
+	if err := modules.RedisClient.DeleteAttachment(attachment); err != nil {
+		respondWithDomainError(w, r, err, "Failed to delete attachment")
+		return
+	}
+	if err := modules.Attachments.Remove(r.Context(), attachment); err != nil {
+		handlerLog.WarnContext(r.Context(), "⚠️ Failed to remove attachment file", "attachment_id", attachment.ID, "error", err)
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("attachments")
+
+	modules.Events.PublishTask(r.Context(), "attachment.deleted", task, attachment)
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Attachment deleted",
+	})
+}
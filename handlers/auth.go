@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"task-manager/config"
+	"task-manager/models"
+	"task-manager/modules"
+)
+
+type loginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// LoginHandler starts a cookie session for browser clients /auth/login
+func LoginHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !modules.SessionsEnabled() {
+		respondWithError(w, "Cookie sessions are disabled", http.StatusNotFound)
+		return
+	}
+
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, ok := signIn(w, r, req)
+	if !ok {
+		return
+	}
+
+	session, err := modules.RedisClient.CreateSession(user.ID, config.AppConfig.SessionTTL)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to create session")
+		return
+	}
+
+	modules.SetSessionCookie(w, session)
+	respondWithSuccess(w, sessionResponse(user, session))
+}
+
+// PasswordPolicyHandler shows the rules new passwords must meet, so sign-up
+// and password forms can check them before submitting /auth/password-policy
+func PasswordPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"policy": modules.CurrentPasswordPolicy(),
+	})
+}
+
+// LogoutHandler ends the caller's cookie session /auth/logout
+func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if authCtx.Session == nil {
+		respondWithError(w, "Not signed in with a session cookie", http.StatusBadRequest)
+		return
+	}
+
+	if err := modules.RedisClient.DeleteSession(authCtx.Session); err != nil {
+		respondWithDomainError(w, r, err, "Failed to end session")
+		return
+	}
+
+	modules.ClearSessionCookie(w)
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Signed out",
+	})
+}
+
+// SessionHandler returns the caller's session and its CSRF token /auth/session
+func SessionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if authCtx.Session == nil {
+		respondWithError(w, "Not signed in with a session cookie", http.StatusBadRequest)
+		return
+	}
+
+	respondWithSuccess(w, sessionResponse(authCtx.User, authCtx.Session))
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenHandler issues a bearer access token and a refresh token /auth/token
+func TokenHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, ok := signIn(w, r, req)
+	if !ok {
+		return
+	}
+
+	cfg := config.AppConfig
+	pair, err := modules.RedisClient.IssueTokens(user.ID, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to issue tokens")
+		return
+	}
+
+	respondWithSuccess(w, pair)
+}
+
+// RefreshHandler exchanges a refresh token for a new token pair /auth/refresh.
+// Refresh tokens are single use; reusing one revokes every token from the same sign-in.
+func RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		respondWithError(w, "refresh_token is required", http.StatusBadRequest)
+		return
+	}
+
+	cfg := config.AppConfig
+	pair, userID, err := modules.RedisClient.RotateRefreshToken(req.RefreshToken, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	if errors.Is(err, modules.ErrTokenReused) {
+		modules.ReportTokenReuse(r.Context(), userID, modules.ClientIP(r))
+		respondWithError(w, "Refresh token was already used; please sign in again", http.StatusUnauthorized)
+		return
+	}
+	if modules.IsInvalidToken(err) {
+		respondWithError(w, "Invalid or expired refresh token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to refresh tokens")
+		return
+	}
+
+	// The user may have been deactivated since signing in
+	if user, err := modules.RedisClient.GetUser(userID); err != nil || user.Deactivated {
+		modules.RedisClient.RevokeRefreshToken(pair.RefreshToken, cfg.RefreshTokenTTL)
+		respondWithError(w, "Invalid or expired refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	respondWithSuccess(w, pair)
+}
+
+// RevokeHandler signs out every token issued from the same sign-in as a refresh token /auth/revoke
+func RevokeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		respondWithError(w, "refresh_token is required", http.StatusBadRequest)
+		return
+	}
+
+	err := modules.RedisClient.RevokeRefreshToken(req.RefreshToken, config.AppConfig.RefreshTokenTTL)
+	if err != nil && !modules.IsInvalidToken(err) {
+		respondWithDomainError(w, r, err, "Failed to revoke tokens")
+		return
+	}
+
+	// Revoking an unknown token succeeds too, so callers learn nothing about it
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Tokens revoked",
+	})
+}
+
+// signIn validates and checks credentials, counting failures against the
+// caller's IP. It writes the error response itself when sign-in fails.
+func signIn(w http.ResponseWriter, r *http.Request, req loginRequest) (*models.User, bool) {
+	v := newValidator()
+	v.required(req.Email, "email")
+	v.required(req.Password, "password")
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return nil, false
+	}
+
+	ip := modules.ClientIP(r)
+	if _, blocked := modules.Limiter.AuthBlocked(ip); blocked {
+		respondWithError(w, "Too many failed sign-in attempts", http.StatusTooManyRequests)
+		return nil, false
+	}
+
+	user, err := modules.VerifyCredentials(req.Email, req.Password)
+	if err != nil {
+		modules.Limiter.RecordAuthFailure(ip)
+		respondWithError(w, "Invalid email or password", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return user, true
+}
+
+func sessionResponse(user *models.User, session *modules.Session) map[string]interface{} {
+	return map[string]interface{}{
+		"user":       models.NewUserResponse(user, true),
+		"csrf_token": session.CSRFToken,
+		"expires_at": session.ExpiresAt,
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"task-manager/modules"
+)
+
+// handleGroupBacklog handles GET /groups/{id}/backlog, the group's open
+// tasks ordered by votes, and PUT and DELETE /groups/{id}/backlog/{tid}/vote,
+// where members upvote a task or take their vote back
+func handleGroupBacklog(w http.ResponseWriter, r *http.Request, groupID int, remainingParts []string) {
+	if len(remainingParts) == 0 {
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		getGroupBacklog(w, r, groupID)
+		return
+	}
+
+	if len(remainingParts) != 2 || remainingParts[1] != "vote" {
+		http.Error(w, "Invalid backlog sub-path", http.StatusBadRequest)
+		return
+	}
+	taskID, err := strconv.Atoi(remainingParts[0])
+	if err != nil {
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case "PUT":
+		voteBacklogTask(w, r, groupID, taskID, true)
+	case "DELETE":
+		voteBacklogTask(w, r, groupID, taskID, false)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func getGroupBacklog(w http.ResponseWriter, r *http.Request, groupID int) {
+	authCtx := modules.GetAuthContext(r)
+	userID := 0
+	if authCtx.User != nil {
+		userID = authCtx.User.ID
+	}
+	if !administersGroup(authCtx, groupID) && !userInGroup(userID, groupID) {
+		respondWithError(w, "Only members of this group can view its backlog", http.StatusForbidden)
+		return
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > 500 {
+			respondWithError(w, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	backlog, err := modules.RedisClient.GetGroupBacklog(groupID, userID)
+	if err != nil {
+		respondWithError(w, "Failed to load backlog", http.StatusInternalServerError)
+		return
+	}
+	total := len(backlog)
+	if total > limit {
+		backlog = backlog[:limit]
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"group_id": groupID,
+		"limit":    limit,
+		"total":    total,
+		"tasks":    backlog,
+	})
+}
+
+// voteBacklogTask records or withdraws the caller's vote. Voting twice, or
+// withdrawing a vote never cast, changes nothing.
+func voteBacklogTask(w http.ResponseWriter, r *http.Request, groupID, taskID int, vote bool) {
+	authCtx := modules.GetAuthContext(r)
+	if authCtx.User == nil || !userInGroup(authCtx.User.ID, groupID) {
+		respondWithError(w, "Only members of this group can vote", http.StatusForbidden)
+		return
+	}
+
+	task, err := modules.RedisClient.GetTask(taskID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Task not found")
+		return
+	}
+	if task.GroupID != groupID {
+		respondWithError(w, "Task is not in this group", http.StatusNotFound)
+		return
+	}
+	if vote && task.Closed() {
+		respondWithError(w, "Only open tasks can be voted for", http.StatusConflict)
+		return
+	}
+
+	changed, votes, err := modules.RedisClient.VoteTask(taskID, authCtx.User.ID, vote)
+	if err != nil {
+		respondWithError(w, "Failed to record vote", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"task_id": taskID,
+		"votes":   votes,
+		"voted":   vote,
+		"changed": changed,
+	})
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+)
+
+// BackupsHandler handles /admin/backups: listing and taking PostgreSQL
+// snapshots, downloading one with GET /admin/backups/{name}, reading its
+// manifest with GET /admin/backups/{name}/manifest, checking it with
+// GET /admin/backups/{name}/verify and restoring it into the staging
+// database with POST /admin/backups/{name}/restore
+func BackupsHandler(w http.ResponseWriter, r *http.Request) {
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		respondWithError(w, "Only owner can manage backups", http.StatusForbidden)
+		return
+	}
+	if modules.Backups == nil {
+		respondWithError(w, "Backups are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/backups"), "/")
+	parts := strings.Split(path, "/")
+	switch {
+	case path == "" && r.Method == "GET":
+		listBackups(w, r)
+	case path == "" && r.Method == "POST":
+		takeBackup(w, r)
+	case len(parts) == 1 && r.Method == "GET":
+		downloadBackup(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "manifest" && r.Method == "GET":
+		getBackupManifest(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "verify" && r.Method == "GET":
+		verifyBackup(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "restore" && r.Method == "POST":
+		restoreBackup(w, r, parts[0])
+	case path == "" || len(parts) == 1 || (len(parts) == 2 && (parts[1] == "restore" || parts[1] == "manifest" || parts[1] == "verify")):
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+func listBackups(w http.ResponseWriter, r *http.Request) {
+	backups, err := modules.Backups.List()
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to list backups")
+		return
+	}
+
+	status := modules.Backups.Status()
+	status["backups"] = backups
+	respondWithSuccess(w, status)
+}
+
+// takeBackup starts a snapshot, which is listed once it is complete
+func takeBackup(w http.ResponseWriter, r *http.Request) {
+	err := modules.Backups.SnapshotAsync()
+	if errors.Is(err, modules.ErrNoPostgres) {
+		respondWithError(w, "Backups need PostgreSQL, which is not connected", http.StatusServiceUnavailable)
+		return
+	}
+	if errors.Is(err, modules.ErrBackupBusy) {
+		respondWithError(w, "A snapshot is already being taken or restored", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to start backup")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Snapshot started",
+	}, http.StatusAccepted)
+}
+
+func downloadBackup(w http.ResponseWriter, r *http.Request, name string) {
+	file, err := modules.Backups.Open(name)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Backup not found")
+		return
+	}
+	defer file.Close()
+
+	contentType := "application/gzip"
+	if strings.HasSuffix(name, ".enc") {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	if info, err := file.Stat(); err == nil {
+		http.ServeContent(w, r, name, info.ModTime(), file)
+		return
+	}
+	io.Copy(w, file)
+}
+
+func getBackupManifest(w http.ResponseWriter, r *http.Request, name string) {
+	manifest, err := modules.Backups.Manifest(name)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Manifest not found")
+		return
+	}
+	respondWithSuccess(w, manifest)
+}
+
+// verifyBackup reads a snapshot through and checks it against its manifest;
+// problems are part of the report rather than an error status
+func verifyBackup(w http.ResponseWriter, r *http.Request, name string) {
+	report, err := modules.Backups.Verify(name)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Backup not found")
+		return
+	}
+	respondWithSuccess(w, report)
+}
+
+// restoreBackup verifies a snapshot and starts loading it into the staging
+// database. Its outcome shows as the last job in the backup list.
+func restoreBackup(w http.ResponseWriter, r *http.Request, name string) {
+	report, err := modules.Backups.RestoreAsync(name)
+	if errors.Is(err, modules.ErrNoStaging) {
+		respondWithError(w, "Set BACKUP_STAGING_DSN to restore backups", http.StatusServiceUnavailable)
+		return
+	}
+	if errors.Is(err, modules.ErrBackupInvalid) {
+		// Nothing was restored; report what failed
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(models.APIResponse{
+			Success:   false,
+			Error:     "Backup failed verification and was not restored",
+			Data:      report,
+			RequestID: w.Header().Get(modules.RequestIDHeader),
+		})
+		return
+	}
+	if errors.Is(err, modules.ErrBackupBusy) {
+		respondWithError(w, "A snapshot is already being taken or restored", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		respondWithDomainError(w, r, err, "Backup not found")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":      "Restore to staging started",
+		"backup":       name,
+		"verification": report,
+	}, http.StatusAccepted)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"task-manager/config"
+	"task-manager/modules"
+)
+
+// CapabilitiesHandler handles GET /capabilities, telling clients which
+// optional subsystems this deployment has turned on and the limits requests
+// must stay within. It needs no sign-in, so sign-in screens can adapt too.
+func CapabilitiesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cfg := config.AppConfig
+	dailyQuota, monthlyQuota := modules.Quotas.Defaults()
+	email := map[string]interface{}{
+		"enabled": modules.Mailer.Enabled(),
+		"inbound": cfg.InboundEmailDomain != "",
+	}
+	if modules.Mailer.Enabled() {
+		email["provider"] = modules.Mailer.Provider()
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"features": map[string]interface{}{
+			// Events are streamed as server-sent events on /stream and
+			// over a WebSocket on /ws
+			"stream":         "sse",
+			"websocket":      true,
+			"email":          email,
+			"webhooks":       true,
+			"attachments":    modules.Attachments.Backend(),
+			"search_index":   modules.PostgresClient != nil,
+			"two_factor":     false,
+			"session_cookie": cfg.SessionCookies,
+			"bearer_tokens":  true,
+			"api_analytics":  modules.APIUsage.Enabled(),
+		},
+		"limits": map[string]interface{}{
+			"max_import_file_bytes": maxImportFileSize,
+			"max_import_rows":       maxImportRows,
+			"max_intake_body_bytes": maxIntakeBodySize,
+			"max_group_users_batch": maxGroupUsersBatch,
+			"max_move_tasks":        maxMoveTasks,
+			"max_quick_add_length":  maxQuickAddLength,
+			"max_attachment_bytes":  modules.Attachments.MaxSize(),
+			"attachment_types":      modules.Attachments.Types(),
+			"rate_limits":           modules.Limiter.Policies(),
+			"api_quota":             map[string]int{"daily": dailyQuota, "monthly": monthlyQuota},
+			"access_token_ttl":      cfg.AccessTokenTTL.String(),
+			"max_sessions_per_user": cfg.MaxSessionsPerUser,
+			"session_idle_timeout":  cfg.SessionIdleTimeout.String(),
+		},
+		"password_policy": modules.CurrentPasswordPolicy(),
+	})
+}
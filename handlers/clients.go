@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+// ClientsHandler handles /clients endpoint
+func ClientsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		getAllClients(w, r)
+	case "POST":
+		createClient(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ClientHandler handles /clients/{id} and sub-paths
+func ClientHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/clients/")
+	parts := strings.Split(path, "/")
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+
+	client, err := modules.RedisClient.GetClient(id)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Client not found")
+		return
+	}
+
+	if len(parts) == 1 {
+		// /clients/{id}
+		switch r.Method {
+		case "GET":
+			respondWithSuccess(w, client)
+		case "PUT":
+			updateClient(w, r, client)
+		case "DELETE":
+			deleteClient(w, r, client)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if parts[1] != "portal-tokens" || len(parts) > 3 {
+		http.Error(w, "Invalid sub-path", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 3 {
+		// /clients/{id}/portal-tokens/{tokenID}
+		if r.Method != "DELETE" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		revokePortalToken(w, r, client, parts[2])
+		return
+	}
+
+	// /clients/{id}/portal-tokens
+	switch r.Method {
+	case "GET":
+		getPortalTokens(w, r, client)
+	case "POST":
+		issuePortalToken(w, r, client)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func getAllClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := modules.RedisClient.GetAllClients()
+	if err != nil {
+		respondWithError(w, fmt.Sprintf("Failed to get clients: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"clients": clients,
+		"count":   len(clients),
+	})
+}
+
+func createClient(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	v.required(req.Company, "company")
+	checkClient(v, 0, req.Company, req.Contacts, req.GroupIDs)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	clientID, err := modules.RedisClient.GetNextClientID()
+	if err != nil {
+		respondWithError(w, "Failed to generate client ID", http.StatusInternalServerError)
+		return
+	}
+
+	client := &models.Client{
+		ID:        clientID,
+		Company:   strings.TrimSpace(req.Company),
+		Contacts:  req.Contacts,
+		GroupIDs:  req.GroupIDs,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := modules.RedisClient.SaveClient(client); err != nil {
+		respondWithError(w, "Failed to save client", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("clients")
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Client created successfully",
+		"client":  client,
+	}, http.StatusCreated)
+}
+
+func updateClient(w http.ResponseWriter, r *http.Request, client *models.Client) {
+	var req models.UpdateClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	checkClient(v, client.ID, req.Company, req.Contacts, req.GroupIDs)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	if strings.TrimSpace(req.Company) != "" {
+		client.Company = strings.TrimSpace(req.Company)
+	}
+	if req.Contacts != nil {
+		client.Contacts = req.Contacts
+	}
+	if req.GroupIDs != nil {
+		client.GroupIDs = req.GroupIDs
+	}
+	client.UpdatedAt = time.Now()
+
+	if err := modules.RedisClient.SaveClient(client); err != nil {
+		respondWithError(w, "Failed to update client", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("clients")
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Client updated successfully",
+		"client":  client,
+	})
+}
+
+func deleteClient(w http.ResponseWriter, r *http.Request, client *models.Client) {
+	if err := modules.RedisClient.RevokeClientPortalTokens(client.ID); err != nil {
+		respondWithDomainError(w, r, err, "Failed to revoke portal tokens")
+		return
+	}
+
+	if err := modules.RedisClient.DeleteClient(client.ID); err != nil {
+		respondWithError(w, "Failed to delete client", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("clients")
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Client deleted successfully",
+	})
+}
+
+func getPortalTokens(w http.ResponseWriter, r *http.Request, client *models.Client) {
+	tokens, err := modules.RedisClient.GetPortalTokens(client.ID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get portal tokens")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"client_id": client.ID,
+		"tokens":    tokens,
+		"count":     len(tokens),
+	})
+}
+
+// issuePortalToken creates a portal token for one of the client's people.
+// The token is only shown in this response.
+func issuePortalToken(w http.ResponseWriter, r *http.Request, client *models.Client) {
+	var req struct {
+		Label string `json:"label"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	v.required(req.Label, "label")
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	token, portalToken, err := modules.RedisClient.IssuePortalToken(client.ID, strings.TrimSpace(req.Label))
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to create portal token")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"token":        token,
+		"portal_token": portalToken,
+	}, http.StatusCreated)
+}
+
+func revokePortalToken(w http.ResponseWriter, r *http.Request, client *models.Client, tokenID string) {
+	if err := modules.RedisClient.RevokePortalToken(client.ID, tokenID); err != nil {
+		respondWithDomainError(w, r, err, "Portal token not found")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Portal token revoked",
+	})
+}
+
+// PortalHandler shows a client the high-level status of its groups /portal.
+// It is served without the normal authentication; callers present a client
+// portal token as a bearer token instead.
+func PortalHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="Client Portal"`)
+		respondWithError(w, "Portal token required", http.StatusUnauthorized)
+		return
+	}
+
+	portalToken, err := modules.RedisClient.PortalTokenClient(strings.TrimSpace(token))
+	if modules.IsInvalidToken(err) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="Client Portal"`)
+		respondWithError(w, "Invalid portal token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to check portal token")
+		return
+	}
+
+	client, err := modules.RedisClient.GetClient(portalToken.ClientID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Client not found")
+		return
+	}
+
+	now := time.Now()
+	projects := make([]map[string]interface{}, 0, len(client.GroupIDs))
+	for _, groupID := range client.GroupIDs {
+		group, err := modules.RedisClient.GetGroup(groupID)
+		if err != nil {
+			continue
+		}
+		tasks, err := modules.RedisClient.GetGroupTasks(groupID)
+		if err != nil {
+			respondWithError(w, "Failed to get group tasks", http.StatusInternalServerError)
+			return
+		}
+
+		completed, overdue := 0, 0
+		lastActivity := group.UpdatedAt
+		for _, task := range tasks {
+			if task.Status {
+				completed++
+			} else if modules.IsTaskOverdue(task, now) {
+				overdue++
+			}
+			if task.UpdatedAt.After(lastActivity) {
+				lastActivity = task.UpdatedAt
+			}
+		}
+
+		completionRate := 0.0
+		if len(tasks) > 0 {
+			completionRate = float64(completed) / float64(len(tasks)) * 100
+		}
+
+		projects = append(projects, map[string]interface{}{
+			"id":              group.ID,
+			"name":            group.Name,
+			"total_tasks":     len(tasks),
+			"completed_tasks": completed,
+			"open_tasks":      len(tasks) - completed,
+			"overdue_tasks":   overdue,
+			"completion_rate": fmt.Sprintf("%.1f%%", completionRate),
+			"last_activity":   lastActivity,
+		})
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"client": map[string]interface{}{
+			"id":      client.ID,
+			"company": client.Company,
+		},
+		"projects": projects,
+	})
+}
+
+// checkClient validates client fields that are set; clientID is the client
+// being updated, or 0 when creating one
+func checkClient(v *validator, clientID int, company string, contacts []models.ClientContact, groupIDs []int) {
+	if company = strings.TrimSpace(company); company != "" {
+		clients, err := modules.RedisClient.GetAllClients()
+		if err == nil {
+			for _, other := range clients {
+				if other.ID != clientID && strings.EqualFold(other.Company, company) {
+					v.check(false, "company", "already_exists")
+					break
+				}
+			}
+		}
+	}
+
+	for _, contact := range contacts {
+		v.check(strings.TrimSpace(contact.Name) != "", "contacts", "contact_name")
+	}
+
+	checkGroupsExist(v, groupIDs)
+}
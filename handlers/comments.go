@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+	"unicode/utf8"
+)
+
+// maxCommentLength caps a comment's Markdown, in characters
+const maxCommentLength = 10000
+
+// handleUserTaskComments handles /users/{id}/tasks/{tid}/comments[/{cid}]
+func handleUserTaskComments(w http.ResponseWriter, r *http.Request, userID, taskID int, remainingParts []string) {
+	task, err := modules.RedisClient.GetTask(taskID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Task not found")
+		return
+	}
+
+	if task.UserID != userID {
+		respondWithError(w, "Task does not belong to this user", http.StatusNotFound)
+		return
+	}
+
+	handleTaskComments(w, r, task, remainingParts)
+}
+
+// handleTaskComments serves a task's comments. Everyone who may see the task
+// reads them; those who may modify it write them.
+func handleTaskComments(w http.ResponseWriter, r *http.Request, task *models.Task, remainingParts []string) {
+	authCtx := modules.GetAuthContext(r)
+
+	// Tasks the caller may not see are reported as missing
+	if !modules.CanViewTask(authCtx, task) {
+		respondWithError(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != "GET" && !modules.CanModifyTask(authCtx, task) {
+		respondWithError(w, "Insufficient permissions to modify this task", http.StatusForbidden)
+		return
+	}
+
+	if len(remainingParts) == 0 {
+		// .../comments
+		switch r.Method {
+		case "GET":
+			getTaskComments(w, r, task)
+		case "POST":
+			createTaskComment(w, r, task)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if len(remainingParts) != 1 {
+		http.Error(w, "Invalid comment sub-path", http.StatusBadRequest)
+		return
+	}
+
+	commentID, err := strconv.Atoi(remainingParts[0])
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	comment, err := modules.RedisClient.GetComment(commentID)
+	if err != nil || comment.TaskID != task.ID {
+		respondWithError(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+
+	// .../comments/{cid}
+	switch r.Method {
+	case "GET":
+		respondWithSuccess(w, comment)
+	case "DELETE":
+		deleteTaskComment(w, r, task, comment)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func getTaskComments(w http.ResponseWriter, r *http.Request, task *models.Task) {
+	comments, err := modules.RedisClient.GetTaskComments(task.ID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get comments")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"task_id":  task.ID,
+		"comments": comments,
+		"count":    len(comments),
+	})
+}
+
+// createTaskComment adds a comment by the caller and lets the task's other
+// assignees know
+func createTaskComment(w http.ResponseWriter, r *http.Request, task *models.Task) {
+	var req models.CreateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	body := strings.TrimSpace(req.Body)
+	v.required(body, "body")
+	v.check(utf8.RuneCountInString(body) <= maxCommentLength, "body", "comment_too_long", maxCommentLength)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	if groupFrozen(w, r, task.GroupID) {
+		return
+	}
+
+	commentID, err := modules.RedisClient.GetNextCommentID()
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to create comment")
+		return
+	}
+
+	comment := &models.Comment{
+		ID:         commentID,
+		TaskID:     task.ID,
+		AuthorName: "The owner",
+		Body:       body,
+		CreatedAt:  time.Now(),
+	}
+	authCtx := modules.GetAuthContext(r)
+	if authCtx.User != nil {
+		comment.AuthorID = authCtx.User.ID
+		comment.AuthorName = authCtx.User.FullName
+	}
+
+	if err := modules.RedisClient.SaveComment(comment); err != nil {
+		respondWithDomainError(w, r, err, "Failed to save comment")
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("comments")
+
+	modules.Events.PublishTask(r.Context(), "comment.created", task, comment)
+	for _, userID := range task.Assignees() {
+		if userID == comment.AuthorID {
+			continue
+		}
+		user, err := modules.RedisClient.GetUser(userID)
+		if err != nil {
+			continue
+		}
+		modules.Notifications.Notify(r.Context(), user, &modules.Notification{
+			Kind:    "comment",
+			Message: fmt.Sprintf("%s commented on %q (#%d)", comment.AuthorName, task.Title, task.ID),
+			TaskID:  task.ID,
+		})
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Comment added",
+		"comment": comment,
+	}, http.StatusCreated)
+}
+
+// deleteTaskComment removes a comment; authors remove their own, and the
+// task's group admins and the owner remove any
+func deleteTaskComment(w http.ResponseWriter, r *http.Request, task *models.Task, comment *models.Comment) {
+	authCtx := modules.GetAuthContext(r)
+	isAuthor := authCtx.User != nil && authCtx.User.ID == comment.AuthorID
+	if !isAuthor && !administersGroup(authCtx, task.GroupID) {
+		respondWithError(w, "You can only delete your own comments", http.StatusForbidden)
+		return
+	}
+
+	if groupFrozen(w, r, task.GroupID) {
+		return
+	}
+
+	if err := modules.RedisClient.DeleteComment(comment); err != nil {
+		respondWithDomainError(w, r, err, "Failed to delete comment")
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("comments")
+
+	modules.Events.PublishTask(r.Context(), "comment.deleted", task, comment)
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Comment deleted",
+	})
+}
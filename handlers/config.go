@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"task-manager/modules"
+)
+
+type configBundleRequest struct {
+	Version  int                          `json:"version"`
+	Settings json.RawMessage              `json:"settings"`
+	Holidays map[string]map[string]string `json:"holidays"`
+}
+
+// ConfigHandler handles GET/PUT /admin/config, exporting the organization's
+// configuration as a bundle and applying one
+func ConfigHandler(w http.ResponseWriter, r *http.Request) {
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		respondWithError(w, "Only owner can manage configuration", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		exportConfig(w, r)
+	case "PUT":
+		applyConfig(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// exportConfig writes the bundle as a bare, indented document so it can be
+// saved and committed as is
+func exportConfig(w http.ResponseWriter, r *http.Request) {
+	bundle, err := modules.RedisClient.ExportConfig()
+	if err != nil {
+		respondWithError(w, "Failed to export configuration", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="gask-config.json"`)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(bundle)
+}
+
+// applyConfig makes the configuration match the uploaded bundle. With
+// ?dry_run=true it only reports what would change.
+func applyConfig(w http.ResponseWriter, r *http.Request) {
+	var req configBundleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	v.check(req.Version == modules.ConfigBundleVersion, "version", "invalid_choice", "1")
+
+	bundle := &modules.ConfigBundle{Version: req.Version}
+	if len(req.Settings) > 0 && string(req.Settings) != "null" {
+		settings, err := modules.ParseOrganizationSettings(req.Settings)
+		if err != nil {
+			v.check(false, "settings", "invalid_value", err.Error())
+		} else {
+			checkSettings(v, settings)
+			bundle.Settings = settings
+		}
+	}
+
+	if req.Holidays != nil {
+		bundle.Holidays = make(map[string]map[string]string, len(req.Holidays))
+		regions := make([]string, 0, len(req.Holidays))
+		for region := range req.Holidays {
+			regions = append(regions, region)
+		}
+		sort.Strings(regions)
+
+		for _, region := range regions {
+			v.check(modules.IsValidRegion(region), "holidays", "invalid_region")
+			dates := make(map[string]string, len(req.Holidays[region]))
+			for date, name := range req.Holidays[region] {
+				checkHoliday(v, "holidays", date, name)
+				dates[date] = strings.TrimSpace(name)
+			}
+			bundle.Holidays[region] = dates
+		}
+	}
+
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	changes, err := modules.RedisClient.ApplyConfig(bundle, dryRun)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to apply configuration")
+		return
+	}
+
+	message := "Configuration applied"
+	if !changes.Changed() {
+		message = "Configuration already up to date"
+	} else if dryRun {
+		message = "Configuration would change"
+	} else {
+		handlerLog.InfoContext(r.Context(), "Configuration applied",
+			"settings", changes.Settings,
+			"holiday_regions", changes.HolidayRegions,
+		)
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": message,
+		"dry_run": dryRun,
+		"changes": changes,
+	})
+}
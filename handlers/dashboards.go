@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+// DashboardHandler handles /dashboards/users/{id}, /dashboards/groups/{id}
+// and /dashboards/groups/{id}/burndown, which read the projections the
+// projector keeps from task changes rather than the tasks themselves
+func DashboardHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/dashboards/"), "/"), "/")
+	if len(parts) < 2 {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case parts[0] == "users" && len(parts) == 2:
+		getUserDashboard(w, r, id)
+	case parts[0] == "groups" && len(parts) == 2:
+		getGroupDashboard(w, r, id)
+	case parts[0] == "groups" && len(parts) == 3 && parts[2] == "burndown":
+		getGroupBurndownDashboard(w, r, id)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// projectedCountsResponse adds the pending count to a projection
+func projectedCountsResponse(counts *modules.ProjectedCounts) map[string]interface{} {
+	return map[string]interface{}{
+		"open":      counts.Open,
+		"blocked":   counts.Blocked,
+		"done":      counts.Done,
+		"cancelled": counts.Cancelled,
+		"pending":   counts.Pending(),
+	}
+}
+
+// getUserDashboard shows a user's tasks by state; users see their own, and
+// whoever may see the user sees theirs
+func getUserDashboard(w http.ResponseWriter, r *http.Request, userID int) {
+	authCtx := modules.GetAuthContext(r)
+	user, err := modules.RedisClient.GetUser(userID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "User not found")
+		return
+	}
+	if !authCtx.IsOwner && userID != authCtx.User.ID && len(modules.FilterUsersByPermissions(authCtx, []*models.User{user})) == 0 {
+		respondWithError(w, "Insufficient permissions to view this user's dashboard", http.StatusForbidden)
+		return
+	}
+
+	counts, err := modules.RedisClient.GetUserProjections([]int{userID})
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to load dashboard")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"user_id":   user.ID,
+		"full_name": user.FullName,
+		"tasks":     projectedCountsResponse(counts[userID]),
+	})
+}
+
+// getGroupDashboard shows a group's tasks by state and how many open tasks
+// each member has across all their groups
+func getGroupDashboard(w http.ResponseWriter, r *http.Request, groupID int) {
+	if !canViewGroupDashboard(w, r, groupID) {
+		return
+	}
+
+	group, err := modules.RedisClient.GetGroup(groupID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Group not found")
+		return
+	}
+	users, err := modules.RedisClient.GetGroupUsers(groupID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get group users")
+		return
+	}
+
+	counts, err := modules.RedisClient.GetGroupProjection(groupID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to load dashboard")
+		return
+	}
+	userIDs := make([]int, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	perUser, err := modules.RedisClient.GetUserProjections(userIDs)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to load dashboard")
+		return
+	}
+
+	type memberCounts struct {
+		UserID   int    `json:"user_id"`
+		FullName string `json:"full_name"`
+		Open     int64  `json:"open"`
+		Blocked  int64  `json:"blocked"`
+	}
+	members := make([]memberCounts, 0, len(users))
+	for _, user := range users {
+		members = append(members, memberCounts{
+			UserID:   user.ID,
+			FullName: user.FullName,
+			Open:     perUser[user.ID].Open,
+			Blocked:  perUser[user.ID].Blocked,
+		})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Open != members[j].Open {
+			return members[i].Open > members[j].Open
+		}
+		return members[i].UserID < members[j].UserID
+	})
+
+	respondWithSuccess(w, map[string]interface{}{
+		"group": map[string]interface{}{
+			"id":   group.ID,
+			"name": group.Name,
+		},
+		"tasks":   projectedCountsResponse(counts),
+		"members": members,
+	})
+}
+
+// getGroupBurndownDashboard answers a group's daily burndown between from
+// and to, the last 30 days by default
+func getGroupBurndownDashboard(w http.ResponseWriter, r *http.Request, groupID int) {
+	if !canViewGroupDashboard(w, r, groupID) {
+		return
+	}
+
+	query := r.URL.Query()
+	now := time.Now()
+	v := newValidator()
+	from := parseReportDate(v, query.Get("from"), "from", now.AddDate(0, 0, -29))
+	to := parseReportDate(v, query.Get("to"), "to", now)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	points, err := modules.RedisClient.GetGroupBurndownProjection(groupID, from, to)
+	if errors.Is(err, modules.ErrInvalidReportRange) {
+		respondWithError(w, "A burndown covers 1 to 400 days, with from on or before to", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to load burndown")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"group_id": groupID,
+		"points":   points,
+	})
+}
+
+// canViewGroupDashboard lets the owner, the group's admin and its members
+// through, answering 403 to everyone else
+func canViewGroupDashboard(w http.ResponseWriter, r *http.Request, groupID int) bool {
+	authCtx := modules.GetAuthContext(r)
+	if !administersGroup(authCtx, groupID) && !isGroupMember(authCtx, groupID) {
+		respondWithError(w, "Insufficient permissions to view this group's dashboard", http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"task-manager/modules"
+)
+
+var handlerLog = modules.Logger("http")
+
+// statusForError maps domain errors from modules to HTTP status codes
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, modules.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, modules.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, modules.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, modules.ErrValidation):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondWithDomainError responds with message and the status matching err.
+// Unexpected errors are logged and reported as a generic internal error.
+func respondWithDomainError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	statusCode := statusForError(err)
+	if statusCode == http.StatusInternalServerError {
+		handlerLog.ErrorContext(r.Context(), "⚠️ "+message, "error", err)
+		message = "Internal server error"
+	}
+
+	respondWithError(w, message, statusCode)
+}
@@ -0,0 +1,443 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+// estimationTaskResult is one task of an estimation session. Who voted is
+// always shown; the estimates themselves stay hidden until the reveal.
+type estimationTaskResult struct {
+	TaskID    int             `json:"task_id"`
+	Title     string          `json:"title,omitempty"`
+	Voters    []int           `json:"voters"`
+	Estimates map[int]float64 `json:"estimates,omitempty"`
+	Suggested *float64        `json:"suggested_hours,omitempty"`
+	Agreed    bool            `json:"agreed,omitempty"`
+}
+
+// handleGroupEstimations routes planning-poker sessions /groups/{id}/estimations/...
+func handleGroupEstimations(w http.ResponseWriter, r *http.Request, groupID int, remainingParts []string) {
+	if len(remainingParts) == 0 {
+		// /groups/{id}/estimations
+		switch r.Method {
+		case "GET":
+			getGroupEstimations(w, r, groupID)
+		case "POST":
+			createGroupEstimation(w, r, groupID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	sessionID, err := strconv.Atoi(remainingParts[0])
+	if err != nil {
+		http.Error(w, "Invalid estimation session ID", http.StatusBadRequest)
+		return
+	}
+
+	session, err := modules.RedisClient.GetEstimationSession(sessionID)
+	if err != nil || session.GroupID != groupID {
+		respondWithError(w, "Estimation session not found", http.StatusNotFound)
+		return
+	}
+
+	if len(remainingParts) == 1 {
+		// /groups/{id}/estimations/{sid}
+		switch r.Method {
+		case "GET":
+			getGroupEstimation(w, r, session)
+		case "DELETE":
+			deleteGroupEstimation(w, r, session)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if len(remainingParts) != 2 {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+	if r.Method != "PUT" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch remainingParts[1] {
+	case "votes":
+		submitEstimates(w, r, session)
+	case "reveal":
+		revealEstimation(w, r, session)
+	case "accept":
+		acceptEstimation(w, r, session)
+	default:
+		http.Error(w, "Invalid sub-path", http.StatusBadRequest)
+	}
+}
+
+func getGroupEstimations(w http.ResponseWriter, r *http.Request, groupID int) {
+	sessions, err := modules.RedisClient.GetGroupEstimationSessions(groupID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get estimation sessions")
+		return
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ID > sessions[j].ID
+	})
+
+	respondWithSuccess(w, map[string]interface{}{
+		"group_id": groupID,
+		"sessions": sessions,
+		"count":    len(sessions),
+	})
+}
+
+func createGroupEstimation(w http.ResponseWriter, r *http.Request, groupID int) {
+	var req models.CreateEstimationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	v.check(len(req.TaskIDs) > 0, "task_ids", "required")
+
+	seen := make(map[int]bool)
+	var taskIDs []int
+	for _, taskID := range req.TaskIDs {
+		if seen[taskID] {
+			continue
+		}
+		seen[taskID] = true
+
+		task, err := modules.RedisClient.GetTask(taskID)
+		v.check(err == nil && task.GroupID == groupID, "task_ids", "task_missing", taskID)
+		taskIDs = append(taskIDs, taskID)
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	sessionID, err := modules.RedisClient.GetNextEstimationID()
+	if err != nil {
+		respondWithError(w, "Failed to generate estimation session ID", http.StatusInternalServerError)
+		return
+	}
+
+	session := &models.EstimationSession{
+		ID:        sessionID,
+		GroupID:   groupID,
+		TaskIDs:   taskIDs,
+		Status:    models.EstimationOpen,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if authCtx := modules.GetAuthContext(r); authCtx.User != nil {
+		session.CreatedBy = authCtx.User.ID
+	}
+
+	if err := modules.RedisClient.SaveEstimationSession(session); err != nil {
+		respondWithError(w, "Failed to save estimation session", http.StatusInternalServerError)
+		return
+	}
+
+	modules.Events.Publish(r.Context(), "estimation.created", session.CreatedBy, groupID, session)
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Estimation session created successfully",
+		"session": session,
+	}, http.StatusCreated)
+}
+
+func getGroupEstimation(w http.ResponseWriter, r *http.Request, session *models.EstimationSession) {
+	results, err := estimationResults(session)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get estimates")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"session": session,
+		"tasks":   results,
+	})
+}
+
+func deleteGroupEstimation(w http.ResponseWriter, r *http.Request, session *models.EstimationSession) {
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner && !authCtx.IsGroupAdmin {
+		respondWithError(w, "Only group admins can delete estimation sessions", http.StatusForbidden)
+		return
+	}
+
+	if err := modules.RedisClient.DeleteEstimationSession(session); err != nil {
+		respondWithDomainError(w, r, err, "Failed to delete estimation session")
+		return
+	}
+
+	modules.Events.Publish(r.Context(), "estimation.deleted", session.CreatedBy, session.GroupID, session)
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Estimation session deleted successfully",
+	})
+}
+
+// submitEstimates records the caller's hidden estimates /groups/{id}/estimations/{sid}/votes.
+// Members may change their estimates until the session is revealed.
+func submitEstimates(w http.ResponseWriter, r *http.Request, session *models.EstimationSession) {
+	authCtx := modules.GetAuthContext(r)
+	if authCtx.User == nil {
+		respondWithError(w, "Only users can submit estimates", http.StatusBadRequest)
+		return
+	}
+
+	if session.Status != models.EstimationOpen {
+		respondWithError(w, "Estimation session is no longer open", http.StatusConflict)
+		return
+	}
+
+	var req models.EstimatesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	checkEstimates(v, session, req.Estimates)
+	v.check(len(req.Estimates) > 0, "estimates", "required")
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	if err := modules.RedisClient.SetEstimates(session.ID, authCtx.User.ID, req.Estimates); err != nil {
+		respondWithDomainError(w, r, err, "Failed to save estimates")
+		return
+	}
+
+	// Only say who voted on what; the values stay hidden until the reveal
+	taskIDs := make([]int, 0, len(req.Estimates))
+	for taskID := range req.Estimates {
+		taskIDs = append(taskIDs, taskID)
+	}
+	sort.Ints(taskIDs)
+	modules.Events.Publish(r.Context(), "estimation.voted", authCtx.User.ID, session.GroupID, map[string]interface{}{
+		"session_id": session.ID,
+		"user_id":    authCtx.User.ID,
+		"task_ids":   taskIDs,
+	})
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":   "Estimates submitted",
+		"estimates": req.Estimates,
+	})
+}
+
+// revealEstimation shows everyone's estimates and the suggested consensus /groups/{id}/estimations/{sid}/reveal
+func revealEstimation(w http.ResponseWriter, r *http.Request, session *models.EstimationSession) {
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner && !authCtx.IsGroupAdmin {
+		respondWithError(w, "Only group admins can reveal estimates", http.StatusForbidden)
+		return
+	}
+
+	if session.Status != models.EstimationOpen {
+		respondWithError(w, "Estimation session was already revealed", http.StatusConflict)
+		return
+	}
+
+	session.Status = models.EstimationRevealed
+	session.UpdatedAt = time.Now()
+	if err := modules.RedisClient.SaveEstimationSession(session); err != nil {
+		respondWithDomainError(w, r, err, "Failed to save estimation session")
+		return
+	}
+
+	results, err := estimationResults(session)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get estimates")
+		return
+	}
+
+	modules.Events.Publish(r.Context(), "estimation.revealed", session.CreatedBy, session.GroupID, map[string]interface{}{
+		"session": session,
+		"tasks":   results,
+	})
+
+	respondWithSuccess(w, map[string]interface{}{
+		"session": session,
+		"tasks":   results,
+	})
+}
+
+// acceptEstimation writes estimated_hours to the session's tasks /groups/{id}/estimations/{sid}/accept.
+// Each task gets the suggested estimate unless the request overrides it; tasks
+// nobody estimated are left alone.
+func acceptEstimation(w http.ResponseWriter, r *http.Request, session *models.EstimationSession) {
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner && !authCtx.IsGroupAdmin {
+		respondWithError(w, "Only group admins can accept estimates", http.StatusForbidden)
+		return
+	}
+
+	if session.Status != models.EstimationRevealed {
+		respondWithError(w, "Only revealed estimation sessions can be accepted", http.StatusConflict)
+		return
+	}
+	if groupFrozen(w, r, session.GroupID) {
+		return
+	}
+
+	var req models.EstimatesRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	v := newValidator()
+	checkEstimates(v, session, req.Estimates)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	results, err := estimationResults(session)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get estimates")
+		return
+	}
+
+	var updatedTasks []*models.Task
+	var errs []string
+	for _, result := range results {
+		hours, ok := req.Estimates[result.TaskID]
+		if !ok {
+			if result.Suggested == nil {
+				continue
+			}
+			hours = *result.Suggested
+		}
+
+		task, err := modules.RedisClient.GetTask(result.TaskID)
+		if err != nil || task.GroupID != session.GroupID {
+			errs = append(errs, fmt.Sprintf("Task %d not found", result.TaskID))
+			continue
+		}
+
+		task.EstimatedHours = hours
+		task.UpdatedAt = time.Now()
+		err = modules.RedisClient.SaveTaskIfVersion(task, task.Version)
+		if errors.Is(err, modules.ErrVersionConflict) {
+			errs = append(errs, fmt.Sprintf("Task %d was modified by another request", result.TaskID))
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Failed to update task %d", result.TaskID))
+			continue
+		}
+
+		modules.Events.PublishTask(r.Context(), "task.updated", task, task)
+		updatedTasks = append(updatedTasks, task)
+	}
+
+	if len(updatedTasks) > 0 {
+		modules.RedisClient.MarkDirty("tasks")
+	}
+
+	session.Status = models.EstimationAccepted
+	session.UpdatedAt = time.Now()
+	if err := modules.RedisClient.SaveEstimationSession(session); err != nil {
+		respondWithDomainError(w, r, err, "Failed to save estimation session")
+		return
+	}
+
+	modules.Events.Publish(r.Context(), "estimation.accepted", session.CreatedBy, session.GroupID, session)
+
+	respondWithSuccess(w, map[string]interface{}{
+		"session":       session,
+		"updated_tasks": updatedTasks,
+		"updated_count": len(updatedTasks),
+		"errors":        errs,
+		"error_count":   len(errs),
+	})
+}
+
+// checkEstimates fails estimates that name tasks outside the session or negative hours
+func checkEstimates(v *validator, session *models.EstimationSession, estimates map[int]float64) {
+	inSession := make(map[int]bool, len(session.TaskIDs))
+	for _, taskID := range session.TaskIDs {
+		inSession[taskID] = true
+	}
+
+	for taskID, hours := range estimates {
+		v.check(inSession[taskID], "estimates", "task_not_in_session", taskID)
+		v.check(hours >= 0, "estimates", "not_negative")
+	}
+}
+
+// estimationResults lists the session's tasks with their voters, adding the
+// estimates and suggested consensus once the session has been revealed
+func estimationResults(session *models.EstimationSession) ([]*estimationTaskResult, error) {
+	estimates, err := modules.RedisClient.GetEstimates(session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	revealed := session.Status != models.EstimationOpen
+	results := make([]*estimationTaskResult, 0, len(session.TaskIDs))
+	for _, taskID := range session.TaskIDs {
+		result := &estimationTaskResult{
+			TaskID: taskID,
+			Voters: []int{},
+		}
+		if task, err := modules.RedisClient.GetTask(taskID); err == nil {
+			result.Title = task.Title
+		}
+
+		votes := estimates[taskID]
+		for userID := range votes {
+			result.Voters = append(result.Voters, userID)
+		}
+		sort.Ints(result.Voters)
+
+		if revealed && len(votes) > 0 {
+			result.Estimates = votes
+			suggested, agreed := suggestEstimate(votes)
+			result.Suggested = &suggested
+			result.Agreed = agreed
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// suggestEstimate proposes the median estimate rounded to the nearest half hour,
+// and reports whether everyone gave the same estimate
+func suggestEstimate(votes map[int]float64) (float64, bool) {
+	values := make([]float64, 0, len(votes))
+	for _, hours := range votes {
+		values = append(values, hours)
+	}
+	sort.Float64s(values)
+
+	median := values[len(values)/2]
+	if len(values)%2 == 0 {
+		median = (values[len(values)/2-1] + values[len(values)/2]) / 2
+	}
+
+	return math.Round(median*2) / 2, values[0] == values[len(values)-1]
+}
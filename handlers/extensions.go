@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+// handleTaskExtensions handles /users/{id}/tasks/{tid}/extensions, where an
+// assignee asks to move a task's deadline, and .../extensions/{eid}/approve
+// and .../reject, where the group's admin decides
+func handleTaskExtensions(w http.ResponseWriter, r *http.Request, userID, taskID int, remainingParts []string) {
+	if len(remainingParts) == 0 {
+		switch r.Method {
+		case "GET":
+			getTaskExtensions(w, r, userID, taskID)
+		case "POST":
+			requestTaskExtension(w, r, userID, taskID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	extensionID, err := strconv.Atoi(remainingParts[0])
+	if err != nil {
+		http.Error(w, "Invalid extension ID", http.StatusBadRequest)
+		return
+	}
+	if len(remainingParts) == 2 && (remainingParts[1] == "approve" || remainingParts[1] == "reject") {
+		if r.Method != "PUT" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		reviewTaskExtension(w, r, userID, taskID, extensionID, remainingParts[1])
+		return
+	}
+
+	http.Error(w, "Invalid extension sub-path", http.StatusBadRequest)
+}
+
+// extensionTask loads a task of user {id}, answering 404 if it is not theirs
+func extensionTask(w http.ResponseWriter, r *http.Request, userID, taskID int) *models.Task {
+	task, err := modules.RedisClient.GetTask(taskID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Task not found")
+		return nil
+	}
+	if !task.IsAssignee(userID) {
+		respondWithError(w, "Task does not belong to this user", http.StatusNotFound)
+		return nil
+	}
+	return task
+}
+
+func getTaskExtensions(w http.ResponseWriter, r *http.Request, userID, taskID int) {
+	task := extensionTask(w, r, userID, taskID)
+	if task == nil {
+		return
+	}
+
+	extensions := task.Extensions
+	if extensions == nil {
+		extensions = models.DeadlineExtensions{}
+	}
+	respondWithSuccess(w, map[string]interface{}{
+		"task_id":           task.ID,
+		"deadline":          task.Deadline,
+		"original_deadline": task.OriginalDeadline,
+		"extensions":        extensions,
+		"count":             len(extensions),
+	})
+}
+
+func requestTaskExtension(w http.ResponseWriter, r *http.Request, userID, taskID int) {
+	var req models.CreateExtensionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	task := extensionTask(w, r, userID, taskID)
+	if task == nil {
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if !modules.CanModifyTask(authCtx, task) {
+		respondWithError(w, "Insufficient permissions to modify this task", http.StatusForbidden)
+		return
+	}
+	if groupFrozen(w, r, task.GroupID) {
+		return
+	}
+
+	v := newValidator()
+	v.required(req.Deadline, "deadline")
+	v.required(strings.TrimSpace(req.Reason), "reason")
+	if req.Deadline != "" {
+		v.check(validDeadline(req.Deadline), "deadline", "invalid_date")
+	}
+	if v.valid() && len(task.Deadline) >= 10 {
+		v.check(req.Deadline[:10] > task.Deadline[:10], "deadline", "date_order", task.Deadline[:10])
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	switch {
+	case task.Deadline == "":
+		respondWithError(w, "Task has no deadline to extend", http.StatusConflict)
+		return
+	case task.Closed():
+		respondWithError(w, fmt.Sprintf("Task is already %s", task.State), http.StatusConflict)
+		return
+	case task.Extensions.Pending() != nil:
+		respondWithError(w, "Task already has an extension awaiting a decision", http.StatusConflict)
+		return
+	}
+
+	extension := models.DeadlineExtension{
+		ID:          len(task.Extensions) + 1,
+		RequestedBy: userID,
+		From:        task.Deadline,
+		To:          req.Deadline,
+		Reason:      strings.TrimSpace(req.Reason),
+		Status:      models.ExtensionPending,
+		RequestedAt: time.Now(),
+	}
+	task.Extensions = append(task.Extensions, extension)
+
+	if err := modules.RedisClient.SaveTaskBehind(task); err != nil {
+		respondWithError(w, "Failed to save extension request", http.StatusInternalServerError)
+		return
+	}
+
+	modules.Events.PublishTask(r.Context(), "extension.requested", task, task)
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":   "Extension requested",
+		"extension": extension,
+		"task":      task,
+	}, http.StatusCreated)
+}
+
+func reviewTaskExtension(w http.ResponseWriter, r *http.Request, userID, taskID, extensionID int, action string) {
+	var req models.ReviewLeaveRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	task := extensionTask(w, r, userID, taskID)
+	if task == nil {
+		return
+	}
+	extension := task.Extension(extensionID)
+	if extension == nil {
+		respondWithError(w, "Extension not found", http.StatusNotFound)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if !canReviewExtension(authCtx, task, extension) {
+		respondWithError(w, "Insufficient permissions to review this extension", http.StatusForbidden)
+		return
+	}
+	if groupFrozen(w, r, task.GroupID) {
+		return
+	}
+	if extension.Status != models.ExtensionPending {
+		respondWithError(w, fmt.Sprintf("Extension is already %s", extension.Status), http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	extension.Status = models.ExtensionRejected
+	if action == "approve" {
+		extension.Status = models.ExtensionApproved
+		if task.OriginalDeadline == "" {
+			task.OriginalDeadline = task.Deadline
+		}
+		task.Deadline = extension.To
+	}
+	if authCtx.User != nil {
+		extension.ReviewerID = authCtx.User.ID
+	}
+	extension.ReviewNote = req.Note
+	extension.ReviewedAt = &now
+
+	if err := modules.RedisClient.SaveTaskBehind(task); err != nil {
+		respondWithError(w, "Failed to update extension", http.StatusInternalServerError)
+		return
+	}
+
+	// Notify the requester of the decision
+	modules.Events.PublishTask(r.Context(), "extension."+extension.Status, task, task)
+	if requester, err := modules.RedisClient.GetUser(extension.RequestedBy); err == nil {
+		modules.Notifications.Notify(r.Context(), requester, &modules.Notification{
+			Kind:    "extension." + extension.Status,
+			Message: fmt.Sprintf("Your extension of %q (#%d) to %s was %s", task.Title, task.ID, extension.To, extension.Status),
+			TaskID:  task.ID,
+		})
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":   fmt.Sprintf("Extension %s", extension.Status),
+		"extension": extension,
+		"task":      task,
+	})
+}
+
+// getGroupExtensions handles GET /groups/{id}/extensions, listing the
+// extensions asked for on the group's tasks, pending ones by default
+func getGroupExtensions(w http.ResponseWriter, r *http.Request, groupID int) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if !administersGroup(authCtx, groupID) {
+		respondWithError(w, "Only the group's admin can review extensions", http.StatusForbidden)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.ExtensionPending
+	}
+	v := newValidator()
+	v.check(status == "all" || status == models.ExtensionPending || status == models.ExtensionApproved || status == models.ExtensionRejected,
+		"status", "invalid_choice", "pending, approved, rejected, all")
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	tasks, err := modules.RedisClient.GetGroupTasks(groupID)
+	if err != nil {
+		respondWithError(w, fmt.Sprintf("Failed to get tasks: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	type groupExtension struct {
+		models.DeadlineExtension
+		TaskID    int    `json:"task_id"`
+		TaskTitle string `json:"task_title"`
+		UserID    int    `json:"user_id"`
+	}
+	extensions := []groupExtension{}
+	for _, task := range tasks {
+		for _, extension := range task.Extensions {
+			if status == "all" || extension.Status == status {
+				extensions = append(extensions, groupExtension{extension, task.ID, task.Title, task.UserID})
+			}
+		}
+	}
+	sort.Slice(extensions, func(i, j int) bool {
+		return extensions[i].RequestedAt.Before(extensions[j].RequestedAt)
+	})
+
+	respondWithSuccess(w, map[string]interface{}{
+		"group_id":   groupID,
+		"extensions": extensions,
+		"count":      len(extensions),
+	})
+}
+
+// canReviewExtension checks whether the caller may decide an extension: the
+// owner, or an admin of the task's group who did not ask for it
+func canReviewExtension(authCtx *modules.AuthContext, task *models.Task, extension *models.DeadlineExtension) bool {
+	if authCtx.IsOwner {
+		return true
+	}
+	return administersGroup(authCtx, task.GroupID) && authCtx.User != nil && authCtx.User.ID != extension.RequestedBy
+}
+
+// validDeadline accepts a YYYY-MM-DD date or an RFC 3339 time
+func validDeadline(value string) bool {
+	if _, err := time.Parse(models.LeaveDateLayout, value); err == nil {
+		return true
+	}
+	_, err := time.Parse(time.RFC3339, value)
+	return err == nil
+}
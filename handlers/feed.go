@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"task-manager/config"
+	"task-manager/modules"
+	"time"
+)
+
+// feedTitles describes each recorded event type in feed entry titles
+var feedTitles = map[string]string{
+	"task.created":   "Task created",
+	"task.completed": "Task completed",
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Author  atomAuthor `xml:"author"`
+	Summary string     `xml:"summary"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// getGroupFeed serves the group's recent activity as an Atom feed /groups/{id}/feed.atom?token=...
+// The route is public; the group's feed token stands in for credentials.
+func getGroupFeed(w http.ResponseWriter, r *http.Request, groupID int) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Unknown and disabled feeds look the same to the caller
+	if !modules.RedisClient.ValidGroupFeedToken(groupID, r.URL.Query().Get("token")) {
+		respondWithError(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	group, err := modules.RedisClient.GetGroup(groupID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Group not found")
+		return
+	}
+
+	events, err := modules.RedisClient.GetGroupActivity(groupID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get group activity")
+		return
+	}
+
+	updated := group.UpdatedAt
+	if len(events) > 0 {
+		updated = events[0].CreatedAt
+	}
+
+	feed := atomFeed{
+		ID:      fmt.Sprintf("urn:gask:group:%d", groupID),
+		Title:   fmt.Sprintf("%s activity", group.Name),
+		Updated: updated.UTC().Format(time.RFC3339),
+		Link:    atomLink{Href: feedURL(r, groupID, ""), Rel: "self"},
+		Entries: make([]atomEntry, 0, len(events)),
+	}
+	for _, event := range events {
+		feed.Entries = append(feed.Entries, newAtomEntry(event))
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header))
+	if err := xml.NewEncoder(w).Encode(feed); err != nil {
+		handlerLog.WarnContext(r.Context(), "⚠️ Failed to encode feed", "group_id", groupID, "error", err)
+	}
+}
+
+func newAtomEntry(event *modules.Event) atomEntry {
+	title := feedTitles[event.Type]
+	summary := ""
+	taskID := 0
+	if data, ok := event.Data.(map[string]interface{}); ok {
+		if taskTitle, ok := data["title"].(string); ok {
+			title = fmt.Sprintf("%s: %s", title, taskTitle)
+		}
+		if information, ok := data["information"].(string); ok {
+			summary = information
+		}
+		if id, ok := data["id"].(float64); ok {
+			taskID = int(id)
+		}
+	}
+
+	author := "Unknown user"
+	if user, err := modules.RedisClient.GetUser(event.UserID); err == nil {
+		author = user.FullName
+	}
+
+	return atomEntry{
+		ID:      fmt.Sprintf("urn:gask:group:%d:%s:%d:%d", event.GroupID, event.Type, taskID, event.CreatedAt.UnixNano()),
+		Title:   title,
+		Updated: event.CreatedAt.UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: author},
+		Summary: summary,
+	}
+}
+
+// handleGroupFeedToken manages the group's feed token /groups/{id}/feed-token.
+// POST issues a new token, revoking the previous one; DELETE turns the feed off.
+func handleGroupFeedToken(w http.ResponseWriter, r *http.Request, groupID int) {
+	switch r.Method {
+	case "GET":
+		enabled, err := modules.RedisClient.HasGroupFeedToken(groupID)
+		if err != nil {
+			respondWithDomainError(w, r, err, "Failed to get feed token")
+			return
+		}
+		respondWithSuccess(w, map[string]interface{}{
+			"enabled": enabled,
+		})
+	case "POST":
+		token, err := modules.RedisClient.RotateGroupFeedToken(groupID)
+		if err != nil {
+			respondWithDomainError(w, r, err, "Failed to create feed token")
+			return
+		}
+		respondWithSuccess(w, map[string]interface{}{
+			"token":    token,
+			"feed_url": feedURL(r, groupID, token),
+		}, http.StatusCreated)
+	case "DELETE":
+		if err := modules.RedisClient.DeleteGroupFeedToken(groupID); err != nil {
+			respondWithDomainError(w, r, err, "Failed to delete feed token")
+			return
+		}
+		respondWithSuccess(w, map[string]interface{}{
+			"message": "Feed disabled",
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// feedURL builds the absolute feed address from the request, leaving the token out when empty
+func feedURL(r *http.Request, groupID int, token string) string {
+	url := fmt.Sprintf("%s/groups/%d/feed.atom", baseURL(r), groupID)
+	if token != "" {
+		url += "?token=" + token
+	}
+	return url
+}
+
+// baseURL is the scheme and host the caller reached the API on
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	} else if proto := r.Header.Get("X-Forwarded-Proto"); config.AppConfig.TrustProxy && proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -47,7 +48,7 @@ func GroupHandler(w http.ResponseWriter, r *http.Request) {
 	// Check if group exists
 	_, err = modules.RedisClient.GetGroup(id)
 	if err != nil {
-		http.Error(w, "Group not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "Group not found")
 		return
 	}
 
@@ -71,9 +72,39 @@ func GroupHandler(w http.ResponseWriter, r *http.Request) {
 	case "users":
 		handleGroupUsers(w, r, id, parts[2:])
 	case "tasks":
+		if len(parts) == 3 && parts[2] == "move" {
+			moveGroupTasks(w, r, id)
+			return
+		}
 		GetTasksByGroupHandler(w, r, id)
 	case "stats":
 		getGroupStats(w, r, id)
+	case "board":
+		getGroupBoard(w, r, id)
+	case "schedule":
+		getGroupSchedule(w, r, id)
+	case "calendar":
+		getGroupCalendar(w, r, id)
+	case "estimations":
+		handleGroupEstimations(w, r, id, parts[2:])
+	case "feed.atom":
+		getGroupFeed(w, r, id)
+	case "feed-token":
+		handleGroupFeedToken(w, r, id)
+	case "risks":
+		handleGroupRisks(w, r, id, parts[2:])
+	case "email-alias":
+		handleGroupEmailAlias(w, r, id)
+	case "extensions":
+		getGroupExtensions(w, r, id)
+	case "backlog":
+		handleGroupBacklog(w, r, id, parts[2:])
+	case "visible-tasks":
+		handleGroupVisibleTasks(w, r, id, parts[2:])
+	case "archive":
+		archiveGroup(w, r, id, true)
+	case "unarchive":
+		archiveGroup(w, r, id, false)
 	default:
 		http.Error(w, "Invalid sub-path", http.StatusBadRequest)
 	}
@@ -88,6 +119,17 @@ func getAllGroups(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Archived groups are only listed on request
+	if r.URL.Query().Get("include_archived") != "true" {
+		active := groups[:0]
+		for _, group := range groups {
+			if !group.Archived() {
+				active = append(active, group)
+			}
+		}
+		groups = active
+	}
+
 	// Filter groups based on permissions
 	var filteredGroups []*models.Group
 
@@ -136,26 +178,22 @@ func createGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Name == "" {
-		respondWithError(w, "Group name is required", http.StatusBadRequest)
-		return
-	}
+	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
 
-	if req.AdminID == 0 {
-		respondWithError(w, "Admin ID is required", http.StatusBadRequest)
-		return
+	v := newValidator()
+	v.required(req.Name, "name")
+	v.check(req.AdminID != 0, "admin_id", "required")
+	if req.Code != "" {
+		checkGroupCode(v, req.Code, 0)
 	}
 
 	// Check if admin user exists and is eligible
-	admin, err := modules.RedisClient.GetUser(req.AdminID)
-	if err != nil {
-		respondWithError(w, "Admin user not found", http.StatusBadRequest)
-		return
+	var admin *models.User
+	if req.AdminID != 0 {
+		admin = checkGroupAdmin(v, req.AdminID)
 	}
-
-	// Admin must be at least 'group_admin' or 'owner' role
-	if admin.Role != "group_admin" && admin.Role != "owner" {
-		respondWithError(w, "Selected user must have 'group_admin' or 'owner' role", http.StatusBadRequest)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
 		return
 	}
 
@@ -178,6 +216,7 @@ func createGroup(w http.ResponseWriter, r *http.Request) {
 	group := &models.Group{
 		ID:        groupID,
 		Name:      req.Name,
+		Code:      req.Code,
 		AdminID:   req.AdminID,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
@@ -209,7 +248,7 @@ func createGroup(w http.ResponseWriter, r *http.Request) {
 func getGroup(w http.ResponseWriter, r *http.Request, id int) {
 	group, err := modules.RedisClient.GetGroup(id)
 	if err != nil {
-		respondWithError(w, "Group not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "Group not found")
 		return
 	}
 
@@ -226,11 +265,16 @@ func getGroup(w http.ResponseWriter, r *http.Request, id int) {
 		"id":          group.ID,
 		"name":        group.Name,
 		"admin_id":    group.AdminID,
+		"version":     group.Version,
 		"created_at":  group.CreatedAt,
 		"updated_at":  group.UpdatedAt,
 		"users_count": len(users),
 		"tasks_count": len(tasks),
 	}
+	if group.Archived() {
+		result["archived_at"] = group.ArchivedAt
+		result["archived_by"] = group.ArchivedBy
+	}
 
 	if admin != nil {
 		result["admin"] = map[string]interface{}{
@@ -240,6 +284,7 @@ func getGroup(w http.ResponseWriter, r *http.Request, id int) {
 		}
 	}
 
+	setETag(w, group.Version)
 	respondWithSuccess(w, result)
 }
 
@@ -260,7 +305,17 @@ func updateGroup(w http.ResponseWriter, r *http.Request, id int) {
 
 	group, err := modules.RedisClient.GetGroup(id)
 	if err != nil {
-		respondWithError(w, "Group not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "Group not found")
+		return
+	}
+
+	expectedVersion, checkVersion, err := parseIfMatch(r)
+	if err != nil {
+		respondWithError(w, "Invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+	if checkVersion && expectedVersion != group.Version {
+		respondWithConflict(w, "Group was modified by another request", group, group.Version)
 		return
 	}
 
@@ -277,16 +332,26 @@ func updateGroup(w http.ResponseWriter, r *http.Request, id int) {
 		group.Name = req.Name
 	}
 
-	if req.AdminID != 0 && req.AdminID != group.AdminID {
-		// Validate new admin
-		newAdmin, err := modules.RedisClient.GetUser(req.AdminID)
-		if err != nil {
-			respondWithError(w, "New admin user not found", http.StatusBadRequest)
-			return
+	// A new code applies to tasks created from now on; existing keys stay as they are
+	if req.Code != nil {
+		code := strings.ToUpper(strings.TrimSpace(*req.Code))
+		if code != "" && code != group.Code {
+			v := newValidator()
+			checkGroupCode(v, code, id)
+			if !v.valid() {
+				respondWithValidationErrors(w, r, v)
+				return
+			}
 		}
+		group.Code = code
+	}
 
-		if newAdmin.Role != "group_admin" && newAdmin.Role != "owner" {
-			respondWithError(w, "New admin must have 'group_admin' or 'owner' role", http.StatusBadRequest)
+	if req.AdminID != 0 && req.AdminID != group.AdminID {
+		// Validate new admin
+		v := newValidator()
+		newAdmin := checkGroupAdmin(v, req.AdminID)
+		if !v.valid() {
+			respondWithValidationErrors(w, r, v)
 			return
 		}
 
@@ -325,8 +390,19 @@ func updateGroup(w http.ResponseWriter, r *http.Request, id int) {
 
 	group.UpdatedAt = time.Now()
 
-	// Save group
-	if err := modules.RedisClient.SaveGroup(group); err != nil {
+	// Save group, rejecting the update if another request changed it first
+	if checkVersion {
+		err = modules.RedisClient.SaveGroupIfVersion(group, expectedVersion)
+	} else {
+		err = modules.RedisClient.SaveGroup(group)
+	}
+	if errors.Is(err, modules.ErrVersionConflict) {
+		if latest, err := modules.RedisClient.GetGroup(id); err == nil {
+			respondWithConflict(w, "Group was modified by another request", latest, latest.Version)
+			return
+		}
+	}
+	if err != nil {
 		respondWithError(w, "Failed to update group", http.StatusInternalServerError)
 		return
 	}
@@ -334,6 +410,7 @@ func updateGroup(w http.ResponseWriter, r *http.Request, id int) {
 	// Mark data as dirty for sync
 	modules.RedisClient.MarkDirty("groups")
 
+	setETag(w, group.Version)
 	respondWithSuccess(w, map[string]interface{}{
 		"message": "Group updated successfully",
 		"group":   group,
@@ -351,7 +428,7 @@ func deleteGroup(w http.ResponseWriter, r *http.Request, id int) {
 
 	group, err := modules.RedisClient.GetGroup(id)
 	if err != nil {
-		respondWithError(w, "Group not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "Group not found")
 		return
 	}
 
@@ -376,6 +453,15 @@ func deleteGroup(w http.ResponseWriter, r *http.Request, id int) {
 		modules.RedisClient.DeleteTask(task.ID)
 	}
 
+	// Stop accepting mail for the group
+	modules.RedisClient.DeleteGroupEmailAlias(id)
+
+	// Delete the group's risk register
+	risks, _ := modules.RedisClient.GetGroupRisks(id)
+	for _, risk := range risks {
+		modules.RedisClient.DeleteRisk(risk.ID)
+	}
+
 	// Delete group
 	if err := modules.RedisClient.DeleteGroup(id); err != nil {
 		respondWithError(w, "Failed to delete group", http.StatusInternalServerError)
@@ -386,6 +472,9 @@ func deleteGroup(w http.ResponseWriter, r *http.Request, id int) {
 	modules.RedisClient.MarkDirty("groups")
 	modules.RedisClient.MarkDirty("users")
 	modules.RedisClient.MarkDirty("tasks")
+	if len(risks) > 0 {
+		modules.RedisClient.MarkDirty("risks")
+	}
 
 	respondWithSuccess(w, map[string]interface{}{
 		"message":        "Group deleted successfully",
@@ -395,6 +484,64 @@ func deleteGroup(w http.ResponseWriter, r *http.Request, id int) {
 	})
 }
 
+// archiveGroup handles POST /groups/{id}/archive and /unarchive. The owner
+// or the group's admin archives a group once all its tasks are closed,
+// making them read-only, and unarchives it to change them again.
+func archiveGroup(w http.ResponseWriter, r *http.Request, id int, archive bool) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if !administersGroup(authCtx, id) {
+		respondWithError(w, "Only owner or group admin can archive groups", http.StatusForbidden)
+		return
+	}
+
+	var group *models.Group
+	var err error
+	if archive {
+		userID := 0
+		if authCtx.User != nil {
+			userID = authCtx.User.ID
+		}
+		group, err = modules.RedisClient.ArchiveGroup(id, userID)
+	} else {
+		group, err = modules.RedisClient.UnarchiveGroup(id)
+	}
+	if errors.Is(err, modules.ErrGroupHasOpenTasks) {
+		respondWithError(w, "Close or move the group's open tasks before archiving it", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to archive group")
+		return
+	}
+
+	message := "Group archived"
+	if !archive {
+		message = "Group unarchived"
+	}
+	handlerLog.InfoContext(r.Context(), message, "group_id", id)
+	setETag(w, group.Version)
+	respondWithSuccess(w, map[string]interface{}{
+		"message": message,
+		"group":   group,
+	})
+}
+
+// groupFrozen responds with a conflict and reports true when a task change
+// would touch an archived group
+func groupFrozen(w http.ResponseWriter, r *http.Request, groupIDs ...int) bool {
+	err := modules.RedisClient.CheckGroupWritable(groupIDs...)
+	if err == nil {
+		return false
+	}
+	respondWithDomainError(w, r, err, "Group is archived, its tasks are read-only")
+	return true
+}
+
 func handleGroupUsers(w http.ResponseWriter, r *http.Request, groupID int, remainingParts []string) {
 	if len(remainingParts) == 0 {
 		// /groups/{id}/users
@@ -409,6 +556,16 @@ func handleGroupUsers(w http.ResponseWriter, r *http.Request, groupID int, remai
 		return
 	}
 
+	if len(remainingParts) == 1 && remainingParts[0] == "batch" {
+		// /groups/{id}/users/batch
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		batchGroupUsers(w, r, groupID)
+		return
+	}
+
 	if len(remainingParts) == 1 {
 		userIDStr := remainingParts[0]
 		userID, err := strconv.Atoi(userIDStr)
@@ -434,19 +591,7 @@ func getGroupUsers(w http.ResponseWriter, r *http.Request, groupID int) {
 		return
 	}
 
-	// Remove passwords from response
-	var safeUsers []map[string]interface{}
-	for _, user := range users {
-		safeUser := map[string]interface{}{
-			"id":         user.ID,
-			"full_name":  user.FullName,
-			"role":       user.Role,
-			"email":      user.Email,
-			"number":     user.Number,
-			"created_at": user.CreatedAt,
-		}
-		safeUsers = append(safeUsers, safeUser)
-	}
+	safeUsers := userResponses(modules.GetAuthContext(r), users)
 
 	respondWithSuccess(w, map[string]interface{}{
 		"group_id": groupID,
@@ -483,15 +628,18 @@ func addUserToGroup(w http.ResponseWriter, r *http.Request, groupID int) {
 		return
 	}
 
-	if req.UserID == 0 {
-		respondWithError(w, "User ID is required", http.StatusBadRequest)
+	v := newValidator()
+	v.check(req.UserID != 0, "user_id", "required")
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
 		return
 	}
 
 	// Check if user exists
 	user, err := modules.RedisClient.GetUser(req.UserID)
 	if err != nil {
-		respondWithError(w, "User not found", http.StatusBadRequest)
+		v.check(false, "user_id", "not_found")
+		respondWithValidationErrors(w, r, v)
 		return
 	}
 
@@ -569,8 +717,189 @@ func removeUserFromGroup(w http.ResponseWriter, r *http.Request, groupID, userID
 		return
 	}
 
+	affectedTasks := releaseGroupTasks(user, groupID)
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("users")
+	if affectedTasks > 0 {
+		modules.RedisClient.MarkDirty("tasks")
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":        "User removed from group successfully",
+		"group_id":       groupID,
+		"user_id":        userID,
+		"affected_tasks": affectedTasks,
+	})
+}
+
+// maxGroupUsersBatch bounds how many users one membership batch may list
+const maxGroupUsersBatch = 500
+
+// membershipResult is the outcome of adding or removing one user in a batch
+type membershipResult struct {
+	UserID        int    `json:"user_id"`
+	Action        string `json:"action"`
+	Status        string `json:"status"`
+	AffectedTasks int    `json:"affected_tasks,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// batchGroupUsers handles POST /groups/{id}/users/batch, adding and removing
+// many members in one call. Each user gets their own result, so one that
+// fails does not stop the rest; the change is marked for sync and logged
+// once for the whole batch.
+func batchGroupUsers(w http.ResponseWriter, r *http.Request, groupID int) {
+	authCtx := modules.GetAuthContext(r)
+	if !administersGroup(authCtx, groupID) {
+		respondWithError(w, "Insufficient permissions to manage users of this group", http.StatusForbidden)
+		return
+	}
+
+	var req models.GroupUsersBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	total := len(req.Add) + len(req.Remove)
+	v.check(total > 0, "users", "batch_empty")
+	v.check(total <= maxGroupUsersBatch, "users", "between", 1, maxGroupUsersBatch)
+	listed := make(map[int]bool, total)
+	for _, userID := range append(append([]int{}, req.Add...), req.Remove...) {
+		v.check(!listed[userID], "users", "duplicate_user", userID)
+		listed[userID] = true
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	results := make([]membershipResult, 0, total)
+	added, removed, failed, affectedTasks := 0, 0, 0, 0
+	for _, userID := range req.Add {
+		result := addGroupMember(groupID, userID)
+		if result.Status == "added" {
+			added++
+		} else if result.Error != "" {
+			failed++
+		}
+		results = append(results, result)
+	}
+	for _, userID := range req.Remove {
+		result := removeGroupMember(groupID, userID)
+		if result.Status == "removed" {
+			removed++
+			affectedTasks += result.AffectedTasks
+		} else if result.Error != "" {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	// Mark data as dirty for sync
+	if added+removed > 0 {
+		modules.RedisClient.MarkDirty("users")
+	}
+	if affectedTasks > 0 {
+		modules.RedisClient.MarkDirty("tasks")
+	}
+
+	summary := map[string]interface{}{
+		"group_id":       groupID,
+		"added":          added,
+		"removed":        removed,
+		"unchanged":      total - added - removed - failed,
+		"failed":         failed,
+		"affected_tasks": affectedTasks,
+	}
+	actorID := 0
+	if authCtx.User != nil {
+		actorID = authCtx.User.ID
+	}
+	handlerLog.InfoContext(r.Context(), "Group memberships updated",
+		"group_id", groupID,
+		"actor_user_id", actorID,
+		"added", added,
+		"removed", removed,
+		"failed", failed,
+		"affected_tasks", affectedTasks,
+	)
+	modules.Events.Publish(r.Context(), "group.members_updated", actorID, groupID, summary)
+
+	summary["results"] = results
+	respondWithSuccess(w, summary)
+}
+
+// addGroupMember adds one user of a batch to the group
+func addGroupMember(groupID, userID int) membershipResult {
+	result := membershipResult{UserID: userID, Action: "add"}
+	user, err := modules.RedisClient.GetUser(userID)
+	if err != nil {
+		result.Status, result.Error = "failed", "user not found"
+		return result
+	}
+	if user.Deactivated {
+		result.Status, result.Error = "failed", "user is deactivated"
+		return result
+	}
+	for _, userGroupID := range user.GroupIDs {
+		if userGroupID == groupID {
+			result.Status = "already_member"
+			return result
+		}
+	}
+
+	user.GroupIDs = append(user.GroupIDs, groupID)
+	if err := modules.RedisClient.SaveUser(user); err != nil {
+		result.Status, result.Error = "failed", "failed to save user"
+		return result
+	}
+	result.Status = "added"
+	return result
+}
+
+// removeGroupMember removes one user of a batch from the group, moving
+// their tasks there as removeUserFromGroup does
+func removeGroupMember(groupID, userID int) membershipResult {
+	result := membershipResult{UserID: userID, Action: "remove"}
+	user, err := modules.RedisClient.GetUser(userID)
+	if err != nil {
+		result.Status, result.Error = "failed", "user not found"
+		return result
+	}
+
+	found := false
+	var newGroupIDs []int
+	for _, userGroupID := range user.GroupIDs {
+		if userGroupID == groupID {
+			found = true
+		} else {
+			newGroupIDs = append(newGroupIDs, userGroupID)
+		}
+	}
+	if !found {
+		result.Status = "not_member"
+		return result
+	}
+
+	user.GroupIDs = models.IntSlice(newGroupIDs)
+	if err := modules.RedisClient.SaveUser(user); err != nil {
+		result.Status, result.Error = "failed", "failed to save user"
+		return result
+	}
+	result.Status = "removed"
+	result.AffectedTasks = releaseGroupTasks(user, groupID)
+	return result
+}
+
+// releaseGroupTasks moves the tasks a user just removed from a group had in
+// it to their first remaining group, or deletes them if none is left. It
+// returns how many tasks it touched.
+func releaseGroupTasks(user *models.User, groupID int) int {
 	// Optionally move or delete user's tasks in this group
-	tasks, _ := modules.RedisClient.GetUserTasks(userID)
+	tasks, _ := modules.RedisClient.GetUserTasks(user.ID)
 	var affectedTasks int
 	for _, task := range tasks {
 		if task.GroupID == groupID {
@@ -590,19 +919,7 @@ func removeUserFromGroup(w http.ResponseWriter, r *http.Request, groupID, userID
 			}
 		}
 	}
-
-	// Mark data as dirty for sync
-	modules.RedisClient.MarkDirty("users")
-	if affectedTasks > 0 {
-		modules.RedisClient.MarkDirty("tasks")
-	}
-
-	respondWithSuccess(w, map[string]interface{}{
-		"message":        "User removed from group successfully",
-		"group_id":       groupID,
-		"user_id":        userID,
-		"affected_tasks": affectedTasks,
-	})
+	return affectedTasks
 }
 
 func getGroupStats(w http.ResponseWriter, r *http.Request, groupID int) {
@@ -635,7 +952,7 @@ func getGroupStats(w http.ResponseWriter, r *http.Request, groupID int) {
 	// Get group info
 	group, err := modules.RedisClient.GetGroup(groupID)
 	if err != nil {
-		respondWithError(w, "Group not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "Group not found")
 		return
 	}
 
@@ -646,38 +963,35 @@ func getGroupStats(w http.ResponseWriter, r *http.Request, groupID int) {
 		return
 	}
 
-	// Get group tasks
-	tasks, err := modules.RedisClient.GetGroupTasks(groupID)
+	// Count from the group's task sets without reading the tasks
+	counts, err := modules.RedisClient.GetGroupTaskCounts(groupID)
 	if err != nil {
 		respondWithError(w, "Failed to get group tasks", http.StatusInternalServerError)
 		return
 	}
 
-	// Calculate stats
-	totalTasks := len(tasks)
-	completedTasks := 0
-	pendingTasks := 0
-	userTaskCounts := make(map[string]int)
-
-	for _, task := range tasks {
-		if task.Status {
-			completedTasks++
-		} else {
-			pendingTasks++
-		}
+	perGroup, err := modules.RedisClient.GetGroupTaskStats([]int{groupID})
+	if err != nil {
+		respondWithError(w, "Failed to get group tasks", http.StatusInternalServerError)
+		return
+	}
 
-		// Find user name for task count
-		for _, user := range users {
-			if user.ID == task.UserID {
-				userTaskCounts[user.FullName]++
-				break
-			}
+	userTaskCounts := make(map[string]int)
+	for _, user := range users {
+		if count := perGroup[groupID].ByUser[user.ID]; count > 0 {
+			userTaskCounts[user.FullName] += int(count)
 		}
 	}
 
 	completionRate := 0.0
-	if totalTasks > 0 {
-		completionRate = float64(completedTasks) / float64(totalTasks) * 100
+	if counts.Total-counts.Cancelled > 0 {
+		completionRate = float64(counts.Done) / float64(counts.Total-counts.Cancelled) * 100
+	}
+
+	risks, err := modules.RedisClient.GetGroupRisks(groupID)
+	if err != nil {
+		respondWithError(w, "Failed to get group risks", http.StatusInternalServerError)
+		return
 	}
 
 	stats := map[string]interface{}{
@@ -686,12 +1000,87 @@ func getGroupStats(w http.ResponseWriter, r *http.Request, groupID int) {
 			"name": group.Name,
 		},
 		"users_count":      len(users),
-		"total_tasks":      totalTasks,
-		"completed_tasks":  completedTasks,
-		"pending_tasks":    pendingTasks,
+		"total_tasks":      counts.Total,
+		"completed_tasks":  counts.Done,
+		"cancelled_tasks":  counts.Cancelled,
+		"pending_tasks":    counts.Open,
+		"overdue_tasks":    counts.Overdue,
 		"completion_rate":  completionRate,
 		"user_task_counts": userTaskCounts,
+		"risks":            riskSummary(risks),
 	}
 
 	respondWithSuccess(w, stats)
 }
+
+// getGroupBoard shows a group's open tasks by deadline and its recently
+// completed ones, read from the group's own task sets
+func getGroupBoard(w http.ResponseWriter, r *http.Request, groupID int) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if !administersGroup(authCtx, groupID) {
+		respondWithError(w, "Only the owner and the group's admin can view the board", http.StatusForbidden)
+		return
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > 500 {
+			respondWithError(w, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	board, err := modules.RedisClient.GetGroupBoard(groupID, limit)
+	if err != nil {
+		respondWithError(w, "Failed to load board", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"group_id": groupID,
+		"limit":    limit,
+		"board":    board,
+	})
+}
+
+// getGroupSchedule plans the group's open tasks forward from now
+// /groups/{id}/schedule, flagging those that end after their deadline
+func getGroupSchedule(w http.ResponseWriter, r *http.Request, groupID int) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if !administersGroup(authCtx, groupID) {
+		respondWithError(w, "Only the owner and the group's admin can view the schedule", http.StatusForbidden)
+		return
+	}
+
+	schedule, err := modules.RedisClient.ScheduleGroup(groupID, time.Now())
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to schedule tasks")
+		return
+	}
+	respondWithSuccess(w, schedule)
+}
+
+// checkGroupCode validates a task key code and that no other group uses it
+func checkGroupCode(v *validator, code string, groupID int) {
+	if !modules.GroupCodePattern.MatchString(code) {
+		v.check(false, "code", "invalid_code")
+		return
+	}
+
+	groups, _ := modules.RedisClient.GetAllGroups()
+	for _, group := range groups {
+		v.check(group.ID == groupID || group.Code != code, "code", "already_exists")
+	}
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+type holidayRequest struct {
+	Region string `json:"region"`
+	Date   string `json:"date"`
+	Name   string `json:"name"`
+}
+
+// HolidaysHandler handles /holidays and /holidays/{region}[/{date}]
+func HolidaysHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/holidays"), "/")
+	var parts []string
+	if path != "" {
+		parts = strings.Split(path, "/")
+	}
+
+	switch {
+	case len(parts) == 0:
+		// /holidays
+		switch r.Method {
+		case "GET":
+			getHolidays(w, r)
+		case "POST":
+			addHoliday(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 1 && parts[0] == "regions":
+		// /holidays/regions
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		getHolidayRegions(w, r)
+	case len(parts) == 1:
+		// /holidays/{region}
+		if r.Method != "PUT" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		uploadHolidays(w, r, parts[0])
+	case len(parts) == 2:
+		// /holidays/{region}/{date}
+		if r.Method != "DELETE" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		deleteHoliday(w, r, parts[0], parts[1])
+	default:
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+	}
+}
+
+// getHolidays lists the holidays of a region, the caller's own region by default
+func getHolidays(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	region := query.Get("region")
+	if region == "" {
+		if authCtx := modules.GetAuthContext(r); authCtx.User != nil {
+			region = modules.UserHolidayRegion(authCtx.User)
+		}
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	v := newValidator()
+	if fromStr := query.Get("from"); fromStr != "" {
+		parsed, err := time.Parse(modules.HolidayDateLayout, fromStr)
+		v.check(err == nil, "from", "invalid_date")
+		from = parsed
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		parsed, err := time.Parse(modules.HolidayDateLayout, toStr)
+		v.check(err == nil, "to", "invalid_date")
+		to = parsed
+	}
+	if v.valid() {
+		v.check(!to.Before(from), "to", "date_order", from.Format(modules.HolidayDateLayout))
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	holidays, err := modules.RedisClient.GetHolidays(region, from, to)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get holidays")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"region":   region,
+		"from":     from.Format(modules.HolidayDateLayout),
+		"to":       to.Format(modules.HolidayDateLayout),
+		"holidays": holidays,
+		"count":    len(holidays),
+	})
+}
+
+func getHolidayRegions(w http.ResponseWriter, r *http.Request) {
+	custom, err := modules.RedisClient.GetCustomHolidayRegions()
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get holiday regions")
+		return
+	}
+	sort.Strings(custom)
+
+	respondWithSuccess(w, map[string]interface{}{
+		"built_in": modules.BuiltInHolidayRegions(),
+		"custom":   custom,
+	})
+}
+
+// addHoliday adds or renames one custom holiday; region defaults to the organization calendar
+func addHoliday(w http.ResponseWriter, r *http.Request) {
+	var req holidayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Region == "" {
+		req.Region = modules.OrgHolidayRegion
+	}
+
+	v := newValidator()
+	v.check(modules.IsValidRegion(req.Region), "region", "invalid_region")
+	checkHoliday(v, "", req.Date, req.Name)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := modules.RedisClient.SetCustomHoliday(req.Region, req.Date, name); err != nil {
+		respondWithDomainError(w, r, err, "Failed to save holiday")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Holiday saved",
+		"holiday": &modules.Holiday{Date: req.Date, Name: name, Region: req.Region},
+	}, http.StatusCreated)
+}
+
+// uploadHolidays replaces a region's custom holidays with the uploaded list /holidays/{region}
+func uploadHolidays(w http.ResponseWriter, r *http.Request, region string) {
+	var req struct {
+		Holidays []holidayRequest `json:"holidays"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	v.check(modules.IsValidRegion(region), "region", "invalid_region")
+	holidays := make([]*modules.Holiday, 0, len(req.Holidays))
+	for _, holiday := range req.Holidays {
+		checkHoliday(v, "holidays", holiday.Date, holiday.Name)
+		holidays = append(holidays, &modules.Holiday{
+			Date:   holiday.Date,
+			Name:   strings.TrimSpace(holiday.Name),
+			Region: region,
+		})
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	if err := modules.RedisClient.ReplaceCustomHolidays(region, holidays); err != nil {
+		respondWithDomainError(w, r, err, "Failed to save holidays")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":  "Holidays replaced",
+		"region":   region,
+		"holidays": holidays,
+		"count":    len(holidays),
+	})
+}
+
+func deleteHoliday(w http.ResponseWriter, r *http.Request, region, date string) {
+	if err := modules.RedisClient.DeleteCustomHoliday(region, date); err != nil {
+		respondWithDomainError(w, r, err, "Holiday not found")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Holiday deleted",
+	})
+}
+
+// checkHoliday validates a holiday's date and name. Errors go to field, or
+// to "date" and "name" when field is empty.
+func checkHoliday(v *validator, field, date, name string) {
+	dateField, nameField := "date", "name"
+	if field != "" {
+		dateField, nameField = field, field
+	}
+
+	_, err := time.Parse(modules.HolidayDateLayout, date)
+	v.check(err == nil, dateField, "invalid_date")
+	v.required(name, nameField)
+}
+
+// groupHolidays lists the holidays between from and to that give members of
+// a group the day off, with the members each one applies to
+func groupHolidays(users []*models.User, from, to time.Time) []map[string]interface{} {
+	usersByRegion := make(map[string][]int)
+	for _, user := range users {
+		region := modules.UserHolidayRegion(user)
+		usersByRegion[region] = append(usersByRegion[region], user.ID)
+	}
+
+	type holidayKey struct{ date, name, region string }
+	byHoliday := make(map[holidayKey]map[string]interface{})
+	var result []map[string]interface{}
+	for region, userIDs := range usersByRegion {
+		holidays, err := modules.RedisClient.GetHolidays(region, from, to)
+		if err != nil {
+			continue
+		}
+
+		for _, holiday := range holidays {
+			// Organization holidays show up once for every region; merge them
+			key := holidayKey{holiday.Date, holiday.Name, holiday.Region}
+			entry, ok := byHoliday[key]
+			if !ok {
+				entry = map[string]interface{}{
+					"date":     holiday.Date,
+					"name":     holiday.Name,
+					"region":   holiday.Region,
+					"user_ids": []int{},
+				}
+				byHoliday[key] = entry
+				result = append(result, entry)
+			}
+			entry["user_ids"] = append(entry["user_ids"].([]int), userIDs...)
+		}
+	}
+
+	for _, entry := range result {
+		sort.Ints(entry["user_ids"].([]int))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i]["date"].(string) < result[j]["date"].(string)
+	})
+	return result
+}
@@ -0,0 +1,547 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+const (
+	maxImportFileSize = 5 << 20
+	maxImportRows     = 5000
+	importSampleRows  = 5
+)
+
+// importFields are the task fields a column can be mapped to
+var importFields = []string{"title", "information", "deadline", "priority", "status", "estimated_hours", "assignee"}
+
+// importColumnAliases suggests a field for common column names
+var importColumnAliases = map[string]string{
+	"name":        "title",
+	"summary":     "title",
+	"task":        "title",
+	"description": "information",
+	"notes":       "information",
+	"details":     "information",
+	"due":         "deadline",
+	"due_date":    "deadline",
+	"done":        "status",
+	"completed":   "status",
+	"estimate":    "estimated_hours",
+	"hours":       "estimated_hours",
+	"owner":       "assignee",
+	"assigned_to": "assignee",
+	"email":       "assignee",
+}
+
+var (
+	importDoneValues = map[string]bool{"done": true, "true": true, "yes": true, "1": true, "x": true, "completed": true}
+	importOpenValues = map[string]bool{"open": true, "false": true, "no": true, "0": true, "todo": true}
+)
+
+// ImportsHandler routes the two-step task import: POST /imports/tasks uploads
+// a CSV file, POST /imports/{id}/execute maps its columns and creates tasks
+func ImportsHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/imports/"), "/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) == 1 && parts[0] == "tasks" {
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		uploadTaskImport(w, r)
+		return
+	}
+
+	imp, err := modules.RedisClient.GetImport(parts[0])
+	if err != nil || !canUseImport(modules.GetAuthContext(r), imp) {
+		respondWithError(w, "Import not found", http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		// /imports/{id}
+		switch r.Method {
+		case "GET":
+			respondWithSuccess(w, importPreview(imp))
+		case "DELETE":
+			if err := modules.RedisClient.DeleteImport(imp.ID); err != nil {
+				respondWithError(w, "Failed to delete import", http.StatusInternalServerError)
+				return
+			}
+			respondWithSuccess(w, map[string]interface{}{
+				"message": "Import deleted successfully",
+			})
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "execute":
+		// /imports/{id}/execute
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		executeTaskImport(w, r, imp)
+	default:
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+	}
+}
+
+// uploadTaskImport reads a CSV file sent as the request body or as the
+// "file" part of a multipart form, and keeps it until it is executed
+func uploadTaskImport(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize)
+
+	var body io.Reader = r.Body
+	filename := ""
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			respondWithError(w, "Missing file: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+		filename = header.Filename
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		respondWithError(w, "Failed to read file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	imp, err := parseImportFile(data)
+	if err != nil {
+		respondWithError(w, "Invalid CSV: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	imp.Filename = filename
+	if authCtx := modules.GetAuthContext(r); authCtx.User != nil {
+		imp.CreatedBy = authCtx.User.ID
+	}
+
+	if err := modules.RedisClient.CreateImport(imp); err != nil {
+		respondWithError(w, "Failed to save import", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "File uploaded successfully",
+		"import":  importPreview(imp),
+	}, http.StatusCreated)
+}
+
+// parseImportFile splits a CSV file into its header and rows. The delimiter
+// is whichever of comma, semicolon or tab splits the header into the most
+// columns.
+func parseImportFile(data []byte) (*modules.Import, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	delimiter := ','
+	for _, candidate := range []rune{';', '\t'} {
+		if bytes.Count(header, []byte(string(candidate))) > bytes.Count(header, []byte(string(delimiter))) {
+			delimiter = candidate
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("file is empty")
+	}
+	if len(records) == 1 {
+		return nil, errors.New("file has no rows below the header")
+	}
+	if len(records)-1 > maxImportRows {
+		return nil, fmt.Errorf("file has more than %d rows", maxImportRows)
+	}
+
+	columns := make([]string, len(records[0]))
+	seen := make(map[string]bool)
+	for i, column := range records[0] {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			return nil, fmt.Errorf("column %d has no name", i+1)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("column %q appears twice", column)
+		}
+		seen[column] = true
+		columns[i] = column
+	}
+
+	return &modules.Import{
+		Delimiter: string(delimiter),
+		Columns:   columns,
+		Rows:      records[1:],
+	}, nil
+}
+
+// importPreview is what the client needs to build the column mapping
+func importPreview(imp *modules.Import) map[string]interface{} {
+	return map[string]interface{}{
+		"import_id":         imp.ID,
+		"filename":          imp.Filename,
+		"delimiter":         imp.Delimiter,
+		"columns":           imp.Columns,
+		"sample_rows":       imp.Rows[:min(len(imp.Rows), importSampleRows)],
+		"row_count":         len(imp.Rows),
+		"fields":            importFields,
+		"suggested_mapping": suggestImportMapping(imp.Columns),
+		"expires_at":        imp.ExpiresAt,
+	}
+}
+
+// suggestImportMapping maps columns named like a task field to that field
+func suggestImportMapping(columns []string) map[string]string {
+	mapping := make(map[string]string)
+	taken := make(map[string]bool)
+	for _, column := range columns {
+		name := strings.ReplaceAll(strings.ToLower(column), " ", "_")
+		field := importColumnAliases[name]
+		for _, importField := range importFields {
+			if name == importField {
+				field = importField
+			}
+		}
+
+		if field != "" && !taken[field] {
+			mapping[column] = field
+			taken[field] = true
+		}
+	}
+	return mapping
+}
+
+// executeTaskImport validates every row against the mapping and creates the
+// tasks. Nothing is created if a row is invalid, unless skip_invalid is set;
+// dry_run only validates.
+func executeTaskImport(w http.ResponseWriter, r *http.Request, imp *modules.Import) {
+	var req models.ExecuteImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.DateFormat == "" {
+		req.DateFormat = "YYYY-MM-DD"
+	}
+
+	v := newValidator()
+	v.check(req.GroupID != 0, "group_id", "required")
+	fieldColumns := checkImportMapping(v, imp, req.Mapping)
+	dateLayout, ok := importDateLayout(req.DateFormat)
+	v.check(ok, "date_format", "invalid_date_format")
+	for _, priority := range req.PriorityMap {
+		v.check(priority.Valid(), "priority_map", "invalid_choice", models.PriorityLabels)
+	}
+
+	var group *models.Group
+	if req.GroupID != 0 {
+		var err error
+		group, err = modules.RedisClient.GetGroup(req.GroupID)
+		v.check(err == nil, "group_id", "not_found")
+		if err == nil && req.DefaultUserID != 0 {
+			checkGroupMember(v, "default_user_id", req.DefaultUserID, group.ID)
+		}
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	if !administersGroup(modules.GetAuthContext(r), group.ID) {
+		respondWithError(w, "You can only import into groups you administer", http.StatusForbidden)
+		return
+	}
+	if groupFrozen(w, r, group.ID) {
+		return
+	}
+
+	defaultUserID := group.AdminID
+	if req.DefaultUserID != 0 {
+		defaultUserID = req.DefaultUserID
+	}
+	priorities := make(map[string]models.Priority, len(req.PriorityMap))
+	for value, priority := range req.PriorityMap {
+		priorities[strings.ToLower(strings.TrimSpace(value))] = priority
+	}
+
+	columnIndex := make(map[string]int, len(imp.Columns))
+	for i, column := range imp.Columns {
+		columnIndex[column] = i
+	}
+
+	lang := negotiateLanguage(r.Header.Get("Accept-Language"))
+	results := make([]models.ImportRowResult, len(imp.Rows))
+	tasks := make([]*models.Task, len(imp.Rows))
+	invalid := 0
+	for i, row := range imp.Rows {
+		value := func(field string) (string, string) {
+			column, mapped := fieldColumns[field]
+			if !mapped || columnIndex[column] >= len(row) {
+				return column, ""
+			}
+			return column, strings.TrimSpace(row[columnIndex[column]])
+		}
+
+		task, rowValidator := importTask(value, group.ID, defaultUserID, dateLayout, req.DateFormat, priorities)
+		results[i] = models.ImportRowResult{Row: i + 1, Status: models.ImportRowValid}
+		if !rowValidator.valid() {
+			results[i].Status = models.ImportRowInvalid
+			results[i].Errors = rowValidator.messages(lang)
+			invalid++
+			continue
+		}
+		tasks[i] = task
+	}
+
+	executed := !req.DryRun && (invalid == 0 || req.SkipInvalid)
+	created := 0
+	if executed {
+		for i, task := range tasks {
+			if task == nil {
+				continue
+			}
+			if err := saveImportedTask(task); err != nil {
+				handlerLog.WarnContext(r.Context(), "⚠️ Failed to import task", "import_id", imp.ID, "row", i+1, "error", err)
+				results[i].Status = models.ImportRowFailed
+				continue
+			}
+
+			results[i].Status = models.ImportRowCreated
+			results[i].TaskID = task.ID
+			created++
+			modules.Events.PublishTask(r.Context(), "task.created", task, task)
+		}
+
+		// Mark data as dirty for sync
+		if created > 0 {
+			modules.RedisClient.MarkDirty("tasks")
+		}
+
+		// An executed import is used up so it cannot create the tasks twice
+		if err := modules.RedisClient.DeleteImport(imp.ID); err != nil {
+			handlerLog.WarnContext(r.Context(), "⚠️ Failed to delete executed import", "import_id", imp.ID, "error", err)
+		}
+	}
+
+	summary := map[string]interface{}{
+		"import_id": imp.ID,
+		"group_id":  group.ID,
+		"executed":  executed,
+		"dry_run":   req.DryRun,
+		"rows":      len(imp.Rows),
+		"valid":     len(imp.Rows) - invalid,
+		"invalid":   invalid,
+		"created":   created,
+		"results":   results,
+	}
+
+	if !executed && !req.DryRun {
+		// Nothing was imported; report the rows to fix
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(models.APIResponse{
+			Success:   false,
+			Error:     fmt.Sprintf("%d of %d rows are invalid, nothing was imported", invalid, len(imp.Rows)),
+			Data:      summary,
+			RequestID: w.Header().Get(modules.RequestIDHeader),
+		})
+		return
+	}
+
+	summary["message"] = "Tasks imported successfully"
+	if req.DryRun {
+		summary["message"] = "Import validated successfully"
+	}
+	respondWithSuccess(w, summary)
+}
+
+// checkImportMapping validates a column→field mapping against the file and
+// returns it inverted, field→column. Columns mapped to "" are ignored.
+func checkImportMapping(v *validator, imp *modules.Import, mapping map[string]string) map[string]string {
+	v.check(len(mapping) > 0, "mapping", "required")
+
+	columns := make(map[string]bool, len(imp.Columns))
+	for _, column := range imp.Columns {
+		columns[column] = true
+	}
+	validFields := make(map[string]bool, len(importFields))
+	for _, field := range importFields {
+		validFields[field] = true
+	}
+
+	mappedColumns := make([]string, 0, len(mapping))
+	for column := range mapping {
+		mappedColumns = append(mappedColumns, column)
+	}
+	sort.Strings(mappedColumns)
+
+	fieldColumns := make(map[string]string)
+	for _, column := range mappedColumns {
+		field := mapping[column]
+		v.check(columns[column], "mapping", "unknown_column", column)
+		if field == "" {
+			continue
+		}
+		v.check(validFields[field], "mapping", "invalid_choice", strings.Join(importFields, ", "))
+		_, duplicate := fieldColumns[field]
+		v.check(!duplicate, "mapping", "duplicate_mapping", field)
+		fieldColumns[field] = column
+	}
+
+	if len(mapping) > 0 {
+		_, hasTitle := fieldColumns["title"]
+		v.check(hasTitle, "mapping", "title_unmapped")
+	}
+	return fieldColumns
+}
+
+// importDateLayout turns a date format such as DD/MM/YYYY into a time layout.
+// Day and month may be written with or without a leading zero.
+func importDateLayout(format string) (string, bool) {
+	for _, token := range []string{"YYYY", "MM", "DD"} {
+		if strings.Count(format, token) != 1 {
+			return "", false
+		}
+	}
+
+	separators := strings.NewReplacer("YYYY", "", "MM", "", "DD", "").Replace(format)
+	if strings.Trim(separators, "-/. ") != "" {
+		return "", false
+	}
+	return strings.NewReplacer("YYYY", "2006", "MM", "1", "DD", "2").Replace(format), true
+}
+
+// importTask builds the task for one row. value returns the column mapped to
+// a field and the row's value in it; errors are reported by column name.
+func importTask(value func(field string) (string, string), groupID, defaultUserID int, dateLayout, dateFormat string, priorities map[string]models.Priority) (*models.Task, *validator) {
+	v := newValidator()
+	task := &models.Task{
+		Priority: models.PriorityLow,
+		UserID:   defaultUserID,
+		GroupID:  groupID,
+	}
+
+	column, title := value("title")
+	v.required(title, column)
+	task.Title = title
+
+	_, task.Information = value("information")
+
+	if column, deadline := value("deadline"); deadline != "" {
+		date, err := time.Parse(dateLayout, deadline)
+		v.check(err == nil, column, "invalid_date_as", dateFormat)
+		task.Deadline = date.Format(models.LeaveDateLayout)
+	}
+
+	if column, priority := value("priority"); priority != "" {
+		if mapped, ok := priorities[strings.ToLower(priority)]; ok {
+			task.Priority = mapped
+		} else {
+			parsed, ok := models.ParsePriority(priority)
+			v.check(ok, column, "invalid_choice", models.PriorityLabels)
+			task.Priority = parsed
+		}
+	}
+
+	if column, status := value("status"); status != "" {
+		status = strings.ToLower(status)
+		v.check(importDoneValues[status] || importOpenValues[status], column, "invalid_choice", "done, yes, true, x, 1, open, no, false, todo, 0")
+		task.Status = importDoneValues[status]
+	}
+
+	if column, hours := value("estimated_hours"); hours != "" {
+		parsed, err := strconv.ParseFloat(hours, 64)
+		v.check(err == nil, column, "invalid_number")
+		v.check(parsed >= 0, column, "not_negative")
+		task.EstimatedHours = parsed
+	}
+
+	if column, assignee := value("assignee"); assignee != "" {
+		if user := importAssignee(assignee); user != nil {
+			checkGroupMember(v, column, user.ID, groupID)
+			task.UserID = user.ID
+		} else {
+			v.check(false, column, "not_found")
+		}
+	}
+
+	return task, v
+}
+
+// importAssignee finds a user by ID or email address
+func importAssignee(value string) *models.User {
+	if userID, err := strconv.Atoi(value); err == nil {
+		user, err := modules.RedisClient.GetUser(userID)
+		if err != nil {
+			return nil
+		}
+		return user
+	}
+
+	user, err := modules.RedisClient.FindUserByEmail(value)
+	if err != nil {
+		user, err = modules.RedisClient.FindUserByEmail(strings.ToLower(value))
+	}
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+func saveImportedTask(task *models.Task) error {
+	taskID, err := modules.RedisClient.GetNextTaskID()
+	if err != nil {
+		return err
+	}
+	task.ID = taskID
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = time.Now()
+	if err := modules.RedisClient.AssignTaskKey(task); err != nil {
+		return err
+	}
+	return modules.RedisClient.SaveTask(task)
+}
+
+// canUseImport reports whether the caller uploaded the import
+func canUseImport(authCtx *modules.AuthContext, imp *modules.Import) bool {
+	if authCtx.IsOwner {
+		return true
+	}
+	return authCtx.User != nil && authCtx.User.ID == imp.CreatedBy
+}
+
+// administersGroup reports whether the caller may manage the group's tasks
+func administersGroup(authCtx *modules.AuthContext, groupID int) bool {
+	if authCtx.IsOwner {
+		return true
+	}
+	for _, adminGroupID := range authCtx.AdminGroupIDs {
+		if adminGroupID == groupID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/mail"
+	"strings"
+	"task-manager/config"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+// Limits on what one inbound email may put into a task
+const (
+	maxInboundBodySize  = 1 << 20
+	maxInboundTitle     = 200
+	maxInboundInfoRunes = 10000
+)
+
+// inboundEmail is a message as the email gateway posts it to /inbound/email
+type inboundEmail struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text"`
+}
+
+// handleGroupEmailAlias manages the group's inbound address /groups/{id}/email-alias:
+// GET shows it, POST issues a new one and DELETE stops accepting mail
+func handleGroupEmailAlias(w http.ResponseWriter, r *http.Request, groupID int) {
+	switch r.Method {
+	case "GET":
+		alias, err := modules.RedisClient.GetGroupEmailAlias(groupID)
+		if err != nil {
+			respondWithDomainError(w, r, err, "Failed to get email alias")
+			return
+		}
+		response := map[string]interface{}{
+			"group_id": groupID,
+			"enabled":  alias != "" && modules.InboundEmailEnabled(),
+		}
+		if alias != "" {
+			response["address"] = modules.EmailAliasAddress(alias)
+		}
+		respondWithSuccess(w, response)
+	case "POST":
+		if !modules.InboundEmailEnabled() {
+			v := newValidator()
+			v.check(false, "inbound_email", "not_configured")
+			respondWithValidationErrors(w, r, v)
+			return
+		}
+		alias, err := modules.RedisClient.RotateGroupEmailAlias(groupID)
+		if err != nil {
+			respondWithDomainError(w, r, err, "Failed to create email alias")
+			return
+		}
+		respondWithSuccess(w, map[string]interface{}{
+			"message": "Email alias created",
+			"address": modules.EmailAliasAddress(alias),
+		}, http.StatusCreated)
+	case "DELETE":
+		if err := modules.RedisClient.DeleteGroupEmailAlias(groupID); err != nil {
+			respondWithDomainError(w, r, err, "Failed to delete email alias")
+			return
+		}
+		respondWithSuccess(w, map[string]interface{}{
+			"message": "Email alias deleted",
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// InboundEmailHandler handles /inbound/email, where the email gateway posts
+// received messages. Each recipient that is a group alias gets a task.
+// Unknown recipients are reported back but not treated as errors, so the
+// gateway does not retry them.
+func InboundEmailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !modules.InboundEmailEnabled() {
+		respondWithError(w, "Inbound email is not configured", http.StatusNotFound)
+		return
+	}
+	if !modules.ValidInboundSecret(r.Header.Get("X-Inbound-Secret")) {
+		respondWithError(w, "Invalid inbound secret", http.StatusUnauthorized)
+		return
+	}
+
+	var msg inboundEmail
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxInboundBodySize)).Decode(&msg); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sender, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		respondWithError(w, "Invalid sender address", http.StatusBadRequest)
+		return
+	}
+
+	taskIDs := []int{}
+	ignored := []string{}
+	for _, recipient := range msg.To {
+		groupID, ok := inboundGroup(recipient)
+		if !ok {
+			ignored = append(ignored, recipient)
+			continue
+		}
+
+		task, err := createInboundTask(groupID, sender, &msg)
+		if err != nil {
+			respondWithDomainError(w, r, err, "Failed to create task")
+			return
+		}
+
+		modules.Events.PublishTask(r.Context(), "task.created", task, task)
+		taskIDs = append(taskIDs, task.ID)
+	}
+
+	if len(taskIDs) > 0 {
+		// Mark data as dirty for sync
+		modules.RedisClient.MarkDirty("tasks")
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"task_ids": taskIDs,
+		"ignored":  ignored,
+	})
+}
+
+// inboundGroup finds the group a recipient address is the alias of
+func inboundGroup(recipient string) (int, bool) {
+	address, err := mail.ParseAddress(recipient)
+	if err != nil {
+		return 0, false
+	}
+
+	alias, domain, ok := strings.Cut(address.Address, "@")
+	if !ok || !strings.EqualFold(domain, config.AppConfig.InboundEmailDomain) {
+		return 0, false
+	}
+
+	groupID, err := modules.RedisClient.GroupForEmailAlias(alias)
+	if err != nil {
+		return 0, false
+	}
+	// Mail to an archived group is ignored like mail to no group
+	if group, err := modules.RedisClient.GetGroup(groupID); err != nil || group.Archived() {
+		return 0, false
+	}
+	return groupID, true
+}
+
+// createInboundTask turns a message into a task of the group. A sender who
+// is a member gets the task; anyone else is recorded as the external
+// reporter and the task goes to the group admin.
+func createInboundTask(groupID int, sender *mail.Address, msg *inboundEmail) (*models.Task, error) {
+	group, err := modules.RedisClient.GetGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	title := truncateRunes(strings.TrimSpace(msg.Subject), maxInboundTitle)
+	if title == "" {
+		title = "(no subject)"
+	}
+
+	information := strings.TrimSpace(msg.Text)
+	userID := group.AdminID
+	if member := inboundMember(sender.Address, groupID); member != nil {
+		userID = member.ID
+	} else {
+		reporter := "Reported by " + sender.String() + " via email"
+		if information != "" {
+			reporter += "\n\n" + information
+		}
+		information = reporter
+	}
+
+	taskID, err := modules.RedisClient.GetNextTaskID()
+	if err != nil {
+		return nil, err
+	}
+
+	task := &models.Task{
+		ID:          taskID,
+		Title:       title,
+		Priority:    models.PriorityLow,
+		Information: truncateRunes(information, maxInboundInfoRunes),
+		UserID:      userID,
+		GroupID:     groupID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := modules.RedisClient.AssignTaskKey(task); err != nil {
+		return nil, err
+	}
+	if err := modules.RedisClient.SaveTask(task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// inboundMember returns the group member with the sender's address, if any
+func inboundMember(address string, groupID int) *models.User {
+	user, err := modules.RedisClient.GetUserByEmail(address)
+	if err != nil {
+		user, err = modules.RedisClient.GetUserByEmail(strings.ToLower(address))
+	}
+	if err != nil {
+		return nil
+	}
+
+	for _, userGroupID := range user.GroupIDs {
+		if userGroupID == groupID {
+			return user
+		}
+	}
+	return nil
+}
+
+func truncateRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+// maxIntakeBodySize caps what a public intake submission may upload
+const maxIntakeBodySize = 64 << 10
+
+var intakeFieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// intakeTargets are the task fields an intake form field can fill
+var intakeTargets = map[string]bool{
+	"title":       true,
+	"information": true,
+	"deadline":    true,
+	"priority":    true,
+}
+
+// IntakeFormsHandler handles /intake-forms endpoint
+func IntakeFormsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		getAllIntakeForms(w, r)
+	case "POST":
+		createIntakeForm(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// IntakeFormHandler handles /intake-forms/{id}, its token and its submissions log
+func IntakeFormHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/intake-forms/")
+	parts := strings.Split(path, "/")
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid intake form ID", http.StatusBadRequest)
+		return
+	}
+
+	form, err := modules.RedisClient.GetIntakeForm(id)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Intake form not found")
+		return
+	}
+
+	if len(parts) == 1 {
+		// /intake-forms/{id}
+		switch r.Method {
+		case "GET":
+			getIntakeForm(w, r, form)
+		case "PUT":
+			updateIntakeForm(w, r, form)
+		case "DELETE":
+			deleteIntakeForm(w, r, form)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if len(parts) != 2 {
+		http.Error(w, "Invalid sub-path", http.StatusBadRequest)
+		return
+	}
+
+	switch parts[1] {
+	case "token":
+		// /intake-forms/{id}/token
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rotateIntakeFormToken(w, r, form)
+	case "submissions":
+		// /intake-forms/{id}/submissions
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		getIntakeSubmissions(w, r, form)
+	default:
+		http.Error(w, "Invalid sub-path", http.StatusBadRequest)
+	}
+}
+
+func getAllIntakeForms(w http.ResponseWriter, r *http.Request) {
+	forms, err := modules.RedisClient.GetAllIntakeForms()
+	if err != nil {
+		respondWithError(w, fmt.Sprintf("Failed to get intake forms: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"forms": forms,
+		"count": len(forms),
+	})
+}
+
+func getIntakeForm(w http.ResponseWriter, r *http.Request, form *models.IntakeForm) {
+	hasToken, err := modules.RedisClient.HasIntakeFormToken(form.ID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get intake form")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"form":      form,
+		"has_token": hasToken,
+	})
+}
+
+// createIntakeForm defines a new form. Submissions go to assignee_id, the
+// group admin by default; they open once a token is issued.
+func createIntakeForm(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateIntakeFormRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	v.required(req.Name, "name")
+	group, err := modules.RedisClient.GetGroup(req.GroupID)
+	v.check(err == nil, "group_id", "not_found")
+	if err == nil && req.AssigneeID == 0 {
+		req.AssigneeID = group.AdminID
+	}
+	if err == nil {
+		checkGroupMember(v, "assignee_id", req.AssigneeID, req.GroupID)
+	}
+	fields := checkIntakeFields(v, req.Fields)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	formID, err := modules.RedisClient.GetNextIntakeFormID()
+	if err != nil {
+		respondWithError(w, "Failed to generate intake form ID", http.StatusInternalServerError)
+		return
+	}
+
+	form := &models.IntakeForm{
+		ID:          formID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		GroupID:     req.GroupID,
+		AssigneeID:  req.AssigneeID,
+		Fields:      fields,
+		Enabled:     true,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := modules.RedisClient.SaveIntakeForm(form); err != nil {
+		respondWithError(w, "Failed to save intake form", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Intake form created successfully",
+		"form":    form,
+	}, http.StatusCreated)
+}
+
+func updateIntakeForm(w http.ResponseWriter, r *http.Request, form *models.IntakeForm) {
+	var req models.UpdateIntakeFormRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	if req.Name != "" {
+		form.Name = strings.TrimSpace(req.Name)
+	}
+	if req.Description != nil {
+		form.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.AssigneeID != 0 {
+		checkGroupMember(v, "assignee_id", req.AssigneeID, form.GroupID)
+		form.AssigneeID = req.AssigneeID
+	}
+	if req.Fields != nil {
+		form.Fields = checkIntakeFields(v, req.Fields)
+	}
+	if req.Enabled != nil {
+		form.Enabled = *req.Enabled
+	}
+	v.required(form.Name, "name")
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	form.UpdatedAt = time.Now()
+	if err := modules.RedisClient.SaveIntakeForm(form); err != nil {
+		respondWithError(w, "Failed to update intake form", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Intake form updated successfully",
+		"form":    form,
+	})
+}
+
+func deleteIntakeForm(w http.ResponseWriter, r *http.Request, form *models.IntakeForm) {
+	if err := modules.RedisClient.DeleteIntakeForm(form.ID); err != nil {
+		respondWithDomainError(w, r, err, "Failed to delete intake form")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Intake form deleted successfully",
+	})
+}
+
+// rotateIntakeFormToken issues the form's public submission token, replacing
+// the previous one. The token is only shown in this response.
+func rotateIntakeFormToken(w http.ResponseWriter, r *http.Request, form *models.IntakeForm) {
+	token, err := modules.RedisClient.RotateIntakeFormToken(form.ID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to issue intake form token")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":    "Intake form token issued",
+		"token":      token,
+		"intake_url": baseURL(r) + "/intake/" + token,
+	}, http.StatusCreated)
+}
+
+func getIntakeSubmissions(w http.ResponseWriter, r *http.Request, form *models.IntakeForm) {
+	submissions, err := modules.RedisClient.GetIntakeSubmissions(form.ID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get submissions")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"form_id":     form.ID,
+		"submissions": submissions,
+		"count":       len(submissions),
+	})
+}
+
+// IntakeHandler serves public intake forms at /intake/{token}: GET describes
+// the form and POST submits it as JSON or as a regular HTML form post
+func IntakeHandler(w http.ResponseWriter, r *http.Request) {
+	token := strings.Trim(strings.TrimPrefix(r.URL.Path, "/intake/"), "/")
+	if token == "" || strings.Contains(token, "/") {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+
+	form, err := modules.RedisClient.IntakeFormByToken(token)
+	if err != nil || !form.Enabled {
+		respondWithError(w, "Form not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		respondWithSuccess(w, map[string]interface{}{
+			"name":        form.Name,
+			"description": form.Description,
+			"fields":      form.Fields,
+		})
+	case "POST":
+		submitIntakeForm(w, r, form)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func submitIntakeForm(w http.ResponseWriter, r *http.Request, form *models.IntakeForm) {
+	submission := &models.IntakeSubmission{
+		At:      time.Now(),
+		IP:      modules.ClientIP(r),
+		Outcome: models.IntakeRejected,
+	}
+	defer func() {
+		if err := modules.RedisClient.LogIntakeSubmission(form.ID, submission); err != nil {
+			handlerLog.WarnContext(r.Context(), "⚠️ Failed to log intake submission", "form_id", form.ID, "error", err)
+		}
+	}()
+
+	if result, throttled := modules.Limiter.TakeIntakeSubmission(form.ID, submission.IP); throttled {
+		submission.Outcome = models.IntakeThrottled
+		seconds := int((result.RetryAfter + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+		return
+	}
+
+	values, err := readIntakeValues(w, r)
+	if err != nil {
+		submission.Error = err.Error()
+		respondWithError(w, "Invalid submission: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group, err := modules.RedisClient.GetGroup(form.GroupID)
+	if err != nil {
+		submission.Error = "group no longer exists"
+		respondWithError(w, "Form not found", http.StatusNotFound)
+		return
+	}
+	if group.Archived() {
+		submission.Error = "group is archived"
+		respondWithError(w, "Form not found", http.StatusNotFound)
+		return
+	}
+
+	task := &models.Task{
+		Priority: models.PriorityLow,
+		UserID:   form.AssigneeID,
+		GroupID:  group.ID,
+	}
+	if _, err := modules.RedisClient.GetUser(form.AssigneeID); err != nil {
+		task.UserID = group.AdminID
+	}
+
+	v := newValidator()
+	var information []string
+	for _, field := range form.Fields {
+		value := strings.TrimSpace(values[field.Name])
+		if value == "" {
+			v.check(!field.Required, field.Name, "required")
+			continue
+		}
+
+		switch field.MapsTo {
+		case "title":
+			task.Title = value
+		case "deadline":
+			_, err := time.Parse(models.LeaveDateLayout, value)
+			v.check(err == nil, field.Name, "invalid_date")
+			task.Deadline = value
+		case "priority":
+			priority, ok := models.ParsePriority(value)
+			v.check(ok, field.Name, "invalid_choice", models.PriorityLabels)
+			task.Priority = priority
+		case "information":
+			information = append(information, field.Label+": "+value)
+		}
+	}
+	if !v.valid() {
+		submission.Error = "validation failed"
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+	task.Information = strings.Join(information, "\n")
+
+	taskID, err := modules.RedisClient.GetNextTaskID()
+	if err != nil {
+		submission.Error = "failed to create task"
+		respondWithError(w, "Failed to submit form", http.StatusInternalServerError)
+		return
+	}
+	task.ID = taskID
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = time.Now()
+
+	if err := modules.RedisClient.AssignTaskKey(task); err != nil {
+		submission.Error = "failed to create task"
+		respondWithError(w, "Failed to submit form", http.StatusInternalServerError)
+		return
+	}
+
+	if err := modules.RedisClient.SaveTask(task); err != nil {
+		submission.Error = "failed to create task"
+		respondWithError(w, "Failed to submit form", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("tasks")
+
+	modules.Events.PublishTask(r.Context(), "task.created", task, task)
+
+	submission.Outcome = models.IntakeAccepted
+	submission.TaskID = task.ID
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":   "Submission received",
+		"reference": task.ID,
+	}, http.StatusCreated)
+}
+
+// readIntakeValues reads a submission sent as a JSON object or as form data
+func readIntakeValues(w http.ResponseWriter, r *http.Request) (map[string]string, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxIntakeBodySize)
+
+	values := make(map[string]string)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		for name, value := range body {
+			switch value := value.(type) {
+			case string:
+				values[name] = value
+			case float64:
+				values[name] = strconv.FormatFloat(value, 'f', -1, 64)
+			}
+		}
+		return values, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	for name := range r.PostForm {
+		values[name] = r.PostForm.Get(name)
+	}
+	return values, nil
+}
+
+// checkIntakeFields validates a form's fields and fills in missing labels.
+// A form needs a required title field and can fill the deadline and
+// priority at most once each.
+func checkIntakeFields(v *validator, fields []models.IntakeField) []models.IntakeField {
+	v.check(len(fields) > 0, "fields", "required")
+
+	names := make(map[string]bool)
+	targets := make(map[string]int)
+	result := make([]models.IntakeField, 0, len(fields))
+	for _, field := range fields {
+		field.Name = strings.TrimSpace(field.Name)
+		field.Label = strings.TrimSpace(field.Label)
+		if field.Label == "" {
+			field.Label = field.Name
+		}
+
+		v.check(intakeFieldNamePattern.MatchString(field.Name), "fields", "invalid_field_name", field.Name)
+		v.check(!names[field.Name], "fields", "duplicate_field", field.Name)
+		v.check(intakeTargets[field.MapsTo], "fields", "invalid_choice", "title, information, deadline, priority")
+		if field.MapsTo == "title" && !field.Required {
+			targets["optional_title"]++
+		}
+
+		names[field.Name] = true
+		targets[field.MapsTo]++
+		result = append(result, field)
+	}
+
+	if len(fields) > 0 {
+		v.check(targets["title"] == 1 && targets["optional_title"] == 0, "fields", "one_title")
+		v.check(targets["deadline"] <= 1 && targets["priority"] <= 1, "fields", "duplicate_target")
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"task-manager/modules"
+)
+
+// JobsHandler handles /admin/jobs: GET lists the periodic jobs with their
+// schedules, last and next runs, and POST /admin/jobs/{name}/run runs one now
+func JobsHandler(w http.ResponseWriter, r *http.Request) {
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		respondWithError(w, "Only owner can manage jobs", http.StatusForbidden)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/jobs"), "/")
+	name, action, _ := strings.Cut(path, "/")
+	switch {
+	case path == "" && r.Method == "GET":
+		jobs := modules.Jobs.Status()
+		respondWithSuccess(w, map[string]interface{}{
+			"jobs":  jobs,
+			"count": len(jobs),
+		})
+	case path == "":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	case action == "run" && r.Method == "POST":
+		triggerJob(w, r, name)
+	case action == "run":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+func triggerJob(w http.ResponseWriter, r *http.Request, name string) {
+	err := modules.Jobs.Trigger(name)
+	if errors.Is(err, modules.ErrJobRunning) {
+		respondWithError(w, "Job is already running", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		respondWithDomainError(w, r, err, "Job not found")
+		return
+	}
+
+	handlerLog.InfoContext(r.Context(), "Job triggered by owner", "job", name)
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Job started",
+		"job":     name,
+	}, http.StatusAccepted)
+}
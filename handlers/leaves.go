@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+var validLeaveTypes = map[string]bool{
+	"vacation": true,
+	"sick":     true,
+	"personal": true,
+	"other":    true,
+}
+
+func handleUserLeaves(w http.ResponseWriter, r *http.Request, userID int, remainingParts []string) {
+	if len(remainingParts) == 0 {
+		// /users/{id}/leaves
+		switch r.Method {
+		case "GET":
+			getUserLeaves(w, r, userID)
+		case "POST":
+			createUserLeave(w, r, userID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	leaveID, err := strconv.Atoi(remainingParts[0])
+	if err != nil {
+		http.Error(w, "Invalid leave request ID", http.StatusBadRequest)
+		return
+	}
+
+	if len(remainingParts) == 1 {
+		// /users/{id}/leaves/{lid}
+		switch r.Method {
+		case "GET":
+			getUserLeave(w, r, userID, leaveID)
+		case "DELETE":
+			cancelUserLeave(w, r, userID, leaveID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if len(remainingParts) == 2 && (remainingParts[1] == "approve" || remainingParts[1] == "reject") {
+		// /users/{id}/leaves/{lid}/approve and /users/{id}/leaves/{lid}/reject
+		if r.Method != "PUT" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		reviewUserLeave(w, r, userID, leaveID, remainingParts[1])
+		return
+	}
+
+	http.Error(w, "Invalid leave sub-path", http.StatusBadRequest)
+}
+
+func getUserLeaves(w http.ResponseWriter, r *http.Request, userID int) {
+	leaves, err := modules.RedisClient.GetUserLeaveRequests(userID)
+	if err != nil {
+		respondWithError(w, fmt.Sprintf("Failed to get leave requests: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	var filtered []*models.LeaveRequest
+	for _, leave := range leaves {
+		if status != "" && leave.Status != status {
+			continue
+		}
+		filtered = append(filtered, leave)
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].StartDate < filtered[j].StartDate
+	})
+
+	respondWithSuccess(w, map[string]interface{}{
+		"user_id": userID,
+		"leaves":  filtered,
+		"count":   len(filtered),
+	})
+}
+
+func createUserLeave(w http.ResponseWriter, r *http.Request, userID int) {
+	var req models.CreateLeaveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	v.required(req.StartDate, "start_date")
+	v.required(req.EndDate, "end_date")
+
+	start, err := time.Parse(models.LeaveDateLayout, req.StartDate)
+	v.check(err == nil, "start_date", "invalid_date")
+	end, err := time.Parse(models.LeaveDateLayout, req.EndDate)
+	v.check(err == nil, "end_date", "invalid_date")
+	if v.valid() {
+		v.check(!end.Before(start), "end_date", "date_order", "start_date")
+	}
+
+	if req.Type == "" {
+		req.Type = "vacation"
+	}
+	v.check(validLeaveTypes[req.Type], "type", "invalid_choice", "vacation, sick, personal, other")
+
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	// Reject overlaps with existing pending or approved leave
+	existing, _ := modules.RedisClient.GetUserLeaveRequests(userID)
+	for _, leave := range existing {
+		if leave.Status == "rejected" {
+			continue
+		}
+		if leave.StartDate <= req.EndDate && req.StartDate <= leave.EndDate {
+			respondWithError(w, fmt.Sprintf("Leave overlaps with existing request %d", leave.ID), http.StatusConflict)
+			return
+		}
+	}
+
+	leaveID, err := modules.RedisClient.GetNextLeaveID()
+	if err != nil {
+		respondWithError(w, "Failed to generate leave request ID", http.StatusInternalServerError)
+		return
+	}
+
+	leave := &models.LeaveRequest{
+		ID:        leaveID,
+		UserID:    userID,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Type:      req.Type,
+		Reason:    req.Reason,
+		Status:    "pending",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := modules.RedisClient.SaveLeaveRequest(leave); err != nil {
+		respondWithError(w, "Failed to save leave request", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("leaves")
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Leave request submitted successfully",
+		"leave":   leave,
+	}, http.StatusCreated)
+}
+
+func getUserLeave(w http.ResponseWriter, r *http.Request, userID, leaveID int) {
+	leave, err := modules.RedisClient.GetLeaveRequest(leaveID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Leave request not found")
+		return
+	}
+	if leave.UserID != userID {
+		respondWithError(w, "Leave request not found", http.StatusNotFound)
+		return
+	}
+
+	respondWithSuccess(w, leave)
+}
+
+func cancelUserLeave(w http.ResponseWriter, r *http.Request, userID, leaveID int) {
+	leave, err := modules.RedisClient.GetLeaveRequest(leaveID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Leave request not found")
+		return
+	}
+	if leave.UserID != userID {
+		respondWithError(w, "Leave request not found", http.StatusNotFound)
+		return
+	}
+
+	// Requesters may only withdraw their own pending requests; reviewers may remove any
+	authCtx := modules.GetAuthContext(r)
+	if !canReviewLeave(authCtx, userID) && leave.Status != "pending" {
+		respondWithError(w, "Only pending leave requests can be cancelled", http.StatusConflict)
+		return
+	}
+
+	if err := modules.RedisClient.DeleteLeaveRequest(leaveID); err != nil {
+		respondWithError(w, "Failed to cancel leave request", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("leaves")
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Leave request cancelled successfully",
+		"leave":   leave,
+	})
+}
+
+func reviewUserLeave(w http.ResponseWriter, r *http.Request, userID, leaveID int, action string) {
+	authCtx := modules.GetAuthContext(r)
+	if !canReviewLeave(authCtx, userID) {
+		respondWithError(w, "Insufficient permissions to review this leave request", http.StatusForbidden)
+		return
+	}
+
+	var req models.ReviewLeaveRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	leave, err := modules.RedisClient.GetLeaveRequest(leaveID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Leave request not found")
+		return
+	}
+	if leave.UserID != userID {
+		respondWithError(w, "Leave request not found", http.StatusNotFound)
+		return
+	}
+
+	if leave.Status != "pending" {
+		respondWithError(w, fmt.Sprintf("Leave request is already %s", leave.Status), http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	if action == "approve" {
+		leave.Status = "approved"
+	} else {
+		leave.Status = "rejected"
+	}
+	if authCtx.User != nil {
+		leave.ReviewerID = authCtx.User.ID
+	}
+	leave.ReviewNote = req.Note
+	leave.ReviewedAt = &now
+	leave.UpdatedAt = now
+
+	if err := modules.RedisClient.SaveLeaveRequest(leave); err != nil {
+		respondWithError(w, "Failed to update leave request", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("leaves")
+
+	// Notify the requester of the decision
+	modules.Events.Publish(r.Context(), "leave."+leave.Status, leave.UserID, 0, leave)
+	if requester, err := modules.RedisClient.GetUser(leave.UserID); err == nil {
+		modules.Notifications.Notify(r.Context(), requester, &modules.Notification{
+			Kind:    "leave." + leave.Status,
+			Message: fmt.Sprintf("Your %s leave from %s to %s was %s", leave.Type, leave.StartDate, leave.EndDate, leave.Status),
+		})
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": fmt.Sprintf("Leave request %s", leave.Status),
+		"leave":   leave,
+	})
+}
+
+// getGroupCalendar handles /groups/{id}/calendar
+func getGroupCalendar(w http.ResponseWriter, r *http.Request, groupID int) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	from := time.Now()
+	if fromStr := query.Get("from"); fromStr != "" {
+		parsed, err := time.Parse(models.LeaveDateLayout, fromStr)
+		if err != nil {
+			respondWithError(w, "Invalid 'from' date. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+
+	to := from.AddDate(0, 0, 30)
+	if toStr := query.Get("to"); toStr != "" {
+		parsed, err := time.Parse(models.LeaveDateLayout, toStr)
+		if err != nil {
+			respondWithError(w, "Invalid 'to' date. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+
+	if to.Before(from) {
+		respondWithError(w, "'to' date must not be before 'from' date", http.StatusBadRequest)
+		return
+	}
+
+	includePending := query.Get("include_pending") == "true"
+	fromStr := from.Format(models.LeaveDateLayout)
+	toStr := to.Format(models.LeaveDateLayout)
+
+	users, err := modules.RedisClient.GetGroupUsers(groupID)
+	if err != nil {
+		respondWithError(w, fmt.Sprintf("Failed to get group users: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var absences []map[string]interface{}
+	days := make(map[string][]int)
+
+	for _, user := range users {
+		leaves, err := modules.RedisClient.GetUserLeaveRequests(user.ID)
+		if err != nil {
+			continue
+		}
+
+		for _, leave := range leaves {
+			if leave.Status != "approved" && !(includePending && leave.Status == "pending") {
+				continue
+			}
+			if leave.EndDate < fromStr || leave.StartDate > toStr {
+				continue
+			}
+
+			absences = append(absences, map[string]interface{}{
+				"user_id":    user.ID,
+				"full_name":  user.FullName,
+				"leave_id":   leave.ID,
+				"type":       leave.Type,
+				"status":     leave.Status,
+				"start_date": leave.StartDate,
+				"end_date":   leave.EndDate,
+			})
+
+			for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+				if leave.Covers(day) {
+					key := day.Format(models.LeaveDateLayout)
+					days[key] = append(days[key], user.ID)
+				}
+			}
+		}
+	}
+
+	sort.Slice(absences, func(i, j int) bool {
+		return absences[i]["start_date"].(string) < absences[j]["start_date"].(string)
+	})
+
+	respondWithSuccess(w, map[string]interface{}{
+		"group_id": groupID,
+		"from":     fromStr,
+		"to":       toStr,
+		"absences": absences,
+		"days":     days,
+		"holidays": groupHolidays(users, from, to),
+		"count":    len(absences),
+	})
+}
+
+// canReviewLeave checks whether the caller may approve or reject a user's leave
+func canReviewLeave(authCtx *modules.AuthContext, userID int) bool {
+	if authCtx.IsOwner {
+		return true
+	}
+
+	// Group admins review leave for members of their groups, but not their own
+	if authCtx.IsGroupAdmin && authCtx.User.ID != userID {
+		return isUserInAdminGroups(userID, authCtx.AdminGroupIDs)
+	}
+
+	return false
+}
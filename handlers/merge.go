@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+)
+
+// AdminUserHandler handles /admin/users/{id}/merge and
+// /admin/users/{id}/offboard
+func AdminUserHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/users/"), "/")
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	switch parts[1] {
+	case "merge":
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		mergeUser(w, r, userID)
+	case "offboard":
+		switch r.Method {
+		case "GET":
+			getUserOffboarding(w, r, userID)
+		case "POST":
+			offboardUser(w, r, userID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+	}
+}
+
+// mergeUser handles POST /admin/users/{id}/merge, folding a duplicate
+// account into user {id}
+func mergeUser(w http.ResponseWriter, r *http.Request, targetID int) {
+	authCtx := modules.GetAuthContext(r)
+	target, err := modules.RedisClient.GetUser(targetID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "User not found")
+		return
+	}
+	if !authCtx.ManagesUser(target) {
+		respondWithError(w, "Only owner or a user admin can merge users", http.StatusForbidden)
+		return
+	}
+	if target.Deactivated {
+		respondWithError(w, "Cannot merge into a deactivated user", http.StatusConflict)
+		return
+	}
+
+	var req models.MergeUsersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	v.check(req.SourceUserID != 0, "source_user_id", "required")
+	v.check(req.SourceUserID != target.ID, "source_user_id", "merge_self")
+	var source *models.User
+	if v.valid() {
+		source, err = modules.RedisClient.GetUser(req.SourceUserID)
+		v.check(err == nil, "source_user_id", "not_found")
+		v.check(err != nil || source.Role != "owner", "source_user_id", "owner_account")
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+	if !authCtx.ManagesUser(source) {
+		respondWithError(w, "Only owner or a user admin can merge users", http.StatusForbidden)
+		return
+	}
+
+	merge, err := modules.RedisClient.MergeUsers(source, target)
+
+	// Mark data as dirty for sync, including whatever a failed merge already moved
+	modules.RedisClient.MarkDirty("users")
+	modules.RedisClient.MarkDirty("tasks")
+	modules.RedisClient.MarkDirty("groups")
+	modules.RedisClient.MarkDirty("leaves")
+
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to merge users")
+		return
+	}
+
+	// The duplicate account is deactivated and must not keep sessions or tokens alive
+	if err := modules.SignOutUser(source.ID); err != nil {
+		handlerLog.WarnContext(r.Context(), "⚠️ Failed to sign out merged user", "user_id", source.ID, "error", err)
+	}
+
+	handlerLog.InfoContext(r.Context(), "Users merged",
+		"source_user_id", source.ID,
+		"source_email", source.Email,
+		"target_user_id", target.ID,
+		"target_email", target.Email,
+		"tasks", merge.Tasks,
+		"leaves", merge.Leaves,
+		"group_ids", merge.GroupIDs,
+		"admin_groups", merge.AdminGroups,
+	)
+
+	modules.Events.Publish(r.Context(), "user.merged", target.ID, 0, merge)
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Users merged successfully",
+		"merge":   merge,
+		"user":    userResponse(authCtx, target),
+	})
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ndjsonContentType is requested in the Accept header to have list
+// endpoints stream one JSON object per line as rows are read
+const ndjsonContentType = "application/x-ndjson"
+
+// ndjsonFlushRows is how many rows are buffered before they are sent
+const ndjsonFlushRows = 100
+
+// wantsNDJSON reports whether the client accepts newline-delimited JSON
+func wantsNDJSON(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(accept, ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), ndjsonContentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// ndjsonStream writes rows of a list response as they are read. The status
+// is sent before the first row, so a failure partway through is reported
+// as a final {"error": "..."} line instead.
+type ndjsonStream struct {
+	encoder    *json.Encoder
+	controller *http.ResponseController
+	rows       int
+}
+
+func newNDJSONStream(w http.ResponseWriter) *ndjsonStream {
+	// Large exports outlive the server write timeout
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	return &ndjsonStream{encoder: json.NewEncoder(w), controller: controller}
+}
+
+// write sends one row. An error means the client has gone away.
+func (s *ndjsonStream) write(row interface{}) error {
+	if err := s.encoder.Encode(row); err != nil {
+		return err
+	}
+	s.rows++
+	if s.rows%ndjsonFlushRows == 0 {
+		return s.controller.Flush()
+	}
+	return nil
+}
+
+// close ends the stream, with an error line if listing failed
+func (s *ndjsonStream) close(err error) {
+	if err != nil {
+		s.encoder.Encode(map[string]string{"error": err.Error()})
+	}
+	s.controller.Flush()
+}
@@ -0,0 +1,507 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+var quarterPattern = regexp.MustCompile(`^\d{4}-Q[1-4]$`)
+
+var validMetrics = map[string]bool{
+	models.MetricTasks:  true,
+	models.MetricManual: true,
+}
+
+// keyResultProgress is a key result with its progress worked out
+type keyResultProgress struct {
+	models.KeyResult
+	Progress   float64 `json:"progress"`
+	TasksDone  int     `json:"tasks_done,omitempty"`
+	TasksTotal int     `json:"tasks_total,omitempty"`
+}
+
+// objectiveProgress is an objective with progress rolled up from its key results
+type objectiveProgress struct {
+	*models.Objective
+	KeyResults []keyResultProgress `json:"key_results"`
+	Progress   float64             `json:"progress"`
+}
+
+// ObjectivesHandler handles /objectives endpoint
+func ObjectivesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		getObjectives(w, r)
+	case "POST":
+		createObjective(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ObjectiveHandler handles /objectives/report, /objectives/{id} and its key results
+func ObjectiveHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/objectives/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) == 1 && parts[0] == "report" {
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		getQuarterlyReport(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid objective ID", http.StatusBadRequest)
+		return
+	}
+
+	objective, err := modules.RedisClient.GetObjective(id)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Objective not found")
+		return
+	}
+
+	if len(parts) == 1 {
+		// /objectives/{id}
+		switch r.Method {
+		case "GET":
+			respondWithSuccess(w, objectiveWithProgress(objective))
+		case "PUT":
+			updateObjective(w, r, objective)
+		case "DELETE":
+			deleteObjective(w, r, objective)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if parts[1] != "key-results" || len(parts) > 3 {
+		http.Error(w, "Invalid sub-path", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 2 {
+		// /objectives/{id}/key-results
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		addKeyResult(w, r, objective)
+		return
+	}
+
+	krID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		http.Error(w, "Invalid key result ID", http.StatusBadRequest)
+		return
+	}
+
+	index := -1
+	for i, kr := range objective.KeyResults {
+		if kr.ID == krID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		respondWithError(w, "Key result not found", http.StatusNotFound)
+		return
+	}
+
+	// /objectives/{id}/key-results/{krid}
+	switch r.Method {
+	case "PUT":
+		updateKeyResult(w, r, objective, index)
+	case "DELETE":
+		deleteKeyResult(w, r, objective, index)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// getObjectives lists objectives with their progress, optionally for one ?quarter=
+func getObjectives(w http.ResponseWriter, r *http.Request) {
+	quarter := r.URL.Query().Get("quarter")
+	if quarter != "" && !quarterPattern.MatchString(quarter) {
+		v := newValidator()
+		v.check(false, "quarter", "invalid_quarter")
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	objectives, err := quarterObjectives(quarter)
+	if err != nil {
+		respondWithError(w, fmt.Sprintf("Failed to get objectives: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"objectives": objectives,
+		"count":      len(objectives),
+	})
+}
+
+func createObjective(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateObjectiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Quarter == "" {
+		req.Quarter = currentQuarter(time.Now())
+	}
+
+	v := newValidator()
+	v.required(req.Title, "title")
+	checkObjective(v, req.Quarter, req.OwnerID)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	objectiveID, err := modules.RedisClient.GetNextObjectiveID()
+	if err != nil {
+		respondWithError(w, "Failed to generate objective ID", http.StatusInternalServerError)
+		return
+	}
+
+	objective := &models.Objective{
+		ID:          objectiveID,
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+		Quarter:     req.Quarter,
+		OwnerID:     req.OwnerID,
+		KeyResults:  models.KeyResults{},
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := modules.RedisClient.SaveObjective(objective); err != nil {
+		respondWithError(w, "Failed to save objective", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("objectives")
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":   "Objective created successfully",
+		"objective": objectiveWithProgress(objective),
+	}, http.StatusCreated)
+}
+
+func updateObjective(w http.ResponseWriter, r *http.Request, objective *models.Objective) {
+	var req models.UpdateObjectiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Title != "" {
+		objective.Title = strings.TrimSpace(req.Title)
+	}
+	if req.Description != nil {
+		objective.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Quarter != "" {
+		objective.Quarter = req.Quarter
+	}
+	if req.OwnerID != nil {
+		objective.OwnerID = *req.OwnerID
+	}
+
+	v := newValidator()
+	v.required(objective.Title, "title")
+	checkObjective(v, objective.Quarter, objective.OwnerID)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	saveObjective(w, objective, "Objective updated successfully")
+}
+
+func deleteObjective(w http.ResponseWriter, r *http.Request, objective *models.Objective) {
+	if err := modules.RedisClient.DeleteObjective(objective.ID); err != nil {
+		respondWithError(w, "Failed to delete objective", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("objectives")
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Objective deleted successfully",
+	})
+}
+
+func addKeyResult(w http.ResponseWriter, r *http.Request, objective *models.Objective) {
+	var req models.KeyResultRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	nextID := 1
+	for _, kr := range objective.KeyResults {
+		if kr.ID >= nextID {
+			nextID = kr.ID + 1
+		}
+	}
+
+	kr := models.KeyResult{ID: nextID, Metric: models.MetricTasks, TaskIDs: []int{}, GroupIDs: []int{}}
+	if !applyKeyResult(w, r, &kr, &req) {
+		return
+	}
+
+	objective.KeyResults = append(objective.KeyResults, kr)
+	saveObjective(w, objective, "Key result added successfully", http.StatusCreated)
+}
+
+func updateKeyResult(w http.ResponseWriter, r *http.Request, objective *models.Objective, index int) {
+	var req models.KeyResultRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	kr := objective.KeyResults[index]
+	if !applyKeyResult(w, r, &kr, &req) {
+		return
+	}
+
+	objective.KeyResults[index] = kr
+	saveObjective(w, objective, "Key result updated successfully")
+}
+
+func deleteKeyResult(w http.ResponseWriter, r *http.Request, objective *models.Objective, index int) {
+	objective.KeyResults = append(objective.KeyResults[:index], objective.KeyResults[index+1:]...)
+	saveObjective(w, objective, "Key result deleted successfully")
+}
+
+// applyKeyResult copies the set fields of req onto kr and validates the
+// result, responding with the errors and returning false if it is invalid.
+// Task and group lists replace the current links.
+func applyKeyResult(w http.ResponseWriter, r *http.Request, kr *models.KeyResult, req *models.KeyResultRequest) bool {
+	if req.Title != "" {
+		kr.Title = strings.TrimSpace(req.Title)
+	}
+	if req.Metric != "" {
+		kr.Metric = req.Metric
+	}
+	if req.Target != nil {
+		kr.Target = *req.Target
+	}
+	if req.Current != nil {
+		kr.Current = *req.Current
+	}
+	if req.TaskIDs != nil {
+		kr.TaskIDs = req.TaskIDs
+	}
+	if req.GroupIDs != nil {
+		kr.GroupIDs = req.GroupIDs
+	}
+
+	v := newValidator()
+	v.required(kr.Title, "title")
+	v.check(validMetrics[kr.Metric], "metric", "invalid_choice", "tasks, manual")
+	v.check(kr.Metric != models.MetricManual || kr.Target > 0, "target", "required")
+	v.check(kr.Current >= 0, "current", "not_negative")
+	for _, taskID := range kr.TaskIDs {
+		_, err := modules.RedisClient.GetTask(taskID)
+		v.check(err == nil, "task_ids", "task_not_found", taskID)
+	}
+	checkGroupsExist(v, kr.GroupIDs)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return false
+	}
+	return true
+}
+
+func saveObjective(w http.ResponseWriter, objective *models.Objective, message string, statusCode ...int) {
+	objective.UpdatedAt = time.Now()
+	if err := modules.RedisClient.SaveObjective(objective); err != nil {
+		respondWithError(w, "Failed to save objective", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("objectives")
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":   message,
+		"objective": objectiveWithProgress(objective),
+	}, statusCode...)
+}
+
+// getQuarterlyReport summarizes a quarter's objectives, the current quarter by default
+func getQuarterlyReport(w http.ResponseWriter, r *http.Request) {
+	quarter := r.URL.Query().Get("quarter")
+	if quarter == "" {
+		quarter = currentQuarter(time.Now())
+	}
+	if !quarterPattern.MatchString(quarter) {
+		v := newValidator()
+		v.check(false, "quarter", "invalid_quarter")
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	objectives, err := quarterObjectives(quarter)
+	if err != nil {
+		respondWithError(w, fmt.Sprintf("Failed to get objectives: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	keyResults := 0
+	completedKeyResults := 0
+	completedObjectives := 0
+	totalProgress := 0.0
+	for _, objective := range objectives {
+		totalProgress += objective.Progress
+		if len(objective.KeyResults) > 0 && objective.Progress >= 100 {
+			completedObjectives++
+		}
+		for _, kr := range objective.KeyResults {
+			keyResults++
+			if kr.Progress >= 100 {
+				completedKeyResults++
+			}
+		}
+	}
+
+	averageProgress := 0.0
+	if len(objectives) > 0 {
+		averageProgress = roundProgress(totalProgress / float64(len(objectives)))
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"quarter":               quarter,
+		"objectives":            objectives,
+		"objectives_count":      len(objectives),
+		"completed_objectives":  completedObjectives,
+		"key_results_count":     keyResults,
+		"completed_key_results": completedKeyResults,
+		"average_progress":      averageProgress,
+	})
+}
+
+// quarterObjectives returns the objectives of a quarter, or all of them for
+// an empty quarter, with their progress
+func quarterObjectives(quarter string) ([]*objectiveProgress, error) {
+	objectives, err := modules.RedisClient.GetAllObjectives()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*objectiveProgress{}
+	for _, objective := range objectives {
+		if quarter == "" || objective.Quarter == quarter {
+			result = append(result, objectiveWithProgress(objective))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Quarter != result[j].Quarter {
+			return result[i].Quarter > result[j].Quarter
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// objectiveWithProgress rolls key result progress up to the objective as
+// their unweighted average
+func objectiveWithProgress(objective *models.Objective) *objectiveProgress {
+	result := &objectiveProgress{
+		Objective:  objective,
+		KeyResults: []keyResultProgress{},
+	}
+
+	total := 0.0
+	for _, kr := range objective.KeyResults {
+		progress := progressOf(kr)
+		result.KeyResults = append(result.KeyResults, progress)
+		total += progress.Progress
+	}
+	if len(result.KeyResults) > 0 {
+		result.Progress = roundProgress(total / float64(len(result.KeyResults)))
+	}
+	return result
+}
+
+// progressOf works out a key result's progress in percent. Tasks count once
+// even when linked directly and through a group; deleted tasks are ignored.
+func progressOf(kr models.KeyResult) keyResultProgress {
+	result := keyResultProgress{KeyResult: kr}
+
+	if kr.Metric == models.MetricManual {
+		if kr.Target > 0 {
+			result.Progress = roundProgress(math.Min(kr.Current/kr.Target*100, 100))
+		}
+		return result
+	}
+
+	tasks := make(map[int]*models.Task)
+	for _, taskID := range kr.TaskIDs {
+		if task, err := modules.RedisClient.GetTask(taskID); err == nil {
+			tasks[task.ID] = task
+		}
+	}
+	for _, groupID := range kr.GroupIDs {
+		groupTasks, err := modules.RedisClient.GetGroupTasks(groupID)
+		if err != nil {
+			continue
+		}
+		for _, task := range groupTasks {
+			tasks[task.ID] = task
+		}
+	}
+
+	for _, task := range tasks {
+		// Cancelled work no longer counts toward the key result
+		if task.CurrentState() == models.TaskCancelled {
+			continue
+		}
+		result.TasksTotal++
+		if task.Status {
+			result.TasksDone++
+		}
+	}
+	if result.TasksTotal > 0 {
+		result.Progress = roundProgress(float64(result.TasksDone) / float64(result.TasksTotal) * 100)
+	}
+	return result
+}
+
+func checkObjective(v *validator, quarter string, ownerID int) {
+	v.check(quarterPattern.MatchString(quarter), "quarter", "invalid_quarter")
+	if ownerID != 0 {
+		_, err := modules.RedisClient.GetUser(ownerID)
+		v.check(err == nil, "owner_id", "not_found")
+	}
+}
+
+// currentQuarter names the quarter t falls in, such as "2026-Q3"
+func currentQuarter(t time.Time) string {
+	return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+}
+
+func roundProgress(progress float64) float64 {
+	return math.Round(progress*10) / 10
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+)
+
+// offboardUser handles POST /admin/users/{id}/offboard: deactivates the
+// user, ends their sign-ins and hands their open tasks over, answering with
+// a report that GET keeps showing
+func offboardUser(w http.ResponseWriter, r *http.Request, userID int) {
+	authCtx := modules.GetAuthContext(r)
+	user, err := modules.RedisClient.GetUser(userID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "User not found")
+		return
+	}
+	if !authCtx.ManagesUser(user) {
+		respondWithError(w, "Only owner or a user admin can offboard users", http.StatusForbidden)
+		return
+	}
+	// Only an offboarding that failed part way, and so has no report, runs again
+	if _, err := modules.RedisClient.GetUserOffboarding(userID); err == nil {
+		respondWithError(w, "User has already been offboarded", http.StatusConflict)
+		return
+	}
+
+	var req models.OffboardUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	byGroup, err := modules.RedisClient.GetPendingTasksByGroup(userID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get tasks")
+		return
+	}
+
+	v := newValidator()
+	v.check(user.Role != "owner", "user_id", "owner_account")
+	assignees := offboardingAssignees(v, userID, req.Assignees, byGroup)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	actorID := 0
+	if authCtx.User != nil {
+		actorID = authCtx.User.ID
+	}
+	report, err := modules.RedisClient.OffboardUser(user, assignees, strings.TrimSpace(req.Reason), actorID)
+
+	// Mark data as dirty for sync, including whatever a failed offboarding already changed
+	modules.RedisClient.MarkDirty("users")
+	modules.RedisClient.MarkDirty("tasks")
+
+	if err != nil {
+		handlerLog.ErrorContext(r.Context(), "❌ Offboarding failed part way; run it again to finish",
+			"user_id", userID, "tasks_reassigned", len(report.Tasks), "error", err)
+		respondWithDomainError(w, r, err, "Failed to offboard user")
+		return
+	}
+
+	handlerLog.InfoContext(r.Context(), "🚪 User offboarded",
+		"user_id", user.ID,
+		"email", user.Email,
+		"actor_user_id", actorID,
+		"reason", report.Reason,
+		"sign_ins_revoked", report.SignInsRevoked,
+		"tasks_reassigned", len(report.Tasks),
+		"collaborations_dropped", len(report.Collaborations),
+		"admin_groups", report.AdminGroups,
+	)
+	modules.Events.Publish(r.Context(), "user.offboarded", user.ID, 0, report)
+
+	for _, reassigned := range report.Tasks {
+		if task, err := modules.RedisClient.GetTask(reassigned.TaskID); err == nil {
+			notifyAssigned(r, task, []int{reassigned.AssignedTo})
+		}
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":     "User offboarded",
+		"offboarding": report,
+	})
+}
+
+// offboardingAssignees picks who takes the leaving user's open tasks in each
+// group: the member assignees names, otherwise the group's admin. Groups
+// with nobody to take them fail assignees.{group ID}.
+func offboardingAssignees(v *validator, userID int, requested map[int]int, byGroup map[int][]*models.Task) map[int]int {
+	groupIDs := make([]int, 0, len(byGroup))
+	for groupID := range byGroup {
+		groupIDs = append(groupIDs, groupID)
+	}
+	sort.Ints(groupIDs)
+
+	assignees := make(map[int]int, len(groupIDs))
+	for _, groupID := range groupIDs {
+		field := "assignees." + strconv.Itoa(groupID)
+		group, err := modules.RedisClient.GetGroup(groupID)
+		if err != nil {
+			v.check(false, field, "group_missing", groupID)
+			continue
+		}
+
+		if assignee, ok := requested[groupID]; ok {
+			v.check(activeUser(assignee) && assignee != userID && (userInGroup(assignee, groupID) || group.AdminID == assignee),
+				field, "offboard_member", assignee, groupID)
+			assignees[groupID] = assignee
+			continue
+		}
+
+		v.check(group.AdminID != userID && activeUser(group.AdminID), field, "offboard_assignee", groupID)
+		assignees[groupID] = group.AdminID
+	}
+	return assignees
+}
+
+// activeUser reports whether the user exists and is not deactivated
+func activeUser(userID int) bool {
+	user, err := modules.RedisClient.GetUser(userID)
+	return err == nil && !user.Deactivated
+}
+
+// getUserOffboarding handles GET /admin/users/{id}/offboard, the report the
+// user's offboarding left
+func getUserOffboarding(w http.ResponseWriter, r *http.Request, userID int) {
+	authCtx := modules.GetAuthContext(r)
+	user, err := modules.RedisClient.GetUser(userID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "User not found")
+		return
+	}
+	if !authCtx.ManagesUser(user) {
+		respondWithError(w, "Only owner or a user admin can offboard users", http.StatusForbidden)
+		return
+	}
+
+	report, err := modules.RedisClient.GetUserOffboarding(userID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "User has not been offboarded")
+		return
+	}
+	respondWithSuccess(w, report)
+}
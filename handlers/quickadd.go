@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+	"unicode/utf8"
+)
+
+// maxQuickAddLength bounds the line a quick-add is read from
+const maxQuickAddLength = 500
+
+// QuickAddTaskHandler handles POST /tasks/quick, reading a one-line task
+// such as "Fix login bug !p1 #backend due friday 5pm @ali". Nothing is
+// saved: the response shows how the line was read, with the request to
+// send to POST /users/{id}/tasks once the client has confirmed it.
+func QuickAddTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.QuickAddRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	v.required(strings.TrimSpace(req.Text), "text")
+	v.check(utf8.RuneCountInString(req.Text) <= maxQuickAddLength, "text", "between", 1, maxQuickAddLength)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	now := time.Now().In(modules.OrgSettings().Location())
+	quick := modules.ParseQuickTask(req.Text, now)
+	if quick.Title == "" {
+		quick.Warnings = append(quick.Warnings, "the line has no title left once the markers are read")
+	}
+
+	assignee := authCtx.User
+	if quick.Assignee != "" {
+		user, warning, err := quickAssignee(authCtx, quick.Assignee)
+		if err != nil {
+			respondWithError(w, "Failed to look up users", http.StatusInternalServerError)
+			return
+		}
+		assignee = user
+		if warning != "" {
+			quick.Warnings = append(quick.Warnings, warning)
+		}
+	} else if assignee == nil {
+		quick.Warnings = append(quick.Warnings, "name an assignee with @")
+	}
+
+	group, tags := quickGroup(assignee, quick.Tags, req.GroupID)
+	if assignee != nil && group == nil {
+		quick.Warnings = append(quick.Warnings, "name the group with a #tag matching its code or name, or send group_id")
+	}
+
+	create := models.CreateTaskRequest{
+		Title:    quick.Title,
+		Priority: quick.Priority,
+		Deadline: quick.Deadline,
+	}
+	if len(tags) > 0 {
+		// Tasks have no tags of their own, so the rest are kept in the notes
+		create.Information = "Tags: #" + strings.Join(tags, " #")
+	}
+	if group != nil {
+		create.GroupID = group.ID
+	}
+	response := map[string]interface{}{
+		"parsed":  quick,
+		"request": create,
+		"ready":   quick.Title != "" && assignee != nil && group != nil,
+	}
+	if assignee != nil {
+		response["assignee"] = map[string]interface{}{
+			"id":        assignee.ID,
+			"full_name": assignee.FullName,
+			"email":     assignee.Email,
+		}
+		response["create_path"] = fmt.Sprintf("/users/%d/tasks", assignee.ID)
+	}
+	if group != nil {
+		response["group"] = map[string]interface{}{
+			"id":   group.ID,
+			"name": group.Name,
+		}
+	}
+
+	respondWithSuccess(w, response)
+}
+
+// quickAssignee finds the one active user an @name stands for: by the part
+// of their email before the @, their first name, or their full name
+// without spaces. Users other than the owner can only name people who
+// share a group with them.
+func quickAssignee(authCtx *modules.AuthContext, name string) (*models.User, string, error) {
+	users, err := modules.RedisClient.GetAllUsers()
+	if err != nil {
+		return nil, "", err
+	}
+
+	name = strings.ToLower(name)
+	var matches []*models.User
+	for _, user := range users {
+		if user.Deactivated || (!authCtx.IsOwner && !sharesGroup(authCtx.User, user)) {
+			continue
+		}
+		local, _, _ := strings.Cut(strings.ToLower(user.Email), "@")
+		fullName := strings.ToLower(user.FullName)
+		first, _, _ := strings.Cut(fullName, " ")
+		if name == local || name == first || name == strings.ReplaceAll(fullName, " ", "") {
+			matches = append(matches, user)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Sprintf("@%s matches no one you can assign", name), nil
+	case 1:
+		return matches[0], "", nil
+	default:
+		return nil, fmt.Sprintf("@%s matches %d people; use their email name instead", name, len(matches)), nil
+	}
+}
+
+func sharesGroup(a, b *models.User) bool {
+	if a == nil {
+		return false
+	}
+	for _, groupID := range a.GroupIDs {
+		for _, other := range b.GroupIDs {
+			if groupID == other {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// quickGroup picks the assignee's group named by a tag, by its code or by
+// its name with hyphens for spaces, falling back to groupID and then to
+// their only group. It returns the tags that named no group.
+func quickGroup(assignee *models.User, tags []string, groupID int) (*models.Group, []string) {
+	if assignee == nil {
+		return nil, tags
+	}
+
+	var groups []*models.Group
+	for _, id := range assignee.GroupIDs {
+		if group, err := modules.RedisClient.GetGroup(id); err == nil {
+			groups = append(groups, group)
+		}
+	}
+
+	for i, tag := range tags {
+		for _, group := range groups {
+			if tag == strings.ToLower(group.Code) || tag == strings.ToLower(strings.ReplaceAll(group.Name, " ", "-")) {
+				rest := append(append([]string{}, tags[:i]...), tags[i+1:]...)
+				return group, rest
+			}
+		}
+	}
+	for _, group := range groups {
+		if group.ID == groupID {
+			return group, tags
+		}
+	}
+	if groupID == 0 && len(groups) == 1 {
+		return groups[0], tags
+	}
+	return nil, tags
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"task-manager/modules"
+)
+
+// QuotaHandler handles GET /quota: the caller's use of their daily and
+// monthly API quotas. Checking never counts against them.
+func QuotaHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if authCtx.User == nil {
+		respondWithSuccess(w, map[string]interface{}{
+			"unlimited": true,
+			"message":   "The owner has no quota",
+		})
+		return
+	}
+
+	usage, err := modules.Quotas.Usage(authCtx.User.ID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get quota")
+		return
+	}
+	respondWithSuccess(w, usage)
+}
+
+// QuotasHandler handles /admin/quotas: GET lists the default quotas and the
+// users who override them, and /admin/quotas/{userID} shows (GET), sets
+// (PUT) or removes (DELETE) a user's override
+func QuotasHandler(w http.ResponseWriter, r *http.Request) {
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		respondWithError(w, "Only owner can manage quotas", http.StatusForbidden)
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/quotas"), "/")
+	if id == "" {
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		listQuotaOverrides(w, r)
+		return
+	}
+
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := modules.RedisClient.GetUser(userID); err != nil {
+		respondWithDomainError(w, r, err, "User not found")
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		getUserQuota(w, r, userID)
+	case "PUT":
+		setUserQuota(w, r, userID)
+	case "DELETE":
+		deleteUserQuota(w, r, userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func listQuotaOverrides(w http.ResponseWriter, r *http.Request) {
+	overrides, err := modules.RedisClient.GetQuotaOverrides()
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to list quota overrides")
+		return
+	}
+
+	type userOverride struct {
+		UserID int `json:"user_id"`
+		*modules.QuotaOverride
+	}
+	list := make([]userOverride, 0, len(overrides))
+	for userID, override := range overrides {
+		list = append(list, userOverride{UserID: userID, QuotaOverride: override})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].UserID < list[j].UserID
+	})
+
+	daily, monthly := modules.Quotas.Defaults()
+	respondWithSuccess(w, map[string]interface{}{
+		"defaults":  map[string]int{"daily": daily, "monthly": monthly},
+		"overrides": list,
+		"count":     len(list),
+	})
+}
+
+func getUserQuota(w http.ResponseWriter, r *http.Request, userID int) {
+	usage, err := modules.Quotas.Usage(userID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get quota")
+		return
+	}
+	respondWithSuccess(w, usage)
+}
+
+// setUserQuota replaces a user's override; a limit left out keeps the default
+func setUserQuota(w http.ResponseWriter, r *http.Request, userID int) {
+	var req modules.QuotaOverride
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	v.check(req.Daily != nil || req.Monthly != nil, "daily", "at_least_one", "daily, monthly")
+	v.check(req.Daily == nil || *req.Daily >= 0, "daily", "not_negative")
+	v.check(req.Monthly == nil || *req.Monthly >= 0, "monthly", "not_negative")
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	if err := modules.RedisClient.SetQuotaOverride(userID, &req); err != nil {
+		respondWithDomainError(w, r, err, "Failed to save quota")
+		return
+	}
+
+	handlerLog.InfoContext(r.Context(), "📏 Quota override set", "user_id", userID)
+	getUserQuota(w, r, userID)
+}
+
+func deleteUserQuota(w http.ResponseWriter, r *http.Request, userID int) {
+	if err := modules.RedisClient.DeleteQuotaOverride(userID); err != nil {
+		respondWithDomainError(w, r, err, "Failed to remove quota")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Quota override removed; the defaults apply",
+	})
+}
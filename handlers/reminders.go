@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+// handleTaskReminders handles /users/{id}/tasks/{tid}/reminders[/{rid}[/snooze]]
+func handleTaskReminders(w http.ResponseWriter, r *http.Request, userID, taskID int, remainingParts []string) {
+	task, err := modules.RedisClient.GetTask(taskID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Task not found")
+		return
+	}
+
+	if task.UserID != userID {
+		respondWithError(w, "Task does not belong to this user", http.StatusNotFound)
+		return
+	}
+
+	if len(remainingParts) == 0 {
+		// /users/{id}/tasks/{tid}/reminders
+		switch r.Method {
+		case "GET":
+			getTaskReminders(w, r, task)
+		case "POST":
+			createTaskReminder(w, r, task)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	reminderID, err := strconv.Atoi(remainingParts[0])
+	if err != nil {
+		http.Error(w, "Invalid reminder ID", http.StatusBadRequest)
+		return
+	}
+
+	reminder, err := modules.RedisClient.GetReminder(reminderID)
+	if err != nil || reminder.TaskID != task.ID {
+		respondWithError(w, "Reminder not found", http.StatusNotFound)
+		return
+	}
+
+	if len(remainingParts) == 1 {
+		// /users/{id}/tasks/{tid}/reminders/{rid}
+		switch r.Method {
+		case "GET":
+			respondWithSuccess(w, reminder)
+		case "DELETE":
+			deleteTaskReminder(w, r, reminder)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if len(remainingParts) == 2 && remainingParts[1] == "snooze" {
+		// /users/{id}/tasks/{tid}/reminders/{rid}/snooze
+		if r.Method == "PUT" {
+			snoozeTaskReminder(w, r, reminder)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else {
+		http.Error(w, "Invalid reminder sub-path", http.StatusBadRequest)
+	}
+}
+
+func getTaskReminders(w http.ResponseWriter, r *http.Request, task *models.Task) {
+	reminders, err := modules.RedisClient.GetTaskReminders(task.ID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get reminders")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"task_id":   task.ID,
+		"reminders": reminders,
+		"count":     len(reminders),
+	})
+}
+
+func createTaskReminder(w http.ResponseWriter, r *http.Request, task *models.Task) {
+	var req models.CreateReminderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	var remindAt time.Time
+	switch {
+	case req.RemindAt != "" && req.In != "":
+		v.check(false, "remind_at", "one_of", "remind_at, in")
+	case req.In != "":
+		remindAt = checkClockDuration(v, "in", req.In, req.Clock)
+	default:
+		remindAt = checkFutureTime(v, "remind_at", req.RemindAt)
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	reminderID, err := modules.RedisClient.GetNextReminderID()
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to create reminder")
+		return
+	}
+
+	now := time.Now()
+	reminder := &models.Reminder{
+		ID:        reminderID,
+		TaskID:    task.ID,
+		UserID:    task.UserID,
+		RemindAt:  remindAt,
+		Note:      strings.TrimSpace(req.Note),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := modules.RedisClient.SaveReminder(reminder); err != nil {
+		respondWithDomainError(w, r, err, "Failed to save reminder")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":  "Reminder created",
+		"reminder": reminder,
+	}, http.StatusCreated)
+}
+
+// deleteTaskReminder removes a custom reminder; the deadline reminder follows
+// the task and can only be snoozed
+func deleteTaskReminder(w http.ResponseWriter, r *http.Request, reminder *models.Reminder) {
+	if reminder.System {
+		respondWithError(w, "Deadline reminders cannot be deleted, only snoozed", http.StatusConflict)
+		return
+	}
+
+	if err := modules.RedisClient.DeleteReminder(reminder); err != nil {
+		respondWithDomainError(w, r, err, "Failed to delete reminder")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Reminder deleted",
+	})
+}
+
+// snoozeTaskReminder pushes a reminder back to an exact time ("until") or by
+// a duration from now ("for") on the chosen clock, sending it again even if
+// it already went out
+func snoozeTaskReminder(w http.ResponseWriter, r *http.Request, reminder *models.Reminder) {
+	var req models.SnoozeReminderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	var remindAt time.Time
+	switch {
+	case (req.Until == "") == (req.For == ""):
+		v.check(false, "until", "one_of", "until, for")
+	case req.Until != "":
+		remindAt = checkFutureTime(v, "until", req.Until)
+	default:
+		remindAt = checkClockDuration(v, "for", req.For, req.Clock)
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	reminder.RemindAt = remindAt
+	reminder.Snoozed = true
+	reminder.SentAt = nil
+	reminder.UpdatedAt = time.Now()
+
+	if err := modules.RedisClient.SaveReminder(reminder); err != nil {
+		respondWithDomainError(w, r, err, "Failed to snooze reminder")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":  "Reminder snoozed",
+		"reminder": reminder,
+	})
+}
+
+// checkClockDuration returns when a duration from now runs out on a clock:
+// the wall clock by default, or the business clock, which only counts the
+// organization's working hours outside holidays
+func checkClockDuration(v *validator, field, value, clock string) time.Time {
+	duration, err := time.ParseDuration(value)
+	v.check(err == nil && duration > 0, field, "invalid_duration")
+	v.check(modules.IsClockPolicy(clock), "clock", "invalid_choice", modules.ClockPolicies)
+	if !v.valid() {
+		return time.Time{}
+	}
+
+	at, ok := modules.RedisClient.AddClockTime(clock, time.Now(), duration)
+	v.check(ok, field, "no_business_time")
+	return at
+}
+
+// checkFutureTime parses an RFC 3339 time that must lie in the future
+func checkFutureTime(v *validator, field, value string) time.Time {
+	if value == "" {
+		v.required(value, field)
+		return time.Time{}
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	v.check(err == nil, field, "invalid_time")
+	v.check(err != nil || parsed.After(time.Now()), field, "future_time")
+	return parsed
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+// ReportingHandler handles /reports/burndown, /reports/velocity and
+// /reports/yearly, which read the task history from PostgreSQL
+func ReportingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report := strings.Trim(strings.TrimPrefix(r.URL.Path, "/reports/"), "/")
+	if report != "burndown" && report != "velocity" && report != "yearly" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	if modules.PostgresClient == nil {
+		respondWithError(w, "Reports need PostgreSQL, which is not connected", http.StatusServiceUnavailable)
+		return
+	}
+
+	scope, ok := reportScope(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	v := newValidator()
+	var (
+		result interface{}
+		err    error
+	)
+	switch report {
+	case "burndown":
+		now := time.Now()
+		from := parseReportDate(v, query.Get("from"), "from", now.AddDate(0, 0, -29))
+		to := parseReportDate(v, query.Get("to"), "to", now)
+		if !v.valid() {
+			respondWithValidationErrors(w, r, v)
+			return
+		}
+		result, err = modules.PostgresClient.Reporting().Burndown(scope, from, to)
+	case "velocity":
+		weeks := parseReportInt(v, query.Get("weeks"), "weeks", 8)
+		if !v.valid() {
+			respondWithValidationErrors(w, r, v)
+			return
+		}
+		result, err = modules.PostgresClient.Reporting().Velocity(scope, weeks, time.Now())
+	case "yearly":
+		year := parseReportInt(v, query.Get("year"), "year", time.Now().In(modules.OrgSettings().Location()).Year())
+		if !v.valid() {
+			respondWithValidationErrors(w, r, v)
+			return
+		}
+		result, err = modules.PostgresClient.Reporting().YearSummary(scope, year)
+	}
+	if errors.Is(err, modules.ErrInvalidReportRange) {
+		respondWithError(w, "A report covers 1 to 400 days, with from on or before to and at most 57 weeks", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to build report")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"report":           report,
+		"group_id":         scope.GroupID,
+		"user_id":          scope.UserID,
+		"include_archived": scope.IncludeArchived,
+		"data":             result,
+	})
+}
+
+// reportScope reads group_id, user_id and include_archived, checking the
+// caller may see them. Without either, the owner gets every task and anyone
+// else their own.
+func reportScope(w http.ResponseWriter, r *http.Request) (modules.ReportScope, bool) {
+	authCtx := modules.GetAuthContext(r)
+	query := r.URL.Query()
+	var scope modules.ReportScope
+
+	v := newValidator()
+	scope.GroupID = parseReportInt(v, query.Get("group_id"), "group_id", 0)
+	scope.UserID = parseReportInt(v, query.Get("user_id"), "user_id", 0)
+	scope.IncludeArchived = query.Get("include_archived") == "true"
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return scope, false
+	}
+
+	if scope.GroupID != 0 && !administersGroup(authCtx, scope.GroupID) && !isGroupMember(authCtx, scope.GroupID) {
+		respondWithError(w, "Insufficient permissions to view this group's reports", http.StatusForbidden)
+		return scope, false
+	}
+
+	if scope.UserID != 0 && !authCtx.IsOwner && scope.UserID != authCtx.User.ID {
+		user, err := modules.RedisClient.GetUser(scope.UserID)
+		if err != nil || len(modules.FilterUsersByPermissions(authCtx, []*models.User{user})) == 0 {
+			respondWithError(w, "Insufficient permissions to view this user's reports", http.StatusForbidden)
+			return scope, false
+		}
+	}
+
+	if scope.GroupID == 0 && scope.UserID == 0 && !authCtx.IsOwner {
+		scope.UserID = authCtx.User.ID
+	}
+	return scope, true
+}
+
+func isGroupMember(authCtx *modules.AuthContext, groupID int) bool {
+	if authCtx.User == nil {
+		return false
+	}
+	for _, userGroupID := range authCtx.User.GroupIDs {
+		if userGroupID == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+func parseReportInt(v *validator, value, field string, fallback int) int {
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	v.check(err == nil && n >= 0, field, "invalid_number")
+	return n
+}
+
+func parseReportDate(v *validator, value, field string, fallback time.Time) time.Time {
+	if value == "" {
+		return fallback
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, modules.OrgSettings().Location())
+	v.check(err == nil, field, "invalid_date")
+	return date
+}
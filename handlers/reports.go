@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+func handleUserReports(w http.ResponseWriter, r *http.Request, userID int, remainingParts []string) {
+	if len(remainingParts) == 0 {
+		// /users/{id}/reports
+		switch r.Method {
+		case "GET":
+			getUserReportSubscriptions(w, r, userID)
+		case "POST":
+			createUserReportSubscription(w, r, userID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	subID, err := strconv.Atoi(remainingParts[0])
+	if err != nil {
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := modules.RedisClient.GetReportSubscription(subID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Report subscription not found")
+		return
+	}
+	if sub.UserID != userID {
+		respondWithError(w, "Report subscription not found", http.StatusNotFound)
+		return
+	}
+
+	if len(remainingParts) == 1 {
+		// /users/{id}/reports/{sid}
+		switch r.Method {
+		case "GET":
+			respondWithSuccess(w, sub)
+		case "DELETE":
+			deleteUserReportSubscription(w, r, sub)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if len(remainingParts) == 2 {
+		switch remainingParts[1] {
+		case "pause", "resume":
+			// /users/{id}/reports/{sid}/pause and /users/{id}/reports/{sid}/resume
+			if r.Method != "PUT" {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			setReportSubscriptionPaused(w, r, sub, remainingParts[1] == "pause")
+			return
+		case "test":
+			// /users/{id}/reports/{sid}/test
+			if r.Method != "POST" {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			testReportWebhook(w, r, sub)
+			return
+		case "run":
+			// /users/{id}/reports/{sid}/run
+			if r.Method != "POST" {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			runReportSubscription(w, r, sub)
+			return
+		}
+	}
+
+	http.Error(w, "Invalid report sub-path", http.StatusBadRequest)
+}
+
+func getUserReportSubscriptions(w http.ResponseWriter, r *http.Request, userID int) {
+	subs, err := modules.RedisClient.GetUserReportSubscriptions(userID)
+	if err != nil {
+		respondWithError(w, fmt.Sprintf("Failed to get report subscriptions: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"user_id":       userID,
+		"subscriptions": subs,
+		"count":         len(subs),
+		"report_types":  modules.ReportTypes,
+	})
+}
+
+func createUserReportSubscription(w http.ResponseWriter, r *http.Request, userID int) {
+	var req models.CreateReportSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := newValidator()
+	_, validType := modules.ReportTypes[req.ReportType]
+	v.check(validType, "report_type", "invalid_choice", "weekly_task_report, group_status_summary, group_daily_digest")
+	groupReport := req.ReportType == "group_status_summary" || req.ReportType == "group_daily_digest"
+	if req.ReportType == "group_daily_digest" && req.Schedule == "" {
+		req.Schedule = "@daily"
+	}
+
+	schedule, err := modules.ParseCron(req.Schedule)
+	if err != nil {
+		v.check(false, "schedule", "invalid_value", err.Error())
+	}
+
+	if req.Delivery == "" {
+		req.Delivery = "email"
+	}
+	switch req.Delivery {
+	case "email":
+		v.check(modules.Mailer.Enabled(), "delivery", "not_configured")
+	case "webhook":
+		parsed, err := url.Parse(req.WebhookURL)
+		validURL := err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+		v.check(validURL, "webhook_url", "invalid_url")
+		if validURL {
+			v.check(modules.CheckPublicURL(req.WebhookURL) == nil, "webhook_url", "public_url")
+		}
+		if req.WebhookTemplate != "" {
+			if _, err := modules.ParseWebhookTemplate(req.WebhookTemplate); err != nil {
+				v.check(false, "webhook_template", "invalid_value", err.Error())
+			}
+		}
+		names := make([]string, 0, len(req.WebhookHeaders))
+		for name := range req.WebhookHeaders {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			problem := modules.CheckWebhookHeader(name, req.WebhookHeaders[name])
+			v.check(problem == "", "webhook_headers", "invalid_header", name, problem)
+		}
+	default:
+		v.check(false, "delivery", "invalid_choice", "email, webhook")
+	}
+
+	if groupReport {
+		v.check(req.GroupID != 0, "group_id", "required")
+		if req.GroupID != 0 {
+			_, err := modules.RedisClient.GetGroup(req.GroupID)
+			v.check(err == nil, "group_id", "not_found")
+		}
+	}
+
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+
+	if req.ReportType == "group_daily_digest" && !administersGroup(authCtx, req.GroupID) {
+		respondWithError(w, "Only the group's admin can subscribe to its digest", http.StatusForbidden)
+		return
+	}
+	if groupReport {
+		user, err := modules.RedisClient.GetUser(userID)
+		if err != nil {
+			respondWithDomainError(w, r, err, "User not found")
+			return
+		}
+
+		isMember := false
+		for _, groupID := range user.GroupIDs {
+			if groupID == req.GroupID {
+				isMember = true
+				break
+			}
+		}
+		if !isMember && !authCtx.IsOwner {
+			respondWithError(w, "User does not belong to specified group", http.StatusForbidden)
+			return
+		}
+	} else {
+		req.GroupID = 0
+	}
+
+	// Templates and headers only shape webhook deliveries
+	if req.Delivery != "webhook" {
+		req.WebhookTemplate = ""
+		req.WebhookHeaders = nil
+	}
+
+	subID, err := modules.RedisClient.GetNextReportSubscriptionID()
+	if err != nil {
+		respondWithError(w, "Failed to generate subscription ID", http.StatusInternalServerError)
+		return
+	}
+
+	nextRun := schedule.Next(time.Now().In(modules.OrgSettings().Location()))
+	sub := &models.ReportSubscription{
+		ID:              subID,
+		UserID:          userID,
+		ReportType:      req.ReportType,
+		GroupID:         req.GroupID,
+		Schedule:        req.Schedule,
+		Delivery:        req.Delivery,
+		WebhookURL:      req.WebhookURL,
+		WebhookTemplate: req.WebhookTemplate,
+		WebhookHeaders:  req.WebhookHeaders,
+		NextRunAt:       &nextRun,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+
+	if err := modules.RedisClient.SaveReportSubscription(sub); err != nil {
+		respondWithError(w, "Failed to save report subscription", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("reports")
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":      "Report subscription created successfully",
+		"subscription": sub,
+	}, http.StatusCreated)
+}
+
+func deleteUserReportSubscription(w http.ResponseWriter, r *http.Request, sub *models.ReportSubscription) {
+	if err := modules.RedisClient.DeleteReportSubscription(sub.ID); err != nil {
+		respondWithError(w, "Failed to delete report subscription", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("reports")
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":      "Report subscription deleted successfully",
+		"subscription": sub,
+	})
+}
+
+func setReportSubscriptionPaused(w http.ResponseWriter, r *http.Request, sub *models.ReportSubscription, paused bool) {
+	sub.Paused = paused
+
+	// Recompute the next run on resume so missed runs are not replayed
+	if !paused {
+		if schedule, err := modules.ParseCron(sub.Schedule); err == nil {
+			nextRun := schedule.Next(time.Now().In(modules.OrgSettings().Location()))
+			sub.NextRunAt = &nextRun
+		}
+	}
+	sub.UpdatedAt = time.Now()
+
+	if err := modules.RedisClient.SaveReportSubscription(sub); err != nil {
+		respondWithError(w, "Failed to update report subscription", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("reports")
+
+	message := "Report subscription resumed"
+	if paused {
+		message = "Report subscription paused"
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":      message,
+		"subscription": sub,
+	})
+}
+
+func runReportSubscription(w http.ResponseWriter, r *http.Request, sub *models.ReportSubscription) {
+	if err := modules.RedisClient.EnqueueReportJob(sub.ID); err != nil {
+		respondWithError(w, "Failed to enqueue report", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":         "Report queued for generation",
+		"subscription_id": sub.ID,
+	}, http.StatusAccepted)
+}
+
+// testReportWebhook generates the report now and sends it to the webhook,
+// returning the rendered payload and the receiver's status. Only the owner
+// sees the body of the answer, so the test cannot be used to read pages a
+// webhook was pointed at. With ?dry_run=true it only renders.
+func testReportWebhook(w http.ResponseWriter, r *http.Request, sub *models.ReportSubscription) {
+	if sub.Delivery != "webhook" {
+		respondWithError(w, "Report subscription is not delivered by webhook", http.StatusBadRequest)
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	result, err := modules.Reporter.TestWebhook(sub, dryRun)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to generate report")
+		return
+	}
+
+	if !modules.GetAuthContext(r).IsOwner {
+		result.Response = ""
+	}
+
+	message := "Webhook test sent"
+	if dryRun {
+		message = "Webhook payload rendered"
+	}
+	if result.Error != "" {
+		message = "Webhook test failed"
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":         message,
+		"subscription_id": sub.ID,
+		"dry_run":         dryRun,
+		"result":          result,
+	})
+}
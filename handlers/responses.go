@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"task-manager/models"
+	"task-manager/modules"
+)
+
+// canSeePrivateUserFields reports whether the caller may see a user's contact details and work times:
+// the owner, the user themselves, a user admin managing them, or an admin of one of the user's groups
+func canSeePrivateUserFields(authCtx *modules.AuthContext, user *models.User) bool {
+	if authCtx == nil {
+		return false
+	}
+	if authCtx.IsOwner {
+		return true
+	}
+	if authCtx.User == nil {
+		return false
+	}
+	if authCtx.User.ID == user.ID {
+		return true
+	}
+	if authCtx.ManagesUser(user) {
+		return true
+	}
+
+	if authCtx.IsGroupAdmin {
+		for _, groupID := range user.GroupIDs {
+			for _, adminGroupID := range authCtx.AdminGroupIDs {
+				if groupID == adminGroupID {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// userResponse converts a user to the response visible to the caller
+func userResponse(authCtx *modules.AuthContext, user *models.User) *models.UserResponse {
+	return models.NewUserResponse(user, canSeePrivateUserFields(authCtx, user))
+}
+
+// userResponses converts users to the responses visible to the caller
+func userResponses(authCtx *modules.AuthContext, users []*models.User) []*models.UserResponse {
+	responses := make([]*models.UserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, userResponse(authCtx, user))
+	}
+	return responses
+}
+
+// taskDetails is a task with the previews of the links in its description,
+// as far as they have been fetched, and the roll-up of its subtasks
+type taskDetails struct {
+	task     *models.Task
+	previews []*modules.LinkPreview
+	subtasks *modules.SubtaskProgress
+}
+
+// taskResponse adds link previews and subtask progress to a single task's
+// response. Links not previewed yet are fetched in the background, for the
+// next read.
+func taskResponse(task *models.Task) interface{} {
+	previews := modules.LinkPreviews.Previews(task.Information)
+	subtasks := subtaskProgress(task)
+	if len(previews) == 0 && subtasks == nil {
+		return task
+	}
+	return taskDetails{task: task, previews: previews, subtasks: subtasks}
+}
+
+// MarshalJSON adds the previews and subtasks as the task's last fields,
+// since the task marshals itself
+func (t taskDetails) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(t.task)
+	if err != nil {
+		return nil, err
+	}
+
+	extra := map[string]interface{}{}
+	if len(t.previews) > 0 {
+		extra["link_previews"] = t.previews
+	}
+	if t.subtasks != nil {
+		extra["subtasks"] = t.subtasks
+	}
+	fields, err := json.Marshal(extra)
+	if err != nil {
+		return nil, err
+	}
+
+	data = append(data[:len(data)-1], ',')
+	return append(data, fields[1:]...), nil
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+var validRiskStatuses = map[string]bool{
+	models.RiskOpen:       true,
+	models.RiskMitigating: true,
+	models.RiskClosed:     true,
+}
+
+// handleGroupRisks routes the group's risk register /groups/{id}/risks[/{rid}]
+func handleGroupRisks(w http.ResponseWriter, r *http.Request, groupID int, remainingParts []string) {
+	if len(remainingParts) == 0 {
+		// /groups/{id}/risks
+		switch r.Method {
+		case "GET":
+			getGroupRisks(w, r, groupID)
+		case "POST":
+			createGroupRisk(w, r, groupID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if len(remainingParts) != 1 {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+
+	riskID, err := strconv.Atoi(remainingParts[0])
+	if err != nil {
+		http.Error(w, "Invalid risk ID", http.StatusBadRequest)
+		return
+	}
+
+	risk, err := modules.RedisClient.GetRisk(riskID)
+	if err != nil || risk.GroupID != groupID {
+		respondWithError(w, "Risk not found", http.StatusNotFound)
+		return
+	}
+
+	// /groups/{id}/risks/{rid}
+	switch r.Method {
+	case "GET":
+		respondWithSuccess(w, risk)
+	case "PUT":
+		updateGroupRisk(w, r, risk)
+	case "DELETE":
+		deleteGroupRisk(w, r, risk)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// getGroupRisks lists the register highest score first, optionally filtered by ?status=
+func getGroupRisks(w http.ResponseWriter, r *http.Request, groupID int) {
+	risks, err := modules.RedisClient.GetGroupRisks(groupID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get risks")
+		return
+	}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		var filtered []*models.Risk
+		for _, risk := range risks {
+			if risk.Status == status {
+				filtered = append(filtered, risk)
+			}
+		}
+		risks = filtered
+	}
+	sortRisks(risks)
+
+	respondWithSuccess(w, map[string]interface{}{
+		"group_id": groupID,
+		"risks":    risks,
+		"count":    len(risks),
+		"summary":  riskSummary(risks),
+	})
+}
+
+func createGroupRisk(w http.ResponseWriter, r *http.Request, groupID int) {
+	var req models.CreateRiskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Status == "" {
+		req.Status = models.RiskOpen
+	}
+
+	v := newValidator()
+	v.required(req.Description, "description")
+	checkRisk(v, groupID, req.Probability, req.Impact, req.OwnerID, req.Status)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	riskID, err := modules.RedisClient.GetNextRiskID()
+	if err != nil {
+		respondWithError(w, "Failed to generate risk ID", http.StatusInternalServerError)
+		return
+	}
+
+	risk := &models.Risk{
+		ID:          riskID,
+		GroupID:     groupID,
+		Description: strings.TrimSpace(req.Description),
+		Probability: req.Probability,
+		Impact:      req.Impact,
+		Score:       req.Probability * req.Impact,
+		Mitigation:  strings.TrimSpace(req.Mitigation),
+		OwnerID:     req.OwnerID,
+		Status:      req.Status,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := modules.RedisClient.SaveRisk(risk); err != nil {
+		respondWithError(w, "Failed to save risk", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("risks")
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Risk created successfully",
+		"risk":    risk,
+	}, http.StatusCreated)
+}
+
+func updateGroupRisk(w http.ResponseWriter, r *http.Request, risk *models.Risk) {
+	var req models.UpdateRiskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Description != "" {
+		risk.Description = strings.TrimSpace(req.Description)
+	}
+	if req.Probability != 0 {
+		risk.Probability = req.Probability
+	}
+	if req.Impact != 0 {
+		risk.Impact = req.Impact
+	}
+	if req.Mitigation != nil {
+		risk.Mitigation = strings.TrimSpace(*req.Mitigation)
+	}
+	if req.OwnerID != nil {
+		risk.OwnerID = *req.OwnerID
+	}
+	if req.Status != "" {
+		risk.Status = req.Status
+	}
+
+	v := newValidator()
+	v.required(risk.Description, "description")
+	checkRisk(v, risk.GroupID, risk.Probability, risk.Impact, risk.OwnerID, risk.Status)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	risk.Score = risk.Probability * risk.Impact
+	risk.UpdatedAt = time.Now()
+
+	if err := modules.RedisClient.SaveRisk(risk); err != nil {
+		respondWithError(w, "Failed to update risk", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("risks")
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Risk updated successfully",
+		"risk":    risk,
+	})
+}
+
+func deleteGroupRisk(w http.ResponseWriter, r *http.Request, risk *models.Risk) {
+	if err := modules.RedisClient.DeleteRisk(risk.ID); err != nil {
+		respondWithDomainError(w, r, err, "Failed to delete risk")
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("risks")
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Risk deleted successfully",
+	})
+}
+
+// checkRisk validates the ratings, owner and status of a risk in a group
+func checkRisk(v *validator, groupID, probability, impact, ownerID int, status string) {
+	v.check(probability >= 1 && probability <= 5, "probability", "between", 1, 5)
+	v.check(impact >= 1 && impact <= 5, "impact", "between", 1, 5)
+	v.check(validRiskStatuses[status], "status", "invalid_choice", "open, mitigating, closed")
+
+	if ownerID != 0 {
+		checkGroupMember(v, "owner_id", ownerID, groupID)
+	}
+}
+
+func sortRisks(risks []*models.Risk) {
+	sort.Slice(risks, func(i, j int) bool {
+		if risks[i].Score != risks[j].Score {
+			return risks[i].Score > risks[j].Score
+		}
+		return risks[i].ID < risks[j].ID
+	})
+}
+
+// riskSummary rolls a group's risks up for its stats. Only risks that are
+// not closed count towards the exposure.
+func riskSummary(risks []*models.Risk) map[string]interface{} {
+	byStatus := map[string]int{
+		models.RiskOpen:       0,
+		models.RiskMitigating: 0,
+		models.RiskClosed:     0,
+	}
+	activeRisks := 0
+	totalScore := 0
+	maxScore := 0
+
+	for _, risk := range risks {
+		byStatus[risk.Status]++
+		if risk.Status == models.RiskClosed {
+			continue
+		}
+
+		activeRisks++
+		totalScore += risk.Score
+		if risk.Score > maxScore {
+			maxScore = risk.Score
+		}
+	}
+
+	return map[string]interface{}{
+		"active":      activeRisks,
+		"by_status":   byStatus,
+		"total_score": totalScore,
+		"max_score":   maxScore,
+		"level":       riskLevel(maxScore),
+	}
+}
+
+// riskLevel grades a 1-25 risk score the way a 5x5 risk matrix does
+func riskLevel(score int) string {
+	switch {
+	case score == 0:
+		return "none"
+	case score <= 4:
+		return "low"
+	case score <= 9:
+		return "medium"
+	case score <= 16:
+		return "high"
+	default:
+		return "critical"
+	}
+}
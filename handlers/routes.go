@@ -0,0 +1,97 @@
+package handlers
+
+import "net/http"
+
+// RegisterRoutes adds the API routes served by this package to mux. The
+// admin and health endpoints that belong to the server binary are added
+// by main.
+func RegisterRoutes(mux *http.ServeMux) {
+	// User routes
+	mux.HandleFunc("/users", UsersHandler)
+	mux.HandleFunc("/users/", UserHandler)
+	mux.HandleFunc("/users/search", SearchUsersHandler)
+
+	// Group routes
+	mux.HandleFunc("/groups", GroupsHandler)
+	mux.HandleFunc("/groups/", GroupHandler)
+
+	// Client routes
+	mux.HandleFunc("/clients", ClientsHandler)
+	mux.HandleFunc("/clients/", ClientHandler)
+	mux.HandleFunc("/portal", PortalHandler)
+
+	// Intake forms: managed by the owner, submitted publicly by token
+	mux.HandleFunc("/intake-forms", IntakeFormsHandler)
+	mux.HandleFunc("/intake-forms/", IntakeFormHandler)
+	mux.HandleFunc("/intake/", IntakeHandler)
+
+	// Inbound email gateway webhook
+	mux.HandleFunc("/inbound/email", InboundEmailHandler)
+
+	// Objectives and key results
+	mux.HandleFunc("/objectives", ObjectivesHandler)
+	mux.HandleFunc("/objectives/", ObjectiveHandler)
+
+	// Import routes
+	mux.HandleFunc("/imports/", ImportsHandler)
+
+	// Task templates, portable between deployments
+	mux.HandleFunc("/templates", TaskTemplatesHandler)
+	mux.HandleFunc("/templates/", TaskTemplateHandler)
+
+	// Session and token routes
+	mux.HandleFunc("/auth/login", LoginHandler)
+	mux.HandleFunc("/auth/logout", LogoutHandler)
+	mux.HandleFunc("/auth/session", SessionHandler)
+	mux.HandleFunc("/auth/token", TokenHandler)
+	mux.HandleFunc("/auth/refresh", RefreshHandler)
+	mux.HandleFunc("/auth/revoke", RevokeHandler)
+	mux.HandleFunc("/auth/password-policy", PasswordPolicyHandler)
+
+	// Holiday calendars
+	mux.HandleFunc("/holidays", HolidaysHandler)
+	mux.HandleFunc("/holidays/", HolidaysHandler)
+
+	// Global search
+	mux.HandleFunc("/search", GlobalSearchHandler)
+
+	// Reports over the task history in PostgreSQL
+	mux.HandleFunc("/reports/", ReportingHandler)
+
+	// Dashboards read from projections kept up by the projector
+	mux.HandleFunc("/dashboards/", DashboardHandler)
+
+	// Realtime event stream
+	mux.HandleFunc("/stream", StreamHandler)
+	mux.HandleFunc("/ws", WebSocketHandler)
+	mux.HandleFunc("/capabilities", CapabilitiesHandler)
+
+	// What the caller may do and why
+	mux.HandleFunc("/whoami/permissions", WhoAmIPermissionsHandler)
+
+	// API quotas: the caller's use, and the owner's overrides
+	mux.HandleFunc("/quota", QuotaHandler)
+	mux.HandleFunc("/admin/quotas", QuotasHandler)
+	mux.HandleFunc("/admin/quotas/", QuotasHandler)
+
+	// Global task routes
+	mux.HandleFunc("/tasks/search", SearchTasksHandler)
+	mux.HandleFunc("/tasks/stats", GetTaskStatsHandler)
+	mux.HandleFunc("/tasks/batch", BatchUpdateTasksHandler)
+	mux.HandleFunc("/tasks/filter", GetTasksWithFiltersHandler)
+	mux.HandleFunc("/tasks/key/", TaskByKeyHandler)
+	mux.HandleFunc("/tasks/quick", QuickAddTaskHandler)
+	mux.HandleFunc("/tasks/", TaskHandler)
+
+	// Admin routes
+	mux.HandleFunc("/admin/users/", AdminUserHandler)
+	mux.HandleFunc("/admin/settings", SettingsHandler)
+	mux.HandleFunc("/admin/config", ConfigHandler)
+	mux.HandleFunc("/admin/backups", BackupsHandler)
+	mux.HandleFunc("/admin/backups/", BackupsHandler)
+	mux.HandleFunc("/admin/sessions", SessionsHandler)
+	mux.HandleFunc("/admin/sessions/", SessionsHandler)
+	mux.HandleFunc("/admin/analytics/api", APIAnalyticsHandler)
+	mux.HandleFunc("/admin/jobs", JobsHandler)
+	mux.HandleFunc("/admin/jobs/", JobsHandler)
+}
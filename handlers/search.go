@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"task-manager/modules"
+)
+
+// GlobalSearchHandler handles ranked full-text search across entities /search
+func GlobalSearchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if modules.PostgresClient == nil {
+		respondWithError(w, "Search needs PostgreSQL, which is not connected", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		respondWithError(w, "Query parameter 'q' is required", http.StatusBadRequest)
+		return
+	}
+
+	types := modules.SearchTypes
+	if typesParam := r.URL.Query().Get("types"); typesParam != "" {
+		types = nil
+		for _, searchType := range strings.Split(typesParam, ",") {
+			searchType = strings.TrimSpace(searchType)
+			valid := false
+			for _, known := range modules.SearchTypes {
+				if searchType == known {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				respondWithError(w, fmt.Sprintf("Invalid type '%s'. Must be one of: %s", searchType, strings.Join(modules.SearchTypes, ", ")), http.StatusBadRequest)
+				return
+			}
+			types = append(types, searchType)
+		}
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if n, err := strconv.Atoi(limitStr); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+
+	authCtx := modules.GetAuthContext(r)
+
+	// Build the visibility scope from the caller's role
+	scope := modules.SearchScope{All: authCtx.IsOwner}
+	if !authCtx.IsOwner {
+		scope.UserID = authCtx.User.ID
+		if authCtx.IsGroupAdmin {
+			scope.GroupIDs = authCtx.AdminGroupIDs
+		}
+	}
+
+	results, err := modules.PostgresClient.Search(query, types, scope, limit)
+	if errors.Is(err, modules.ErrEmptySearchQuery) {
+		respondWithError(w, "Query must contain at least one letter or digit", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		respondWithDomainError(w, r, err, "Search failed")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"query":   query,
+		"types":   types,
+		"results": results,
+		"count":   len(results),
+	})
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"task-manager/config"
+	"task-manager/modules"
+)
+
+// SessionsHandler handles /admin/sessions: GET ?user_id= lists a user's
+// cookie sessions and token sign-ins with their recent revocations, and
+// DELETE /admin/sessions/{id} signs one out
+func SessionsHandler(w http.ResponseWriter, r *http.Request) {
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		respondWithError(w, "Only owner can manage sessions", http.StatusForbidden)
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/sessions"), "/")
+	switch {
+	case id == "" && r.Method == "GET":
+		listUserSignIns(w, r)
+	case id != "" && !strings.Contains(id, "/") && r.Method == "DELETE":
+		revokeSignIn(w, r, id)
+	case id == "" || !strings.Contains(id, "/"):
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+func listUserSignIns(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil {
+		respondWithError(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+	if _, err := modules.RedisClient.GetUser(userID); err != nil {
+		respondWithDomainError(w, r, err, "User not found")
+		return
+	}
+
+	signIns, err := modules.RedisClient.ListUserSignIns(userID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to list sessions")
+		return
+	}
+	revocations, err := modules.RedisClient.GetSignInRevocations(userID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to list revoked sessions")
+		return
+	}
+
+	cfg := config.AppConfig
+	respondWithSuccess(w, map[string]interface{}{
+		"user_id":      userID,
+		"sessions":     signIns,
+		"count":        len(signIns),
+		"revoked":      revocations,
+		"max_sessions": cfg.MaxSessionsPerUser,
+		"idle_timeout": cfg.SessionIdleTimeout.String(),
+	})
+}
+
+func revokeSignIn(w http.ResponseWriter, r *http.Request, id string) {
+	// IDs are hex hashes, which keeps them from naming other Redis keys
+	if _, err := hex.DecodeString(id); err != nil || len(id) != 64 {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	signIn, err := modules.RedisClient.RevokeSignIn(id)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Session not found")
+		return
+	}
+
+	handlerLog.InfoContext(r.Context(), "Session revoked by owner",
+		"user_id", signIn.UserID, "kind", signIn.Kind)
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Session revoked",
+		"session": signIn,
+	})
+}
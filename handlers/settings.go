@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+type settingsRequest struct {
+	Name            *string                          `json:"name"`
+	LogoURL         *string                          `json:"logo_url"`
+	Timezone        *string                          `json:"timezone"`
+	WorkingHours    map[string][]models.WorkInterval `json:"working_hours"`
+	WeekStart       *string                          `json:"week_start"`
+	FiscalYearStart *int                             `json:"fiscal_year_start"`
+}
+
+// SettingsHandler handles GET/PUT /admin/settings, the organization-wide defaults
+func SettingsHandler(w http.ResponseWriter, r *http.Request) {
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		respondWithError(w, "Only owner can manage organization settings", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		settings, err := modules.RedisClient.GetOrganizationSettings()
+		if err != nil {
+			respondWithError(w, "Failed to get settings", http.StatusInternalServerError)
+			return
+		}
+		respondWithSuccess(w, map[string]interface{}{
+			"settings": settings,
+		})
+	case "PUT":
+		updateSettings(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func updateSettings(w http.ResponseWriter, r *http.Request) {
+	var req settingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	settings, err := modules.RedisClient.GetOrganizationSettings()
+	if err != nil {
+		respondWithError(w, "Failed to get settings", http.StatusInternalServerError)
+		return
+	}
+
+	if req.Name != nil {
+		settings.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.LogoURL != nil {
+		settings.LogoURL = strings.TrimSpace(*req.LogoURL)
+	}
+	if req.Timezone != nil {
+		settings.Timezone = strings.TrimSpace(*req.Timezone)
+	}
+	if req.WorkingHours != nil {
+		settings.WorkingHours = workTimesFrom(req.WorkingHours)
+	}
+	if req.WeekStart != nil {
+		settings.WeekStart = strings.ToLower(strings.TrimSpace(*req.WeekStart))
+	}
+	if req.FiscalYearStart != nil {
+		settings.FiscalYearStart = *req.FiscalYearStart
+	}
+
+	v := newValidator()
+	checkSettings(v, settings)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	now := time.Now()
+	settings.UpdatedAt = &now
+	if err := modules.RedisClient.SaveOrganizationSettings(settings); err != nil {
+		respondWithError(w, "Failed to update settings", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":  "Settings updated successfully",
+		"settings": settings,
+	})
+}
+
+func checkSettings(v *validator, settings *modules.OrganizationSettings) {
+	v.required(settings.Name, "name")
+
+	if settings.LogoURL != "" {
+		parsed, err := url.Parse(settings.LogoURL)
+		v.check(err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "", "logo_url", "invalid_url")
+	}
+
+	_, err := time.LoadLocation(settings.Timezone)
+	v.check(settings.Timezone != "" && err == nil, "timezone", "invalid_timezone")
+
+	checkWorkTimes(v, "working_hours", settings.WorkingHours)
+
+	_, isWeekday := modules.Weekdays[settings.WeekStart]
+	v.check(isWeekday, "week_start", "invalid_choice", "sunday, monday, tuesday, wednesday, thursday, friday, saturday")
+	v.check(settings.FiscalYearStart >= 1 && settings.FiscalYearStart <= 12, "fiscal_year_start", "between", 1, 12)
+}
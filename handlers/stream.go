@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"task-manager/modules"
+	"time"
+)
+
+const streamHeartbeatInterval = 25 * time.Second
+
+// StreamHandler pushes realtime events to the caller as Server-Sent Events /stream
+func StreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if modules.Events == nil {
+		respondWithError(w, "Event streaming is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Optional comma-separated event type filter, e.g. ?types=task.created,task.updated
+	var types map[string]bool
+	if typesParam := r.URL.Query().Get("types"); typesParam != "" {
+		types = make(map[string]bool)
+		for _, eventType := range strings.Split(typesParam, ",") {
+			types[strings.TrimSpace(eventType)] = true
+		}
+	}
+
+	// Streams outlive the server write timeout, so lift the deadline for this connection
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	events := modules.Events.Subscribe()
+	defer modules.Events.Unsubscribe(events)
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	fmt.Fprint(w, ": connected\n\n")
+	controller.Flush()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok || modules.StreamRevoked(authCtx, event) {
+				return
+			}
+			if types != nil && !types[event.Type] {
+				continue
+			}
+			if !modules.CanReceiveEvent(authCtx, event) {
+				continue
+			}
+
+			payload, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, payload)
+		case <-heartbeat.C:
+			if modules.StreamRevoked(authCtx, nil) {
+				return
+			}
+			fmt.Fprint(w, ": ping\n\n")
+		case <-r.Context().Done():
+			return
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
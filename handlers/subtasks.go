@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"task-manager/models"
+	"task-manager/modules"
+)
+
+// checkParentTask fails parent_task_id unless it names a task of the same
+// group that is not taskID or one of its subtasks; taskID is 0 for a task
+// not created yet
+func checkParentTask(v *validator, parentID, taskID, groupID int) {
+	if parentID == 0 {
+		return
+	}
+
+	parent, err := modules.RedisClient.GetTask(parentID)
+	if err != nil {
+		v.check(false, "parent_task_id", "task_not_found", parentID)
+		return
+	}
+	v.check(parent.GroupID == groupID, "parent_task_id", "task_missing", parentID)
+	if taskID != 0 {
+		cyclic, err := modules.RedisClient.IsSubtaskOf(parentID, taskID)
+		v.check(err == nil && !cyclic, "parent_task_id", "parent_cycle")
+	}
+}
+
+// getUserSubtasks handles GET /users/{id}/tasks/{tid}/subtasks: the task's
+// direct subtasks, whoever they are assigned to, and how many are done
+func getUserSubtasks(w http.ResponseWriter, r *http.Request, userID, taskID int) {
+	task, err := modules.RedisClient.GetTask(taskID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Task not found")
+		return
+	}
+
+	if !task.IsAssignee(userID) {
+		respondWithError(w, "Task does not belong to this user", http.StatusNotFound)
+		return
+	}
+
+	subtasks, err := modules.RedisClient.GetSubtasks(taskID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to get subtasks")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"task_id":  taskID,
+		"subtasks": subtasks,
+		"count":    len(subtasks),
+		"progress": modules.RollUpSubtasks(subtasks),
+	})
+}
+
+// subtaskProgress rolls up a task's subtasks for its response, leaving it
+// out when the task has none or they cannot be read
+func subtaskProgress(task *models.Task) *modules.SubtaskProgress {
+	progress, err := modules.RedisClient.GetSubtaskProgress(task.ID)
+	if err != nil {
+		return nil
+	}
+	return progress
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"task-manager/models"
+	"task-manager/modules"
+)
+
+// maxMoveTasks bounds one move, which runs as a single Redis transaction
+const maxMoveTasks = 500
+
+// moveGroupTasks handles POST /groups/{id}/tasks/move, moving tasks to
+// another group along with their indexes, in one step
+func moveGroupTasks(w http.ResponseWriter, r *http.Request, groupID int) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.MoveTasksRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	taskIDs := make([]int, 0, len(req.TaskIDs))
+	seen := make(map[int]bool, len(req.TaskIDs))
+	for _, taskID := range req.TaskIDs {
+		if !seen[taskID] {
+			seen[taskID] = true
+			taskIDs = append(taskIDs, taskID)
+		}
+	}
+
+	v := newValidator()
+	v.check(req.TargetGroupID != 0, "target_group_id", "required")
+	v.check(req.TargetGroupID != groupID, "target_group_id", "same_group")
+	v.check(len(taskIDs) > 0, "task_ids", "required")
+	v.check(len(taskIDs) <= maxMoveTasks, "task_ids", "between", 1, maxMoveTasks)
+	if v.valid() {
+		_, err := modules.RedisClient.GetGroup(req.TargetGroupID)
+		v.check(err == nil, "target_group_id", "not_found")
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if !administersGroup(authCtx, groupID) || !administersGroup(authCtx, req.TargetGroupID) {
+		respondWithError(w, "You can only move tasks between groups you administer", http.StatusForbidden)
+		return
+	}
+	if groupFrozen(w, r, groupID, req.TargetGroupID) {
+		return
+	}
+
+	for _, to := range req.AssigneeMap {
+		checkGroupMember(v, "assignee_map", to, req.TargetGroupID)
+	}
+	members := make(map[int]bool)
+	for _, taskID := range taskIDs {
+		task, err := modules.RedisClient.GetTask(taskID)
+		if err != nil || task.GroupID != groupID {
+			v.check(false, "task_ids", "task_missing", taskID)
+			continue
+		}
+		for _, userID := range task.Assignees() {
+			if to, ok := req.AssigneeMap[userID]; ok {
+				userID = to
+			}
+			if _, checked := members[userID]; !checked {
+				members[userID] = userInGroup(userID, req.TargetGroupID)
+			}
+			v.check(members[userID], "assignee_map", "assignee_outside", userID)
+		}
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	tasks, err := modules.RedisClient.MoveTasks(groupID, req.TargetGroupID, taskIDs, req.AssigneeMap)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to move tasks")
+		return
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("tasks")
+
+	for _, task := range tasks {
+		modules.Events.PublishTask(r.Context(), "task.updated", task, task)
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":         "Tasks moved successfully",
+		"source_group_id": groupID,
+		"target_group_id": req.TargetGroupID,
+		"tasks":           tasks,
+		"count":           len(tasks),
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"task-manager/models"
 	"task-manager/modules"
 )
@@ -39,6 +41,64 @@ func SearchTasksHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// TaskByKeyHandler resolves a task key such as GASK-142 /tasks/key/{key}
+func TaskByKeyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	key := strings.Trim(strings.TrimPrefix(r.URL.Path, "/tasks/key/"), "/")
+	if !modules.IsTaskKey(key) {
+		respondWithError(w, "Invalid task key", http.StatusBadRequest)
+		return
+	}
+
+	task, err := modules.RedisClient.GetTaskByKey(key)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Task not found")
+		return
+	}
+
+	// Tasks the caller may not see are reported as missing
+	if !modules.CanViewTask(modules.GetAuthContext(r), task) {
+		respondWithError(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	setETag(w, task.Version)
+	respondWithSuccess(w, taskResponse(task))
+}
+
+// TaskHandler handles the task routes addressed by ID alone, without the
+// assignee: /tasks/{id}/comments[/{cid}] and /tasks/{id}/attachments[/{aid}]
+func TaskHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/tasks/"), "/")
+	parts := strings.Split(path, "/")
+
+	taskID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+	if len(parts) < 2 || (parts[1] != "comments" && parts[1] != "attachments") {
+		http.Error(w, "Invalid task sub-path", http.StatusBadRequest)
+		return
+	}
+
+	task, err := modules.RedisClient.GetTask(taskID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Task not found")
+		return
+	}
+
+	if parts[1] == "attachments" {
+		handleTaskAttachments(w, r, task, parts[2:])
+	} else {
+		handleTaskComments(w, r, task, parts[2:])
+	}
+}
+
 // GetTaskStatsHandler provides task statistics
 func GetTaskStatsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
@@ -133,11 +193,27 @@ func GetTasksByGroupHandler(w http.ResponseWriter, r *http.Request, groupID int)
 		tasks = filteredTasks
 	}
 
-	respondWithSuccess(w, map[string]interface{}{
+	response := map[string]interface{}{
 		"group_id": groupID,
 		"tasks":    tasks,
 		"count":    len(tasks),
-	})
+	}
+
+	// Tasks other groups shared with this one are listed apart, for those
+	// who see the whole group
+	if authCtx.IsOwner || authCtx.IsGroupAdmin {
+		visibleTasks, err := modules.RedisClient.GetGroupVisibleTasks(groupID)
+		if err != nil {
+			respondWithError(w, fmt.Sprintf("Failed to get group tasks: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if visibleTasks == nil {
+			visibleTasks = []*models.Task{}
+		}
+		response["visible_tasks"] = visibleTasks
+	}
+
+	respondWithSuccess(w, response)
 }
 
 // Helper function for checking if user is in admin groups
@@ -172,9 +248,10 @@ func filterTaskSearchResults(authCtx *modules.AuthContext, searchResults []*mode
 		if result.Task.UserID == authCtx.User.ID {
 			canSee = true
 		} else if authCtx.IsGroupAdmin {
-			// Group admins can see tasks from their administered groups
+			// Group admins can see tasks from their administered groups,
+			// and those shared with them
 			for _, adminGroupID := range authCtx.AdminGroupIDs {
-				if result.Task.GroupID == adminGroupID {
+				if result.Task.VisibleTo(adminGroupID) {
 					canSee = true
 					break
 				}
@@ -203,137 +280,108 @@ func getGlobalTaskStats() (map[string]interface{}, error) {
 		return nil, err
 	}
 
-	stats := make(map[string]interface{})
-	totalTasks := 0
-	completedTasks := 0
-	pendingTasks := 0
+	counts, err := modules.RedisClient.GetGlobalTaskCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]int, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	perUser, err := modules.RedisClient.GetUserTaskStats(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	userTaskCounts := make(map[string]int)
 	groupTaskCounts := make(map[int]int)
-
 	for _, user := range users {
-		tasks, err := modules.RedisClient.GetUserTasks(user.ID)
-		if err != nil {
-			continue
-		}
-
-		userTaskCount := len(tasks)
-		userTaskCounts[user.FullName] = userTaskCount
-		totalTasks += userTaskCount
-
-		for _, task := range tasks {
-			if task.Status {
-				completedTasks++
-			} else {
-				pendingTasks++
-			}
-
-			groupTaskCounts[task.GroupID]++
+		userTaskCounts[user.FullName] = int(perUser[user.ID].Total)
+		for groupID, count := range perUser[user.ID].ByGroup {
+			groupTaskCounts[groupID] += int(count)
 		}
 	}
 
-	stats["total_tasks"] = totalTasks
-	stats["completed_tasks"] = completedTasks
-	stats["pending_tasks"] = pendingTasks
-	stats["completion_rate"] = 0.0
-
-	if totalTasks > 0 {
-		stats["completion_rate"] = float64(completedTasks) / float64(totalTasks) * 100
-	}
-
-	stats["user_task_counts"] = userTaskCounts
-	stats["group_task_counts"] = groupTaskCounts
-	stats["total_users"] = len(users)
-
-	return stats, nil
+	return map[string]interface{}{
+		"total_tasks":       counts.Total,
+		"completed_tasks":   counts.Done,
+		"cancelled_tasks":   counts.Cancelled,
+		"blocked_tasks":     counts.Blocked,
+		"pending_tasks":     counts.Pending(),
+		"completion_rate":   counts.CompletionRate(),
+		"user_task_counts":  userTaskCounts,
+		"group_task_counts": groupTaskCounts,
+		"total_users":       len(users),
+	}, nil
 }
 
 func getGroupAdminTaskStats(adminGroupIDs []int) (map[string]interface{}, error) {
-	stats := make(map[string]interface{})
-	totalTasks := 0
-	completedTasks := 0
-	pendingTasks := 0
-	userTaskCounts := make(map[string]int)
-	groupTaskCounts := make(map[int]int)
+	perGroup, err := modules.RedisClient.GetGroupTaskStats(adminGroupIDs)
+	if err != nil {
+		return nil, err
+	}
 
+	total := &modules.TaskCounts{}
+	groupTaskCounts := make(map[int]int)
+	userTaskCounts := make(map[string]int)
 	for _, groupID := range adminGroupIDs {
-		tasks, err := modules.RedisClient.GetGroupTasks(groupID)
-		if err != nil {
-			continue
-		}
-
-		groupTaskCount := len(tasks)
-		groupTaskCounts[groupID] = groupTaskCount
-		totalTasks += groupTaskCount
-
-		for _, task := range tasks {
-			if task.Status {
-				completedTasks++
-			} else {
-				pendingTasks++
-			}
-
+		counts := perGroup[groupID]
+		total.Total += counts.Total
+		total.Done += counts.Done
+		total.Cancelled += counts.Cancelled
+		total.Blocked += counts.Blocked
+		groupTaskCounts[groupID] = int(counts.Total)
+
+		for userID, count := range counts.ByUser {
 			// Get user name for stats
-			user, err := modules.RedisClient.GetUser(task.UserID)
+			user, err := modules.RedisClient.GetUser(userID)
 			if err == nil {
-				userTaskCounts[user.FullName]++
+				userTaskCounts[user.FullName] += int(count)
 			}
 		}
 	}
 
-	stats["total_tasks"] = totalTasks
-	stats["completed_tasks"] = completedTasks
-	stats["pending_tasks"] = pendingTasks
-	stats["completion_rate"] = 0.0
-
-	if totalTasks > 0 {
-		stats["completion_rate"] = float64(completedTasks) / float64(totalTasks) * 100
-	}
-
-	stats["user_task_counts"] = userTaskCounts
-	stats["group_task_counts"] = groupTaskCounts
-	stats["administered_groups"] = adminGroupIDs
-
-	return stats, nil
+	return map[string]interface{}{
+		"total_tasks":         total.Total,
+		"completed_tasks":     total.Done,
+		"cancelled_tasks":     total.Cancelled,
+		"blocked_tasks":       total.Blocked,
+		"pending_tasks":       total.Pending(),
+		"completion_rate":     total.CompletionRate(),
+		"user_task_counts":    userTaskCounts,
+		"group_task_counts":   groupTaskCounts,
+		"administered_groups": adminGroupIDs,
+	}, nil
 }
 
 func getUserTaskStats(userID int) (map[string]interface{}, error) {
-	tasks, err := modules.RedisClient.GetUserTasks(userID)
+	perUser, err := modules.RedisClient.GetUserTaskStats([]int{userID})
 	if err != nil {
 		return nil, err
 	}
+	counts := perUser[userID]
 
-	stats := make(map[string]interface{})
-	totalTasks := len(tasks)
-	completedTasks := 0
-	pendingTasks := 0
 	priorityCounts := make(map[int]int)
-	groupTaskCounts := make(map[int]int)
-
-	for _, task := range tasks {
-		if task.Status {
-			completedTasks++
-		} else {
-			pendingTasks++
-		}
-
-		priorityCounts[task.Priority]++
-		groupTaskCounts[task.GroupID]++
+	for priority, count := range counts.ByPriority {
+		priorityCounts[priority] = int(count)
 	}
-
-	stats["total_tasks"] = totalTasks
-	stats["completed_tasks"] = completedTasks
-	stats["pending_tasks"] = pendingTasks
-	stats["completion_rate"] = 0.0
-
-	if totalTasks > 0 {
-		stats["completion_rate"] = float64(completedTasks) / float64(totalTasks) * 100
+	groupTaskCounts := make(map[int]int)
+	for groupID, count := range counts.ByGroup {
+		groupTaskCounts[groupID] = int(count)
 	}
 
-	stats["priority_counts"] = priorityCounts
-	stats["group_task_counts"] = groupTaskCounts
-	stats["user_id"] = userID
-
-	return stats, nil
+	return map[string]interface{}{
+		"total_tasks":       counts.Total,
+		"completed_tasks":   counts.Done,
+		"cancelled_tasks":   counts.Cancelled,
+		"blocked_tasks":     counts.Blocked,
+		"pending_tasks":     counts.Pending(),
+		"completion_rate":   counts.CompletionRate(),
+		"priority_counts":   priorityCounts,
+		"group_task_counts": groupTaskCounts,
+		"user_id":           userID,
+	}, nil
 }
 
 // Task batch operations
@@ -361,6 +409,16 @@ func BatchUpdateTasksHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	v := newValidator()
+	v.check(req.Updates.Priority == 0 || req.Updates.Priority.Valid(), "updates.priority", "invalid_choice", models.PriorityLabels)
+	if req.Updates.State != "" {
+		checkTaskState(v, &req.Updates, "updates.")
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
 	authCtx := modules.GetAuthContext(r)
 	var updatedTasks []*models.Task
 	var errors []string
@@ -377,6 +435,10 @@ func BatchUpdateTasksHandler(w http.ResponseWriter, r *http.Request) {
 			errors = append(errors, fmt.Sprintf("Insufficient permissions for task %d", taskID))
 			continue
 		}
+		if modules.RedisClient.CheckGroupWritable(task.GroupID, req.Updates.GroupID) != nil {
+			errors = append(errors, fmt.Sprintf("Task %d belongs to an archived group", taskID))
+			continue
+		}
 
 		// Perform action
 		switch req.Action {
@@ -385,6 +447,8 @@ func BatchUpdateTasksHandler(w http.ResponseWriter, r *http.Request) {
 		case "delete":
 			if err := modules.RedisClient.DeleteTask(taskID); err != nil {
 				errors = append(errors, fmt.Sprintf("Failed to delete task %d", taskID))
+			} else {
+				modules.Events.PublishTask(r.Context(), "task.deleted", task, task)
 			}
 			continue
 		default: // "update" or empty (default to update)
@@ -401,12 +465,31 @@ func BatchUpdateTasksHandler(w http.ResponseWriter, r *http.Request) {
 			if req.Updates.Information != "" {
 				task.Information = req.Updates.Information
 			}
+			if req.Updates.EstimatedHours > 0 {
+				task.EstimatedHours = req.Updates.EstimatedHours
+			}
 			if req.Updates.Status != nil {
 				task.Status = *req.Updates.Status
 			}
+			if req.Updates.State != "" {
+				if req.Updates.BlockedBy == task.ID {
+					errors = append(errors, fmt.Sprintf("Task %d cannot block itself", taskID))
+					continue
+				}
+				applyTaskState(task, &req.Updates)
+			}
 			if req.Updates.GroupID != 0 {
 				task.GroupID = req.Updates.GroupID
 			}
+			if req.Updates.ParentTaskID != nil {
+				v := newValidator()
+				checkParentTask(v, *req.Updates.ParentTaskID, task.ID, task.GroupID)
+				if !v.valid() {
+					errors = append(errors, fmt.Sprintf("Task %d cannot be a subtask of task %d", taskID, *req.Updates.ParentTaskID))
+					continue
+				}
+				task.ParentTaskID = *req.Updates.ParentTaskID
+			}
 		}
 
 		// Save updated task
@@ -415,6 +498,12 @@ func BatchUpdateTasksHandler(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		eventType := "task.updated"
+		if req.Action == "mark_done" {
+			eventType = "task.completed"
+		}
+		modules.Events.PublishTask(r.Context(), eventType, task, task)
+
 		updatedTasks = append(updatedTasks, task)
 	}
 
@@ -435,6 +524,45 @@ func BatchUpdateTasksHandler(w http.ResponseWriter, r *http.Request) {
 	respondWithSuccess(w, result)
 }
 
+// checkTaskState validates a requested state change. Fields are reported
+// under prefix, for requests that nest the update.
+func checkTaskState(v *validator, req *models.UpdateTaskRequest, prefix string) {
+	reason := strings.TrimSpace(req.StateReason)
+	switch req.State {
+	case models.TaskOpen, models.TaskDone:
+	case models.TaskBlocked:
+		v.check(reason != "" || req.BlockedBy != 0, prefix+"state_reason", "blocked_reason")
+		if req.BlockedBy != 0 {
+			_, err := modules.RedisClient.GetTask(req.BlockedBy)
+			v.check(err == nil, prefix+"blocked_by", "task_not_found", req.BlockedBy)
+		}
+	case models.TaskCancelled:
+		v.required(reason, prefix+"state_reason")
+	default:
+		v.check(false, prefix+"state", "invalid_choice", models.TaskStates)
+	}
+}
+
+// checkCollaborators fails collaborators unless each is a member of the group
+func checkCollaborators(v *validator, userIDs []int, groupID int) {
+	for _, userID := range userIDs {
+		checkGroupMember(v, "collaborators", userID, groupID)
+	}
+}
+
+// applyTaskState moves a task to a state checked by checkTaskState
+func applyTaskState(task *models.Task, req *models.UpdateTaskRequest) {
+	// Going from done to cancelled or back closes the task again
+	if task.CurrentState() != req.State {
+		task.ClosedAt = nil
+	}
+	task.Status = req.State == models.TaskDone
+	task.State = req.State
+	task.StateReason = strings.TrimSpace(req.StateReason)
+	task.BlockedBy = req.BlockedBy
+	task.SyncState()
+}
+
 // GetTasksWithFiltersHandler provides advanced task filtering
 func GetTasksWithFiltersHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
@@ -442,16 +570,32 @@ func GetTasksWithFiltersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse query parameters
 	query := r.URL.Query()
+	filter := taskFilter{
+		status:   query.Get("status"),   // "completed", "pending", "blocked", "cancelled", "all"
+		priority: query.Get("priority"), // "high", or its number "3"
+		groupID:  query.Get("group_id"), // filter by group
+		userID:   query.Get("user_id"),  // filter by user (if permitted)
+	}
 
-	status := query.Get("status")     // "completed", "pending", "all"
-	priority := query.Get("priority") // "1", "2", "3", etc.
-	groupID := query.Get("group_id")  // filter by group
-	userID := query.Get("user_id")    // filter by user (if permitted)
+	if filter.priority != "" {
+		var ok bool
+		filter.priorityLevel, ok = models.ParsePriority(filter.priority)
+		if !ok {
+			v := newValidator()
+			v.check(false, "priority", "invalid_choice", models.PriorityLabels)
+			respondWithValidationErrors(w, r, v)
+			return
+		}
+	}
 
 	authCtx := modules.GetAuthContext(r)
 
+	if wantsNDJSON(r) {
+		streamFilteredTasks(w, authCtx, filter)
+		return
+	}
+
 	var allTasks []*models.Task
 	var err error
 
@@ -472,13 +616,23 @@ func GetTasksWithFiltersHandler(w http.ResponseWriter, r *http.Request) {
 			allTasks = append(allTasks, tasks...)
 		}
 	} else if authCtx.IsGroupAdmin {
-		// Group admin sees tasks from their groups
+		// Group admin sees tasks from their groups and those shared with them
+		seen := make(map[int]bool)
 		for _, adminGroupID := range authCtx.AdminGroupIDs {
 			tasks, err := modules.RedisClient.GetGroupTasks(adminGroupID)
 			if err != nil {
 				continue
 			}
-			allTasks = append(allTasks, tasks...)
+			visibleTasks, err := modules.RedisClient.GetGroupVisibleTasks(adminGroupID)
+			if err != nil {
+				continue
+			}
+			for _, task := range append(tasks, visibleTasks...) {
+				if !seen[task.ID] {
+					seen[task.ID] = true
+					allTasks = append(allTasks, task)
+				}
+			}
 		}
 	} else {
 		// Regular user sees only their own tasks
@@ -491,62 +645,127 @@ func GetTasksWithFiltersHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Apply filters
 	var filteredTasks []*models.Task
-
 	for _, task := range allTasks {
-		// Status filter
-		if status != "" && status != "all" {
-			if status == "completed" && !task.Status {
-				continue
-			}
-			if status == "pending" && task.Status {
-				continue
-			}
+		if filter.matches(authCtx, task) {
+			filteredTasks = append(filteredTasks, task)
 		}
+	}
 
-		// Priority filter
-		if priority != "" {
-			var requestedPriority int
-			if n, err := fmt.Sscanf(priority, "%d", &requestedPriority); err != nil || n != 1 || task.Priority != requestedPriority {
-				continue
-			}
+	respondWithSuccess(w, map[string]interface{}{
+		"tasks": filteredTasks,
+		"count": len(filteredTasks),
+		"filters": map[string]string{
+			"status":   filter.status,
+			"priority": filter.priority,
+			"group_id": filter.groupID,
+			"user_id":  filter.userID,
+		},
+	})
+}
+
+// streamFilteredTasks writes the tasks GetTasksWithFiltersHandler would
+// return as NDJSON, reading them from Redis a batch at a time
+func streamFilteredTasks(w http.ResponseWriter, authCtx *modules.AuthContext, filter taskFilter) {
+	stream := newNDJSONStream(w)
+	each := func(task *models.Task) error {
+		if !filter.matches(authCtx, task) {
+			return nil
 		}
+		return stream.write(task)
+	}
 
-		// Group filter
-		if groupID != "" {
-			var requestedGroupID int
-			if n, err := fmt.Sscanf(groupID, "%d", &requestedGroupID); err != nil || n != 1 || task.GroupID != requestedGroupID {
-				continue
+	var err error
+	if authCtx.IsOwner {
+		err = modules.RedisClient.EachUser(func(user *models.User) error {
+			return modules.RedisClient.EachUserTask(user.ID, each)
+		})
+	} else if authCtx.IsGroupAdmin {
+		// A task shared between two of the admin's groups is written once
+		seen := make(map[int]bool)
+		eachOnce := func(task *models.Task) error {
+			if seen[task.ID] {
+				return nil
 			}
+			seen[task.ID] = true
+			return each(task)
 		}
-
-		// User filter (only if permitted)
-		if userID != "" {
-			requestedUserID := 0
-			fmt.Sscanf(userID, "%d", &requestedUserID)
-
-			// Check if requester can see this user's tasks
-			if !authCtx.IsOwner && authCtx.User.ID != requestedUserID {
-				if !authCtx.IsGroupAdmin || !isUserInAdminGroups(requestedUserID, authCtx.AdminGroupIDs) {
-					continue
+		for _, adminGroupID := range authCtx.AdminGroupIDs {
+			if err = modules.RedisClient.EachGroupTask(adminGroupID, eachOnce); err != nil {
+				break
+			}
+			var visibleTasks []*models.Task
+			if visibleTasks, err = modules.RedisClient.GetGroupVisibleTasks(adminGroupID); err != nil {
+				break
+			}
+			for _, task := range visibleTasks {
+				if err = eachOnce(task); err != nil {
+					break
 				}
 			}
+			if err != nil {
+				break
+			}
+		}
+	} else {
+		err = modules.RedisClient.EachUserTask(authCtx.User.ID, each)
+	}
+	stream.close(err)
+}
 
-			if task.UserID != requestedUserID {
-				continue
+// taskFilter holds the query parameters of /tasks/filter
+type taskFilter struct {
+	status   string
+	priority string
+	groupID  string
+	userID   string
+
+	// priorityLevel is priority parsed, which may be a label or a number
+	priorityLevel models.Priority
+}
+
+func (f taskFilter) matches(authCtx *modules.AuthContext, task *models.Task) bool {
+	// Status filter
+	if f.status != "" && f.status != "all" {
+		if f.status == "completed" && !task.Status {
+			return false
+		}
+		if f.status == "pending" && task.Closed() {
+			return false
+		}
+		if (f.status == models.TaskBlocked || f.status == models.TaskCancelled) && task.CurrentState() != f.status {
+			return false
+		}
+	}
+
+	// Priority filter
+	if f.priorityLevel != 0 && task.Priority != f.priorityLevel {
+		return false
+	}
+
+	// Group filter
+	if f.groupID != "" {
+		var requestedGroupID int
+		if n, err := fmt.Sscanf(f.groupID, "%d", &requestedGroupID); err != nil || n != 1 || !task.VisibleTo(requestedGroupID) {
+			return false
+		}
+	}
+
+	// User filter (only if permitted)
+	if f.userID != "" {
+		requestedUserID := 0
+		fmt.Sscanf(f.userID, "%d", &requestedUserID)
+
+		// Check if requester can see this user's tasks
+		if !authCtx.IsOwner && authCtx.User.ID != requestedUserID {
+			if !authCtx.IsGroupAdmin || !isUserInAdminGroups(requestedUserID, authCtx.AdminGroupIDs) {
+				return false
 			}
 		}
 
-		filteredTasks = append(filteredTasks, task)
+		if task.UserID != requestedUserID {
+			return false
+		}
 	}
 
-	respondWithSuccess(w, map[string]interface{}{
-		"tasks": filteredTasks,
-		"count": len(filteredTasks),
-		"filters": map[string]string{
-			"status":   status,
-			"priority": priority,
-			"group_id": groupID,
-			"user_id":  userID,
-		},
-	})
+	return true
 }
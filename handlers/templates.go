@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+	"time"
+)
+
+const (
+	maxTemplateSize  = 1 << 20
+	maxTemplateTasks = 200
+)
+
+var templateRefPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+var templateFilenameUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// TaskTemplatesHandler handles /templates: GET lists the imported templates
+// and POST imports a template document
+func TaskTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		getAllTaskTemplates(w, r)
+	case "POST":
+		importTaskTemplate(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// TaskTemplateHandler handles /templates/{id}, its portable document at
+// /templates/{id}/export and creating its tasks at /templates/{id}/apply
+func TaskTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/templates/"), "/")
+	parts := strings.Split(path, "/")
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	template, err := modules.RedisClient.GetTaskTemplate(id)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Template not found")
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		// /templates/{id}
+		switch r.Method {
+		case "GET":
+			respondWithSuccess(w, template)
+		case "PUT":
+			replaceTaskTemplate(w, r, template)
+		case "DELETE":
+			deleteTaskTemplate(w, r, template)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "export":
+		// /templates/{id}/export
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		exportTaskTemplate(w, template)
+	case len(parts) == 2 && parts[1] == "apply":
+		// /templates/{id}/apply
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		applyTaskTemplate(w, r, template)
+	default:
+		http.Error(w, "Invalid sub-path", http.StatusBadRequest)
+	}
+}
+
+func getAllTaskTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := modules.RedisClient.GetAllTaskTemplates()
+	if err != nil {
+		respondWithError(w, fmt.Sprintf("Failed to get templates: %v", err), http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].ID < templates[j].ID
+	})
+
+	respondWithSuccess(w, map[string]interface{}{
+		"templates": templates,
+		"count":     len(templates),
+	})
+}
+
+// readTemplateDocument decodes and validates a template document from the
+// request body, responding with the problem when it is not valid
+func readTemplateDocument(w http.ResponseWriter, r *http.Request) (*models.TaskTemplateDocument, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxTemplateSize)
+
+	var doc models.TaskTemplateDocument
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	v := newValidator()
+	checkTemplateDocument(v, &doc)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return nil, false
+	}
+	return &doc, true
+}
+
+// checkTemplateDocument validates a template document, trimming its text.
+// Tasks may only be blocked by tasks listed before them, so applying a
+// template never meets a cycle.
+func checkTemplateDocument(v *validator, doc *models.TaskTemplateDocument) {
+	doc.Name = strings.TrimSpace(doc.Name)
+	doc.Description = strings.TrimSpace(doc.Description)
+
+	v.check(doc.Format == models.TaskTemplateFormat, "format", "invalid_choice", models.TaskTemplateFormat)
+	v.required(doc.Name, "name")
+	v.check(len(doc.Tasks) >= 1 && len(doc.Tasks) <= maxTemplateTasks, "tasks", "between", 1, maxTemplateTasks)
+
+	refs := make(map[string]bool)
+	for i := range doc.Tasks {
+		task := &doc.Tasks[i]
+		number := i + 1
+		task.Ref = strings.TrimSpace(task.Ref)
+		task.Title = strings.TrimSpace(task.Title)
+		task.BlockedBy = strings.TrimSpace(task.BlockedBy)
+
+		v.check(task.Title != "", "tasks", "template_title", number)
+		if task.Ref != "" {
+			v.check(templateRefPattern.MatchString(task.Ref), "tasks", "template_ref", number, task.Ref)
+			v.check(!refs[task.Ref], "tasks", "duplicate_ref", task.Ref)
+		}
+		v.check(task.Priority == 0 || task.Priority.Valid(), "tasks", "template_priority", number, models.PriorityLabels)
+		v.check(task.EstimatedHours >= 0 && (task.DueAfterDays == nil || *task.DueAfterDays >= 0),
+			"tasks", "template_negative", number)
+		v.check(task.BlockedBy == "" || refs[task.BlockedBy], "tasks", "template_blocker", number, task.BlockedBy)
+
+		if task.Ref != "" {
+			refs[task.Ref] = true
+		}
+	}
+}
+
+// importTaskTemplate adds a template document to this deployment
+func importTaskTemplate(w http.ResponseWriter, r *http.Request) {
+	doc, ok := readTemplateDocument(w, r)
+	if !ok {
+		return
+	}
+
+	templateID, err := modules.RedisClient.GetNextTaskTemplateID()
+	if err != nil {
+		respondWithError(w, "Failed to generate template ID", http.StatusInternalServerError)
+		return
+	}
+
+	template := &models.TaskTemplate{
+		ID:                   templateID,
+		TaskTemplateDocument: *doc,
+		CreatedAt:            time.Now(),
+		UpdatedAt:            time.Now(),
+	}
+	if authCtx := modules.GetAuthContext(r); authCtx.User != nil {
+		template.CreatedBy = authCtx.User.ID
+	}
+
+	if err := modules.RedisClient.SaveTaskTemplate(template); err != nil {
+		respondWithError(w, "Failed to save template", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":  "Template imported successfully",
+		"template": template,
+	}, http.StatusCreated)
+}
+
+// replaceTaskTemplate replaces a template with a new version of its document
+func replaceTaskTemplate(w http.ResponseWriter, r *http.Request, template *models.TaskTemplate) {
+	doc, ok := readTemplateDocument(w, r)
+	if !ok {
+		return
+	}
+
+	template.TaskTemplateDocument = *doc
+	template.UpdatedAt = time.Now()
+	if err := modules.RedisClient.SaveTaskTemplate(template); err != nil {
+		respondWithError(w, "Failed to update template", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":  "Template updated successfully",
+		"template": template,
+	})
+}
+
+func deleteTaskTemplate(w http.ResponseWriter, r *http.Request, template *models.TaskTemplate) {
+	if err := modules.RedisClient.DeleteTaskTemplate(template.ID); err != nil {
+		respondWithDomainError(w, r, err, "Failed to delete template")
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Template deleted successfully",
+	})
+}
+
+// exportTaskTemplate downloads the template's portable document, ready to
+// be kept in git or imported elsewhere
+func exportTaskTemplate(w http.ResponseWriter, template *models.TaskTemplate) {
+	filename := strings.Trim(templateFilenameUnsafe.ReplaceAllString(strings.ToLower(template.Name), "-"), "-")
+	if filename == "" {
+		filename = fmt.Sprintf("template-%d", template.ID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(template.TaskTemplateDocument)
+}
+
+// applyTaskTemplate creates a template's tasks in a group the caller
+// administers, assigned to user_id: the caller when they are a member,
+// otherwise the group admin. Deadlines count from start_date, today by
+// default, and tasks blocked by another start out blocked by its new task.
+func applyTaskTemplate(w http.ResponseWriter, r *http.Request, template *models.TaskTemplate) {
+	var req models.ApplyTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	location := modules.OrgSettings().Location()
+	now := time.Now().In(location)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+
+	v := newValidator()
+	v.check(req.GroupID != 0, "group_id", "required")
+	if req.StartDate != "" {
+		date, err := time.ParseInLocation("2006-01-02", req.StartDate, location)
+		v.check(err == nil, "start_date", "invalid_date")
+		start = date
+	}
+
+	var group *models.Group
+	if req.GroupID != 0 {
+		var err error
+		group, err = modules.RedisClient.GetGroup(req.GroupID)
+		v.check(err == nil, "group_id", "not_found")
+		if err == nil {
+			if req.UserID == 0 {
+				req.UserID = group.AdminID
+				if authCtx.User != nil && userInGroup(authCtx.User.ID, group.ID) {
+					req.UserID = authCtx.User.ID
+				}
+			}
+			checkGroupMember(v, "user_id", req.UserID, group.ID)
+		}
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	if !administersGroup(authCtx, group.ID) {
+		respondWithError(w, "You can only apply templates to groups you administer", http.StatusForbidden)
+		return
+	}
+	if groupFrozen(w, r, group.ID) {
+		return
+	}
+
+	tasks := make([]*models.Task, 0, len(template.Tasks))
+	refs := make(map[string]int)
+	for _, item := range template.Tasks {
+		task := &models.Task{
+			Title:          item.Title,
+			Information:    item.Information,
+			Priority:       item.Priority,
+			EstimatedHours: item.EstimatedHours,
+			UserID:         req.UserID,
+			GroupID:        group.ID,
+			State:          models.TaskOpen,
+		}
+		if task.Priority == 0 {
+			task.Priority = models.PriorityLow
+		}
+		if item.DueAfterDays != nil {
+			task.Deadline = start.AddDate(0, 0, *item.DueAfterDays).Format("2006-01-02")
+		}
+		if item.BlockedBy != "" {
+			task.State = models.TaskBlocked
+			task.BlockedBy = refs[item.BlockedBy]
+		}
+
+		if err := saveImportedTask(task); err != nil {
+			handlerLog.ErrorContext(r.Context(), "❌ Failed to create template task", "template_id", template.ID,
+				"created", len(tasks), "error", err)
+			if len(tasks) > 0 {
+				modules.RedisClient.MarkDirty("tasks")
+			}
+			respondWithError(w, fmt.Sprintf("Failed to create task %q after creating %d", item.Title, len(tasks)), http.StatusInternalServerError)
+			return
+		}
+		if item.Ref != "" {
+			refs[item.Ref] = task.ID
+		}
+		tasks = append(tasks, task)
+		modules.Events.PublishTask(r.Context(), "task.created", task, task)
+	}
+
+	// Mark data as dirty for sync
+	modules.RedisClient.MarkDirty("tasks")
+
+	handlerLog.InfoContext(r.Context(), "📋 Template applied", "template_id", template.ID, "group_id", group.ID, "tasks", len(tasks))
+	respondWithSuccess(w, map[string]interface{}{
+		"message":     "Template applied successfully",
+		"template_id": template.ID,
+		"group_id":    group.ID,
+		"user_id":     req.UserID,
+		"tasks":       tasks,
+		"count":       len(tasks),
+	}, http.StatusCreated)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -47,7 +48,7 @@ func UserHandler(w http.ResponseWriter, r *http.Request) {
 	// Check if user exists
 	_, err = modules.RedisClient.GetUser(id)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "User not found")
 		return
 	}
 
@@ -71,6 +72,14 @@ func UserHandler(w http.ResponseWriter, r *http.Request) {
 		handleUserTasks(w, r, id, parts[2:])
 	} else if subPath == "worktimes" && len(parts) == 2 {
 		handleUserWorkTimes(w, r, id)
+	} else if subPath == "suggest-deadline" && len(parts) == 2 {
+		suggestUserDeadline(w, r, id)
+	} else if subPath == "user-admin" && len(parts) == 2 {
+		handleUserAdmin(w, r, id)
+	} else if subPath == "leaves" {
+		handleUserLeaves(w, r, id, parts[2:])
+	} else if subPath == "reports" {
+		handleUserReports(w, r, id, parts[2:])
 	} else {
 		http.Error(w, "Invalid sub-path", http.StatusBadRequest)
 	}
@@ -102,7 +111,7 @@ func SearchUsersHandler(w http.ResponseWriter, r *http.Request) {
 
 	respondWithSuccess(w, map[string]interface{}{
 		"query":   query,
-		"results": filteredUsers,
+		"results": userResponses(authCtx, filteredUsers),
 		"count":   len(filteredUsers),
 	})
 }
@@ -110,6 +119,18 @@ func SearchUsersHandler(w http.ResponseWriter, r *http.Request) {
 func getAllUsers(w http.ResponseWriter, r *http.Request) {
 	authCtx := modules.GetAuthContext(r)
 
+	if wantsNDJSON(r) {
+		stream := newNDJSONStream(w)
+		err := modules.RedisClient.EachUser(func(user *models.User) error {
+			if len(modules.FilterUsersByPermissions(authCtx, []*models.User{user})) == 0 {
+				return nil
+			}
+			return stream.write(userResponse(authCtx, user))
+		})
+		stream.close(err)
+		return
+	}
+
 	users, err := modules.RedisClient.GetAllUsers()
 	if err != nil {
 		respondWithError(w, fmt.Sprintf("Failed to get users: %v", err), http.StatusInternalServerError)
@@ -120,7 +141,7 @@ func getAllUsers(w http.ResponseWriter, r *http.Request) {
 	filteredUsers := modules.FilterUsersByPermissions(authCtx, users)
 
 	respondWithSuccess(w, map[string]interface{}{
-		"users": filteredUsers,
+		"users": userResponses(authCtx, filteredUsers),
 		"count": len(filteredUsers),
 	})
 }
@@ -133,8 +154,12 @@ func createUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate required fields
-	if req.FullName == "" || req.Email == "" || req.Password == "" {
-		respondWithError(w, "Full name, email and password are required", http.StatusBadRequest)
+	v := newValidator()
+	v.required(req.FullName, "full_name")
+	v.required(req.Email, "email")
+	v.required(req.Password, "password")
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
 		return
 	}
 
@@ -152,25 +177,27 @@ func createUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate role
-	if req.Role != "user" && req.Role != "group_admin" && req.Role != "owner" {
-		respondWithError(w, "Invalid role. Must be 'user', 'group_admin', or 'owner'", http.StatusBadRequest)
+	v.check(isValidRole(req.Role), "role", "invalid_choice", "user, group_admin, owner")
+	v.check(req.Region == "" || modules.IsValidRegion(req.Region), "region", "invalid_region")
+	checkWorkTimes(v, "work_times", workTimesFrom(req.WorkTimes))
+	checkPassword(v, "password", req.Password, nil)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
 		return
 	}
 
 	// Check if email already exists
-	existingUser, _ := modules.RedisClient.GetUserByEmail(req.Email)
+	existingUser, _ := modules.RedisClient.FindUserByEmail(req.Email)
 	if existingUser != nil {
 		respondWithError(w, "User with this email already exists", http.StatusConflict)
 		return
 	}
 
 	// Validate groups exist
-	for _, groupID := range req.GroupIDs {
-		_, err := modules.RedisClient.GetGroup(groupID)
-		if err != nil {
-			respondWithError(w, fmt.Sprintf("Group %d not found", groupID), http.StatusBadRequest)
-			return
-		}
+	checkGroupsExist(v, req.GroupIDs)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
 	}
 
 	// Get next user ID
@@ -188,15 +215,23 @@ func createUser(w http.ResponseWriter, r *http.Request) {
 		GroupIDs:  models.IntSlice(req.GroupIDs),
 		Number:    req.Number,
 		Email:     req.Email,
-		Password:  req.Password,
-		WorkTimes: models.WorkTimes(req.WorkTimes),
+		WorkTimes: workTimesFrom(req.WorkTimes),
+		Region:    req.Region,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	// Initialize WorkTimes if nil
+	if err := modules.SetPassword(user, req.Password); err != nil {
+		respondWithError(w, "Failed to hash password", http.StatusInternalServerError)
+		return
+	}
+
+	// Start from the organization's working hours if none were given
 	if user.WorkTimes == nil {
 		user.WorkTimes = make(models.WorkTimes)
+		for day, intervals := range modules.OrgSettings().WorkingHours {
+			user.WorkTimes[day] = append([]models.WorkInterval(nil), intervals...)
+		}
 	}
 
 	// Save user
@@ -208,26 +243,32 @@ func createUser(w http.ResponseWriter, r *http.Request) {
 	// Mark data as dirty for sync
 	modules.RedisClient.MarkDirty("users")
 
-	// Remove password from response
-	user.Password = ""
+	if err := modules.RedisClient.RecordPassword(user.ID, req.Password); err != nil {
+		handlerLog.WarnContext(r.Context(), "⚠️ Failed to record password history", "error", err)
+	}
+
+	// Send invitation email in the background
+	modules.Mailer.SendTemplateAsync(r.Context(), user.Email, "invite", map[string]interface{}{
+		"FullName": user.FullName,
+		"Email":    user.Email,
+		"Role":     user.Role,
+	})
 
 	respondWithSuccess(w, map[string]interface{}{
 		"message": "User created successfully",
-		"user":    user,
+		"user":    userResponse(modules.GetAuthContext(r), user),
 	}, http.StatusCreated)
 }
 
 func getUser(w http.ResponseWriter, r *http.Request, id int) {
 	user, err := modules.RedisClient.GetUser(id)
 	if err != nil {
-		respondWithError(w, "User not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "User not found")
 		return
 	}
 
-	// Remove password from response
-	user.Password = ""
-
-	respondWithSuccess(w, user)
+	setETag(w, user.Version)
+	respondWithSuccess(w, userResponse(modules.GetAuthContext(r), user))
 }
 
 func updateUser(w http.ResponseWriter, r *http.Request, id int) {
@@ -239,16 +280,30 @@ func updateUser(w http.ResponseWriter, r *http.Request, id int) {
 
 	user, err := modules.RedisClient.GetUser(id)
 	if err != nil {
-		respondWithError(w, "User not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "User not found")
 		return
 	}
 
 	authCtx := modules.GetAuthContext(r)
 
+	expectedVersion, checkVersion, err := parseIfMatch(r)
+	if err != nil {
+		respondWithError(w, "Invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+	if checkVersion && expectedVersion != user.Version {
+		respondWithConflict(w, "User was modified by another request", userResponse(authCtx, user), user.Version)
+		return
+	}
+
 	// Check if trying to change role or groups - need special permissions
 	if req.Role != "" && req.Role != user.Role {
-		if !authCtx.IsOwner {
-			respondWithError(w, "Only owner can change user roles", http.StatusForbidden)
+		if !authCtx.ManagesUser(user) {
+			respondWithError(w, "Only owner or a user admin can change user roles", http.StatusForbidden)
+			return
+		}
+		if req.Role == "owner" && !authCtx.IsOwner {
+			respondWithError(w, "Only owner can make users owners", http.StatusForbidden)
 			return
 		}
 	}
@@ -257,30 +312,41 @@ func updateUser(w http.ResponseWriter, r *http.Request, id int) {
 	if req.FullName != "" {
 		user.FullName = req.FullName
 	}
+	v := newValidator()
 	if req.Role != "" {
-		if req.Role != "user" && req.Role != "group_admin" && req.Role != "owner" {
-			respondWithError(w, "Invalid role", http.StatusBadRequest)
-			return
-		}
+		v.check(isValidRole(req.Role), "role", "invalid_choice", "user, group_admin, owner")
 		user.Role = req.Role
 	}
 	if req.GroupIDs != nil {
 		// Validate groups exist
-		for _, groupID := range req.GroupIDs {
-			_, err := modules.RedisClient.GetGroup(groupID)
-			if err != nil {
-				respondWithError(w, fmt.Sprintf("Group %d not found", groupID), http.StatusBadRequest)
-				return
-			}
+		checkGroupsExist(v, req.GroupIDs)
+		if groupID, ok := authCtx.UnmanagedGroupChange(user.GroupIDs, req.GroupIDs); !ok {
+			respondWithError(w, fmt.Sprintf("Insufficient permissions to move users into or out of group %d", groupID), http.StatusForbidden)
+			return
 		}
 		user.GroupIDs = models.IntSlice(req.GroupIDs)
 	}
+	if req.Region != "" {
+		v.check(modules.IsValidRegion(req.Region), "region", "invalid_region")
+		user.Region = req.Region
+	}
+	if req.WorkTimes != nil {
+		user.WorkTimes = workTimesFrom(req.WorkTimes)
+		checkWorkTimes(v, "work_times", user.WorkTimes)
+	}
+	if req.Password != "" {
+		checkPassword(v, "password", req.Password, user)
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
 	if req.Number != "" {
 		user.Number = req.Number
 	}
 	if req.Email != "" {
 		// Check if email already exists (for other users)
-		existingUser, _ := modules.RedisClient.GetUserByEmail(req.Email)
+		existingUser, _ := modules.RedisClient.FindUserByEmail(req.Email)
 		if existingUser != nil && existingUser.ID != user.ID {
 			respondWithError(w, "User with this email already exists", http.StatusConflict)
 			return
@@ -288,16 +354,26 @@ func updateUser(w http.ResponseWriter, r *http.Request, id int) {
 		user.Email = req.Email
 	}
 	if req.Password != "" {
-		user.Password = req.Password
-	}
-	if req.WorkTimes != nil {
-		user.WorkTimes = models.WorkTimes(req.WorkTimes)
+		if err := modules.SetPassword(user, req.Password); err != nil {
+			respondWithError(w, "Failed to hash password", http.StatusInternalServerError)
+			return
+		}
 	}
-
 	user.UpdatedAt = time.Now()
 
-	// Save user
-	if err := modules.RedisClient.SaveUser(user); err != nil {
+	// Save user, rejecting the update if another request changed it first
+	if checkVersion {
+		err = modules.RedisClient.SaveUserIfVersion(user, expectedVersion)
+	} else {
+		err = modules.RedisClient.SaveUser(user)
+	}
+	if errors.Is(err, modules.ErrVersionConflict) {
+		if latest, err := modules.RedisClient.GetUser(id); err == nil {
+			respondWithConflict(w, "User was modified by another request", userResponse(authCtx, latest), latest.Version)
+			return
+		}
+	}
+	if err != nil {
 		respondWithError(w, "Failed to update user", http.StatusInternalServerError)
 		return
 	}
@@ -305,35 +381,58 @@ func updateUser(w http.ResponseWriter, r *http.Request, id int) {
 	// Mark data as dirty for sync
 	modules.RedisClient.MarkDirty("users")
 
-	// Remove password from response
-	user.Password = ""
+	// A new password signs the user out of existing sessions and tokens
+	if req.Password != "" {
+		if err := modules.RedisClient.RecordPassword(user.ID, req.Password); err != nil {
+			handlerLog.WarnContext(r.Context(), "⚠️ Failed to record password history", "error", err)
+		}
+		if err := modules.SignOutUser(user.ID); err != nil {
+			handlerLog.WarnContext(r.Context(), "⚠️ Failed to sign out user after password change", "error", err)
+		}
+	}
 
+	setETag(w, user.Version)
 	respondWithSuccess(w, map[string]interface{}{
 		"message": "User updated successfully",
-		"user":    user,
+		"user":    userResponse(authCtx, user),
 	})
 }
 
 func deleteUser(w http.ResponseWriter, r *http.Request, id int) {
 	authCtx := modules.GetAuthContext(r)
 
-	// Only owner can delete users
-	if !authCtx.IsOwner {
-		respondWithError(w, "Only owner can delete users", http.StatusForbidden)
+	user, err := modules.RedisClient.GetUser(id)
+	if err != nil {
+		respondWithDomainError(w, r, err, "User not found")
 		return
 	}
 
-	user, err := modules.RedisClient.GetUser(id)
-	if err != nil {
-		respondWithError(w, "User not found", http.StatusNotFound)
+	// Only owner and the user admins managing the user can delete them
+	if !authCtx.ManagesUser(user) {
+		respondWithError(w, "Only owner or a user admin can delete users", http.StatusForbidden)
 		return
 	}
 
-	// Delete all user tasks first
+	// Delete all user tasks first, and take them off tasks they collaborate on
 	tasks, _ := modules.RedisClient.GetUserTasks(id)
 	for _, task := range tasks {
 		modules.RedisClient.DeleteTask(task.ID)
 	}
+	if _, err := modules.RedisClient.ReplaceCollaborator(id, 0); err != nil {
+		handlerLog.WarnContext(r.Context(), "⚠️ Failed to remove deleted user from shared tasks", "user_id", id, "error", err)
+	}
+
+	// Delete all user leave requests
+	leaves, _ := modules.RedisClient.GetUserLeaveRequests(id)
+	for _, leave := range leaves {
+		modules.RedisClient.DeleteLeaveRequest(leave.ID)
+	}
+
+	// Delete all user report subscriptions
+	subs, _ := modules.RedisClient.GetUserReportSubscriptions(id)
+	for _, sub := range subs {
+		modules.RedisClient.DeleteReportSubscription(sub.ID)
+	}
 
 	// Delete user
 	if err := modules.RedisClient.DeleteUser(id); err != nil {
@@ -344,13 +443,16 @@ func deleteUser(w http.ResponseWriter, r *http.Request, id int) {
 	// Mark data as dirty for sync
 	modules.RedisClient.MarkDirty("users")
 	modules.RedisClient.MarkDirty("tasks")
-
-	// Remove password from response
-	user.Password = ""
+	if len(leaves) > 0 {
+		modules.RedisClient.MarkDirty("leaves")
+	}
+	if len(subs) > 0 {
+		modules.RedisClient.MarkDirty("reports")
+	}
 
 	respondWithSuccess(w, map[string]interface{}{
 		"message": "User deleted successfully",
-		"user":    user,
+		"user":    userResponse(authCtx, user),
 	})
 }
 
@@ -388,6 +490,16 @@ func handleUserTasks(w http.ResponseWriter, r *http.Request, userID int, remaini
 			default:
 				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			}
+		} else if len(remainingParts) == 2 && remainingParts[1] == "check" {
+			// /users/{id}/tasks/{tid}/check
+			switch r.Method {
+			case "PUT":
+				checkUserTask(w, r, userID, taskID, true)
+			case "DELETE":
+				checkUserTask(w, r, userID, taskID, false)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
 		} else if len(remainingParts) == 2 && remainingParts[1] == "done" {
 			// /users/{id}/tasks/{tid}/done
 			if r.Method == "PUT" {
@@ -395,6 +507,29 @@ func handleUserTasks(w http.ResponseWriter, r *http.Request, userID int, remaini
 			} else {
 				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			}
+		} else if len(remainingParts) == 2 && remainingParts[1] == "rank" {
+			// /users/{id}/tasks/{tid}/rank
+			if r.Method == "PATCH" {
+				rankUserTask(w, r, userID, taskID)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if len(remainingParts) == 2 && remainingParts[1] == "subtasks" {
+			// /users/{id}/tasks/{tid}/subtasks
+			if r.Method == "GET" {
+				getUserSubtasks(w, r, userID, taskID)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if remainingParts[1] == "extensions" {
+			// /users/{id}/tasks/{tid}/extensions[/...]
+			handleTaskExtensions(w, r, userID, taskID, remainingParts[2:])
+		} else if remainingParts[1] == "reminders" {
+			// /users/{id}/tasks/{tid}/reminders[/...]
+			handleTaskReminders(w, r, userID, taskID, remainingParts[2:])
+		} else if remainingParts[1] == "comments" {
+			// /users/{id}/tasks/{tid}/comments[/...]
+			handleUserTaskComments(w, r, userID, taskID, remainingParts[2:])
 		} else {
 			http.Error(w, "Invalid task sub-path", http.StatusBadRequest)
 		}
@@ -412,17 +547,39 @@ func handleUserWorkTimes(w http.ResponseWriter, r *http.Request, userID int) {
 	}
 }
 
+// getUserTasks lists user {id}'s tasks, sorted when ?sort= names one of
+// modules.TaskSorts
 func getUserTasks(w http.ResponseWriter, r *http.Request, userID int) {
+	sortBy := r.URL.Query().Get("sort")
+	v := newValidator()
+	v.check(sortBy == "" || modules.IsTaskSort(sortBy), "sort", "invalid_choice", modules.TaskSorts)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
 	tasks, err := modules.RedisClient.GetUserTasks(userID)
 	if err != nil {
 		respondWithError(w, fmt.Sprintf("Failed to get tasks: %v", err), http.StatusInternalServerError)
 		return
 	}
+	if sortBy != "" {
+		modules.SortTasks(tasks, sortBy)
+	}
+
+	// Tasks the user collaborates on are listed apart, as they belong to
+	// their primary assignee
+	shared, err := modules.RedisClient.GetUserSharedTasks(userID)
+	if err != nil {
+		respondWithError(w, fmt.Sprintf("Failed to get tasks: %v", err), http.StatusInternalServerError)
+		return
+	}
 
 	respondWithSuccess(w, map[string]interface{}{
-		"user_id": userID,
-		"tasks":   tasks,
-		"count":   len(tasks),
+		"user_id":      userID,
+		"tasks":        tasks,
+		"count":        len(tasks),
+		"shared_tasks": shared,
 	})
 }
 
@@ -433,27 +590,29 @@ func createUserTask(w http.ResponseWriter, r *http.Request, userID int) {
 		return
 	}
 
-	if req.Title == "" {
-		respondWithError(w, "Title is required", http.StatusBadRequest)
-		return
+	v := newValidator()
+	v.required(req.Title, "title")
+	v.check(req.GroupID != 0, "group_id", "required")
+	v.check(req.EstimatedHours >= 0, "estimated_hours", "not_negative")
+	v.check(req.Priority == 0 || req.Priority.Valid(), "priority", "invalid_choice", models.PriorityLabels)
+	if v.valid() {
+		// Validate group exists
+		_, err := modules.RedisClient.GetGroup(req.GroupID)
+		v.check(err == nil, "group_id", "not_found")
+		if err == nil {
+			checkCollaborators(v, req.Collaborators, req.GroupID)
+			checkParentTask(v, req.ParentTaskID, 0, req.GroupID)
+		}
 	}
-
-	if req.GroupID == 0 {
-		respondWithError(w, "Group ID is required", http.StatusBadRequest)
-		return
-	}
-
-	// Validate group exists
-	_, err := modules.RedisClient.GetGroup(req.GroupID)
-	if err != nil {
-		respondWithError(w, "Group not found", http.StatusBadRequest)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
 		return
 	}
 
 	// Check if user belongs to the group
 	user, err := modules.RedisClient.GetUser(userID)
 	if err != nil {
-		respondWithError(w, "User not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "User not found")
 		return
 	}
 
@@ -470,6 +629,9 @@ func createUserTask(w http.ResponseWriter, r *http.Request, userID int) {
 		respondWithError(w, "User does not belong to specified group", http.StatusForbidden)
 		return
 	}
+	if groupFrozen(w, r, req.GroupID) {
+		return
+	}
 
 	// Get next task ID
 	taskID, err := modules.RedisClient.GetNextTaskID()
@@ -478,17 +640,29 @@ func createUserTask(w http.ResponseWriter, r *http.Request, userID int) {
 		return
 	}
 
+	if req.Priority == 0 {
+		req.Priority = models.PriorityLow
+	}
+
 	task := &models.Task{
-		ID:          taskID,
-		Title:       req.Title,
-		Priority:    req.Priority,
-		Deadline:    req.Deadline,
-		Information: req.Information,
-		Status:      false,
-		UserID:      userID,
-		GroupID:     req.GroupID,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:             taskID,
+		Title:          req.Title,
+		Priority:       req.Priority,
+		Deadline:       req.Deadline,
+		Information:    req.Information,
+		EstimatedHours: req.EstimatedHours,
+		Status:         false,
+		UserID:         userID,
+		GroupID:        req.GroupID,
+		ParentTaskID:   req.ParentTaskID,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	task.SetCollaborators(req.Collaborators)
+
+	if err := modules.RedisClient.AssignTaskKey(task); err != nil {
+		respondWithError(w, "Failed to generate task key", http.StatusInternalServerError)
+		return
 	}
 
 	if err := modules.RedisClient.SaveTask(task); err != nil {
@@ -499,25 +673,60 @@ func createUserTask(w http.ResponseWriter, r *http.Request, userID int) {
 	// Mark data as dirty for sync
 	modules.RedisClient.MarkDirty("tasks")
 
+	modules.Events.PublishTask(r.Context(), "task.created", task, task)
+	notifyAssigned(r, task, task.Assignees())
+
 	respondWithSuccess(w, map[string]interface{}{
 		"message": "Task created successfully",
-		"task":    task,
+		"task":    taskResponse(task),
 	}, http.StatusCreated)
 }
 
+// notifyAssigned emails users just assigned a task straight away, except
+// whoever assigned it
+func notifyAssigned(r *http.Request, task *models.Task, userIDs []int) {
+	authCtx := modules.GetAuthContext(r)
+	assignedBy := "The owner"
+	if authCtx.User != nil {
+		assignedBy = authCtx.User.FullName
+	}
+
+	for _, userID := range userIDs {
+		if authCtx.User != nil && authCtx.User.ID == userID {
+			continue
+		}
+		user, err := modules.RedisClient.GetUser(userID)
+		if err != nil {
+			continue
+		}
+		modules.Notifications.Notify(r.Context(), user, &modules.Notification{
+			Kind:     "task_assigned",
+			Message:  fmt.Sprintf("%s assigned you %q (#%d)", assignedBy, task.Title, task.ID),
+			TaskID:   task.ID,
+			Urgent:   true,
+			Template: "task_assigned",
+			Data: map[string]interface{}{
+				"Task":       task,
+				"AssignedBy": assignedBy,
+			},
+		})
+	}
+}
+
 func getUserTask(w http.ResponseWriter, r *http.Request, userID, taskID int) {
 	task, err := modules.RedisClient.GetTask(taskID)
 	if err != nil {
-		respondWithError(w, "Task not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "Task not found")
 		return
 	}
 
-	if task.UserID != userID {
+	if !task.IsAssignee(userID) {
 		respondWithError(w, "Task does not belong to this user", http.StatusNotFound)
 		return
 	}
 
-	respondWithSuccess(w, task)
+	setETag(w, task.Version)
+	respondWithSuccess(w, taskResponse(task))
 }
 
 func updateUserTask(w http.ResponseWriter, r *http.Request, userID, taskID int) {
@@ -529,11 +738,11 @@ func updateUserTask(w http.ResponseWriter, r *http.Request, userID, taskID int)
 
 	task, err := modules.RedisClient.GetTask(taskID)
 	if err != nil {
-		respondWithError(w, "Task not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "Task not found")
 		return
 	}
 
-	if task.UserID != userID {
+	if !task.IsAssignee(userID) {
 		respondWithError(w, "Task does not belong to this user", http.StatusNotFound)
 		return
 	}
@@ -543,12 +752,31 @@ func updateUserTask(w http.ResponseWriter, r *http.Request, userID, taskID int)
 		respondWithError(w, "Insufficient permissions to modify this task", http.StatusForbidden)
 		return
 	}
+	if groupFrozen(w, r, task.GroupID, req.GroupID) {
+		return
+	}
+
+	expectedVersion, checkVersion, err := parseIfMatch(r)
+	if err != nil {
+		respondWithError(w, "Invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+	if checkVersion && expectedVersion != task.Version {
+		respondWithConflict(w, "Task was modified by another request", task, task.Version)
+		return
+	}
 
 	// Update fields
 	if req.Title != "" {
 		task.Title = req.Title
 	}
 	if req.Priority != 0 {
+		if !req.Priority.Valid() {
+			v := newValidator()
+			v.check(false, "priority", "invalid_choice", models.PriorityLabels)
+			respondWithValidationErrors(w, r, v)
+			return
+		}
 		task.Priority = req.Priority
 	}
 	if req.Deadline != "" {
@@ -557,39 +785,102 @@ func updateUserTask(w http.ResponseWriter, r *http.Request, userID, taskID int)
 	if req.Information != "" {
 		task.Information = req.Information
 	}
+	if req.EstimatedHours != 0 {
+		if req.EstimatedHours < 0 {
+			v := newValidator()
+			v.check(false, "estimated_hours", "not_negative")
+			respondWithValidationErrors(w, r, v)
+			return
+		}
+		task.EstimatedHours = req.EstimatedHours
+	}
 	if req.Status != nil {
 		task.Status = *req.Status
 	}
+	if req.State != "" {
+		v := newValidator()
+		checkTaskState(v, &req, "")
+		v.check(req.BlockedBy != task.ID, "blocked_by", "blocked_by_self")
+		if !v.valid() {
+			respondWithValidationErrors(w, r, v)
+			return
+		}
+		applyTaskState(task, &req)
+	}
 	if req.GroupID != 0 {
 		// Validate group exists and user belongs to it
 		_, err := modules.RedisClient.GetGroup(req.GroupID)
 		if err != nil {
-			respondWithError(w, "Group not found", http.StatusBadRequest)
+			v := newValidator()
+			v.check(false, "group_id", "not_found")
+			respondWithValidationErrors(w, r, v)
 			return
 		}
 		task.GroupID = req.GroupID
 	}
+	if req.ParentTaskID != nil || req.GroupID != 0 {
+		// A task moved to another group must leave a parent left behind
+		if req.ParentTaskID != nil {
+			task.ParentTaskID = *req.ParentTaskID
+		}
+		v := newValidator()
+		checkParentTask(v, task.ParentTaskID, task.ID, task.GroupID)
+		if !v.valid() {
+			respondWithValidationErrors(w, r, v)
+			return
+		}
+	}
+	var added []int
+	if req.Collaborators != nil {
+		v := newValidator()
+		checkCollaborators(v, *req.Collaborators, task.GroupID)
+		if !v.valid() {
+			respondWithValidationErrors(w, r, v)
+			return
+		}
+		previous := *task
+		task.SetCollaborators(*req.Collaborators)
+		for _, userID := range task.Collaborators {
+			if !previous.IsAssignee(userID) {
+				added = append(added, userID)
+			}
+		}
+	}
 
 	task.UpdatedAt = time.Now()
 
-	if err := modules.RedisClient.SaveTask(task); err != nil {
+	// Save task, rejecting the update if another request changed it first.
+	// Rapid updates of the same task may be merged into one write.
+	if checkVersion {
+		err = modules.RedisClient.SaveTaskBehindIfVersion(task, expectedVersion)
+	} else {
+		err = modules.RedisClient.SaveTaskBehind(task)
+	}
+	if errors.Is(err, modules.ErrVersionConflict) {
+		if latest, err := modules.RedisClient.GetTask(taskID); err == nil {
+			respondWithConflict(w, "Task was modified by another request", latest, latest.Version)
+			return
+		}
+	}
+	if err != nil {
 		respondWithError(w, "Failed to update task", http.StatusInternalServerError)
 		return
 	}
 
-	// Mark data as dirty for sync
-	modules.RedisClient.MarkDirty("tasks")
+	modules.Events.PublishTask(r.Context(), "task.updated", task, task)
+	notifyAssigned(r, task, added)
 
+	setETag(w, task.Version)
 	respondWithSuccess(w, map[string]interface{}{
 		"message": "Task updated successfully",
-		"task":    task,
+		"task":    taskResponse(task),
 	})
 }
 
 func deleteUserTask(w http.ResponseWriter, r *http.Request, userID, taskID int) {
 	task, err := modules.RedisClient.GetTask(taskID)
 	if err != nil {
-		respondWithError(w, "Task not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "Task not found")
 		return
 	}
 
@@ -603,6 +894,9 @@ func deleteUserTask(w http.ResponseWriter, r *http.Request, userID, taskID int)
 		respondWithError(w, "Insufficient permissions to delete this task", http.StatusForbidden)
 		return
 	}
+	if groupFrozen(w, r, task.GroupID) {
+		return
+	}
 
 	if err := modules.RedisClient.DeleteTask(taskID); err != nil {
 		respondWithError(w, "Failed to delete task", http.StatusInternalServerError)
@@ -612,6 +906,8 @@ func deleteUserTask(w http.ResponseWriter, r *http.Request, userID, taskID int)
 	// Mark data as dirty for sync
 	modules.RedisClient.MarkDirty("tasks")
 
+	modules.Events.PublishTask(r.Context(), "task.deleted", task, task)
+
 	respondWithSuccess(w, map[string]interface{}{
 		"message": "Task deleted successfully",
 		"task":    task,
@@ -621,11 +917,11 @@ func deleteUserTask(w http.ResponseWriter, r *http.Request, userID, taskID int)
 func markUserTaskDone(w http.ResponseWriter, r *http.Request, userID, taskID int) {
 	task, err := modules.RedisClient.GetTask(taskID)
 	if err != nil {
-		respondWithError(w, "Task not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "Task not found")
 		return
 	}
 
-	if task.UserID != userID {
+	if !task.IsAssignee(userID) {
 		respondWithError(w, "Task does not belong to this user", http.StatusNotFound)
 		return
 	}
@@ -635,17 +931,19 @@ func markUserTaskDone(w http.ResponseWriter, r *http.Request, userID, taskID int
 		respondWithError(w, "Insufficient permissions to modify this task", http.StatusForbidden)
 		return
 	}
+	if groupFrozen(w, r, task.GroupID) {
+		return
+	}
 
 	task.Status = true
 	task.UpdatedAt = time.Now()
 
-	if err := modules.RedisClient.SaveTask(task); err != nil {
+	if err := modules.RedisClient.SaveTaskBehind(task); err != nil {
 		respondWithError(w, "Failed to update task", http.StatusInternalServerError)
 		return
 	}
 
-	// Mark data as dirty for sync
-	modules.RedisClient.MarkDirty("tasks")
+	modules.Events.PublishTask(r.Context(), "task.completed", task, task)
 
 	respondWithSuccess(w, map[string]interface{}{
 		"message": "Task marked as done",
@@ -653,10 +951,114 @@ func markUserTaskDone(w http.ResponseWriter, r *http.Request, userID, taskID int
 	})
 }
 
+// rankUserTask moves a task in user {id}'s own list to just after another
+// of their tasks, or to the top when after_task_id is 0. The new order shows
+// with GET /users/{id}/tasks?sort=manual.
+func rankUserTask(w http.ResponseWriter, r *http.Request, userID, taskID int) {
+	var req models.RankTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	task, err := modules.RedisClient.GetTask(taskID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Task not found")
+		return
+	}
+
+	// Only the primary assignee's list is ranked; shared tasks are listed apart
+	if task.UserID != userID {
+		respondWithError(w, "Task does not belong to this user", http.StatusNotFound)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if !modules.CanModifyTask(authCtx, task) {
+		respondWithError(w, "Insufficient permissions to modify this task", http.StatusForbidden)
+		return
+	}
+	if groupFrozen(w, r, task.GroupID) {
+		return
+	}
+
+	v := newValidator()
+	v.check(req.AfterTaskID >= 0, "after_task_id", "not_negative")
+	v.check(req.AfterTaskID != taskID, "after_task_id", "blocked_by_self")
+	if v.valid() && req.AfterTaskID != 0 {
+		after, err := modules.RedisClient.GetTask(req.AfterTaskID)
+		v.check(err == nil && after.UserID == userID, "after_task_id", "task_not_found", req.AfterTaskID)
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	task, reranked, err := modules.RedisClient.RankTaskAfter(userID, taskID, req.AfterTaskID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Failed to rank task")
+		return
+	}
+
+	modules.Events.PublishTask(r.Context(), "task.updated", task, task)
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message":  "Task moved",
+		"task":     task,
+		"reranked": reranked,
+	})
+}
+
+// checkUserTask ticks or clears user {id}'s checkbox on a task they are
+// assigned. Checking the last open box completes the task.
+func checkUserTask(w http.ResponseWriter, r *http.Request, userID, taskID int, checked bool) {
+	task, err := modules.RedisClient.GetTask(taskID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Task not found")
+		return
+	}
+
+	if !task.IsAssignee(userID) {
+		respondWithError(w, "Task does not belong to this user", http.StatusNotFound)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if !modules.CanModifyTask(authCtx, task) {
+		respondWithError(w, "Insufficient permissions to modify this task", http.StatusForbidden)
+		return
+	}
+	if groupFrozen(w, r, task.GroupID) {
+		return
+	}
+
+	completed := task.SetChecked(userID, checked) && !task.Status
+	if completed {
+		task.Status = true
+	}
+	task.UpdatedAt = time.Now()
+
+	if err := modules.RedisClient.SaveTaskBehind(task); err != nil {
+		respondWithError(w, "Failed to update task", http.StatusInternalServerError)
+		return
+	}
+
+	eventType := "task.updated"
+	if completed {
+		eventType = "task.completed"
+	}
+	modules.Events.PublishTask(r.Context(), eventType, task, task)
+
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "Checkbox updated",
+		"task":    task,
+	})
+}
+
 func getUserWorkTimes(w http.ResponseWriter, r *http.Request, userID int) {
 	user, err := modules.RedisClient.GetUser(userID)
 	if err != nil {
-		respondWithError(w, "User not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "User not found")
 		return
 	}
 
@@ -673,13 +1075,21 @@ func updateUserWorkTimes(w http.ResponseWriter, r *http.Request, userID int) {
 		return
 	}
 
+	v := newValidator()
+	workTimes := workTimesFrom(req.WorkTimes)
+	checkWorkTimes(v, "work_times", workTimes)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
 	user, err := modules.RedisClient.GetUser(userID)
 	if err != nil {
-		respondWithError(w, "User not found", http.StatusNotFound)
+		respondWithDomainError(w, r, err, "User not found")
 		return
 	}
 
-	user.WorkTimes = models.WorkTimes(req.WorkTimes)
+	user.WorkTimes = workTimes
 	user.UpdatedAt = time.Now()
 
 	if err := modules.RedisClient.SaveUser(user); err != nil {
@@ -696,6 +1106,128 @@ func updateUserWorkTimes(w http.ResponseWriter, r *http.Request, userID int) {
 	})
 }
 
+// maxSuggestedEffort bounds the effort a deadline is suggested for, 500 hours
+const maxSuggestedEffort = 500 * 60
+
+// suggestUserDeadline handles GET /users/{id}/suggest-deadline, proposing
+// when the user could finish effort_minutes of new work, counted in their
+// own work times or, with clock=business, in business hours
+func suggestUserDeadline(w http.ResponseWriter, r *http.Request, userID int) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	effortStr := r.URL.Query().Get("effort_minutes")
+	effort, err := strconv.Atoi(effortStr)
+	v := newValidator()
+	v.required(effortStr, "effort_minutes")
+	v.check(effortStr == "" || err == nil, "effort_minutes", "invalid_number")
+	v.check(err != nil || (effort >= 1 && effort <= maxSuggestedEffort), "effort_minutes", "between", 1, maxSuggestedEffort)
+	clock := r.URL.Query().Get("clock")
+	v.check(modules.IsClockPolicy(clock), "clock", "invalid_choice", modules.ClockPolicies)
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	user, err := modules.RedisClient.GetUser(userID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "User not found")
+		return
+	}
+
+	suggestion, err := modules.RedisClient.SuggestDeadline(user, clock, effort, time.Now())
+	if errors.Is(err, modules.ErrNoWorkingTime) {
+		respondWithError(w, "There is no working time in the next 180 days to fit this effort", http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		respondWithError(w, "Failed to suggest a deadline", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"user_id":    userID,
+		"suggestion": suggestion,
+	})
+}
+
+// handleUserAdmin handles PUT /users/{id}/user-admin, which lets the owner
+// delegate user administration for every user or members of some groups,
+// and DELETE, which takes it back
+func handleUserAdmin(w http.ResponseWriter, r *http.Request, userID int) {
+	if r.Method != "PUT" && r.Method != "DELETE" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		respondWithError(w, "Only owner can delegate user administration", http.StatusForbidden)
+		return
+	}
+
+	user, err := modules.RedisClient.GetUser(userID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "User not found")
+		return
+	}
+	if user.Role == "owner" {
+		respondWithError(w, "Owners already administer all users", http.StatusConflict)
+		return
+	}
+
+	var req models.UserAdminRequest
+	if r.Method == "PUT" {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		v := newValidator()
+		v.check(req.Global != (len(req.GroupIDs) > 0), "group_ids", "one_of", "global, group_ids")
+		checkGroupsExist(v, req.GroupIDs)
+		if !v.valid() {
+			respondWithValidationErrors(w, r, v)
+			return
+		}
+	}
+
+	user.UserAdmin = r.Method == "PUT"
+	user.UserAdminGroups = nil
+	if len(req.GroupIDs) > 0 {
+		user.UserAdminGroups = models.IntSlice(req.GroupIDs)
+	}
+	user.UpdatedAt = time.Now()
+	if err := modules.RedisClient.SaveUser(user); err != nil {
+		respondWithError(w, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+	modules.RedisClient.MarkDirty("users")
+
+	handlerLog.InfoContext(r.Context(), "User administration delegated",
+		"user_id", user.ID, "user_admin", user.UserAdmin, "group_ids", []int(user.UserAdminGroups))
+	setETag(w, user.Version)
+	respondWithSuccess(w, map[string]interface{}{
+		"message": "User administration updated",
+		"user":    userResponse(authCtx, user),
+	})
+}
+
+// workTimesFrom reads work times from a request, with lowercase day names.
+// It is nil when none were sent.
+func workTimesFrom(days map[string][]models.WorkInterval) models.WorkTimes {
+	if days == nil {
+		return nil
+	}
+	workTimes := make(models.WorkTimes, len(days))
+	for day, intervals := range days {
+		workTimes[strings.ToLower(strings.TrimSpace(day))] = intervals
+	}
+	return workTimes
+}
+
 // Helper functions
 func respondWithSuccess(w http.ResponseWriter, data interface{}, statusCode ...int) {
 	code := http.StatusOK
@@ -715,11 +1247,47 @@ func respondWithSuccess(w http.ResponseWriter, data interface{}, statusCode ...i
 
 func respondWithError(w http.ResponseWriter, message string, statusCode int) {
 	response := models.APIResponse{
-		Success: false,
-		Error:   message,
+		Success:   false,
+		Error:     message,
+		RequestID: w.Header().Get(modules.RequestIDHeader),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(response)
 }
+
+// setETag exposes an entity's version so clients can send it back in If-Match
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// parseIfMatch returns the version a client expects to update, if it sent one
+func parseIfMatch(r *http.Request) (int, bool, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, false, nil
+	}
+
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false, err
+	}
+	return version, true, nil
+}
+
+// respondWithConflict reports a stale If-Match version along with the current entity
+func respondWithConflict(w http.ResponseWriter, message string, latest interface{}, version int) {
+	response := models.APIResponse{
+		Success:   false,
+		Error:     message,
+		Data:      latest,
+		RequestID: w.Header().Get(modules.RequestIDHeader),
+	}
+
+	setETag(w, version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"task-manager/modules"
+)
+
+// defaultLanguage is used when Accept-Language names no supported language
+const defaultLanguage = "en"
+
+// validationMessages holds the translated text of every validation rule.
+// Messages are printf templates filled with the rule's arguments.
+var validationMessages = map[string]map[string]string{
+	"en": {
+		"failed":              "Validation failed",
+		"required":            "is required",
+		"invalid_choice":      "must be one of: %s",
+		"not_found":           "does not exist",
+		"group_missing":       "group %d does not exist",
+		"admin_role":          "must be a user with the 'group_admin' or 'owner' role",
+		"invalid_date":        "must be a date in YYYY-MM-DD format",
+		"date_order":          "must not be before %s",
+		"invalid_url":         "must be a valid http(s) URL",
+		"public_url":          "must reach a public address on port 80 or 443",
+		"invalid_value":       "is invalid: %s",
+		"not_configured":      "is not configured on this server",
+		"not_negative":        "must not be negative",
+		"task_missing":        "task %d does not exist in this group",
+		"task_not_in_session": "task %d is not part of this session",
+		"already_exists":      "is already in use",
+		"contact_name":        "every contact needs a name",
+		"invalid_region":      "must be 2-16 lowercase letters, digits or hyphens",
+		"invalid_time":        "must be a time in RFC 3339 format",
+		"future_time":         "must be in the future",
+		"invalid_duration":    "must be a positive duration such as 30m or 2h",
+		"one_of":              "exactly one of %s is required",
+		"at_least_one":        "at least one of %s is required",
+		"no_business_time":    "does not fit in the business hours of the next 180 days",
+		"between":             "must be between %d and %d",
+		"not_group_member":    "must be a member of this group",
+		"task_not_found":      "task %d does not exist",
+		"invalid_quarter":     "must be a quarter such as 2026-Q3",
+		"invalid_field_name":  "field name %q must be lowercase letters, digits or underscores",
+		"duplicate_field":     "field name %q is used twice",
+		"one_title":           "need exactly one required field that maps to title",
+		"duplicate_target":    "only one field may map to deadline and one to priority",
+		"unknown_column":      "column %q is not in the file",
+		"duplicate_mapping":   "field %q is mapped from more than one column",
+		"title_unmapped":      "a column must map to title",
+		"invalid_date_format": "must use YYYY, MM and DD once each, such as DD/MM/YYYY",
+		"invalid_date_as":     "must be a date in %s format",
+		"invalid_number":      "must be a number",
+		"merge_self":          "must be a different user",
+		"owner_account":       "must not be an owner account",
+		"invalid_timezone":    "must be an IANA timezone such as Europe/Berlin",
+		"invalid_weekday":     "%q is not a day of the week",
+		"invalid_code":        "must be 2-10 uppercase letters or digits, starting with a letter",
+		"invalid_header":      "header %q cannot be sent: %s",
+		"blocked_by_self":     "must be a different task",
+		"parent_cycle":        "must not be the task itself or one of its subtasks",
+		"blocked_reason":      "is required unless blocked_by names the blocking task",
+		"same_group":          "must be a different group",
+		"assignee_outside":    "user %d is not a member of the target group; map them to one who is",
+		"offboard_assignee":   "group %d has open tasks and no other active admin to take them; name a member for it",
+		"offboard_member":     "user %d cannot take over tasks in group %d: must be another active member of it",
+		"invalid_clock":       "%s: times must be HH:MM, such as 09:00 or 17:30",
+		"interval_order":      "%s: %s-%s must end after it starts",
+		"interval_overlap":    "%s: intervals must be in order and must not overlap",
+		"batch_empty":         "list at least one user to add or remove",
+		"duplicate_user":      "user %d is listed more than once",
+		"password_length":     "must be at least %d characters",
+		"password_too_long":   "must be at most %d bytes",
+		"password_classes":    "must mix at least %d of lowercase letters, uppercase letters, digits and symbols",
+		"password_common":     "is too common; choose a less predictable password",
+		"password_reused":     "must not be one of your last %d passwords",
+		"comment_too_long":    "must be at most %d characters",
+		"attachment_type":     "%s files are not accepted; accepted types are %s",
+		"template_title":      "task %d needs a title",
+		"template_ref":        "task %d: ref %q must be up to 32 lowercase letters, digits, - or _",
+		"duplicate_ref":       "ref %q is used twice",
+		"template_priority":   "task %d: priority must be one of: %s",
+		"template_negative":   "task %d: estimated_hours and due_after_days must not be negative",
+		"template_blocker":    "task %d: blocked_by %q must be the ref of an earlier task",
+	},
+	"fa": {
+		"failed":              "اعتبارسنجی ناموفق بود",
+		"required":            "الزامی است",
+		"invalid_choice":      "باید یکی از این مقادیر باشد: %s",
+		"not_found":           "وجود ندارد",
+		"group_missing":       "گروه %d وجود ندارد",
+		"admin_role":          "باید کاربری با نقش 'group_admin' یا 'owner' باشد",
+		"invalid_date":        "باید تاریخی با قالب YYYY-MM-DD باشد",
+		"date_order":          "نباید قبل از %s باشد",
+		"invalid_url":         "باید یک نشانی http(s) معتبر باشد",
+		"public_url":          "باید به یک نشانی عمومی روی درگاه ۸۰ یا ۴۴۳ برسد",
+		"invalid_value":       "نامعتبر است: %s",
+		"not_configured":      "روی این سرور پیکربندی نشده است",
+		"not_negative":        "نباید منفی باشد",
+		"task_missing":        "وظیفه %d در این گروه وجود ندارد",
+		"task_not_in_session": "وظیفه %d بخشی از این جلسه نیست",
+		"already_exists":      "قبلاً استفاده شده است",
+		"contact_name":        "هر مخاطب باید نام داشته باشد",
+		"invalid_region":      "باید ۲ تا ۱۶ حرف کوچک لاتین، رقم یا خط تیره باشد",
+		"invalid_time":        "باید زمانی با قالب RFC 3339 باشد",
+		"future_time":         "باید در آینده باشد",
+		"invalid_duration":    "باید مدتی مثبت مانند 30m یا 2h باشد",
+		"one_of":              "دقیقاً یکی از %s الزامی است",
+		"at_least_one":        "دست‌کم یکی از %s الزامی است",
+		"no_business_time":    "در ساعات کاری ۱۸۰ روز آینده نمی‌گنجد",
+		"between":             "باید بین %d و %d باشد",
+		"not_group_member":    "باید عضو این گروه باشد",
+		"task_not_found":      "وظیفه %d وجود ندارد",
+		"invalid_quarter":     "باید فصلی مانند 2026-Q3 باشد",
+		"invalid_field_name":  "نام فیلد %q باید فقط حروف کوچک لاتین، رقم یا زیرخط باشد",
+		"duplicate_field":     "نام فیلد %q دو بار استفاده شده است",
+		"one_title":           "دقیقاً یک فیلد الزامی باید به title نگاشت شود",
+		"duplicate_target":    "فقط یک فیلد می‌تواند به deadline و یکی به priority نگاشت شود",
+		"unknown_column":      "ستون %q در فایل وجود ندارد",
+		"duplicate_mapping":   "فیلد %q از بیش از یک ستون نگاشت شده است",
+		"title_unmapped":      "یک ستون باید به title نگاشت شود",
+		"invalid_date_format": "باید YYYY، MM و DD را هر کدام یک بار داشته باشد، مانند DD/MM/YYYY",
+		"invalid_date_as":     "باید تاریخی با قالب %s باشد",
+		"invalid_number":      "باید یک عدد باشد",
+		"merge_self":          "باید کاربر دیگری باشد",
+		"owner_account":       "نباید حساب مالک باشد",
+		"invalid_timezone":    "باید یک منطقه زمانی IANA مانند Asia/Tehran باشد",
+		"invalid_weekday":     "%q روزی از هفته نیست",
+		"invalid_code":        "باید ۲ تا ۱۰ حرف بزرگ لاتین یا رقم باشد و با حرف شروع شود",
+		"invalid_header":      "سرآیند %q قابل ارسال نیست: %s",
+		"blocked_by_self":     "باید وظیفه دیگری باشد",
+		"parent_cycle":        "نباید خود وظیفه یا یکی از زیروظیفه‌های آن باشد",
+		"blocked_reason":      "الزامی است مگر آنکه blocked_by وظیفه مسدودکننده را مشخص کند",
+		"same_group":          "باید گروه دیگری باشد",
+		"assignee_outside":    "کاربر %d عضو گروه مقصد نیست؛ او را به عضوی از آن نگاشت کنید",
+		"offboard_assignee":   "گروه %d وظایف باز دارد و مدیر فعال دیگری برای تحویل گرفتن آن‌ها ندارد؛ عضوی را برای آن مشخص کنید",
+		"offboard_member":     "کاربر %d نمی‌تواند وظایف گروه %d را تحویل بگیرد: باید عضو فعال دیگری از آن باشد",
+		"invalid_clock":       "%s: زمان‌ها باید با قالب HH:MM باشند، مانند 09:00 یا 17:30",
+		"interval_order":      "%s: بازه %s-%s باید پس از شروع خود به پایان برسد",
+		"interval_overlap":    "%s: بازه‌ها باید به ترتیب باشند و با هم هم‌پوشانی نداشته باشند",
+		"batch_empty":         "دست‌کم یک کاربر برای افزودن یا حذف مشخص کنید",
+		"duplicate_user":      "کاربر %d بیش از یک بار آمده است",
+		"password_length":     "باید دست‌کم %d نویسه باشد",
+		"password_too_long":   "باید حداکثر %d بایت باشد",
+		"password_classes":    "باید دست‌کم %d نوع از حروف کوچک، حروف بزرگ، رقم و نماد را داشته باشد",
+		"password_common":     "بیش از حد رایج است؛ گذرواژه‌ای پیش‌بینی‌ناپذیرتر انتخاب کنید",
+		"password_reused":     "نباید یکی از %d گذرواژه اخیر شما باشد",
+		"comment_too_long":    "باید حداکثر %d نویسه باشد",
+		"attachment_type":     "پرونده‌های %s پذیرفته نمی‌شوند؛ انواع پذیرفته %s هستند",
+		"template_title":      "وظیفه %d به عنوان نیاز دارد",
+		"template_ref":        "وظیفه %d: ref %q باید حداکثر ۳۲ حرف کوچک، رقم، - یا _ باشد",
+		"duplicate_ref":       "ref %q دو بار استفاده شده است",
+		"template_priority":   "وظیفه %d: اولویت باید یکی از این مقادیر باشد: %s",
+		"template_negative":   "وظیفه %d: estimated_hours و due_after_days نباید منفی باشند",
+		"template_blocker":    "وظیفه %d: blocked_by %q باید ref یکی از وظایف قبلی باشد",
+	},
+}
+
+// fieldError is a failed rule for one request field
+type fieldError struct {
+	rule string
+	args []interface{}
+}
+
+// validator collects failed rules per field so a response can report all of them at once
+type validator struct {
+	errors map[string]fieldError
+}
+
+func newValidator() *validator {
+	return &validator{errors: make(map[string]fieldError)}
+}
+
+// check records rule as failed for field unless ok; only the first failure per field is kept
+func (v *validator) check(ok bool, field, rule string, args ...interface{}) {
+	if ok {
+		return
+	}
+	if _, exists := v.errors[field]; !exists {
+		v.errors[field] = fieldError{rule: rule, args: args}
+	}
+}
+
+// required fails field when it is empty
+func (v *validator) required(value, field string) {
+	v.check(strings.TrimSpace(value) != "", field, "required")
+}
+
+func (v *validator) valid() bool {
+	return len(v.errors) == 0
+}
+
+// messages renders the failed rules as field-keyed messages in lang
+func (v *validator) messages(lang string) map[string]string {
+	messages := validationMessages[lang]
+
+	fields := make(map[string]string, len(v.errors))
+	for field, fieldErr := range v.errors {
+		fields[field] = fmt.Sprintf(messages[fieldErr.rule], fieldErr.args...)
+	}
+	return fields
+}
+
+// respondWithValidationErrors responds 400 with field-keyed messages in the caller's language
+func respondWithValidationErrors(w http.ResponseWriter, r *http.Request, v *validator) {
+	lang := negotiateLanguage(r.Header.Get("Accept-Language"))
+
+	response := models.APIResponse{
+		Success:   false,
+		Error:     validationMessages[lang]["failed"],
+		Fields:    v.messages(lang),
+		RequestID: w.Header().Get(modules.RequestIDHeader),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", lang)
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(response)
+}
+
+// negotiateLanguage picks the supported language with the highest q-value from an Accept-Language header
+func negotiateLanguage(header string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := validationMessages[lang]; !ok {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return defaultLanguage
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].lang
+}
+
+// isValidRole reports whether role is one of the known user roles
+func isValidRole(role string) bool {
+	return role == "user" || role == "group_admin" || role == "owner"
+}
+
+// checkGroupsExist fails group_ids with the first group that does not exist
+func checkGroupsExist(v *validator, groupIDs []int) {
+	for _, groupID := range groupIDs {
+		if _, err := modules.RedisClient.GetGroup(groupID); err != nil {
+			v.check(false, "group_ids", "group_missing", groupID)
+			return
+		}
+	}
+}
+
+// checkGroupMember fails field unless userID names a member of the group
+func checkGroupMember(v *validator, field string, userID, groupID int) {
+	v.check(userInGroup(userID, groupID), field, "not_group_member")
+}
+
+// userInGroup reports whether the user exists and is a member of the group
+func userInGroup(userID, groupID int) bool {
+	user, err := modules.RedisClient.GetUser(userID)
+	if err != nil {
+		return false
+	}
+	for _, userGroupID := range user.GroupIDs {
+		if userGroupID == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+// checkGroupAdmin fails admin_id unless it names a group_admin or owner, returning the user it found
+func checkGroupAdmin(v *validator, adminID int) *models.User {
+	admin, err := modules.RedisClient.GetUser(adminID)
+	if err != nil {
+		v.check(false, "admin_id", "not_found")
+		return nil
+	}
+	v.check(admin.Role == "group_admin" || admin.Role == "owner", "admin_id", "admin_role")
+	return admin
+}
+
+// checkWorkTimes validates work times: days of the week, each with
+// intervals of HH:MM times that end after they start, in order and not
+// overlapping
+func checkWorkTimes(v *validator, field string, workTimes models.WorkTimes) {
+	days := make([]string, 0, len(workTimes))
+	for day := range workTimes {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	for _, day := range days {
+		_, isWeekday := modules.Weekdays[day]
+		v.check(isWeekday, field, "invalid_weekday", day)
+
+		previousEnd := 0
+		for _, interval := range workTimes[day] {
+			start, end, ok := interval.Minutes()
+			v.check(ok, field, "invalid_clock", day)
+			if !ok {
+				break
+			}
+			v.check(start < end, field, "interval_order", day, interval.Start, interval.End)
+			v.check(start >= previousEnd, field, "interval_overlap", day)
+			previousEnd = end
+		}
+	}
+}
+
+// checkPassword validates a new password against the password policy and,
+// for an existing user, against the passwords they used last
+func checkPassword(v *validator, field, password string, user *models.User) {
+	policy := modules.CurrentPasswordPolicy()
+	if violation := policy.Check(password); violation != nil {
+		v.check(false, field, violation.Rule, violation.Args...)
+		return
+	}
+	if user != nil {
+		v.check(!modules.RedisClient.PasswordReused(user, password), field, "password_reused", policy.History)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"task-manager/models"
+	"task-manager/modules"
+)
+
+// handleGroupVisibleTasks handles GET /groups/{id}/visible-tasks, the tasks
+// other groups shared with the group, and PUT and DELETE
+// /groups/{id}/visible-tasks/{tid}, which share a task with the group or
+// stop sharing it
+func handleGroupVisibleTasks(w http.ResponseWriter, r *http.Request, groupID int, remainingParts []string) {
+	if len(remainingParts) == 0 {
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		getGroupVisibleTasks(w, r, groupID)
+		return
+	}
+
+	if len(remainingParts) != 1 {
+		http.Error(w, "Invalid visible-tasks sub-path", http.StatusBadRequest)
+		return
+	}
+	taskID, err := strconv.Atoi(remainingParts[0])
+	if err != nil {
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case "PUT":
+		setTaskVisibility(w, r, groupID, taskID, true)
+	case "DELETE":
+		setTaskVisibility(w, r, groupID, taskID, false)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func getGroupVisibleTasks(w http.ResponseWriter, r *http.Request, groupID int) {
+	authCtx := modules.GetAuthContext(r)
+	if !administersGroup(authCtx, groupID) {
+		respondWithError(w, "Only the group's admin can view tasks shared with it", http.StatusForbidden)
+		return
+	}
+
+	tasks, err := modules.RedisClient.GetGroupVisibleTasks(groupID)
+	if err != nil {
+		respondWithError(w, "Failed to get visible tasks", http.StatusInternalServerError)
+		return
+	}
+	if tasks == nil {
+		tasks = []*models.Task{}
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"group_id": groupID,
+		"tasks":    tasks,
+		"count":    len(tasks),
+	})
+}
+
+// setTaskVisibility shares a task with the group or stops sharing it. Only
+// the admin of the task's own group may share it; either group's admin may
+// stop sharing it.
+func setTaskVisibility(w http.ResponseWriter, r *http.Request, groupID, taskID int, visible bool) {
+	if _, err := modules.RedisClient.GetGroup(groupID); err != nil {
+		respondWithDomainError(w, r, err, "Group not found")
+		return
+	}
+
+	task, err := modules.RedisClient.GetTask(taskID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "Task not found")
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	allowed := administersGroup(authCtx, task.GroupID)
+	if !visible {
+		allowed = allowed || administersGroup(authCtx, groupID)
+	}
+	if !allowed {
+		respondWithError(w, "Only the admin of the task's group can share it", http.StatusForbidden)
+		return
+	}
+	if task.GroupID == groupID {
+		respondWithError(w, "Task already belongs to this group", http.StatusConflict)
+		return
+	}
+	if groupFrozen(w, r, task.GroupID) {
+		return
+	}
+
+	changed := false
+	if visible && !task.VisibleTo(groupID) {
+		task.VisibleToGroups = append(task.VisibleToGroups, groupID)
+		changed = true
+	} else if !visible && task.VisibleTo(groupID) {
+		groupIDs := make(models.IntSlice, 0, len(task.VisibleToGroups))
+		for _, visibleGroupID := range task.VisibleToGroups {
+			if visibleGroupID != groupID {
+				groupIDs = append(groupIDs, visibleGroupID)
+			}
+		}
+		task.VisibleToGroups = groupIDs
+		changed = true
+	}
+
+	if changed {
+		if err := modules.RedisClient.SaveTaskBehind(task); err != nil {
+			respondWithError(w, "Failed to update task visibility", http.StatusInternalServerError)
+			return
+		}
+		modules.Events.PublishTask(r.Context(), "task.updated", task, task)
+	}
+
+	respondWithSuccess(w, map[string]interface{}{
+		"task_id":           task.ID,
+		"group_id":          groupID,
+		"visible":           visible,
+		"changed":           changed,
+		"visible_to_groups": task.VisibleToGroups,
+	})
+}
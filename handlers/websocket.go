@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"task-manager/modules"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// webSocketWriteTimeout drops clients that stop reading
+	webSocketWriteTimeout = 10 * time.Second
+
+	// maxWebSocketMessage caps what clients send, which is only ever a
+	// subscription change
+	maxWebSocketMessage = 4 << 10
+
+	// allEventTopics subscribes to every event the caller may see
+	allEventTopics = "*"
+)
+
+var errCrossOriginSocket = errors.New("session connections must come from the app's own origin")
+
+// webSocketPing sends a ping frame, which clients answer on their own
+var webSocketPing = websocket.Codec{
+	Marshal: func(interface{}) ([]byte, byte, error) {
+		return nil, websocket.PingFrame, nil
+	},
+}
+
+// webSocketMessage changes a connection's subscription
+type webSocketMessage struct {
+	Action string   `json:"action"`
+	Topics []string `json:"topics"`
+}
+
+// eventTopics are the events a connection is subscribed to: event types
+// such as task.created, or a prefix such as task for every task event
+type eventTopics map[string]bool
+
+func parseEventTopics(value string) eventTopics {
+	topics := make(eventTopics)
+	if value == "" {
+		topics[allEventTopics] = true
+		return topics
+	}
+	for _, topic := range strings.Split(value, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics[topic] = true
+		}
+	}
+	return topics
+}
+
+func (t eventTopics) match(eventType string) bool {
+	if t[allEventTopics] || t[eventType] {
+		return true
+	}
+	prefix, _, _ := strings.Cut(eventType, ".")
+	return t[prefix]
+}
+
+func (t eventTopics) list() []string {
+	topics := make([]string, 0, len(t))
+	for topic := range t {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// apply carries out a client message, returning the reply: the topics now
+// subscribed to, or what was wrong with the message
+func (t eventTopics) apply(message string) map[string]interface{} {
+	var msg webSocketMessage
+	if err := json.Unmarshal([]byte(message), &msg); err != nil {
+		return map[string]interface{}{"type": "error", "error": "Invalid JSON: " + err.Error()}
+	}
+
+	switch msg.Action {
+	case "subscribe":
+		for _, topic := range msg.Topics {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				t[topic] = true
+			}
+		}
+	case "unsubscribe":
+		for _, topic := range msg.Topics {
+			delete(t, strings.TrimSpace(topic))
+		}
+	default:
+		return map[string]interface{}{"type": "error", "error": "action must be subscribe or unsubscribe"}
+	}
+	return map[string]interface{}{"type": "subscribed", "topics": t.list()}
+}
+
+// hijackableWriter lets the WebSocket server take over connections through
+// the response writers the middlewares wrap around them
+type hijackableWriter struct {
+	http.ResponseWriter
+}
+
+func (w hijackableWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// WebSocketHandler pushes realtime events to the caller over a WebSocket
+// /ws. It delivers the same events as /stream, including the change events
+// published for every saved or deleted task, user and group, and clients
+// change what they receive by sending subscribe and unsubscribe messages.
+func WebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if modules.Events == nil {
+		respondWithError(w, "Event streaming is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Only HTTP/1.1 connections can be upgraded
+	if r.ProtoMajor != 1 || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		respondWithError(w, "Connect with a WebSocket client", http.StatusUpgradeRequired)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	topics := parseEventTopics(r.URL.Query().Get("topics"))
+
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			origin, err := websocket.Origin(config, req)
+			if err != nil {
+				return err
+			}
+			config.Origin = origin
+
+			// Browsers send cookies with WebSockets opened by any site, so
+			// sessions may only connect from a page served here
+			if authCtx.Session != nil && (origin == nil || origin.Host != req.Host) {
+				return errCrossOriginSocket
+			}
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			serveWebSocketEvents(ws, authCtx, topics)
+		},
+	}
+	server.ServeHTTP(hijackableWriter{w}, r)
+}
+
+// serveWebSocketEvents sends the caller the events they may see and are
+// subscribed to until either side closes the connection. Only this
+// goroutine writes; a reader passes client messages to it.
+func serveWebSocketEvents(ws *websocket.Conn, authCtx *modules.AuthContext, topics eventTopics) {
+	// The hijacked connection keeps the server's timeouts, which sockets outlive
+	ws.SetDeadline(time.Time{})
+	ws.MaxPayloadBytes = maxWebSocketMessage
+
+	events := modules.Events.Subscribe()
+	defer modules.Events.Unsubscribe(events)
+
+	messages := make(chan string)
+	closed := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		defer close(closed)
+		for {
+			var message string
+			if err := websocket.Message.Receive(ws, &message); err != nil {
+				return
+			}
+			select {
+			case messages <- message:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	send := func(codec websocket.Codec, v interface{}) bool {
+		ws.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+		return codec.Send(ws, v) == nil
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	if !send(websocket.JSON, map[string]interface{}{"type": "connected", "topics": topics.list()}) {
+		return
+	}
+
+	for {
+		var ok bool
+		select {
+		case event, open := <-events:
+			if !open || modules.StreamRevoked(authCtx, event) {
+				return
+			}
+			if !topics.match(event.Type) || !modules.CanReceiveEvent(authCtx, event) {
+				continue
+			}
+			ok = send(websocket.JSON, event)
+		case message := <-messages:
+			ok = send(websocket.JSON, topics.apply(message))
+		case <-heartbeat.C:
+			if modules.StreamRevoked(authCtx, nil) {
+				return
+			}
+			ok = send(webSocketPing, nil)
+		case <-closed:
+			return
+		}
+
+		if !ok {
+			return
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"task-manager/modules"
+)
+
+// permissionProbe is a request the permissions report checks for the
+// caller; {me} stands for the caller's own user ID and {id} for any record
+type permissionProbe struct {
+	Method string
+	Path   string
+}
+
+// permissionProbes are checked per resource type, in the order listed
+var permissionProbes = []struct {
+	Resource string
+	Probes   []permissionProbe
+}{
+	{"users", []permissionProbe{{"GET", "/users"}, {"POST", "/users"}, {"GET", "/users/{me}"}, {"PUT", "/users/{me}"}, {"GET", "/users/{me}/tasks"}}},
+	{"groups", []permissionProbe{{"GET", "/groups"}, {"POST", "/groups"}}},
+	{"tasks", []permissionProbe{{"GET", "/tasks/filter"}, {"GET", "/tasks/stats"}, {"POST", "/tasks/batch"}}},
+	{"search", []permissionProbe{{"GET", "/search"}, {"GET", "/tasks/search"}}},
+	{"reports", []permissionProbe{{"GET", "/reports/burndown"}}},
+	{"dashboards", []permissionProbe{{"GET", "/dashboards/users/{me}"}}},
+	{"imports", []permissionProbe{{"POST", "/imports/tasks"}}},
+	{"templates", []permissionProbe{{"GET", "/templates"}, {"POST", "/templates"}, {"POST", "/templates/{id}/apply"}}},
+	{"holidays", []permissionProbe{{"GET", "/holidays"}, {"PUT", "/holidays/{id}"}}},
+	{"objectives", []permissionProbe{{"GET", "/objectives"}, {"POST", "/objectives"}}},
+	{"stream", []permissionProbe{{"GET", "/stream"}, {"GET", "/ws"}}},
+	{"quota", []permissionProbe{{"GET", "/quota"}}},
+	{"admin", []permissionProbe{{"GET", "/admin/status"}, {"POST", "/admin/sync"}}},
+}
+
+// permissionCheck is one request and how the route check decides it
+type permissionCheck struct {
+	Request string `json:"request"`
+	modules.AccessDecision
+}
+
+// WhoAmIPermissionsHandler handles GET /whoami/permissions: who the caller
+// is to the server, their groups, and which rule lets each kind of request
+// through or refuses it. ?method=PUT&path=/groups/3 explains one request.
+func WhoAmIPermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	query := r.URL.Query()
+
+	v := newValidator()
+	method := strings.ToUpper(strings.TrimSpace(query.Get("method")))
+	path := strings.TrimSpace(query.Get("path"))
+	if method != "" || path != "" {
+		v.required(path, "path")
+		v.check(path == "" || strings.HasPrefix(path, "/"), "path", "invalid_value", "must start with /")
+		v.check(method == "" || method == "GET" || method == "POST" || method == "PUT" || method == "PATCH" || method == "DELETE",
+			"method", "invalid_choice", "GET, POST, PUT, PATCH, DELETE")
+	}
+	if !v.valid() {
+		respondWithValidationErrors(w, r, v)
+		return
+	}
+
+	meID := 0
+	caller := map[string]interface{}{
+		"role":             "owner",
+		"authenticated_by": authCtx.Method,
+	}
+	roles := map[string]interface{}{
+		"owner":       authCtx.IsOwner,
+		"group_admin": authCtx.IsGroupAdmin,
+		"user_admin":  authCtx.ManagesUsers(),
+	}
+	if user := authCtx.User; user != nil {
+		meID = user.ID
+		caller["user_id"] = user.ID
+		caller["email"] = user.Email
+		caller["full_name"] = user.FullName
+		caller["role"] = user.Role
+		if user.UserAdmin && len(user.UserAdminGroups) > 0 {
+			roles["user_admin_groups"] = user.UserAdminGroups
+		}
+	}
+
+	resolve := func(p string) string {
+		p = strings.ReplaceAll(p, "{me}", strconv.Itoa(meID))
+		return strings.ReplaceAll(p, "{id}", "0")
+	}
+
+	resources := make([]map[string]interface{}, 0, len(permissionProbes))
+	for _, resource := range permissionProbes {
+		checks := make([]permissionCheck, 0, len(resource.Probes))
+		for _, probe := range resource.Probes {
+			checks = append(checks, permissionCheck{
+				Request:        probe.Method + " " + probe.Path,
+				AccessDecision: modules.Authorize(authCtx, probe.Method, resolve(probe.Path)),
+			})
+		}
+		resources = append(resources, map[string]interface{}{
+			"resource": resource.Resource,
+			"checks":   checks,
+		})
+	}
+
+	response := map[string]interface{}{
+		"caller":    caller,
+		"roles":     roles,
+		"groups":    callerGroups(authCtx),
+		"resources": resources,
+		"note":      "Checks show the route rules; handlers may still refuse a request for the record it names, such as a task the caller is not assigned",
+	}
+	if path != "" {
+		if method == "" {
+			method = "GET"
+		}
+		response["check"] = permissionCheck{
+			Request:        method + " " + path,
+			AccessDecision: modules.ExplainAccess(authCtx, method, path),
+		}
+	}
+	respondWithSuccess(w, response)
+}
+
+// callerGroups lists the groups the caller belongs to or administers, with
+// whether the route rules let them view and change each one
+func callerGroups(authCtx *modules.AuthContext) []map[string]interface{} {
+	groups := []map[string]interface{}{}
+	if authCtx.User == nil {
+		return groups
+	}
+
+	ids := map[int]bool{}
+	for _, groupID := range authCtx.User.GroupIDs {
+		ids[groupID] = true
+	}
+	for _, groupID := range authCtx.AdminGroupIDs {
+		ids[groupID] = true
+	}
+	sorted := make([]int, 0, len(ids))
+	for groupID := range ids {
+		sorted = append(sorted, groupID)
+	}
+	sort.Ints(sorted)
+
+	for _, groupID := range sorted {
+		entry := map[string]interface{}{
+			"id":     groupID,
+			"member": isGroupMember(authCtx, groupID),
+			"admin":  administersGroup(authCtx, groupID),
+			"view":   modules.Authorize(authCtx, "GET", "/groups/"+strconv.Itoa(groupID)),
+			"manage": modules.Authorize(authCtx, "PUT", "/groups/"+strconv.Itoa(groupID)),
+		}
+		if group, err := modules.RedisClient.GetGroup(groupID); err == nil {
+			entry["name"] = group.Name
+			entry["archived"] = group.ArchivedAt != nil
+		}
+		groups = append(groups, entry)
+	}
+	return groups
+}
@@ -2,30 +2,58 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"task-manager/config"
 	"task-manager/handlers"
 	"task-manager/models"
 	"task-manager/modules"
 	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+var (
+	appLog  = modules.Logger("app")
+	httpLog = modules.Logger("http")
 )
 
 func main() {
-	// ASCII Art Banner
-	printBanner()
+	// Run a one-off command instead of the server, e.g. `gask seed`
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
 
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("❌ Failed to load configuration: %v", err)
 	}
-	cfg.Print()
+	if err := modules.InitLogging(cfg); err != nil {
+		log.Fatalf("❌ Failed to configure logging: %v", err)
+	}
+	if err := modules.InitAccessLog(cfg); err != nil {
+		log.Fatalf("❌ Failed to open access log: %v", err)
+	}
+
+	// Decorative output is for terminals; production logs get one startup event
+	pretty := cfg.Environment != "production"
+	if pretty {
+		printBanner()
+		cfg.Print()
+	} else {
+		logStartup(cfg)
+	}
 
 	// Initialize Redis
 	if err := modules.InitRedis(cfg); err != nil {
@@ -37,12 +65,89 @@ func main() {
 		log.Fatalf("❌ Failed to initialize PostgreSQL: %v", err)
 	}
 
+	// Initialize IP Filter
+	if err := modules.InitIPFilter(cfg); err != nil {
+		log.Fatalf("❌ Failed to load IP rules: %v", err)
+	}
+
+	// Initialize Rate Limiter
+	if err := modules.InitRateLimiter(cfg); err != nil {
+		log.Fatalf("❌ Failed to load rate limits: %v", err)
+	}
+
+	// Initialize PostgreSQL snapshots
+	if err := modules.InitBackups(cfg); err != nil {
+		log.Fatalf("❌ Failed to configure backups: %v", err)
+	}
+
+	// Initialize task attachment storage
+	if err := modules.InitAttachments(cfg); err != nil {
+		log.Fatalf("❌ Failed to configure attachments: %v", err)
+	}
+
+	// Initialize outbound HTTP for integrations
+	if err := modules.InitOutbound(cfg); err != nil {
+		log.Fatalf("❌ Failed to configure outbound requests: %v", err)
+	}
+
+	// Initialize link previews
+	modules.InitLinkPreviews(cfg)
+
+	// Initialize latency objectives
+	if err := modules.InitSLOs(cfg); err != nil {
+		log.Fatalf("❌ Failed to load SLOs: %v", err)
+	}
+
+	// Initialize API analytics
+	if err := modules.InitAPIAnalytics(cfg); err != nil {
+		log.Fatalf("❌ Failed to configure API analytics: %v", err)
+	}
+
+	// Initialize API usage quotas
+	if err := modules.InitQuotas(cfg); err != nil {
+		log.Fatalf("❌ Failed to configure API quotas: %v", err)
+	}
+
+	// Initialize Email Service
+	if err := modules.InitEmail(cfg); err != nil {
+		log.Fatalf("❌ Failed to initialize email service: %v", err)
+	}
+
+	// Read job schedule overrides before services register their jobs
+	if err := modules.InitJobs(cfg); err != nil {
+		log.Fatalf("❌ Failed to configure job schedules: %v", err)
+	}
+
 	// Initialize Sync Service
 	modules.InitSyncService()
 
+	// Initialize Report Scheduler
+	modules.InitReportScheduler()
+
+	// Initialize Reminder Scheduler
+	modules.InitReminderScheduler()
+
+	// Initialize notification batching
+	modules.InitNotifications(cfg.NotificationBatchWindow)
+
+	// Initialize Event Hub
+	modules.InitEvents()
+
+	// Initialize email and task existence filters
+	modules.InitExistenceFilters()
+
+	// Initialize the task write-behind buffer
+	modules.InitTaskWriteBuffer(cfg.TaskWriteBehind)
+
+	// Initialize task counter reconciliation
+	modules.InitStatsReconciler(cfg.StatsReconcileInterval)
+
+	// Initialize the dashboard projector
+	modules.InitProjector(cfg.ProjectionReconcileInterval)
+
 	// Load data from PostgreSQL to Redis on startup
 	if err := loadInitialData(); err != nil {
-		log.Printf("⚠️  Warning: Failed to load initial data: %v", err)
+		appLog.Warn("⚠️ Failed to load initial data", "error", err)
 	}
 
 	// Ensure owner user exists
@@ -53,6 +158,45 @@ func main() {
 	// Start sync service
 	modules.Syncer.Start()
 
+	// Start email retry loop
+	modules.Mailer.Start()
+
+	// Start report scheduler
+	modules.Reporter.Start()
+
+	// Start reminder scheduler
+	modules.Reminders.Start()
+
+	// Send notification digests as they fall due
+	modules.Notifications.Start()
+
+	// Start event hub
+	modules.Events.Start()
+
+	// Build the existence filters and keep them in step with other replicas
+	modules.Existence.Start()
+
+	// Recount tasks periodically to correct counter drift
+	modules.StatsReconcile.Start()
+
+	// Keep dashboard projections from task changes
+	modules.Projections.Start()
+
+	// Watch the rate limit policy file
+	modules.Limiter.Start()
+
+	// Pick up IP rules changed on other replicas
+	modules.IPRules.Start()
+
+	// Evaluate latency objectives
+	modules.SLOs.Start()
+
+	// Take scheduled snapshots
+	modules.Backups.Start()
+
+	// Run the periodic jobs the services above registered
+	modules.Jobs.Start()
+
 	// Set up HTTP server
 	server := setupServer(cfg)
 
@@ -62,10 +206,20 @@ func main() {
 
 	// Start server in goroutine
 	go func() {
-		fmt.Printf("\n🚀 GASK API Server running at http://%s\n\n", cfg.GetAPIAddr())
-		printEndpoints()
+		if pretty {
+			fmt.Printf("\n🚀 GASK API Server running at %s://%s\n\n", cfg.APIScheme(), cfg.GetAPIAddr())
+			printEndpoints()
+		} else {
+			appLog.Info("🚀 API server listening", "address", cfg.APIScheme()+"://"+cfg.GetAPIAddr())
+		}
 
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if cfg.TLSEnabled() {
+			err = server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("❌ Server failed to start: %v", err)
 		}
 	}()
@@ -74,13 +228,67 @@ func main() {
 	<-stop
 	fmt.Println("\n🔄 Shutting down server...")
 
-	// Stop sync service
+	shutdown(server, cfg.ShutdownTimeout)
+}
+
+// shutdown stops intake first, drains in-flight work within the timeout,
+// then syncs to PostgreSQL and closes the database connections last
+func shutdown(server *http.Server, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Stop picking up new background work
+	modules.Jobs.Stop()
+	modules.Limiter.Stop()
+	modules.IPRules.Stop()
+	modules.Reporter.Stop()
+	modules.Reminders.Stop()
+	modules.Notifications.Stop()
+	modules.Mailer.Stop()
 	modules.Syncer.Stop()
+	modules.Existence.Stop()
+	modules.StatsReconcile.Stop()
+	modules.Projections.Stop()
+	modules.SLOs.Stop()
+	modules.Backups.Stop()
+
+	// End open event streams so their connections can drain
+	modules.Events.Stop()
+
+	// Stop accepting connections and wait for in-flight requests
+	if err := server.Shutdown(ctx); err != nil {
+		appLog.Warn("⚠️ Server forced to shutdown", "error", err)
+	} else {
+		fmt.Println("✅ HTTP server drained")
+	}
 
-	// Force final sync before shutdown
+	// Wait for running report jobs, scheduled jobs, email sends and sync alerts
+	if err := modules.Reporter.Drain(ctx); err != nil {
+		appLog.Warn("⚠️ Report jobs did not finish in time", "error", err)
+	}
+	if err := modules.Jobs.Drain(ctx); err != nil {
+		appLog.Warn("⚠️ Scheduled jobs did not finish in time", "error", err)
+	}
+	if err := modules.Mailer.Drain(ctx); err != nil {
+		appLog.Warn("⚠️ Email sends did not finish in time", "error", err)
+	}
+	if err := modules.Syncer.Drain(ctx); err != nil {
+		appLog.Warn("⚠️ Sync cycle did not finish in time", "error", err)
+	}
+	if err := modules.Backups.Drain(ctx); err != nil {
+		appLog.Warn("⚠️ Snapshot did not finish in time", "error", err)
+	}
+	if err := modules.SLOs.Drain(ctx); err != nil {
+		appLog.Warn("⚠️ SLO alert did not finish in time", "error", err)
+	}
+
+	// Write task saves still held back
+	modules.TaskWrites.Flush()
+
+	// Final sync once nothing else can write to Redis
 	fmt.Println("📤 Performing final sync...")
 	if err := modules.Syncer.ForceSyncNow(); err != nil {
-		log.Printf("⚠️  Final sync failed: %v", err)
+		appLog.Warn("⚠️ Final sync failed", "error", err)
 	} else {
 		fmt.Println("✅ Final sync completed")
 	}
@@ -92,52 +300,62 @@ func main() {
 	if modules.PostgresClient != nil {
 		modules.PostgresClient.Close()
 	}
+	modules.AccessLog.Close()
 
-	// Shutdown server gracefully
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("⚠️  Server forced to shutdown: %v", err)
-	} else {
-		fmt.Println("✅ Server shutdown completed")
-	}
+	fmt.Println("✅ Server shutdown completed")
 }
 
 func setupServer(cfg *config.Config) *http.Server {
 	mux := http.NewServeMux()
 
-	// User routes
-	mux.HandleFunc("/users", handlers.UsersHandler)
-	mux.HandleFunc("/users/", handlers.UserHandler)
-	mux.HandleFunc("/users/search", handlers.SearchUsersHandler)
-
-	// Group routes
-	mux.HandleFunc("/groups", handlers.GroupsHandler)
-	mux.HandleFunc("/groups/", handlers.GroupHandler)
-
-	// Global task routes
-	mux.HandleFunc("/tasks/search", handlers.SearchTasksHandler)
-	mux.HandleFunc("/tasks/stats", handlers.GetTaskStatsHandler)
-	mux.HandleFunc("/tasks/batch", handlers.BatchUpdateTasksHandler)
-	mux.HandleFunc("/tasks/filter", handlers.GetTasksWithFiltersHandler)
+	// API routes
+	handlers.RegisterRoutes(mux)
 
 	// Admin/monitoring routes
 	mux.HandleFunc("/admin/sync", adminSyncHandler)
 	mux.HandleFunc("/admin/status", adminStatusHandler)
 	mux.HandleFunc("/admin/stats", adminStatsHandler)
+	mux.HandleFunc("/admin/emails", adminEmailsHandler)
+	mux.HandleFunc("/admin/health", adminHealthHandler)
+	mux.HandleFunc("/admin/log-level", adminLogLevelHandler)
+	mux.HandleFunc("/admin/ip-rules", adminIPRulesHandler)
+	mux.HandleFunc("/admin/verify", adminVerifyHandler)
+	if modules.ChaosAvailable && cfg.Environment != "production" {
+		mux.HandleFunc("/admin/chaos", adminChaosHandler)
+		fmt.Println("🧪 Fault injection available at /admin/chaos")
+	}
 	mux.HandleFunc("/health", healthCheckHandler)
+	mux.HandleFunc("/version", versionHandler)
+	mux.HandleFunc("/metrics", metricsHandler)
+
+	// Apply middleware: Request ID -> Logging -> Access Log -> Compression -> IP Filter -> CORS -> Auth -> Rate Limit -> SLO timing
+	var handler http.Handler = modules.IPFilterMiddleware(corsMiddleware(modules.AuthMiddleware(cfg.OwnerPassword)(modules.RateLimitMiddleware(modules.QuotaMiddleware(modules.SLOMiddleware(mux))))))
+	if cfg.Compression {
+		handler = modules.CompressionMiddleware(cfg.CompressionMinSize)(handler)
+	}
+	handler = modules.RequestIDMiddleware(loggingMiddleware(modules.AccessLogMiddleware(modules.APIAnalyticsMiddleware(handler))))
 
-	// Apply middleware: CORS -> Auth -> Logging
-	handler := loggingMiddleware(corsMiddleware(modules.AuthMiddleware(cfg.OwnerPassword)(mux)))
+	// Cleartext HTTP/2 is for running behind a proxy that speaks it to the backend
+	if cfg.H2C && cfg.HTTP2 && !cfg.TLSEnabled() {
+		handler = h2c.NewHandler(handler, &http2.Server{IdleTimeout: cfg.IdleTimeout})
+	}
 
-	return &http.Server{
-		Addr:         cfg.GetAPIAddr(),
-		Handler:      handler,
-		ReadTimeout:  cfg.APITimeout,
-		WriteTimeout: cfg.APITimeout,
-		IdleTimeout:  60 * time.Second,
+	server := &http.Server{
+		Addr:              cfg.GetAPIAddr(),
+		Handler:           handler,
+		ReadTimeout:       cfg.APITimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.APITimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		ConnState:         modules.Connections.Track,
 	}
+	server.SetKeepAlivesEnabled(cfg.KeepAlive)
+	if !cfg.HTTP2 {
+		// A non-nil empty map turns off HTTP/2 over TLS
+		server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+	return server
 }
 
 func loadInitialData() error {
@@ -148,11 +366,24 @@ func loadInitialData() error {
 
 	if len(users) == 0 {
 		fmt.Println("🔄 Loading initial data from PostgreSQL to Redis...")
-		return modules.Syncer.SyncFromPostgresToRedis()
+		if err := modules.Syncer.SyncFromPostgresToRedis(); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println("✅ Redis already has data, skipping initial load")
+
+		// Redis may have been restored from an older snapshot than PostgreSQL
+		if err := modules.Syncer.ReconcileCounters(); err != nil {
+			return err
+		}
 	}
 
-	fmt.Println("✅ Redis already has data, skipping initial load")
-	return nil
+	// Tasks saved by older versions are not yet filed by group and status,
+	// nor counted
+	if err := modules.RedisClient.EnsureGroupTaskIndexes(); err != nil {
+		return err
+	}
+	return modules.RedisClient.EnsureTaskStats()
 }
 
 func ensureOwnerExists(ownerEmail, ownerPassword string) error {
@@ -181,11 +412,13 @@ func ensureOwnerExists(ownerEmail, ownerPassword string) error {
 			Role:      "owner",
 			GroupIDs:  models.IntSlice{},
 			Email:     ownerEmail,
-			Password:  ownerPassword,
 			WorkTimes: make(models.WorkTimes),
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
+		if err := modules.SetPassword(owner, ownerPassword); err != nil {
+			return err
+		}
 
 		if err := modules.RedisClient.SaveUser(owner); err != nil {
 			return err
@@ -235,6 +468,33 @@ func adminSyncHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, `{"success": true, "message": "Sync completed successfully", "action": "%s"}`, action)
 }
 
+// adminVerifyHandler cross-checks Redis and PostgreSQL. GET only reports;
+// POST also repairs what can be repaired safely.
+func adminVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		http.Error(w, "Only owner can verify data integrity", http.StatusForbidden)
+		return
+	}
+
+	report, err := modules.VerifyIntegrity(r.Method == "POST")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Verification failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    report,
+	})
+}
+
 func adminStatusHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -252,6 +512,13 @@ func adminStatusHandler(w http.ResponseWriter, r *http.Request) {
 	// Add connection info
 	if modules.RedisClient != nil {
 		status["redis"] = modules.RedisClient.GetConnectionInfo()
+		status["locks"] = modules.GetLockStats()
+	}
+	if modules.Limiter.Enabled() {
+		status["rate_limits"] = map[string]interface{}{
+			"policies": modules.Limiter.Policies(),
+			"source":   modules.Limiter.Source(),
+		}
 	}
 	if modules.PostgresClient != nil {
 		status["postgres"] = modules.PostgresClient.GetConnectionInfo()
@@ -263,6 +530,34 @@ func adminStatusHandler(w http.ResponseWriter, r *http.Request) {
 		"api_port":      cfg.APIPort,
 		"sync_interval": cfg.SyncInterval.String(),
 		"environment":   cfg.Environment,
+		"server": map[string]interface{}{
+			"tls":                 cfg.TLSEnabled(),
+			"http2":               cfg.HTTP2,
+			"h2c":                 cfg.H2C && cfg.HTTP2 && !cfg.TLSEnabled(),
+			"keep_alive":          cfg.KeepAlive,
+			"read_timeout":        cfg.APITimeout.String(),
+			"read_header_timeout": cfg.ReadHeaderTimeout.String(),
+			"write_timeout":       cfg.APITimeout.String(),
+			"idle_timeout":        cfg.IdleTimeout.String(),
+			"max_header_bytes":    cfg.MaxHeaderBytes,
+		},
+	}
+	status["build"] = buildInfo()
+	status["connections"] = modules.Connections.Snapshot()
+	status["existence_filters"] = modules.Existence.Status()
+	status["task_write_behind"] = modules.TaskWrites.Status()
+	if modules.AccessLog.Enabled() {
+		status["access_log"] = modules.AccessLog.Status()
+	}
+	status["outbound"] = modules.Outbound.Status()
+	if modules.APIUsage.Enabled() {
+		status["api_analytics"] = modules.APIUsage.Status()
+	}
+	if modules.SLOs.Enabled() {
+		status["slos"] = map[string]interface{}{
+			"window":     modules.SLOs.Window().String(),
+			"objectives": modules.SLOs.Status(),
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -272,6 +567,27 @@ func adminStatusHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// metricsHandler serves the latency objectives and business metrics in the
+// Prometheus text format
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		http.Error(w, "Only owner can view metrics", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	modules.SLOs.WriteMetrics(w)
+	if err := modules.WriteBusinessMetrics(w); err != nil {
+		httpLog.ErrorContext(r.Context(), "❌ Failed to read business metrics", "error", err)
+	}
+}
+
 func adminStatsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -318,6 +634,42 @@ func adminStatsHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func adminEmailsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		http.Error(w, "Only owner can view the email log", http.StatusForbidden)
+		return
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if n, err := strconv.Atoi(limitStr); err == nil && n > 0 && n <= 500 {
+			limit = n
+		}
+	}
+
+	entries, err := modules.PostgresClient.GetEmailLogs(limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get email log: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"provider": modules.Mailer.Provider(),
+			"emails":   entries,
+			"count":    len(entries),
+		},
+	})
+}
+
 func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	// Accept both GET and HEAD methods for health checks
 	if r.Method != "GET" && r.Method != "HEAD" {
@@ -325,35 +677,212 @@ func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	status := "healthy"
-	code := http.StatusOK
+	report := modules.CheckHealth()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(healthStatusCode(report.Status))
+
+	// For HEAD requests, don't write body
+	if r.Method == "GET" {
+		payload := map[string]string{
+			"status":    report.Status,
+			"timestamp": report.Timestamp.Format(time.RFC3339),
+		}
+		for key, value := range buildInfo() {
+			payload[key] = value
+		}
+		json.NewEncoder(w).Encode(payload)
+	}
+}
+
+// versionHandler reports the build that is running, without authentication
+// so deploys can be checked like health
+func versionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == "GET" {
+		json.NewEncoder(w).Encode(buildInfo())
+	}
+}
+
+// adminHealthHandler reports per-dependency health and latency
+func adminHealthHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-	// Check Redis
-	if _, err := modules.RedisClient.GetLastSyncTime(); err != nil {
-		status = "unhealthy"
-		code = http.StatusServiceUnavailable
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		http.Error(w, "Only owner can view detailed health", http.StatusForbidden)
+		return
 	}
 
-	// Check PostgreSQL
-	if err := modules.PostgresClient.Ping(); err != nil {
-		status = "unhealthy"
-		code = http.StatusServiceUnavailable
+	report := modules.CheckHealth()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(healthStatusCode(report.Status))
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": report.Status != "unhealthy",
+		"data":    report,
+	})
+}
+
+// adminLogLevelHandler shows or changes log levels at runtime
+func adminLogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		http.Error(w, "Only owner can manage log levels", http.StatusForbidden)
+		return
 	}
 
-	// Check sync service
-	if !modules.Syncer.IsHealthy() {
-		status = "degraded"
-		if code == http.StatusOK {
-			code = http.StatusPartialContent
+	switch r.Method {
+	case "GET":
+	case "PUT":
+		var req struct {
+			Module string `json:"module"`
+			Level  string `json:"level"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
 		}
+		if err := modules.SetLogLevel(req.Module, req.Level); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		appLog.Info("Log level changed", "target_module", req.Module, "level", req.Level)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    modules.GetLogLevels(),
+	})
+}
 
-	// For HEAD requests, don't write body
-	if r.Method == "GET" {
-		fmt.Fprintf(w, `{"status": "%s", "timestamp": "%s"}`, status, time.Now().Format(time.RFC3339))
+// adminChaosHandler shows, sets (PUT) and clears (DELETE) the faults injected
+// into Redis and PostgreSQL on this replica. It is only routed in
+// non-production binaries built with the chaos tag.
+func adminChaosHandler(w http.ResponseWriter, r *http.Request) {
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		http.Error(w, "Only owner can inject faults", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+	case "PUT":
+		var req modules.ChaosSettings
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, faults := range []modules.Faults{req.Redis, req.Postgres} {
+			if faults.ErrorRate < 0 || faults.ErrorRate > 1 || faults.LatencyMs < 0 || faults.LatencyMs > 60000 {
+				http.Error(w, "error_rate must be between 0 and 1 and latency_ms between 0 and 60000", http.StatusBadRequest)
+				return
+			}
+		}
+		modules.SetChaos(req)
+		appLog.Warn("⚠️ Fault injection changed",
+			"redis_error_rate", req.Redis.ErrorRate,
+			"redis_latency_ms", req.Redis.LatencyMs,
+			"postgres_error_rate", req.Postgres.ErrorRate,
+			"postgres_latency_ms", req.Postgres.LatencyMs)
+	case "DELETE":
+		modules.SetChaos(modules.ChaosSettings{})
+		appLog.Info("Fault injection cleared")
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    modules.GetChaos(),
+	})
+}
+
+// adminIPRulesHandler lists, adds and removes runtime IP allow/deny rules
+func adminIPRulesHandler(w http.ResponseWriter, r *http.Request) {
+	authCtx := modules.GetAuthContext(r)
+	if !authCtx.IsOwner {
+		http.Error(w, "Only owner can manage IP rules", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+	case "POST", "DELETE":
+		var req struct {
+			List string `json:"list"`
+			CIDR string `json:"cidr"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var cidr string
+		var err error
+		if r.Method == "POST" {
+			cidr, err = modules.IPRules.Add(req.List, req.CIDR)
+		} else {
+			cidr, err = modules.IPRules.Remove(req.List, req.CIDR)
+		}
+		switch {
+		case errors.Is(err, modules.ErrValidation):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, modules.ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, "Failed to update IP rules: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Undo changes that would shut the caller out of admin routes
+		if !modules.IPRules.AdminReachableFrom(r) {
+			if r.Method == "POST" {
+				modules.IPRules.Remove(req.List, cidr)
+			} else {
+				modules.IPRules.Add(req.List, cidr)
+			}
+			http.Error(w, "Change reverted: it would block your own IP from admin routes", http.StatusConflict)
+			return
+		}
+		appLog.InfoContext(r.Context(), "IP rules changed", "method", r.Method, "list", req.List, "cidr", cidr)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    modules.IPRules.Rules(),
+	})
+}
+
+func healthStatusCode(status string) int {
+	switch status {
+	case "unhealthy":
+		return http.StatusServiceUnavailable
+	case "degraded":
+		return http.StatusPartialContent
+	default:
+		return http.StatusOK
 	}
 }
 
@@ -364,15 +893,20 @@ func loggingMiddleware(next http.Handler) http.Handler {
 		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 		next.ServeHTTP(rw, r)
 		duration := time.Since(start)
-		log.Printf("%s %s %d %v", r.Method, r.URL.Path, rw.statusCode, duration)
+		httpLog.InfoContext(r.Context(), "request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rw.statusCode,
+			"duration_ms", float64(duration.Microseconds())/1000)
 	})
 }
 
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Owner-Password")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Owner-Password, X-Request-ID, X-CSRF-Token")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 		w.Header().Set("Access-Control-Max-Age", "86400")
 
 		if r.Method == "OPTIONS" {
@@ -394,7 +928,31 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap exposes the underlying writer so http.ResponseController can flush streams
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// logStartup records what is starting as one structured event
+func logStartup(cfg *config.Config) {
+	build := buildInfo()
+	appLog.Info("🚀 Starting "+cfg.AppName,
+		"version", build["version"],
+		"commit", build["commit"],
+		"build_date", build["build_date"],
+		"go", build["go"],
+		"environment", cfg.Environment,
+		"address", cfg.APIScheme()+"://"+cfg.GetAPIAddr(),
+		"redis", cfg.GetRedisAddr(),
+		"postgres", fmt.Sprintf("%s:%d/%s", cfg.PostgresHost, cfg.PostgresPort, cfg.PostgresDB),
+		"email", cfg.EmailProvider,
+		"timezone", cfg.Timezone)
+}
+
 func printBanner() {
+	build := buildInfo()
+	version := fmt.Sprintf("Version %s (%s)", build["version"], build["commit"])
+
 	banner := `
 ╔═══════════════════════════════════════════════════════════╗
 ║                                                           ║
@@ -406,23 +964,38 @@ func printBanner() {
 ║    ╚═════╝ ╚═╝  ╚═╝╚══════╝╚═╝  ╚═╝                     ║
 ║                                                           ║
 ║   Go-based Advanced taSK management system                ║
-║   Version 2.0 - Production Ready                         ║
+║   %-56s║
 ║                                                           ║
 ╚═══════════════════════════════════════════════════════════╝
 `
-	fmt.Println(banner)
+	fmt.Printf(banner+"\n", version)
 }
 
 func printEndpoints() {
 	fmt.Println("📚 Available Endpoints:")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("🔐 Auth:       POST /auth/token, /auth/refresh, /auth/login")
 	fmt.Println("👥 Users:      GET/POST /users")
 	fmt.Println("👔 Groups:     GET/POST /groups")
 	fmt.Println("📋 Tasks:      GET/POST /users/{id}/tasks")
-	fmt.Println("🔍 Search:     GET /tasks/search?q=...")
+	fmt.Println("⏰ Reminders:  GET/POST /users/{id}/tasks/{tid}/reminders")
+	fmt.Println("💬 Comments:   GET/POST /tasks/{id}/comments")
+	fmt.Println("📎 Files:      GET/POST /tasks/{id}/attachments")
+	fmt.Println("🌴 Leaves:     GET/POST /users/{id}/leaves")
+	fmt.Println("🔍 Search:     GET /search?q=...")
 	fmt.Println("📊 Stats:      GET /tasks/stats")
-	fmt.Println("🔧 Admin:      POST /admin/sync")
-	fmt.Println("🏥 Health:     GET /health")
+	fmt.Println("🧭 Dashboards: GET /dashboards/users/{id}, /dashboards/groups/{id}[/burndown]")
+	fmt.Println("📡 Stream:     GET /stream, WebSocket /ws")
+	fmt.Println("📰 Feeds:      GET /groups/{id}/feed.atom?token=...")
+	fmt.Println("🏢 Clients:    GET/POST /clients, GET /portal")
+	fmt.Println("📥 Intake:     GET/POST /intake-forms, POST /intake/{token}")
+	fmt.Println("🎯 OKRs:       GET/POST /objectives, GET /objectives/report")
+	fmt.Println("📄 Imports:    POST /imports/tasks, POST /imports/{id}/execute")
+	fmt.Println("🧩 Templates:  GET/POST /templates, POST /templates/{id}/apply")
+	fmt.Println("🔧 Admin:      POST /admin/sync, GET/POST /admin/verify")
+	fmt.Println("💾 Backups:    GET/POST /admin/backups")
+	fmt.Println("🏥 Health:     GET /health, GET /version")
+	fmt.Println("📈 Metrics:    GET /metrics")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println("📖 Full API documentation in README.md")
 	fmt.Println()
@@ -4,28 +4,325 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type Task struct {
-	ID          int       `json:"id" gorm:"primaryKey"`
-	Title       string    `json:"title" gorm:"not null"`
-	Status      bool      `json:"status" gorm:"default:false"`
-	Priority    int       `json:"priority" gorm:"default:1"`
-	Deadline    string    `json:"deadline"`
-	Information string    `json:"information"`
-	UserID      int       `json:"user_id" gorm:"not null;index"`
-	GroupID     int       `json:"group_id" gorm:"not null;index"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID             int       `json:"id" gorm:"primaryKey"`
+	Key            string    `json:"key,omitempty" gorm:"index"`
+	Title          string    `json:"title" gorm:"not null"`
+	Status         bool      `json:"status" gorm:"default:false"`
+	State          string    `json:"state" gorm:"size:16;index"`
+	StateReason    string    `json:"state_reason,omitempty"`
+	BlockedBy      int       `json:"blocked_by,omitempty"`
+	ParentTaskID   int       `json:"parent_task_id,omitempty" gorm:"index"`
+	Priority       Priority  `json:"priority" gorm:"default:1"`
+	Deadline       string    `json:"deadline"`
+	Information    string    `json:"information"`
+	EstimatedHours float64   `json:"estimated_hours" gorm:"default:0"`
+	UserID         int       `json:"user_id" gorm:"not null;index"`
+	Collaborators  IntSlice  `json:"collaborators,omitempty" gorm:"type:json"`
+	CheckedBy      IntSlice  `json:"checked_by,omitempty" gorm:"type:json"`
+	GroupID        int       `json:"group_id" gorm:"not null;index"`
+	Version        int       `json:"version" gorm:"default:0"`
+	Rank           string    `json:"rank,omitempty" gorm:"size:64"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// OriginalDeadline is the deadline before the first approved extension;
+	// Extensions lists every extension asked for, in order
+	OriginalDeadline string             `json:"original_deadline,omitempty"`
+	Extensions       DeadlineExtensions `json:"extensions,omitempty" gorm:"type:json"`
+
+	// VisibleToGroups lists other groups that may read the task, such as
+	// one that depends on it. It grants no right to change it.
+	VisibleToGroups IntSlice `json:"visible_to_groups,omitempty" gorm:"type:json"`
+
+	// ClosedAt is when the task was done or cancelled, set by SyncState and
+	// cleared when it reopens. Reports count closed tasks on this day.
+	ClosedAt *time.Time `json:"closed_at,omitempty"`
+}
+
+// VisibleTo reports whether the task belongs to the group or is shared
+// with it
+func (t *Task) VisibleTo(groupID int) bool {
+	if t.GroupID == groupID {
+		return true
+	}
+	for _, visibleGroupID := range t.VisibleToGroups {
+		if visibleGroupID == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+// A task is open until it is done. Blocked tasks are still to be done but
+// wait on another task or an outside reason; cancelled tasks never will be.
+// Status stays true exactly when the state is done.
+const (
+	TaskOpen      = "open"
+	TaskDone      = "done"
+	TaskBlocked   = "blocked"
+	TaskCancelled = "cancelled"
+)
+
+// TaskStates lists the states in the order they are shown
+const TaskStates = "open, blocked, done, cancelled"
+
+// CurrentState reads the state from Status and State, which may lag behind
+// a Status change or be empty for tasks saved before states existed
+func (t *Task) CurrentState() string {
+	if t.Status {
+		return TaskDone
+	}
+	if t.State == TaskBlocked || t.State == TaskCancelled {
+		return t.State
+	}
+	return TaskOpen
+}
+
+// SyncState stores the current state, dropping the reason and blocking
+// task once the task is no longer blocked or cancelled, and stamps ClosedAt
+// the first time it is seen closed
+func (t *Task) SyncState() {
+	t.State = t.CurrentState()
+	if t.State == TaskOpen || t.State == TaskDone {
+		t.StateReason = ""
+		t.BlockedBy = 0
+	}
+	if t.State == TaskCancelled {
+		t.BlockedBy = 0
+	}
+
+	switch {
+	case !t.Closed():
+		t.ClosedAt = nil
+	case t.ClosedAt == nil:
+		now := time.Now()
+		t.ClosedAt = &now
+	}
+}
+
+// ClosedTime is when a closed task was closed. Tasks closed before ClosedAt
+// was kept, and not saved since, fall back to their last update.
+func (t *Task) ClosedTime() time.Time {
+	if t.ClosedAt != nil {
+		return *t.ClosedAt
+	}
+	return t.UpdatedAt
+}
+
+// Closed reports whether the task needs no more work, being done or cancelled
+func (t *Task) Closed() bool {
+	return t.Status || t.State == TaskCancelled
+}
+
+// A task's assignees are its primary assignee, UserID, and any
+// collaborators. Each assignee checks off their own part in CheckedBy; the
+// task is done once all have, or when marked done outright. Stats count a
+// task for its primary assignee only.
+
+// Assignees lists the primary assignee first, then the collaborators
+func (t *Task) Assignees() []int {
+	return append([]int{t.UserID}, t.Collaborators...)
+}
+
+func (t *Task) IsAssignee(userID int) bool {
+	return t.UserID == userID || containsInt(t.Collaborators, userID)
+}
+
+func (t *Task) HasChecked(userID int) bool {
+	return containsInt(t.CheckedBy, userID)
+}
+
+// SetChecked ticks or clears an assignee's checkbox, reporting whether every
+// assignee has now checked theirs
+func (t *Task) SetChecked(userID int, checked bool) bool {
+	if checked && !t.HasChecked(userID) {
+		t.CheckedBy = append(t.CheckedBy, userID)
+	}
+	if !checked {
+		t.CheckedBy = removeInt(t.CheckedBy, userID)
+	}
+	for _, assignee := range t.Assignees() {
+		if !t.HasChecked(assignee) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetCollaborators replaces the collaborators, dropping the primary
+// assignee and repeats, and forgets the checks of anyone no longer assigned
+func (t *Task) SetCollaborators(userIDs []int) {
+	collaborators := IntSlice{}
+	for _, userID := range userIDs {
+		if userID != t.UserID && !containsInt(collaborators, userID) {
+			collaborators = append(collaborators, userID)
+		}
+	}
+	if len(collaborators) == 0 {
+		collaborators = nil
+	}
+	t.Collaborators = collaborators
+
+	var checked IntSlice
+	for _, userID := range t.CheckedBy {
+		if t.IsAssignee(userID) {
+			checked = append(checked, userID)
+		}
+	}
+	t.CheckedBy = checked
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func removeInt(values IntSlice, value int) IntSlice {
+	var kept IntSlice
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// MarshalJSON adds the priority's label next to its number and fills in
+// the current state
+func (t Task) MarshalJSON() ([]byte, error) {
+	type task Task
+	t.State = t.CurrentState()
+	return json.Marshal(struct {
+		task
+		PriorityLabel string `json:"priority_label,omitempty"`
+	}{task(t), t.Priority.Label()})
+}
+
+// Priority ranks a task from low to critical. It is stored and compared as
+// its number, and accepted in requests as either the number or the label.
+type Priority int
+
+const (
+	PriorityLow      Priority = 1
+	PriorityMedium   Priority = 2
+	PriorityHigh     Priority = 3
+	PriorityCritical Priority = 4
+)
+
+// legacyPriorityMax is the top of the 1-5 scale used before priorities had
+// labels; 5 now reads as critical
+const legacyPriorityMax = 5
+
+// PriorityLabels lists the labels in order, for messages and docs
+const PriorityLabels = "low, medium, high, critical"
+
+var priorityLabels = map[Priority]string{
+	PriorityLow:      "low",
+	PriorityMedium:   "medium",
+	PriorityHigh:     "high",
+	PriorityCritical: "critical",
+}
+
+func (p Priority) Valid() bool {
+	return p >= PriorityLow && p <= PriorityCritical
+}
+
+// Label returns the priority's name, or "" if it is not valid
+func (p Priority) Label() string {
+	return priorityLabels[p]
+}
+
+// ParsePriority reads a label, in any case, or a number from 1 to 5
+func ParsePriority(value string) (Priority, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for priority, label := range priorityLabels {
+		if value == label {
+			return priority, true
+		}
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	priority := normalizePriority(n)
+	return priority, priority.Valid()
+}
+
+func normalizePriority(n int) Priority {
+	if n == legacyPriorityMax {
+		return PriorityCritical
+	}
+	return Priority(n)
+}
+
+// Scan reads the stored number, so rows saved with the legacy 5 load as
+// critical
+func (p *Priority) Scan(value interface{}) error {
+	switch n := value.(type) {
+	case nil:
+		*p = 0
+	case int64:
+		*p = normalizePriority(int(n))
+	default:
+		return fmt.Errorf("cannot scan %T into Priority", value)
+	}
+	return nil
+}
+
+// UnmarshalJSON accepts a number or a label. Numbers are kept as given, bar
+// the legacy 5, so out-of-range ones can be reported by validation.
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		*p = normalizePriority(n)
+		return nil
+	}
+
+	var label string
+	if err := json.Unmarshal(data, &label); err != nil {
+		return fmt.Errorf("priority must be a number or one of: %s", PriorityLabels)
+	}
+	if label == "" {
+		*p = 0
+		return nil
+	}
+	priority, ok := ParsePriority(label)
+	if !ok {
+		return fmt.Errorf("priority %q is not one of: %s", label, PriorityLabels)
+	}
+	*p = priority
+	return nil
 }
 
 type Group struct {
 	ID        int       `json:"id" gorm:"primaryKey"`
 	Name      string    `json:"name" gorm:"not null;uniqueIndex"`
+	Code      string    `json:"code,omitempty" gorm:"index"`
 	AdminID   int       `json:"admin_id" gorm:"not null;index"`
+	Version   int       `json:"version" gorm:"default:0"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// ArchivedAt is set while the group is archived: its tasks are read-only
+	// and it is left out of group listings
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	ArchivedBy int        `json:"archived_by,omitempty"`
+}
+
+// Archived reports whether the group is archived
+func (g *Group) Archived() bool {
+	return g.ArchivedAt != nil
 }
 
 type IntSlice []int
@@ -68,18 +365,113 @@ func (is IntSlice) MarshalJSON() ([]byte, error) {
 	return json.Marshal([]int(is))
 }
 
-type WorkTimes map[string]float64
+// WorkTimes are when a user works: for each lowercase weekday, the
+// intervals they work in the organization's timezone. A day that is
+// missing, or has no intervals, is a day off.
+type WorkTimes map[string][]WorkInterval
+
+// WorkInterval is a stretch of work within a day, from Start to End as
+// "HH:MM". End may be "24:00" for work that runs to midnight.
+type WorkInterval struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// legacyWorkStart is when a day stored the old way, as a number of hours,
+// is taken to start, in minutes past midnight
+const legacyWorkStart = 9 * 60
+
+// Minutes returns the interval's start and end as minutes past midnight
+func (i WorkInterval) Minutes() (int, int, bool) {
+	start, startOK := parseClock(i.Start)
+	end, endOK := parseClock(i.End)
+	return start, end, startOK && endOK
+}
+
+// parseClock reads an "HH:MM" time as minutes past midnight
+func parseClock(value string) (int, bool) {
+	if len(value) != 5 || value[2] != ':' {
+		return 0, false
+	}
+	for _, c := range value[:2] + value[3:] {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	hours, _ := strconv.Atoi(value[:2])
+	minutes, _ := strconv.Atoi(value[3:])
+	if minutes > 59 || hours > 24 || (hours == 24 && minutes > 0) {
+		return 0, false
+	}
+	return hours*60 + minutes, true
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// Hours returns how long the user works on a day
+func (wt WorkTimes) Hours(day string) float64 {
+	total := 0
+	for _, interval := range wt[day] {
+		if start, end, ok := interval.Minutes(); ok && end > start {
+			total += end - start
+		}
+	}
+	return float64(total) / 60
+}
+
+// AvailableAt reports whether at falls within one of the intervals of its
+// day. at should be in the organization's timezone.
+func (wt WorkTimes) AvailableAt(at time.Time) bool {
+	minute := at.Hour()*60 + at.Minute()
+	for _, interval := range wt[strings.ToLower(at.Weekday().String())] {
+		if start, end, ok := interval.Minutes(); ok && start <= minute && minute < end {
+			return true
+		}
+	}
+	return false
+}
+
+// NextAvailable returns the first moment from at on that the user works:
+// at itself when they are working, otherwise the start of their next
+// interval within a week. It is false for someone who never works.
+func (wt WorkTimes) NextAvailable(at time.Time) (time.Time, bool) {
+	if wt.AvailableAt(at) {
+		return at, true
+	}
+
+	midnight := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	for offset := 0; offset <= 7; offset++ {
+		day := midnight.AddDate(0, 0, offset)
+		earliest := -1
+		for _, interval := range wt[strings.ToLower(day.Weekday().String())] {
+			start, end, ok := interval.Minutes()
+			if !ok || end <= start {
+				continue
+			}
+			startsAt := day.Add(time.Duration(start) * time.Minute)
+			if startsAt.After(at) && (earliest < 0 || start < earliest) {
+				earliest = start
+			}
+		}
+		if earliest >= 0 {
+			return day.Add(time.Duration(earliest) * time.Minute), true
+		}
+	}
+	return time.Time{}, false
+}
 
 func (wt WorkTimes) Value() (driver.Value, error) {
 	if wt == nil {
-		return json.Marshal(map[string]float64{})
+		return json.Marshal(map[string][]WorkInterval{})
 	}
-	return json.Marshal(wt)
+	return json.Marshal(map[string][]WorkInterval(wt))
 }
 
 func (wt *WorkTimes) Scan(value interface{}) error {
 	if value == nil {
-		*wt = make(map[string]float64)
+		*wt = make(WorkTimes)
 		return nil
 	}
 
@@ -87,34 +479,412 @@ func (wt *WorkTimes) Scan(value interface{}) error {
 	if !ok {
 		return errors.New("cannot scan into WorkTimes")
 	}
+	return wt.UnmarshalJSON(bytes)
+}
 
-	result := make(map[string]float64)
-	if err := json.Unmarshal(bytes, &result); err != nil {
+func (wt WorkTimes) MarshalJSON() ([]byte, error) {
+	if wt == nil {
+		return json.Marshal(map[string][]WorkInterval{})
+	}
+	return json.Marshal(map[string][]WorkInterval(wt))
+}
+
+// UnmarshalJSON reads work times, including those stored before they had
+// intervals, as a number of hours per day. Those become one interval
+// starting at 09:00.
+func (wt *WorkTimes) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var days map[string]json.RawMessage
+	if err := json.Unmarshal(data, &days); err != nil {
 		return err
 	}
 
+	result := make(WorkTimes, len(days))
+	for day, raw := range days {
+		var hours float64
+		if json.Unmarshal(raw, &hours) == nil {
+			if hours > 0 {
+				end := legacyWorkStart + int(hours*60)
+				if end > 24*60 {
+					end = 24 * 60
+				}
+				result[day] = []WorkInterval{{Start: formatClock(legacyWorkStart), End: formatClock(end)}}
+			}
+			continue
+		}
+
+		var intervals []WorkInterval
+		if err := json.Unmarshal(raw, &intervals); err != nil {
+			return fmt.Errorf("work times for %s: %v", day, err)
+		}
+		result[day] = intervals
+	}
+
 	*wt = result
 	return nil
 }
 
-func (wt WorkTimes) MarshalJSON() ([]byte, error) {
-	if wt == nil {
-		return json.Marshal(map[string]float64{})
+// WebhookHeaders are extra HTTP headers sent with a webhook delivery
+type WebhookHeaders map[string]string
+
+func (wh WebhookHeaders) Value() (driver.Value, error) {
+	if wh == nil {
+		return json.Marshal(map[string]string{})
+	}
+	return json.Marshal(wh)
+}
+
+func (wh *WebhookHeaders) Scan(value interface{}) error {
+	if value == nil {
+		*wh = make(map[string]string)
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan into WebhookHeaders")
+	}
+
+	result := make(map[string]string)
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return err
+	}
+
+	*wh = result
+	return nil
+}
+
+// ClientContact is a person to reach at a client company
+type ClientContact struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+type ClientContacts []ClientContact
+
+func (cc ClientContacts) Value() (driver.Value, error) {
+	if cc == nil {
+		return json.Marshal([]ClientContact{})
 	}
-	return json.Marshal(map[string]float64(wt))
+	return json.Marshal(cc)
+}
+
+func (cc *ClientContacts) Scan(value interface{}) error {
+	if value == nil {
+		*cc = []ClientContact{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan into ClientContacts")
+	}
+
+	var result []ClientContact
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return err
+	}
+
+	if result == nil {
+		*cc = []ClientContact{}
+	} else {
+		*cc = result
+	}
+	return nil
+}
+
+func (cc ClientContacts) MarshalJSON() ([]byte, error) {
+	if cc == nil {
+		return json.Marshal([]ClientContact{})
+	}
+	return json.Marshal([]ClientContact(cc))
+}
+
+// Deadline extension states
+const (
+	ExtensionPending  = "pending"
+	ExtensionApproved = "approved"
+	ExtensionRejected = "rejected"
+)
+
+// DeadlineExtension is an assignee's request to move a task's deadline,
+// decided by the group's admin
+type DeadlineExtension struct {
+	ID          int        `json:"id"`
+	RequestedBy int        `json:"requested_by"`
+	From        string     `json:"from"`
+	To          string     `json:"to"`
+	Reason      string     `json:"reason"`
+	Status      string     `json:"status"`
+	ReviewerID  int        `json:"reviewer_id,omitempty"`
+	ReviewNote  string     `json:"review_note,omitempty"`
+	RequestedAt time.Time  `json:"requested_at"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+}
+
+type DeadlineExtensions []DeadlineExtension
+
+func (de DeadlineExtensions) Value() (driver.Value, error) {
+	if de == nil {
+		return json.Marshal([]DeadlineExtension{})
+	}
+	return json.Marshal(de)
+}
+
+func (de *DeadlineExtensions) Scan(value interface{}) error {
+	if value == nil {
+		*de = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan into DeadlineExtensions")
+	}
+
+	var result []DeadlineExtension
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return err
+	}
+	*de = result
+	return nil
+}
+
+// Pending returns the extension awaiting a decision, if there is one
+func (de DeadlineExtensions) Pending() *DeadlineExtension {
+	for i := range de {
+		if de[i].Status == ExtensionPending {
+			return &de[i]
+		}
+	}
+	return nil
+}
+
+// Extension returns the task's extension with the given ID
+func (t *Task) Extension(id int) *DeadlineExtension {
+	for i := range t.Extensions {
+		if t.Extensions[i].ID == id {
+			return &t.Extensions[i]
+		}
+	}
+	return nil
+}
+
+// Key result metrics
+const (
+	MetricTasks  = "tasks"
+	MetricManual = "manual"
+)
+
+// KeyResult is a measurable outcome of an objective. Its progress comes
+// from the linked tasks and groups (metric "tasks") or from Current
+// against Target (metric "manual").
+type KeyResult struct {
+	ID       int     `json:"id"`
+	Title    string  `json:"title"`
+	Metric   string  `json:"metric"`
+	Target   float64 `json:"target,omitempty"`
+	Current  float64 `json:"current,omitempty"`
+	TaskIDs  []int   `json:"task_ids"`
+	GroupIDs []int   `json:"group_ids"`
+}
+
+type KeyResults []KeyResult
+
+func (kr KeyResults) Value() (driver.Value, error) {
+	if kr == nil {
+		return json.Marshal([]KeyResult{})
+	}
+	return json.Marshal(kr)
+}
+
+func (kr *KeyResults) Scan(value interface{}) error {
+	if value == nil {
+		*kr = []KeyResult{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan into KeyResults")
+	}
+
+	var result []KeyResult
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return err
+	}
+
+	if result == nil {
+		*kr = []KeyResult{}
+	} else {
+		*kr = result
+	}
+	return nil
+}
+
+func (kr KeyResults) MarshalJSON() ([]byte, error) {
+	if kr == nil {
+		return json.Marshal([]KeyResult{})
+	}
+	return json.Marshal([]KeyResult(kr))
 }
 
 type User struct {
-	ID        int       `json:"id" gorm:"primaryKey"`
-	FullName  string    `json:"full_name" gorm:"not null"`
-	Role      string    `json:"role" gorm:"not null;default:'user'"`
-	GroupIDs  IntSlice  `json:"group_ids" gorm:"type:json"`
-	Number    string    `json:"number"`
-	Email     string    `json:"email" gorm:"not null;uniqueIndex"`
-	Password  string    `json:"password,omitempty" gorm:"not null"`
-	WorkTimes WorkTimes `json:"work_times" gorm:"type:json"`
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID          int       `json:"id" gorm:"primaryKey"`
+	FullName    string    `json:"full_name" gorm:"not null"`
+	Role        string    `json:"role" gorm:"not null;default:'user'"`
+	GroupIDs    IntSlice  `json:"group_ids" gorm:"type:json"`
+	Number      string    `json:"number"`
+	Email       string    `json:"email" gorm:"not null;uniqueIndex"`
+	Password    string    `json:"password,omitempty" gorm:"not null"`
+	WorkTimes   WorkTimes `json:"work_times" gorm:"type:json"`
+	Region      string    `json:"region"`
+	Deactivated bool      `json:"deactivated" gorm:"default:false"`
+	Version     int       `json:"version" gorm:"default:0"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// UserAdmin lets the owner delegate user administration: creating and
+	// deleting users and changing their roles, for every user or, when
+	// UserAdminGroups is set, for members of those groups only
+	UserAdmin       bool     `json:"user_admin" gorm:"default:false"`
+	UserAdminGroups IntSlice `json:"user_admin_groups,omitempty" gorm:"type:json"`
+}
+
+// UserAdminRequest delegates user administration, for every user with
+// Global or for members of GroupIDs
+type UserAdminRequest struct {
+	Global   bool  `json:"global"`
+	GroupIDs []int `json:"group_ids"`
+}
+
+// Client is a customer company whose work is organised in one or more groups
+type Client struct {
+	ID        int            `json:"id" gorm:"primaryKey"`
+	Company   string         `json:"company" gorm:"not null;uniqueIndex"`
+	Contacts  ClientContacts `json:"contacts" gorm:"type:json"`
+	GroupIDs  IntSlice       `json:"group_ids" gorm:"type:json"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type CreateClientRequest struct {
+	Company  string          `json:"company" binding:"required"`
+	Contacts []ClientContact `json:"contacts"`
+	GroupIDs []int           `json:"group_ids"`
+}
+
+type UpdateClientRequest struct {
+	Company  string          `json:"company,omitempty"`
+	Contacts []ClientContact `json:"contacts,omitempty"`
+	GroupIDs []int           `json:"group_ids,omitempty"`
+}
+
+// Risk statuses
+const (
+	RiskOpen       = "open"
+	RiskMitigating = "mitigating"
+	RiskClosed     = "closed"
+)
+
+// Risk is an entry in a group's risk register. Probability and impact are
+// rated 1-5 and Score is their product.
+type Risk struct {
+	ID          int       `json:"id" gorm:"primaryKey"`
+	GroupID     int       `json:"group_id" gorm:"not null;index"`
+	Description string    `json:"description" gorm:"not null"`
+	Probability int       `json:"probability" gorm:"not null"`
+	Impact      int       `json:"impact" gorm:"not null"`
+	Score       int       `json:"score" gorm:"not null"`
+	Mitigation  string    `json:"mitigation"`
+	OwnerID     int       `json:"owner_id,omitempty"`
+	Status      string    `json:"status" gorm:"not null;default:'open';index"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type CreateRiskRequest struct {
+	Description string `json:"description" binding:"required"`
+	Probability int    `json:"probability" binding:"required"`
+	Impact      int    `json:"impact" binding:"required"`
+	Mitigation  string `json:"mitigation"`
+	OwnerID     int    `json:"owner_id"`
+	Status      string `json:"status"`
+}
+
+type UpdateRiskRequest struct {
+	Description string  `json:"description,omitempty"`
+	Probability int     `json:"probability,omitempty"`
+	Impact      int     `json:"impact,omitempty"`
+	Mitigation  *string `json:"mitigation,omitempty"`
+	OwnerID     *int    `json:"owner_id,omitempty"`
+	Status      string  `json:"status,omitempty"`
+}
+
+// Comment is a note on a task, written in Markdown. AuthorID is 0 for the
+// owner; AuthorName is kept so comments outlive their author's account.
+type Comment struct {
+	ID         int       `json:"id" gorm:"primaryKey"`
+	TaskID     int       `json:"task_id" gorm:"not null;index"`
+	AuthorID   int       `json:"author_id" gorm:"index"`
+	AuthorName string    `json:"author_name"`
+	Body       string    `json:"body" gorm:"type:text;not null"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateCommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// Attachment is a file attached to a task; the file itself is kept by the
+// attachment storage. UploadedBy is 0 for the owner.
+type Attachment struct {
+	ID          int       `json:"id" gorm:"primaryKey"`
+	TaskID      int       `json:"task_id" gorm:"not null;index"`
+	Filename    string    `json:"filename" gorm:"not null"`
+	ContentType string    `json:"content_type" gorm:"not null"`
+	SizeBytes   int64     `json:"size_bytes"`
+	UploadedBy  int       `json:"uploaded_by" gorm:"index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Objective is a goal for a quarter such as "2026-Q3", measured by its key results
+type Objective struct {
+	ID          int        `json:"id" gorm:"primaryKey"`
+	Title       string     `json:"title" gorm:"not null"`
+	Description string     `json:"description"`
+	Quarter     string     `json:"quarter" gorm:"not null;index"`
+	OwnerID     int        `json:"owner_id,omitempty"`
+	KeyResults  KeyResults `json:"key_results" gorm:"type:json"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type CreateObjectiveRequest struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+	Quarter     string `json:"quarter"`
+	OwnerID     int    `json:"owner_id"`
+}
+
+type UpdateObjectiveRequest struct {
+	Title       string  `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Quarter     string  `json:"quarter,omitempty"`
+	OwnerID     *int    `json:"owner_id,omitempty"`
+}
+
+type KeyResultRequest struct {
+	Title    string   `json:"title,omitempty"`
+	Metric   string   `json:"metric,omitempty"`
+	Target   *float64 `json:"target,omitempty"`
+	Current  *float64 `json:"current,omitempty"`
+	TaskIDs  []int    `json:"task_ids,omitempty"`
+	GroupIDs []int    `json:"group_ids,omitempty"`
 }
 
 type UserGroup struct {
@@ -128,10 +898,12 @@ type SearchTask struct {
 }
 
 type APIResponse struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Success   bool              `json:"success"`
+	Message   string            `json:"message,omitempty"`
+	Data      interface{}       `json:"data,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
 }
 
 type PaginatedResponse struct {
@@ -143,53 +915,508 @@ type PaginatedResponse struct {
 	Total   int64       `json:"total,omitempty"`
 }
 
+// UserResponse is the public view of a User; it never carries the password.
+// Contact details and work times are only filled in for privileged viewers.
+type UserResponse struct {
+	ID          int       `json:"id"`
+	FullName    string    `json:"full_name"`
+	Role        string    `json:"role"`
+	GroupIDs    IntSlice  `json:"group_ids"`
+	Email       string    `json:"email"`
+	Number      string    `json:"number,omitempty"`
+	WorkTimes   WorkTimes `json:"work_times,omitempty"`
+	Region      string    `json:"region,omitempty"`
+	Deactivated bool      `json:"deactivated"`
+	Version     int       `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	UserAdmin       bool     `json:"user_admin,omitempty"`
+	UserAdminGroups IntSlice `json:"user_admin_groups,omitempty"`
+}
+
+// NewUserResponse maps a user to its response, including private fields only when asked
+func NewUserResponse(user *User, includePrivate bool) *UserResponse {
+	response := &UserResponse{
+		ID:          user.ID,
+		FullName:    user.FullName,
+		Role:        user.Role,
+		GroupIDs:    user.GroupIDs,
+		Email:       user.Email,
+		Region:      user.Region,
+		Deactivated: user.Deactivated,
+		Version:     user.Version,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+
+		UserAdmin:       user.UserAdmin,
+		UserAdminGroups: user.UserAdminGroups,
+	}
+
+	if includePrivate {
+		response.Number = user.Number
+		response.WorkTimes = user.WorkTimes
+	}
+
+	return response
+}
+
 type CreateUserRequest struct {
-	FullName  string             `json:"full_name" binding:"required"`
-	Role      string             `json:"role"`
-	GroupIDs  []int              `json:"group_ids"`
-	Number    string             `json:"number"`
-	Email     string             `json:"email" binding:"required,email"`
-	Password  string             `json:"password" binding:"required,min=6"`
-	WorkTimes map[string]float64 `json:"work_times"`
+	FullName  string                    `json:"full_name" binding:"required"`
+	Role      string                    `json:"role"`
+	GroupIDs  []int                     `json:"group_ids"`
+	Number    string                    `json:"number"`
+	Email     string                    `json:"email" binding:"required,email"`
+	Password  string                    `json:"password" binding:"required,min=6"`
+	WorkTimes map[string][]WorkInterval `json:"work_times"`
+	Region    string                    `json:"region"`
 }
 
 type UpdateUserRequest struct {
-	FullName  string             `json:"full_name,omitempty"`
-	Role      string             `json:"role,omitempty"`
-	GroupIDs  []int              `json:"group_ids,omitempty"`
-	Number    string             `json:"number,omitempty"`
-	Email     string             `json:"email,omitempty"`
-	Password  string             `json:"password,omitempty"`
-	WorkTimes map[string]float64 `json:"work_times,omitempty"`
+	FullName  string                    `json:"full_name,omitempty"`
+	Role      string                    `json:"role,omitempty"`
+	GroupIDs  []int                     `json:"group_ids,omitempty"`
+	Number    string                    `json:"number,omitempty"`
+	Email     string                    `json:"email,omitempty"`
+	Password  string                    `json:"password,omitempty"`
+	WorkTimes map[string][]WorkInterval `json:"work_times,omitempty"`
+	Region    string                    `json:"region,omitempty"`
+}
+
+// MergeUsersRequest names the duplicate account to merge into another user
+type MergeUsersRequest struct {
+	SourceUserID int `json:"source_user_id" binding:"required"`
+}
+
+// OffboardUserRequest says who takes over a leaving user's open tasks:
+// Assignees maps a group ID to the member taking them in that group, and
+// groups left out go to their admin
+type OffboardUserRequest struct {
+	Assignees map[int]int `json:"assignees"`
+	Reason    string      `json:"reason"`
 }
 
 type CreateTaskRequest struct {
-	Title       string `json:"title" binding:"required"`
-	Priority    int    `json:"priority"`
-	Deadline    string `json:"deadline"`
-	Information string `json:"information"`
-	GroupID     int    `json:"group_id" binding:"required"`
+	Title          string   `json:"title" binding:"required"`
+	Priority       Priority `json:"priority"`
+	Deadline       string   `json:"deadline"`
+	Information    string   `json:"information"`
+	EstimatedHours float64  `json:"estimated_hours"`
+	GroupID        int      `json:"group_id" binding:"required"`
+	Collaborators  []int    `json:"collaborators"`
+
+	// ParentTaskID makes the task a subtask of another task in its group
+	ParentTaskID int `json:"parent_task_id"`
 }
 
 type UpdateTaskRequest struct {
-	Title       string `json:"title,omitempty"`
-	Priority    int    `json:"priority,omitempty"`
-	Deadline    string `json:"deadline,omitempty"`
-	Information string `json:"information,omitempty"`
-	Status      *bool  `json:"status,omitempty"`
-	GroupID     int    `json:"group_id,omitempty"`
+	Title          string   `json:"title,omitempty"`
+	Priority       Priority `json:"priority,omitempty"`
+	Deadline       string   `json:"deadline,omitempty"`
+	Information    string   `json:"information,omitempty"`
+	EstimatedHours float64  `json:"estimated_hours,omitempty"`
+	Status         *bool    `json:"status,omitempty"`
+	GroupID        int      `json:"group_id,omitempty"`
+
+	// Collaborators replaces the task's collaborators when present; an
+	// empty list removes them all
+	Collaborators *[]int `json:"collaborators,omitempty"`
+
+	// ParentTaskID moves the task under another task of its group when
+	// present; 0 makes it a top-level task again
+	ParentTaskID *int `json:"parent_task_id,omitempty"`
+
+	// State moves the task to open, blocked, done or cancelled. Cancelling
+	// needs a reason; blocking needs a reason, the blocking task, or both.
+	State       string `json:"state,omitempty"`
+	StateReason string `json:"state_reason,omitempty"`
+	BlockedBy   int    `json:"blocked_by,omitempty"`
+}
+
+// RankTaskRequest moves a task to just after another task of the same
+// user, or to the top of their list when AfterTaskID is 0
+type RankTaskRequest struct {
+	AfterTaskID int `json:"after_task_id"`
+}
+
+// QuickAddRequest is a one-line task to be read by POST /tasks/quick.
+// GroupID is used when no tag names one of the assignee's groups.
+type QuickAddRequest struct {
+	Text    string `json:"text" binding:"required"`
+	GroupID int    `json:"group_id"`
+}
+
+// CreateExtensionRequest asks to move a task's deadline to Deadline
+type CreateExtensionRequest struct {
+	Deadline string `json:"deadline" binding:"required"`
+	Reason   string `json:"reason" binding:"required"`
 }
 
 type CreateGroupRequest struct {
 	Name    string `json:"name" binding:"required"`
+	Code    string `json:"code"`
 	AdminID int    `json:"admin_id" binding:"required"`
 }
 
 type UpdateGroupRequest struct {
-	Name    string `json:"name,omitempty"`
-	AdminID int    `json:"admin_id,omitempty"`
+	Name    string  `json:"name,omitempty"`
+	Code    *string `json:"code,omitempty"`
+	AdminID int     `json:"admin_id,omitempty"`
+}
+
+// GroupUsersBatchRequest adds and removes several group members at once
+type GroupUsersBatchRequest struct {
+	Add    []int `json:"add"`
+	Remove []int `json:"remove"`
 }
 
 type WorkTimesRequest struct {
-	WorkTimes map[string]float64 `json:"work_times" binding:"required"`
+	WorkTimes map[string][]WorkInterval `json:"work_times" binding:"required"`
+}
+
+type LeaveRequest struct {
+	ID         int        `json:"id" gorm:"primaryKey"`
+	UserID     int        `json:"user_id" gorm:"not null;index"`
+	StartDate  string     `json:"start_date" gorm:"not null"`
+	EndDate    string     `json:"end_date" gorm:"not null"`
+	Type       string     `json:"type" gorm:"not null;default:'vacation'"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status" gorm:"not null;default:'pending';index"`
+	ReviewerID int        `json:"reviewer_id,omitempty"`
+	ReviewNote string     `json:"review_note,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type CreateLeaveRequest struct {
+	StartDate string `json:"start_date" binding:"required"`
+	EndDate   string `json:"end_date" binding:"required"`
+	Type      string `json:"type"`
+	Reason    string `json:"reason"`
+}
+
+type ReviewLeaveRequest struct {
+	Note string `json:"note"`
+}
+
+// LeaveDateLayout is the date format used for leave start and end dates
+const LeaveDateLayout = "2006-01-02"
+
+// Covers reports whether the leave spans the given calendar day
+func (l *LeaveRequest) Covers(day time.Time) bool {
+	start, err := time.Parse(LeaveDateLayout, l.StartDate)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(LeaveDateLayout, l.EndDate)
+	if err != nil {
+		return false
+	}
+
+	d, _ := time.Parse(LeaveDateLayout, day.Format(LeaveDateLayout))
+	return !d.Before(start) && !d.After(end)
+}
+
+type EmailLog struct {
+	ID        int        `json:"id" gorm:"primaryKey"`
+	To        string     `json:"to" gorm:"not null;index"`
+	Subject   string     `json:"subject" gorm:"not null"`
+	Template  string     `json:"template" gorm:"index"`
+	HTMLBody  string     `json:"-" gorm:"type:text"`
+	TextBody  string     `json:"-" gorm:"type:text"`
+	Provider  string     `json:"provider"`
+	Status    string     `json:"status" gorm:"not null;default:'pending';index"`
+	Attempts  int        `json:"attempts" gorm:"default:0"`
+	LastError string     `json:"last_error,omitempty"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type ReportSubscription struct {
+	ID              int            `json:"id" gorm:"primaryKey"`
+	UserID          int            `json:"user_id" gorm:"not null;index"`
+	ReportType      string         `json:"report_type" gorm:"not null"`
+	GroupID         int            `json:"group_id,omitempty"`
+	Schedule        string         `json:"schedule" gorm:"not null"`
+	Delivery        string         `json:"delivery" gorm:"not null;default:'email'"`
+	WebhookURL      string         `json:"webhook_url,omitempty"`
+	WebhookTemplate string         `json:"webhook_template,omitempty" gorm:"type:text"`
+	WebhookHeaders  WebhookHeaders `json:"webhook_headers,omitempty" gorm:"type:json"`
+	Paused          bool           `json:"paused" gorm:"default:false"`
+	LastRunAt       *time.Time     `json:"last_run_at,omitempty"`
+	NextRunAt       *time.Time     `json:"next_run_at,omitempty"`
+	LastError       string         `json:"last_error,omitempty"`
+	CreatedAt       time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type CreateReportSubscriptionRequest struct {
+	ReportType      string            `json:"report_type" binding:"required"`
+	GroupID         int               `json:"group_id"`
+	Schedule        string            `json:"schedule" binding:"required"`
+	Delivery        string            `json:"delivery"`
+	WebhookURL      string            `json:"webhook_url"`
+	WebhookTemplate string            `json:"webhook_template"`
+	WebhookHeaders  map[string]string `json:"webhook_headers"`
+}
+
+// WebhookTest is the outcome of test-firing a subscription's webhook
+type WebhookTest struct {
+	Payload    string            `json:"payload"`
+	Headers    map[string]string `json:"headers"`
+	Sent       bool              `json:"sent"`
+	StatusCode int               `json:"status_code,omitempty"`
+	Response   string            `json:"response,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	DurationMs int64             `json:"duration_ms,omitempty"`
+}
+
+// Report is a generated report delivered to a subscriber
+type Report struct {
+	SubscriptionID int                    `json:"subscription_id"`
+	ReportType     string                 `json:"report_type"`
+	Title          string                 `json:"title"`
+	PeriodStart    time.Time              `json:"period_start"`
+	PeriodEnd      time.Time              `json:"period_end"`
+	GeneratedAt    time.Time              `json:"generated_at"`
+	Summary        map[string]interface{} `json:"summary"`
+	Digest         *GroupDigest           `json:"digest,omitempty"`
+}
+
+// GroupDigest lists what changed in a group over a report's period, for
+// group_daily_digest reports
+type GroupDigest struct {
+	GroupID       int            `json:"group_id"`
+	GroupName     string         `json:"group_name"`
+	Created       []DigestTask   `json:"created"`
+	Completed     []DigestTask   `json:"completed"`
+	Overdue       []DigestTask   `json:"overdue"`
+	MembersJoined []DigestMember `json:"members_joined"`
+	MembersLeft   []DigestMember `json:"members_left"`
+}
+
+type DigestTask struct {
+	ID       int      `json:"id"`
+	Key      string   `json:"key,omitempty"`
+	Title    string   `json:"title"`
+	UserID   int      `json:"user_id"`
+	Priority Priority `json:"priority"`
+	Deadline string   `json:"deadline,omitempty"`
+}
+
+type DigestMember struct {
+	UserID   int       `json:"user_id"`
+	FullName string    `json:"full_name"`
+	At       time.Time `json:"at"`
+}
+
+// Reminder is a notification about a task due at RemindAt. System reminders
+// are kept in line with the task's deadline; users add their own on top.
+type Reminder struct {
+	ID        int        `json:"id"`
+	TaskID    int        `json:"task_id"`
+	UserID    int        `json:"user_id"`
+	RemindAt  time.Time  `json:"remind_at"`
+	Note      string     `json:"note,omitempty"`
+	System    bool       `json:"system"`
+	Deadline  string     `json:"deadline,omitempty"`
+	Snoozed   bool       `json:"snoozed"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// CreateReminderRequest sets a reminder at a time or after a duration such
+// as "4h", counted on the wall or business clock
+type CreateReminderRequest struct {
+	RemindAt string `json:"remind_at"`
+	In       string `json:"in"`
+	Clock    string `json:"clock"`
+	Note     string `json:"note"`
+}
+
+// SnoozeReminderRequest delays a reminder until a time or by a duration such
+// as "2h", counted on the wall or business clock
+type SnoozeReminderRequest struct {
+	Until string `json:"until"`
+	For   string `json:"for"`
+	Clock string `json:"clock"`
+}
+
+// Intake submission outcomes
+const (
+	IntakeAccepted  = "accepted"
+	IntakeRejected  = "rejected"
+	IntakeThrottled = "throttled"
+)
+
+// IntakeField is one input of an intake form and the task field it fills:
+// title, information, deadline or priority. Several fields may fill the
+// information, which lists them by label.
+type IntakeField struct {
+	Name     string `json:"name"`
+	Label    string `json:"label"`
+	MapsTo   string `json:"maps_to"`
+	Required bool   `json:"required"`
+}
+
+// IntakeForm lets people without an account submit tasks into a group.
+// Submissions become open tasks of the triage assignee.
+type IntakeForm struct {
+	ID          int           `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	GroupID     int           `json:"group_id"`
+	AssigneeID  int           `json:"assignee_id"`
+	Fields      []IntakeField `json:"fields"`
+	Enabled     bool          `json:"enabled"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+type CreateIntakeFormRequest struct {
+	Name        string        `json:"name" binding:"required"`
+	Description string        `json:"description"`
+	GroupID     int           `json:"group_id" binding:"required"`
+	AssigneeID  int           `json:"assignee_id"`
+	Fields      []IntakeField `json:"fields" binding:"required"`
+}
+
+type UpdateIntakeFormRequest struct {
+	Name        string        `json:"name,omitempty"`
+	Description *string       `json:"description,omitempty"`
+	AssigneeID  int           `json:"assignee_id,omitempty"`
+	Fields      []IntakeField `json:"fields,omitempty"`
+	Enabled     *bool         `json:"enabled,omitempty"`
+}
+
+// IntakeSubmission is an entry in an intake form's submissions log
+type IntakeSubmission struct {
+	At      time.Time `json:"at"`
+	IP      string    `json:"ip"`
+	Outcome string    `json:"outcome"`
+	TaskID  int       `json:"task_id,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// MoveTasksRequest moves tasks to another group. AssigneeMap hands the tasks
+// of a user outside the target group to one inside it, by user ID.
+type MoveTasksRequest struct {
+	TargetGroupID int         `json:"target_group_id" binding:"required"`
+	TaskIDs       []int       `json:"task_ids" binding:"required"`
+	AssigneeMap   map[int]int `json:"assignee_map"`
+}
+
+// ExecuteImportRequest maps the columns of an uploaded file to task fields
+// and says how to read their values. Mapping is keyed by column name.
+type ExecuteImportRequest struct {
+	GroupID       int                 `json:"group_id" binding:"required"`
+	Mapping       map[string]string   `json:"mapping" binding:"required"`
+	DateFormat    string              `json:"date_format"`
+	PriorityMap   map[string]Priority `json:"priority_map"`
+	DefaultUserID int                 `json:"default_user_id"`
+	SkipInvalid   bool                `json:"skip_invalid"`
+	DryRun        bool                `json:"dry_run"`
+}
+
+// Import row outcomes
+const (
+	ImportRowCreated = "created"
+	ImportRowValid   = "valid"
+	ImportRowInvalid = "invalid"
+	ImportRowFailed  = "failed"
+)
+
+// ImportRowResult is the outcome of one row of an import. Errors are keyed
+// by column name.
+type ImportRowResult struct {
+	Row    int               `json:"row"`
+	Status string            `json:"status"`
+	TaskID int               `json:"task_id,omitempty"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Estimation session statuses
+const (
+	EstimationOpen     = "open"
+	EstimationRevealed = "revealed"
+	EstimationAccepted = "accepted"
+)
+
+// EstimationSession is a planning-poker round over some of a group's tasks.
+// Sessions only live in Redis; accepted estimates end up on the tasks.
+type EstimationSession struct {
+	ID        int       `json:"id"`
+	GroupID   int       `json:"group_id"`
+	TaskIDs   []int     `json:"task_ids"`
+	CreatedBy int       `json:"created_by,omitempty"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateEstimationRequest struct {
+	TaskIDs []int `json:"task_ids" binding:"required"`
+}
+
+// EstimatesRequest maps task IDs to estimated hours
+type EstimatesRequest struct {
+	Estimates map[int]float64 `json:"estimates"`
+}
+
+type SearchResult struct {
+	Type    string  `json:"type"`
+	ID      int     `json:"id"`
+	Key     string  `json:"key,omitempty"`
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet"`
+	Rank    float64 `json:"rank"`
+	UserID  int     `json:"user_id,omitempty"`
+	GroupID int     `json:"group_id,omitempty"`
+}
+
+// TaskTemplateFormat names the version of the template document format
+const TaskTemplateFormat = "gask.task-template/v1"
+
+// TaskTemplateDocument is the portable form of a task template, such as an
+// onboarding or release checklist. It holds no IDs or users, so the same
+// file can be kept in git and imported into any deployment.
+type TaskTemplateDocument struct {
+	Format      string         `json:"format"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Tasks       []TemplateTask `json:"tasks"`
+}
+
+// TemplateTask is a task a template creates. Ref names it for BlockedBy of
+// later tasks; DueAfterDays sets its deadline that many days after the
+// date the template is applied from.
+type TemplateTask struct {
+	Ref            string   `json:"ref,omitempty"`
+	Title          string   `json:"title"`
+	Information    string   `json:"information,omitempty"`
+	Priority       Priority `json:"priority,omitempty"`
+	EstimatedHours float64  `json:"estimated_hours,omitempty"`
+	DueAfterDays   *int     `json:"due_after_days,omitempty"`
+	BlockedBy      string   `json:"blocked_by,omitempty"`
+}
+
+// TaskTemplate is a template imported into this deployment. Templates only
+// live in Redis.
+type TaskTemplate struct {
+	ID int `json:"id"`
+	TaskTemplateDocument
+	CreatedBy int       `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ApplyTemplateRequest creates a template's tasks in a group for one
+// assignee, with deadlines counted from StartDate
+type ApplyTemplateRequest struct {
+	GroupID   int    `json:"group_id" binding:"required"`
+	UserID    int    `json:"user_id"`
+	StartDate string `json:"start_date"`
 }
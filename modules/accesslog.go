@@ -0,0 +1,314 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"task-manager/config"
+	"time"
+)
+
+// clfTimeFormat is the timestamp layout of the Common Log Format
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// rotatedSuffixFormat is appended to a rotated file's name. It sorts in time
+// order, which pruning relies on.
+const rotatedSuffixFormat = "20060102-150405"
+
+var accessLogLog = Logger("accesslog")
+
+// AccessLogger writes one line per request to a file, rotating it by size
+// and age
+type AccessLogger struct {
+	format   string
+	path     string
+	maxSize  int64
+	rotate   time.Duration
+	maxFiles int
+
+	mu        sync.Mutex
+	file      *os.File
+	size      int64
+	rotateAt  time.Time
+	written   uint64
+	rotations uint64
+}
+
+var AccessLog *AccessLogger
+
+// InitAccessLog opens ACCESS_LOG_FILE for appending. Without one, requests
+// are only logged to stderr.
+func InitAccessLog(cfg *config.Config) error {
+	if cfg == nil {
+		cfg = config.AppConfig
+	}
+	if cfg.AccessLogFile == "" {
+		return nil
+	}
+	if cfg.AccessLogFormat != "clf" && cfg.AccessLogFormat != "json" {
+		return fmt.Errorf("invalid ACCESS_LOG_FORMAT %q, must be 'clf' or 'json'", cfg.AccessLogFormat)
+	}
+
+	logger := &AccessLogger{
+		format:   cfg.AccessLogFormat,
+		path:     cfg.AccessLogFile,
+		maxSize:  int64(cfg.AccessLogMaxSize) << 20,
+		rotate:   cfg.AccessLogRotate,
+		maxFiles: cfg.AccessLogMaxFiles,
+	}
+	if err := os.MkdirAll(filepath.Dir(logger.path), 0755); err != nil {
+		return err
+	}
+	if err := logger.open(time.Now()); err != nil {
+		return err
+	}
+	AccessLog = logger
+	return nil
+}
+
+// Enabled reports whether requests are written to a file
+func (l *AccessLogger) Enabled() bool {
+	return l != nil
+}
+
+func (l *AccessLogger) open(now time.Time) error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	l.file = file
+	l.size = info.Size()
+	if l.rotate > 0 {
+		l.rotateAt = now.Truncate(l.rotate).Add(l.rotate)
+	}
+	return nil
+}
+
+// AccessLogEntry is one finished request
+type AccessLogEntry struct {
+	Time       time.Time `json:"time"`
+	RemoteAddr string    `json:"remote_addr"`
+	User       string    `json:"user"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Proto      string    `json:"proto"`
+	Status     int       `json:"status"`
+	Bytes      int64     `json:"bytes"`
+	DurationMs float64   `json:"duration_ms"`
+	RequestID  string    `json:"request_id,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	Referer    string    `json:"referer,omitempty"`
+}
+
+func (e *AccessLogEntry) line(format string) []byte {
+	if format == "json" {
+		line, _ := json.Marshal(e)
+		return append(line, '\n')
+	}
+
+	size := "-"
+	if e.Bytes > 0 {
+		size = strconv.FormatInt(e.Bytes, 10)
+	}
+	return []byte(fmt.Sprintf("%s - %s [%s] %s %d %s\n",
+		e.RemoteAddr, e.User, e.Time.Format(clfTimeFormat),
+		strconv.Quote(e.Method+" "+e.Path+" "+e.Proto), e.Status, size))
+}
+
+// Log writes an entry, rotating the file first when it is due
+func (l *AccessLogger) Log(entry *AccessLogEntry) {
+	line := entry.line(l.format)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return
+	}
+	if l.due(entry.Time, len(line)) {
+		if err := l.rotateFile(entry.Time); err != nil {
+			accessLogLog.Warn("⚠️ Failed to rotate access log", "file", l.path, "error", err)
+		}
+		if l.file == nil {
+			return
+		}
+	}
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		accessLogLog.Warn("⚠️ Failed to write access log", "file", l.path, "error", err)
+		return
+	}
+	l.written++
+}
+
+func (l *AccessLogger) due(now time.Time, next int) bool {
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(next) > l.maxSize {
+		return true
+	}
+	return l.rotate > 0 && !now.Before(l.rotateAt)
+}
+
+// rotateFile renames the current file with the time of rotation, opens a
+// new one and removes the oldest rotated files over the limit
+func (l *AccessLogger) rotateFile(now time.Time) error {
+	if err := l.file.Close(); err != nil {
+		accessLogLog.Warn("⚠️ Failed to close access log", "file", l.path, "error", err)
+	}
+	l.file = nil
+
+	rotated := l.path + "." + now.Format(rotatedSuffixFormat)
+	for i := 1; fileExists(rotated); i++ {
+		rotated = fmt.Sprintf("%s.%s-%d", l.path, now.Format(rotatedSuffixFormat), i)
+	}
+	if err := os.Rename(l.path, rotated); err != nil && !os.IsNotExist(err) {
+		// Keep appending to the same file rather than losing lines
+		if reopenErr := l.open(now); reopenErr != nil {
+			return reopenErr
+		}
+		return err
+	}
+	l.rotations++
+
+	if err := l.open(now); err != nil {
+		return err
+	}
+	return l.prune()
+}
+
+func (l *AccessLogger) prune() error {
+	if l.maxFiles <= 0 {
+		return nil
+	}
+	rotated, err := filepath.Glob(l.path + ".*")
+	if err != nil || len(rotated) <= l.maxFiles {
+		return err
+	}
+	sort.Strings(rotated)
+	for _, name := range rotated[:len(rotated)-l.maxFiles] {
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Close closes the file at shutdown. Later requests are no longer written.
+func (l *AccessLogger) Close() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
+// Status returns the file and counts for the admin status endpoint
+func (l *AccessLogger) Status() map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	status := map[string]interface{}{
+		"file":            l.path,
+		"format":          l.format,
+		"size_bytes":      l.size,
+		"lines_written":   l.written,
+		"rotations_total": l.rotations,
+	}
+	if l.rotate > 0 {
+		status["next_rotation"] = l.rotateAt
+	}
+	return status
+}
+
+// accessLogWriter records the status and body size of a response
+type accessLogWriter struct {
+	http.ResponseWriter
+	statusCode int
+	bytes      int64
+}
+
+func (w *accessLogWriter) WriteHeader(code int) {
+	if w.statusCode == 0 {
+		w.statusCode = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap exposes the underlying writer so http.ResponseController can flush streams
+func (w *accessLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// AccessLogMiddleware writes every request to the access log file. It sits
+// below RequestIDMiddleware so the caller set by AuthMiddleware is known once
+// the request finishes.
+func AccessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !AccessLog.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		rw := &accessLogWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+
+		entry := &AccessLogEntry{
+			Time:       start,
+			RemoteAddr: ClientIP(r),
+			User:       "-",
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Proto:      r.Proto,
+			Status:     rw.statusCode,
+			Bytes:      rw.bytes,
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+			UserAgent:  r.UserAgent(),
+			Referer:    r.Referer(),
+		}
+		if entry.Status == 0 {
+			entry.Status = http.StatusOK
+		}
+		if scope := GetRequestScope(r.Context()); scope != nil {
+			entry.RequestID = scope.ID
+			switch {
+			case scope.IsOwner:
+				entry.User = "owner"
+			case scope.UserID != 0:
+				entry.User = "user:" + strconv.Itoa(scope.UserID)
+			}
+		}
+		AccessLog.Log(entry)
+	})
+}
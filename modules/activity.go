@@ -0,0 +1,91 @@
+package modules
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// activityFeedLength is how many recent events are kept per group
+const activityFeedLength = 50
+
+// activityEventTypes are the events recorded in group activity feeds
+var activityEventTypes = map[string]bool{
+	"task.created":   true,
+	"task.completed": true,
+}
+
+func groupActivityKey(groupID int) string {
+	return fmt.Sprintf("group:%d:activity", groupID)
+}
+
+func groupFeedTokenKey(groupID int) string {
+	return fmt.Sprintf("group:%d:feed_token", groupID)
+}
+
+// RecordGroupActivity keeps an encoded event in the group's capped activity list
+func (r *RedisManager) RecordGroupActivity(groupID int, payload []byte) error {
+	key := groupActivityKey(groupID)
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(r.ctx, key, payload)
+		pipe.LTrim(r.ctx, key, 0, activityFeedLength-1)
+		return nil
+	})
+	return err
+}
+
+// GetGroupActivity returns the group's recent events, newest first
+func (r *RedisManager) GetGroupActivity(groupID int) ([]*Event, error) {
+	payloads, err := r.client.LRange(r.ctx, groupActivityKey(groupID), 0, activityFeedLength-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*Event, 0, len(payloads))
+	for _, payload := range payloads {
+		var event Event
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			continue
+		}
+		events = append(events, &event)
+	}
+	return events, nil
+}
+
+// RotateGroupFeedToken issues a new feed token for the group, replacing any previous one.
+// Only a hash is stored, so the token is shown once.
+func (r *RedisManager) RotateGroupFeedToken(groupID int) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	if err := r.client.Set(r.ctx, groupFeedTokenKey(groupID), hashToken(token), 0).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// DeleteGroupFeedToken turns the group's feed off
+func (r *RedisManager) DeleteGroupFeedToken(groupID int) error {
+	return r.client.Del(r.ctx, groupFeedTokenKey(groupID)).Err()
+}
+
+// HasGroupFeedToken reports whether the group's feed is enabled
+func (r *RedisManager) HasGroupFeedToken(groupID int) (bool, error) {
+	count, err := r.client.Exists(r.ctx, groupFeedTokenKey(groupID)).Result()
+	return count > 0, err
+}
+
+// ValidGroupFeedToken reports whether token opens the group's feed
+func (r *RedisManager) ValidGroupFeedToken(groupID int, token string) bool {
+	if token == "" {
+		return false
+	}
+	stored, err := r.client.Get(r.ctx, groupFeedTokenKey(groupID)).Result()
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(hashToken(token))) == 1
+}
@@ -0,0 +1,320 @@
+package modules
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"task-manager/config"
+	"time"
+)
+
+// analyticsSlots is how many slots the analytics window is split into; the
+// window slides by one slot at a time
+const analyticsSlots = 60
+
+// analyticsMaxConsumers bounds memory when many clients call anonymously.
+// Consumers idle for a whole window make room for new ones; beyond that new
+// consumers are not tracked.
+const analyticsMaxConsumers = 5000
+
+// analyticsMinRequests keeps a few failed requests from flagging a quiet consumer
+const analyticsMinRequests = 20
+
+// analyticsErrorRate is the share of failed requests that flags a consumer
+const analyticsErrorRate = 0.25
+
+// analyticsSlot counts one consumer's requests in one slot of the window
+type analyticsSlot struct {
+	index        int64
+	requests     uint64
+	clientErrors uint64
+	serverErrors uint64
+	throttled    uint64
+	endpoints    map[string]*EndpointUsage
+}
+
+type consumerStats struct {
+	slots    [analyticsSlots]analyticsSlot
+	lastSlot int64
+}
+
+// ConsumerUsage is how one consumer used the API over a window. Consumers
+// are the owner, signed-in users and, for requests without valid
+// credentials, client IPs.
+type ConsumerUsage struct {
+	Consumer          string           `json:"consumer"`
+	Requests          uint64           `json:"requests"`
+	RequestsPerMinute float64          `json:"requests_per_minute"`
+	ClientErrors      uint64           `json:"client_errors"`
+	ServerErrors      uint64           `json:"server_errors"`
+	Throttled         uint64           `json:"throttled"`
+	ErrorRate         float64          `json:"error_rate"`
+	TopEndpoints      []*EndpointUsage `json:"top_endpoints"`
+	Flags             []string         `json:"flags,omitempty"`
+}
+
+// EndpointUsage counts the requests to one endpoint, a method and a path with
+// IDs and tokens replaced by placeholders
+type EndpointUsage struct {
+	Endpoint string `json:"endpoint"`
+	Requests uint64 `json:"requests"`
+	Errors   uint64 `json:"errors"`
+}
+
+// APIAnalytics counts requests per consumer over a sliding window so the
+// owner can spot misbehaving clients. Each replica counts its own requests.
+type APIAnalytics struct {
+	window time.Duration
+	slot   time.Duration
+
+	mu        sync.Mutex
+	consumers map[string]*consumerStats
+	untracked uint64
+}
+
+var APIUsage *APIAnalytics
+
+// InitAPIAnalytics starts counting requests over API_ANALYTICS_WINDOW; 0
+// turns analytics off
+func InitAPIAnalytics(cfg *config.Config) error {
+	if cfg == nil {
+		cfg = config.AppConfig
+	}
+	if cfg.APIAnalyticsWindow == 0 {
+		return nil
+	}
+	if cfg.APIAnalyticsWindow < analyticsSlots*time.Second {
+		return fmt.Errorf("API_ANALYTICS_WINDOW must be at least %ds", analyticsSlots)
+	}
+
+	APIUsage = &APIAnalytics{
+		window:    cfg.APIAnalyticsWindow,
+		slot:      cfg.APIAnalyticsWindow / analyticsSlots,
+		consumers: make(map[string]*consumerStats),
+	}
+	return nil
+}
+
+// Enabled reports whether requests are counted
+func (a *APIAnalytics) Enabled() bool {
+	return a != nil
+}
+
+// Window is how far back analytics reach
+func (a *APIAnalytics) Window() time.Duration {
+	if a == nil {
+		return 0
+	}
+	return a.window
+}
+
+func (a *APIAnalytics) slotIndex(now time.Time) int64 {
+	return now.UnixNano() / int64(a.slot)
+}
+
+// Observe counts a finished request against its consumer
+func (a *APIAnalytics) Observe(consumer, method, path string, status int) {
+	if !a.Enabled() {
+		return
+	}
+	index := a.slotIndex(time.Now())
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stats := a.consumers[consumer]
+	if stats == nil {
+		if len(a.consumers) >= analyticsMaxConsumers {
+			a.pruneLocked(index)
+		}
+		if len(a.consumers) >= analyticsMaxConsumers {
+			a.untracked++
+			return
+		}
+		stats = &consumerStats{}
+		a.consumers[consumer] = stats
+	}
+	stats.lastSlot = index
+
+	slot := &stats.slots[index%analyticsSlots]
+	if slot.index != index || slot.endpoints == nil {
+		*slot = analyticsSlot{index: index, endpoints: make(map[string]*EndpointUsage)}
+	}
+	slot.requests++
+	failed := status >= 400
+	switch {
+	case status == http.StatusTooManyRequests:
+		slot.throttled++
+		slot.clientErrors++
+	case status >= 500:
+		slot.serverErrors++
+	case failed:
+		slot.clientErrors++
+	}
+
+	endpoint := method + " " + analyticsPath(path)
+	usage := slot.endpoints[endpoint]
+	if usage == nil {
+		usage = &EndpointUsage{Endpoint: endpoint}
+		slot.endpoints[endpoint] = usage
+	}
+	usage.Requests++
+	if failed {
+		usage.Errors++
+	}
+}
+
+// pruneLocked forgets consumers with no requests in the window
+func (a *APIAnalytics) pruneLocked(index int64) {
+	for consumer, stats := range a.consumers {
+		if stats.lastSlot <= index-analyticsSlots {
+			delete(a.consumers, consumer)
+		}
+	}
+}
+
+// analyticsPath replaces IDs and tokens in a path with placeholders so
+// requests to the same endpoint are counted together
+func analyticsPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range parts {
+		if _, err := strconv.Atoi(part); err == nil {
+			parts[i] = "{id}"
+		} else if len(part) >= 20 {
+			parts[i] = "{token}"
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// Usage sums each consumer's requests over the last window, rounded up to
+// whole slots and capped at the analytics window, busiest consumers first.
+// A consumer filter limits it to one consumer, and top caps the endpoints
+// listed per consumer.
+func (a *APIAnalytics) Usage(window time.Duration, consumer string, top int) []*ConsumerUsage {
+	if !a.Enabled() {
+		return nil
+	}
+	slots := int64((window + a.slot - 1) / a.slot)
+	if slots <= 0 || slots > analyticsSlots {
+		slots = analyticsSlots
+	}
+	minutes := (time.Duration(slots) * a.slot).Minutes()
+	index := a.slotIndex(time.Now())
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	usages := []*ConsumerUsage{}
+	for name, stats := range a.consumers {
+		if consumer != "" && name != consumer {
+			continue
+		}
+
+		usage := &ConsumerUsage{Consumer: name}
+		endpoints := make(map[string]*EndpointUsage)
+		for _, slot := range stats.slots {
+			if slot.index <= index-slots || slot.index > index {
+				continue
+			}
+			usage.Requests += slot.requests
+			usage.ClientErrors += slot.clientErrors
+			usage.ServerErrors += slot.serverErrors
+			usage.Throttled += slot.throttled
+			for key, counts := range slot.endpoints {
+				total := endpoints[key]
+				if total == nil {
+					total = &EndpointUsage{Endpoint: key}
+					endpoints[key] = total
+				}
+				total.Requests += counts.Requests
+				total.Errors += counts.Errors
+			}
+		}
+		if usage.Requests == 0 {
+			continue
+		}
+
+		usage.RequestsPerMinute = float64(usage.Requests) / minutes
+		usage.ErrorRate = float64(usage.ClientErrors+usage.ServerErrors) / float64(usage.Requests)
+		usage.TopEndpoints = make([]*EndpointUsage, 0, len(endpoints))
+		for _, endpoint := range endpoints {
+			usage.TopEndpoints = append(usage.TopEndpoints, endpoint)
+		}
+		sort.Slice(usage.TopEndpoints, func(i, j int) bool {
+			if usage.TopEndpoints[i].Requests != usage.TopEndpoints[j].Requests {
+				return usage.TopEndpoints[i].Requests > usage.TopEndpoints[j].Requests
+			}
+			return usage.TopEndpoints[i].Endpoint < usage.TopEndpoints[j].Endpoint
+		})
+		if top > 0 && len(usage.TopEndpoints) > top {
+			usage.TopEndpoints = usage.TopEndpoints[:top]
+		}
+
+		if usage.Requests >= analyticsMinRequests && usage.ErrorRate >= analyticsErrorRate {
+			usage.Flags = append(usage.Flags, "high_error_rate")
+		}
+		if usage.Throttled > 0 {
+			usage.Flags = append(usage.Flags, "throttled")
+		}
+		usages = append(usages, usage)
+	}
+
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Requests != usages[j].Requests {
+			return usages[i].Requests > usages[j].Requests
+		}
+		return usages[i].Consumer < usages[j].Consumer
+	})
+	return usages
+}
+
+// Status reports how many consumers are tracked, for /admin/status
+func (a *APIAnalytics) Status() map[string]interface{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return map[string]interface{}{
+		"window":    a.window.String(),
+		"consumers": len(a.consumers),
+		"untracked": a.untracked,
+	}
+}
+
+// requestConsumer names who made a request: the owner, a user, or the
+// client IP when AuthMiddleware did not identify anyone
+func requestConsumer(r *http.Request) string {
+	if scope := GetRequestScope(r.Context()); scope != nil {
+		switch {
+		case scope.IsOwner:
+			return "owner"
+		case scope.UserID != 0:
+			return "user:" + strconv.Itoa(scope.UserID)
+		}
+	}
+	return "ip:" + ClientIP(r)
+}
+
+// APIAnalyticsMiddleware counts every request for the API analytics. Like
+// AccessLogMiddleware it sits below RequestIDMiddleware so the caller is
+// known once the request finishes.
+func APIAnalyticsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !APIUsage.Enabled() || r.Method == "OPTIONS" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rw := &accessLogWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+
+		status := rw.statusCode
+		if status == 0 {
+			status = http.StatusOK
+		}
+		APIUsage.Observe(requestConsumer(r), r.Method, r.URL.Path, status)
+	})
+}
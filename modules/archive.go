@@ -0,0 +1,90 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"task-manager/models"
+	"time"
+)
+
+// ErrGroupArchived is returned when a task of an archived group would change
+var ErrGroupArchived = fmt.Errorf("%w: group is archived", ErrConflict)
+
+// ErrGroupHasOpenTasks is returned when archiving a group whose work is
+// not finished
+var ErrGroupHasOpenTasks = fmt.Errorf("%w: group has open tasks", ErrConflict)
+
+// CheckGroupWritable returns ErrGroupArchived when any of the groups is
+// archived, so their tasks must not be created, changed or deleted. Groups
+// that no longer exist are not archived.
+func (r *RedisManager) CheckGroupWritable(groupIDs ...int) error {
+	for _, groupID := range groupIDs {
+		group, err := r.GetGroup(groupID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if group.Archived() {
+			return fmt.Errorf("group %d: %w", groupID, ErrGroupArchived)
+		}
+	}
+	return nil
+}
+
+// ArchiveGroup freezes a group whose tasks are all done or cancelled,
+// returning ErrGroupHasOpenTasks otherwise
+func (r *RedisManager) ArchiveGroup(groupID, userID int) (*models.Group, error) {
+	group, err := r.GetGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.Archived() {
+		return group, nil
+	}
+
+	tasks, err := r.GetGroupTasks(groupID)
+	if err != nil {
+		return nil, err
+	}
+	open := 0
+	for _, task := range tasks {
+		if !task.Closed() {
+			open++
+		}
+	}
+	if open > 0 {
+		return nil, fmt.Errorf("%d tasks: %w", open, ErrGroupHasOpenTasks)
+	}
+
+	now := time.Now()
+	group.ArchivedAt = &now
+	group.ArchivedBy = userID
+	group.UpdatedAt = now
+	if err := r.SaveGroup(group); err != nil {
+		return nil, err
+	}
+	r.MarkDirty("groups")
+	return group, nil
+}
+
+// UnarchiveGroup makes an archived group's tasks writable again
+func (r *RedisManager) UnarchiveGroup(groupID int) (*models.Group, error) {
+	group, err := r.GetGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if !group.Archived() {
+		return group, nil
+	}
+
+	group.ArchivedAt = nil
+	group.ArchivedBy = 0
+	group.UpdatedAt = time.Now()
+	if err := r.SaveGroup(group); err != nil {
+		return nil, err
+	}
+	r.MarkDirty("groups")
+	return group, nil
+}
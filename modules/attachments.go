@@ -0,0 +1,405 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"task-manager/config"
+	"task-manager/models"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var attachmentLog = Logger("attachments")
+
+// AttachmentStore keeps attachment files by key
+type AttachmentStore interface {
+	Name() string
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Open returns the file, or an error wrapping ErrNotFound when it is missing
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// AttachmentService stores task attachments within the configured limits
+type AttachmentService struct {
+	store   AttachmentStore
+	maxSize int64
+	types   []string
+}
+
+var Attachments *AttachmentService
+
+// InitAttachments configures where attachments are stored and which files
+// are accepted
+func InitAttachments(cfg *config.Config) error {
+	if cfg == nil {
+		cfg = config.AppConfig
+	}
+
+	var store AttachmentStore
+	switch cfg.AttachmentStorage {
+	case "local":
+		store = &LocalAttachmentStore{dir: cfg.AttachmentDir}
+	case "s3":
+		if cfg.S3Endpoint == "" || cfg.S3Bucket == "" || cfg.S3AccessKeyID == "" || cfg.S3SecretAccessKey == "" {
+			return fmt.Errorf("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required for s3 attachment storage")
+		}
+		endpoint, err := url.Parse(strings.TrimRight(cfg.S3Endpoint, "/"))
+		if err != nil || endpoint.Host == "" {
+			return fmt.Errorf("S3_ENDPOINT must be a URL such as https://s3.us-east-1.amazonaws.com")
+		}
+		store = &S3AttachmentStore{
+			endpoint:        endpoint,
+			region:          cfg.S3Region,
+			bucket:          cfg.S3Bucket,
+			accessKeyID:     cfg.S3AccessKeyID,
+			secretAccessKey: cfg.S3SecretAccessKey,
+			client:          NewOutboundClient("s3"),
+		}
+	default:
+		return fmt.Errorf("unknown attachment storage: %s", cfg.AttachmentStorage)
+	}
+
+	var types []string
+	for _, contentType := range strings.Split(cfg.AttachmentTypes, ",") {
+		if contentType = strings.ToLower(strings.TrimSpace(contentType)); contentType != "" {
+			types = append(types, contentType)
+		}
+	}
+
+	Attachments = &AttachmentService{
+		store:   store,
+		maxSize: int64(cfg.AttachmentMaxSizeMB) << 20,
+		types:   types,
+	}
+	return nil
+}
+
+// Backend names where attachments are stored
+func (a *AttachmentService) Backend() string {
+	return a.store.Name()
+}
+
+// MaxSize is the largest file accepted, in bytes
+func (a *AttachmentService) MaxSize() int64 {
+	return a.maxSize
+}
+
+// AllowsType reports whether files of a MIME type are accepted. Types are
+// listed exactly, as a family such as image/*, or as * for anything.
+func (a *AttachmentService) AllowsType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	family, _, _ := strings.Cut(contentType, "/")
+	for _, allowed := range a.types {
+		if allowed == "*" || allowed == contentType || allowed == family+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Types lists the accepted MIME types for validation messages
+func (a *AttachmentService) Types() string {
+	return strings.Join(a.types, ", ")
+}
+
+// attachmentStorageKey is where an attachment's file is stored. It is made
+// of IDs only, so uploaded filenames never reach the storage.
+func attachmentStorageKey(attachment *models.Attachment) string {
+	return fmt.Sprintf("tasks/%d/%d", attachment.TaskID, attachment.ID)
+}
+
+func (a *AttachmentService) Store(ctx context.Context, attachment *models.Attachment, data []byte) error {
+	return a.store.Put(ctx, attachmentStorageKey(attachment), attachment.ContentType, data)
+}
+
+func (a *AttachmentService) Open(ctx context.Context, attachment *models.Attachment) (io.ReadCloser, error) {
+	return a.store.Open(ctx, attachmentStorageKey(attachment))
+}
+
+func (a *AttachmentService) Remove(ctx context.Context, attachment *models.Attachment) error {
+	return a.store.Delete(ctx, attachmentStorageKey(attachment))
+}
+
+// LocalAttachmentStore keeps attachments as files under a directory
+type LocalAttachmentStore struct {
+	dir string
+}
+
+func (s *LocalAttachmentStore) Name() string {
+	return "local"
+}
+
+func (s *LocalAttachmentStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+// Put writes the file next to its final path and renames it into place, so
+// readers never see part of a file
+func (s *LocalAttachmentStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *LocalAttachmentStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	file, err := os.Open(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("attachment file %w", ErrNotFound)
+	}
+	return file, err
+}
+
+func (s *LocalAttachmentStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// S3AttachmentStore keeps attachments in a bucket of an S3-compatible
+// service, addressing objects path-style so it works with AWS, MinIO and
+// other implementations alike
+type S3AttachmentStore struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+	client          *OutboundClient
+}
+
+func (s *S3AttachmentStore) Name() string {
+	return "s3"
+}
+
+func (s *S3AttachmentStore) objectURL(key string) string {
+	object := *s.endpoint
+	object.Path = strings.TrimRight(object.Path, "/") + "/" + s.bucket + "/" + key
+	return object.String()
+}
+
+func (s *S3AttachmentStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "PUT", s.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, data, time.Now().UTC())
+
+	return doProviderRequest(s.client, req)
+}
+
+func (s *S3AttachmentStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.objectURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, nil, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("attachment file %w", ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("s3 returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp.Body, nil
+}
+
+func (s *S3AttachmentStore) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", s.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, nil, time.Now().UTC())
+
+	return doProviderRequest(s.client, req)
+}
+
+// sign applies AWS Signature Version 4 headers to an object request
+func (s *S3AttachmentStore) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, s.region)
+
+	payloadHash := sha256.Sum256(payload)
+	contentHash := hex.EncodeToString(payloadHash[:])
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", contentHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + contentHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		contentHash,
+	}, "\n")
+
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+// Attachment metadata operations
+func attachmentKey(attachmentID int) string {
+	return fmt.Sprintf("attachment:%d", attachmentID)
+}
+
+// taskAttachmentsKey orders a task's attachments by ID, which is the order
+// they were uploaded in
+func taskAttachmentsKey(taskID int) string {
+	return fmt.Sprintf("task:%d:attachments", taskID)
+}
+
+func (r *RedisManager) GetNextAttachmentID() (int, error) {
+	id, err := r.client.Incr(r.ctx, "counter:attachment_id").Result()
+	return int(id), err
+}
+
+func (r *RedisManager) SaveAttachment(attachment *models.Attachment) error {
+	attachmentJSON, err := json.Marshal(attachment)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(r.ctx, attachmentKey(attachment.ID), attachmentJSON, 0)
+		pipe.SAdd(r.ctx, "attachments:all", attachment.ID)
+		pipe.ZAdd(r.ctx, taskAttachmentsKey(attachment.TaskID), &redis.Z{Score: float64(attachment.ID), Member: attachment.ID})
+		return nil
+	})
+	return err
+}
+
+func (r *RedisManager) GetAttachment(attachmentID int) (*models.Attachment, error) {
+	attachmentJSON, err := r.client.Get(r.ctx, attachmentKey(attachmentID)).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("attachment %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var attachment models.Attachment
+	err = json.Unmarshal([]byte(attachmentJSON), &attachment)
+	return &attachment, err
+}
+
+func (r *RedisManager) getAttachments(attachmentIDs []string) ([]*models.Attachment, error) {
+	attachments := make([]*models.Attachment, 0, len(attachmentIDs))
+	for _, attachmentIDStr := range attachmentIDs {
+		attachmentID, err := strconv.Atoi(attachmentIDStr)
+		if err != nil {
+			continue
+		}
+
+		attachment, err := r.GetAttachment(attachmentID)
+		if err == nil {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments, nil
+}
+
+// GetTaskAttachments returns a task's attachments, oldest first
+func (r *RedisManager) GetTaskAttachments(taskID int) ([]*models.Attachment, error) {
+	attachmentIDs, err := r.client.ZRange(r.ctx, taskAttachmentsKey(taskID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	return r.getAttachments(attachmentIDs)
+}
+
+func (r *RedisManager) GetAllAttachments() ([]*models.Attachment, error) {
+	attachmentIDs, err := r.client.SMembers(r.ctx, "attachments:all").Result()
+	if err != nil {
+		return nil, err
+	}
+	return r.getAttachments(attachmentIDs)
+}
+
+// DeleteAttachment removes an attachment's metadata; its file is removed
+// by the attachment storage
+func (r *RedisManager) DeleteAttachment(attachment *models.Attachment) error {
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(r.ctx, attachmentKey(attachment.ID))
+		pipe.SRem(r.ctx, "attachments:all", attachment.ID)
+		pipe.ZRem(r.ctx, taskAttachmentsKey(attachment.TaskID), attachment.ID)
+		pipe.SAdd(r.ctx, deletedIDsKey("attachments"), attachment.ID)
+		return nil
+	})
+	return err
+}
+
+// DeleteTaskAttachments removes every attachment of a task and their files,
+// marking attachments dirty so the sync removes them from PostgreSQL too
+func (r *RedisManager) DeleteTaskAttachments(taskID int) error {
+	attachments, err := r.GetTaskAttachments(taskID)
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		if err := r.DeleteAttachment(attachment); err != nil {
+			return err
+		}
+		if Attachments != nil {
+			if err := Attachments.Remove(r.ctx, attachment); err != nil {
+				attachmentLog.Warn("⚠️ Failed to remove attachment file", "attachment_id", attachment.ID, "error", err)
+			}
+		}
+	}
+	if len(attachments) > 0 {
+		r.MarkDirty("attachments")
+	}
+	return r.client.Del(r.ctx, taskAttachmentsKey(taskID)).Err()
+}
@@ -2,10 +2,14 @@ package modules
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 	"task-manager/models"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 // AuthContext holds authentication information
@@ -14,8 +18,46 @@ type AuthContext struct {
 	IsOwner       bool
 	IsGroupAdmin  bool
 	AdminGroupIDs []int
+	Session       *Session // set when authenticated by session cookie
+	Method        string   // how the caller signed in: owner_password, basic, token or session
+}
+
+// publicPaths are served without authentication; sign-in endpoints, the
+// client portal and the inbound email webhook check credentials themselves,
+// and the password policy and capabilities are shown to sign-in forms
+var publicPaths = map[string]bool{
+	"/health":               true,
+	"/version":              true,
+	"/auth/login":           true,
+	"/auth/token":           true,
+	"/auth/refresh":         true,
+	"/auth/revoke":          true,
+	"/auth/password-policy": true,
+	"/capabilities":         true,
+	"/portal":               true,
+	"/inbound/email":        true,
 }
 
+// isPublicPath reports whether path is served without authentication. Group
+// activity feeds check their own feed token so feed readers can poll them,
+// and intake forms check the token in their path.
+func isPublicPath(path string) bool {
+	if publicPaths[path] {
+		return true
+	}
+	if strings.HasPrefix(path, "/intake/") {
+		return true
+	}
+	return strings.HasPrefix(path, "/groups/") && strings.HasSuffix(path, "/feed.atom")
+}
+
+var (
+	// errNoCredentials means the request carried no credentials at all
+	errNoCredentials = errors.New("no credentials")
+	// errInvalidSession means the session cookie is unknown or expired
+	errInvalidSession = errors.New("invalid session")
+)
+
 // AuthMiddleware enforces authentication and authorization
 func AuthMiddleware(ownerPassword string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -26,25 +68,56 @@ func AuthMiddleware(ownerPassword string) func(http.Handler) http.Handler {
 				return
 			}
 
-			// Allow health check without authentication
-			if r.URL.Path == "/health" {
+			// Allow health check, sign-in, feeds and the client portal without authentication
+			if isPublicPath(r.URL.Path) {
 				next.ServeHTTP(w, r)
 				return
 			}
 
+			// Refuse sign-in attempts from IPs with too many recent failures
+			ip := ClientIP(r)
+			if result, blocked := Limiter.AuthBlocked(ip); blocked {
+				setRateLimitHeaders(w, result)
+				http.Error(w, "Too many failed sign-in attempts", http.StatusTooManyRequests)
+				return
+			}
+
 			authCtx, err := authenticate(r, ownerPassword)
 			if err != nil {
+				// Only wrong passwords count towards the sign-in limit; stale
+				// cookies and expired tokens are not guessing attempts
+				switch {
+				case errors.Is(err, errInvalidSession):
+					ClearSessionCookie(w)
+				case errors.Is(err, errInvalidToken), errors.Is(err, errNoCredentials):
+					// Nothing to record
+				default:
+					Limiter.RecordAuthFailure(ip)
+				}
 				w.Header().Set("WWW-Authenticate", `Basic realm="User Area"`)
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
+			// Cookies are sent by browsers automatically, so cookie sessions
+			// must also prove the request came from the app itself
+			if authCtx.Session != nil && isStateChanging(r.Method) && !authCtx.Session.ValidCSRFToken(r) {
+				http.Error(w, "Invalid or missing CSRF token", http.StatusForbidden)
+				return
+			}
+
 			// Check authorization for the requested resource
-			if !isAuthorized(authCtx, r) {
+			if decision := Authorize(authCtx, r.Method, r.URL.Path); !decision.Allowed {
+				securityLog.DebugContext(r.Context(), "🚫 Request refused", "rule", decision.Rule)
 				http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
 				return
 			}
 
+			// Tag the request's log lines with the caller
+			if scope := GetRequestScope(r.Context()); scope != nil {
+				scope.setCaller(authCtx)
+			}
+
 			// Store auth context in request context for handlers
 			ctx := SetAuthContext(r.Context(), authCtx)
 			r = r.WithContext(ctx)
@@ -56,45 +129,193 @@ func AuthMiddleware(ownerPassword string) func(http.Handler) http.Handler {
 // authenticate validates credentials and returns AuthContext
 func authenticate(r *http.Request, ownerPassword string) (*AuthContext, error) {
 	// 1) Owner header check
-	if r.Header.Get("X-Owner-Password") == ownerPassword {
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Owner-Password")), []byte(ownerPassword)) == 1 {
 		return &AuthContext{
 			User:          nil, // Owner doesn't need a user object
 			IsOwner:       true,
 			IsGroupAdmin:  false,
 			AdminGroupIDs: []int{},
+			Method:        "owner_password",
 		}, nil
 	}
 
 	// 2) Basic Auth user check
-	userStr, pass, ok := r.BasicAuth()
-	if !ok {
-		return nil, http.ErrNoCookie
+	if userStr, pass, ok := r.BasicAuth(); ok {
+		user, err := VerifyCredentials(userStr, pass)
+		if err != nil {
+			return nil, err
+		}
+		authCtx := buildAuthContext(user)
+		authCtx.Method = "basic"
+		return authCtx, nil
 	}
 
+	// 3) Bearer access token check
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return authenticateToken(strings.TrimSpace(token))
+	}
+
+	// 4) Session cookie check
+	if SessionsEnabled() {
+		if cookie, err := r.Cookie(SessionCookieName); err == nil {
+			return authenticateSession(cookie.Value)
+		}
+	}
+
+	return nil, errNoCredentials
+}
+
+// VerifyCredentials checks a user ID or email and password pair
+func VerifyCredentials(identifier, password string) (*models.User, error) {
 	// Try to get user by ID first
-	userID, err := strconv.Atoi(userStr)
+	userID, err := strconv.Atoi(identifier)
 	var user *models.User
 
 	if err == nil {
-		// userStr is numeric, try to get by ID
+		// identifier is numeric, try to get by ID
 		user, err = RedisClient.GetUser(userID)
-		if err != nil {
-			return nil, err
-		}
 	} else {
-		// userStr is not numeric, try to get by email
-		user, err = RedisClient.GetUserByEmail(userStr)
-		if err != nil {
-			return nil, err
-		}
+		// identifier is not numeric, try to get by email
+		user, err = RedisClient.GetUserByEmail(identifier)
+	}
+	if err != nil {
+		// Spend as long as checking a real password would
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return nil, err
 	}
 
 	// Check password
-	if user.Password != pass {
+	if !CheckPassword(user, password) {
 		return nil, http.ErrNoCookie
 	}
 
-	// Build AuthContext
+	// Deactivated accounts cannot sign in
+	if user.Deactivated {
+		return nil, ErrForbidden
+	}
+
+	// Passwords saved before they were hashed are hashed on first use
+	if !PasswordHashed(user.Password) {
+		rehashPassword(user, password)
+	}
+
+	return user, nil
+}
+
+// rehashPassword replaces a user's plaintext password with its hash, leaving
+// it for the next sign-in if the user changed meanwhile
+func rehashPassword(user *models.User, password string) {
+	updated := *user
+	if err := SetPassword(&updated, password); err != nil {
+		return
+	}
+	if err := RedisClient.SaveUserIfVersion(&updated, user.Version); err != nil {
+		securityLog.Warn("⚠️ Failed to hash stored password", "user_id", user.ID, "error", err)
+		return
+	}
+	RedisClient.MarkDirty("users")
+	*user = updated
+}
+
+// authenticateSession resolves a session cookie to its user
+func authenticateSession(token string) (*AuthContext, error) {
+	session, err := RedisClient.GetSession(token)
+	if err != nil {
+		return nil, errInvalidSession
+	}
+
+	if err := RedisClient.TouchSession(session); err != nil {
+		return nil, errInvalidSession
+	}
+
+	user, err := RedisClient.GetUser(session.UserID)
+	if err != nil || user.Deactivated {
+		return nil, errInvalidSession
+	}
+
+	authCtx := buildAuthContext(user)
+	authCtx.Session = session
+	authCtx.Method = "session"
+	return authCtx, nil
+}
+
+// authenticateToken resolves a bearer access token to its user
+func authenticateToken(token string) (*AuthContext, error) {
+	userID, err := RedisClient.AccessTokenUser(token)
+	if err != nil {
+		return nil, errInvalidToken
+	}
+
+	user, err := RedisClient.GetUser(userID)
+	if err != nil || user.Deactivated {
+		return nil, errInvalidToken
+	}
+
+	authCtx := buildAuthContext(user)
+	authCtx.Method = "token"
+	return authCtx, nil
+}
+
+// ManagesUsers reports whether the caller administers users: the owner, or
+// a user the owner delegated user administration to
+func (a *AuthContext) ManagesUsers() bool {
+	return a.IsOwner || (a.User != nil && a.User.UserAdmin)
+}
+
+// ManagesUser reports whether the caller may delete a user or change their
+// role. Delegated user admins never manage the owner or themselves, and
+// those limited to groups only manage members of those groups.
+func (a *AuthContext) ManagesUser(user *models.User) bool {
+	if a.IsOwner {
+		return true
+	}
+	if !a.ManagesUsers() || user.Role == "owner" || user.ID == a.User.ID {
+		return false
+	}
+	for _, groupID := range user.GroupIDs {
+		if a.managesUsersIn(groupID) {
+			return true
+		}
+	}
+	return len(a.User.UserAdminGroups) == 0
+}
+
+// managesUsersIn reports whether a delegated user admin's scope covers a group
+func (a *AuthContext) managesUsersIn(groupID int) bool {
+	return len(a.User.UserAdminGroups) == 0 || containsID(a.User.UserAdminGroups, groupID)
+}
+
+// ManagesMembersOf reports whether the caller may put users in a group or
+// take them out of it: the owner, user admins whose scope covers the group
+// and the group's admins
+func (a *AuthContext) ManagesMembersOf(groupID int) bool {
+	if a.IsOwner {
+		return true
+	}
+	if a.ManagesUsers() && a.managesUsersIn(groupID) {
+		return true
+	}
+	return a.IsGroupAdmin && containsID(a.AdminGroupIDs, groupID)
+}
+
+// UnmanagedGroupChange finds a group joined or left in moving a user from
+// current to requested groups whose members the caller does not manage
+func (a *AuthContext) UnmanagedGroupChange(current, requested []int) (int, bool) {
+	for _, groupID := range requested {
+		if !containsID(current, groupID) && !a.ManagesMembersOf(groupID) {
+			return groupID, false
+		}
+	}
+	for _, groupID := range current {
+		if !containsID(requested, groupID) && !a.ManagesMembersOf(groupID) {
+			return groupID, false
+		}
+	}
+	return 0, true
+}
+
+// buildAuthContext derives roles and administered groups for a signed-in user
+func buildAuthContext(user *models.User) *AuthContext {
 	authCtx := &AuthContext{
 		User:          user,
 		IsOwner:       user.Role == "owner",
@@ -114,23 +335,37 @@ func authenticate(r *http.Request, ownerPassword string) (*AuthContext, error) {
 		}
 	}
 
-	return authCtx, nil
+	return authCtx
 }
 
-// isAuthorized checks if the authenticated user has permission for the requested resource
-func isAuthorized(authCtx *AuthContext, r *http.Request) bool {
-	path := r.URL.Path
-	method := r.Method
+// AccessDecision is whether the route check lets a request through and the
+// rule that decided it. Handlers may still refuse it for the record it names.
+type AccessDecision struct {
+	Allowed bool   `json:"allowed"`
+	Rule    string `json:"rule"`
+}
+
+func allow(rule string) AccessDecision {
+	return AccessDecision{Allowed: true, Rule: rule}
+}
+
+func deny(rule string) AccessDecision {
+	return AccessDecision{Allowed: false, Rule: rule}
+}
 
+// Authorize checks if the authenticated user has permission for the
+// requested resource, naming the rule that decided it so refusals can be
+// explained
+func Authorize(authCtx *AuthContext, method, path string) AccessDecision {
 	// Owner has access to everything
 	if authCtx.IsOwner {
-		return true
+		return allow("The owner may do anything")
 	}
 
 	// Parse the path to understand what resource is being accessed
 	pathInfo := parseResourcePath(path)
 	if pathInfo == nil {
-		return false
+		return deny("Only the owner may use the root path")
 	}
 
 	// Check permissions based on resource type and user role
@@ -143,9 +378,54 @@ func isAuthorized(authCtx *AuthContext, r *http.Request) bool {
 		return checkTaskPermissions(authCtx, pathInfo, method)
 	case "search":
 		return checkSearchPermissions(authCtx, pathInfo, method)
+	case "quota", "whoami":
+		return readOnly(method, "Users check their own quota and permissions")
+	case "stream", "ws":
+		return readOnly(method, "Any signed-in user may stream; events are filtered per caller")
+	case "auth":
+		return allow("Session endpoints only act on the caller's own session")
+	case "holidays":
+		return readOnly(method, "Everyone may read holiday calendars; only the owner manages them")
+	case "objectives":
+		return readOnly(method, "Everyone may follow the OKRs; only the owner sets them")
+	case "imports":
+		if authCtx.IsGroupAdmin {
+			return allow("Group admins import into their own groups, checked per request")
+		}
+		return deny("Only group admins and the owner import tasks")
+	case "reports":
+		return readOnly(method, "Reports are limited to what the caller may see, checked per request")
+	case "dashboards":
+		return readOnly(method, "Dashboards are limited to what the caller may see, checked per request")
+	case "admin":
+		return checkAdminPermissions(authCtx, path)
+	case "templates":
+		if method == "GET" {
+			return allow("Everyone may browse templates")
+		}
+		if authCtx.IsGroupAdmin && method == "POST" && pathInfo.SubResource == "apply" {
+			return allow("Group admins apply templates to their own groups, checked per request")
+		}
+		return deny("Only the owner manages templates; group admins may apply them")
 	default:
-		return false
+		return deny("Only the owner may use /" + pathInfo.ResourceType)
+	}
+}
+
+// ExplainAccess is Authorize for any path, public ones included
+func ExplainAccess(authCtx *AuthContext, method, path string) AccessDecision {
+	if isPublicPath(path) {
+		return allow("Served without signing in; the endpoint checks its own credentials")
 	}
+	return Authorize(authCtx, method, path)
+}
+
+// readOnly allows GET requests under rule and refuses the rest to all but the owner
+func readOnly(method, rule string) AccessDecision {
+	if method == "GET" {
+		return allow(rule)
+	}
+	return deny(rule)
 }
 
 // ResourcePathInfo holds parsed information about the requested resource
@@ -214,14 +494,20 @@ func parseResourcePath(path string) *ResourcePathInfo {
 }
 
 // checkUserPermissions validates permissions for user-related endpoints
-func checkUserPermissions(authCtx *AuthContext, pathInfo *ResourcePathInfo, method string) bool {
+func checkUserPermissions(authCtx *AuthContext, pathInfo *ResourcePathInfo, method string) AccessDecision {
 	user := authCtx.User
 
 	// Global user operations (like /users, /users/search)
 	if pathInfo.ResourceID == 0 {
-		// Only owner and group admins can list/search all users
+		// Only owner, user admins and group admins can list/search all users
 		// Group admins can see users in their groups
-		return authCtx.IsOwner || authCtx.IsGroupAdmin
+		if authCtx.ManagesUsers() {
+			return allow("User admins list and create users")
+		}
+		if authCtx.IsGroupAdmin {
+			return allow("Group admins list and create users in their groups")
+		}
+		return deny("Only the owner, user admins and group admins list users")
 	}
 
 	// Specific user operations (/users/{id})
@@ -229,32 +515,67 @@ func checkUserPermissions(authCtx *AuthContext, pathInfo *ResourcePathInfo, meth
 
 	// Users can always access their own data
 	if user.ID == targetUserID {
-		return true
+		return allow("Users can always access their own data")
 	}
 
 	// Owner can access any user
 	if authCtx.IsOwner {
-		return true
+		return allow("The owner may access any user")
+	}
+
+	// User admins can access the users they manage
+	if authCtx.ManagesUsers() {
+		if target, err := RedisClient.GetUser(targetUserID); err == nil && authCtx.ManagesUser(target) {
+			return allow("User admins access the users they manage")
+		}
 	}
 
 	// Group admins can access users in their administered groups
 	if authCtx.IsGroupAdmin {
-		return isUserInAdminGroups(targetUserID, authCtx.AdminGroupIDs)
+		if isUserInAdminGroups(targetUserID, authCtx.AdminGroupIDs) {
+			return allow("Group admins access users in the groups they administer")
+		}
+		return deny("Group admins only access users in the groups they administer")
 	}
 
-	return false
+	return deny("Users only access their own data")
+}
+
+// checkAdminPermissions lets user admins merge and offboard the users they
+// manage through /admin/users/{id}/merge and /admin/users/{id}/offboard;
+// the rest of /admin is the owner's
+func checkAdminPermissions(authCtx *AuthContext, path string) AccessDecision {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(path, "/admin/users/"), "/"), "/")
+	if !strings.HasPrefix(path, "/admin/users/") || len(parts) != 2 || (parts[1] != "merge" && parts[1] != "offboard") {
+		return deny("Only the owner may use /admin")
+	}
+	if !authCtx.ManagesUsers() {
+		return deny("Only the owner and user admins merge and offboard users")
+	}
+
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return deny("Only the owner and user admins merge and offboard users")
+	}
+	if target, err := RedisClient.GetUser(userID); err == nil && authCtx.ManagesUser(target) {
+		return allow("User admins merge and offboard the users they manage")
+	}
+	return deny("User admins only merge and offboard the users they manage")
 }
 
 // checkGroupPermissions validates permissions for group-related endpoints
-func checkGroupPermissions(authCtx *AuthContext, pathInfo *ResourcePathInfo, method string) bool {
+func checkGroupPermissions(authCtx *AuthContext, pathInfo *ResourcePathInfo, method string) AccessDecision {
 	// Global group operations
 	if pathInfo.ResourceID == 0 {
 		// Only owner can create groups or list all groups
 		if method == "POST" {
-			return authCtx.IsOwner
+			return deny("Only the owner creates groups")
 		}
 		// Group admins can see their own groups
-		return authCtx.IsOwner || authCtx.IsGroupAdmin
+		if authCtx.IsGroupAdmin {
+			return allow("Group admins list the groups they administer")
+		}
+		return deny("Only the owner and group admins list groups")
 	}
 
 	// Specific group operations
@@ -262,28 +583,56 @@ func checkGroupPermissions(authCtx *AuthContext, pathInfo *ResourcePathInfo, met
 
 	// Owner can access any group
 	if authCtx.IsOwner {
-		return true
+		return allow("The owner may access any group")
 	}
 
 	// Group admins can only access their own administered groups
 	if authCtx.IsGroupAdmin {
 		for _, adminGroupID := range authCtx.AdminGroupIDs {
 			if adminGroupID == groupID {
-				return true
+				return allow("Group admins manage the groups they administer")
 			}
 		}
 	}
 
 	// Regular users can view groups they belong to (read-only)
 	if method == "GET" {
-		return isUserInGroup(authCtx.User.ID, groupID)
+		if isUserInGroup(authCtx.User.ID, groupID) {
+			return allow("Members may view their groups")
+		}
+		return deny("Only members and the group's admin view a group")
 	}
 
-	return false
+	// ...and submit their own estimates in the group's estimation sessions
+	if method == "PUT" && pathInfo.SubResource == "estimations" && pathInfo.Action == "votes" {
+		if isUserInGroup(authCtx.User.ID, groupID) {
+			return allow("Members vote in their groups' estimation sessions")
+		}
+		return deny("Only members vote in a group's estimation sessions")
+	}
+
+	// ...and vote on the group's backlog
+	if (method == "PUT" || method == "DELETE") && pathInfo.SubResource == "backlog" && pathInfo.Action == "vote" {
+		if isUserInGroup(authCtx.User.ID, groupID) {
+			return allow("Members vote on their groups' backlogs")
+		}
+		return deny("Only members vote on a group's backlog")
+	}
+
+	// Admins share their groups' tasks with other groups; the handler
+	// checks they administer the task's group
+	if (method == "PUT" || method == "DELETE") && pathInfo.SubResource == "visible-tasks" && pathInfo.SubResourceID != 0 {
+		if authCtx.IsGroupAdmin {
+			return allow("Group admins share their groups' tasks, checked per request")
+		}
+		return deny("Only group admins share tasks with other groups")
+	}
+
+	return deny("Only the group's admin and the owner change a group")
 }
 
 // checkTaskPermissions validates permissions for task-related endpoints
-func checkTaskPermissions(authCtx *AuthContext, pathInfo *ResourcePathInfo, method string) bool {
+func checkTaskPermissions(authCtx *AuthContext, pathInfo *ResourcePathInfo, method string) AccessDecision {
 	// This handles both /users/{id}/tasks and direct task access
 	user := authCtx.User
 
@@ -293,44 +642,52 @@ func checkTaskPermissions(authCtx *AuthContext, pathInfo *ResourcePathInfo, meth
 
 		// Users can access their own tasks
 		if user.ID == targetUserID {
-			return true
+			return allow("Users can access their own tasks")
 		}
 
 		// Owner can access any user's tasks
 		if authCtx.IsOwner {
-			return true
+			return allow("The owner may access any user's tasks")
 		}
 
 		// Group admins can access tasks of users in their groups
 		if authCtx.IsGroupAdmin {
-			return isUserInAdminGroups(targetUserID, authCtx.AdminGroupIDs)
+			if isUserInAdminGroups(targetUserID, authCtx.AdminGroupIDs) {
+				return allow("Group admins access tasks of users in their groups")
+			}
+			return deny("Group admins only access tasks of users in their groups")
 		}
 	}
 
 	// Direct task access would need task ID lookup
 	// For now, we'll handle this in the handlers
-	return true // Let handlers do detailed task-level permission checks
+	return allow("Task permissions are checked per task") // Let handlers do detailed task-level permission checks
 }
 
 // checkSearchPermissions validates permissions for search endpoints
-func checkSearchPermissions(authCtx *AuthContext, pathInfo *ResourcePathInfo, method string) bool {
+func checkSearchPermissions(authCtx *AuthContext, pathInfo *ResourcePathInfo, method string) AccessDecision {
 	// Only GET method for searches
 	if method != "GET" {
-		return false
+		return deny("Searches are read-only")
 	}
 
 	// Owner can search everything
 	if authCtx.IsOwner {
-		return true
+		return allow("The owner may search everything")
 	}
 
 	// Group admins can search within their scope
 	if authCtx.IsGroupAdmin {
-		return true
+		return allow("Group admins search within their groups")
 	}
 
-	// Regular users cannot use global search
-	return false
+	// /search filters matches to what the caller may see, so anyone may use it
+	if !pathInfo.IsGlobal {
+		return allow("Everyone may search; matches are limited to what the caller may see")
+	}
+
+	// Regular users cannot search all tasks or users
+	return deny("Regular users cannot search all tasks or users")
 }
 
 // Helper functions
@@ -390,6 +747,18 @@ func CanCreateUser(authCtx *AuthContext, targetRole string, groupIDs []int) bool
 		return true
 	}
 
+	// User admins can create anyone but owners; those limited to groups
+	// only in their groups
+	if authCtx.ManagesUsers() && targetRole != "owner" {
+		allowed := len(authCtx.User.UserAdminGroups) == 0 || len(groupIDs) > 0
+		for _, groupID := range groupIDs {
+			allowed = allowed && authCtx.managesUsersIn(groupID)
+		}
+		if allowed {
+			return true
+		}
+	}
+
 	// Group admins can only create regular users in their groups
 	if authCtx.IsGroupAdmin {
 		if targetRole != "" && targetRole != "user" {
@@ -421,8 +790,8 @@ func CanModifyTask(authCtx *AuthContext, task *models.Task) bool {
 		return true
 	}
 
-	// Users can modify their own tasks
-	if authCtx.User.ID == task.UserID {
+	// Users can modify tasks they are assigned, as primary or collaborator
+	if task.IsAssignee(authCtx.User.ID) {
 		return true
 	}
 
@@ -450,6 +819,11 @@ func FilterUsersByPermissions(authCtx *AuthContext, users []*models.User) []*mod
 			continue
 		}
 
+		if authCtx.ManagesUser(user) {
+			filtered = append(filtered, user) // Managed user
+			continue
+		}
+
 		if authCtx.IsGroupAdmin {
 			// Check if user is in admin's groups
 			if isUserInAdminGroups(user.ID, authCtx.AdminGroupIDs) {
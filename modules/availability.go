@@ -0,0 +1,43 @@
+package modules
+
+import (
+	"task-manager/models"
+	"time"
+)
+
+// availabilityHorizon is how far ahead NextUserAvailability looks, enough
+// to get past a long leave
+const availabilityHorizon = 31 * 24 * time.Hour
+
+// UserAvailableAt reports whether a user is at work at a moment: within
+// their work times in the organization's timezone, and neither on leave
+// nor on a holiday of their region
+func (r *RedisManager) UserAvailableAt(user *models.User, at time.Time) bool {
+	at = at.In(OrgSettings().Location())
+	if !user.WorkTimes.AvailableAt(at) {
+		return false
+	}
+	return !r.IsUserOnLeave(user.ID, at) && !r.IsHoliday(UserHolidayRegion(user), at)
+}
+
+// NextUserAvailability returns the first moment from at on that a user is
+// at work, skipping days they are on leave and holidays. It is false when
+// they are not at work within availabilityHorizon.
+func (r *RedisManager) NextUserAvailability(user *models.User, at time.Time) (time.Time, bool) {
+	at = at.In(OrgSettings().Location())
+	limit := at.Add(availabilityHorizon)
+	region := UserHolidayRegion(user)
+
+	for at.Before(limit) {
+		next, ok := user.WorkTimes.NextAvailable(at)
+		if !ok || !next.Before(limit) {
+			return time.Time{}, false
+		}
+		if !r.IsUserOnLeave(user.ID, next) && !r.IsHoliday(region, next) {
+			return next, true
+		}
+		// The whole day is off, so look from the next one
+		at = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,190 @@
+package modules
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Encrypted snapshots are AES-256-GCM in chunks, so neither writing nor
+// restoring holds a whole snapshot in memory. The file starts with
+// backupCryptMagic and a random 7-byte nonce prefix. Each chunk's nonce is
+// that prefix, the chunk's number and whether it is the last one, so chunks
+// cannot be reordered, dropped or cut off without failing to open.
+const (
+	backupCryptMagic     = "GASKENC1"
+	backupCryptChunk     = 64 << 10
+	backupCryptPrefixLen = 7
+)
+
+// ErrBackupKey is returned for an encrypted snapshot that does not open
+// with the key, because it was taken with another or has been damaged
+var ErrBackupKey = errors.New("snapshot does not decrypt with BACKUP_ENCRYPTION_KEY")
+
+// parseBackupKey decodes BACKUP_ENCRYPTION_KEY, 32 bytes in base64
+func parseBackupKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("BACKUP_ENCRYPTION_KEY must be 32 bytes in base64, e.g. from openssl rand -base64 32")
+	}
+	return key, nil
+}
+
+// backupKeyID names a key in manifests without giving it away, so a
+// snapshot taken with another key is told apart from a damaged one
+func backupKeyID(key []byte) string {
+	sum := sha256.Sum256(append([]byte("gask-backup-key:"), key...))
+	return hex.EncodeToString(sum[:8])
+}
+
+func newBackupAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func backupChunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[backupCryptPrefixLen:], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// backupEncrypter seals what is written to it chunk by chunk; Close seals
+// the last chunk and must be called for the file to open
+type backupEncrypter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	buf     []byte
+}
+
+func newBackupEncrypter(w io.Writer, key []byte) (*backupEncrypter, error) {
+	aead, err := newBackupAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, backupCryptPrefixLen)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append([]byte(backupCryptMagic), prefix...)); err != nil {
+		return nil, err
+	}
+	return &backupEncrypter{w: w, aead: aead, prefix: prefix, buf: make([]byte, 0, backupCryptChunk)}, nil
+}
+
+func (e *backupEncrypter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		// A full chunk is only sealed once more follows, as the last must be marked
+		if len(e.buf) == backupCryptChunk {
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (e *backupEncrypter) seal(last bool) error {
+	sealed := e.aead.Seal(nil, backupChunkNonce(e.prefix, e.counter, last), e.buf, nil)
+	e.counter++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(sealed)
+	return err
+}
+
+func (e *backupEncrypter) Close() error {
+	return e.seal(true)
+}
+
+// backupDecrypter opens an encrypted snapshot chunk by chunk, failing with
+// ErrBackupKey on any chunk that does not authenticate
+type backupDecrypter struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	chunk   []byte
+	plain   []byte
+	done    bool
+}
+
+// isEncryptedBackup reports whether r starts like an encrypted snapshot,
+// without consuming it
+func isEncryptedBackup(r *bufio.Reader) bool {
+	magic, err := r.Peek(len(backupCryptMagic))
+	return err == nil && string(magic) == backupCryptMagic
+}
+
+func newBackupDecrypter(r *bufio.Reader, key []byte) (*backupDecrypter, error) {
+	header := make([]byte, len(backupCryptMagic)+backupCryptPrefixLen)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(backupCryptMagic)]) != backupCryptMagic {
+		return nil, fmt.Errorf("not an encrypted snapshot")
+	}
+	aead, err := newBackupAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &backupDecrypter{
+		r:      r,
+		aead:   aead,
+		prefix: header[len(backupCryptMagic):],
+		chunk:  make([]byte, backupCryptChunk+aead.Overhead()),
+	}, nil
+}
+
+func (d *backupDecrypter) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// next opens the following chunk; it is the last when nothing follows it
+func (d *backupDecrypter) next() error {
+	n, err := io.ReadFull(d.r, d.chunk)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		if err == io.EOF {
+			return fmt.Errorf("encrypted snapshot is cut off")
+		}
+		return err
+	}
+	_, peekErr := d.r.Peek(1)
+	last := peekErr == io.EOF
+
+	plain, err := d.aead.Open(d.chunk[:0:0], backupChunkNonce(d.prefix, d.counter, last), d.chunk[:n], nil)
+	if err != nil {
+		return ErrBackupKey
+	}
+	d.counter++
+	d.plain = plain
+	d.done = last
+	return nil
+}
@@ -0,0 +1,859 @@
+package modules
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"task-manager/config"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// BackupFormatVersion is the snapshot format this server writes and restores
+const BackupFormatVersion = 1
+
+// backupTables are exported in this order and restored in the same order.
+// Email logs are left out, as they are not state.
+var backupTables = []string{
+	"users", "groups", "user_groups", "tasks", "leave_requests",
+	"report_subscriptions", "clients", "risks", "objectives", "comments",
+	"attachments",
+}
+
+// backupNamePattern is what a finished snapshot file is called. The name
+// holds the UTC time it was taken, so names sort by age; encrypted ones end
+// in .enc.
+var backupNamePattern = regexp.MustCompile(`^gask-(\d{8}-\d{6})\.jsonl\.gz(\.enc)?$`)
+
+const backupTimeFormat = "20060102-150405"
+
+// backupRestoreBatch is how many rows are inserted per statement on restore
+const backupRestoreBatch = 500
+
+var backupLog = Logger("backups")
+
+// ErrBackupBusy is returned while this instance is taking or restoring a snapshot
+var ErrBackupBusy = fmt.Errorf("%w: a snapshot is already being taken or restored", ErrConflict)
+
+// ErrNoPostgres is returned for a snapshot while PostgreSQL is not connected
+var ErrNoPostgres = errors.New("PostgreSQL is not connected")
+
+// ErrNoStaging is returned for a restore without BACKUP_STAGING_DSN
+var ErrNoStaging = errors.New("no staging database is configured")
+
+// ErrBackupInvalid is returned for a restore of a snapshot that fails verification
+var ErrBackupInvalid = fmt.Errorf("%w: snapshot failed verification", ErrValidation)
+
+// backupHeader is the first line of a snapshot
+type backupHeader struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Tables    []string  `json:"tables"`
+}
+
+// backupRow is every other line: one row of one table as PostgreSQL
+// renders it with row_to_json
+type backupRow struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// Backup is a finished snapshot file
+type Backup struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	SizeBytes int64     `json:"size_bytes"`
+	Encrypted bool      `json:"encrypted"`
+}
+
+// BackupManifest is written beside each snapshot, as gask-{time}.manifest.json,
+// with the checksums verification compares the snapshot against
+type BackupManifest struct {
+	Version   int                            `json:"version"`
+	Backup    string                         `json:"backup"`
+	CreatedAt time.Time                      `json:"created_at"`
+	Encrypted bool                           `json:"encrypted"`
+	KeyID     string                         `json:"key_id,omitempty"`
+	Files     []BackupFileChecksum           `json:"files"`
+	Tables    map[string]BackupTableChecksum `json:"tables"`
+}
+
+// BackupFileChecksum is the size and SHA-256 of a file as written to disk
+type BackupFileChecksum struct {
+	Name      string `json:"name"`
+	SizeBytes int64  `json:"size_bytes"`
+	SHA256    string `json:"sha256"`
+}
+
+// BackupTableChecksum is the row count of a table and the SHA-256 of its
+// rows, each followed by a newline, in the order they were exported
+type BackupTableChecksum struct {
+	Rows   int64  `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+// BackupVerification is the outcome of checking a snapshot against its
+// manifest. Snapshots taken before manifests are only checked to read.
+type BackupVerification struct {
+	Backup    string           `json:"backup"`
+	Valid     bool             `json:"valid"`
+	Encrypted bool             `json:"encrypted"`
+	Manifest  bool             `json:"manifest"`
+	Rows      map[string]int64 `json:"rows,omitempty"`
+	Problems  []string         `json:"problems"`
+}
+
+// BackupJob is the last snapshot or restore this instance ran
+type BackupJob struct {
+	Kind       string           `json:"kind"`
+	Backup     string           `json:"backup,omitempty"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+	Rows       map[string]int64 `json:"rows,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// BackupScheduler takes logical snapshots of PostgreSQL on a cron schedule,
+// prunes old ones and restores them into a staging database
+type BackupScheduler struct {
+	dir           string
+	scheduleSpec  string
+	retentionDays int
+	stagingDSN    string
+	key           []byte
+
+	mu      sync.Mutex
+	busy    bool
+	lastJob *BackupJob
+
+	running bool
+	wg      sync.WaitGroup
+}
+
+var Backups *BackupScheduler
+
+// InitBackups reads the snapshot settings. Without BACKUP_SCHEDULE,
+// snapshots are only taken on request.
+func InitBackups(cfg *config.Config) error {
+	if cfg == nil {
+		cfg = config.AppConfig
+	}
+
+	scheduler := &BackupScheduler{
+		dir:           cfg.BackupDir,
+		retentionDays: cfg.BackupRetentionDays,
+		stagingDSN:    cfg.BackupStagingDSN,
+	}
+	if cfg.BackupSchedule != "" {
+		if _, err := ParseCron(cfg.BackupSchedule); err != nil {
+			return fmt.Errorf("BACKUP_SCHEDULE: %v", err)
+		}
+		scheduler.scheduleSpec = cfg.BackupSchedule
+	}
+	if scheduler.stagingDSN != "" && scheduler.stagingDSN == cfg.GetPostgresDSN() {
+		return fmt.Errorf("BACKUP_STAGING_DSN must not be the live database")
+	}
+	if cfg.BackupEncryptionKey != "" {
+		key, err := parseBackupKey(cfg.BackupEncryptionKey)
+		if err != nil {
+			return err
+		}
+		scheduler.key = key
+	}
+
+	Backups = scheduler
+	return nil
+}
+
+// StagingConfigured reports whether snapshots can be restored
+func (b *BackupScheduler) StagingConfigured() bool {
+	return b.stagingDSN != ""
+}
+
+// Start registers the "backups" job on BACKUP_SCHEDULE; without one it only
+// runs when triggered
+func (b *BackupScheduler) Start() {
+	if b.running {
+		return
+	}
+
+	b.running = true
+	schedule := b.scheduleSpec
+	if schedule == "" {
+		schedule = "off"
+	}
+	Jobs.Register(JobSpec{
+		Name:     "backups",
+		Schedule: schedule,
+		Run:      b.scheduledSnapshot,
+	})
+}
+
+func (b *BackupScheduler) Stop() {
+	b.running = false
+}
+
+// Drain waits for a running snapshot or restore to finish after Stop
+func (b *BackupScheduler) Drain(ctx context.Context) error {
+	return waitForGroup(ctx, &b.wg)
+}
+
+// scheduledSnapshot takes the snapshot due this minute. The claim on the
+// minute is left to expire so no other replica takes the same snapshot.
+func (b *BackupScheduler) scheduledSnapshot() error {
+	minute := time.Now().In(OrgSettings().Location()).Truncate(time.Minute)
+	if _, err := RedisClient.AcquireLock("backups:"+minute.UTC().Format(backupTimeFormat), 2*time.Minute); err != nil {
+		if errors.Is(err, ErrLockNotAcquired) {
+			return nil
+		}
+		return fmt.Errorf("failed to claim scheduled snapshot: %v", err)
+	}
+	if err := b.begin("snapshot", ""); err != nil {
+		return err
+	}
+	b.snapshot()
+	return nil
+}
+
+// begin marks this instance busy with a job, one at a time
+func (b *BackupScheduler) begin(kind, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.busy {
+		return ErrBackupBusy
+	}
+	b.busy = true
+	b.lastJob = &BackupJob{Kind: kind, Backup: name, StartedAt: time.Now()}
+	return nil
+}
+
+func (b *BackupScheduler) finish(name string, rows map[string]int64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.busy = false
+	b.lastJob.FinishedAt = &now
+	b.lastJob.Rows = rows
+	if name != "" {
+		b.lastJob.Backup = name
+	}
+	if err != nil {
+		b.lastJob.Error = err.Error()
+	}
+}
+
+// Status returns the settings and the last snapshot or restore this
+// instance ran
+func (b *BackupScheduler) Status() map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := map[string]interface{}{
+		"schedule":       b.scheduleSpec,
+		"retention_days": b.retentionDays,
+		"staging":        b.StagingConfigured(),
+		"encrypted":      b.key != nil,
+		"busy":           b.busy,
+	}
+	if b.lastJob != nil {
+		job := *b.lastJob
+		status["last_job"] = &job
+	}
+	return status
+}
+
+// SnapshotAsync takes a snapshot in the background
+func (b *BackupScheduler) SnapshotAsync() error {
+	if PostgresClient == nil {
+		return ErrNoPostgres
+	}
+	if err := b.begin("snapshot", ""); err != nil {
+		return err
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.snapshot()
+	}()
+	return nil
+}
+
+// snapshot syncs pending changes so the snapshot is current, writes it and
+// removes snapshots past retention
+func (b *BackupScheduler) snapshot() {
+	if err := Syncer.ForceSyncNow(); err != nil {
+		backupLog.Warn("⚠️ Sync before snapshot failed, snapshot may miss recent changes", "error", err)
+	}
+
+	name, rows, err := b.writeSnapshot(time.Now())
+	b.finish(name, rows, err)
+	if err != nil {
+		backupLog.Error("❌ Snapshot failed", "error", err)
+		return
+	}
+	backupLog.Info("💾 Snapshot taken", "backup", name, "rows", rows)
+
+	if err := b.prune(time.Now()); err != nil {
+		backupLog.Warn("⚠️ Failed to remove old snapshots", "error", err)
+	}
+}
+
+// writeSnapshot exports every table in one read-only transaction, so the
+// snapshot is a single point in time. With BACKUP_ENCRYPTION_KEY the
+// compressed rows are encrypted. The manifest is written first and the
+// snapshot only gets its final name once complete.
+func (b *BackupScheduler) writeSnapshot(now time.Time) (string, map[string]int64, error) {
+	if PostgresClient == nil {
+		return "", nil, ErrNoPostgres
+	}
+	if err := os.MkdirAll(b.dir, 0750); err != nil {
+		return "", nil, err
+	}
+
+	name := "gask-" + now.UTC().Format(backupTimeFormat) + ".jsonl.gz"
+	if b.key != nil {
+		name += ".enc"
+	}
+	path := filepath.Join(b.dir, name)
+	partial := path + ".partial"
+
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return "", nil, err
+	}
+	defer os.Remove(partial)
+	defer file.Close()
+
+	fileHash := sha256.New()
+	var (
+		sink      io.Writer = io.MultiWriter(file, fileHash)
+		encrypter *backupEncrypter
+	)
+	if b.key != nil {
+		if encrypter, err = newBackupEncrypter(sink, b.key); err != nil {
+			return "", nil, err
+		}
+		sink = encrypter
+	}
+
+	zw := gzip.NewWriter(sink)
+	buffered := bufio.NewWriter(zw)
+	encoder := json.NewEncoder(buffered)
+
+	header := backupHeader{Version: BackupFormatVersion, CreatedAt: now.UTC(), Tables: backupTables}
+	if err := encoder.Encode(header); err != nil {
+		return "", nil, err
+	}
+
+	manifest := &BackupManifest{
+		Version:   BackupFormatVersion,
+		Backup:    name,
+		CreatedAt: now.UTC(),
+		Encrypted: b.key != nil,
+		Tables:    make(map[string]BackupTableChecksum, len(backupTables)),
+	}
+	if b.key != nil {
+		manifest.KeyID = backupKeyID(b.key)
+	}
+	rows := make(map[string]int64, len(backupTables))
+	err = PostgresClient.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range backupTables {
+			tableHash := sha256.New()
+			count, err := exportTable(tx, table, encoder, tableHash)
+			if err != nil {
+				return fmt.Errorf("export %s: %w", table, err)
+			}
+			rows[table] = count
+			manifest.Tables[table] = BackupTableChecksum{Rows: count, SHA256: hex.EncodeToString(tableHash.Sum(nil))}
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := buffered.Flush(); err != nil {
+		return "", nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return "", nil, err
+	}
+	if encrypter != nil {
+		if err := encrypter.Close(); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := file.Sync(); err != nil {
+		return "", nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return "", nil, err
+	}
+	if err := file.Close(); err != nil {
+		return "", nil, err
+	}
+
+	manifest.Files = []BackupFileChecksum{{Name: name, SizeBytes: info.Size(), SHA256: hex.EncodeToString(fileHash.Sum(nil))}}
+	if err := b.writeManifest(manifest); err != nil {
+		return "", nil, fmt.Errorf("write manifest: %w", err)
+	}
+	if err := os.Rename(partial, path); err != nil {
+		return "", nil, err
+	}
+	return name, rows, nil
+}
+
+// exportTable writes a table's rows, hashing each as verification reads it back
+func exportTable(tx *gorm.DB, table string, encoder *json.Encoder, rowHash hash.Hash) (int64, error) {
+	result, err := tx.Raw(fmt.Sprintf(`SELECT row_to_json(t)::text FROM %q t`, table)).Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer result.Close()
+
+	var count int64
+	for result.Next() {
+		var row string
+		if err := result.Scan(&row); err != nil {
+			return count, err
+		}
+		if err := encoder.Encode(backupRow{Table: table, Row: json.RawMessage(row)}); err != nil {
+			return count, err
+		}
+		hashBackupRow(rowHash, json.RawMessage(row))
+		count++
+	}
+	return count, result.Err()
+}
+
+// hashBackupRow adds a row to its table's checksum. The row is compacted
+// first, as the encoder compacts it on the way into the snapshot.
+func hashBackupRow(rowHash hash.Hash, row json.RawMessage) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, row); err != nil {
+		compact.Write(row)
+	}
+	compact.WriteByte('\n')
+	rowHash.Write(compact.Bytes())
+}
+
+// backupManifestName is the manifest beside a snapshot, named after its time
+func backupManifestName(name string) string {
+	match := backupNamePattern.FindStringSubmatch(name)
+	if match == nil {
+		return ""
+	}
+	return "gask-" + match[1] + ".manifest.json"
+}
+
+func (b *BackupScheduler) writeManifest(manifest *BackupManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(b.dir, backupManifestName(manifest.Backup))
+	if err := os.WriteFile(path+".partial", data, 0640); err != nil {
+		return err
+	}
+	return os.Rename(path+".partial", path)
+}
+
+// Manifest reads a snapshot's manifest; snapshots taken before manifests
+// have none and return ErrNotFound
+func (b *BackupScheduler) Manifest(name string) (*BackupManifest, error) {
+	if !backupNamePattern.MatchString(name) {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(b.dir, backupManifestName(name)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("manifest %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	return &manifest, nil
+}
+
+// prune removes snapshots older than the retention period, with their manifests
+func (b *BackupScheduler) prune(now time.Time) error {
+	if b.retentionDays <= 0 {
+		return nil
+	}
+
+	backups, err := b.List()
+	if err != nil {
+		return err
+	}
+	cutoff := now.AddDate(0, 0, -b.retentionDays)
+	for _, backup := range backups {
+		if backup.CreatedAt.Before(cutoff) {
+			if err := os.Remove(filepath.Join(b.dir, backup.Name)); err != nil {
+				return err
+			}
+			if err := os.Remove(filepath.Join(b.dir, backupManifestName(backup.Name))); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			backupLog.Info("🗑️ Removed old snapshot", "backup", backup.Name)
+		}
+	}
+	return nil
+}
+
+// List returns the finished snapshots, newest first
+func (b *BackupScheduler) List() ([]*Backup, error) {
+	entries, err := os.ReadDir(b.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []*Backup{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []*Backup{}
+	for _, entry := range entries {
+		match := backupNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		createdAt, _ := time.Parse(backupTimeFormat, match[1])
+		backups = append(backups, &Backup{
+			Name:      entry.Name(),
+			CreatedAt: createdAt,
+			SizeBytes: info.Size(),
+			Encrypted: match[2] != "",
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Name > backups[j].Name })
+	return backups, nil
+}
+
+// Open opens a finished snapshot for download
+func (b *BackupScheduler) Open(name string) (*os.File, error) {
+	if !backupNamePattern.MatchString(name) {
+		return nil, ErrNotFound
+	}
+	file, err := os.Open(filepath.Join(b.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// readSnapshot decrypts a snapshot when it is encrypted and decompresses
+// it, returning its rows after the header
+func (b *BackupScheduler) readSnapshot(r io.Reader) (*bufio.Scanner, *backupHeader, error) {
+	buffered := bufio.NewReader(r)
+	var plain io.Reader = buffered
+	if isEncryptedBackup(buffered) {
+		if b.key == nil {
+			return nil, nil, fmt.Errorf("snapshot is encrypted; set BACKUP_ENCRYPTION_KEY to read it")
+		}
+		decrypter, err := newBackupDecrypter(buffered, b.key)
+		if err != nil {
+			return nil, nil, err
+		}
+		plain = decrypter
+	}
+
+	zr, err := gzip.NewReader(plain)
+	if err != nil {
+		if errors.Is(err, ErrBackupKey) {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("snapshot is not gzipped: %v", err)
+	}
+
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 64*1024), 64<<20)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("snapshot is empty")
+	}
+	var header backupHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil, nil, fmt.Errorf("invalid snapshot header: %v", err)
+	}
+	if header.Version != BackupFormatVersion {
+		return nil, nil, fmt.Errorf("unsupported snapshot version %d", header.Version)
+	}
+	return scanner, &header, nil
+}
+
+// Verify reads a snapshot through, decrypting and decompressing it, and
+// compares its size, checksum, tables and rows with its manifest. Snapshots
+// taken before manifests pass when they read without error.
+func (b *BackupScheduler) Verify(name string) (*BackupVerification, error) {
+	file, err := b.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	report := &BackupVerification{Backup: name, Problems: []string{}}
+	manifest, err := b.Manifest(name)
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		report.Problems = append(report.Problems, err.Error())
+	default:
+		report.Manifest = true
+		report.Encrypted = manifest.Encrypted
+		if manifest.Backup != name {
+			report.Problems = append(report.Problems, fmt.Sprintf("manifest describes %s", manifest.Backup))
+		}
+		if manifest.Encrypted && b.key != nil && manifest.KeyID != backupKeyID(b.key) {
+			report.Problems = append(report.Problems, "snapshot was encrypted with another key than BACKUP_ENCRYPTION_KEY")
+		}
+	}
+	if len(report.Problems) > 0 {
+		return report, nil
+	}
+
+	fileHash := sha256.New()
+	counted := &countingReader{r: io.TeeReader(file, fileHash)}
+	rows, tableHashes, err := b.readRows(counted)
+	if err != nil {
+		report.Problems = append(report.Problems, err.Error())
+		return report, nil
+	}
+	report.Rows = rows
+	report.Encrypted = report.Encrypted || strings.HasSuffix(name, ".enc")
+
+	// Hash what follows the end of the compressed rows too
+	if _, err := io.Copy(io.Discard, counted); err != nil {
+		return nil, err
+	}
+
+	if manifest != nil {
+		for _, expected := range manifest.Files {
+			if expected.Name != name {
+				continue
+			}
+			if expected.SizeBytes != counted.n {
+				report.Problems = append(report.Problems, fmt.Sprintf("file is %d bytes, manifest says %d", counted.n, expected.SizeBytes))
+			} else if sum := hex.EncodeToString(fileHash.Sum(nil)); sum != expected.SHA256 {
+				report.Problems = append(report.Problems, "file checksum does not match the manifest")
+			}
+		}
+
+		tables := make([]string, 0, len(manifest.Tables))
+		for table := range manifest.Tables {
+			tables = append(tables, table)
+		}
+		sort.Strings(tables)
+		for _, table := range tables {
+			expected := manifest.Tables[table]
+			switch {
+			case rows[table] != expected.Rows:
+				report.Problems = append(report.Problems, fmt.Sprintf("%s has %d rows, manifest says %d", table, rows[table], expected.Rows))
+			case hex.EncodeToString(tableHashes[table].Sum(nil)) != expected.SHA256:
+				report.Problems = append(report.Problems, fmt.Sprintf("%s rows do not match their checksum", table))
+			}
+		}
+		for table := range rows {
+			if _, ok := manifest.Tables[table]; !ok {
+				report.Problems = append(report.Problems, fmt.Sprintf("%s is not in the manifest", table))
+			}
+		}
+	}
+
+	report.Valid = len(report.Problems) == 0
+	return report, nil
+}
+
+// readRows reads every row of a snapshot, counting and hashing them by table
+func (b *BackupScheduler) readRows(r io.Reader) (map[string]int64, map[string]hash.Hash, error) {
+	scanner, _, err := b.readSnapshot(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := make(map[string]int64)
+	hashes := make(map[string]hash.Hash)
+	for _, table := range backupTables {
+		rows[table] = 0
+		hashes[table] = sha256.New()
+	}
+	for scanner.Scan() {
+		var row backupRow
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			// A read error ends the snapshot with a partial row
+			if scanner.Err() != nil {
+				break
+			}
+			return nil, nil, fmt.Errorf("invalid snapshot row: %v", err)
+		}
+		if !contains(backupTables, row.Table) {
+			return nil, nil, fmt.Errorf("unknown table %q in snapshot", row.Table)
+		}
+		rows[row.Table]++
+		hashBackupRow(hashes[row.Table], row.Row)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, ErrBackupKey) {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("snapshot is damaged: %v", err)
+	}
+	return rows, hashes, nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// RestoreAsync verifies a snapshot and loads it into the staging database
+// in the background. A snapshot that fails verification is not restored
+// and its report is returned with ErrBackupInvalid.
+func (b *BackupScheduler) RestoreAsync(name string) (*BackupVerification, error) {
+	if !b.StagingConfigured() {
+		return nil, ErrNoStaging
+	}
+	report, err := b.Verify(name)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Valid {
+		return report, ErrBackupInvalid
+	}
+
+	file, err := b.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.begin("restore", name); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer file.Close()
+
+		rows, err := b.restore(file)
+		b.finish("", rows, err)
+		if err != nil {
+			backupLog.Error("❌ Restore to staging failed", "backup", name, "error", err)
+			return
+		}
+		backupLog.Info("♻️ Snapshot restored to staging", "backup", name, "rows", rows)
+	}()
+	return report, nil
+}
+
+// restore replaces the staging database's tables with the snapshot's rows
+// in one transaction. PostgreSQL converts each row back from JSON, so
+// columns the snapshot lacks are left null.
+func (b *BackupScheduler) restore(file *os.File) (map[string]int64, error) {
+	scanner, header, err := b.readSnapshot(file)
+	if err != nil {
+		return nil, err
+	}
+
+	staging, err := gorm.Open(postgres.Open(b.stagingDSN), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, err
+	}
+	if sqlDB, err := staging.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	if err := staging.AutoMigrate(postgresModels...); err != nil {
+		return nil, fmt.Errorf("migrate staging: %v", err)
+	}
+
+	rows := make(map[string]int64, len(header.Tables))
+	err = staging.Transaction(func(tx *gorm.DB) error {
+		quoted := make([]string, len(backupTables))
+		for i, table := range backupTables {
+			quoted[i] = fmt.Sprintf("%q", table)
+		}
+		if err := tx.Exec("TRUNCATE " + strings.Join(quoted, ", ")).Error; err != nil {
+			return err
+		}
+
+		var (
+			table string
+			batch []json.RawMessage
+		)
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			values, _ := json.Marshal(batch)
+			statement := fmt.Sprintf(`INSERT INTO %q SELECT * FROM json_populate_recordset(NULL::%q, ?)`, table, table)
+			if err := tx.Exec(statement, string(values)).Error; err != nil {
+				return fmt.Errorf("restore %s: %w", table, err)
+			}
+			rows[table] += int64(len(batch))
+			batch = batch[:0]
+			return nil
+		}
+
+		for scanner.Scan() {
+			var row backupRow
+			if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+				return fmt.Errorf("invalid snapshot row: %v", err)
+			}
+			if !contains(backupTables, row.Table) {
+				return fmt.Errorf("unknown table %q in snapshot", row.Table)
+			}
+			if row.Table != table || len(batch) == backupRestoreBatch {
+				if err := flush(); err != nil {
+					return err
+				}
+				table = row.Table
+			}
+			batch = append(batch, row.Row)
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		return flush()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,88 @@
+package modules
+
+import (
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// BloomFilter answers "definitely not added" or "maybe added" in constant
+// time and memory. It never forgets an item, so removals need a rebuild.
+type BloomFilter struct {
+	mu       sync.RWMutex
+	bits     []uint64
+	size     uint64
+	hashes   uint64
+	capacity int
+	count    int
+}
+
+// NewBloomFilter sizes a filter to hold capacity items at the given false
+// positive rate
+func NewBloomFilter(capacity int, falsePositiveRate float64) *BloomFilter {
+	if capacity < 1 {
+		capacity = 1
+	}
+	size := uint64(math.Ceil(-float64(capacity) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	hashes := uint64(math.Max(1, math.Round(float64(size)/float64(capacity)*math.Ln2)))
+
+	return &BloomFilter{
+		bits:     make([]uint64, (size+63)/64),
+		size:     size,
+		hashes:   hashes,
+		capacity: capacity,
+	}
+}
+
+// positions derives the filter's bit positions for an item from two hashes
+func (f *BloomFilter) positions(item string) func(i uint64) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(item))
+	h1 := h.Sum64()
+
+	h2Hash := fnv.New64()
+	h2Hash.Write([]byte(item))
+	h2 := h2Hash.Sum64() | 1
+
+	return func(i uint64) uint64 {
+		return (h1 + i*h2) % f.size
+	}
+}
+
+func (f *BloomFilter) Add(item string) {
+	position := f.positions(item)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := position(i)
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.count++
+}
+
+// MightContain is false only for items that were never added
+func (f *BloomFilter) MightContain(item string) bool {
+	position := f.positions(item)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := position(i)
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Stats reports the filter's size and load for the admin status endpoint
+func (f *BloomFilter) Stats() map[string]interface{} {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return map[string]interface{}{
+		"added":    f.count,
+		"capacity": f.capacity,
+		"bytes":    len(f.bits) * 8,
+	}
+}
@@ -0,0 +1,107 @@
+package modules
+
+import (
+	"task-manager/config"
+	"task-manager/models"
+	"time"
+)
+
+// Clock policies say how a timer counts time: the wall clock counts every
+// minute, the business clock only working hours outside holidays
+const (
+	ClockWall     = "wall"
+	ClockBusiness = "business"
+)
+
+// ClockPolicies lists the clock policies for validation messages
+const ClockPolicies = "wall, business"
+
+// IsClockPolicy reports whether policy names a clock; empty means the wall clock
+func IsClockPolicy(policy string) bool {
+	return policy == "" || policy == ClockWall || policy == ClockBusiness
+}
+
+// WorkCalendar is when work happens: the intervals of each weekday in the
+// organization's timezone, outside the holidays of a region and, for a
+// user, their leave
+type WorkCalendar struct {
+	Hours  models.WorkTimes
+	Region string
+	// UserID is the user whose leave is skipped, 0 for the organization
+	UserID int
+}
+
+// UserCalendar is when a user works
+func UserCalendar(user *models.User) *WorkCalendar {
+	return &WorkCalendar{Hours: user.WorkTimes, Region: UserHolidayRegion(user), UserID: user.ID}
+}
+
+// BusinessCalendar is the organization's working hours outside the holidays
+// of the default region, which the business clock counts
+func BusinessCalendar() *WorkCalendar {
+	return &WorkCalendar{Hours: OrgSettings().WorkingHours, Region: config.AppConfig.HolidayRegion}
+}
+
+// WeeklyHours is how many hours the calendar works in a week
+func (c *WorkCalendar) WeeklyHours() float64 {
+	weekly := 0.0
+	for day := range Weekdays {
+		weekly += c.Hours.Hours(day)
+	}
+	return weekly
+}
+
+// dayOff says why no work happens on a date that may be a working day:
+// "holiday", "leave", or empty when it is not a day off
+func (r *RedisManager) dayOff(c *WorkCalendar, date time.Time) string {
+	switch {
+	case r.IsHoliday(c.Region, date):
+		return "holiday"
+	case c.UserID != 0 && r.IsUserOnLeave(c.UserID, date):
+		return "leave"
+	default:
+		return ""
+	}
+}
+
+// workSpan returns when work starting at from first happens and when
+// minutes of it are done. It is false when that does not fit within
+// suggestionHorizon days.
+func (r *RedisManager) workSpan(c *WorkCalendar, from time.Time, minutes int) (time.Time, time.Time, bool) {
+	from = from.In(OrgSettings().Location())
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+
+	var start time.Time
+	for offset := 0; offset < suggestionHorizon; offset++ {
+		date := day.AddDate(0, 0, offset)
+		at := date
+		if offset == 0 {
+			at = from
+		}
+		available := workMinutesFrom(c.Hours, at)
+		if available == 0 || r.dayOff(c, date) != "" {
+			continue
+		}
+
+		if start.IsZero() {
+			start = finishTime(c.Hours, at, 0)
+		}
+		if minutes <= available {
+			return start, finishTime(c.Hours, at, minutes), true
+		}
+		minutes -= available
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// AddClockTime returns when a timer of d started at from runs out under a
+// clock policy. The business clock counts whole minutes of the business
+// calendar, and is false when it has no working time for d.
+func (r *RedisManager) AddClockTime(policy string, from time.Time, d time.Duration) (time.Time, bool) {
+	if policy != ClockBusiness {
+		return from.Add(d), true
+	}
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	_, end, ok := r.workSpan(BusinessCalendar(), from, minutes)
+	return end, ok
+}
@@ -0,0 +1,124 @@
+package modules
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"task-manager/models"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// taskThroughputKey counts tasks created and completed since the database
+// was set up, for rates over any period. Unlike the stats hashes it is not
+// recounted by reconciliation, which cannot know past events.
+const taskThroughputKey = "stats:task_throughput"
+
+// countTaskEvents counts a save that creates or completes a task
+func (r *RedisManager) countTaskEvents(pipe redis.Pipeliner, previous, current *taskPlacement) {
+	if previous == nil {
+		pipe.HIncrBy(r.ctx, taskThroughputKey, "created", 1)
+	}
+	if current.Status && (previous == nil || !previous.Status) {
+		pipe.HIncrBy(r.ctx, taskThroughputKey, "completed", 1)
+	}
+}
+
+// BusinessMetrics are product figures read from Redis when metrics are
+// scraped, so every replica reports the same values
+type BusinessMetrics struct {
+	TasksByState   map[string]int64
+	OverdueTasks   int64
+	TasksCreated   int64
+	TasksCompleted int64
+	LastSync       time.Time
+	PendingSync    int
+}
+
+// GetBusinessMetrics reads the business metrics from the task counters and
+// group indexes, without reading any task
+func (r *RedisManager) GetBusinessMetrics() (*BusinessMetrics, error) {
+	counts, err := r.GetGlobalTaskCounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read task counts: %v", err)
+	}
+
+	groupIDs, err := r.client.SMembers(r.ctx, "groups:all").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read groups: %v", err)
+	}
+
+	today := fmt.Sprintf("(%d", todayDay())
+	pipe := r.client.Pipeline()
+	overdue := make([]*redis.IntCmd, 0, len(groupIDs))
+	for _, idStr := range groupIDs {
+		groupID, err := strconv.Atoi(idStr)
+		if err != nil {
+			continue
+		}
+		overdue = append(overdue, pipe.ZCount(r.ctx, groupOpenTasksKey(groupID), "-inf", today))
+	}
+	throughput := pipe.HGetAll(r.ctx, taskThroughputKey)
+	dirty := pipe.SCard(r.ctx, "dirty:types")
+	if _, err := pipe.Exec(r.ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	metrics := &BusinessMetrics{
+		TasksByState: map[string]int64{
+			models.TaskOpen:      counts.Pending() - counts.Blocked,
+			models.TaskBlocked:   counts.Blocked,
+			models.TaskDone:      counts.Done,
+			models.TaskCancelled: counts.Cancelled,
+		},
+		PendingSync: int(dirty.Val()),
+	}
+	for _, cmd := range overdue {
+		metrics.OverdueTasks += cmd.Val()
+	}
+	metrics.TasksCreated, _ = strconv.ParseInt(throughput.Val()["created"], 10, 64)
+	metrics.TasksCompleted, _ = strconv.ParseInt(throughput.Val()["completed"], 10, 64)
+
+	metrics.LastSync, err = r.GetLastSyncTime()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read last sync time: %v", err)
+	}
+	return metrics, nil
+}
+
+// WriteBusinessMetrics writes the business metrics in the Prometheus text
+// format
+func WriteBusinessMetrics(w io.Writer) error {
+	metrics, err := RedisClient.GetBusinessMetrics()
+	if err != nil {
+		return err
+	}
+
+	writeMetricHeader(w, "gask_tasks", "gauge", "Tasks by state.")
+	for _, state := range []string{models.TaskOpen, models.TaskBlocked, models.TaskDone, models.TaskCancelled} {
+		fmt.Fprintf(w, "gask_tasks{state=%q} %d\n", state, metrics.TasksByState[state])
+	}
+	writeMetric(w, "gask_tasks_overdue", "gauge", "Open tasks past their deadline.", float64(metrics.OverdueTasks))
+	writeMetric(w, "gask_tasks_created_total", "counter", "Tasks created.", float64(metrics.TasksCreated))
+	writeMetric(w, "gask_tasks_completed_total", "counter", "Tasks marked done.", float64(metrics.TasksCompleted))
+	writeMetric(w, "gask_sync_pending_types", "gauge", "Record types with changes not yet synced to PostgreSQL.", float64(metrics.PendingSync))
+
+	// Before the first sync there is no lag to report
+	if !metrics.LastSync.IsZero() {
+		writeMetric(w, "gask_sync_last_success_timestamp_seconds", "gauge", "Unix time of the last successful sync.",
+			float64(metrics.LastSync.Unix()))
+		writeMetric(w, "gask_sync_lag_seconds", "gauge", "Seconds since the last successful sync.",
+			time.Since(metrics.LastSync).Seconds())
+	}
+	return nil
+}
+
+func writeMetricHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeMetric(w io.Writer, name, kind, help string, value float64) {
+	writeMetricHeader(w, name, kind, help)
+	fmt.Fprintf(w, "%s %s\n", name, strconv.FormatFloat(value, 'g', -1, 64))
+}
@@ -0,0 +1,42 @@
+package modules
+
+import "task-manager/models"
+
+// Change events are published whenever a task, user or group is saved or
+// deleted, whichever handler, job or command made the change, so dashboards
+// can refresh what they show instead of polling
+const (
+	TaskChanged  = "task.changed"
+	UserChanged  = "user.changed"
+	GroupChanged = "group.changed"
+)
+
+// Ways a record changes
+const (
+	ChangeSaved   = "saved"
+	ChangeDeleted = "deleted"
+	// ChangeMoved is sent to the assignee and group a task left
+	ChangeMoved = "moved"
+)
+
+// Change says which record changed and how. It never carries the record, so
+// events stay small and only tell readers what to read again, with their
+// own permissions.
+type Change struct {
+	ID      int    `json:"id"`
+	Op      string `json:"op"`
+	Version int    `json:"version,omitempty"`
+	State   string `json:"state,omitempty"`
+}
+
+// publishChange tells every replica about a change; like other events it is
+// delivered to the owner, the user it concerns and the group's admins
+func (r *RedisManager) publishChange(eventType string, userID, groupID int, change *Change) {
+	Events.Publish(r.ctx, eventType, userID, groupID, change)
+}
+
+// publishTaskChange tells every replica about a change to a task, delivered
+// to whoever may view the task
+func (r *RedisManager) publishTaskChange(task *models.Task, change *Change) {
+	Events.PublishTask(r.ctx, TaskChanged, task, change)
+}
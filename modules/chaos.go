@@ -0,0 +1,56 @@
+package modules
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrInjectedFault is returned by store calls failed on purpose by fault injection
+var ErrInjectedFault = errors.New("injected fault")
+
+// Faults are the failures injected into calls to one store
+type Faults struct {
+	// ErrorRate is the share of calls that fail, from 0 to 1
+	ErrorRate float64 `json:"error_rate"`
+	// LatencyMs is added to every call before it runs
+	LatencyMs int `json:"latency_ms"`
+}
+
+// ChaosSettings are the faults injected into Redis and PostgreSQL. They only
+// take effect in binaries built with the chaos tag, and only on this replica.
+type ChaosSettings struct {
+	Redis    Faults `json:"redis"`
+	Postgres Faults `json:"postgres"`
+}
+
+var chaos struct {
+	mu       sync.RWMutex
+	settings ChaosSettings
+}
+
+// GetChaos returns the faults currently injected
+func GetChaos() ChaosSettings {
+	chaos.mu.RLock()
+	defer chaos.mu.RUnlock()
+	return chaos.settings
+}
+
+// SetChaos replaces the faults injected from now on
+func SetChaos(settings ChaosSettings) {
+	chaos.mu.Lock()
+	chaos.settings = settings
+	chaos.mu.Unlock()
+}
+
+// injectFault delays a call and then fails it at the configured rate
+func injectFault(faults Faults) error {
+	if faults.LatencyMs > 0 {
+		time.Sleep(time.Duration(faults.LatencyMs) * time.Millisecond)
+	}
+	if faults.ErrorRate > 0 && rand.Float64() < faults.ErrorRate {
+		return ErrInjectedFault
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+//go:build chaos
+
+package modules
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// ChaosAvailable reports whether this binary can inject faults
+const ChaosAvailable = true
+
+// chaosRedisHook fails or delays commands before they are sent
+type chaosRedisHook struct{}
+
+func (chaosRedisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, injectFault(GetChaos().Redis)
+}
+
+func (chaosRedisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (chaosRedisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, injectFault(GetChaos().Redis)
+}
+
+func (chaosRedisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func installRedisChaos(client *redis.Client) {
+	client.AddHook(chaosRedisHook{})
+}
+
+// installPostgresChaos fails or delays statements before gorm runs them
+func installPostgresChaos(db *gorm.DB) error {
+	inject := func(tx *gorm.DB) {
+		if err := injectFault(GetChaos().Postgres); err != nil {
+			tx.AddError(err)
+		}
+	}
+
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("chaos:create", inject); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("chaos:query", inject); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("chaos:update", inject); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("chaos:delete", inject); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("chaos:row", inject); err != nil {
+		return err
+	}
+	return callbacks.Raw().Before("gorm:raw").Register("chaos:raw", inject)
+}
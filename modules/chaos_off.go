@@ -0,0 +1,17 @@
+//go:build !chaos
+
+package modules
+
+import (
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// ChaosAvailable reports whether this binary can inject faults
+const ChaosAvailable = false
+
+func installRedisChaos(client *redis.Client) {}
+
+func installPostgresChaos(db *gorm.DB) error {
+	return nil
+}
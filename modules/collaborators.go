@@ -0,0 +1,98 @@
+package modules
+
+import (
+	"fmt"
+	"strconv"
+	"task-manager/models"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// user:{id}:shared_tasks holds the tasks a user collaborates on, beside
+// user:{id}:tasks for those they are the primary assignee of
+func sharedTasksKey(userID int) string {
+	return fmt.Sprintf("user:%d:shared_tasks", userID)
+}
+
+// indexSharedTask files a task under each collaborator, leaving those of
+// the collaborators it had before
+func (r *RedisManager) indexSharedTask(pipe redis.Pipeliner, previous *taskPlacement, task *models.Task) {
+	if previous != nil {
+		for _, userID := range previous.Collaborators {
+			if !containsID(task.Collaborators, userID) {
+				pipe.SRem(r.ctx, sharedTasksKey(userID), task.ID)
+			}
+		}
+	}
+	for _, userID := range task.Collaborators {
+		pipe.SAdd(r.ctx, sharedTasksKey(userID), task.ID)
+	}
+}
+
+// GetUserSharedTasks reads the tasks a user collaborates on
+func (r *RedisManager) GetUserSharedTasks(userID int) ([]*models.Task, error) {
+	TaskWrites.Flush()
+
+	taskIDs, err := r.client.SMembers(r.ctx, sharedTasksKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*models.Task
+	for _, taskIDStr := range taskIDs {
+		taskID, err := strconv.Atoi(taskIDStr)
+		if err != nil {
+			continue
+		}
+
+		task, err := r.GetTask(taskID)
+		if err == nil {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks, nil
+}
+
+// ReplaceCollaborator moves a user's place on the tasks they collaborate on
+// to another user, or drops it when replacement is 0. It returns how many
+// tasks changed.
+func (r *RedisManager) ReplaceCollaborator(userID, replacement int) (int, error) {
+	tasks, err := r.GetUserSharedTasks(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, task := range tasks {
+		collaborators := make([]int, 0, len(task.Collaborators))
+		for _, collaborator := range task.Collaborators {
+			if collaborator != userID {
+				collaborators = append(collaborators, collaborator)
+			} else if replacement != 0 {
+				collaborators = append(collaborators, replacement)
+			}
+		}
+		checked := replacement != 0 && task.HasChecked(userID)
+		task.SetCollaborators(collaborators)
+		if checked {
+			task.SetChecked(replacement, true)
+		}
+		task.UpdatedAt = time.Now()
+		if err := r.SaveTask(task); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+func containsID(ids []int, id int) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
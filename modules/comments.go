@@ -0,0 +1,154 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"task-manager/models"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// deletedIDsKey holds the IDs of records of a type, such as comments,
+// deleted since the last sync, which the sync removes from PostgreSQL.
+// Unlike pruning every row missing from Redis, this never loses rows that
+// Redis lost.
+func deletedIDsKey(dataType string) string {
+	return dataType + ":deleted"
+}
+
+// GetDeletedIDs returns the IDs of the records of a type deleted since the last sync
+func (r *RedisManager) GetDeletedIDs(dataType string) ([]int, error) {
+	members, err := r.client.SMembers(r.ctx, deletedIDsKey(dataType)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(members))
+	for _, member := range members {
+		if id, err := strconv.Atoi(member); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// ClearDeletedIDs forgets deletions once they reached PostgreSQL
+func (r *RedisManager) ClearDeletedIDs(dataType string, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(ids))
+	for i, id := range ids {
+		members[i] = id
+	}
+	return r.client.SRem(r.ctx, deletedIDsKey(dataType), members...).Err()
+}
+
+// Comment operations
+func commentKey(commentID int) string {
+	return fmt.Sprintf("comment:%d", commentID)
+}
+
+// taskCommentsKey orders a task's comments by ID, which is the order they
+// were written in
+func taskCommentsKey(taskID int) string {
+	return fmt.Sprintf("task:%d:comments", taskID)
+}
+
+func (r *RedisManager) GetNextCommentID() (int, error) {
+	id, err := r.client.Incr(r.ctx, "counter:comment_id").Result()
+	return int(id), err
+}
+
+func (r *RedisManager) SaveComment(comment *models.Comment) error {
+	commentJSON, err := json.Marshal(comment)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(r.ctx, commentKey(comment.ID), commentJSON, 0)
+		pipe.SAdd(r.ctx, "comments:all", comment.ID)
+		pipe.ZAdd(r.ctx, taskCommentsKey(comment.TaskID), &redis.Z{Score: float64(comment.ID), Member: comment.ID})
+		return nil
+	})
+	return err
+}
+
+func (r *RedisManager) GetComment(commentID int) (*models.Comment, error) {
+	commentJSON, err := r.client.Get(r.ctx, commentKey(commentID)).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("comment %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var comment models.Comment
+	err = json.Unmarshal([]byte(commentJSON), &comment)
+	return &comment, err
+}
+
+func (r *RedisManager) getComments(commentIDs []string) ([]*models.Comment, error) {
+	comments := make([]*models.Comment, 0, len(commentIDs))
+	for _, commentIDStr := range commentIDs {
+		commentID, err := strconv.Atoi(commentIDStr)
+		if err != nil {
+			continue
+		}
+
+		comment, err := r.GetComment(commentID)
+		if err == nil {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+// GetTaskComments returns a task's comments, oldest first
+func (r *RedisManager) GetTaskComments(taskID int) ([]*models.Comment, error) {
+	commentIDs, err := r.client.ZRange(r.ctx, taskCommentsKey(taskID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	return r.getComments(commentIDs)
+}
+
+func (r *RedisManager) GetAllComments() ([]*models.Comment, error) {
+	commentIDs, err := r.client.SMembers(r.ctx, "comments:all").Result()
+	if err != nil {
+		return nil, err
+	}
+	return r.getComments(commentIDs)
+}
+
+func (r *RedisManager) DeleteComment(comment *models.Comment) error {
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(r.ctx, commentKey(comment.ID))
+		pipe.SRem(r.ctx, "comments:all", comment.ID)
+		pipe.ZRem(r.ctx, taskCommentsKey(comment.TaskID), comment.ID)
+		pipe.SAdd(r.ctx, deletedIDsKey("comments"), comment.ID)
+		return nil
+	})
+	return err
+}
+
+// DeleteTaskComments removes every comment of a task, marking comments
+// dirty so the sync removes them from PostgreSQL too
+func (r *RedisManager) DeleteTaskComments(taskID int) error {
+	comments, err := r.GetTaskComments(taskID)
+	if err != nil {
+		return err
+	}
+
+	for _, comment := range comments {
+		if err := r.DeleteComment(comment); err != nil {
+			return err
+		}
+	}
+	if len(comments) > 0 {
+		r.MarkDirty("comments")
+	}
+	return r.client.Del(r.ctx, taskCommentsKey(taskID)).Err()
+}
@@ -0,0 +1,163 @@
+package modules
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// ConfigBundleVersion is the bundle format this server writes and accepts
+const ConfigBundleVersion = 1
+
+// ConfigBundle is the organization's configuration as a document that can
+// be kept in version control and applied to another environment. Custom
+// holidays are keyed by region and then by date.
+type ConfigBundle struct {
+	Version  int                          `json:"version"`
+	Settings *OrganizationSettings        `json:"settings,omitempty"`
+	Holidays map[string]map[string]string `json:"holidays,omitempty"`
+}
+
+// ConfigChanges lists what applying a bundle changed, or would change
+type ConfigChanges struct {
+	Settings       bool     `json:"settings"`
+	HolidayRegions []string `json:"holiday_regions"`
+}
+
+// Changed reports whether applying the bundle changed anything
+func (c *ConfigChanges) Changed() bool {
+	return c.Settings || len(c.HolidayRegions) > 0
+}
+
+// ExportConfig builds a bundle of the current configuration
+func (r *RedisManager) ExportConfig() (*ConfigBundle, error) {
+	settings, err := r.GetOrganizationSettings()
+	if err != nil {
+		return nil, err
+	}
+	// When settings were saved is not configuration
+	settings.UpdatedAt = nil
+
+	holidays, err := r.customHolidaysByRegion()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConfigBundle{
+		Version:  ConfigBundleVersion,
+		Settings: settings,
+		Holidays: holidays,
+	}, nil
+}
+
+// ApplyConfig makes the configuration match the bundle. Sections missing
+// from the bundle are left alone. Custom holidays are declarative: regions
+// not in the bundle lose their custom holidays. Applying the same bundle
+// twice changes nothing the second time. With dryRun nothing is saved.
+func (r *RedisManager) ApplyConfig(bundle *ConfigBundle, dryRun bool) (*ConfigChanges, error) {
+	changes := &ConfigChanges{HolidayRegions: []string{}}
+
+	if bundle.Settings != nil {
+		current, err := r.GetOrganizationSettings()
+		if err != nil {
+			return nil, err
+		}
+
+		if !sameSettings(current, bundle.Settings) {
+			changes.Settings = true
+			if !dryRun {
+				settings := *bundle.Settings
+				now := time.Now()
+				settings.UpdatedAt = &now
+				if err := r.SaveOrganizationSettings(&settings); err != nil {
+					return changes, err
+				}
+			}
+		}
+	}
+
+	if bundle.Holidays != nil {
+		current, err := r.customHolidaysByRegion()
+		if err != nil {
+			return changes, err
+		}
+
+		regions := make([]string, 0, len(current)+len(bundle.Holidays))
+		for region := range current {
+			regions = append(regions, region)
+		}
+		for region := range bundle.Holidays {
+			if _, ok := current[region]; !ok {
+				regions = append(regions, region)
+			}
+		}
+		sort.Strings(regions)
+
+		for _, region := range regions {
+			wanted := bundle.Holidays[region]
+			if len(wanted) == 0 && len(current[region]) == 0 {
+				continue
+			}
+			if reflect.DeepEqual(wanted, current[region]) {
+				continue
+			}
+
+			changes.HolidayRegions = append(changes.HolidayRegions, region)
+			if dryRun {
+				continue
+			}
+
+			holidays := make([]*Holiday, 0, len(wanted))
+			for date, name := range wanted {
+				holidays = append(holidays, &Holiday{Date: date, Name: name, Region: region})
+			}
+			if err := r.ReplaceCustomHolidays(region, holidays); err != nil {
+				return changes, err
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func (r *RedisManager) customHolidaysByRegion() (map[string]map[string]string, error) {
+	regions, err := r.GetCustomHolidayRegions()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]map[string]string, len(regions))
+	for _, region := range regions {
+		holidays, err := r.GetCustomHolidays(region)
+		if err != nil {
+			return nil, err
+		}
+		if len(holidays) == 0 {
+			continue
+		}
+
+		dates := make(map[string]string, len(holidays))
+		for _, holiday := range holidays {
+			dates[holiday.Date] = holiday.Name
+		}
+		result[region] = dates
+	}
+	return result, nil
+}
+
+// sameSettings compares settings by their JSON form, ignoring when they were saved
+func sameSettings(a, b *OrganizationSettings) bool {
+	left, right := *a, *b
+	left.UpdatedAt, right.UpdatedAt = nil, nil
+
+	leftJSON, err := json.Marshal(left)
+	if err != nil {
+		return false
+	}
+	rightJSON, err := json.Marshal(right)
+	if err != nil {
+		return false
+	}
+	return string(leftJSON) == string(rightJSON)
+}
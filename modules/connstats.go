@@ -0,0 +1,127 @@
+package modules
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// connDurationBuckets are the upper bounds connection lifetimes are counted
+// under, chosen to tell short requests from keep-alive and long-lived streams
+var connDurationBuckets = []struct {
+	label string
+	max   time.Duration
+}{
+	{"1s", time.Second},
+	{"10s", 10 * time.Second},
+	{"1m", time.Minute},
+	{"10m", 10 * time.Minute},
+	{"1h", time.Hour},
+}
+
+// ConnStats counts the API server's client connections. Install Track as
+// http.Server.ConnState.
+type ConnStats struct {
+	mu       sync.Mutex
+	open     map[net.Conn]*connInfo
+	accepted uint64
+	closed   uint64
+	hijacked uint64
+
+	// Lifetimes of finished connections, the last bucket being over an hour
+	buckets       [6]uint64
+	totalDuration time.Duration
+	maxDuration   time.Duration
+}
+
+type connInfo struct {
+	openedAt time.Time
+	state    http.ConnState
+}
+
+var Connections = &ConnStats{open: make(map[net.Conn]*connInfo)}
+
+// Track follows a connection through its states. Hijacked connections, such
+// as h2c upgrades, are counted as finished when they leave the server.
+func (s *ConnStats) Track(conn net.Conn, state http.ConnState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch state {
+	case http.StateNew:
+		s.accepted++
+		s.open[conn] = &connInfo{openedAt: time.Now(), state: state}
+	case http.StateActive, http.StateIdle:
+		if info := s.open[conn]; info != nil {
+			info.state = state
+		}
+	case http.StateHijacked, http.StateClosed:
+		info := s.open[conn]
+		if info == nil {
+			return
+		}
+		delete(s.open, conn)
+		if state == http.StateHijacked {
+			s.hijacked++
+		} else {
+			s.closed++
+		}
+		s.record(time.Since(info.openedAt))
+	}
+}
+
+func (s *ConnStats) record(duration time.Duration) {
+	s.totalDuration += duration
+	if duration > s.maxDuration {
+		s.maxDuration = duration
+	}
+	for i, bucket := range connDurationBuckets {
+		if duration <= bucket.max {
+			s.buckets[i]++
+			return
+		}
+	}
+	s.buckets[len(connDurationBuckets)]++
+}
+
+// Snapshot returns the current counts for the admin status endpoint
+func (s *ConnStats) Snapshot() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byState := map[string]int{"new": 0, "active": 0, "idle": 0}
+	var oldest time.Duration
+	for _, info := range s.open {
+		byState[info.state.String()]++
+		if age := time.Since(info.openedAt); age > oldest {
+			oldest = age
+		}
+	}
+
+	finished := s.closed + s.hijacked
+	var average time.Duration
+	if finished > 0 {
+		average = s.totalDuration / time.Duration(finished)
+	}
+
+	buckets := make(map[string]uint64, len(s.buckets))
+	for i, bucket := range connDurationBuckets {
+		buckets["le_"+bucket.label] = s.buckets[i]
+	}
+	buckets["over_1h"] = s.buckets[len(connDurationBuckets)]
+
+	return map[string]interface{}{
+		"open":                len(s.open),
+		"by_state":            byState,
+		"oldest_open_seconds": int(oldest.Seconds()),
+		"accepted_total":      s.accepted,
+		"closed_total":        s.closed,
+		"hijacked_total":      s.hijacked,
+		"duration": map[string]interface{}{
+			"average_ms": average.Milliseconds(),
+			"max_ms":     s.maxDuration.Milliseconds(),
+			"buckets":    buckets,
+		},
+	}
+}
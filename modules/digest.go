@@ -0,0 +1,136 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"task-manager/models"
+	"time"
+)
+
+// membershipLogLength is how many membership changes are kept per group,
+// plenty for a day's digest
+const membershipLogLength = 200
+
+// digestListLimit bounds each list in a digest, so chat bridges are not
+// sent more than they can show. The summary still counts every task.
+const digestListLimit = 100
+
+// membershipChange is a user joining or leaving a group
+type membershipChange struct {
+	UserID   int       `json:"user_id"`
+	FullName string    `json:"full_name"`
+	Joined   bool      `json:"joined"`
+	At       time.Time `json:"at"`
+}
+
+func groupMembershipKey(groupID int) string {
+	return fmt.Sprintf("group:%d:membership", groupID)
+}
+
+// recordMembershipChanges notes the groups a user joined and left in a save
+func (r *RedisManager) recordMembershipChanges(user *models.User, before, after []int) {
+	now := time.Now()
+	note := func(groupID int, joined bool) {
+		payload, _ := json.Marshal(membershipChange{UserID: user.ID, FullName: user.FullName, Joined: joined, At: now})
+		key := groupMembershipKey(groupID)
+		pipe := r.client.TxPipeline()
+		pipe.LPush(r.ctx, key, payload)
+		pipe.LTrim(r.ctx, key, 0, membershipLogLength-1)
+		pipe.Exec(r.ctx)
+	}
+
+	for _, groupID := range after {
+		if !containsID(before, groupID) {
+			note(groupID, true)
+		}
+	}
+	for _, groupID := range before {
+		if !containsID(after, groupID) {
+			note(groupID, false)
+		}
+	}
+}
+
+// getMembershipChanges returns a group's membership changes since a time,
+// oldest first
+func (r *RedisManager) getMembershipChanges(groupID int, since time.Time) ([]membershipChange, error) {
+	payloads, err := r.client.LRange(r.ctx, groupMembershipKey(groupID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []membershipChange
+	for i := len(payloads) - 1; i >= 0; i-- {
+		var change membershipChange
+		if json.Unmarshal([]byte(payloads[i]), &change) == nil && change.At.After(since) {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// generateGroupDigest fills a group_daily_digest report with the tasks
+// created, completed and overdue and the members who joined or left
+func generateGroupDigest(report *models.Report, group *models.Group, tasks []*models.Task) error {
+	start, now := report.PeriodStart, report.PeriodEnd
+	digest := &models.GroupDigest{
+		GroupID:       group.ID,
+		GroupName:     group.Name,
+		Created:       []models.DigestTask{},
+		Completed:     []models.DigestTask{},
+		Overdue:       []models.DigestTask{},
+		MembersJoined: []models.DigestMember{},
+		MembersLeft:   []models.DigestMember{},
+	}
+
+	created, completed, overdue := 0, 0, 0
+	for _, task := range tasks {
+		if task.CreatedAt.After(start) {
+			created++
+			digest.Created = appendDigestTask(digest.Created, task)
+		}
+		if task.Status && task.ClosedAt != nil && task.ClosedAt.After(start) {
+			completed++
+			digest.Completed = appendDigestTask(digest.Completed, task)
+		}
+		if IsTaskOverdue(task, now) {
+			overdue++
+			digest.Overdue = appendDigestTask(digest.Overdue, task)
+		}
+	}
+
+	changes, err := RedisClient.getMembershipChanges(group.ID, start)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		member := models.DigestMember{UserID: change.UserID, FullName: change.FullName, At: change.At}
+		if change.Joined {
+			digest.MembersJoined = append(digest.MembersJoined, member)
+		} else {
+			digest.MembersLeft = append(digest.MembersLeft, member)
+		}
+	}
+
+	report.Summary["created_tasks"] = created
+	report.Summary["completed_tasks"] = completed
+	report.Summary["overdue_tasks"] = overdue
+	report.Summary["members_joined"] = len(digest.MembersJoined)
+	report.Summary["members_left"] = len(digest.MembersLeft)
+	report.Digest = digest
+	return nil
+}
+
+func appendDigestTask(list []models.DigestTask, task *models.Task) []models.DigestTask {
+	if len(list) >= digestListLimit {
+		return list
+	}
+	return append(list, models.DigestTask{
+		ID:       task.ID,
+		Key:      task.Key,
+		Title:    task.Title,
+		UserID:   task.UserID,
+		Priority: task.Priority,
+		Deadline: task.Deadline,
+	})
+}
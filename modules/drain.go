@@ -0,0 +1,22 @@
+package modules
+
+import (
+	"context"
+	"sync"
+)
+
+// waitForGroup blocks until wg is done or ctx expires, whichever comes first
+func waitForGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,538 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"task-manager/config"
+	"task-manager/models"
+	"time"
+)
+
+var mailLog = Logger("email")
+
+// EmailMessage is a rendered outbound email
+type EmailMessage struct {
+	From     string
+	To       []string
+	Subject  string
+	HTMLBody string
+	TextBody string
+}
+
+// EmailSender delivers rendered emails through a specific transport
+type EmailSender interface {
+	Name() string
+	Send(msg *EmailMessage) error
+}
+
+// TransientError marks a delivery failure that is worth retrying
+type TransientError struct {
+	Err error
+}
+
+func (e *TransientError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransientError) Unwrap() error {
+	return e.Err
+}
+
+// IsTransient reports whether err is a retryable delivery failure
+func IsTransient(err error) bool {
+	var transient *TransientError
+	return errors.As(err, &transient)
+}
+
+type EmailService struct {
+	sender        EmailSender
+	from          string
+	maxAttempts   int
+	retryInterval time.Duration
+	running       bool
+	wg            sync.WaitGroup
+}
+
+var Mailer *EmailService
+
+// InitEmail configures the outbound email service for the selected provider
+func InitEmail(cfg *config.Config) error {
+	if cfg == nil {
+		cfg = config.AppConfig
+	}
+
+	var sender EmailSender
+	switch cfg.EmailProvider {
+	case "", "none":
+		sender = nil
+	case "smtp":
+		sender = &SMTPSender{
+			host:     cfg.SMTPHost,
+			port:     cfg.SMTPPort,
+			username: cfg.SMTPUsername,
+			password: cfg.SMTPPassword,
+		}
+	case "sendgrid":
+		if cfg.SendGridAPIKey == "" {
+			return fmt.Errorf("SENDGRID_API_KEY is required for the sendgrid email provider")
+		}
+		sender = &SendGridSender{
+			apiKey: cfg.SendGridAPIKey,
+			client: NewOutboundClient("sendgrid"),
+		}
+	case "ses":
+		if cfg.SESAccessKeyID == "" || cfg.SESSecretAccessKey == "" {
+			return fmt.Errorf("SES_ACCESS_KEY_ID and SES_SECRET_ACCESS_KEY are required for the ses email provider")
+		}
+		sender = &SESSender{
+			region:          cfg.SESRegion,
+			accessKeyID:     cfg.SESAccessKeyID,
+			secretAccessKey: cfg.SESSecretAccessKey,
+			client:          NewOutboundClient("ses"),
+		}
+	default:
+		return fmt.Errorf("unknown email provider: %s", cfg.EmailProvider)
+	}
+
+	Mailer = &EmailService{
+		sender:        sender,
+		from:          cfg.EmailFrom,
+		maxAttempts:   cfg.EmailMaxAttempts,
+		retryInterval: cfg.EmailRetryInterval,
+	}
+
+	if sender != nil {
+		fmt.Printf("✅ Email service configured with %s provider\n", sender.Name())
+	}
+	return nil
+}
+
+// Enabled reports whether an email provider is configured
+func (e *EmailService) Enabled() bool {
+	return e != nil && e.sender != nil
+}
+
+// Check probes the provider when it supports a cheap connectivity check
+func (e *EmailService) Check() error {
+	if checker, ok := e.sender.(interface{ Check() error }); ok {
+		return checker.Check()
+	}
+	return nil
+}
+
+// Provider returns the configured provider name
+func (e *EmailService) Provider() string {
+	if !e.Enabled() {
+		return "none"
+	}
+	return e.sender.Name()
+}
+
+// SendTemplate renders the named template and delivers it, recording the attempt in the email log
+func (e *EmailService) SendTemplate(ctx context.Context, to, templateName string, data map[string]interface{}) error {
+	if !e.Enabled() {
+		return nil
+	}
+
+	subject, htmlBody, textBody, err := renderEmailTemplate(templateName, data)
+	if err != nil {
+		return err
+	}
+
+	entry := &models.EmailLog{
+		To:       to,
+		Subject:  subject,
+		Template: templateName,
+		HTMLBody: htmlBody,
+		TextBody: textBody,
+		Provider: e.sender.Name(),
+		Status:   "pending",
+	}
+
+	if PostgresClient != nil {
+		if err := PostgresClient.CreateEmailLog(entry); err != nil {
+			mailLog.WarnContext(ctx, "⚠️ Failed to record email log", "error", err)
+		}
+	}
+
+	return e.deliver(ctx, entry)
+}
+
+func (e *EmailService) deliver(ctx context.Context, entry *models.EmailLog) error {
+	entry.Attempts++
+
+	err := e.sender.Send(&EmailMessage{
+		From:     e.from,
+		To:       []string{entry.To},
+		Subject:  entry.Subject,
+		HTMLBody: entry.HTMLBody,
+		TextBody: entry.TextBody,
+	})
+
+	if err == nil {
+		now := time.Now()
+		entry.Status = "sent"
+		entry.SentAt = &now
+		entry.LastError = ""
+	} else if IsTransient(err) && entry.Attempts < e.maxAttempts {
+		entry.Status = "retrying"
+		entry.LastError = err.Error()
+	} else {
+		entry.Status = "failed"
+		entry.LastError = err.Error()
+	}
+
+	if PostgresClient != nil && entry.ID != 0 {
+		if saveErr := PostgresClient.SaveEmailLog(entry); saveErr != nil {
+			mailLog.WarnContext(ctx, "⚠️ Failed to update email log", "email_id", entry.ID, "error", saveErr)
+		}
+	}
+
+	return err
+}
+
+// Start schedules the "email-retry" job, which resends emails that failed
+// transiently
+func (e *EmailService) Start() {
+	if !e.Enabled() || e.running {
+		return
+	}
+
+	e.running = true
+	Jobs.Register(JobSpec{
+		Name:     "email-retry",
+		Schedule: EverySchedule(e.retryInterval),
+		Run:      e.retryPending,
+	})
+}
+
+// Stop marks the service stopped; the retry job stops with Jobs
+func (e *EmailService) Stop() {
+	if !e.Enabled() || !e.running {
+		return
+	}
+
+	e.running = false
+}
+
+// SendTemplateAsync sends a templated email in the background, logging failures.
+// Pending sends are waited for by Drain.
+func (e *EmailService) SendTemplateAsync(ctx context.Context, to, name string, data map[string]interface{}) {
+	if !e.Enabled() {
+		return
+	}
+
+	// Keep the request's correlation fields but not its cancellation
+	ctx = context.WithoutCancel(ctx)
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		if err := e.SendTemplate(ctx, to, name, data); err != nil {
+			mailLog.WarnContext(ctx, "⚠️ Failed to send email", "template", name, "to", to, "error", err)
+		}
+	}()
+}
+
+// Drain waits for background sends to finish after Stop
+func (e *EmailService) Drain(ctx context.Context) error {
+	if e == nil {
+		return nil
+	}
+	return waitForGroup(ctx, &e.wg)
+}
+
+func (e *EmailService) retryPending() error {
+	if PostgresClient == nil {
+		return nil
+	}
+
+	entries, err := PostgresClient.GetRetryableEmailLogs(e.maxAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to load emails for retry: %v", err)
+	}
+
+	for _, entry := range entries {
+		if err := e.deliver(context.Background(), entry); err != nil {
+			mailLog.Warn("⚠️ Email retry failed", "email_id", entry.ID, "attempt", entry.Attempts, "error", err)
+		}
+	}
+	return nil
+}
+
+// SMTPSender delivers email through an SMTP relay
+type SMTPSender struct {
+	host     string
+	port     int
+	username string
+	password string
+}
+
+func (s *SMTPSender) Name() string {
+	return "smtp"
+}
+
+// Check verifies the SMTP server accepts connections
+func (s *SMTPSender) Check() error {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", s.host, s.port), 3*time.Second)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (s *SMTPSender) Send(msg *EmailMessage) error {
+	body, err := buildMIMEMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	err = smtp.SendMail(fmt.Sprintf("%s:%d", s.host, s.port), auth, msg.From, msg.To, body)
+	if err == nil {
+		return nil
+	}
+
+	// 4xx replies and network failures are temporary; 5xx replies are permanent
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		if protoErr.Code >= 400 && protoErr.Code < 500 {
+			return &TransientError{Err: err}
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return &TransientError{Err: err}
+	}
+
+	return err
+}
+
+// encodeSubject keeps a subject on its header line, since it can carry
+// task titles and other user text, and encodes anything beyond ASCII
+func encodeSubject(subject string) string {
+	subject = strings.Join(strings.FieldsFunc(subject, func(c rune) bool {
+		return c == '\r' || c == '\n'
+	}), " ")
+	return mime.QEncoding.Encode("utf-8", subject)
+}
+
+func buildMIMEMessage(msg *EmailMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", encodeSubject(msg.Subject))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=UTF-8", msg.TextBody},
+		{"text/html; charset=UTF-8", msg.HTMLBody},
+	}
+
+	for _, part := range parts {
+		if part.body == "" {
+			continue
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SendGridSender delivers email through the SendGrid v3 API
+type SendGridSender struct {
+	apiKey string
+	client *OutboundClient
+}
+
+func (s *SendGridSender) Name() string {
+	return "sendgrid"
+}
+
+func (s *SendGridSender) Send(msg *EmailMessage) error {
+	var to []map[string]string
+	for _, address := range msg.To {
+		to = append(to, map[string]string{"email": address})
+	}
+
+	var content []map[string]string
+	if msg.TextBody != "" {
+		content = append(content, map[string]string{"type": "text/plain", "value": msg.TextBody})
+	}
+	if msg.HTMLBody != "" {
+		content = append(content, map[string]string{"type": "text/html", "value": msg.HTMLBody})
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"personalizations": []map[string]interface{}{{"to": to}},
+		"from":             map[string]string{"email": msg.From},
+		"subject":          msg.Subject,
+		"content":          content,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", "https://api.sendgrid.com/v3/mail/send", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	return doProviderRequest(s.client, req)
+}
+
+// SESSender delivers email through the Amazon SES v2 API
+type SESSender struct {
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	client          *OutboundClient
+}
+
+func (s *SESSender) Name() string {
+	return "ses"
+}
+
+func (s *SESSender) Send(msg *EmailMessage) error {
+	body := make(map[string]interface{})
+	if msg.HTMLBody != "" {
+		body["Html"] = map[string]string{"Data": msg.HTMLBody}
+	}
+	if msg.TextBody != "" {
+		body["Text"] = map[string]string{"Data": msg.TextBody}
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"FromEmailAddress": msg.From,
+		"Destination":      map[string]interface{}{"ToAddresses": msg.To},
+		"Content": map[string]interface{}{
+			"Simple": map[string]interface{}{
+				"Subject": map[string]string{"Data": msg.Subject},
+				"Body":    body,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	host := fmt.Sprintf("email.%s.amazonaws.com", s.region)
+	req, err := http.NewRequest("POST", "https://"+host+"/v2/email/outbound-emails", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	s.sign(req, host, payload, time.Now().UTC())
+
+	return doProviderRequest(s.client, req)
+}
+
+// sign applies AWS Signature Version 4 headers to the request
+func (s *SESSender) sign(req *http.Request, host string, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/ses/aws4_request", date, s.region)
+
+	req.Header.Set("Host", host)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	payloadHash := sha256.Sum256(payload)
+	signedHeaders := "content-type;host;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + host,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "ses")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// doProviderRequest executes an HTTP provider call, classifying throttling and 5xx responses as transient
+func doProviderRequest(client *OutboundClient, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return &TransientError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("provider returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return &TransientError{Err: err}
+	}
+	return err
+}
@@ -0,0 +1,199 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"task-manager/config"
+	texttemplate "text/template"
+)
+
+type emailTemplate struct {
+	subject string
+	html    string
+	text    string
+}
+
+const emailLayout = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #222; max-width: 600px; margin: 0 auto;">
+{{if .LogoURL}}<img src="{{.LogoURL}}" alt="{{.OrgName}}" style="max-height: 48px;">
+{{end}}<h2 style="color: #2d6cdf;">{{.OrgName}}</h2>
+{{template "content" .}}
+<hr style="border: none; border-top: 1px solid #ddd;">
+<p style="font-size: 12px; color: #888;">This message was sent automatically by {{.AppName}}.</p>
+</body>
+</html>`
+
+var emailTemplates = map[string]emailTemplate{
+	"invite": {
+		subject: `You have been invited to {{.AppName}}`,
+		html: `<p>Hi {{.FullName}},</p>
+<p>An account has been created for you on {{.AppName}} with the role <strong>{{.Role}}</strong>.</p>
+<p>Sign in with your email address <strong>{{.Email}}</strong> and the password provided by your administrator.</p>`,
+		text: `Hi {{.FullName}},
+
+An account has been created for you on {{.AppName}} with the role {{.Role}}.
+Sign in with your email address {{.Email}} and the password provided by your administrator.`,
+	},
+	"password_reset": {
+		subject: `Reset your {{.AppName}} password`,
+		html: `<p>Hi {{.FullName}},</p>
+<p>Use the code below to reset your password. It expires in {{.ExpiresIn}}.</p>
+<p style="font-size: 20px; letter-spacing: 2px;"><strong>{{.ResetToken}}</strong></p>
+<p>If you did not request a reset you can ignore this email.</p>`,
+		text: `Hi {{.FullName}},
+
+Use this code to reset your password: {{.ResetToken}}
+It expires in {{.ExpiresIn}}. If you did not request a reset you can ignore this email.`,
+	},
+	"deadline_alert": {
+		subject: `Task "{{.Task.Title}}" is due {{.Task.Deadline}}`,
+		html: `<p>Hi {{.FullName}},</p>
+<p>Your task <strong>{{.Task.Title}}</strong> (#{{.Task.ID}}) is due on <strong>{{.Task.Deadline}}</strong> and is not completed yet.</p>`,
+		text: `Hi {{.FullName}},
+
+Your task "{{.Task.Title}}" (#{{.Task.ID}}) is due on {{.Task.Deadline}} and is not completed yet.`,
+	},
+	"task_reminder": {
+		subject: `Reminder: "{{.Task.Title}}"`,
+		html: `<p>Hi {{.FullName}},</p>
+<p>This is your reminder about <strong>{{.Task.Title}}</strong> (#{{.Task.ID}}).</p>{{if .Note}}
+<p>{{.Note}}</p>{{end}}`,
+		text: `Hi {{.FullName}},
+
+This is your reminder about "{{.Task.Title}}" (#{{.Task.ID}}).{{if .Note}}
+
+{{.Note}}{{end}}`,
+	},
+	"task_assigned": {
+		subject: `You have been assigned "{{.Task.Title}}"`,
+		html: `<p>Hi {{.FullName}},</p>
+<p>{{.AssignedBy}} assigned you <strong>{{.Task.Title}}</strong> (#{{.Task.ID}}){{if .Task.Deadline}}, due on <strong>{{.Task.Deadline}}</strong>{{end}}.</p>`,
+		text: `Hi {{.FullName}},
+
+{{.AssignedBy}} assigned you "{{.Task.Title}}" (#{{.Task.ID}}){{if .Task.Deadline}}, due on {{.Task.Deadline}}{{end}}.`,
+	},
+	"notification_digest": {
+		subject: `{{len .Notifications}} updates from {{.AppName}}`,
+		html: `<p>Hi {{.FullName}},</p>
+<p>Here is what happened since your last update:</p>
+<ul>
+{{range .Notifications}}<li>{{.Message}} <span style="color: #888;">({{.At.Format "Jan 2 15:04"}})</span></li>
+{{end}}</ul>`,
+		text: `Hi {{.FullName}},
+
+Here is what happened since your last update:
+{{range .Notifications}}- {{.Message}} ({{.At.Format "Jan 2 15:04"}})
+{{end}}`,
+	},
+	"digest": {
+		subject: `Your {{.AppName}} digest: {{len .Tasks}} open tasks`,
+		html: `<p>Hi {{.FullName}},</p>
+<p>Here is a summary of your open tasks:</p>
+<ul>
+{{range .Tasks}}<li><strong>{{.Title}}</strong>{{if .Deadline}} &mdash; due {{.Deadline}}{{end}}</li>
+{{end}}</ul>`,
+		text: `Hi {{.FullName}},
+
+Here is a summary of your open tasks:
+{{range .Tasks}}- {{.Title}}{{if .Deadline}} (due {{.Deadline}}){{end}}
+{{end}}`,
+	},
+	"report": {
+		subject: `{{.Report.Title}}`,
+		html: `<p>Hi {{.FullName}},</p>
+<p>Here is your scheduled report for {{.Report.PeriodStart.Format "2006-01-02"}} to {{.Report.PeriodEnd.Format "2006-01-02"}}.</p>
+<table style="border-collapse: collapse;">
+{{range $key, $value := .Report.Summary}}<tr><td style="padding: 4px 12px 4px 0;">{{$key}}</td><td><strong>{{$value}}</strong></td></tr>
+{{end}}</table>`,
+		text: `Hi {{.FullName}},
+
+Here is your scheduled report for {{.Report.PeriodStart.Format "2006-01-02"}} to {{.Report.PeriodEnd.Format "2006-01-02"}}.
+{{range $key, $value := .Report.Summary}}- {{$key}}: {{$value}}
+{{end}}`,
+	},
+	"sync_failing": {
+		subject: `{{.AppName}} sync has failed {{.Failures}} times in a row`,
+		html: `<p>Syncing from Redis to PostgreSQL has failed {{.Failures}} times in a row since {{.Since.Format "2006-01-02 15:04 MST"}}. PostgreSQL and the reports read from it are falling behind.</p>
+<p>Last error: <code>{{.LastError}}</code></p>{{if .FailedTable}}
+<p>Failed on: <strong>{{.FailedTable}}</strong></p>{{end}}{{if .PendingTables}}
+<p>Not synced: {{range $i, $table := .PendingTables}}{{if $i}}, {{end}}{{$table}}{{end}}</p>{{end}}`,
+		text: `Syncing from Redis to PostgreSQL has failed {{.Failures}} times in a row since {{.Since.Format "2006-01-02 15:04 MST"}}. PostgreSQL and the reports read from it are falling behind.
+
+Last error: {{.LastError}}{{if .FailedTable}}
+Failed on: {{.FailedTable}}{{end}}{{if .PendingTables}}
+Not synced: {{range $i, $table := .PendingTables}}{{if $i}}, {{end}}{{$table}}{{end}}{{end}}`,
+	},
+	"sync_recovered": {
+		subject: `{{.AppName}} sync has recovered`,
+		html:    `<p>Syncing from Redis to PostgreSQL succeeded again after {{.Failures}} failures since {{.Since.Format "2006-01-02 15:04 MST"}}.</p>`,
+		text:    `Syncing from Redis to PostgreSQL succeeded again after {{.Failures}} failures since {{.Since.Format "2006-01-02 15:04 MST"}}.`,
+	},
+}
+
+// renderEmailTemplate renders the subject, HTML and plain text bodies of a named template
+func renderEmailTemplate(name string, data map[string]interface{}) (string, string, string, error) {
+	tmpl, ok := emailTemplates[name]
+	if !ok {
+		return "", "", "", fmt.Errorf("unknown email template: %s", name)
+	}
+
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	if _, ok := data["AppName"]; !ok {
+		appName := "GASK"
+		if config.AppConfig != nil {
+			appName = config.AppConfig.AppName
+		}
+		data["AppName"] = appName
+	}
+
+	// Messages carry the organization's name and logo
+	settings := OrgSettings()
+	if _, ok := data["OrgName"]; !ok {
+		data["OrgName"] = settings.Name
+	}
+	if _, ok := data["LogoURL"]; !ok {
+		data["LogoURL"] = settings.LogoURL
+	}
+
+	subject, err := renderText(name+"_subject", tmpl.subject, data)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	textBody, err := renderText(name+"_text", tmpl.text, data)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	layout, err := htmltemplate.New("layout").Parse(emailLayout)
+	if err != nil {
+		return "", "", "", err
+	}
+	if _, err := layout.New("content").Parse(tmpl.html); err != nil {
+		return "", "", "", err
+	}
+
+	var htmlBuf bytes.Buffer
+	if err := layout.Execute(&htmlBuf, data); err != nil {
+		return "", "", "", err
+	}
+
+	return subject, htmlBuf.String(), textBody, nil
+}
+
+func renderText(name, source string, data map[string]interface{}) (string, error) {
+	tmpl, err := texttemplate.New(name).Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,21 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Domain errors returned by modules; handlers map them to HTTP status codes.
+// Specific errors wrap one of these so callers can test them with errors.Is.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrForbidden  = errors.New("forbidden")
+	ErrValidation = errors.New("validation failed")
+)
+
+// ErrVersionConflict is returned when an entity changed since the version the client last read
+var ErrVersionConflict = fmt.Errorf("%w: version changed", ErrConflict)
+
+// ErrEmptySearchQuery is returned when a query has no searchable terms
+var ErrEmptySearchQuery = fmt.Errorf("%w: query contains no searchable terms", ErrValidation)
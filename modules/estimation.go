@@ -0,0 +1,141 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// estimationTTL is how long an estimation session and its votes are kept
+const estimationTTL = 7 * 24 * time.Hour
+
+func estimationKey(sessionID int) string {
+	return fmt.Sprintf("estimation:%d", sessionID)
+}
+
+func estimationVotesKey(sessionID int) string {
+	return fmt.Sprintf("estimation:%d:votes", sessionID)
+}
+
+func (r *RedisManager) GetNextEstimationID() (int, error) {
+	id, err := r.client.Incr(r.ctx, "counter:estimation_id").Result()
+	return int(id), err
+}
+
+// SaveEstimationSession stores a session, restarting its expiry
+func (r *RedisManager) SaveEstimationSession(session *models.EstimationSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	indexKey := fmt.Sprintf("group:%d:estimations", session.GroupID)
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(r.ctx, estimationKey(session.ID), data, estimationTTL)
+		pipe.Expire(r.ctx, estimationVotesKey(session.ID), estimationTTL)
+		pipe.SAdd(r.ctx, indexKey, session.ID)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisManager) GetEstimationSession(sessionID int) (*models.EstimationSession, error) {
+	data, err := r.client.Get(r.ctx, estimationKey(sessionID)).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("estimation session %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session models.EstimationSession
+	err = json.Unmarshal([]byte(data), &session)
+	return &session, err
+}
+
+// GetGroupEstimationSessions returns the group's sessions, dropping expired ones from the index
+func (r *RedisManager) GetGroupEstimationSessions(groupID int) ([]*models.EstimationSession, error) {
+	indexKey := fmt.Sprintf("group:%d:estimations", groupID)
+	sessionIDs, err := r.client.SMembers(r.ctx, indexKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []*models.EstimationSession
+	for _, sessionIDStr := range sessionIDs {
+		sessionID, err := strconv.Atoi(sessionIDStr)
+		if err != nil {
+			continue
+		}
+
+		session, err := r.GetEstimationSession(sessionID)
+		if err != nil {
+			r.client.SRem(r.ctx, indexKey, sessionID)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func (r *RedisManager) DeleteEstimationSession(session *models.EstimationSession) error {
+	r.client.SRem(r.ctx, fmt.Sprintf("group:%d:estimations", session.GroupID), session.ID)
+	return r.client.Del(r.ctx, estimationKey(session.ID), estimationVotesKey(session.ID)).Err()
+}
+
+// SetEstimates records a user's hidden estimates, replacing earlier ones for the same tasks
+func (r *RedisManager) SetEstimates(sessionID, userID int, estimates map[int]float64) error {
+	values := make(map[string]interface{}, len(estimates))
+	for taskID, hours := range estimates {
+		values[fmt.Sprintf("%d:%d", taskID, userID)] = hours
+	}
+
+	key := estimationVotesKey(sessionID)
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(r.ctx, key, values)
+		pipe.Expire(r.ctx, key, estimationTTL)
+		return nil
+	})
+	return err
+}
+
+// GetEstimates returns every estimate of a session as task ID → user ID → hours
+func (r *RedisManager) GetEstimates(sessionID int) (map[int]map[int]float64, error) {
+	fields, err := r.client.HGetAll(r.ctx, estimationVotesKey(sessionID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	estimates := make(map[int]map[int]float64)
+	for field, value := range fields {
+		taskIDStr, userIDStr, ok := strings.Cut(field, ":")
+		if !ok {
+			continue
+		}
+		taskID, err := strconv.Atoi(taskIDStr)
+		if err != nil {
+			continue
+		}
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			continue
+		}
+		hours, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+
+		if estimates[taskID] == nil {
+			estimates[taskID] = make(map[int]float64)
+		}
+		estimates[taskID][userID] = hours
+	}
+
+	return estimates, nil
+}
@@ -0,0 +1,234 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"task-manager/models"
+	"time"
+)
+
+// eventsChannel is the Redis pub/sub channel events are fanned out on, so every
+// server replica delivers events published by any other replica
+const eventsChannel = "events"
+
+var eventsLog = Logger("events")
+
+// Event is a realtime notification pushed to subscribed clients
+type Event struct {
+	Type      string      `json:"type"`
+	UserID    int         `json:"user_id,omitempty"`
+	GroupID   int         `json:"group_id,omitempty"`
+	TaskID    int         `json:"task_id,omitempty"`
+	Data      interface{} `json:"data"`
+	RequestID string      `json:"request_id,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// EventHub relays events from Redis pub/sub to the clients connected to this replica
+type EventHub struct {
+	mu          sync.RWMutex
+	subscribers map[chan *Event]bool
+	bufferSize  int
+	stopChan    chan bool
+	running     bool
+}
+
+var Events *EventHub
+
+func InitEvents() {
+	Events = &EventHub{
+		subscribers: make(map[chan *Event]bool),
+		bufferSize:  32,
+		stopChan:    make(chan bool),
+		running:     false,
+	}
+}
+
+func (h *EventHub) Start() {
+	if h.running {
+		return
+	}
+
+	h.running = true
+	go h.listen()
+	fmt.Println("📡 Event hub started")
+}
+
+func (h *EventHub) Stop() {
+	if !h.running {
+		return
+	}
+
+	close(h.stopChan)
+	h.running = false
+
+	// Close subscriber channels so open streams end
+	h.mu.Lock()
+	for ch := range h.subscribers {
+		close(ch)
+		delete(h.subscribers, ch)
+	}
+	h.mu.Unlock()
+
+	fmt.Println("⏹️ Event hub stopped")
+}
+
+// Publish sends an event to every replica; failures are logged and never block the caller
+func (h *EventHub) Publish(ctx context.Context, eventType string, userID, groupID int, data interface{}) {
+	h.publish(ctx, &Event{Type: eventType, UserID: userID, GroupID: groupID, Data: data})
+}
+
+// PublishTask sends an event about a task, delivered to whoever may view
+// the task when it arrives
+func (h *EventHub) PublishTask(ctx context.Context, eventType string, task *models.Task, data interface{}) {
+	h.publish(ctx, &Event{Type: eventType, UserID: task.UserID, GroupID: task.GroupID, TaskID: task.ID, Data: data})
+}
+
+func (h *EventHub) publish(ctx context.Context, event *Event) {
+	if h == nil {
+		return
+	}
+
+	event.RequestID = RequestID(ctx)
+	event.CreatedAt = time.Now()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		eventsLog.WarnContext(ctx, "⚠️ Failed to encode event", "type", event.Type, "error", err)
+		return
+	}
+
+	if err := RedisClient.PublishEvent(eventsChannel, payload); err != nil {
+		eventsLog.WarnContext(ctx, "⚠️ Failed to publish event", "type", event.Type, "error", err)
+	}
+
+	if event.GroupID != 0 && activityEventTypes[event.Type] {
+		if err := RedisClient.RecordGroupActivity(event.GroupID, payload); err != nil {
+			eventsLog.WarnContext(ctx, "⚠️ Failed to record group activity", "type", event.Type, "group_id", event.GroupID, "error", err)
+		}
+	}
+}
+
+// Subscribe registers a local listener; call Unsubscribe with the returned channel when done
+func (h *EventHub) Subscribe() chan *Event {
+	ch := make(chan *Event, h.bufferSize)
+
+	h.mu.Lock()
+	h.subscribers[ch] = true
+	h.mu.Unlock()
+
+	return ch
+}
+
+func (h *EventHub) Unsubscribe(ch chan *Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[ch] {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+// SubscriberCount returns the number of clients streaming from this replica
+func (h *EventHub) SubscriberCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers)
+}
+
+// listen forwards events from Redis to local subscribers until stopped
+func (h *EventHub) listen() {
+	pubsub := RedisClient.SubscribeEvents(eventsChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var event Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				eventsLog.Warn("⚠️ Dropping malformed event", "error", err)
+				continue
+			}
+			h.broadcast(&event)
+		case <-h.stopChan:
+			return
+		}
+	}
+}
+
+// broadcast delivers an event to local subscribers, skipping any that are too slow to keep up
+func (h *EventHub) broadcast(event *Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// StreamRevoked reports whether a caller's open stream must close because
+// they were offboarded: on their user.offboarded event, or, with no event,
+// once their account is found deactivated, in case that event was missed
+func StreamRevoked(authCtx *AuthContext, event *Event) bool {
+	if authCtx.User == nil {
+		return false
+	}
+	if event != nil {
+		return event.Type == "user.offboarded" && event.UserID == authCtx.User.ID
+	}
+	user, err := RedisClient.GetUser(authCtx.User.ID)
+	return errors.Is(err, ErrNotFound) || (err == nil && user.Deactivated)
+}
+
+// CanReceiveEvent reports whether an event is visible to the caller: the owner sees
+// everything, events about a task go to whoever may view it, users see their own
+// events and group admins see their groups' events. Estimation events go to every
+// member of the group, since all of them take part. The caller is read again for
+// each event, so a stream follows role and group admin changes made while it is open.
+func CanReceiveEvent(authCtx *AuthContext, event *Event) bool {
+	if authCtx.IsOwner {
+		return true
+	}
+	if authCtx.User == nil {
+		return false
+	}
+	user, err := RedisClient.GetUser(authCtx.User.ID)
+	if err != nil {
+		return false
+	}
+	current := buildAuthContext(user)
+	if current.IsOwner {
+		return true
+	}
+
+	// A deleted task is judged by the assignee and group it had
+	if event.TaskID != 0 {
+		if task, err := RedisClient.GetTask(event.TaskID); err == nil {
+			return CanViewTask(current, task)
+		}
+	}
+
+	if event.UserID == user.ID {
+		return true
+	}
+	if current.IsGroupAdmin && event.GroupID != 0 && containsID(current.AdminGroupIDs, event.GroupID) {
+		return true
+	}
+	if event.GroupID != 0 && strings.HasPrefix(event.Type, "estimation.") && containsID(user.GroupIDs, event.GroupID) {
+		return true
+	}
+
+	return false
+}
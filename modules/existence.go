@@ -0,0 +1,244 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// existenceChannel carries emails and task IDs saved on any replica, so
+// every replica's filters learn about them
+const existenceChannel = "existence"
+
+// existenceRebuildInterval drops deleted items and resizes the filters
+const existenceRebuildInterval = time.Hour
+
+// existenceMinCapacity keeps a small database's filters from filling up
+// between rebuilds
+const existenceMinCapacity = 10000
+
+// existenceFalsePositiveRate is how often a miss still costs a Redis lookup
+const existenceFalsePositiveRate = 0.01
+
+var existenceLog = Logger("existence")
+
+// ExistenceFilters hold Bloom filters of the user emails and task IDs stored
+// in Redis, so checks that usually miss, such as email uniqueness on create,
+// skip Redis when the answer is a definite no. The filters live in memory
+// because a filter in Redis would cost the same round trip as the lookup.
+// Until they are built, and while they are rebuilt, every check falls
+// through to Redis.
+type ExistenceFilters struct {
+	mu       sync.RWMutex
+	emails   *BloomFilter
+	tasks    *BloomFilter
+	builtAt  time.Time
+	pending  []string
+	stopChan chan bool
+	running  bool
+}
+
+var Existence *ExistenceFilters
+
+func InitExistenceFilters() {
+	Existence = &ExistenceFilters{stopChan: make(chan bool)}
+}
+
+func (e *ExistenceFilters) Start() {
+	if e == nil || e.running {
+		return
+	}
+
+	e.running = true
+	go e.listen()
+}
+
+func (e *ExistenceFilters) Stop() {
+	if e == nil || !e.running {
+		return
+	}
+
+	close(e.stopChan)
+	e.running = false
+}
+
+// MightHaveEmail is false only when no user was ever saved with the email
+func (e *ExistenceFilters) MightHaveEmail(email string) bool {
+	if e == nil {
+		return true
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.emails == nil || e.emails.MightContain(email)
+}
+
+// MightHaveTask is false only when no task was ever saved with the ID
+func (e *ExistenceFilters) MightHaveTask(taskID int) bool {
+	if e == nil {
+		return true
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.tasks == nil || e.tasks.MightContain(strconv.Itoa(taskID))
+}
+
+// add records an item saved on this or another replica, given as
+// "email:<address>" or "task:<id>"
+func (e *ExistenceFilters) add(item string) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.pending != nil {
+		e.pending = append(e.pending, item)
+	}
+	addExisting(e.emails, e.tasks, item)
+}
+
+func addExisting(emails, tasks *BloomFilter, item string) {
+	kind, value, ok := strings.Cut(item, ":")
+	if !ok {
+		return
+	}
+	switch {
+	case kind == "email" && emails != nil:
+		emails.Add(value)
+	case kind == "task" && tasks != nil:
+		tasks.Add(value)
+	}
+}
+
+// invalidate makes every check fall through to Redis until the next rebuild
+func (e *ExistenceFilters) invalidate() {
+	e.mu.Lock()
+	e.emails, e.tasks = nil, nil
+	e.mu.Unlock()
+}
+
+// listen applies items saved on other replicas and rebuilds the filters
+// each time the subscription is (re)established, since messages sent while
+// it was down are lost
+func (e *ExistenceFilters) listen() {
+	pubsub := RedisClient.SubscribeEvents(existenceChannel)
+	defer pubsub.Close()
+
+	rebuild := time.NewTicker(existenceRebuildInterval)
+	defer rebuild.Stop()
+
+	messages := pubsub.ChannelWithSubscriptions(RedisClient.ctx, 1000)
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				e.invalidate()
+				return
+			}
+			switch msg := msg.(type) {
+			case *redis.Subscription:
+				if msg.Kind == "subscribe" {
+					e.invalidate()
+					e.rebuild()
+				}
+			case *redis.Message:
+				e.add(msg.Payload)
+			}
+		case <-rebuild.C:
+			e.rebuild()
+		case <-e.stopChan:
+			return
+		}
+	}
+}
+
+// rebuild reads every email and task ID from Redis into new filters sized
+// for twice the current count. Items saved here while it reads are kept
+// aside and added before the new filters replace the old ones.
+func (e *ExistenceFilters) rebuild() {
+	e.mu.Lock()
+	e.pending = []string{}
+	e.mu.Unlock()
+
+	emailFilter, taskFilter, err := buildExistenceFilters()
+	if err != nil {
+		existenceLog.Warn("⚠️ Failed to rebuild existence filters", "error", err)
+		e.mu.Lock()
+		e.emails, e.tasks, e.pending = nil, nil, nil
+		e.mu.Unlock()
+		return
+	}
+
+	e.mu.Lock()
+	for _, item := range e.pending {
+		addExisting(emailFilter, taskFilter, item)
+	}
+	e.emails, e.tasks, e.pending = emailFilter, taskFilter, nil
+	e.builtAt = time.Now()
+	e.mu.Unlock()
+}
+
+func buildExistenceFilters() (*BloomFilter, *BloomFilter, error) {
+	users, err := RedisClient.client.SCard(RedisClient.ctx, "users:all").Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	tasks, err := RedisClient.client.SCard(RedisClient.ctx, "tasks:all").Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	emailFilter := NewBloomFilter(max(int(users)*2, existenceMinCapacity), existenceFalsePositiveRate)
+	taskFilter := NewBloomFilter(max(int(tasks)*2, existenceMinCapacity), existenceFalsePositiveRate)
+
+	err = RedisClient.scanRecords("users:all", "user:%d", func(data []byte) error {
+		var user struct {
+			Email string `json:"email"`
+		}
+		if json.Unmarshal(data, &user) == nil {
+			emailFilter.Add(user.Email)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := RedisClient.scanIDs("tasks:all", taskFilter.Add); err != nil {
+		return nil, nil, err
+	}
+
+	existenceLog.Debug("🧮 Existence filters built", "users", users, "tasks", tasks)
+	return emailFilter, taskFilter, nil
+}
+
+// Status reports the filters' load for the admin status endpoint
+func (e *ExistenceFilters) Status() map[string]interface{} {
+	if e == nil {
+		return map[string]interface{}{"ready": false}
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.emails == nil || e.tasks == nil {
+		return map[string]interface{}{"ready": false}
+	}
+	return map[string]interface{}{
+		"ready":    true,
+		"built_at": e.builtAt,
+		"emails":   e.emails.Stats(),
+		"tasks":    e.tasks.Stats(),
+	}
+}
+
+// publishExisting adds a saved email or task to this replica's filters and
+// tells every other replica about it
+func (r *RedisManager) publishExisting(cmd redis.Cmdable, kind string, value interface{}) {
+	item := fmt.Sprintf("%s:%v", kind, value)
+	Existence.add(item)
+	cmd.Publish(r.ctx, existenceChannel, item)
+}
@@ -0,0 +1,304 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"task-manager/models"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Tasks are partitioned by group so that group stats, boards and syncs
+// read only that group's keys:
+//
+//	group:{id}:tasks       set of every task ID in the group
+//	group:{id}:tasks:open  open tasks scored by deadline day, noDeadlineDay without one
+//	group:{id}:tasks:done  completed tasks scored by last update time
+//	dirty:task_groups      groups whose tasks changed since the last sync
+//
+// Cancelled tasks are in neither the open nor the done set.
+const (
+	dirtyTaskGroupsKey = "dirty:task_groups"
+
+	// groupTaskIndexKey marks a database whose open and done sets are built
+	groupTaskIndexKey = "schema:group_task_index"
+
+	// noDeadlineDay sorts tasks without a deadline after every real date
+	noDeadlineDay = math.MaxInt32
+)
+
+func groupTasksKey(groupID int) string {
+	return fmt.Sprintf("group:%d:tasks", groupID)
+}
+
+func groupOpenTasksKey(groupID int) string {
+	return fmt.Sprintf("group:%d:tasks:open", groupID)
+}
+
+func groupDoneTasksKey(groupID int) string {
+	return fmt.Sprintf("group:%d:tasks:done", groupID)
+}
+
+// deadlineDay numbers a deadline date in days since the Unix epoch, so
+// scores do not depend on the organization's timezone
+func deadlineDay(deadline string) float64 {
+	if len(deadline) < 10 {
+		return noDeadlineDay
+	}
+	date, err := time.Parse("2006-01-02", deadline[:10])
+	if err != nil {
+		return noDeadlineDay
+	}
+	return float64(date.Unix() / 86400)
+}
+
+// todayDay is the day number of today in the organization's timezone
+func todayDay() int64 {
+	year, month, day := time.Now().In(OrgSettings().Location()).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
+
+// indexGroupTask files a task under its group's open or done set
+func (r *RedisManager) indexGroupTask(pipe redis.Pipeliner, task *models.Task) {
+	pipe.SAdd(r.ctx, groupTasksKey(task.GroupID), task.ID)
+	switch task.CurrentState() {
+	case models.TaskDone:
+		pipe.ZRem(r.ctx, groupOpenTasksKey(task.GroupID), task.ID)
+		pipe.ZAdd(r.ctx, groupDoneTasksKey(task.GroupID), &redis.Z{Score: float64(task.ClosedTime().Unix()), Member: task.ID})
+	case models.TaskCancelled:
+		pipe.ZRem(r.ctx, groupOpenTasksKey(task.GroupID), task.ID)
+		pipe.ZRem(r.ctx, groupDoneTasksKey(task.GroupID), task.ID)
+	default:
+		pipe.ZRem(r.ctx, groupDoneTasksKey(task.GroupID), task.ID)
+		pipe.ZAdd(r.ctx, groupOpenTasksKey(task.GroupID), &redis.Z{Score: deadlineDay(task.Deadline), Member: task.ID})
+	}
+}
+
+// unindexGroupTask removes a task from every set of a group
+func (r *RedisManager) unindexGroupTask(pipe redis.Pipeliner, groupID, taskID int) {
+	pipe.SRem(r.ctx, groupTasksKey(groupID), taskID)
+	pipe.ZRem(r.ctx, groupOpenTasksKey(groupID), taskID)
+	pipe.ZRem(r.ctx, groupDoneTasksKey(groupID), taskID)
+}
+
+// taskPlacement is what a stored task is indexed and counted under
+type taskPlacement struct {
+	UserID   int             `json:"user_id"`
+	GroupID  int             `json:"group_id"`
+	Priority models.Priority `json:"priority"`
+	Status   bool            `json:"status"`
+	State    string          `json:"state"`
+
+	ParentTaskID    int   `json:"parent_task_id"`
+	Collaborators   []int `json:"collaborators"`
+	VisibleToGroups []int `json:"visible_to_groups"`
+}
+
+func placementOf(task *models.Task) *taskPlacement {
+	return &taskPlacement{
+		UserID:          task.UserID,
+		GroupID:         task.GroupID,
+		Priority:        task.Priority,
+		Status:          task.Status,
+		State:           task.CurrentState(),
+		ParentTaskID:    task.ParentTaskID,
+		Collaborators:   task.Collaborators,
+		VisibleToGroups: task.VisibleToGroups,
+	}
+}
+
+// storedPlacement reads a stored task's placement before a save, so a task
+// that moves leaves its old indexes and counters. It is nil for a new task.
+func (r *RedisManager) storedPlacement(taskID int) *taskPlacement {
+	// New tasks are the common case, and need no lookup
+	if !Existence.MightHaveTask(taskID) {
+		return nil
+	}
+
+	data, err := r.client.Get(r.ctx, fmt.Sprintf("task:%d", taskID)).Bytes()
+	if err != nil {
+		return nil
+	}
+
+	var placement taskPlacement
+	if err := json.Unmarshal(data, &placement); err != nil {
+		return nil
+	}
+	return &placement
+}
+
+// GroupTaskCounts summarises a group's tasks from its open and done sets.
+// Open counts blocked tasks too.
+type GroupTaskCounts struct {
+	Total     int64 `json:"total"`
+	Open      int64 `json:"open"`
+	Done      int64 `json:"done"`
+	Cancelled int64 `json:"cancelled"`
+	Overdue   int64 `json:"overdue"`
+}
+
+// GetGroupTaskCounts counts a group's tasks without reading them
+func (r *RedisManager) GetGroupTaskCounts(groupID int) (*GroupTaskCounts, error) {
+	TaskWrites.Flush()
+
+	pipe := r.client.Pipeline()
+	total := pipe.SCard(r.ctx, groupTasksKey(groupID))
+	open := pipe.ZCard(r.ctx, groupOpenTasksKey(groupID))
+	done := pipe.ZCard(r.ctx, groupDoneTasksKey(groupID))
+	overdue := pipe.ZCount(r.ctx, groupOpenTasksKey(groupID), "-inf", fmt.Sprintf("(%d", todayDay()))
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return nil, err
+	}
+
+	return &GroupTaskCounts{
+		Total:     total.Val(),
+		Open:      open.Val(),
+		Done:      done.Val(),
+		Cancelled: total.Val() - open.Val() - done.Val(),
+		Overdue:   overdue.Val(),
+	}, nil
+}
+
+// GroupBoard is a group's tasks in columns. Open columns are ordered by
+// deadline and done by most recently completed.
+type GroupBoard struct {
+	Counts     *GroupTaskCounts `json:"counts"`
+	Overdue    []*models.Task   `json:"overdue"`
+	Upcoming   []*models.Task   `json:"upcoming"`
+	NoDeadline []*models.Task   `json:"no_deadline"`
+	Done       []*models.Task   `json:"done"`
+}
+
+// GetGroupBoard reads up to limit tasks per column from the group's sets
+func (r *RedisManager) GetGroupBoard(groupID, limit int) (*GroupBoard, error) {
+	counts, err := r.GetGroupTaskCounts(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := strconv.FormatInt(todayDay(), 10)
+	never := strconv.Itoa(noDeadlineDay)
+	openKey := groupOpenTasksKey(groupID)
+	pipe := r.client.Pipeline()
+	overdue := pipe.ZRangeByScore(r.ctx, openKey, &redis.ZRangeBy{Min: "-inf", Max: "(" + today, Count: int64(limit)})
+	upcoming := pipe.ZRangeByScore(r.ctx, openKey, &redis.ZRangeBy{Min: today, Max: "(" + never, Count: int64(limit)})
+	noDeadline := pipe.ZRangeByScore(r.ctx, openKey, &redis.ZRangeBy{Min: never, Max: never, Count: int64(limit)})
+	done := pipe.ZRevRange(r.ctx, groupDoneTasksKey(groupID), 0, int64(limit-1))
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return nil, err
+	}
+
+	board := &GroupBoard{Counts: counts}
+	for _, column := range []struct {
+		ids   []string
+		tasks *[]*models.Task
+	}{
+		{overdue.Val(), &board.Overdue},
+		{upcoming.Val(), &board.Upcoming},
+		{noDeadline.Val(), &board.NoDeadline},
+		{done.Val(), &board.Done},
+	} {
+		tasks, err := r.getTasksByID(column.ids)
+		if err != nil {
+			return nil, err
+		}
+		*column.tasks = tasks
+	}
+	return board, nil
+}
+
+// getTasksByID reads tasks in the order given, skipping any that are gone
+func (r *RedisManager) getTasksByID(ids []string) ([]*models.Task, error) {
+	tasks := make([]*models.Task, 0, len(ids))
+	if len(ids) == 0 {
+		return tasks, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = "task:" + id
+	}
+	values, err := r.client.MGet(r.ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var task models.Task
+		if err := json.Unmarshal([]byte(data), &task); err == nil {
+			tasks = append(tasks, &task)
+		}
+	}
+	return tasks, nil
+}
+
+// GetDirtyTaskGroups lists the groups whose tasks changed since the last sync
+func (r *RedisManager) GetDirtyTaskGroups() ([]int, error) {
+	members, err := r.client.SMembers(r.ctx, dirtyTaskGroupsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	groupIDs := make([]int, 0, len(members))
+	for _, member := range members {
+		if groupID, err := strconv.Atoi(member); err == nil {
+			groupIDs = append(groupIDs, groupID)
+		}
+	}
+	return groupIDs, nil
+}
+
+// ClearDirtyTaskGroups forgets the given groups once their tasks are synced
+func (r *RedisManager) ClearDirtyTaskGroups(groupIDs []int) error {
+	if len(groupIDs) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(groupIDs))
+	for i, groupID := range groupIDs {
+		members[i] = groupID
+	}
+	return r.client.SRem(r.ctx, dirtyTaskGroupsKey, members...).Err()
+}
+
+// EnsureGroupTaskIndexes builds the open and done sets of every group from
+// the stored tasks, once per Redis database, for data saved before tasks
+// were partitioned by status
+func (r *RedisManager) EnsureGroupTaskIndexes() error {
+	built, err := r.client.Exists(r.ctx, groupTaskIndexKey).Result()
+	if err != nil || built > 0 {
+		return err
+	}
+
+	indexed := 0
+	err = r.scanRecords("tasks:all", "task:%d", func(data []byte) error {
+		var task models.Task
+		if err := json.Unmarshal(data, &task); err != nil {
+			return nil
+		}
+		pipe := r.client.Pipeline()
+		r.indexGroupTask(pipe, &task)
+		if _, err := pipe.Exec(r.ctx); err != nil {
+			return err
+		}
+		indexed++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := r.client.Set(r.ctx, groupTaskIndexKey, time.Now().Unix(), 0).Err(); err != nil {
+		return err
+	}
+	if indexed > 0 {
+		redisLog.Info("📇 Built per-group task indexes", "tasks", indexed)
+	}
+	return nil
+}
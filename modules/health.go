@@ -0,0 +1,190 @@
+package modules
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Health states, from best to worst
+const (
+	HealthUp       = "up"
+	HealthDisabled = "disabled"
+	HealthDegraded = "degraded"
+	HealthDown     = "down"
+)
+
+// reportQueueBacklogLimit is the queued report count above which the job queue is degraded
+const reportQueueBacklogLimit = 100
+
+// DependencyHealth is the result of checking one dependency or subsystem
+type DependencyHealth struct {
+	Name      string                 `json:"name"`
+	Status    string                 `json:"status"`
+	Critical  bool                   `json:"critical"`
+	LatencyMs float64                `json:"latency_ms"`
+	Error     string                 `json:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// HealthReport aggregates dependency checks. Status is "healthy", "degraded" when a
+// non-critical dependency is failing, or "unhealthy" when a critical one is down.
+type HealthReport struct {
+	Status       string              `json:"status"`
+	Timestamp    time.Time           `json:"timestamp"`
+	Dependencies []*DependencyHealth `json:"dependencies"`
+}
+
+type healthCheck struct {
+	name     string
+	critical bool
+	run      func(result *DependencyHealth) error
+}
+
+// CheckHealth runs every dependency check concurrently
+func CheckHealth() *HealthReport {
+	checks := []healthCheck{
+		{name: "redis", critical: true, run: checkRedisHealth},
+		{name: "postgres", critical: true, run: checkPostgresHealth},
+		{name: "sync", run: checkSyncHealth},
+		{name: "email", run: checkEmailHealth},
+		{name: "report_queue", run: checkReportQueueHealth},
+		{name: "events", run: checkEventsHealth},
+	}
+
+	report := &HealthReport{
+		Status:       "healthy",
+		Timestamp:    time.Now(),
+		Dependencies: make([]*DependencyHealth, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check healthCheck) {
+			defer wg.Done()
+			report.Dependencies[i] = runHealthCheck(check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, dep := range report.Dependencies {
+		switch {
+		case dep.Status == HealthDown && dep.Critical:
+			report.Status = "unhealthy"
+		case (dep.Status == HealthDown || dep.Status == HealthDegraded) && report.Status == "healthy":
+			report.Status = "degraded"
+		}
+	}
+
+	return report
+}
+
+func runHealthCheck(check healthCheck) (result *DependencyHealth) {
+	result = &DependencyHealth{
+		Name:     check.name,
+		Status:   HealthUp,
+		Critical: check.critical,
+	}
+
+	start := time.Now()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result.Status = HealthDown
+			result.Error = fmt.Sprintf("check panicked: %v", recovered)
+		}
+		result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	}()
+
+	if err := check.run(result); err != nil {
+		result.Status = HealthDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func checkRedisHealth(result *DependencyHealth) error {
+	if RedisClient == nil {
+		return fmt.Errorf("not initialized")
+	}
+	return RedisClient.Ping()
+}
+
+func checkPostgresHealth(result *DependencyHealth) error {
+	if PostgresClient == nil {
+		return fmt.Errorf("not initialized")
+	}
+	return PostgresClient.Ping()
+}
+
+func checkSyncHealth(result *DependencyHealth) error {
+	if Syncer == nil {
+		return fmt.Errorf("not initialized")
+	}
+
+	result.Details = map[string]interface{}{"running": Syncer.running}
+	if lastSync, err := RedisClient.GetLastSyncTime(); err == nil && !lastSync.IsZero() {
+		result.Details["last_sync"] = lastSync
+	}
+
+	if !Syncer.running || !Syncer.IsHealthy() {
+		result.Status = HealthDegraded
+	}
+	return nil
+}
+
+func checkEmailHealth(result *DependencyHealth) error {
+	if !Mailer.Enabled() {
+		result.Status = HealthDisabled
+		return nil
+	}
+
+	result.Details = map[string]interface{}{"provider": Mailer.Provider()}
+	if PostgresClient != nil {
+		if retrying, err := PostgresClient.CountEmailLogsByStatus("retrying"); err == nil {
+			result.Details["retrying"] = retrying
+			if retrying > 0 {
+				result.Status = HealthDegraded
+			}
+		}
+	}
+
+	return Mailer.Check()
+}
+
+func checkReportQueueHealth(result *DependencyHealth) error {
+	if Reporter == nil {
+		result.Status = HealthDisabled
+		return nil
+	}
+
+	queued, err := RedisClient.ReportQueueLength()
+	if err != nil {
+		return err
+	}
+
+	result.Details = map[string]interface{}{
+		"running": Reporter.running,
+		"queued":  queued,
+	}
+	if !Reporter.running || queued > reportQueueBacklogLimit {
+		result.Status = HealthDegraded
+	}
+	return nil
+}
+
+func checkEventsHealth(result *DependencyHealth) error {
+	if Events == nil {
+		result.Status = HealthDisabled
+		return nil
+	}
+
+	result.Details = map[string]interface{}{
+		"running":     Events.running,
+		"subscribers": Events.SubscriberCount(),
+	}
+	if !Events.running {
+		result.Status = HealthDegraded
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package modules
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"task-manager/config"
+	"task-manager/models"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// OrgHolidayRegion holds the organization's own holidays, which apply to everyone
+const OrgHolidayRegion = "org"
+
+// HolidayDateLayout is the date format used for holidays
+const HolidayDateLayout = "2006-01-02"
+
+// Holiday is a day off in a region's calendar
+type Holiday struct {
+	Date    string `json:"date"`
+	Name    string `json:"name"`
+	Region  string `json:"region"`
+	BuiltIn bool   `json:"built_in"`
+}
+
+type fixedHoliday struct {
+	month time.Month
+	day   int
+	name  string
+}
+
+// builtInHolidays are fixed-date public holidays per region. Holidays that
+// move from year to year (Easter, lunar holidays) are not included and can
+// be added as custom holidays.
+var builtInHolidays = map[string][]fixedHoliday{
+	"us": {
+		{time.January, 1, "New Year's Day"},
+		{time.June, 19, "Juneteenth"},
+		{time.July, 4, "Independence Day"},
+		{time.November, 11, "Veterans Day"},
+		{time.December, 25, "Christmas Day"},
+	},
+	"gb": {
+		{time.January, 1, "New Year's Day"},
+		{time.December, 25, "Christmas Day"},
+		{time.December, 26, "Boxing Day"},
+	},
+	"de": {
+		{time.January, 1, "Neujahr"},
+		{time.May, 1, "Tag der Arbeit"},
+		{time.October, 3, "Tag der Deutschen Einheit"},
+		{time.December, 25, "1. Weihnachtstag"},
+		{time.December, 26, "2. Weihnachtstag"},
+	},
+	"fr": {
+		{time.January, 1, "Jour de l'an"},
+		{time.May, 1, "Fête du Travail"},
+		{time.May, 8, "Victoire 1945"},
+		{time.July, 14, "Fête nationale"},
+		{time.August, 15, "Assomption"},
+		{time.November, 1, "Toussaint"},
+		{time.November, 11, "Armistice 1918"},
+		{time.December, 25, "Noël"},
+	},
+}
+
+var regionPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,15}$`)
+
+// IsValidRegion reports whether region is a well-formed holiday region name
+func IsValidRegion(region string) bool {
+	return regionPattern.MatchString(region)
+}
+
+// BuiltInHolidayRegions lists the regions with a built-in holiday calendar
+func BuiltInHolidayRegions() []string {
+	regions := make([]string, 0, len(builtInHolidays))
+	for region := range builtInHolidays {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// UserHolidayRegion returns the holiday calendar a user follows
+func UserHolidayRegion(user *models.User) string {
+	if user.Region != "" {
+		return user.Region
+	}
+	return config.AppConfig.HolidayRegion
+}
+
+func holidaysKey(region string) string {
+	return "holidays:" + region
+}
+
+// GetCustomHolidays returns a region's custom holidays sorted by date
+func (r *RedisManager) GetCustomHolidays(region string) ([]*Holiday, error) {
+	entries, err := r.client.HGetAll(r.ctx, holidaysKey(region)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := make([]*Holiday, 0, len(entries))
+	for date, name := range entries {
+		holidays = append(holidays, &Holiday{Date: date, Name: name, Region: region})
+	}
+	sort.Slice(holidays, func(i, j int) bool {
+		return holidays[i].Date < holidays[j].Date
+	})
+	return holidays, nil
+}
+
+// GetCustomHolidayRegions lists the regions that have custom holidays
+func (r *RedisManager) GetCustomHolidayRegions() ([]string, error) {
+	return r.client.SMembers(r.ctx, "holidays:regions").Result()
+}
+
+// SetCustomHoliday adds or renames a custom holiday
+func (r *RedisManager) SetCustomHoliday(region, date, name string) error {
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(r.ctx, holidaysKey(region), date, name)
+		pipe.SAdd(r.ctx, "holidays:regions", region)
+		return nil
+	})
+	return err
+}
+
+// ReplaceCustomHolidays swaps a region's custom holidays for an uploaded set
+func (r *RedisManager) ReplaceCustomHolidays(region string, holidays []*Holiday) error {
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(r.ctx, holidaysKey(region))
+		if len(holidays) == 0 {
+			pipe.SRem(r.ctx, "holidays:regions", region)
+			return nil
+		}
+
+		values := make(map[string]interface{}, len(holidays))
+		for _, holiday := range holidays {
+			values[holiday.Date] = holiday.Name
+		}
+		pipe.HSet(r.ctx, holidaysKey(region), values)
+		pipe.SAdd(r.ctx, "holidays:regions", region)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisManager) DeleteCustomHoliday(region, date string) error {
+	removed, err := r.client.HDel(r.ctx, holidaysKey(region), date).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return fmt.Errorf("holiday %w", ErrNotFound)
+	}
+	return nil
+}
+
+// GetHolidays returns the days off between from and to (inclusive) for a
+// region: its built-in calendar, its custom holidays and the organization's
+// holidays. An empty region only gets the organization's holidays.
+func (r *RedisManager) GetHolidays(region string, from, to time.Time) ([]*Holiday, error) {
+	fromStr := from.Format(HolidayDateLayout)
+	toStr := to.Format(HolidayDateLayout)
+
+	var holidays []*Holiday
+	for year := from.Year(); year <= to.Year(); year++ {
+		for _, fixed := range builtInHolidays[region] {
+			date := time.Date(year, fixed.month, fixed.day, 0, 0, 0, 0, time.UTC).Format(HolidayDateLayout)
+			if date >= fromStr && date <= toStr {
+				holidays = append(holidays, &Holiday{Date: date, Name: fixed.name, Region: region, BuiltIn: true})
+			}
+		}
+	}
+
+	regions := []string{OrgHolidayRegion}
+	if region != "" && region != OrgHolidayRegion {
+		regions = append(regions, region)
+	}
+	for _, customRegion := range regions {
+		custom, err := r.GetCustomHolidays(customRegion)
+		if err != nil {
+			return nil, err
+		}
+		for _, holiday := range custom {
+			if holiday.Date >= fromStr && holiday.Date <= toStr {
+				holidays = append(holidays, holiday)
+			}
+		}
+	}
+
+	sort.SliceStable(holidays, func(i, j int) bool {
+		return holidays[i].Date < holidays[j].Date
+	})
+	return holidays, nil
+}
+
+// IsHoliday reports whether day is a holiday in the region's calendar
+func (r *RedisManager) IsHoliday(region string, day time.Time) bool {
+	holidays, err := r.GetHolidays(region, day, day)
+	return err == nil && len(holidays) > 0
+}
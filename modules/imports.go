@@ -0,0 +1,63 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// importTTL is how long an uploaded file waits for its column mapping
+const importTTL = time.Hour
+
+// Import is an uploaded CSV file waiting to be mapped and executed
+type Import struct {
+	ID        string     `json:"id"`
+	Filename  string     `json:"filename,omitempty"`
+	Delimiter string     `json:"delimiter"`
+	Columns   []string   `json:"columns"`
+	Rows      [][]string `json:"rows"`
+	CreatedBy int        `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+}
+
+func importKey(importID string) string {
+	return "import:" + importID
+}
+
+// CreateImport stores an uploaded file under a new random ID until importTTL passes
+func (r *RedisManager) CreateImport(imp *Import) error {
+	id, err := randomToken()
+	if err != nil {
+		return err
+	}
+	imp.ID = id[:24]
+	imp.CreatedAt = time.Now()
+	imp.ExpiresAt = imp.CreatedAt.Add(importTTL)
+
+	data, err := json.Marshal(imp)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(r.ctx, importKey(imp.ID), data, importTTL).Err()
+}
+
+func (r *RedisManager) GetImport(importID string) (*Import, error) {
+	data, err := r.client.Get(r.ctx, importKey(importID)).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("import %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var imp Import
+	err = json.Unmarshal([]byte(data), &imp)
+	return &imp, err
+}
+
+func (r *RedisManager) DeleteImport(importID string) error {
+	return r.client.Del(r.ctx, importKey(importID)).Err()
+}
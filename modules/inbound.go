@@ -0,0 +1,109 @@
+package modules
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"task-manager/config"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// emailAliasPrefix starts every group's inbound email alias, e.g. proj-3fa2c1
+const emailAliasPrefix = "proj-"
+
+func groupEmailAliasKey(groupID int) string {
+	return fmt.Sprintf("group:%d:email_alias", groupID)
+}
+
+func emailAliasKey(alias string) string {
+	return "email_alias:" + alias
+}
+
+// InboundEmailEnabled reports whether mail to group aliases is accepted
+func InboundEmailEnabled() bool {
+	return config.AppConfig.InboundEmailDomain != "" && config.AppConfig.InboundEmailSecret != ""
+}
+
+// ValidInboundSecret reports whether secret authenticates the email gateway
+func ValidInboundSecret(secret string) bool {
+	expected := config.AppConfig.InboundEmailSecret
+	return expected != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) == 1
+}
+
+// EmailAliasAddress is the full address of an alias at the inbound domain
+func EmailAliasAddress(alias string) string {
+	return alias + "@" + config.AppConfig.InboundEmailDomain
+}
+
+// GetGroupEmailAlias returns the group's alias, or "" when it has none
+func (r *RedisManager) GetGroupEmailAlias(groupID int) (string, error) {
+	alias, err := r.client.Get(r.ctx, groupEmailAliasKey(groupID)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return alias, err
+}
+
+// RotateGroupEmailAlias gives the group a new random alias, retiring the old
+// one so mail to it is no longer accepted
+func (r *RedisManager) RotateGroupEmailAlias(groupID int) (string, error) {
+	oldAlias, err := r.GetGroupEmailAlias(groupID)
+	if err != nil {
+		return "", err
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		b := make([]byte, 3)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		alias := emailAliasPrefix + hex.EncodeToString(b)
+
+		claimed, err := r.client.SetNX(r.ctx, emailAliasKey(alias), groupID, 0).Result()
+		if err != nil {
+			return "", err
+		}
+		if !claimed {
+			continue
+		}
+
+		_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+			if oldAlias != "" {
+				pipe.Del(r.ctx, emailAliasKey(oldAlias))
+			}
+			pipe.Set(r.ctx, groupEmailAliasKey(groupID), alias, 0)
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		return alias, nil
+	}
+	return "", fmt.Errorf("email alias %w: no free alias found", ErrConflict)
+}
+
+// DeleteGroupEmailAlias stops accepting mail for the group
+func (r *RedisManager) DeleteGroupEmailAlias(groupID int) error {
+	alias, err := r.GetGroupEmailAlias(groupID)
+	if err != nil || alias == "" {
+		return err
+	}
+
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(r.ctx, emailAliasKey(alias), groupEmailAliasKey(groupID))
+		return nil
+	})
+	return err
+}
+
+// GroupForEmailAlias returns the ID of the group an alias belongs to
+func (r *RedisManager) GroupForEmailAlias(alias string) (int, error) {
+	groupID, err := r.client.Get(r.ctx, emailAliasKey(strings.ToLower(alias))).Int()
+	if err == redis.Nil {
+		return 0, fmt.Errorf("email alias %w", ErrNotFound)
+	}
+	return groupID, err
+}
@@ -0,0 +1,184 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"task-manager/models"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// intakeLogLength is how many submissions each intake form's log keeps
+const intakeLogLength = 200
+
+func intakeFormKey(formID int) string {
+	return fmt.Sprintf("intake_form:%d", formID)
+}
+
+// intakeFormTokenKey holds the hash of a form's current token, so rotating
+// can drop the old token
+func intakeFormTokenKey(formID int) string {
+	return fmt.Sprintf("intake_form:%d:token", formID)
+}
+
+func intakeTokenKey(hash string) string {
+	return "token:intake:" + hash
+}
+
+func intakeSubmissionsKey(formID int) string {
+	return fmt.Sprintf("intake_form:%d:submissions", formID)
+}
+
+// Intake form operations
+func (r *RedisManager) SaveIntakeForm(form *models.IntakeForm) error {
+	formJSON, err := json.Marshal(form)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(r.ctx, intakeFormKey(form.ID), formJSON, 0)
+		pipe.SAdd(r.ctx, "intake_forms:all", form.ID)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisManager) GetIntakeForm(formID int) (*models.IntakeForm, error) {
+	formJSON, err := r.client.Get(r.ctx, intakeFormKey(formID)).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("intake form %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var form models.IntakeForm
+	err = json.Unmarshal([]byte(formJSON), &form)
+	return &form, err
+}
+
+func (r *RedisManager) GetAllIntakeForms() ([]*models.IntakeForm, error) {
+	formIDs, err := r.client.SMembers(r.ctx, "intake_forms:all").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var forms []*models.IntakeForm
+	for _, formIDStr := range formIDs {
+		formID, err := strconv.Atoi(formIDStr)
+		if err != nil {
+			continue
+		}
+
+		form, err := r.GetIntakeForm(formID)
+		if err == nil {
+			forms = append(forms, form)
+		}
+	}
+
+	return forms, nil
+}
+
+// DeleteIntakeForm removes a form together with its token and submissions log
+func (r *RedisManager) DeleteIntakeForm(formID int) error {
+	hash, err := r.client.Get(r.ctx, intakeFormTokenKey(formID)).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		if hash != "" {
+			pipe.Del(r.ctx, intakeTokenKey(hash))
+		}
+		pipe.Del(r.ctx, intakeFormKey(formID), intakeFormTokenKey(formID), intakeSubmissionsKey(formID))
+		pipe.SRem(r.ctx, "intake_forms:all", formID)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisManager) GetNextIntakeFormID() (int, error) {
+	id, err := r.client.Incr(r.ctx, "counter:intake_form_id").Result()
+	return int(id), err
+}
+
+// RotateIntakeFormToken issues a new submission token for a form, replacing
+// any previous one. Only a hash is stored, so the token is shown once.
+func (r *RedisManager) RotateIntakeFormToken(formID int) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	oldHash, err := r.client.Get(r.ctx, intakeFormTokenKey(formID)).Result()
+	if err != nil && err != redis.Nil {
+		return "", err
+	}
+
+	hash := hashToken(token)
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		if oldHash != "" {
+			pipe.Del(r.ctx, intakeTokenKey(oldHash))
+		}
+		pipe.Set(r.ctx, intakeTokenKey(hash), formID, 0)
+		pipe.Set(r.ctx, intakeFormTokenKey(formID), hash, 0)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// IntakeFormByToken returns the form a submission token belongs to
+func (r *RedisManager) IntakeFormByToken(token string) (*models.IntakeForm, error) {
+	formID, err := r.client.Get(r.ctx, intakeTokenKey(hashToken(token))).Int()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("intake form %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetIntakeForm(formID)
+}
+
+// HasIntakeFormToken reports whether a form can take submissions yet
+func (r *RedisManager) HasIntakeFormToken(formID int) (bool, error) {
+	count, err := r.client.Exists(r.ctx, intakeFormTokenKey(formID)).Result()
+	return count > 0, err
+}
+
+// LogIntakeSubmission records a submission, keeping the latest intakeLogLength
+func (r *RedisManager) LogIntakeSubmission(formID int, submission *models.IntakeSubmission) error {
+	data, err := json.Marshal(submission)
+	if err != nil {
+		return err
+	}
+
+	key := intakeSubmissionsKey(formID)
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(r.ctx, key, data)
+		pipe.LTrim(r.ctx, key, 0, intakeLogLength-1)
+		return nil
+	})
+	return err
+}
+
+// GetIntakeSubmissions returns a form's submissions log, newest first
+func (r *RedisManager) GetIntakeSubmissions(formID int) ([]*models.IntakeSubmission, error) {
+	entries, err := r.client.LRange(r.ctx, intakeSubmissionsKey(formID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	submissions := make([]*models.IntakeSubmission, 0, len(entries))
+	for _, entry := range entries {
+		var submission models.IntakeSubmission
+		if err := json.Unmarshal([]byte(entry), &submission); err == nil {
+			submissions = append(submissions, &submission)
+		}
+	}
+	return submissions, nil
+}
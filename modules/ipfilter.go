@@ -0,0 +1,311 @@
+package modules
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"task-manager/config"
+	"time"
+)
+
+// IP rule lists. The admin allowlist restricts /admin/ routes when it has
+// entries; the denylist blocks sources from every route.
+const (
+	AdminAllowList = "admin_allow"
+	DenyList       = "deny"
+)
+
+// ipRulesRefreshInterval is how often rules added on other replicas are picked up
+const ipRulesRefreshInterval = 10 * time.Second
+
+var ipFilterLog = Logger("ipfilter")
+
+// IPFilter holds the CIDR rules from config plus those added at runtime, which
+// are kept in Redis so every replica enforces them
+type IPFilter struct {
+	mu      sync.RWMutex
+	static  map[string][]netip.Prefix
+	runtime map[string][]netip.Prefix
+
+	stopChan chan bool
+	running  bool
+}
+
+var IPRules *IPFilter
+
+// InitIPFilter parses ADMIN_ALLOWLIST and IP_DENYLIST and loads runtime rules from Redis
+func InitIPFilter(cfg *config.Config) error {
+	if cfg == nil {
+		cfg = config.AppConfig
+	}
+
+	allow, err := ParseCIDRList(cfg.AdminAllowlist)
+	if err != nil {
+		return fmt.Errorf("ADMIN_ALLOWLIST: %v", err)
+	}
+	deny, err := ParseCIDRList(cfg.IPDenylist)
+	if err != nil {
+		return fmt.Errorf("IP_DENYLIST: %v", err)
+	}
+
+	IPRules = &IPFilter{
+		static:   map[string][]netip.Prefix{AdminAllowList: allow, DenyList: deny},
+		runtime:  map[string][]netip.Prefix{},
+		stopChan: make(chan bool),
+	}
+
+	if err := IPRules.refresh(); err != nil {
+		ipFilterLog.Warn("⚠️ Failed to load runtime IP rules", "error", err)
+	}
+	return nil
+}
+
+// Start periodically reloads runtime rules from Redis
+func (f *IPFilter) Start() {
+	if f == nil || f.running {
+		return
+	}
+
+	f.running = true
+	go f.refreshLoop()
+}
+
+// Stop ends the refresh loop
+func (f *IPFilter) Stop() {
+	if f == nil || !f.running {
+		return
+	}
+
+	f.stopChan <- true
+	f.running = false
+}
+
+func (f *IPFilter) refreshLoop() {
+	ticker := time.NewTicker(ipRulesRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := f.refresh(); err != nil {
+				ipFilterLog.Warn("⚠️ Failed to refresh IP rules", "error", err)
+			}
+		case <-f.stopChan:
+			return
+		}
+	}
+}
+
+func (f *IPFilter) refresh() error {
+	runtime := make(map[string][]netip.Prefix)
+	for _, list := range []string{AdminAllowList, DenyList} {
+		entries, err := RedisClient.GetIPRules(list)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			prefix, err := ParseCIDR(entry)
+			if err != nil {
+				continue
+			}
+			runtime[list] = append(runtime[list], prefix)
+		}
+	}
+
+	f.mu.Lock()
+	f.runtime = runtime
+	f.mu.Unlock()
+	return nil
+}
+
+// Add stores a runtime rule and applies it on this replica right away
+func (f *IPFilter) Add(list, cidr string) (string, error) {
+	prefix, err := f.validate(list, cidr)
+	if err != nil {
+		return "", err
+	}
+	if err := RedisClient.AddIPRule(list, prefix.String()); err != nil {
+		return "", err
+	}
+	return prefix.String(), f.refresh()
+}
+
+// Remove deletes a runtime rule; rules from config can only be changed in config
+func (f *IPFilter) Remove(list, cidr string) (string, error) {
+	prefix, err := f.validate(list, cidr)
+	if err != nil {
+		return "", err
+	}
+
+	removed, err := RedisClient.RemoveIPRule(list, prefix.String())
+	if err != nil {
+		return "", err
+	}
+	if !removed {
+		return "", fmt.Errorf("runtime rule %s %w", prefix, ErrNotFound)
+	}
+	return prefix.String(), f.refresh()
+}
+
+func (f *IPFilter) validate(list, cidr string) (netip.Prefix, error) {
+	if list != AdminAllowList && list != DenyList {
+		return netip.Prefix{}, fmt.Errorf("%w: list must be %s or %s", ErrValidation, AdminAllowList, DenyList)
+	}
+	prefix, err := ParseCIDR(cidr)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return prefix, nil
+}
+
+// Rules returns every rule grouped by list and by where it was configured
+func (f *IPFilter) Rules() map[string]map[string][]string {
+	rules := make(map[string]map[string][]string)
+	if f == nil {
+		return rules
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, list := range []string{AdminAllowList, DenyList} {
+		rules[list] = map[string][]string{
+			"config":  prefixStrings(f.static[list]),
+			"runtime": prefixStrings(f.runtime[list]),
+		}
+	}
+	return rules
+}
+
+// Denied reports whether the IP is on the denylist
+func (f *IPFilter) Denied(ip netip.Addr) bool {
+	return f.matches(DenyList, ip)
+}
+
+// AdminAllowed reports whether the IP may reach admin routes; an empty allowlist allows everyone
+func (f *IPFilter) AdminAllowed(ip netip.Addr) bool {
+	f.mu.RLock()
+	empty := len(f.static[AdminAllowList]) == 0 && len(f.runtime[AdminAllowList]) == 0
+	f.mu.RUnlock()
+
+	return empty || f.matches(AdminAllowList, ip)
+}
+
+// AdminReachableFrom reports whether the request's source passes both lists for admin routes
+func (f *IPFilter) AdminReachableFrom(r *http.Request) bool {
+	ip, err := netip.ParseAddr(ClientIP(r))
+	if err != nil {
+		return true
+	}
+	ip = ip.Unmap()
+	return !f.Denied(ip) && f.AdminAllowed(ip)
+}
+
+func (f *IPFilter) matches(list string, ip netip.Addr) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, prefixes := range [][]netip.Prefix{f.static[list], f.runtime[list]} {
+		for _, prefix := range prefixes {
+			if prefix.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IPFilterMiddleware rejects denylisted sources and keeps admin routes to the
+// allowlist. It runs before authentication and rate limiting so blocked
+// sources cost no lookups.
+func IPFilterMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IPRules == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip, err := netip.ParseAddr(ClientIP(r))
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ip = ip.Unmap()
+
+		if IPRules.Denied(ip) {
+			ipFilterLog.InfoContext(r.Context(), "🚫 Denied request from blocked IP", "ip", ip.String(), "path", r.URL.Path)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/admin/") && !IPRules.AdminAllowed(ip) {
+			ipFilterLog.InfoContext(r.Context(), "🚫 Denied admin request from IP outside allowlist", "ip", ip.String(), "path", r.URL.Path)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ParseCIDR parses a CIDR or a single address, which becomes a /32 or /128
+func ParseCIDR(value string) (netip.Prefix, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid CIDR %q", value)
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address %q", value)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ParseCIDRList parses comma-separated CIDRs or addresses
+func ParseCIDRList(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		prefix, err := ParseCIDR(entry)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+func prefixStrings(prefixes []netip.Prefix) []string {
+	result := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		result = append(result, prefix.String())
+	}
+	sort.Strings(result)
+	return result
+}
+
+// GetIPRules returns the runtime rules stored for a list
+func (r *RedisManager) GetIPRules(list string) ([]string, error) {
+	return r.client.SMembers(r.ctx, fmt.Sprintf("iprules:%s", list)).Result()
+}
+
+// AddIPRule stores a runtime rule
+func (r *RedisManager) AddIPRule(list, cidr string) error {
+	return r.client.SAdd(r.ctx, fmt.Sprintf("iprules:%s", list), cidr).Err()
+}
+
+// RemoveIPRule deletes a runtime rule, reporting whether it existed
+func (r *RedisManager) RemoveIPRule(list, cidr string) (bool, error) {
+	removed, err := r.client.SRem(r.ctx, fmt.Sprintf("iprules:%s", list), cidr).Result()
+	return removed > 0, err
+}
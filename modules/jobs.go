@@ -0,0 +1,368 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"task-manager/config"
+	"time"
+)
+
+// ErrJobRunning means a job was asked to run while it still was
+var ErrJobRunning = errors.New("job is already running")
+
+// jobTick is how often the scheduler looks for due jobs, the finest
+// schedule it keeps to
+const jobTick = time.Second
+
+var jobsLog = Logger("jobs")
+
+// JobSpec describes a periodic job. Schedule is a cron expression, a macro
+// such as @daily, "@every 30s" or "off" for manual runs only. Run reports
+// failures as errors; a job that must run on one replica at a time takes
+// its own lock.
+type JobSpec struct {
+	Name       string
+	Schedule   string
+	RunAtStart bool
+	Run        func() error
+}
+
+// jobSchedule is when a job runs next after a given time
+type jobSchedule interface {
+	Next(after time.Time) time.Time
+}
+
+// everySchedule runs a job at a fixed interval
+type everySchedule time.Duration
+
+func (e everySchedule) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+// EverySchedule formats an interval as a job schedule, "off" for 0
+func EverySchedule(interval time.Duration) string {
+	if interval <= 0 {
+		return "off"
+	}
+	return "@every " + interval.String()
+}
+
+// parseJobSchedule parses a job schedule; it is nil for "off"
+func parseJobSchedule(spec string) (jobSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "off" {
+		return nil, nil
+	}
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil || d < jobTick {
+			return nil, fmt.Errorf("invalid interval %q, must be at least %s", interval, jobTick)
+		}
+		return everySchedule(d), nil
+	}
+	return ParseCron(spec)
+}
+
+// JobRun is one run of a job
+type JobRun struct {
+	Trigger    string     `json:"trigger"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// JobStatus is a job's schedule and recent runs on this instance
+type JobStatus struct {
+	Name     string     `json:"name"`
+	Schedule string     `json:"schedule"`
+	Running  bool       `json:"running"`
+	LastRun  *JobRun    `json:"last_run,omitempty"`
+	NextRun  *time.Time `json:"next_run,omitempty"`
+	Runs     uint64     `json:"runs_total"`
+	Failures uint64     `json:"failures_total"`
+	Skipped  uint64     `json:"skipped_total"`
+}
+
+type job struct {
+	JobSpec
+	schedule jobSchedule
+
+	mu       sync.Mutex
+	running  bool
+	next     time.Time
+	lastRun  *JobRun
+	runs     uint64
+	failures uint64
+	skipped  uint64
+}
+
+// JobScheduler runs the periodic jobs of every service from one loop. A job
+// never overlaps itself: a run that comes due while the last one is still
+// going is skipped. Each replica schedules its own runs.
+type JobScheduler struct {
+	mu        sync.Mutex
+	jobs      []*job
+	overrides map[string]string
+
+	stopChan chan bool
+	running  bool
+	wg       sync.WaitGroup
+}
+
+// Jobs starts empty so services can register jobs before InitJobs, as in
+// CLI commands, which never start it
+var Jobs = &JobScheduler{stopChan: make(chan bool)}
+
+// InitJobs reads JOB_SCHEDULES, "name=schedule" entries separated by
+// semicolons that replace the schedules jobs register with
+func InitJobs(cfg *config.Config) error {
+	if cfg == nil {
+		cfg = config.AppConfig
+	}
+
+	overrides := make(map[string]string)
+	for _, entry := range strings.Split(cfg.JobSchedules, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(entry, "=")
+		name, spec = strings.TrimSpace(name), strings.TrimSpace(spec)
+		if !ok || name == "" {
+			return fmt.Errorf("JOB_SCHEDULES entry %q: expected name=schedule", entry)
+		}
+		if _, err := parseJobSchedule(spec); err != nil {
+			return fmt.Errorf("JOB_SCHEDULES entry %q: %v", entry, err)
+		}
+		overrides[name] = spec
+	}
+
+	Jobs.mu.Lock()
+	defer Jobs.mu.Unlock()
+	Jobs.overrides = overrides
+	return nil
+}
+
+// Register adds a job, using the schedule JOB_SCHEDULES gives it if any.
+// A schedule that does not parse leaves the job to manual runs.
+func (s *JobScheduler) Register(spec JobSpec) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if override, ok := s.overrides[spec.Name]; ok {
+		spec.Schedule = override
+	}
+	schedule, err := parseJobSchedule(spec.Schedule)
+	if err != nil {
+		jobsLog.Warn("⚠️ Invalid job schedule, job only runs on request", "job", spec.Name, "schedule", spec.Schedule, "error", err)
+		spec.Schedule = "off"
+	}
+	if schedule == nil {
+		spec.Schedule = "off"
+	}
+
+	for i, existing := range s.jobs {
+		if existing.Name == spec.Name {
+			s.jobs[i] = &job{JobSpec: spec, schedule: schedule}
+			return
+		}
+	}
+	s.jobs = append(s.jobs, &job{JobSpec: spec, schedule: schedule})
+}
+
+func (s *JobScheduler) find(name string) *job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.Name == name {
+			return j
+		}
+	}
+	return nil
+}
+
+func (s *JobScheduler) Start() {
+	if s.running {
+		return
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	for name := range s.overrides {
+		found := false
+		for _, j := range s.jobs {
+			found = found || j.Name == name
+		}
+		if !found {
+			jobsLog.Warn("⚠️ JOB_SCHEDULES names an unknown job", "job", name)
+		}
+	}
+	for _, j := range s.jobs {
+		j.mu.Lock()
+		if j.schedule != nil {
+			j.next = j.nextAfter(now)
+		}
+		j.mu.Unlock()
+	}
+	jobs := append([]*job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	s.running = true
+	for _, j := range jobs {
+		if j.RunAtStart {
+			s.launch(j, "start")
+		}
+	}
+	s.wg.Add(1)
+	go s.loop()
+	fmt.Printf("🗓️ Job scheduler started (%d jobs)\n", len(jobs))
+}
+
+func (s *JobScheduler) Stop() {
+	if !s.running {
+		return
+	}
+
+	close(s.stopChan)
+	s.running = false
+}
+
+// Drain waits for running jobs to finish after Stop
+func (s *JobScheduler) Drain(ctx context.Context) error {
+	return waitForGroup(ctx, &s.wg)
+}
+
+func (s *JobScheduler) loop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(jobTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			s.mu.Lock()
+			jobs := append([]*job(nil), s.jobs...)
+			s.mu.Unlock()
+
+			for _, j := range jobs {
+				j.mu.Lock()
+				due := !j.next.IsZero() && !now.Before(j.next)
+				if due {
+					j.next = j.nextAfter(now)
+				}
+				j.mu.Unlock()
+				if due {
+					s.launch(j, "schedule")
+				}
+			}
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// nextAfter is when the job is next due; cron schedules are read in the
+// organization's timezone
+func (j *job) nextAfter(now time.Time) time.Time {
+	return j.schedule.Next(now.In(OrgSettings().Location()))
+}
+
+// launch starts a run in the background unless the job is already running,
+// counting scheduled runs that are skipped
+func (s *JobScheduler) launch(j *job, trigger string) error {
+	j.mu.Lock()
+	if j.running {
+		if trigger != "manual" {
+			j.skipped++
+		}
+		j.mu.Unlock()
+		jobsLog.Debug("Job still running, run skipped", "job", j.Name, "trigger", trigger)
+		return ErrJobRunning
+	}
+	j.running = true
+	run := &JobRun{Trigger: trigger, StartedAt: time.Now()}
+	j.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := j.call()
+
+		finished := time.Now()
+		run.FinishedAt = &finished
+		run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+		if err != nil {
+			run.Error = err.Error()
+			jobsLog.Error("❌ Job failed", "job", j.Name, "trigger", trigger, "error", err)
+		}
+
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		j.running = false
+		j.lastRun = run
+		j.runs++
+		if err != nil {
+			j.failures++
+		}
+	}()
+	return nil
+}
+
+// call runs the job, turning a panic into an error so the scheduler lives on
+func (j *job) call() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return j.Run()
+}
+
+// Trigger runs a job now, outside its schedule. It fails with ErrNotFound
+// for an unknown job and ErrJobRunning while the job runs.
+func (s *JobScheduler) Trigger(name string) error {
+	j := s.find(name)
+	if j == nil {
+		return fmt.Errorf("job %q %w", name, ErrNotFound)
+	}
+	return s.launch(j, "manual")
+}
+
+// Status lists every job, by name
+func (s *JobScheduler) Status() []*JobStatus {
+	s.mu.Lock()
+	jobs := append([]*job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	statuses := make([]*JobStatus, 0, len(jobs))
+	for _, j := range jobs {
+		j.mu.Lock()
+		status := &JobStatus{
+			Name:     j.Name,
+			Schedule: j.Schedule,
+			Running:  j.running,
+			Runs:     j.runs,
+			Failures: j.failures,
+			Skipped:  j.skipped,
+		}
+		if j.lastRun != nil {
+			last := *j.lastRun
+			status.LastRun = &last
+		}
+		if !j.next.IsZero() {
+			next := j.next
+			status.NextRun = &next
+		}
+		j.mu.Unlock()
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, k int) bool {
+		return statuses[i].Name < statuses[k].Name
+	})
+	return statuses
+}
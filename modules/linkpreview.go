@@ -0,0 +1,408 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"task-manager/config"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/net/html"
+)
+
+const (
+	// maxLinkPreviews caps how many links of one text are previewed
+	maxLinkPreviews = 5
+
+	// maxLinkPreviewPage is how much of a page is read looking for its tags
+	maxLinkPreviewPage = 512 << 10
+
+	linkPreviewTimeout   = 5 * time.Second
+	linkPreviewRedirects = 3
+
+	// linkPreviewRetry is how long a page that could not be read is left
+	// before it is fetched again
+	linkPreviewRetry = time.Hour
+
+	// linkPreviewFetchers bounds the pages fetched at once; links beyond
+	// that wait for a later read
+	linkPreviewFetchers = 4
+)
+
+var linkPattern = regexp.MustCompile("https?://[^\\s<>\"'`]+")
+
+// errAddressNotPublic is returned for links to loopback, private and other
+// internal addresses, which are never fetched
+var errAddressNotPublic = errors.New("address is not public")
+
+// nonPublicNetworks are reserved ranges net.IP does not already count as
+// private or local
+var nonPublicNetworks = parseNetworks("0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "64:ff9b::/96")
+
+var previewLog = Logger("previews")
+
+func linkPreviewKey(link string) string {
+	return "link_preview:" + hashToken(link)
+}
+
+// LinkPreview is what a linked page says about itself in its Open Graph
+// tags, or its title and description
+type LinkPreview struct {
+	URL         string    `json:"url"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Image       string    `json:"image,omitempty"`
+	SiteName    string    `json:"site_name,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// empty previews are kept for pages that could not be read or say nothing,
+// so they are not fetched on every read
+func (p *LinkPreview) empty() bool {
+	return p.Title == "" && p.Description == "" && p.Image == ""
+}
+
+// LinkPreviewer fetches previews of links in the background and keeps them
+// in Redis. Pages are only fetched from public addresses on ports 80 and
+// 443, checked on every connection so redirects and DNS changes cannot
+// reach internal services.
+type LinkPreviewer struct {
+	client *http.Client
+	ttl    time.Duration
+	slots  chan struct{}
+
+	mu       sync.Mutex
+	fetching map[string]bool
+}
+
+// LinkPreviews is nil while link previews are off
+var LinkPreviews *LinkPreviewer
+
+func InitLinkPreviews(cfg *config.Config) {
+	if cfg == nil {
+		cfg = config.AppConfig
+	}
+	if !cfg.LinkPreviews {
+		LinkPreviews = nil
+		return
+	}
+
+	dialer := &net.Dialer{Timeout: linkPreviewTimeout, Control: dialPublicOnly}
+	// No proxy: it would make the connections itself, past the address check
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   linkPreviewTimeout,
+		ResponseHeaderTimeout: linkPreviewTimeout,
+		MaxIdleConns:          linkPreviewFetchers,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	LinkPreviews = &LinkPreviewer{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   linkPreviewTimeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= linkPreviewRedirects {
+					return fmt.Errorf("stopped after %d redirects", linkPreviewRedirects)
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return fmt.Errorf("redirect to %s link", req.URL.Scheme)
+				}
+				return nil
+			},
+		},
+		ttl:      cfg.LinkPreviewTTL,
+		slots:    make(chan struct{}, linkPreviewFetchers),
+		fetching: make(map[string]bool),
+	}
+}
+
+// dialPublicOnly refuses connections to addresses that are not public, once
+// the host name has been resolved
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if port != "80" && port != "443" {
+		return fmt.Errorf("port %s: %w", port, errAddressNotPublic)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%s: %w", host, errAddressNotPublic)
+	}
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// ExtractLinks finds the first maxLinkPreviews distinct http and https
+// links in text, leaving off punctuation that ends a sentence
+func ExtractLinks(text string) []string {
+	var links []string
+	seen := make(map[string]bool)
+	for _, match := range linkPattern.FindAllString(text, -1) {
+		link := strings.TrimRight(match, ".,;:!?)]}")
+		parsed, err := url.Parse(link)
+		if err != nil || parsed.Host == "" || seen[link] {
+			continue
+		}
+		seen[link] = true
+		links = append(links, link)
+		if len(links) == maxLinkPreviews {
+			break
+		}
+	}
+	return links
+}
+
+// Previews returns the previews of the links in text that have been
+// fetched, in order, and fetches the rest in the background for a later read
+func (p *LinkPreviewer) Previews(text string) []*LinkPreview {
+	if p == nil {
+		return nil
+	}
+	links := ExtractLinks(text)
+	if len(links) == 0 {
+		return nil
+	}
+
+	cached, err := RedisClient.getLinkPreviews(links)
+	if err != nil {
+		previewLog.Warn("⚠️ Failed to read link previews", "error", err)
+		return nil
+	}
+
+	var previews []*LinkPreview
+	for _, link := range links {
+		preview, ok := cached[link]
+		if !ok {
+			p.fetchLater(link)
+			continue
+		}
+		if !preview.empty() {
+			previews = append(previews, preview)
+		}
+	}
+	return previews
+}
+
+// fetchLater fetches a link's preview in the background unless it already
+// is being fetched or every fetcher is busy
+func (p *LinkPreviewer) fetchLater(link string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fetching[link] {
+		return
+	}
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		return
+	}
+	p.fetching[link] = true
+
+	go func() {
+		defer func() {
+			p.mu.Lock()
+			delete(p.fetching, link)
+			p.mu.Unlock()
+			<-p.slots
+		}()
+
+		ttl := p.ttl
+		preview, err := p.fetch(link)
+		if err != nil {
+			previewLog.Debug("Link preview failed", "url", link, "error", err)
+			preview, ttl = &LinkPreview{URL: link, FetchedAt: time.Now()}, linkPreviewRetry
+		}
+		if err := RedisClient.saveLinkPreview(preview, ttl); err != nil {
+			previewLog.Warn("⚠️ Failed to save link preview", "url", link, "error", err)
+		}
+	}()
+}
+
+// fetch reads a page's preview from the tags in its head
+func (p *LinkPreviewer) fetch(link string) (*LinkPreview, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), linkPreviewTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", link, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "gask-link-preview/1.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	preview := &LinkPreview{URL: link, FetchedAt: time.Now()}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return preview, nil
+	}
+
+	readPreviewTags(preview, io.LimitReader(resp.Body, maxLinkPreviewPage), resp.Request.URL)
+	return preview, nil
+}
+
+// readPreviewTags fills a preview from a page's Open Graph tags, falling
+// back to its title and description
+func readPreviewTags(preview *LinkPreview, page io.Reader, base *url.URL) {
+	title, description := readHeadTags(preview, page, base)
+	if preview.Title == "" {
+		preview.Title = title
+	}
+	if preview.Description == "" {
+		preview.Description = description
+	}
+	preview.Title = previewText(preview.Title, 300)
+	preview.Description = previewText(preview.Description, 500)
+	preview.SiteName = previewText(preview.SiteName, 100)
+}
+
+// readHeadTags sets what a page's Open Graph tags say and returns its
+// title and description, stopping at the end of the head
+func readHeadTags(preview *LinkPreview, page io.Reader, base *url.URL) (title, description string) {
+	inTitle := false
+
+	tokens := html.NewTokenizer(page)
+	for {
+		switch tokens.Next() {
+		case html.ErrorToken:
+			return title, description
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokens.TagName()
+			switch string(name) {
+			case "body":
+				return title, description
+			case "title":
+				inTitle = true
+			case "meta":
+				var property, content string
+				for hasAttr {
+					var key, value []byte
+					key, value, hasAttr = tokens.TagAttr()
+					switch string(key) {
+					case "property", "name":
+						property = strings.ToLower(string(value))
+					case "content":
+						content = string(value)
+					}
+				}
+				switch property {
+				case "og:title":
+					preview.Title = content
+				case "og:description":
+					preview.Description = content
+				case "description":
+					description = content
+				case "og:site_name":
+					preview.SiteName = content
+				case "og:image":
+					if image, err := base.Parse(content); err == nil && (image.Scheme == "http" || image.Scheme == "https") {
+						preview.Image = image.String()
+					}
+				}
+			}
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = string(tokens.Text())
+			}
+		case html.EndTagToken:
+			name, _ := tokens.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return title, description
+			}
+		}
+	}
+}
+
+// previewText collapses whitespace and cuts text to at most limit runes
+func previewText(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
+
+// getLinkPreviews reads the cached previews of links, by link
+func (r *RedisManager) getLinkPreviews(links []string) (map[string]*LinkPreview, error) {
+	keys := make([]string, len(links))
+	for i, link := range links {
+		keys[i] = linkPreviewKey(link)
+	}
+	values, err := r.client.MGet(r.ctx, keys...).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	previews := make(map[string]*LinkPreview, len(links))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var preview LinkPreview
+		if json.Unmarshal([]byte(data), &preview) == nil {
+			previews[links[i]] = &preview
+		}
+	}
+	return previews, nil
+}
+
+func (r *RedisManager) saveLinkPreview(preview *LinkPreview, ttl time.Duration) error {
+	data, err := json.Marshal(preview)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(r.ctx, linkPreviewKey(preview.URL), data, ttl).Err()
+}
@@ -0,0 +1,92 @@
+package modules
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrLockNotAcquired is returned when another holder owns the lock
+var ErrLockNotAcquired = fmt.Errorf("%w: lock is held by another process", ErrConflict)
+
+// releaseLockScript deletes the lock only if it still belongs to the caller,
+// so a holder whose lock expired cannot release someone else's
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Lock is a held distributed lock; it expires on its own after its TTL
+type Lock struct {
+	key   string
+	token string
+}
+
+var lockStats struct {
+	acquired  int64
+	contended int64
+	expired   int64
+}
+
+// AcquireLock takes the named lock for at most ttl without waiting
+func (r *RedisManager) AcquireLock(name string, ttl time.Duration) (*Lock, error) {
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, err
+	}
+
+	lock := &Lock{
+		key:   fmt.Sprintf("lock:%s", name),
+		token: hex.EncodeToString(tokenBytes),
+	}
+
+	ok, err := r.client.SetNX(r.ctx, lock.key, lock.token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		atomic.AddInt64(&lockStats.contended, 1)
+		return nil, ErrLockNotAcquired
+	}
+
+	atomic.AddInt64(&lockStats.acquired, 1)
+	return lock, nil
+}
+
+// ReleaseLock frees a lock taken with AcquireLock
+func (r *RedisManager) ReleaseLock(lock *Lock) error {
+	released, err := releaseLockScript.Run(r.ctx, r.client, []string{lock.key}, lock.token).Int()
+	if err != nil {
+		return err
+	}
+	if released == 0 {
+		// The TTL ran out before the work finished
+		atomic.AddInt64(&lockStats.expired, 1)
+	}
+	return nil
+}
+
+// WithLock runs fn while holding the named lock, returning ErrLockNotAcquired if it is taken
+func (r *RedisManager) WithLock(name string, ttl time.Duration, fn func() error) error {
+	lock, err := r.AcquireLock(name, ttl)
+	if err != nil {
+		return err
+	}
+	defer r.ReleaseLock(lock)
+
+	return fn()
+}
+
+// GetLockStats returns lock counters for this process
+func GetLockStats() map[string]int64 {
+	return map[string]int64{
+		"acquired":  atomic.LoadInt64(&lockStats.acquired),
+		"contended": atomic.LoadInt64(&lockStats.contended),
+		"expired":   atomic.LoadInt64(&lockStats.expired),
+	}
+}
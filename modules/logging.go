@@ -0,0 +1,194 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"task-manager/config"
+)
+
+// defaultLogModule holds the level used by modules without their own setting
+const defaultLogModule = "default"
+
+// logState is the process-wide logging configuration; module loggers read it on every
+// record so levels can be changed at runtime
+var logState = struct {
+	mu       sync.RWMutex
+	handler  slog.Handler
+	levels   map[string]slog.Level
+	sampling map[string]uint64
+	counters sync.Map // module -> *uint64
+}{
+	handler:  slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	levels:   map[string]slog.Level{defaultLogModule: slog.LevelInfo},
+	sampling: map[string]uint64{},
+}
+
+// InitLogging configures the log format, per-module levels and info sampling.
+// The standard log package is routed through the "app" module.
+func InitLogging(cfg *config.Config) error {
+	defaultLevel, err := parseLogLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
+
+	levels := map[string]slog.Level{defaultLogModule: defaultLevel}
+	for module, value := range parseLogPairs(cfg.LogModules) {
+		level, err := parseLogLevel(value)
+		if err != nil {
+			return fmt.Errorf("LOG_MODULES: %v", err)
+		}
+		levels[module] = level
+	}
+
+	sampling := make(map[string]uint64)
+	for module, value := range parseLogPairs(cfg.LogSampling) {
+		every, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || every == 0 {
+			return fmt.Errorf("LOG_SAMPLING: invalid rate %q for %s", value, module)
+		}
+		sampling[module] = every
+	}
+
+	format := cfg.LogFormat
+	if format == "" {
+		format = "text"
+		if cfg.Environment == "production" {
+			format = "json"
+		}
+	}
+
+	// Filtering happens per module, so the shared handler accepts every level
+	options := &slog.HandlerOptions{Level: slog.LevelDebug}
+
+	var handler slog.Handler
+	switch format {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, options)
+	default:
+		return fmt.Errorf("invalid LOG_FORMAT %q, must be 'json' or 'text'", format)
+	}
+
+	logState.mu.Lock()
+	logState.handler = handler
+	logState.levels = levels
+	logState.sampling = sampling
+	logState.mu.Unlock()
+
+	slog.SetDefault(Logger("app"))
+	return nil
+}
+
+// Logger returns a structured logger tagged with the given module
+func Logger(module string) *slog.Logger {
+	return slog.New(&moduleHandler{module: module})
+}
+
+// SetLogLevel changes the level of one module, or of the default when module is empty
+func SetLogLevel(module, value string) error {
+	level, err := parseLogLevel(value)
+	if err != nil {
+		return err
+	}
+	if module == "" {
+		module = defaultLogModule
+	}
+
+	logState.mu.Lock()
+	logState.levels[module] = level
+	logState.mu.Unlock()
+	return nil
+}
+
+// GetLogLevels returns the configured level of every module
+func GetLogLevels() map[string]string {
+	logState.mu.RLock()
+	defer logState.mu.RUnlock()
+
+	levels := make(map[string]string, len(logState.levels))
+	for module, level := range logState.levels {
+		levels[module] = strings.ToLower(level.String())
+	}
+	return levels
+}
+
+func parseLogLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		return level, fmt.Errorf("invalid log level %q, must be debug, info, warn or error", value)
+	}
+	return level, nil
+}
+
+// parseLogPairs parses "module=value,module=value" settings
+func parseLogPairs(value string) map[string]string {
+	pairs := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		module, setting, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && module != "" {
+			pairs[strings.TrimSpace(module)] = strings.TrimSpace(setting)
+		}
+	}
+	return pairs
+}
+
+// moduleHandler applies module levels and sampling before handing records to the shared handler
+type moduleHandler struct {
+	module string
+	wraps  []func(slog.Handler) slog.Handler // WithAttrs/WithGroup calls, in order
+}
+
+func (h *moduleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	logState.mu.RLock()
+	defer logState.mu.RUnlock()
+
+	minLevel, ok := logState.levels[h.module]
+	if !ok {
+		minLevel = logState.levels[defaultLogModule]
+	}
+	return level >= minLevel
+}
+
+func (h *moduleHandler) Handle(ctx context.Context, record slog.Record) error {
+	logState.mu.RLock()
+	handler := logState.handler
+	every := logState.sampling[h.module]
+	logState.mu.RUnlock()
+
+	// Keep one in every N info-or-lower records of sampled modules; warnings always pass
+	if every > 1 && record.Level <= slog.LevelInfo {
+		counter, _ := logState.counters.LoadOrStore(h.module, new(uint64))
+		if atomic.AddUint64(counter.(*uint64), 1)%every != 1 {
+			return nil
+		}
+	}
+
+	handler = handler.WithAttrs([]slog.Attr{slog.String("module", h.module)})
+	if scope := GetRequestScope(ctx); scope != nil {
+		handler = handler.WithAttrs(scope.logAttrs())
+	}
+	for _, wrap := range h.wraps {
+		handler = wrap(handler)
+	}
+	return handler.Handle(ctx, record)
+}
+
+func (h *moduleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *moduleHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *moduleHandler) with(wrap func(slog.Handler) slog.Handler) slog.Handler {
+	wraps := append(append([]func(slog.Handler) slog.Handler{}, h.wraps...), wrap)
+	return &moduleHandler{module: h.module, wraps: wraps}
+}
@@ -0,0 +1,117 @@
+package modules
+
+import (
+	"fmt"
+	"task-manager/models"
+	"time"
+)
+
+// UserMerge records what a merge moved from a duplicate account to the
+// canonical one
+type UserMerge struct {
+	SourceUserID int   `json:"source_user_id"`
+	TargetUserID int   `json:"target_user_id"`
+	Tasks        int   `json:"tasks"`
+	Leaves       int   `json:"leaves"`
+	GroupIDs     []int `json:"group_ids"`
+	AdminGroups  []int `json:"admin_groups"`
+	Promoted     bool  `json:"promoted"`
+}
+
+// MergeUsers moves the tasks, leave requests, group memberships and group
+// admin roles of source to target, then deactivates source. A target that
+// takes over a group's admin role is promoted to group_admin if needed.
+func (r *RedisManager) MergeUsers(source, target *models.User) (*UserMerge, error) {
+	merge := &UserMerge{
+		SourceUserID: source.ID,
+		TargetUserID: target.ID,
+		GroupIDs:     []int{},
+		AdminGroups:  []int{},
+	}
+
+	tasks, err := r.GetUserTasks(source.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		r.client.SRem(r.ctx, fmt.Sprintf("user:%d:tasks", source.ID), task.ID)
+		task.UserID = target.ID
+		task.SetCollaborators(task.Collaborators)
+		task.UpdatedAt = time.Now()
+		if err := r.SaveTask(task); err != nil {
+			return merge, err
+		}
+		merge.Tasks++
+	}
+
+	shared, err := r.ReplaceCollaborator(source.ID, target.ID)
+	merge.Tasks += shared
+	if err != nil {
+		return merge, err
+	}
+
+	leaves, err := r.GetUserLeaveRequests(source.ID)
+	if err != nil {
+		return merge, err
+	}
+	for _, leave := range leaves {
+		r.client.SRem(r.ctx, fmt.Sprintf("user:%d:leaves", source.ID), leave.ID)
+		leave.UserID = target.ID
+		leave.UpdatedAt = time.Now()
+		if err := r.SaveLeaveRequest(leave); err != nil {
+			return merge, err
+		}
+		merge.Leaves++
+	}
+
+	memberOf := make(map[int]bool, len(target.GroupIDs))
+	for _, groupID := range target.GroupIDs {
+		memberOf[groupID] = true
+	}
+	for _, groupID := range source.GroupIDs {
+		r.client.SRem(r.ctx, fmt.Sprintf("group:%d:users", groupID), source.ID)
+		if !memberOf[groupID] {
+			target.GroupIDs = append(target.GroupIDs, groupID)
+			memberOf[groupID] = true
+			merge.GroupIDs = append(merge.GroupIDs, groupID)
+		}
+	}
+
+	groups, err := r.GetAllGroups()
+	if err != nil {
+		return merge, err
+	}
+	for _, group := range groups {
+		if group.AdminID != source.ID {
+			continue
+		}
+
+		r.client.SRem(r.ctx, fmt.Sprintf("user:%d:admin_groups", source.ID), group.ID)
+		group.AdminID = target.ID
+		group.UpdatedAt = time.Now()
+		if err := r.SaveGroup(group); err != nil {
+			return merge, err
+		}
+		merge.AdminGroups = append(merge.AdminGroups, group.ID)
+
+		if !memberOf[group.ID] {
+			target.GroupIDs = append(target.GroupIDs, group.ID)
+			memberOf[group.ID] = true
+			merge.GroupIDs = append(merge.GroupIDs, group.ID)
+		}
+	}
+	if len(merge.AdminGroups) > 0 && target.Role == "user" {
+		target.Role = "group_admin"
+		merge.Promoted = true
+	}
+
+	target.UpdatedAt = time.Now()
+	if err := r.SaveUser(target); err != nil {
+		return merge, err
+	}
+
+	source.GroupIDs = models.IntSlice{}
+	source.Deactivated = true
+	source.UpdatedAt = time.Now()
+	return merge, r.SaveUser(source)
+}
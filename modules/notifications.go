@@ -0,0 +1,198 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"task-manager/models"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// notificationsDueKey scores each user with pending notifications by when
+// their batch is sent: one window after its first notification
+const notificationsDueKey = "notifications:due"
+
+// notificationFlushInterval is how often batches that are due are sent
+const notificationFlushInterval = 30 * time.Second
+
+var notifyLog = Logger("notifications")
+
+func pendingNotificationsKey(userID int) string {
+	return fmt.Sprintf("user:%d:notifications", userID)
+}
+
+// Notification is something a user is told about by email. Urgent ones,
+// such as being assigned a task, are sent right away with their Template;
+// the rest wait to be sent together in one digest of their Messages.
+type Notification struct {
+	Kind    string    `json:"kind"`
+	Message string    `json:"message"`
+	TaskID  int       `json:"task_id,omitempty"`
+	At      time.Time `json:"at"`
+
+	Urgent   bool                   `json:"-"`
+	Template string                 `json:"-"`
+	Data     map[string]interface{} `json:"-"`
+}
+
+// NotificationBatcher coalesces a user's notifications over a window into a
+// single email. The pending batches are kept in Redis, so any replica can
+// send them.
+type NotificationBatcher struct {
+	window  time.Duration
+	running bool
+}
+
+var Notifications *NotificationBatcher
+
+// InitNotifications sets the batching window; with 0 every notification is
+// sent on its own straight away
+func InitNotifications(window time.Duration) {
+	Notifications = &NotificationBatcher{window: window}
+}
+
+// Start registers the "notifications" job, which sends batches that are due
+func (n *NotificationBatcher) Start() {
+	if n.running || n.window <= 0 {
+		return
+	}
+
+	n.running = true
+	Jobs.Register(JobSpec{
+		Name:     "notifications",
+		Schedule: EverySchedule(notificationFlushInterval),
+		Run: func() error {
+			return n.flushDue(time.Now())
+		},
+	})
+}
+
+func (n *NotificationBatcher) Stop() {
+	n.running = false
+}
+
+// Notify emails a user about note, now when it is urgent or batching is
+// off, otherwise with whatever else reaches them within the window
+func (n *NotificationBatcher) Notify(ctx context.Context, user *models.User, note *Notification) {
+	if !Mailer.Enabled() {
+		return
+	}
+	if note.At.IsZero() {
+		note.At = time.Now()
+	}
+
+	if note.Urgent || n == nil || n.window <= 0 {
+		// Notifications without a template of their own go out as a
+		// digest of one
+		template, data := note.Template, note.Data
+		if template == "" {
+			template, data = "notification_digest", map[string]interface{}{"Notifications": []*Notification{note}}
+		}
+		if data == nil {
+			data = make(map[string]interface{})
+		}
+		if _, ok := data["FullName"]; !ok {
+			data["FullName"] = user.FullName
+		}
+		Mailer.SendTemplateAsync(ctx, user.Email, template, data)
+		return
+	}
+
+	if err := RedisClient.queueNotification(user.ID, note, note.At.Add(n.window)); err != nil {
+		notifyLog.WarnContext(ctx, "⚠️ Failed to queue notification", "user_id", user.ID, "kind", note.Kind, "error", err)
+	}
+}
+
+// queueNotification adds a notification to the user's batch, which is due
+// at dueAt unless it already was due earlier
+func (r *RedisManager) queueNotification(userID int, note *Notification, dueAt time.Time) error {
+	item, err := json.Marshal(note)
+	if err != nil {
+		return err
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.RPush(r.ctx, pendingNotificationsKey(userID), item)
+	pipe.ZAddNX(r.ctx, notificationsDueKey, &redis.Z{Score: float64(dueAt.Unix()), Member: userID})
+	_, err = pipe.Exec(r.ctx)
+	return err
+}
+
+// takeNotifications claims a due batch; false means another replica took
+// it first
+func (r *RedisManager) takeNotifications(userID int) ([]*Notification, bool, error) {
+	removed, err := r.client.ZRem(r.ctx, notificationsDueKey, userID).Result()
+	if err != nil || removed == 0 {
+		return nil, false, err
+	}
+
+	key := pendingNotificationsKey(userID)
+	pipe := r.client.TxPipeline()
+	items := pipe.LRange(r.ctx, key, 0, -1)
+	pipe.Del(r.ctx, key)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return nil, true, err
+	}
+
+	notes := make([]*Notification, 0, len(items.Val()))
+	for _, item := range items.Val() {
+		var note Notification
+		if json.Unmarshal([]byte(item), &note) == nil {
+			notes = append(notes, &note)
+		}
+	}
+	return notes, true, nil
+}
+
+// DeletePendingNotifications drops a deleted user's unsent batch
+func (r *RedisManager) DeletePendingNotifications(userID int) error {
+	pipe := r.client.TxPipeline()
+	pipe.Del(r.ctx, pendingNotificationsKey(userID))
+	pipe.ZRem(r.ctx, notificationsDueKey, userID)
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
+
+// flushDue sends every batch due by now as one digest per user
+func (n *NotificationBatcher) flushDue(now time.Time) error {
+	userIDs, err := RedisClient.client.ZRangeByScore(RedisClient.ctx, notificationsDueKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to load due notifications: %v", err)
+	}
+
+	for _, member := range userIDs {
+		userID, err := strconv.Atoi(member)
+		if err != nil {
+			continue
+		}
+		notes, claimed, err := RedisClient.takeNotifications(userID)
+		if err != nil {
+			notifyLog.Warn("⚠️ Failed to take notifications", "user_id", userID, "error", err)
+			continue
+		}
+		if !claimed || len(notes) == 0 {
+			continue
+		}
+
+		user, err := RedisClient.GetUser(userID)
+		if err != nil {
+			continue
+		}
+		err = Mailer.SendTemplate(context.Background(), user.Email, "notification_digest", map[string]interface{}{
+			"FullName":      user.FullName,
+			"Notifications": notes,
+		})
+		if err != nil {
+			notifyLog.Warn("⚠️ Failed to send notification digest", "user_id", userID, "error", err)
+			continue
+		}
+		notifyLog.Info("📬 Notification digest sent", "user_id", userID, "notifications", len(notes))
+	}
+	return nil
+}
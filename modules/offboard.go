@@ -0,0 +1,197 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"task-manager/config"
+	"task-manager/models"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// user:{id}:offboarding keeps the report of a user's offboarding, for as
+// long as the user does
+func offboardingKey(userID int) string {
+	return fmt.Sprintf("user:%d:offboarding", userID)
+}
+
+// OffboardedTask is an open task handed to another user on offboarding
+type OffboardedTask struct {
+	TaskID     int    `json:"task_id"`
+	Title      string `json:"title"`
+	GroupID    int    `json:"group_id"`
+	AssignedTo int    `json:"assigned_to"`
+}
+
+// UserOffboarding reports what offboarding a user did. Finished tasks and
+// group memberships stay, so history still shows who did what; the groups
+// the user administered need a new admin.
+type UserOffboarding struct {
+	UserID         int              `json:"user_id"`
+	Email          string           `json:"email"`
+	Reason         string           `json:"reason,omitempty"`
+	OffboardedBy   int              `json:"offboarded_by,omitempty"`
+	OffboardedAt   time.Time        `json:"offboarded_at"`
+	SignInsRevoked int              `json:"sign_ins_revoked"`
+	Tasks          []OffboardedTask `json:"tasks"`
+	Collaborations []int            `json:"collaborations_dropped"`
+	AdminGroups    []int            `json:"admin_groups"`
+}
+
+// GetPendingTasksByGroup reads the open and blocked tasks a user is the
+// primary assignee of, by group
+func (r *RedisManager) GetPendingTasksByGroup(userID int) (map[int][]*models.Task, error) {
+	tasks, err := r.GetUserTasks(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byGroup := make(map[int][]*models.Task)
+	for _, task := range tasks {
+		if task.UserID != userID || !isPendingState(task.CurrentState()) {
+			continue
+		}
+		byGroup[task.GroupID] = append(byGroup[task.GroupID], task)
+	}
+	return byGroup, nil
+}
+
+func isPendingState(state string) bool {
+	return state == models.TaskOpen || state == models.TaskBlocked
+}
+
+// OffboardUser deactivates a user, ends their sign-ins, hands their open
+// tasks to the assignee of each task's group and drops them from the open
+// tasks they collaborate on. assignees must cover every group with open
+// tasks; a group left out keeps its tasks with the user. The report is
+// saved last, so an offboarding that failed part way can be run again.
+// The user's open streams close on the user.offboarded event published
+// with the report, or at their next heartbeat.
+func (r *RedisManager) OffboardUser(user *models.User, assignees map[int]int, reason string, actorID int) (*UserOffboarding, error) {
+	report := &UserOffboarding{
+		UserID:         user.ID,
+		Email:          user.Email,
+		Reason:         reason,
+		OffboardedBy:   actorID,
+		OffboardedAt:   time.Now(),
+		Tasks:          []OffboardedTask{},
+		Collaborations: []int{},
+		AdminGroups:    []int{},
+	}
+
+	// Deactivating first stops the user signing in again while the rest runs
+	user.Deactivated = true
+	user.UpdatedAt = time.Now()
+	if err := r.SaveUser(user); err != nil {
+		return report, err
+	}
+	// Writing it to PostgreSQL now keeps it through a restore before the next sync
+	if PostgresClient != nil {
+		if err := PostgresClient.SyncUsers([]*models.User{user}); err != nil {
+			redisLog.Warn("⚠️ Failed to save deactivation to PostgreSQL; the next sync will", "user_id", user.ID, "error", err)
+		}
+	}
+
+	signIns, err := r.ListUserSignIns(user.ID)
+	if err != nil {
+		return report, err
+	}
+	for _, signIn := range signIns {
+		if err := r.revokeSignIn(signIn, RevokedOffboarded); err != nil {
+			return report, err
+		}
+		report.SignInsRevoked++
+	}
+	// Sign-ins too old to be listed go without a record
+	if err := r.DeleteUserSessions(user.ID); err != nil {
+		return report, err
+	}
+	if err := r.RevokeUserTokens(user.ID, config.AppConfig.RefreshTokenTTL); err != nil {
+		return report, err
+	}
+
+	byGroup, err := r.GetPendingTasksByGroup(user.ID)
+	if err != nil {
+		return report, err
+	}
+	for groupID, tasks := range byGroup {
+		assignee := assignees[groupID]
+		if assignee == 0 {
+			continue
+		}
+		for _, task := range tasks {
+			task.UserID = assignee
+			task.SetCollaborators(task.Collaborators)
+			task.UpdatedAt = time.Now()
+			if err := r.SaveTask(task); err != nil {
+				return report, err
+			}
+			report.Tasks = append(report.Tasks, OffboardedTask{
+				TaskID:     task.ID,
+				Title:      task.Title,
+				GroupID:    groupID,
+				AssignedTo: assignee,
+			})
+		}
+	}
+
+	shared, err := r.GetUserSharedTasks(user.ID)
+	if err != nil {
+		return report, err
+	}
+	for _, task := range shared {
+		if !isPendingState(task.CurrentState()) {
+			continue
+		}
+		collaborators := make([]int, 0, len(task.Collaborators))
+		for _, collaborator := range task.Collaborators {
+			if collaborator != user.ID {
+				collaborators = append(collaborators, collaborator)
+			}
+		}
+		task.SetCollaborators(collaborators)
+		task.UpdatedAt = time.Now()
+		if err := r.SaveTask(task); err != nil {
+			return report, err
+		}
+		report.Collaborations = append(report.Collaborations, task.ID)
+	}
+
+	groups, err := r.GetAllGroups()
+	if err != nil {
+		return report, err
+	}
+	for _, group := range groups {
+		if group.AdminID == user.ID {
+			report.AdminGroups = append(report.AdminGroups, group.ID)
+		}
+	}
+
+	return report, r.saveOffboarding(report)
+}
+
+func (r *RedisManager) saveOffboarding(report *UserOffboarding) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(r.ctx, offboardingKey(report.UserID), data, 0).Err()
+}
+
+// GetUserOffboarding reads the report of a user's offboarding
+func (r *RedisManager) GetUserOffboarding(userID int) (*UserOffboarding, error) {
+	data, err := r.client.Get(r.ctx, offboardingKey(userID)).Bytes()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("offboarding %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var report UserOffboarding
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
@@ -0,0 +1,386 @@
+package modules
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"task-manager/config"
+	"time"
+)
+
+// ErrCircuitOpen means a destination failed too often recently, so requests
+// to it are refused until its breaker's cooldown has passed
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// outboundBaseBackoff doubles with each retry, and a receiver's Retry-After
+// is honoured up to outboundMaxRetryWait
+const (
+	outboundBaseBackoff  = 250 * time.Millisecond
+	outboundMaxRetryWait = 10 * time.Second
+)
+
+var outboundLog = Logger("outbound")
+
+// outboundPool holds what every integration's requests share: the transport
+// with its proxy and CA bundle, the retry settings and one circuit breaker
+// per destination
+type outboundPool struct {
+	mu        sync.Mutex
+	transport http.RoundTripper
+	public    http.RoundTripper
+	timeout   time.Duration
+	retries   int
+	threshold int
+	cooldown  time.Duration
+	breakers  map[string]*circuitBreaker
+}
+
+// Outbound starts with defaults so integrations work before InitOutbound,
+// as in CLI commands
+var Outbound = &outboundPool{
+	transport: http.DefaultTransport,
+	public:    publicOnlyTransport(http.DefaultTransport.(*http.Transport)),
+	timeout:   10 * time.Second,
+	retries:   2,
+	threshold: 5,
+	cooldown:  30 * time.Second,
+	breakers:  make(map[string]*circuitBreaker),
+}
+
+// InitOutbound configures integration requests from OUTBOUND_* settings: a
+// proxy (otherwise HTTP_PROXY and HTTPS_PROXY), extra trusted CAs, the
+// timeout per attempt, retries and circuit breaking
+func InitOutbound(cfg *config.Config) error {
+	if cfg == nil {
+		cfg = config.AppConfig
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.OutboundProxy != "" {
+		proxy, err := url.Parse(cfg.OutboundProxy)
+		if err != nil || proxy.Host == "" {
+			return fmt.Errorf("invalid OUTBOUND_PROXY %q", cfg.OutboundProxy)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if cfg.OutboundCAFile != "" {
+		pem, err := os.ReadFile(cfg.OutboundCAFile)
+		if err != nil {
+			return fmt.Errorf("read OUTBOUND_CA_FILE: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("OUTBOUND_CA_FILE %s has no PEM certificates", cfg.OutboundCAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	Outbound.mu.Lock()
+	defer Outbound.mu.Unlock()
+	Outbound.transport = transport
+	Outbound.public = publicOnlyTransport(transport)
+	Outbound.timeout = cfg.OutboundTimeout
+	Outbound.retries = cfg.OutboundRetries
+	Outbound.threshold = cfg.OutboundBreakerThreshold
+	Outbound.cooldown = cfg.OutboundBreakerCooldown
+	return nil
+}
+
+// breaker returns the circuit breaker of a request's scheme and host
+func (p *outboundPool) breaker(destination *url.URL) *circuitBreaker {
+	key := destination.Scheme + "://" + destination.Host
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	breaker := p.breakers[key]
+	if breaker == nil {
+		breaker = &circuitBreaker{threshold: p.threshold, cooldown: p.cooldown}
+		p.breakers[key] = breaker
+	}
+	return breaker
+}
+
+func (p *outboundPool) settings(publicOnly bool) (*http.Client, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if publicOnly {
+		return &http.Client{Transport: p.public, Timeout: p.timeout, CheckRedirect: checkPublicRedirect}, p.retries
+	}
+	return &http.Client{Transport: p.transport, Timeout: p.timeout}, p.retries
+}
+
+// publicOnlyTransport is transport, keeping its CA bundle, for requests to
+// addresses users chose: every connection, redirects included, must go to
+// a public address. It never uses the proxy, which would make the
+// connections itself, past the address check.
+func publicOnlyTransport(transport *http.Transport) *http.Transport {
+	public := transport.Clone()
+	public.Proxy = nil
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: dialPublicOnly}
+	public.DialContext = dialer.DialContext
+	return public
+}
+
+// CheckPublicURL reports an error unless an http(s) URL resolves only to
+// public addresses, on port 80 or 443 as publicOnlyTransport requires. It
+// catches a bad webhook when it is set; publicOnlyTransport still checks
+// every connection, as the name may resolve elsewhere later.
+func CheckPublicURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if port := parsed.Port(); port != "" && port != "80" && port != "443" {
+		return fmt.Errorf("port %s: %w", port, errAddressNotPublic)
+	}
+
+	host := parsed.Hostname()
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return err
+		}
+		ips = ips[:0]
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+	for _, ip := range ips {
+		if !isPublicIP(ip) {
+			return fmt.Errorf("%s: %w", ip, errAddressNotPublic)
+		}
+	}
+	return nil
+}
+
+// checkPublicRedirect follows at most 10 redirects, to http(s) URLs only;
+// the transport checks where each one connects
+func checkPublicRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after %d redirects", len(via))
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("redirect to %s URL", req.URL.Scheme)
+	}
+	return nil
+}
+
+// Status reports every destination's breaker, for /admin/status
+func (p *outboundPool) Status() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	destinations := make(map[string]interface{}, len(p.breakers))
+	for key, breaker := range p.breakers {
+		destinations[key] = breaker.status()
+	}
+	return map[string]interface{}{
+		"timeout":      p.timeout.String(),
+		"retries":      p.retries,
+		"destinations": destinations,
+	}
+}
+
+// circuitBreaker stops calling a destination after threshold failed calls
+// in a row. Once cooldown has passed a single call is let through; its
+// success closes the breaker and its failure opens it again.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+	opened   uint64
+}
+
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold == 0 || b.openedAt.IsZero() {
+		return true
+	}
+	if b.probing || time.Since(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *circuitBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.failures = 0
+		b.openedAt = time.Time{}
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	if b.probing || (b.threshold > 0 && b.failures == b.threshold) {
+		b.openedAt = time.Now()
+		b.probing = false
+		b.opened++
+	}
+}
+
+func (b *circuitBreaker) status() map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := "closed"
+	switch {
+	case b.probing:
+		state = "half_open"
+	case !b.openedAt.IsZero():
+		state = "open"
+	}
+	status := map[string]interface{}{
+		"state":        state,
+		"failures":     b.failures,
+		"opened_total": b.opened,
+	}
+	if !b.openedAt.IsZero() {
+		status["opened_at"] = b.openedAt
+	}
+	return status
+}
+
+// OutboundClient sends an integration's requests through the shared
+// outbound settings. Name labels its retries in the logs.
+type OutboundClient struct {
+	name       string
+	publicOnly bool
+}
+
+// NewOutboundClient returns the client an integration sends requests with
+func NewOutboundClient(name string) *OutboundClient {
+	return &OutboundClient{name: name}
+}
+
+// NewPublicOutboundClient returns a client for URLs users supply, such as
+// webhooks, which only connects to public addresses on ports 80 and 443
+// and so cannot be pointed at internal services
+func NewPublicOutboundClient(name string) *OutboundClient {
+	return &OutboundClient{name: name, publicOnly: true}
+}
+
+// Post sends body to url, like http.Client.Post
+func (c *OutboundClient) Post(url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.Do(req)
+}
+
+// Do sends a request, retrying with jittered backoff when that cannot
+// repeat work the receiver already did: any request the receiver turned
+// away with 429 or 503 or never received, and idempotent requests after
+// other server errors. A destination whose breaker is open is not called
+// and ErrCircuitOpen returned.
+func (c *OutboundClient) Do(req *http.Request) (*http.Response, error) {
+	breaker := Outbound.breaker(req.URL)
+	if !breaker.allow() {
+		return nil, fmt.Errorf("%s: %w", req.URL.Host, ErrCircuitOpen)
+	}
+	client, retries := Outbound.settings(c.publicOnly)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Do(req)
+		wait, retry := retryDelay(req, resp, err, attempt)
+		if !retry || attempt >= retries || !rewindBody(req) {
+			breaker.record(err == nil && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500)
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		outboundLog.Debug("Retrying outbound request", "integration", c.name, "host", req.URL.Host,
+			"attempt", attempt+1, "wait", wait.String(), "error", err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			breaker.record(false)
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// retryDelay decides whether a failed attempt may be retried and after how long
+func retryDelay(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	idempotent := req.Method == "GET" || req.Method == "HEAD" || req.Method == "PUT" ||
+		req.Method == "DELETE" || req.Method == "OPTIONS"
+	if err != nil {
+		if req.Context().Err() != nil {
+			return 0, false
+		}
+		// A failed dial never reached the receiver, so anything may be resent
+		var opErr *net.OpError
+		if !idempotent && !(errors.As(err, &opErr) && opErr.Op == "dial") {
+			return 0, false
+		}
+		return backoff(attempt), true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			wait := time.Duration(seconds) * time.Second
+			return wait, wait <= outboundMaxRetryWait
+		}
+		return backoff(attempt), true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return backoff(attempt), idempotent
+	}
+	return 0, false
+}
+
+// backoff doubles from outboundBaseBackoff, randomised between half and the
+// full delay so clients that failed together do not retry together
+func backoff(attempt int) time.Duration {
+	delay := outboundBaseBackoff << attempt
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// rewindBody prepares a request's body to be sent again, reporting false
+// when it cannot be
+func rewindBody(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody {
+		return true
+	}
+	if req.GetBody == nil {
+		return false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return false
+	}
+	req.Body = body
+	return true
+}
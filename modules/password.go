@@ -0,0 +1,238 @@
+package modules
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"task-manager/config"
+	"task-manager/models"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxPasswordBytes is the longest password bcrypt can hash
+const maxPasswordBytes = 72
+
+// commonPasswords are refused whatever the policy's length and classes,
+// compared without regard to case
+var commonPasswords = []string{
+	"123456", "1234567", "12345678", "123456789", "1234567890", "111111", "000000",
+	"123123", "654321", "121212", "666666", "987654321", "1q2w3e4r", "1qaz2wsx",
+	"password", "password1", "password123", "passw0rd", "p@ssw0rd", "qwerty",
+	"qwerty123", "qwertyuiop", "asdfgh", "asdfghjkl", "zxcvbnm", "abc123",
+	"abcd1234", "admin", "admin123", "administrator", "root", "letmein",
+	"welcome", "welcome1", "iloveyou", "monkey", "dragon", "football",
+	"baseball", "sunshine", "princess", "master", "shadow", "superman",
+	"trustno1", "changeme", "secret", "login", "starwars", "whatever",
+}
+
+var passwordLog = Logger("password")
+
+var bannedPasswords struct {
+	once sync.Once
+	set  map[string]bool
+}
+
+// PasswordPolicy is what new passwords must satisfy, as shown to clients
+type PasswordPolicy struct {
+	MinLength  int  `json:"min_length"`
+	MaxBytes   int  `json:"max_bytes"`
+	MinClasses int  `json:"min_classes"`
+	BanCommon  bool `json:"ban_common"`
+	History    int  `json:"history"`
+}
+
+// CurrentPasswordPolicy returns the configured password policy
+func CurrentPasswordPolicy() PasswordPolicy {
+	policy := PasswordPolicy{MinLength: 6, MaxBytes: maxPasswordBytes, MinClasses: 1, BanCommon: true, History: 5}
+	if config.AppConfig != nil {
+		policy.MinLength = config.AppConfig.PasswordMinLength
+		policy.MinClasses = config.AppConfig.PasswordMinClasses
+		policy.History = config.AppConfig.PasswordHistory
+	}
+	return policy
+}
+
+// PasswordViolation is the first rule a password breaks, named like a
+// validation rule with the values its message needs
+type PasswordViolation struct {
+	Rule string
+	Args []interface{}
+}
+
+func (v *PasswordViolation) Error() string {
+	switch v.Rule {
+	case "password_length":
+		return fmt.Sprintf("password must be at least %d characters", v.Args...)
+	case "password_too_long":
+		return fmt.Sprintf("password must be at most %d bytes", v.Args...)
+	case "password_classes":
+		return fmt.Sprintf("password must mix at least %d of lowercase, uppercase, digits and symbols", v.Args...)
+	case "password_common":
+		return "password is too common"
+	default:
+		return fmt.Sprintf("password must not be one of the last %d used", v.Args...)
+	}
+}
+
+// Check returns the first rule password breaks, or nil when it passes.
+// Reuse is checked separately with PasswordReused.
+func (p PasswordPolicy) Check(password string) *PasswordViolation {
+	if len([]rune(password)) < p.MinLength {
+		return &PasswordViolation{Rule: "password_length", Args: []interface{}{p.MinLength}}
+	}
+	if len(password) > p.MaxBytes {
+		return &PasswordViolation{Rule: "password_too_long", Args: []interface{}{p.MaxBytes}}
+	}
+	if passwordClasses(password) < p.MinClasses {
+		return &PasswordViolation{Rule: "password_classes", Args: []interface{}{p.MinClasses}}
+	}
+	if p.BanCommon && isBannedPassword(password) {
+		return &PasswordViolation{Rule: "password_common"}
+	}
+	return nil
+}
+
+// passwordClasses counts the kinds of characters in a password: lowercase,
+// uppercase, digits and anything else
+func passwordClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	count := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
+
+// isBannedPassword checks the built-in common passwords and those listed in
+// PASSWORD_BANNED_FILE, which is read once
+func isBannedPassword(password string) bool {
+	bannedPasswords.once.Do(func() {
+		bannedPasswords.set = make(map[string]bool, len(commonPasswords))
+		for _, common := range commonPasswords {
+			bannedPasswords.set[common] = true
+		}
+		if config.AppConfig == nil || config.AppConfig.PasswordBannedFile == "" {
+			return
+		}
+
+		file, err := os.Open(config.AppConfig.PasswordBannedFile)
+		if err != nil {
+			passwordLog.Warn("⚠️ Failed to read banned passwords", "file", config.AppConfig.PasswordBannedFile, "error", err)
+			return
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+				bannedPasswords.set[strings.ToLower(line)] = true
+			}
+		}
+	})
+	return bannedPasswords.set[strings.ToLower(password)]
+}
+
+// SetPassword stores a bcrypt hash of a new password on the user
+func SetPassword(user *models.User, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.Password = string(hash)
+	return nil
+}
+
+// PasswordHashed reports whether a stored password is a bcrypt hash rather
+// than plaintext saved before passwords were hashed
+func PasswordHashed(stored string) bool {
+	_, err := bcrypt.Cost([]byte(stored))
+	return err == nil
+}
+
+// CheckPassword reports whether password is the user's, against either a
+// hash or a plaintext password not yet hashed, in constant time
+func CheckPassword(user *models.User, password string) bool {
+	if PasswordHashed(user.Password) {
+		return bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(user.Password), []byte(password)) == 1
+}
+
+// dummyPasswordHash is checked against when there is no such user, so a
+// failed sign-in takes as long whether or not the user exists
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("no such user"), bcrypt.DefaultCost)
+	return hash
+})
+
+// passwordHistoryKey holds bcrypt hashes of a user's recent passwords,
+// newest first
+func passwordHistoryKey(userID int) string {
+	return fmt.Sprintf("user:%d:password_history", userID)
+}
+
+// PasswordReused reports whether password is the user's current one or one
+// of the policy's last History passwords. It is always false with a
+// History of 0.
+func (r *RedisManager) PasswordReused(user *models.User, password string) bool {
+	history := CurrentPasswordPolicy().History
+	if history == 0 {
+		return false
+	}
+	if CheckPassword(user, password) {
+		return true
+	}
+
+	hashes, err := r.client.LRange(r.ctx, passwordHistoryKey(user.ID), 0, int64(history-1)).Result()
+	if err != nil {
+		return false
+	}
+	for _, stored := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordPassword adds a password just set for a user to their history
+func (r *RedisManager) RecordPassword(userID int, password string) error {
+	history := CurrentPasswordPolicy().History
+	key := passwordHistoryKey(userID)
+	if history == 0 {
+		return r.client.Del(r.ctx, key).Err()
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	pipe := r.client.TxPipeline()
+	pipe.LPush(r.ctx, key, hash)
+	pipe.LTrim(r.ctx, key, 0, int64(history-1))
+	_, err = pipe.Exec(r.ctx)
+	return err
+}
+
+// DeletePasswordHistory drops a deleted user's password history
+func (r *RedisManager) DeletePasswordHistory(userID int) error {
+	return r.client.Del(r.ctx, passwordHistoryKey(userID)).Err()
+}
@@ -0,0 +1,135 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// PortalToken describes a client portal token. Portal tokens only open the
+// read-only /portal endpoints for their client and are never accepted by
+// the rest of the API.
+type PortalToken struct {
+	ID        string    `json:"id"`
+	ClientID  int       `json:"client_id"`
+	Label     string    `json:"label"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func portalTokenKey(hash string) string {
+	return "token:portal:" + hash
+}
+
+// clientPortalTokensKey maps a client's token IDs to token hashes, so tokens
+// can be listed and revoked without knowing the secret
+func clientPortalTokensKey(clientID int) string {
+	return fmt.Sprintf("client:%d:portal_tokens", clientID)
+}
+
+// IssuePortalToken creates a portal token for a client. The secret is only
+// returned here; Redis keeps its hash.
+func (r *RedisManager) IssuePortalToken(clientID int, label string) (string, *PortalToken, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", nil, err
+	}
+	id, err := randomToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	portalToken := &PortalToken{
+		ID:        id[:16],
+		ClientID:  clientID,
+		Label:     label,
+		CreatedAt: time.Now(),
+	}
+	data, err := json.Marshal(portalToken)
+	if err != nil {
+		return "", nil, err
+	}
+
+	hash := hashToken(token)
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(r.ctx, portalTokenKey(hash), data, 0)
+		pipe.HSet(r.ctx, clientPortalTokensKey(clientID), portalToken.ID, hash)
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, portalToken, nil
+}
+
+// PortalTokenClient returns the portal token a secret belongs to
+func (r *RedisManager) PortalTokenClient(token string) (*PortalToken, error) {
+	return r.getPortalToken(hashToken(token))
+}
+
+func (r *RedisManager) GetPortalTokens(clientID int) ([]*PortalToken, error) {
+	hashes, err := r.client.HGetAll(r.ctx, clientPortalTokensKey(clientID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]*PortalToken, 0, len(hashes))
+	for _, hash := range hashes {
+		if portalToken, err := r.getPortalToken(hash); err == nil {
+			tokens = append(tokens, portalToken)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *RedisManager) RevokePortalToken(clientID int, tokenID string) error {
+	key := clientPortalTokensKey(clientID)
+	hash, err := r.client.HGet(r.ctx, key, tokenID).Result()
+	if err == redis.Nil {
+		return fmt.Errorf("portal token %w", ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(r.ctx, portalTokenKey(hash))
+		pipe.HDel(r.ctx, key, tokenID)
+		return nil
+	})
+	return err
+}
+
+// RevokeClientPortalTokens revokes every portal token of a client
+func (r *RedisManager) RevokeClientPortalTokens(clientID int) error {
+	key := clientPortalTokensKey(clientID)
+	hashes, err := r.client.HGetAll(r.ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		for _, hash := range hashes {
+			pipe.Del(r.ctx, portalTokenKey(hash))
+		}
+		pipe.Del(r.ctx, key)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisManager) getPortalToken(hash string) (*PortalToken, error) {
+	data, err := r.client.Get(r.ctx, portalTokenKey(hash)).Result()
+	if err == redis.Nil {
+		return nil, errInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var portalToken PortalToken
+	err = json.Unmarshal([]byte(data), &portalToken)
+	return &portalToken, err
+}
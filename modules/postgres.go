@@ -18,6 +18,14 @@ type PostgresManager struct {
 
 var PostgresClient *PostgresManager
 
+// postgresModels are the tables migrated on connect
+var postgresModels = []interface{}{
+	&models.User{}, &models.Group{}, &models.Task{}, &models.UserGroup{}, &models.LeaveRequest{},
+	&models.EmailLog{}, &models.ReportSubscription{}, &models.Client{}, &models.Risk{}, &models.Objective{},
+	&models.Comment{},
+	&models.Attachment{},
+}
+
 // InitPostgres initializes PostgreSQL connection with retry logic
 func InitPostgres(cfg *config.Config) error {
 	if cfg == nil {
@@ -40,7 +48,7 @@ func InitPostgres(cfg *config.Config) error {
 			if err == nil {
 				if err := sqlDB.Ping(); err == nil {
 					// Auto-migrate
-					if err := db.AutoMigrate(&models.User{}, &models.Group{}, &models.Task{}, &models.UserGroup{}); err != nil {
+					if err := db.AutoMigrate(postgresModels...); err != nil {
 						fmt.Printf("⚠️  Migration failed: %v\n", err)
 						if attempt < maxRetries {
 							time.Sleep(retryDelay)
@@ -50,6 +58,18 @@ func InitPostgres(cfg *config.Config) error {
 						return fmt.Errorf("failed to migrate database: %v", err)
 					}
 
+					if err := ensureSearchIndexes(db); err != nil {
+						fmt.Printf("⚠️  Failed to create search indexes: %v\n", err)
+					}
+
+					if err := ensureReportingSchema(db); err != nil {
+						fmt.Printf("⚠️  Failed to prepare reporting columns and indexes: %v\n", err)
+					}
+
+					if err := installPostgresChaos(db); err != nil {
+						fmt.Printf("⚠️  Failed to install fault injection: %v\n", err)
+					}
+
 					PostgresClient = &PostgresManager{
 						db:     db,
 						config: cfg,
@@ -109,6 +129,9 @@ func (p *PostgresManager) SaveUser(user *models.User) error {
 func (p *PostgresManager) GetUser(userID int) (*models.User, error) {
 	var user models.User
 	err := p.db.First(&user, userID).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("user %w", ErrNotFound)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +141,9 @@ func (p *PostgresManager) GetUser(userID int) (*models.User, error) {
 func (p *PostgresManager) GetUserByEmail(email string) (*models.User, error) {
 	var user models.User
 	err := p.db.Where("email = ?", email).First(&user).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("user %w", ErrNotFound)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -133,6 +159,8 @@ func (p *PostgresManager) GetAllUsers() ([]*models.User, error) {
 func (p *PostgresManager) DeleteUser(userID int) error {
 	p.db.Where("user_id = ?", userID).Delete(&models.Task{})
 	p.db.Where("user_id = ?", userID).Delete(&models.UserGroup{})
+	p.db.Where("user_id = ?", userID).Delete(&models.LeaveRequest{})
+	p.db.Where("user_id = ?", userID).Delete(&models.ReportSubscription{})
 	return p.db.Delete(&models.User{}, userID).Error
 }
 
@@ -142,6 +170,12 @@ func (p *PostgresManager) GetMaxUserID() (int, error) {
 	return maxID, err
 }
 
+func (p *PostgresManager) GetUserIDs() ([]int, error) {
+	var ids []int
+	err := p.db.Model(&models.User{}).Pluck("id", &ids).Error
+	return ids, err
+}
+
 func (p *PostgresManager) SaveGroup(group *models.Group) error {
 	return p.db.Save(group).Error
 }
@@ -149,6 +183,9 @@ func (p *PostgresManager) SaveGroup(group *models.Group) error {
 func (p *PostgresManager) GetGroup(groupID int) (*models.Group, error) {
 	var group models.Group
 	err := p.db.First(&group, groupID).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("group %w", ErrNotFound)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -191,6 +228,12 @@ func (p *PostgresManager) GetMaxGroupID() (int, error) {
 	return maxID, err
 }
 
+func (p *PostgresManager) GetGroupIDs() ([]int, error) {
+	var ids []int
+	err := p.db.Model(&models.Group{}).Pluck("id", &ids).Error
+	return ids, err
+}
+
 func (p *PostgresManager) SaveTask(task *models.Task) error {
 	return p.db.Save(task).Error
 }
@@ -198,6 +241,9 @@ func (p *PostgresManager) SaveTask(task *models.Task) error {
 func (p *PostgresManager) GetTask(taskID int) (*models.Task, error) {
 	var task models.Task
 	err := p.db.First(&task, taskID).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("task %w", ErrNotFound)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -226,6 +272,123 @@ func (p *PostgresManager) GetMaxTaskID() (int, error) {
 	return maxID, err
 }
 
+func (p *PostgresManager) GetTaskIDs() ([]int, error) {
+	var ids []int
+	err := p.db.Model(&models.Task{}).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// GetMaxTaskKeySequences returns the highest key number issued per group code
+func (p *PostgresManager) GetMaxTaskKeySequences() (map[string]int, error) {
+	var rows []struct {
+		Code     string
+		Sequence int
+	}
+	err := p.db.Model(&models.Task{}).
+		Select("split_part(key, '-', 1) AS code, MAX(CAST(split_part(key, '-', 2) AS INTEGER)) AS sequence").
+		Where("key <> ''").
+		Group("split_part(key, '-', 1)").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sequences := make(map[string]int, len(rows))
+	for _, row := range rows {
+		sequences[row.Code] = row.Sequence
+	}
+	return sequences, nil
+}
+
+func (p *PostgresManager) GetAllLeaveRequests() ([]*models.LeaveRequest, error) {
+	var leaves []*models.LeaveRequest
+	err := p.db.Find(&leaves).Error
+	return leaves, err
+}
+
+func (p *PostgresManager) GetMaxLeaveID() (int, error) {
+	var maxID int
+	err := p.db.Model(&models.LeaveRequest{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error
+	return maxID, err
+}
+
+func (p *PostgresManager) GetAllReportSubscriptions() ([]*models.ReportSubscription, error) {
+	var subs []*models.ReportSubscription
+	err := p.db.Find(&subs).Error
+	return subs, err
+}
+
+func (p *PostgresManager) GetMaxReportSubscriptionID() (int, error) {
+	var maxID int
+	err := p.db.Model(&models.ReportSubscription{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error
+	return maxID, err
+}
+
+func (p *PostgresManager) GetAllClients() ([]*models.Client, error) {
+	var clients []*models.Client
+	err := p.db.Find(&clients).Error
+	return clients, err
+}
+
+func (p *PostgresManager) GetMaxClientID() (int, error) {
+	var maxID int
+	err := p.db.Model(&models.Client{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error
+	return maxID, err
+}
+
+func (p *PostgresManager) GetAllRisks() ([]*models.Risk, error) {
+	var risks []*models.Risk
+	err := p.db.Find(&risks).Error
+	return risks, err
+}
+
+func (p *PostgresManager) GetMaxRiskID() (int, error) {
+	var maxID int
+	err := p.db.Model(&models.Risk{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error
+	return maxID, err
+}
+
+func (p *PostgresManager) GetAllObjectives() ([]*models.Objective, error) {
+	var objectives []*models.Objective
+	err := p.db.Find(&objectives).Error
+	return objectives, err
+}
+
+func (p *PostgresManager) GetMaxObjectiveID() (int, error) {
+	var maxID int
+	err := p.db.Model(&models.Objective{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error
+	return maxID, err
+}
+
+func (p *PostgresManager) CreateEmailLog(entry *models.EmailLog) error {
+	return p.db.Create(entry).Error
+}
+
+func (p *PostgresManager) SaveEmailLog(entry *models.EmailLog) error {
+	return p.db.Save(entry).Error
+}
+
+func (p *PostgresManager) GetRetryableEmailLogs(maxAttempts int) ([]*models.EmailLog, error) {
+	var entries []*models.EmailLog
+	err := p.db.Where("status = ? AND attempts < ?", "retrying", maxAttempts).
+		Order("id").
+		Limit(100).
+		Find(&entries).Error
+	return entries, err
+}
+
+func (p *PostgresManager) CountEmailLogsByStatus(status string) (int64, error) {
+	var count int64
+	err := p.db.Model(&models.EmailLog{}).Where("status = ?", status).Count(&count).Error
+	return count, err
+}
+
+func (p *PostgresManager) GetEmailLogs(limit int) ([]*models.EmailLog, error) {
+	var entries []*models.EmailLog
+	err := p.db.Order("id DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
 func (p *PostgresManager) AddUserToGroup(userID, groupID int) error {
 	userGroup := &models.UserGroup{
 		UserID:  userID,
@@ -281,6 +444,11 @@ func (p *PostgresManager) SyncUsers(users []*models.User) error {
 			existingUser.Email = user.Email
 			existingUser.Password = user.Password
 			existingUser.WorkTimes = user.WorkTimes
+			existingUser.Region = user.Region
+			existingUser.Deactivated = user.Deactivated
+			existingUser.UserAdmin = user.UserAdmin
+			existingUser.UserAdminGroups = user.UserAdminGroups
+			existingUser.Version = user.Version
 			existingUser.UpdatedAt = user.UpdatedAt
 
 			if saveErr := tx.Save(&existingUser).Error; saveErr != nil {
@@ -344,7 +512,11 @@ func (p *PostgresManager) SyncGroups(groups []*models.Group) error {
 			return errByID
 		} else {
 			existingGroup.Name = group.Name
+			existingGroup.Code = group.Code
 			existingGroup.AdminID = group.AdminID
+			existingGroup.ArchivedAt = group.ArchivedAt
+			existingGroup.ArchivedBy = group.ArchivedBy
+			existingGroup.Version = group.Version
 			existingGroup.UpdatedAt = group.UpdatedAt
 
 			if saveErr := tx.Save(&existingGroup).Error; saveErr != nil {
@@ -361,6 +533,11 @@ func (p *PostgresManager) SyncTasks(tasks []*models.Task) error {
 	tx := p.db.Begin()
 
 	for _, task := range tasks {
+		// Tasks closed before ClosedAt was kept report their last update
+		if task.Closed() && task.ClosedAt == nil {
+			closedAt := task.ClosedTime()
+			task.ClosedAt = &closedAt
+		}
 		if saveErr := tx.Save(task).Error; saveErr != nil {
 			tx.Rollback()
 			return saveErr
@@ -370,6 +547,137 @@ func (p *PostgresManager) SyncTasks(tasks []*models.Task) error {
 	return tx.Commit().Error
 }
 
+func (p *PostgresManager) SyncLeaveRequests(leaves []*models.LeaveRequest) error {
+	tx := p.db.Begin()
+
+	for _, leave := range leaves {
+		if saveErr := tx.Save(leave).Error; saveErr != nil {
+			tx.Rollback()
+			return saveErr
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+func (p *PostgresManager) SyncReportSubscriptions(subs []*models.ReportSubscription) error {
+	tx := p.db.Begin()
+
+	for _, sub := range subs {
+		if saveErr := tx.Save(sub).Error; saveErr != nil {
+			tx.Rollback()
+			return saveErr
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+func (p *PostgresManager) SyncClients(clients []*models.Client) error {
+	tx := p.db.Begin()
+
+	for _, client := range clients {
+		if saveErr := tx.Save(client).Error; saveErr != nil {
+			tx.Rollback()
+			return saveErr
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+func (p *PostgresManager) SyncRisks(risks []*models.Risk) error {
+	tx := p.db.Begin()
+
+	for _, risk := range risks {
+		if saveErr := tx.Save(risk).Error; saveErr != nil {
+			tx.Rollback()
+			return saveErr
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// SyncComments saves the comments in Redis and removes those deleted since
+// the last sync
+func (p *PostgresManager) SyncComments(comments []*models.Comment, deletedIDs []int) error {
+	tx := p.db.Begin()
+
+	for _, comment := range comments {
+		if saveErr := tx.Save(comment).Error; saveErr != nil {
+			tx.Rollback()
+			return saveErr
+		}
+	}
+	if len(deletedIDs) > 0 {
+		if err := tx.Delete(&models.Comment{}, deletedIDs).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+func (p *PostgresManager) GetAllComments() ([]*models.Comment, error) {
+	var comments []*models.Comment
+	err := p.db.Find(&comments).Error
+	return comments, err
+}
+
+func (p *PostgresManager) GetMaxCommentID() (int, error) {
+	var maxID int
+	err := p.db.Model(&models.Comment{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error
+	return maxID, err
+}
+
+// SyncAttachments saves the attachments in Redis and removes those deleted
+// since the last sync
+func (p *PostgresManager) SyncAttachments(attachments []*models.Attachment, deletedIDs []int) error {
+	tx := p.db.Begin()
+
+	for _, attachment := range attachments {
+		if saveErr := tx.Save(attachment).Error; saveErr != nil {
+			tx.Rollback()
+			return saveErr
+		}
+	}
+	if len(deletedIDs) > 0 {
+		if err := tx.Delete(&models.Attachment{}, deletedIDs).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+func (p *PostgresManager) GetAllAttachments() ([]*models.Attachment, error) {
+	var attachments []*models.Attachment
+	err := p.db.Find(&attachments).Error
+	return attachments, err
+}
+
+func (p *PostgresManager) GetMaxAttachmentID() (int, error) {
+	var maxID int
+	err := p.db.Model(&models.Attachment{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error
+	return maxID, err
+}
+
+func (p *PostgresManager) SyncObjectives(objectives []*models.Objective) error {
+	tx := p.db.Begin()
+
+	for _, objective := range objectives {
+		if saveErr := tx.Save(objective).Error; saveErr != nil {
+			tx.Rollback()
+			return saveErr
+		}
+	}
+
+	return tx.Commit().Error
+}
+
 func (p *PostgresManager) CleanupDeletedData() error {
 	return nil
 }
@@ -381,15 +689,17 @@ func (p *PostgresManager) BackupData() error {
 func (p *PostgresManager) GetStats() (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
 
-	var userCount, groupCount, taskCount int64
+	var userCount, groupCount, taskCount, leaveCount int64
 
 	p.db.Model(&models.User{}).Count(&userCount)
 	p.db.Model(&models.Group{}).Count(&groupCount)
 	p.db.Model(&models.Task{}).Count(&taskCount)
+	p.db.Model(&models.LeaveRequest{}).Count(&leaveCount)
 
 	stats["users"] = userCount
 	stats["groups"] = groupCount
 	stats["tasks"] = taskCount
+	stats["leave_requests"] = leaveCount
 
 	return stats, nil
 }
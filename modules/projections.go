@@ -0,0 +1,401 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Dashboards read projections rather than tasks. The projector keeps them
+// from task.changed events, re-reading each changed task:
+//
+//	proj:task:{id}            where the task is counted: user, group, state and days
+//	proj:tasks                every projected task, for reconciliation
+//	proj:user:{id}            the user's tasks by state
+//	proj:group:{id}           the group's tasks by state
+//	proj:group:{id}:days      the group's tasks created, completed and cancelled by day
+//
+// Days are in the organization's timezone; a closed task counts on the day
+// it was closed, as in the PostgreSQL burndown report.
+const projectedTasksKey = "proj:tasks"
+
+var projectionLog = Logger("projections")
+
+func projectedTaskKey(taskID int) string {
+	return fmt.Sprintf("proj:task:%d", taskID)
+}
+
+func userProjectionKey(userID int) string {
+	return fmt.Sprintf("proj:user:%d", userID)
+}
+
+func groupProjectionKey(groupID int) string {
+	return fmt.Sprintf("proj:group:%d", groupID)
+}
+
+func groupDaysProjectionKey(groupID int) string {
+	return fmt.Sprintf("proj:group:%d:days", groupID)
+}
+
+// projectScript moves a task's counts from where it was projected to where
+// it is now, or out of the projections when it is gone. Projecting the same
+// task twice changes nothing, so replicas and reconciliation can all apply it.
+var projectScript = redis.NewScript(`
+local fields = {"user", "group", "state", "created", "closed"}
+
+local function apply(p, n)
+	if p.user ~= "0" then
+		redis.call("HINCRBY", "proj:user:" .. p.user, p.state, n)
+	end
+	if p.group ~= "0" then
+		redis.call("HINCRBY", "proj:group:" .. p.group, p.state, n)
+		local days = "proj:group:" .. p.group .. ":days"
+		redis.call("HINCRBY", days, p.created, n)
+		if p.closed ~= "" then
+			redis.call("HINCRBY", days, p.closed, n)
+		end
+	end
+end
+
+local previous
+local values = redis.call("HMGET", KEYS[1], unpack(fields))
+if values[1] then
+	previous = {}
+	for i, field in ipairs(fields) do
+		previous[field] = values[i] or ""
+	end
+end
+
+local current
+if ARGV[1] == "1" then
+	current = {}
+	for i, field in ipairs(fields) do
+		current[field] = ARGV[i + 2]
+	end
+end
+
+if previous and current then
+	local same = true
+	for _, field in ipairs(fields) do
+		if previous[field] ~= current[field] then
+			same = false
+		end
+	end
+	if same then
+		return 0
+	end
+end
+if not previous and not current then
+	return 0
+end
+
+if previous then
+	apply(previous, -1)
+end
+if current then
+	apply(current, 1)
+	redis.call("DEL", KEYS[1])
+	for _, field in ipairs(fields) do
+		redis.call("HSET", KEYS[1], field, current[field])
+	end
+	redis.call("SADD", KEYS[2], ARGV[2])
+else
+	redis.call("DEL", KEYS[1])
+	redis.call("SREM", KEYS[2], ARGV[2])
+end
+return 1`)
+
+// projectTask brings a task's projection up to date, removing it when task
+// is nil. It reports whether any count changed.
+func (r *RedisManager) projectTask(taskID int, task *models.Task) (bool, error) {
+	args := []interface{}{"0", taskID}
+	if task != nil {
+		loc := OrgSettings().Location()
+		state := task.CurrentState()
+		closed := ""
+		switch state {
+		case models.TaskDone:
+			closed = startOfDay(task.ClosedTime(), loc).Format("2006-01-02") + ":completed"
+		case models.TaskCancelled:
+			closed = startOfDay(task.ClosedTime(), loc).Format("2006-01-02") + ":cancelled"
+		}
+		args = []interface{}{"1", taskID, task.UserID, task.GroupID, state,
+			startOfDay(task.CreatedAt, loc).Format("2006-01-02") + ":created", closed}
+	}
+
+	changed, err := projectScript.Run(r.ctx, r.client, []string{projectedTaskKey(taskID), projectedTasksKey}, args...).Int()
+	return changed == 1, err
+}
+
+// ReconcileProjections projects every task again and drops the projections
+// of tasks that are gone, catching up on events this replica missed. It
+// returns how many tasks' counts changed.
+func (r *RedisManager) ReconcileProjections() (int, error) {
+	changed := 0
+	seen := make(map[int]bool)
+	err := r.eachTask("tasks:all", func(task *models.Task) error {
+		seen[task.ID] = true
+		moved, err := r.projectTask(task.ID, task)
+		if moved {
+			changed++
+		}
+		return err
+	})
+	if err != nil {
+		return changed, err
+	}
+
+	projected, err := r.client.SMembers(r.ctx, projectedTasksKey).Result()
+	if err != nil {
+		return changed, err
+	}
+	for _, idStr := range projected {
+		taskID, err := strconv.Atoi(idStr)
+		if err != nil || seen[taskID] {
+			continue
+		}
+		moved, err := r.projectTask(taskID, nil)
+		if err != nil {
+			return changed, err
+		}
+		if moved {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// ProjectedCounts are a user's or group's tasks by state, as projected
+type ProjectedCounts struct {
+	Open      int64 `json:"open"`
+	Blocked   int64 `json:"blocked"`
+	Done      int64 `json:"done"`
+	Cancelled int64 `json:"cancelled"`
+}
+
+// Pending counts the tasks still to be done, blocked ones included
+func (c *ProjectedCounts) Pending() int64 {
+	return c.Open + c.Blocked
+}
+
+func parseProjectedCounts(fields map[string]string) *ProjectedCounts {
+	counts := &ProjectedCounts{}
+	for state, value := range fields {
+		n, _ := strconv.ParseInt(value, 10, 64)
+		switch state {
+		case models.TaskOpen:
+			counts.Open = n
+		case models.TaskBlocked:
+			counts.Blocked = n
+		case models.TaskDone:
+			counts.Done = n
+		case models.TaskCancelled:
+			counts.Cancelled = n
+		}
+	}
+	return counts
+}
+
+// GetUserProjections reads the projected counts of each user
+func (r *RedisManager) GetUserProjections(userIDs []int) (map[int]*ProjectedCounts, error) {
+	pipe := r.client.Pipeline()
+	cmds := make(map[int]*redis.StringStringMapCmd, len(userIDs))
+	for _, userID := range userIDs {
+		cmds[userID] = pipe.HGetAll(r.ctx, userProjectionKey(userID))
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	counts := make(map[int]*ProjectedCounts, len(userIDs))
+	for userID, cmd := range cmds {
+		counts[userID] = parseProjectedCounts(cmd.Val())
+	}
+	return counts, nil
+}
+
+// GetGroupProjection reads a group's projected counts
+func (r *RedisManager) GetGroupProjection(groupID int) (*ProjectedCounts, error) {
+	fields, err := r.client.HGetAll(r.ctx, groupProjectionKey(groupID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseProjectedCounts(fields), nil
+}
+
+// GetGroupBurndownProjection builds a group's burndown from the days it
+// projected, with the same points as the PostgreSQL report but up to date
+// with Redis
+func (r *RedisManager) GetGroupBurndownProjection(groupID int, from, to time.Time) ([]*BurndownPoint, error) {
+	loc := OrgSettings().Location()
+	start := startOfDay(from, loc)
+	end := startOfDay(to, loc).AddDate(0, 0, 1)
+	days := int(end.Sub(start).Hours()/24 + 0.5)
+	if days < 1 || days > maxReportDays {
+		return nil, ErrInvalidReportRange
+	}
+
+	fields, err := r.client.HGetAll(r.ctx, groupDaysProjectionKey(groupID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	first := start.Format("2006-01-02")
+	open := int64(0)
+	byDay := make(map[string]*BurndownPoint)
+	for field, value := range fields {
+		day, kind, _ := strings.Cut(field, ":")
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n == 0 {
+			continue
+		}
+
+		// Days before the range only set where it starts
+		if day < first {
+			if kind == "created" {
+				open += n
+			} else {
+				open -= n
+			}
+			continue
+		}
+
+		point := byDay[day]
+		if point == nil {
+			point = &BurndownPoint{Date: day}
+			byDay[day] = point
+		}
+		switch kind {
+		case "created":
+			point.Created = n
+		case "completed":
+			point.Completed = n
+		case "cancelled":
+			point.Cancelled = n
+		}
+	}
+
+	points := make([]*BurndownPoint, 0, days)
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		point := byDay[key]
+		if point == nil {
+			point = &BurndownPoint{Date: key}
+		}
+		open += point.Created - point.Completed - point.Cancelled
+		point.Open = open
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// projectionsReconcileLockTTL bounds how long a crashed replica can block
+// reconciliation on the others
+const projectionsReconcileLockTTL = 5 * time.Minute
+
+// Projector keeps the dashboard projections from the task change events
+// every replica receives, and reconciles them on a schedule for events
+// missed while no replica was listening or dropped by a busy one
+type Projector struct {
+	interval time.Duration
+	events   chan *Event
+	done     chan struct{}
+	running  bool
+}
+
+var Projections *Projector
+
+func InitProjector(interval time.Duration) {
+	Projections = &Projector{
+		interval: interval,
+	}
+}
+
+// Start consumes task changes and registers the "projections" job, which
+// also builds the projections on start; with an interval of 0 it only runs
+// then and when triggered
+func (p *Projector) Start() {
+	if p.running {
+		return
+	}
+
+	p.running = true
+	p.events = Events.Subscribe()
+	p.done = make(chan struct{})
+	go p.consume(p.events, p.done)
+
+	Jobs.Register(JobSpec{
+		Name:       "projections",
+		Schedule:   EverySchedule(p.interval),
+		RunAtStart: true,
+		Run:        p.reconcile,
+	})
+}
+
+func (p *Projector) Stop() {
+	if !p.running {
+		return
+	}
+
+	p.running = false
+	Events.Unsubscribe(p.events)
+	<-p.done
+}
+
+// consume projects each changed task until the event hub closes the channel
+func (p *Projector) consume(events chan *Event, done chan struct{}) {
+	defer close(done)
+
+	for event := range events {
+		if event.Type != TaskChanged {
+			continue
+		}
+		// Events arrive decoded from JSON, so their data is a map
+		data, ok := event.Data.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, ok := data["id"].(float64)
+		if !ok {
+			continue
+		}
+
+		if err := p.project(int(id)); err != nil {
+			projectionLog.Warn("⚠️ Failed to project task", "task_id", int(id), "error", err)
+		}
+	}
+}
+
+// project reads a task again rather than trusting the event, so events
+// arriving out of order still leave its latest state
+func (p *Projector) project(taskID int) error {
+	task, err := RedisClient.GetTask(taskID)
+	if errors.Is(err, ErrNotFound) {
+		task, err = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = RedisClient.projectTask(taskID, task)
+	return err
+}
+
+// reconcile projects every task on one replica at a time
+func (p *Projector) reconcile() error {
+	err := RedisClient.WithLock("proj:reconcile", projectionsReconcileLockTTL, func() error {
+		changed, err := RedisClient.ReconcileProjections()
+		if err == nil && changed > 0 {
+			projectionLog.Info("📐 Caught up dashboard projections", "tasks", changed)
+		}
+		return err
+	})
+	if errors.Is(err, ErrLockNotAcquired) {
+		return nil
+	}
+	return err
+}
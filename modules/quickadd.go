@@ -0,0 +1,288 @@
+package modules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"time"
+)
+
+var (
+	quickTagPattern      = regexp.MustCompile(`^#([\pL\pN_-]+)$`)
+	quickAssigneePattern = regexp.MustCompile(`^@([\pL\pN._-]+)$`)
+	quickClockPattern    = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+)
+
+// quickPriorities are the !p1-!p4 markers, p1 being the most urgent
+var quickPriorities = map[string]models.Priority{
+	"p1": models.PriorityCritical,
+	"p2": models.PriorityHigh,
+	"p3": models.PriorityMedium,
+	"p4": models.PriorityLow,
+}
+
+// QuickTask is how a one-line task was read. Words that are not markers,
+// or that could not be read as one, stay in the title.
+type QuickTask struct {
+	Text     string          `json:"text"`
+	Title    string          `json:"title"`
+	Priority models.Priority `json:"priority,omitempty"`
+	Tags     []string        `json:"tags"`
+	Deadline string          `json:"deadline,omitempty"`
+	DueText  string          `json:"due_text,omitempty"`
+	Assignee string          `json:"assignee,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+}
+
+// ParseQuickTask reads a line such as "Fix login bug !p1 #backend due friday
+// 5pm @ali". It understands:
+//
+//	!p1 to !p4, or !low to !critical   priority, !p1 being critical
+//	#tag                               tags, lowercased
+//	@name                              the assignee, resolved by the caller
+//	due <day> [<time>]                 the deadline, in now's location
+//
+// A day is today, tomorrow, a weekday (the next one after today), next
+// week, in N days or weeks, a month and day such as oct 23, or YYYY-MM-DD.
+// A time is 5pm, 5:30pm, 17:00 or noon; a time alone means today, or
+// tomorrow once it has passed. Deadlines with a time are RFC 3339.
+func ParseQuickTask(text string, now time.Time) *QuickTask {
+	quick := &QuickTask{Text: text, Tags: []string{}}
+	words := strings.Fields(text)
+	var title []string
+
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		switch {
+		case strings.HasPrefix(word, "!") && len(word) > 1:
+			marker := strings.ToLower(word[1:])
+			priority, ok := quickPriorities[marker]
+			if !ok {
+				priority, ok = models.ParsePriority(marker)
+				ok = ok && !isDigits(marker)
+			}
+			if !ok {
+				title = append(title, word)
+				continue
+			}
+			if quick.Priority != 0 {
+				quick.Warnings = append(quick.Warnings, fmt.Sprintf("%s overrides an earlier priority", word))
+			}
+			quick.Priority = priority
+
+		case quickTagPattern.MatchString(word):
+			tag := strings.ToLower(word[1:])
+			if !contains(quick.Tags, tag) {
+				quick.Tags = append(quick.Tags, tag)
+			}
+
+		case quickAssigneePattern.MatchString(word):
+			if quick.Assignee != "" {
+				quick.Warnings = append(quick.Warnings, fmt.Sprintf("%s ignored, the task is already for @%s", word, quick.Assignee))
+				continue
+			}
+			quick.Assignee = word[1:]
+
+		case strings.EqualFold(word, "due") && i+1 < len(words):
+			deadline, used := parseQuickDue(words[i+1:], now)
+			if used == 0 {
+				title = append(title, word)
+				continue
+			}
+			if quick.Deadline != "" {
+				quick.Warnings = append(quick.Warnings, "a later due date overrides an earlier one")
+			}
+			quick.Deadline = deadline
+			quick.DueText = strings.Join(words[i+1:i+1+used], " ")
+			i += used
+
+		default:
+			title = append(title, word)
+		}
+	}
+
+	quick.Title = strings.Join(title, " ")
+	if quick.Deadline != "" && quick.Deadline[:10] < now.Format(models.LeaveDateLayout) {
+		quick.Warnings = append(quick.Warnings, "the deadline is in the past")
+	}
+	return quick
+}
+
+// parseQuickDue reads a day, a time or both from the start of words. It
+// returns the deadline and how many words it used, none if it read nothing.
+func parseQuickDue(words []string, now time.Time) (string, int) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	day, used := parseQuickDay(words, today)
+
+	rest := words[used:]
+	if len(rest) > 1 && strings.EqualFold(quickWord(rest[0]), "at") {
+		if clock, ok := parseQuickClock(rest[1]); ok {
+			return quickDeadline(day, used > 0, clock, today, now), used + 2
+		}
+	}
+	if len(rest) > 0 {
+		if clock, ok := parseQuickClock(rest[0]); ok {
+			return quickDeadline(day, used > 0, clock, today, now), used + 1
+		}
+	}
+	if used == 0 {
+		return "", 0
+	}
+	return day.Format(models.LeaveDateLayout), used
+}
+
+// quickDeadline puts a time on a day. Without a day it is today's time, or
+// tomorrow's once today's has passed.
+func quickDeadline(day time.Time, hasDay bool, clock time.Duration, today, now time.Time) string {
+	if !hasDay {
+		day = today
+		if !today.Add(clock).After(now) {
+			day = today.AddDate(0, 0, 1)
+		}
+	}
+	at := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location()).Add(clock)
+	return at.Format(time.RFC3339)
+}
+
+func parseQuickDay(words []string, today time.Time) (time.Time, int) {
+	first := strings.ToLower(quickWord(words[0]))
+	switch first {
+	case "today", "tod":
+		return today, 1
+	case "tomorrow", "tmr", "tmrw":
+		return today.AddDate(0, 0, 1), 1
+	}
+	if weekday, ok := parseQuickWeekday(first); ok {
+		days := (int(weekday) - int(today.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, days), 1
+	}
+	if date, err := time.ParseInLocation(models.LeaveDateLayout, first, today.Location()); err == nil {
+		return date, 1
+	}
+	if len(words) < 2 {
+		return today, 0
+	}
+
+	second := strings.ToLower(quickWord(words[1]))
+	if first == "next" && second == "week" {
+		return today.AddDate(0, 0, 7), 2
+	}
+	if first == "next" {
+		// "next friday" reads as the coming friday
+		if _, ok := parseQuickWeekday(second); ok {
+			day, _ := parseQuickDay(words[1:], today)
+			return day, 2
+		}
+	}
+	if month, ok := parseQuickMonth(first); ok {
+		if date, ok := quickMonthDay(month, second, today); ok {
+			return date, 2
+		}
+	}
+	if month, ok := parseQuickMonth(second); ok {
+		if date, ok := quickMonthDay(month, first, today); ok {
+			return date, 2
+		}
+	}
+	if first == "in" && len(words) > 2 {
+		n, err := strconv.Atoi(second)
+		if err == nil && n > 0 {
+			switch strings.ToLower(quickWord(words[2])) {
+			case "day", "days":
+				return today.AddDate(0, 0, n), 3
+			case "week", "weeks":
+				return today.AddDate(0, 0, 7*n), 3
+			}
+		}
+	}
+	return today, 0
+}
+
+// quickMonthDay is the next such date from today, this year or next
+func quickMonthDay(month time.Month, dayWord string, today time.Time) (time.Time, bool) {
+	dayWord = strings.TrimRight(dayWord, "stndrh")
+	day, err := strconv.Atoi(dayWord)
+	if err != nil || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	date := time.Date(today.Year(), month, day, 0, 0, 0, 0, today.Location())
+	if date.Month() != month {
+		return time.Time{}, false
+	}
+	if date.Before(today) {
+		date = date.AddDate(1, 0, 0)
+	}
+	return date, true
+}
+
+func parseQuickWeekday(word string) (time.Weekday, bool) {
+	if len(word) < 3 {
+		return 0, false
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if strings.HasPrefix(name, word) && (len(word) == 3 || word == name) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+func parseQuickMonth(word string) (time.Month, bool) {
+	if len(word) < 3 {
+		return 0, false
+	}
+	for month := time.January; month <= time.December; month++ {
+		if strings.HasPrefix(strings.ToLower(month.String()), word) {
+			return month, true
+		}
+	}
+	return 0, false
+}
+
+// parseQuickClock reads 5pm, 5:30pm, 17:00 or noon as a time of day
+func parseQuickClock(word string) (time.Duration, bool) {
+	word = strings.ToLower(quickWord(word))
+	if word == "noon" {
+		return 12 * time.Hour, true
+	}
+	match := quickClockPattern.FindStringSubmatch(word)
+	if match == nil || (match[2] == "" && match[3] == "") {
+		// A bare number is not a time
+		return 0, false
+	}
+	hour, _ := strconv.Atoi(match[1])
+	minute := 0
+	if match[2] != "" {
+		minute, _ = strconv.Atoi(match[2])
+	}
+	switch match[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, false
+		}
+		hour %= 12
+		if match[3] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, false
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, true
+}
+
+// quickWord drops punctuation a date is commonly written with
+func quickWord(word string) string {
+	return strings.TrimRight(word, ",.;")
+}
+
+func isDigits(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
@@ -0,0 +1,290 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"task-manager/config"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// quotaOverridesKey maps user IDs to the owner's overrides of their quotas
+const quotaOverridesKey = "quota:overrides"
+
+var quotaLog = Logger("quotas")
+
+// quotaScript counts a request against a user's daily and monthly counters
+// unless either is used up, so refused requests count against neither.
+// Limits of 0 are unlimited; the counters expire when their period ends.
+var quotaScript = redis.NewScript(`
+local dailyLimit = tonumber(ARGV[1])
+local monthlyLimit = tonumber(ARGV[2])
+
+local daily = tonumber(redis.call("GET", KEYS[1]) or "0")
+local monthly = tonumber(redis.call("GET", KEYS[2]) or "0")
+if (dailyLimit > 0 and daily >= dailyLimit) or (monthlyLimit > 0 and monthly >= monthlyLimit) then
+	return {0, daily, monthly}
+end
+
+daily = redis.call("INCR", KEYS[1])
+monthly = redis.call("INCR", KEYS[2])
+redis.call("PEXPIREAT", KEYS[1], ARGV[3])
+redis.call("PEXPIREAT", KEYS[2], ARGV[4])
+return {1, daily, monthly}`)
+
+// QuotaOverride replaces the default quotas of one user. A nil limit keeps
+// the default and 0 is unlimited.
+type QuotaOverride struct {
+	Daily   *int `json:"daily"`
+	Monthly *int `json:"monthly"`
+}
+
+// QuotaPeriod is a user's use of one quota period. Limit 0 is unlimited,
+// in which case Remaining is left out.
+type QuotaPeriod struct {
+	Limit     int       `json:"limit"`
+	Used      int       `json:"used"`
+	Remaining *int      `json:"remaining,omitempty"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// QuotaUsage is a user's use of their daily and monthly quotas
+type QuotaUsage struct {
+	UserID   int            `json:"user_id"`
+	Allowed  bool           `json:"-"`
+	Daily    QuotaPeriod    `json:"daily"`
+	Monthly  QuotaPeriod    `json:"monthly"`
+	Override *QuotaOverride `json:"override,omitempty"`
+}
+
+// APIQuotas limits how many requests each user makes per day and month in
+// the organization's timezone. Unlike rate limits, which smooth bursts, they
+// cap total use. The owner is never limited.
+type APIQuotas struct {
+	daily   int
+	monthly int
+}
+
+var Quotas *APIQuotas
+
+// InitQuotas reads the default quotas; 0 leaves a period unlimited
+func InitQuotas(cfg *config.Config) error {
+	if cfg == nil {
+		cfg = config.AppConfig
+	}
+	if cfg.APIDailyQuota < 0 || cfg.APIMonthlyQuota < 0 {
+		return fmt.Errorf("API_DAILY_QUOTA and API_MONTHLY_QUOTA must not be negative")
+	}
+
+	Quotas = &APIQuotas{daily: cfg.APIDailyQuota, monthly: cfg.APIMonthlyQuota}
+	return nil
+}
+
+// Defaults returns the daily and monthly quotas of users without an override
+func (q *APIQuotas) Defaults() (int, int) {
+	return q.daily, q.monthly
+}
+
+// quotaPeriods returns the counter keys of the day and month now falls in
+// and when each ends
+func quotaPeriods(userID int, now time.Time) (string, time.Time, string, time.Time) {
+	now = now.In(OrgSettings().Location())
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	dayKey := fmt.Sprintf("quota:user:%d:day:%s", userID, day.Format("2006-01-02"))
+	monthKey := fmt.Sprintf("quota:user:%d:month:%s", userID, month.Format("2006-01"))
+	return dayKey, day.AddDate(0, 0, 1), monthKey, month.AddDate(0, 1, 0)
+}
+
+// limits returns a user's daily and monthly quotas with their override
+func (q *APIQuotas) limits(userID int) (int, int, *QuotaOverride, error) {
+	daily, monthly := q.daily, q.monthly
+	override, err := RedisClient.GetQuotaOverride(userID)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if override != nil {
+		if override.Daily != nil {
+			daily = *override.Daily
+		}
+		if override.Monthly != nil {
+			monthly = *override.Monthly
+		}
+	}
+	return daily, monthly, override, nil
+}
+
+func newQuotaUsage(userID, daily, monthly int, override *QuotaOverride, used []int64, dayEnd, monthEnd time.Time) *QuotaUsage {
+	period := func(limit int, used int64, resetsAt time.Time) QuotaPeriod {
+		p := QuotaPeriod{Limit: limit, Used: int(used), ResetsAt: resetsAt}
+		if limit > 0 {
+			remaining := max(limit-int(used), 0)
+			p.Remaining = &remaining
+		}
+		return p
+	}
+	return &QuotaUsage{
+		UserID:   userID,
+		Daily:    period(daily, used[0], dayEnd),
+		Monthly:  period(monthly, used[1], monthEnd),
+		Override: override,
+	}
+}
+
+// Take counts a request against a user's quotas, reporting in Allowed
+// whether it was within them
+func (q *APIQuotas) Take(userID int) (*QuotaUsage, error) {
+	daily, monthly, override, err := q.limits(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	dayKey, dayEnd, monthKey, monthEnd := quotaPeriods(userID, time.Now())
+	values, err := quotaScript.Run(RedisClient.ctx, RedisClient.client, []string{dayKey, monthKey},
+		daily, monthly, dayEnd.UnixMilli(), monthEnd.UnixMilli()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := newQuotaUsage(userID, daily, monthly, override, values[1:], dayEnd, monthEnd)
+	usage.Allowed = values[0] == 1
+	return usage, nil
+}
+
+// Usage returns a user's use of their quotas without counting a request
+func (q *APIQuotas) Usage(userID int) (*QuotaUsage, error) {
+	daily, monthly, override, err := q.limits(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	dayKey, dayEnd, monthKey, monthEnd := quotaPeriods(userID, time.Now())
+	counts, err := RedisClient.client.MGet(RedisClient.ctx, dayKey, monthKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	used := make([]int64, len(counts))
+	for i, count := range counts {
+		if s, ok := count.(string); ok {
+			used[i], _ = strconv.ParseInt(s, 10, 64)
+		}
+	}
+
+	usage := newQuotaUsage(userID, daily, monthly, override, used, dayEnd, monthEnd)
+	usage.Allowed = (daily == 0 || used[0] < int64(daily)) && (monthly == 0 || used[1] < int64(monthly))
+	return usage, nil
+}
+
+// binding returns the limited period with the fewest requests left, or nil
+// when both are unlimited
+func (u *QuotaUsage) binding() *QuotaPeriod {
+	var binding *QuotaPeriod
+	for _, period := range []*QuotaPeriod{&u.Daily, &u.Monthly} {
+		if period.Remaining == nil {
+			continue
+		}
+		// A used-up period binds until it resets, even when the other has none left either
+		if binding == nil || *period.Remaining < *binding.Remaining ||
+			(*period.Remaining == 0 && period.ResetsAt.After(binding.ResetsAt)) {
+			binding = period
+		}
+	}
+	return binding
+}
+
+func (r *RedisManager) GetQuotaOverride(userID int) (*QuotaOverride, error) {
+	overrideJSON, err := r.client.HGet(r.ctx, quotaOverridesKey, strconv.Itoa(userID)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var override QuotaOverride
+	err = json.Unmarshal([]byte(overrideJSON), &override)
+	return &override, err
+}
+
+// GetQuotaOverrides returns every override by user ID
+func (r *RedisManager) GetQuotaOverrides() (map[int]*QuotaOverride, error) {
+	entries, err := r.client.HGetAll(r.ctx, quotaOverridesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[int]*QuotaOverride, len(entries))
+	for userIDStr, overrideJSON := range entries {
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			continue
+		}
+		var override QuotaOverride
+		if err := json.Unmarshal([]byte(overrideJSON), &override); err == nil {
+			overrides[userID] = &override
+		}
+	}
+	return overrides, nil
+}
+
+func (r *RedisManager) SetQuotaOverride(userID int, override *QuotaOverride) error {
+	overrideJSON, err := json.Marshal(override)
+	if err != nil {
+		return err
+	}
+	return r.client.HSet(r.ctx, quotaOverridesKey, strconv.Itoa(userID), overrideJSON).Err()
+}
+
+func (r *RedisManager) DeleteQuotaOverride(userID int) error {
+	return r.client.HDel(r.ctx, quotaOverridesKey, strconv.Itoa(userID)).Err()
+}
+
+// quotaExempt are paths that never count against quotas, so clients that
+// used theirs up can still check it and sign out
+var quotaExempt = map[string]bool{
+	"/health":      true,
+	"/quota":       true,
+	"/auth/logout": true,
+	"/auth/revoke": true,
+}
+
+// QuotaMiddleware counts each signed-in user's requests against their
+// quotas, refusing them with 429 once a quota is used up. Responses carry
+// X-Quota-Limit, X-Quota-Remaining and X-Quota-Reset for the quota nearest
+// to running out. Redis errors let the request through.
+func QuotaMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authCtx := GetAuthContext(r)
+		if Quotas == nil || r.Method == "OPTIONS" || quotaExempt[r.URL.Path] ||
+			authCtx == nil || authCtx.IsOwner || authCtx.User == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		usage, err := Quotas.Take(authCtx.User.ID)
+		if err != nil {
+			quotaLog.WarnContext(r.Context(), "⚠️ Quota check failed, allowing request", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if period := usage.binding(); period != nil {
+			w.Header().Set("X-Quota-Limit", strconv.Itoa(period.Limit))
+			w.Header().Set("X-Quota-Remaining", strconv.Itoa(*period.Remaining))
+			w.Header().Set("X-Quota-Reset", strconv.FormatInt(period.ResetsAt.Unix(), 10))
+			if !usage.Allowed {
+				seconds := int((time.Until(period.ResetsAt) + time.Second - 1) / time.Second)
+				w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+			}
+		}
+		if !usage.Allowed {
+			http.Error(w, "API quota exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
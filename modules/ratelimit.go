@@ -0,0 +1,468 @@
+package modules
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"task-manager/config"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Rate limit policy names. Route groups pick a policy by path; authPolicy
+// throttles failed sign-in attempts per client IP and intakePolicy throttles
+// intake form submissions per form and client IP.
+const (
+	defaultPolicy = "default"
+	authPolicy    = "auth"
+	intakePolicy  = "intake"
+)
+
+// rateLimitReloadInterval is how often the policy file is checked for changes
+const rateLimitReloadInterval = 10 * time.Second
+
+var rateLimitLog = Logger("ratelimit")
+
+// tokenBucketScript refills a bucket for the time elapsed since its last use
+// and takes cost tokens when at least one is available. A cost of 0 checks
+// the bucket without consuming it.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+
+local data = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(data[1]) or burst
+local ts = tonumber(data[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	allowed = 1
+	tokens = tokens - cost
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate) + 1000)
+return {allowed, math.floor(tokens), retry}`)
+
+// RatePolicy allows Rate requests per Per with bursts of up to Burst
+type RatePolicy struct {
+	Rate  int
+	Per   time.Duration
+	Burst int
+}
+
+// String formats the policy the way it is configured, e.g. "10/s:20"
+func (p RatePolicy) String() string {
+	unit := "s"
+	switch p.Per {
+	case time.Minute:
+		unit = "m"
+	case time.Hour:
+		unit = "h"
+	}
+	return fmt.Sprintf("%d/%s:%d", p.Rate, unit, p.Burst)
+}
+
+// RateLimitResult is the outcome of one bucket check
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// RateLimiter enforces per-route-group policies with token buckets in Redis,
+// so limits hold across replicas
+type RateLimiter struct {
+	mu       sync.RWMutex
+	policies map[string]RatePolicy
+	source   string
+
+	envPolicies string
+	file        string
+	fileModTime time.Time
+
+	stopChan chan bool
+	running  bool
+}
+
+var Limiter *RateLimiter
+
+// InitRateLimiter loads policies from RATE_LIMITS and, when set, RATE_LIMIT_FILE.
+// RATE_LIMITS=off without a file disables rate limiting.
+func InitRateLimiter(cfg *config.Config) error {
+	if cfg == nil {
+		cfg = config.AppConfig
+	}
+
+	envPolicies := cfg.RateLimits
+	if strings.EqualFold(envPolicies, "off") {
+		envPolicies = ""
+	}
+
+	Limiter = &RateLimiter{
+		envPolicies: envPolicies,
+		file:        cfg.RateLimitFile,
+		stopChan:    make(chan bool),
+	}
+
+	if err := Limiter.reload(); err != nil {
+		Limiter = nil
+		return err
+	}
+	return nil
+}
+
+// Start watches the policy file so edits apply without a restart
+func (l *RateLimiter) Start() {
+	if l == nil || l.file == "" || l.running {
+		return
+	}
+
+	l.running = true
+	go l.reloadLoop()
+}
+
+// Stop ends the policy file watcher
+func (l *RateLimiter) Stop() {
+	if l == nil || !l.running {
+		return
+	}
+
+	l.stopChan <- true
+	l.running = false
+}
+
+func (l *RateLimiter) reloadLoop() {
+	ticker := time.NewTicker(rateLimitReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			info, err := os.Stat(l.file)
+			if err != nil || info.ModTime().Equal(l.fileModTime) {
+				continue
+			}
+			if err := l.reload(); err != nil {
+				rateLimitLog.Warn("⚠️ Keeping previous rate limits", "file", l.file, "error", err)
+				continue
+			}
+			rateLimitLog.Info("🔄 Rate limits reloaded", "file", l.file)
+		case <-l.stopChan:
+			return
+		}
+	}
+}
+
+// reload rebuilds the policies from the environment and the policy file,
+// with file entries overriding environment entries of the same name
+func (l *RateLimiter) reload() error {
+	policies, err := ParseRatePolicies(l.envPolicies)
+	if err != nil {
+		return fmt.Errorf("RATE_LIMITS: %v", err)
+	}
+	source := "env"
+
+	var modTime time.Time
+	if l.file != "" {
+		info, err := os.Stat(l.file)
+		if err != nil {
+			return fmt.Errorf("RATE_LIMIT_FILE: %v", err)
+		}
+		modTime = info.ModTime()
+
+		filePolicies, err := readRatePolicyFile(l.file)
+		if err != nil {
+			return fmt.Errorf("RATE_LIMIT_FILE: %v", err)
+		}
+		for name, policy := range filePolicies {
+			policies[name] = policy
+		}
+		source = l.file
+	}
+
+	l.mu.Lock()
+	l.policies = policies
+	l.source = source
+	l.fileModTime = modTime
+	l.mu.Unlock()
+	return nil
+}
+
+// Enabled reports whether any policy is configured
+func (l *RateLimiter) Enabled() bool {
+	if l == nil {
+		return false
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.policies) > 0
+}
+
+// Policies returns the active policies keyed by name, in their configured form
+func (l *RateLimiter) Policies() map[string]string {
+	result := make(map[string]string)
+	if l == nil {
+		return result
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for name, policy := range l.policies {
+		result[name] = policy.String()
+	}
+	return result
+}
+
+// Source returns where the active policies were loaded from
+func (l *RateLimiter) Source() string {
+	if l == nil {
+		return ""
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.source
+}
+
+// hasPolicy reports whether the named policy is configured, without the default fallback
+func (l *RateLimiter) hasPolicy(name string) bool {
+	if l == nil {
+		return false
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, ok := l.policies[name]
+	return ok
+}
+
+// policy returns the named policy, falling back to the default policy
+func (l *RateLimiter) policy(name string) (RatePolicy, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if policy, ok := l.policies[name]; ok {
+		return policy, true
+	}
+	policy, ok := l.policies[defaultPolicy]
+	return policy, ok
+}
+
+// take checks the subject's bucket for the named policy, consuming cost tokens
+func (l *RateLimiter) take(policyName, subject string, cost int) (*RateLimitResult, error) {
+	policy, ok := l.policy(policyName)
+	if !ok {
+		return &RateLimitResult{Allowed: true}, nil
+	}
+
+	key := fmt.Sprintf("ratelimit:%s:%s", policyName, subject)
+	perMs := float64(policy.Rate) / float64(policy.Per.Milliseconds())
+	values, err := tokenBucketScript.Run(RedisClient.ctx, RedisClient.client, []string{key},
+		perMs, policy.Burst, time.Now().UnixMilli(), cost).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      policy.Burst,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+// AuthBlocked reports whether an IP has used up its failed sign-in allowance
+func (l *RateLimiter) AuthBlocked(ip string) (*RateLimitResult, bool) {
+	if !l.hasPolicy(authPolicy) {
+		return nil, false
+	}
+
+	result, err := l.take(authPolicy, "ip:"+ip, 0)
+	if err != nil {
+		rateLimitLog.Warn("⚠️ Rate limit check failed, allowing request", "error", err)
+		return nil, false
+	}
+	return result, !result.Allowed
+}
+
+// RecordAuthFailure counts a failed sign-in attempt against an IP
+func (l *RateLimiter) RecordAuthFailure(ip string) {
+	if !l.hasPolicy(authPolicy) {
+		return
+	}
+
+	if _, err := l.take(authPolicy, "ip:"+ip, 1); err != nil {
+		rateLimitLog.Warn("⚠️ Failed to record sign-in failure", "error", err)
+	}
+}
+
+// TakeIntakeSubmission counts an intake form submission from an IP and
+// reports whether it is over the allowance
+func (l *RateLimiter) TakeIntakeSubmission(formID int, ip string) (*RateLimitResult, bool) {
+	if !l.hasPolicy(intakePolicy) {
+		return nil, false
+	}
+
+	result, err := l.take(intakePolicy, fmt.Sprintf("form:%d:ip:%s", formID, ip), 1)
+	if err != nil {
+		rateLimitLog.Warn("⚠️ Rate limit check failed, allowing request", "error", err)
+		return nil, false
+	}
+	return result, !result.Allowed
+}
+
+// RateLimitMiddleware limits each caller per route group: authenticated callers
+// by user, anonymous ones by IP. Redis errors let the request through.
+func RateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !Limiter.Enabled() || r.Method == "OPTIONS" || r.URL.Path == "/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		subject := "ip:" + ClientIP(r)
+		if authCtx := GetAuthContext(r); authCtx != nil {
+			if authCtx.IsOwner {
+				subject = "owner"
+			} else if authCtx.User != nil {
+				subject = fmt.Sprintf("user:%d", authCtx.User.ID)
+			}
+		}
+
+		result, err := Limiter.take(RouteGroup(r.URL.Path), subject, 1)
+		if err != nil {
+			rateLimitLog.WarnContext(r.Context(), "⚠️ Rate limit check failed, allowing request", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if result.Limit > 0 {
+			setRateLimitHeaders(w, result)
+		}
+		if !result.Allowed {
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, result *RateLimitResult) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(max(result.Remaining, 0)))
+	if !result.Allowed {
+		seconds := int((result.RetryAfter + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	}
+}
+
+// RouteGroup names the rate limit policy that applies to a path
+func RouteGroup(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/admin/"):
+		return "admin"
+	case path == "/stream", path == "/ws":
+		return "stream"
+	case path == "/search", path == "/tasks/filter", strings.HasSuffix(path, "/search"):
+		return "search"
+	case path == "/tasks/batch", strings.HasSuffix(path, "/users/batch"):
+		return "batch"
+	default:
+		return defaultPolicy
+	}
+}
+
+// ParseRatePolicies parses "name=rate/unit:burst" entries separated by commas,
+// e.g. "default=20/s:40,auth=5/m:5". The burst defaults to the rate.
+func ParseRatePolicies(value string) (map[string]RatePolicy, error) {
+	policies := make(map[string]RatePolicy)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid policy %q, expected name=rate/unit:burst", entry)
+		}
+		policy, err := parseRatePolicy(strings.TrimSpace(spec))
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %v", strings.TrimSpace(name), err)
+		}
+		policies[strings.TrimSpace(name)] = policy
+	}
+	return policies, nil
+}
+
+func parseRatePolicy(spec string) (RatePolicy, error) {
+	var policy RatePolicy
+
+	rateSpec, burstSpec, hasBurst := strings.Cut(spec, ":")
+	count, unit, ok := strings.Cut(rateSpec, "/")
+	if !ok {
+		return policy, fmt.Errorf("invalid rate %q, expected e.g. 10/s", rateSpec)
+	}
+
+	rate, err := strconv.Atoi(count)
+	if err != nil || rate <= 0 {
+		return policy, fmt.Errorf("invalid rate %q", count)
+	}
+	policy.Rate = rate
+	policy.Burst = rate
+
+	switch unit {
+	case "s":
+		policy.Per = time.Second
+	case "m":
+		policy.Per = time.Minute
+	case "h":
+		policy.Per = time.Hour
+	default:
+		return policy, fmt.Errorf("invalid unit %q, must be s, m or h", unit)
+	}
+
+	if hasBurst {
+		burst, err := strconv.Atoi(burstSpec)
+		if err != nil || burst <= 0 {
+			return policy, fmt.Errorf("invalid burst %q", burstSpec)
+		}
+		policy.Burst = burst
+	}
+	return policy, nil
+}
+
+// readRatePolicyFile reads one policy per line; blank lines and # comments are ignored
+func readRatePolicyFile(path string) (map[string]RatePolicy, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return ParseRatePolicies(strings.Join(entries, ","))
+}
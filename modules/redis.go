@@ -13,6 +13,8 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
+var redisLog = Logger("redis")
+
 type RedisManager struct {
 	client *redis.Client
 	ctx    context.Context
@@ -49,6 +51,7 @@ func InitRedis(cfg *config.Config) error {
 		// Test connection
 		_, err := client.Ping(ctx).Result()
 		if err == nil {
+			installRedisChaos(client)
 			RedisClient = &RedisManager{
 				client: client,
 				ctx:    ctx,
@@ -95,16 +98,71 @@ func (r *RedisManager) GetConnectionInfo() map[string]interface{} {
 	}
 }
 
+// setIfVersion writes value to key only while the stored entity still carries expectedVersion
+func (r *RedisManager) setIfVersion(key string, expectedVersion int, value []byte) error {
+	return r.client.Watch(r.ctx, func(tx *redis.Tx) error {
+		currentJSON, err := tx.Get(r.ctx, key).Result()
+		if err == redis.Nil {
+			return fmt.Errorf("%s %w", key, ErrNotFound)
+		}
+		if err != nil {
+			return err
+		}
+
+		var current struct {
+			Version int `json:"version"`
+		}
+		if err := json.Unmarshal([]byte(currentJSON), &current); err != nil {
+			return err
+		}
+		if current.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+
+		_, err = tx.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(r.ctx, key, value, 0)
+			return nil
+		})
+		if err == redis.TxFailedErr {
+			return ErrVersionConflict
+		}
+		return err
+	}, key)
+}
+
 // User operations
 func (r *RedisManager) SaveUser(user *models.User) error {
+	return r.saveUser(user, false)
+}
+
+// SaveUserIfVersion saves the user only if it is still at the given version
+func (r *RedisManager) SaveUserIfVersion(user *models.User, version int) error {
+	user.Version = version
+	return r.saveUser(user, true)
+}
+
+func (r *RedisManager) saveUser(user *models.User, checkVersion bool) error {
+	var previousGroups []int
+	if previous, err := r.GetUser(user.ID); err == nil {
+		previousGroups = previous.GroupIDs
+	}
+
+	expectedVersion := user.Version
+	user.Version++
+
 	userJSON, err := json.Marshal(user)
 	if err != nil {
 		return err
 	}
 
 	key := fmt.Sprintf("user:%d", user.ID)
-	err = r.client.Set(r.ctx, key, userJSON, 0).Err()
+	if checkVersion {
+		err = r.setIfVersion(key, expectedVersion, userJSON)
+	} else {
+		err = r.client.Set(r.ctx, key, userJSON, 0).Err()
+	}
 	if err != nil {
+		user.Version = expectedVersion
 		return err
 	}
 
@@ -113,11 +171,19 @@ func (r *RedisManager) SaveUser(user *models.User) error {
 
 	// Add to email index
 	r.client.Set(r.ctx, fmt.Sprintf("user:email:%s", user.Email), user.ID, 0)
+	r.publishExisting(r.client, "email", user.Email)
 
-	// Add to group indexes
+	// Add to group indexes, and take the user out of groups they left
 	for _, groupID := range user.GroupIDs {
 		r.client.SAdd(r.ctx, fmt.Sprintf("group:%d:users", groupID), user.ID)
 	}
+	for _, groupID := range previousGroups {
+		if !containsID(user.GroupIDs, groupID) {
+			r.client.SRem(r.ctx, fmt.Sprintf("group:%d:users", groupID), user.ID)
+		}
+	}
+	r.recordMembershipChanges(user, previousGroups, user.GroupIDs)
+	r.publishChange(UserChanged, user.ID, 0, &Change{ID: user.ID, Op: ChangeSaved, Version: user.Version})
 
 	return nil
 }
@@ -126,7 +192,7 @@ func (r *RedisManager) GetUser(userID int) (*models.User, error) {
 	key := fmt.Sprintf("user:%d", userID)
 	userJSON, err := r.client.Get(r.ctx, key).Result()
 	if err == redis.Nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, fmt.Errorf("user %w", ErrNotFound)
 	}
 	if err != nil {
 		return nil, err
@@ -140,7 +206,7 @@ func (r *RedisManager) GetUser(userID int) (*models.User, error) {
 func (r *RedisManager) GetUserByEmail(email string) (*models.User, error) {
 	userIDStr, err := r.client.Get(r.ctx, fmt.Sprintf("user:email:%s", email)).Result()
 	if err == redis.Nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, fmt.Errorf("user %w", ErrNotFound)
 	}
 	if err != nil {
 		return nil, err
@@ -154,6 +220,15 @@ func (r *RedisManager) GetUserByEmail(email string) (*models.User, error) {
 	return r.GetUser(userID)
 }
 
+// FindUserByEmail is GetUserByEmail for lookups that usually miss, such as
+// uniqueness checks, and skips Redis when no user ever had the email
+func (r *RedisManager) FindUserByEmail(email string) (*models.User, error) {
+	if !Existence.MightHaveEmail(email) {
+		return nil, fmt.Errorf("user %w", ErrNotFound)
+	}
+	return r.GetUserByEmail(email)
+}
+
 func (r *RedisManager) GetAllUsers() ([]*models.User, error) {
 	userIDs, err := r.client.SMembers(r.ctx, "users:all").Result()
 	if err != nil {
@@ -190,10 +265,19 @@ func (r *RedisManager) DeleteUser(userID int) error {
 	for _, groupID := range user.GroupIDs {
 		r.client.SRem(r.ctx, fmt.Sprintf("group:%d:users", groupID), userID)
 	}
+	r.recordMembershipChanges(user, user.GroupIDs, nil)
+	r.DeletePasswordHistory(userID)
+	r.client.Del(r.ctx, signInRevocationsKey(userID))
+	r.client.Del(r.ctx, offboardingKey(userID))
+	r.DeletePendingNotifications(userID)
 
 	// Delete user data
 	key := fmt.Sprintf("user:%d", userID)
-	return r.client.Del(r.ctx, key).Err()
+	if err := r.client.Del(r.ctx, key).Err(); err != nil {
+		return err
+	}
+	r.publishChange(UserChanged, userID, 0, &Change{ID: userID, Op: ChangeDeleted})
+	return nil
 }
 
 func (r *RedisManager) SearchUsers(query string) ([]*models.User, error) {
@@ -217,14 +301,32 @@ func (r *RedisManager) SearchUsers(query string) ([]*models.User, error) {
 
 // Group operations
 func (r *RedisManager) SaveGroup(group *models.Group) error {
+	return r.saveGroup(group, false)
+}
+
+// SaveGroupIfVersion saves the group only if it is still at the given version
+func (r *RedisManager) SaveGroupIfVersion(group *models.Group, version int) error {
+	group.Version = version
+	return r.saveGroup(group, true)
+}
+
+func (r *RedisManager) saveGroup(group *models.Group, checkVersion bool) error {
+	expectedVersion := group.Version
+	group.Version++
+
 	groupJSON, err := json.Marshal(group)
 	if err != nil {
 		return err
 	}
 
 	key := fmt.Sprintf("group:%d", group.ID)
-	err = r.client.Set(r.ctx, key, groupJSON, 0).Err()
+	if checkVersion {
+		err = r.setIfVersion(key, expectedVersion, groupJSON)
+	} else {
+		err = r.client.Set(r.ctx, key, groupJSON, 0).Err()
+	}
 	if err != nil {
+		group.Version = expectedVersion
 		return err
 	}
 
@@ -233,6 +335,7 @@ func (r *RedisManager) SaveGroup(group *models.Group) error {
 
 	// Add to admin index
 	r.client.SAdd(r.ctx, fmt.Sprintf("user:%d:admin_groups", group.AdminID), group.ID)
+	r.publishChange(GroupChanged, 0, group.ID, &Change{ID: group.ID, Op: ChangeSaved, Version: group.Version})
 
 	return nil
 }
@@ -241,7 +344,7 @@ func (r *RedisManager) GetGroup(groupID int) (*models.Group, error) {
 	key := fmt.Sprintf("group:%d", groupID)
 	groupJSON, err := r.client.Get(r.ctx, key).Result()
 	if err == redis.Nil {
-		return nil, fmt.Errorf("group not found")
+		return nil, fmt.Errorf("group %w", ErrNotFound)
 	}
 	if err != nil {
 		return nil, err
@@ -325,38 +428,116 @@ func (r *RedisManager) DeleteGroup(groupID int) error {
 
 	// Remove users from group index
 	r.client.Del(r.ctx, fmt.Sprintf("group:%d:users", groupID))
+	r.client.Del(r.ctx, groupVisibleTasksKey(groupID))
 
 	// Delete group data
 	key := fmt.Sprintf("group:%d", groupID)
-	return r.client.Del(r.ctx, key).Err()
+	if err := r.client.Del(r.ctx, key).Err(); err != nil {
+		return err
+	}
+	r.publishChange(GroupChanged, 0, groupID, &Change{ID: groupID, Op: ChangeDeleted})
+	return nil
 }
 
 // Task operations
 func (r *RedisManager) SaveTask(task *models.Task) error {
+	return r.saveTask(task, false)
+}
+
+// SaveTaskIfVersion saves the task only if it is still at the given version
+func (r *RedisManager) SaveTaskIfVersion(task *models.Task, version int) error {
+	task.Version = version
+	return r.saveTask(task, true)
+}
+
+func (r *RedisManager) saveTask(task *models.Task, checkVersion bool) error {
+	// A buffered write of the same task goes first, so this one lands on top
+	TaskWrites.settle(task.ID)
+
+	expectedVersion := task.Version
+	task.Version++
+
+	if err := r.writeTask(task, expectedVersion, checkVersion); err != nil {
+		task.Version = expectedVersion
+		return err
+	}
+	return nil
+}
+
+// writeTask stores a task that already carries its new version, along with
+// its indexes and deadline reminder
+func (r *RedisManager) writeTask(task *models.Task, expectedVersion int, checkVersion bool) error {
+	task.SyncState()
 	taskJSON, err := json.Marshal(task)
 	if err != nil {
 		return err
 	}
 
+	previous := r.storedPlacement(task.ID)
+
 	key := fmt.Sprintf("task:%d", task.ID)
-	err = r.client.Set(r.ctx, key, taskJSON, 0).Err()
+	if checkVersion {
+		err = r.setIfVersion(key, expectedVersion, taskJSON)
+	} else {
+		err = r.client.Set(r.ctx, key, taskJSON, 0).Err()
+	}
 	if err != nil {
 		return err
 	}
 
-	// Add to indexes
-	r.client.SAdd(r.ctx, "tasks:all", task.ID)
-	r.client.SAdd(r.ctx, fmt.Sprintf("user:%d:tasks", task.UserID), task.ID)
-	r.client.SAdd(r.ctx, fmt.Sprintf("group:%d:tasks", task.GroupID), task.ID)
+	pipe := r.client.TxPipeline()
+	r.indexTask(pipe, previous, task)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return err
+	}
+	if task.Key != "" {
+		r.client.Set(r.ctx, taskKeyIndexKey(task.Key), task.ID, 0)
+	}
+
+	// Keep the deadline reminder in step with the saved task
+	if err := r.ScheduleDeadlineReminder(task); err != nil {
+		remindersLog.Warn("⚠️ Failed to schedule deadline reminder", "task_id", task.ID, "error", err)
+	}
+
+	if previous != nil && (previous.UserID != task.UserID || previous.GroupID != task.GroupID) {
+		r.publishChange(TaskChanged, previous.UserID, previous.GroupID, &Change{ID: task.ID, Op: ChangeMoved, Version: task.Version})
+	}
+	r.publishTaskChange(task, &Change{ID: task.ID, Op: ChangeSaved, Version: task.Version, State: task.State})
 
 	return nil
 }
 
+// indexTask adds a saved task to its indexes and counters, leaving those of
+// the user or group it moved from
+func (r *RedisManager) indexTask(pipe redis.Pipeliner, previous *taskPlacement, task *models.Task) {
+	pipe.SAdd(r.ctx, "tasks:all", task.ID)
+	if previous != nil && previous.UserID != task.UserID {
+		pipe.SRem(r.ctx, fmt.Sprintf("user:%d:tasks", previous.UserID), task.ID)
+	}
+	pipe.SAdd(r.ctx, fmt.Sprintf("user:%d:tasks", task.UserID), task.ID)
+	if previous != nil && previous.GroupID != task.GroupID {
+		r.unindexGroupTask(pipe, previous.GroupID, task.ID)
+	}
+	r.indexGroupTask(pipe, task)
+	r.indexSharedTask(pipe, previous, task)
+	r.indexVisibleTask(pipe, previous, task)
+	r.indexSubtask(pipe, previous, task)
+	current := placementOf(task)
+	r.countTask(pipe, previous, current)
+	r.countTaskEvents(pipe, previous, current)
+	pipe.SAdd(r.ctx, dirtyTaskGroupsKey, task.GroupID)
+	r.publishExisting(pipe, "task", task.ID)
+}
+
 func (r *RedisManager) GetTask(taskID int) (*models.Task, error) {
+	if task, ok := TaskWrites.lookup(taskID); ok {
+		return task, nil
+	}
+
 	key := fmt.Sprintf("task:%d", taskID)
 	taskJSON, err := r.client.Get(r.ctx, key).Result()
 	if err == redis.Nil {
-		return nil, fmt.Errorf("task not found")
+		return nil, fmt.Errorf("task %w", ErrNotFound)
 	}
 	if err != nil {
 		return nil, err
@@ -368,6 +549,8 @@ func (r *RedisManager) GetTask(taskID int) (*models.Task, error) {
 }
 
 func (r *RedisManager) GetUserTasks(userID int) ([]*models.Task, error) {
+	TaskWrites.Flush()
+
 	taskIDs, err := r.client.SMembers(r.ctx, fmt.Sprintf("user:%d:tasks", userID)).Result()
 	if err != nil {
 		return nil, err
@@ -390,6 +573,8 @@ func (r *RedisManager) GetUserTasks(userID int) ([]*models.Task, error) {
 }
 
 func (r *RedisManager) GetGroupTasks(groupID int) ([]*models.Task, error) {
+	TaskWrites.Flush()
+
 	taskIDs, err := r.client.SMembers(r.ctx, fmt.Sprintf("group:%d:tasks", groupID)).Result()
 	if err != nil {
 		return nil, err
@@ -412,6 +597,8 @@ func (r *RedisManager) GetGroupTasks(groupID int) ([]*models.Task, error) {
 }
 
 func (r *RedisManager) DeleteTask(taskID int) error {
+	TaskWrites.settle(taskID)
+
 	// Get task first to remove from indexes
 	task, err := r.GetTask(taskID)
 	if err != nil {
@@ -419,16 +606,45 @@ func (r *RedisManager) DeleteTask(taskID int) error {
 	}
 
 	// Remove from indexes
-	r.client.SRem(r.ctx, "tasks:all", taskID)
+	removed, _ := r.client.SRem(r.ctx, "tasks:all", taskID).Result()
 	r.client.SRem(r.ctx, fmt.Sprintf("user:%d:tasks", task.UserID), taskID)
-	r.client.SRem(r.ctx, fmt.Sprintf("group:%d:tasks", task.GroupID), taskID)
+	pipe := r.client.TxPipeline()
+	r.unindexGroupTask(pipe, task.GroupID, taskID)
+	for _, userID := range task.Collaborators {
+		pipe.SRem(r.ctx, sharedTasksKey(userID), taskID)
+	}
+	for _, groupID := range task.VisibleToGroups {
+		pipe.SRem(r.ctx, groupVisibleTasksKey(groupID), taskID)
+	}
+	if task.ParentTaskID != 0 {
+		pipe.SRem(r.ctx, subtasksKey(task.ParentTaskID), taskID)
+	}
+	if removed > 0 {
+		// Only the delete that took the task out of the index uncounts it
+		r.countTask(pipe, placementOf(task), nil)
+	}
+	pipe.Exec(r.ctx)
+	if task.Key != "" {
+		r.client.Del(r.ctx, taskKeyIndexKey(task.Key))
+	}
+	r.DeleteTaskReminders(taskID)
+	r.DeleteTaskVotes(taskID)
+	r.DeleteTaskComments(taskID)
+	r.DeleteTaskAttachments(taskID)
 
 	// Delete task data
 	key := fmt.Sprintf("task:%d", taskID)
-	return r.client.Del(r.ctx, key).Err()
+	if err := r.client.Del(r.ctx, key).Err(); err != nil {
+		return err
+	}
+	r.publishTaskChange(task, &Change{ID: taskID, Op: ChangeDeleted})
+	r.detachSubtasks(taskID)
+	return nil
 }
 
 func (r *RedisManager) SearchTasks(query string) ([]*models.SearchTask, error) {
+	TaskWrites.Flush()
+
 	taskIDs, err := r.client.SMembers(r.ctx, "tasks:all").Result()
 	if err != nil {
 		return nil, err
@@ -449,7 +665,8 @@ func (r *RedisManager) SearchTasks(query string) ([]*models.SearchTask, error) {
 		}
 
 		if strings.Contains(strings.ToLower(task.Title), lowerQuery) ||
-			strings.Contains(strings.ToLower(task.Information), lowerQuery) {
+			strings.Contains(strings.ToLower(task.Information), lowerQuery) ||
+			(task.Key != "" && strings.EqualFold(task.Key, query)) {
 			results = append(results, &models.SearchTask{
 				UserID: task.UserID,
 				Task:   *task,
@@ -460,7 +677,456 @@ func (r *RedisManager) SearchTasks(query string) ([]*models.SearchTask, error) {
 	return results, nil
 }
 
+// Leave request operations
+func (r *RedisManager) SaveLeaveRequest(leave *models.LeaveRequest) error {
+	leaveJSON, err := json.Marshal(leave)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("leave:%d", leave.ID)
+	err = r.client.Set(r.ctx, key, leaveJSON, 0).Err()
+	if err != nil {
+		return err
+	}
+
+	// Add to indexes
+	r.client.SAdd(r.ctx, "leaves:all", leave.ID)
+	r.client.SAdd(r.ctx, fmt.Sprintf("user:%d:leaves", leave.UserID), leave.ID)
+
+	return nil
+}
+
+func (r *RedisManager) GetLeaveRequest(leaveID int) (*models.LeaveRequest, error) {
+	key := fmt.Sprintf("leave:%d", leaveID)
+	leaveJSON, err := r.client.Get(r.ctx, key).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("leave request %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var leave models.LeaveRequest
+	err = json.Unmarshal([]byte(leaveJSON), &leave)
+	return &leave, err
+}
+
+func (r *RedisManager) GetUserLeaveRequests(userID int) ([]*models.LeaveRequest, error) {
+	leaveIDs, err := r.client.SMembers(r.ctx, fmt.Sprintf("user:%d:leaves", userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.getLeaveRequestsByIDs(leaveIDs), nil
+}
+
+func (r *RedisManager) GetAllLeaveRequests() ([]*models.LeaveRequest, error) {
+	leaveIDs, err := r.client.SMembers(r.ctx, "leaves:all").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.getLeaveRequestsByIDs(leaveIDs), nil
+}
+
+func (r *RedisManager) getLeaveRequestsByIDs(leaveIDs []string) []*models.LeaveRequest {
+	var leaves []*models.LeaveRequest
+	for _, leaveIDStr := range leaveIDs {
+		leaveID, err := strconv.Atoi(leaveIDStr)
+		if err != nil {
+			continue
+		}
+
+		leave, err := r.GetLeaveRequest(leaveID)
+		if err == nil {
+			leaves = append(leaves, leave)
+		}
+	}
+
+	return leaves
+}
+
+func (r *RedisManager) DeleteLeaveRequest(leaveID int) error {
+	// Get leave first to remove from indexes
+	leave, err := r.GetLeaveRequest(leaveID)
+	if err != nil {
+		return err
+	}
+
+	// Remove from indexes
+	r.client.SRem(r.ctx, "leaves:all", leaveID)
+	r.client.SRem(r.ctx, fmt.Sprintf("user:%d:leaves", leave.UserID), leaveID)
+
+	// Delete leave data
+	key := fmt.Sprintf("leave:%d", leaveID)
+	return r.client.Del(r.ctx, key).Err()
+}
+
+// IsUserOnLeave reports whether the user has an approved leave covering the given day
+func (r *RedisManager) IsUserOnLeave(userID int, day time.Time) bool {
+	leaves, err := r.GetUserLeaveRequests(userID)
+	if err != nil {
+		return false
+	}
+
+	for _, leave := range leaves {
+		if leave.Status == "approved" && leave.Covers(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// Report subscription operations
+func (r *RedisManager) SaveReportSubscription(sub *models.ReportSubscription) error {
+	subJSON, err := json.Marshal(sub)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("report_sub:%d", sub.ID)
+	err = r.client.Set(r.ctx, key, subJSON, 0).Err()
+	if err != nil {
+		return err
+	}
+
+	// Add to indexes
+	r.client.SAdd(r.ctx, "report_subs:all", sub.ID)
+	r.client.SAdd(r.ctx, fmt.Sprintf("user:%d:report_subs", sub.UserID), sub.ID)
+
+	return nil
+}
+
+func (r *RedisManager) GetReportSubscription(subID int) (*models.ReportSubscription, error) {
+	key := fmt.Sprintf("report_sub:%d", subID)
+	subJSON, err := r.client.Get(r.ctx, key).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("report subscription %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sub models.ReportSubscription
+	err = json.Unmarshal([]byte(subJSON), &sub)
+	return &sub, err
+}
+
+func (r *RedisManager) GetUserReportSubscriptions(userID int) ([]*models.ReportSubscription, error) {
+	subIDs, err := r.client.SMembers(r.ctx, fmt.Sprintf("user:%d:report_subs", userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.getReportSubscriptionsByIDs(subIDs), nil
+}
+
+func (r *RedisManager) GetAllReportSubscriptions() ([]*models.ReportSubscription, error) {
+	subIDs, err := r.client.SMembers(r.ctx, "report_subs:all").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.getReportSubscriptionsByIDs(subIDs), nil
+}
+
+func (r *RedisManager) getReportSubscriptionsByIDs(subIDs []string) []*models.ReportSubscription {
+	var subs []*models.ReportSubscription
+	for _, subIDStr := range subIDs {
+		subID, err := strconv.Atoi(subIDStr)
+		if err != nil {
+			continue
+		}
+
+		sub, err := r.GetReportSubscription(subID)
+		if err == nil {
+			subs = append(subs, sub)
+		}
+	}
+
+	return subs
+}
+
+func (r *RedisManager) DeleteReportSubscription(subID int) error {
+	// Get subscription first to remove from indexes
+	sub, err := r.GetReportSubscription(subID)
+	if err != nil {
+		return err
+	}
+
+	// Remove from indexes
+	r.client.SRem(r.ctx, "report_subs:all", subID)
+	r.client.SRem(r.ctx, fmt.Sprintf("user:%d:report_subs", sub.UserID), subID)
+
+	// Delete subscription data
+	key := fmt.Sprintf("report_sub:%d", subID)
+	return r.client.Del(r.ctx, key).Err()
+}
+
+// Client operations
+func (r *RedisManager) SaveClient(client *models.Client) error {
+	clientJSON, err := json.Marshal(client)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("client:%d", client.ID)
+	err = r.client.Set(r.ctx, key, clientJSON, 0).Err()
+	if err != nil {
+		return err
+	}
+
+	// Add to indexes
+	r.client.SAdd(r.ctx, "clients:all", client.ID)
+
+	return nil
+}
+
+func (r *RedisManager) GetClient(clientID int) (*models.Client, error) {
+	key := fmt.Sprintf("client:%d", clientID)
+	clientJSON, err := r.client.Get(r.ctx, key).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("client %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var client models.Client
+	err = json.Unmarshal([]byte(clientJSON), &client)
+	return &client, err
+}
+
+func (r *RedisManager) GetAllClients() ([]*models.Client, error) {
+	clientIDs, err := r.client.SMembers(r.ctx, "clients:all").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var clients []*models.Client
+	for _, clientIDStr := range clientIDs {
+		clientID, err := strconv.Atoi(clientIDStr)
+		if err != nil {
+			continue
+		}
+
+		client, err := r.GetClient(clientID)
+		if err == nil {
+			clients = append(clients, client)
+		}
+	}
+
+	return clients, nil
+}
+
+func (r *RedisManager) DeleteClient(clientID int) error {
+	r.client.SRem(r.ctx, "clients:all", clientID)
+
+	key := fmt.Sprintf("client:%d", clientID)
+	return r.client.Del(r.ctx, key).Err()
+}
+
+// Risk operations
+func (r *RedisManager) SaveRisk(risk *models.Risk) error {
+	riskJSON, err := json.Marshal(risk)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("risk:%d", risk.ID)
+	err = r.client.Set(r.ctx, key, riskJSON, 0).Err()
+	if err != nil {
+		return err
+	}
+
+	// Add to indexes
+	r.client.SAdd(r.ctx, "risks:all", risk.ID)
+	r.client.SAdd(r.ctx, fmt.Sprintf("group:%d:risks", risk.GroupID), risk.ID)
+
+	return nil
+}
+
+func (r *RedisManager) GetRisk(riskID int) (*models.Risk, error) {
+	key := fmt.Sprintf("risk:%d", riskID)
+	riskJSON, err := r.client.Get(r.ctx, key).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("risk %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var risk models.Risk
+	err = json.Unmarshal([]byte(riskJSON), &risk)
+	return &risk, err
+}
+
+func (r *RedisManager) getRisks(indexKey string) ([]*models.Risk, error) {
+	riskIDs, err := r.client.SMembers(r.ctx, indexKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var risks []*models.Risk
+	for _, riskIDStr := range riskIDs {
+		riskID, err := strconv.Atoi(riskIDStr)
+		if err != nil {
+			continue
+		}
+
+		risk, err := r.GetRisk(riskID)
+		if err == nil {
+			risks = append(risks, risk)
+		}
+	}
+
+	return risks, nil
+}
+
+func (r *RedisManager) GetAllRisks() ([]*models.Risk, error) {
+	return r.getRisks("risks:all")
+}
+
+func (r *RedisManager) GetGroupRisks(groupID int) ([]*models.Risk, error) {
+	return r.getRisks(fmt.Sprintf("group:%d:risks", groupID))
+}
+
+func (r *RedisManager) DeleteRisk(riskID int) error {
+	risk, err := r.GetRisk(riskID)
+	if err != nil {
+		return err
+	}
+
+	r.client.SRem(r.ctx, "risks:all", riskID)
+	r.client.SRem(r.ctx, fmt.Sprintf("group:%d:risks", risk.GroupID), riskID)
+
+	key := fmt.Sprintf("risk:%d", riskID)
+	return r.client.Del(r.ctx, key).Err()
+}
+
+// Objective operations
+func (r *RedisManager) SaveObjective(objective *models.Objective) error {
+	objectiveJSON, err := json.Marshal(objective)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("objective:%d", objective.ID)
+	err = r.client.Set(r.ctx, key, objectiveJSON, 0).Err()
+	if err != nil {
+		return err
+	}
+
+	// Add to indexes
+	r.client.SAdd(r.ctx, "objectives:all", objective.ID)
+
+	return nil
+}
+
+func (r *RedisManager) GetObjective(objectiveID int) (*models.Objective, error) {
+	key := fmt.Sprintf("objective:%d", objectiveID)
+	objectiveJSON, err := r.client.Get(r.ctx, key).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("objective %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var objective models.Objective
+	err = json.Unmarshal([]byte(objectiveJSON), &objective)
+	return &objective, err
+}
+
+func (r *RedisManager) GetAllObjectives() ([]*models.Objective, error) {
+	objectiveIDs, err := r.client.SMembers(r.ctx, "objectives:all").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var objectives []*models.Objective
+	for _, objectiveIDStr := range objectiveIDs {
+		objectiveID, err := strconv.Atoi(objectiveIDStr)
+		if err != nil {
+			continue
+		}
+
+		objective, err := r.GetObjective(objectiveID)
+		if err == nil {
+			objectives = append(objectives, objective)
+		}
+	}
+
+	return objectives, nil
+}
+
+func (r *RedisManager) DeleteObjective(objectiveID int) error {
+	r.client.SRem(r.ctx, "objectives:all", objectiveID)
+
+	key := fmt.Sprintf("objective:%d", objectiveID)
+	return r.client.Del(r.ctx, key).Err()
+}
+
+// EnqueueReportJob queues a report generation job for the given subscription
+func (r *RedisManager) EnqueueReportJob(subID int) error {
+	return r.client.LPush(r.ctx, "queue:reports", subID).Err()
+}
+
+// ReportQueueLength returns the number of report jobs waiting to be processed
+func (r *RedisManager) ReportQueueLength() (int64, error) {
+	return r.client.LLen(r.ctx, "queue:reports").Result()
+}
+
+// DequeueReportJob waits up to timeout for the next queued report job, returning 0 when none arrived
+func (r *RedisManager) DequeueReportJob(timeout time.Duration) (int, error) {
+	result, err := r.client.BRPop(r.ctx, timeout, "queue:reports").Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(result[1])
+}
+
+// Pub/sub operations
+func (r *RedisManager) PublishEvent(channel string, payload []byte) error {
+	return r.client.Publish(r.ctx, channel, payload).Err()
+}
+
+func (r *RedisManager) SubscribeEvents(channel string) *redis.PubSub {
+	return r.client.Subscribe(r.ctx, channel)
+}
+
 // Counter operations
+
+// raiseCounterScript moves a counter up to at least ARGV[1] in one step, so
+// an ID handed out while the counter is raised is never issued again
+var raiseCounterScript = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+local floor = tonumber(ARGV[1])
+if current < floor then
+	redis.call("SET", KEYS[1], floor)
+end
+return 0`)
+
+// RaiseCounter makes sure the next ID a counter hands out is above floor.
+// It never moves a counter down.
+func (r *RedisManager) RaiseCounter(counter string, floor int) error {
+	return raiseCounterScript.Run(r.ctx, r.client, []string{counter}, floor).Err()
+}
+
+// GetCounter returns the last ID a counter handed out, 0 if it never did
+func (r *RedisManager) GetCounter(counter string) (int, error) {
+	value, err := r.client.Get(r.ctx, counter).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return value, err
+}
+
 func (r *RedisManager) GetNextUserID() (int, error) {
 	id, err := r.client.Incr(r.ctx, "counter:user_id").Result()
 	return int(id), err
@@ -476,6 +1142,31 @@ func (r *RedisManager) GetNextTaskID() (int, error) {
 	return int(id), err
 }
 
+func (r *RedisManager) GetNextLeaveID() (int, error) {
+	id, err := r.client.Incr(r.ctx, "counter:leave_id").Result()
+	return int(id), err
+}
+
+func (r *RedisManager) GetNextClientID() (int, error) {
+	id, err := r.client.Incr(r.ctx, "counter:client_id").Result()
+	return int(id), err
+}
+
+func (r *RedisManager) GetNextRiskID() (int, error) {
+	id, err := r.client.Incr(r.ctx, "counter:risk_id").Result()
+	return int(id), err
+}
+
+func (r *RedisManager) GetNextObjectiveID() (int, error) {
+	id, err := r.client.Incr(r.ctx, "counter:objective_id").Result()
+	return int(id), err
+}
+
+func (r *RedisManager) GetNextReportSubscriptionID() (int, error) {
+	id, err := r.client.Incr(r.ctx, "counter:report_sub_id").Result()
+	return int(id), err
+}
+
 // Utility functions
 func (r *RedisManager) SetLastSyncTime() error {
 	return r.client.Set(r.ctx, "sync:last_time", time.Now().Unix(), 0).Err()
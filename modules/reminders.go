@@ -0,0 +1,336 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"task-manager/models"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// deadlineReminderHour is when, in the organization's timezone, system reminders
+// go out on a task's deadline day for assignees who do not work that day
+const deadlineReminderHour = 9
+
+// remindersDueKey is a sorted set of reminder IDs scored by when they are due
+const remindersDueKey = "reminders:due"
+
+var remindersLog = Logger("reminders")
+
+// Reminder operations
+func reminderKey(reminderID int) string {
+	return fmt.Sprintf("reminder:%d", reminderID)
+}
+
+func taskRemindersKey(taskID int) string {
+	return fmt.Sprintf("task:%d:reminders", taskID)
+}
+
+func (r *RedisManager) GetNextReminderID() (int, error) {
+	id, err := r.client.Incr(r.ctx, "counter:reminder_id").Result()
+	return int(id), err
+}
+
+// SaveReminder stores a reminder and queues it unless it was already sent
+func (r *RedisManager) SaveReminder(reminder *models.Reminder) error {
+	reminderJSON, err := json.Marshal(reminder)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(r.ctx, reminderKey(reminder.ID), reminderJSON, 0)
+		pipe.SAdd(r.ctx, taskRemindersKey(reminder.TaskID), reminder.ID)
+		if reminder.SentAt == nil {
+			pipe.ZAdd(r.ctx, remindersDueKey, &redis.Z{Score: float64(reminder.RemindAt.Unix()), Member: reminder.ID})
+		} else {
+			pipe.ZRem(r.ctx, remindersDueKey, reminder.ID)
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *RedisManager) GetReminder(reminderID int) (*models.Reminder, error) {
+	reminderJSON, err := r.client.Get(r.ctx, reminderKey(reminderID)).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("reminder %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var reminder models.Reminder
+	err = json.Unmarshal([]byte(reminderJSON), &reminder)
+	return &reminder, err
+}
+
+// GetTaskReminders returns a task's reminders ordered by when they are due
+func (r *RedisManager) GetTaskReminders(taskID int) ([]*models.Reminder, error) {
+	reminderIDs, err := r.client.SMembers(r.ctx, taskRemindersKey(taskID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var reminders []*models.Reminder
+	for _, reminderIDStr := range reminderIDs {
+		reminderID, err := strconv.Atoi(reminderIDStr)
+		if err != nil {
+			continue
+		}
+
+		reminder, err := r.GetReminder(reminderID)
+		if err == nil {
+			reminders = append(reminders, reminder)
+		}
+	}
+
+	sortReminders(reminders)
+	return reminders, nil
+}
+
+func (r *RedisManager) DeleteReminder(reminder *models.Reminder) error {
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(r.ctx, reminderKey(reminder.ID))
+		pipe.SRem(r.ctx, taskRemindersKey(reminder.TaskID), reminder.ID)
+		pipe.ZRem(r.ctx, remindersDueKey, reminder.ID)
+		return nil
+	})
+	return err
+}
+
+// DeleteTaskReminders removes every reminder of a task
+func (r *RedisManager) DeleteTaskReminders(taskID int) error {
+	reminders, err := r.GetTaskReminders(taskID)
+	if err != nil {
+		return err
+	}
+
+	for _, reminder := range reminders {
+		if err := r.DeleteReminder(reminder); err != nil {
+			return err
+		}
+	}
+	return r.client.Del(r.ctx, taskRemindersKey(taskID)).Err()
+}
+
+// claimDueReminders returns the IDs of reminders due by now. Each ID is
+// removed from the queue as it is claimed, so only one replica sends it.
+func (r *RedisManager) claimDueReminders(now time.Time) ([]int, error) {
+	members, err := r.client.ZRangeByScore(r.ctx, remindersDueKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var claimed []int
+	for _, member := range members {
+		removed, err := r.client.ZRem(r.ctx, remindersDueKey, member).Result()
+		if err != nil || removed == 0 {
+			continue
+		}
+		if reminderID, err := strconv.Atoi(member); err == nil {
+			claimed = append(claimed, reminderID)
+		}
+	}
+	return claimed, nil
+}
+
+// ScheduleDeadlineReminder keeps a task's system reminder in line with its
+// deadline: due when the assignee starts work on the deadline day, or at
+// 09:00 if they do not work that day, and dropped once the task is done
+// or has no deadline. A snoozed or sent reminder is left alone until the
+// deadline changes.
+func (r *RedisManager) ScheduleDeadlineReminder(task *models.Task) error {
+	reminders, err := r.GetTaskReminders(task.ID)
+	if err != nil {
+		return err
+	}
+
+	var existing *models.Reminder
+	for _, reminder := range reminders {
+		if reminder.System {
+			existing = reminder
+			break
+		}
+	}
+
+	var workTimes models.WorkTimes
+	if user, err := r.GetUser(task.UserID); err == nil {
+		workTimes = user.WorkTimes
+	}
+
+	remindAt, hasDeadline := deadlineReminderTime(task.Deadline, workTimes)
+	if task.Closed() || !hasDeadline {
+		if existing != nil {
+			return r.DeleteReminder(existing)
+		}
+		return nil
+	}
+
+	if existing != nil && existing.Deadline == task.Deadline && existing.UserID == task.UserID {
+		return nil
+	}
+
+	reminder := existing
+	if reminder == nil {
+		reminderID, err := r.GetNextReminderID()
+		if err != nil {
+			return err
+		}
+		reminder = &models.Reminder{
+			ID:        reminderID,
+			TaskID:    task.ID,
+			System:    true,
+			CreatedAt: time.Now(),
+		}
+	}
+
+	reminder.UserID = task.UserID
+	reminder.RemindAt = remindAt
+	reminder.Deadline = task.Deadline
+	reminder.Snoozed = false
+	reminder.SentAt = nil
+	reminder.UpdatedAt = time.Now()
+	return r.SaveReminder(reminder)
+}
+
+// deadlineReminderTime returns when the system reminder for a deadline is due
+func deadlineReminderTime(deadline string, workTimes models.WorkTimes) (time.Time, bool) {
+	if len(deadline) < 10 {
+		return time.Time{}, false
+	}
+
+	day, err := time.ParseInLocation(models.LeaveDateLayout, deadline[:10], OrgSettings().Location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	if start, ok := workTimes.NextAvailable(day); ok && start.YearDay() == day.YearDay() && start.Year() == day.Year() {
+		return start, true
+	}
+	return day.Add(deadlineReminderHour * time.Hour), true
+}
+
+func sortReminders(reminders []*models.Reminder) {
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i].RemindAt.Before(reminders[j].RemindAt)
+	})
+}
+
+// ReminderScheduler sends task reminders as they fall due
+type ReminderScheduler struct {
+	checkInterval time.Duration
+	running       bool
+}
+
+var Reminders *ReminderScheduler
+
+func InitReminderScheduler() {
+	Reminders = &ReminderScheduler{
+		checkInterval: 30 * time.Second,
+		running:       false,
+	}
+}
+
+// Start schedules the "reminders" job, which sends reminders that have
+// fallen due
+func (s *ReminderScheduler) Start() {
+	if s.running {
+		return
+	}
+
+	s.running = true
+	Jobs.Register(JobSpec{
+		Name:     "reminders",
+		Schedule: EverySchedule(s.checkInterval),
+		Run: func() error {
+			return s.sendDue(time.Now())
+		},
+	})
+	fmt.Println("⏰ Reminder scheduler started")
+}
+
+func (s *ReminderScheduler) Stop() {
+	if !s.running {
+		return
+	}
+
+	s.running = false
+	fmt.Println("⏹️ Reminder scheduler stopped")
+}
+
+func (s *ReminderScheduler) sendDue(now time.Time) error {
+	reminderIDs, err := RedisClient.claimDueReminders(now)
+	if err != nil {
+		return fmt.Errorf("failed to load due reminders: %v", err)
+	}
+
+	for _, reminderID := range reminderIDs {
+		if err := s.send(reminderID); err != nil {
+			remindersLog.Warn("⚠️ Failed to send reminder", "reminder_id", reminderID, "error", err)
+		}
+	}
+	return nil
+}
+
+func (s *ReminderScheduler) send(reminderID int) error {
+	reminder, err := RedisClient.GetReminder(reminderID)
+	if err != nil {
+		return err
+	}
+
+	task, err := RedisClient.GetTask(reminder.TaskID)
+	if err != nil {
+		return RedisClient.DeleteReminder(reminder)
+	}
+
+	now := time.Now()
+	reminder.SentAt = &now
+	reminder.UpdatedAt = now
+	if err := RedisClient.SaveReminder(reminder); err != nil {
+		return err
+	}
+
+	// Nobody needs reminding about finished work
+	if task.Closed() {
+		return nil
+	}
+
+	user, err := RedisClient.GetUser(reminder.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Reminders users set themselves go out at the time they chose, while
+	// deadline alerts can wait for their next digest
+	ctx := context.Background()
+	note := &Notification{
+		Kind:     "task_reminder",
+		Message:  fmt.Sprintf("Reminder about %q (#%d)", task.Title, task.ID),
+		TaskID:   task.ID,
+		Urgent:   !reminder.System,
+		Template: "task_reminder",
+		Data: map[string]interface{}{
+			"Task": task,
+			"Note": reminder.Note,
+		},
+	}
+	if reminder.System {
+		note.Kind, note.Template = "deadline_alert", "deadline_alert"
+		note.Message = fmt.Sprintf("%q (#%d) is due on %s and is not completed yet", task.Title, task.ID, task.Deadline)
+	}
+	Notifications.Notify(ctx, user, note)
+	Events.Publish(ctx, "task.reminder", reminder.UserID, task.GroupID, map[string]interface{}{
+		"reminder": reminder,
+		"task":     task,
+	})
+
+	remindersLog.Info("⏰ Reminder sent", "reminder_id", reminder.ID, "task_id", task.ID, "user_id", user.ID)
+	return nil
+}
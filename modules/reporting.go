@@ -0,0 +1,303 @@
+package modules
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// reportingSchema backs the reporting queries, which read tasks by group or
+// user over a date range. Done and cancelled tasks count as closed at
+// closed_at; tasks closed before it was kept take their last update.
+var reportingSchema = []string{
+	`UPDATE tasks SET closed_at = updated_at WHERE closed_at IS NULL AND (status OR state = 'cancelled')`,
+	`CREATE INDEX IF NOT EXISTS idx_tasks_group_created ON tasks (group_id, created_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_tasks_user_created ON tasks (user_id, created_at)`,
+	// Sync rewrites updated_at, so the old completion indexes on it go
+	`DROP INDEX IF EXISTS idx_tasks_group_completed`,
+	`DROP INDEX IF EXISTS idx_tasks_user_completed`,
+	`CREATE INDEX IF NOT EXISTS idx_tasks_group_closed ON tasks (group_id, closed_at) WHERE closed_at IS NOT NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_tasks_user_closed ON tasks (user_id, closed_at) WHERE closed_at IS NOT NULL`,
+	// Tasks are appended in creation order, so a BRIN index narrows
+	// organization-wide date ranges at a fraction of a B-tree's size
+	`CREATE INDEX IF NOT EXISTS idx_tasks_created_brin ON tasks USING BRIN (created_at)`,
+}
+
+func ensureReportingSchema(db *gorm.DB) error {
+	for _, statement := range reportingSchema {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrInvalidReportRange is returned for a range that ends before it starts
+// or spans more than maxReportDays
+var ErrInvalidReportRange = fmt.Errorf("%w: invalid report range", ErrValidation)
+
+// Conditions for countByDay and countByMonth
+const (
+	doneTasks      = "status"
+	cancelledTasks = "NOT status AND state = 'cancelled'"
+)
+
+// maxReportDays bounds a burndown to a little over a year of daily points
+const maxReportDays = 400
+
+// ReportScope limits a report to a group, a user, or both. The zero scope
+// covers every task outside archived groups; IncludeArchived counts those
+// too. A report on one group covers it whether or not it is archived.
+type ReportScope struct {
+	GroupID         int
+	UserID          int
+	IncludeArchived bool
+}
+
+func (s ReportScope) apply(db *gorm.DB) *gorm.DB {
+	if s.GroupID != 0 {
+		db = db.Where("group_id = ?", s.GroupID)
+	} else if !s.IncludeArchived {
+		db = db.Where("group_id NOT IN (SELECT id FROM groups WHERE archived_at IS NOT NULL)")
+	}
+	if s.UserID != 0 {
+		db = db.Where("user_id = ?", s.UserID)
+	}
+	return db
+}
+
+// ReportingRepository answers reports over the full task history straight
+// from PostgreSQL, leaving Redis to the live data. Reports reflect the last
+// sync.
+type ReportingRepository struct {
+	db *gorm.DB
+}
+
+func (p *PostgresManager) Reporting() *ReportingRepository {
+	return &ReportingRepository{db: p.db}
+}
+
+// BurndownPoint is the state of the scope's tasks at the end of a day
+type BurndownPoint struct {
+	Date      string `json:"date"`
+	Open      int64  `json:"open"`
+	Created   int64  `json:"created"`
+	Completed int64  `json:"completed"`
+	Cancelled int64  `json:"cancelled"`
+}
+
+// dayCount is one row of a per-day or per-period count
+type dayCount struct {
+	Day   time.Time
+	Count int64
+	Hours float64
+}
+
+// Burndown counts open tasks at the end of each day from the first day to
+// the last, in the organization's timezone
+func (rr *ReportingRepository) Burndown(scope ReportScope, from, to time.Time) ([]*BurndownPoint, error) {
+	loc := OrgSettings().Location()
+	start := startOfDay(from, loc)
+	end := startOfDay(to, loc).AddDate(0, 0, 1)
+	days := int(end.Sub(start).Hours()/24 + 0.5)
+	if days < 1 || days > maxReportDays {
+		return nil, ErrInvalidReportRange
+	}
+
+	var before struct {
+		Created int64
+		Closed  int64
+	}
+	err := scope.apply(rr.db.Table("tasks")).
+		Select(fmt.Sprintf("count(*) AS created, count(*) FILTER (WHERE (%s OR %s) AND closed_at < ?) AS closed", doneTasks, cancelledTasks), start).
+		Where("created_at < ?", start).
+		Scan(&before).Error
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := rr.countByDay(scope, "created_at", "", start, end)
+	if err != nil {
+		return nil, err
+	}
+	completed, err := rr.countByDay(scope, "closed_at", doneTasks, start, end)
+	if err != nil {
+		return nil, err
+	}
+	cancelled, err := rr.countByDay(scope, "closed_at", cancelledTasks, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	open := before.Created - before.Closed
+	points := make([]*BurndownPoint, 0, days)
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		point := &BurndownPoint{Date: key, Created: created[key].Count, Completed: completed[key].Count, Cancelled: cancelled[key].Count}
+		open += point.Created - point.Completed - point.Cancelled
+		point.Open = open
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// countByDay counts the scope's tasks by the local day of column, only
+// those matching condition unless it is empty
+func (rr *ReportingRepository) countByDay(scope ReportScope, column, condition string, start, end time.Time) (map[string]dayCount, error) {
+	loc := OrgSettings().Location().String()
+	db := scope.apply(rr.db.Table("tasks")).
+		Select(fmt.Sprintf("(%s AT TIME ZONE ?)::date AS day, count(*) AS count, coalesce(sum(estimated_hours), 0) AS hours", column), loc).
+		Where(fmt.Sprintf("%s >= ? AND %s < ?", column, column), start, end)
+	if condition != "" {
+		db = db.Where(condition)
+	}
+
+	var rows []dayCount
+	if err := db.Group("day").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]dayCount, len(rows))
+	for _, row := range rows {
+		counts[row.Day.Format("2006-01-02")] = row
+	}
+	return counts, nil
+}
+
+// VelocityPoint is what the scope completed in one week
+type VelocityPoint struct {
+	WeekStart      string  `json:"week_start"`
+	Completed      int64   `json:"completed"`
+	EstimatedHours float64 `json:"estimated_hours"`
+}
+
+// Velocity is the tasks completed in each of the last weeks, the current
+// week last. Weeks start on the organization's first day of the week.
+type Velocity struct {
+	Weeks                 []*VelocityPoint `json:"weeks"`
+	AverageCompleted      float64          `json:"average_completed"`
+	AverageEstimatedHours float64          `json:"average_estimated_hours"`
+}
+
+func (rr *ReportingRepository) Velocity(scope ReportScope, weeks int, now time.Time) (*Velocity, error) {
+	if weeks < 1 || weeks*7 > maxReportDays {
+		return nil, ErrInvalidReportRange
+	}
+
+	settings := OrgSettings()
+	today := startOfDay(now, settings.Location())
+	offset := (int(today.Weekday()) - int(settings.WeekStartDay()) + 7) % 7
+	firstWeek := today.AddDate(0, 0, -offset-7*(weeks-1))
+
+	completed, err := rr.countByDay(scope, "closed_at", doneTasks, firstWeek, today.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	velocity := &Velocity{Weeks: make([]*VelocityPoint, weeks)}
+	for i := range velocity.Weeks {
+		weekStart := firstWeek.AddDate(0, 0, 7*i)
+		point := &VelocityPoint{WeekStart: weekStart.Format("2006-01-02")}
+		for day := 0; day < 7; day++ {
+			count := completed[weekStart.AddDate(0, 0, day).Format("2006-01-02")]
+			point.Completed += count.Count
+			point.EstimatedHours += count.Hours
+		}
+		velocity.Weeks[i] = point
+		velocity.AverageCompleted += float64(point.Completed) / float64(weeks)
+		velocity.AverageEstimatedHours += point.EstimatedHours / float64(weeks)
+	}
+	return velocity, nil
+}
+
+// MonthSummary counts the tasks created and completed in one month
+type MonthSummary struct {
+	Month          int     `json:"month"`
+	Created        int64   `json:"created"`
+	Completed      int64   `json:"completed"`
+	Cancelled      int64   `json:"cancelled"`
+	EstimatedHours float64 `json:"estimated_hours_completed"`
+}
+
+// YearSummary counts a year's tasks by month, with those still open at its end
+type YearSummary struct {
+	Year           int             `json:"year"`
+	Created        int64           `json:"created"`
+	Completed      int64           `json:"completed"`
+	Cancelled      int64           `json:"cancelled"`
+	EstimatedHours float64         `json:"estimated_hours_completed"`
+	OpenAtEnd      int64           `json:"open_at_end"`
+	Months         []*MonthSummary `json:"months"`
+}
+
+func (rr *ReportingRepository) YearSummary(scope ReportScope, year int) (*YearSummary, error) {
+	loc := OrgSettings().Location()
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(1, 0, 0)
+
+	created, err := rr.countByMonth(scope, "created_at", "", start, end)
+	if err != nil {
+		return nil, err
+	}
+	completed, err := rr.countByMonth(scope, "closed_at", doneTasks, start, end)
+	if err != nil {
+		return nil, err
+	}
+	cancelled, err := rr.countByMonth(scope, "closed_at", cancelledTasks, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &YearSummary{Year: year, Months: make([]*MonthSummary, 12)}
+	for i := range summary.Months {
+		month := &MonthSummary{
+			Month:          i + 1,
+			Created:        created[i+1].Count,
+			Completed:      completed[i+1].Count,
+			Cancelled:      cancelled[i+1].Count,
+			EstimatedHours: completed[i+1].Hours,
+		}
+		summary.Months[i] = month
+		summary.Created += month.Created
+		summary.Completed += month.Completed
+		summary.Cancelled += month.Cancelled
+		summary.EstimatedHours += month.EstimatedHours
+	}
+
+	err = scope.apply(rr.db.Table("tasks")).
+		Select("count(*)").
+		Where(fmt.Sprintf("created_at < ? AND NOT ((%s OR %s) AND closed_at < ?)", doneTasks, cancelledTasks), end, end).
+		Scan(&summary.OpenAtEnd).Error
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// countByMonth counts the scope's tasks by the local month of column
+func (rr *ReportingRepository) countByMonth(scope ReportScope, column, condition string, start, end time.Time) (map[int]dayCount, error) {
+	loc := OrgSettings().Location().String()
+	db := scope.apply(rr.db.Table("tasks")).
+		Select(fmt.Sprintf("date_trunc('month', %s AT TIME ZONE ?) AS day, count(*) AS count, coalesce(sum(estimated_hours), 0) AS hours", column), loc).
+		Where(fmt.Sprintf("%s >= ? AND %s < ?", column, column), start, end)
+	if condition != "" {
+		db = db.Where(condition)
+	}
+
+	var rows []dayCount
+	if err := db.Group("day").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]dayCount, len(rows))
+	for _, row := range rows {
+		counts[int(row.Day.Month())] = row
+	}
+	return counts, nil
+}
+
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
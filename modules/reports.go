@@ -0,0 +1,347 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"task-manager/models"
+	"time"
+)
+
+// ReportTypes lists the supported scheduled report types
+var ReportTypes = map[string]string{
+	"weekly_task_report":   "Weekly task report",
+	"group_status_summary": "Group status summary",
+	"group_daily_digest":   "Group daily digest",
+}
+
+var reportsLog = Logger("reports")
+
+type ReportScheduler struct {
+	checkInterval time.Duration
+	client        *OutboundClient
+	stopChan      chan bool
+	running       bool
+	wg            sync.WaitGroup
+}
+
+var Reporter *ReportScheduler
+
+func InitReportScheduler() {
+	Reporter = &ReportScheduler{
+		checkInterval: time.Minute,
+		client:        NewPublicOutboundClient("report webhook"),
+		stopChan:      make(chan bool),
+		running:       false,
+	}
+}
+
+// Start runs the report worker and schedules the "reports" job, which
+// enqueues a generation job for every subscription that has become due
+func (s *ReportScheduler) Start() {
+	if s.running {
+		return
+	}
+
+	s.running = true
+	s.wg.Add(1)
+	go s.workerLoop()
+	Jobs.Register(JobSpec{
+		Name:     "reports",
+		Schedule: EverySchedule(s.checkInterval),
+		Run:      s.schedule,
+	})
+	fmt.Println("📨 Report scheduler started")
+}
+
+func (s *ReportScheduler) Stop() {
+	if !s.running {
+		return
+	}
+
+	close(s.stopChan)
+	s.running = false
+	fmt.Println("⏹️ Report scheduler stopped")
+}
+
+// Drain waits for the report currently being generated to finish after Stop
+func (s *ReportScheduler) Drain(ctx context.Context) error {
+	return waitForGroup(ctx, &s.wg)
+}
+
+// schedule enqueues due reports; only one replica enqueues at a time so
+// they are not sent twice
+func (s *ReportScheduler) schedule() error {
+	err := RedisClient.WithLock("reports:schedule", s.checkInterval, func() error {
+		s.enqueueDue(time.Now())
+		return nil
+	})
+	if errors.Is(err, ErrLockNotAcquired) {
+		return nil
+	}
+	return err
+}
+
+func (s *ReportScheduler) enqueueDue(now time.Time) {
+	subs, err := RedisClient.GetAllReportSubscriptions()
+	if err != nil {
+		reportsLog.Warn("⚠️ Failed to load report subscriptions", "error", err)
+		return
+	}
+
+	for _, sub := range subs {
+		if sub.Paused || sub.NextRunAt == nil || sub.NextRunAt.After(now) {
+			continue
+		}
+
+		if err := RedisClient.EnqueueReportJob(sub.ID); err != nil {
+			reportsLog.Warn("⚠️ Failed to enqueue report", "subscription_id", sub.ID, "error", err)
+			continue
+		}
+
+		schedule, err := ParseCron(sub.Schedule)
+		if err != nil {
+			sub.NextRunAt = nil
+			sub.LastError = err.Error()
+		} else {
+			next := schedule.Next(now.In(OrgSettings().Location()))
+			sub.NextRunAt = &next
+		}
+		RedisClient.SaveReportSubscription(sub)
+		RedisClient.MarkDirty("reports")
+	}
+}
+
+// workerLoop consumes queued report jobs, generating and delivering each one
+func (s *ReportScheduler) workerLoop() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		default:
+		}
+
+		subID, err := RedisClient.DequeueReportJob(5 * time.Second)
+		if err != nil {
+			reportsLog.Warn("⚠️ Failed to dequeue report job", "error", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		if subID == 0 {
+			continue
+		}
+
+		if err := s.RunSubscription(subID); err != nil {
+			reportsLog.Warn("⚠️ Report subscription failed", "subscription_id", subID, "error", err)
+		}
+	}
+}
+
+// RunSubscription generates and delivers the report for a subscription immediately
+func (s *ReportScheduler) RunSubscription(subID int) error {
+	sub, err := RedisClient.GetReportSubscription(subID)
+	if err != nil {
+		return err
+	}
+
+	report, err := GenerateReport(sub, time.Now())
+	if err == nil {
+		err = s.deliver(sub, report)
+	}
+
+	now := time.Now()
+	sub.LastRunAt = &now
+	sub.LastError = ""
+	if err != nil {
+		sub.LastError = err.Error()
+	}
+	sub.UpdatedAt = now
+
+	RedisClient.SaveReportSubscription(sub)
+	RedisClient.MarkDirty("reports")
+
+	return err
+}
+
+func (s *ReportScheduler) deliver(sub *models.ReportSubscription, report *models.Report) error {
+	switch sub.Delivery {
+	case "webhook":
+		payload, headers, err := RenderWebhook(sub, report)
+		if err != nil {
+			return err
+		}
+
+		resp, err := s.postWebhook(sub.WebhookURL, payload, headers)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook returned %d", resp.StatusCode)
+		}
+		return nil
+	default:
+		if !Mailer.Enabled() {
+			return fmt.Errorf("email delivery is not configured")
+		}
+
+		user, err := RedisClient.GetUser(sub.UserID)
+		if err != nil {
+			return err
+		}
+
+		return Mailer.SendTemplate(context.Background(), user.Email, "report", map[string]interface{}{
+			"FullName": user.FullName,
+			"Report":   report,
+		})
+	}
+}
+
+// GenerateReport builds the report for a subscription covering the seven days
+// before now, or the last 24 hours for a digest, with dates in the
+// organization's timezone
+func GenerateReport(sub *models.ReportSubscription, now time.Time) (*models.Report, error) {
+	settings := OrgSettings()
+	now = now.In(settings.Location())
+	periodStart := now.AddDate(0, 0, -7)
+
+	if sub.ReportType == "group_daily_digest" {
+		group, err := RedisClient.GetGroup(sub.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		tasks, err := RedisClient.GetGroupTasks(sub.GroupID)
+		if err != nil {
+			return nil, err
+		}
+
+		report := &models.Report{
+			SubscriptionID: sub.ID,
+			ReportType:     sub.ReportType,
+			Title:          fmt.Sprintf("%s: %s", ReportTypes[sub.ReportType], group.Name),
+			PeriodStart:    now.Add(-24 * time.Hour),
+			PeriodEnd:      now,
+			GeneratedAt:    now,
+			Summary:        map[string]interface{}{},
+		}
+		if err := generateGroupDigest(report, group, tasks); err != nil {
+			return nil, err
+		}
+		return report, nil
+	}
+
+	var tasks []*models.Task
+	var err error
+	title := ReportTypes[sub.ReportType]
+	summary := make(map[string]interface{})
+
+	switch sub.ReportType {
+	case "weekly_task_report":
+		tasks, err = RedisClient.GetUserTasks(sub.UserID)
+		if err != nil {
+			return nil, err
+		}
+	case "group_status_summary":
+		group, err := RedisClient.GetGroup(sub.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		title = fmt.Sprintf("%s: %s", title, group.Name)
+
+		tasks, err = RedisClient.GetGroupTasks(sub.GroupID)
+		if err != nil {
+			return nil, err
+		}
+
+		users, _ := RedisClient.GetGroupUsers(sub.GroupID)
+		summary["members"] = len(users)
+	default:
+		return nil, fmt.Errorf("unknown report type: %s", sub.ReportType)
+	}
+
+	completed, cancelled, pending, overdue, createdInPeriod, completedInPeriod := 0, 0, 0, 0, 0, 0
+	extended, lateAgainstOriginal := 0, 0
+	for _, task := range tasks {
+		if task.OriginalDeadline != "" {
+			extended++
+		}
+		if IsTaskLateAgainstOriginal(task, now) {
+			lateAgainstOriginal++
+		}
+		if task.Status {
+			completed++
+			if task.UpdatedAt.After(periodStart) {
+				completedInPeriod++
+			}
+		} else if task.Closed() {
+			cancelled++
+		} else {
+			pending++
+			if IsTaskOverdue(task, now) {
+				overdue++
+			}
+		}
+		if task.CreatedAt.After(periodStart) {
+			createdInPeriod++
+		}
+	}
+
+	completionRate := 0.0
+	if len(tasks)-cancelled > 0 {
+		completionRate = float64(completed) / float64(len(tasks)-cancelled) * 100
+	}
+
+	summary["total_tasks"] = len(tasks)
+	summary["completed_tasks"] = completed
+	summary["cancelled_tasks"] = cancelled
+	summary["pending_tasks"] = pending
+	summary["overdue_tasks"] = overdue
+	summary["created_this_period"] = createdInPeriod
+	summary["completed_this_period"] = completedInPeriod
+	summary["completion_rate"] = fmt.Sprintf("%.1f%%", completionRate)
+	summary["extended_tasks"] = extended
+	summary["overdue_against_original"] = lateAgainstOriginal
+	summary["fiscal_quarter"] = settings.FiscalQuarter(now)
+
+	return &models.Report{
+		SubscriptionID: sub.ID,
+		ReportType:     sub.ReportType,
+		Title:          title,
+		PeriodStart:    periodStart,
+		PeriodEnd:      now,
+		GeneratedAt:    now,
+		Summary:        summary,
+	}, nil
+}
+
+// IsTaskOverdue reports whether an open task's deadline date has passed
+func IsTaskOverdue(task *models.Task, now time.Time) bool {
+	return !task.Closed() && deadlinePassed(task.Deadline, now)
+}
+
+// IsTaskLateAgainstOriginal reports whether an open task has passed the
+// deadline it had before any extension
+func IsTaskLateAgainstOriginal(task *models.Task, now time.Time) bool {
+	original := task.OriginalDeadline
+	if original == "" {
+		original = task.Deadline
+	}
+	return !task.Closed() && deadlinePassed(original, now)
+}
+
+func deadlinePassed(value string, now time.Time) bool {
+	if len(value) < 10 {
+		return false
+	}
+
+	deadline, err := time.ParseInLocation("2006-01-02", value[:10], now.Location())
+	if err != nil {
+		return false
+	}
+
+	return now.After(deadline.AddDate(0, 0, 1))
+}
@@ -0,0 +1,140 @@
+package modules
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"task-manager/config"
+)
+
+// RequestIDHeader carries the correlation ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+const requestScopeKey contextKey = "request"
+
+// RequestScope holds the correlation fields of one request. RequestIDMiddleware
+// creates it and AuthMiddleware fills in the caller once authenticated, so
+// loggers anywhere below the middleware see both.
+type RequestScope struct {
+	ID      string
+	UserID  int
+	IsOwner bool
+}
+
+// RequestIDMiddleware assigns every request an ID, reusing a well-formed
+// X-Request-ID from the client, and echoes it in the response
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		ctx := WithRequestScope(r.Context(), &RequestScope{ID: id})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// WithRequestScope stores the scope in the context
+func WithRequestScope(ctx context.Context, scope *RequestScope) context.Context {
+	return context.WithValue(ctx, requestScopeKey, scope)
+}
+
+// GetRequestScope returns the scope of the current request, or nil outside one
+func GetRequestScope(ctx context.Context) *RequestScope {
+	if ctx == nil {
+		return nil
+	}
+	scope, _ := ctx.Value(requestScopeKey).(*RequestScope)
+	return scope
+}
+
+// RequestID returns the ID of the current request, or "" outside one
+func RequestID(ctx context.Context) string {
+	if scope := GetRequestScope(ctx); scope != nil {
+		return scope.ID
+	}
+	return ""
+}
+
+// setCaller records the authenticated caller on the scope
+func (s *RequestScope) setCaller(authCtx *AuthContext) {
+	s.IsOwner = authCtx.IsOwner
+	if authCtx.User != nil {
+		s.UserID = authCtx.User.ID
+	}
+}
+
+// logAttrs returns the fields added to every log line written within the request
+func (s *RequestScope) logAttrs() []slog.Attr {
+	attrs := []slog.Attr{slog.String("request_id", s.ID)}
+	switch {
+	case s.IsOwner:
+		attrs = append(attrs, slog.String("actor", "owner"))
+	case s.UserID != 0:
+		attrs = append(attrs, slog.Int("user_id", s.UserID))
+	}
+	return attrs
+}
+
+// ClientIP returns the caller's address, taken from X-Forwarded-For only when
+// TRUST_PROXY is set because clients can forge the header otherwise. Each
+// proxy appends the address it was reached from, so the client is the entry
+// TRUSTED_PROXY_HOPS from the right; anything left of it came from the client.
+func ClientIP(r *http.Request) string {
+	if config.AppConfig != nil && config.AppConfig.TrustProxy {
+		var entries []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, entry := range strings.Split(header, ",") {
+				entries = append(entries, strings.TrimSpace(entry))
+			}
+		}
+		if len(entries) > 0 {
+			hops := config.AppConfig.TrustedProxyHops
+			if hops < 1 {
+				hops = 1
+			}
+			if hops > len(entries) {
+				hops = len(entries)
+			}
+			if ip := entries[len(entries)-hops]; net.ParseIP(ip) != nil {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func newRequestID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// isValidRequestID accepts short IDs made of letters, digits, '-', '_' and '.'
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"task-manager/models"
+)
+
+// scanBatchSize is how many records are read per round trip when listing
+// records one at a time
+const scanBatchSize = 500
+
+// scanRecords calls fn with the JSON of each record whose ID is in the set
+// index, reading them in batches so that only one batch is held in memory.
+// keyFormat turns an ID into the record key, e.g. "user:%d". Records that
+// disappear while scanning are skipped. An error from fn stops the scan.
+func (r *RedisManager) scanRecords(index, keyFormat string, fn func([]byte) error) error {
+	// SSCAN may return an ID more than once while the set is resized
+	seen := make(map[int]bool)
+
+	var cursor uint64
+	for {
+		ids, next, err := r.client.SScan(r.ctx, index, cursor, "", scanBatchSize).Result()
+		if err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(ids))
+		for _, idStr := range ids {
+			id, err := strconv.Atoi(idStr)
+			if err != nil || seen[id] {
+				continue
+			}
+			seen[id] = true
+			keys = append(keys, fmt.Sprintf(keyFormat, id))
+		}
+
+		if len(keys) > 0 {
+			values, err := r.client.MGet(r.ctx, keys...).Result()
+			if err != nil {
+				return err
+			}
+			for _, value := range values {
+				data, ok := value.(string)
+				if !ok {
+					continue
+				}
+				if err := fn([]byte(data)); err != nil {
+					return err
+				}
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// scanIDs calls fn with each member of the set index, without reading the
+// records they point to. A member may be passed more than once.
+func (r *RedisManager) scanIDs(index string, fn func(string)) error {
+	var cursor uint64
+	for {
+		ids, next, err := r.client.SScan(r.ctx, index, cursor, "", scanBatchSize).Result()
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			fn(id)
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// EachUser calls fn for every user without loading them all at once
+func (r *RedisManager) EachUser(fn func(*models.User) error) error {
+	return r.scanRecords("users:all", "user:%d", func(data []byte) error {
+		var user models.User
+		if err := json.Unmarshal(data, &user); err != nil {
+			return nil
+		}
+		return fn(&user)
+	})
+}
+
+// EachUserTask calls fn for every task of a user without loading them all at once
+func (r *RedisManager) EachUserTask(userID int, fn func(*models.Task) error) error {
+	return r.eachTask(fmt.Sprintf("user:%d:tasks", userID), fn)
+}
+
+// EachGroupTask calls fn for every task of a group without loading them all at once
+func (r *RedisManager) EachGroupTask(groupID int, fn func(*models.Task) error) error {
+	return r.eachTask(fmt.Sprintf("group:%d:tasks", groupID), fn)
+}
+
+func (r *RedisManager) eachTask(index string, fn func(*models.Task) error) error {
+	TaskWrites.Flush()
+
+	return r.scanRecords(index, "task:%d", func(data []byte) error {
+		var task models.Task
+		if err := json.Unmarshal(data, &task); err != nil {
+			return nil
+		}
+		return fn(&task)
+	})
+}
@@ -0,0 +1,218 @@
+package modules
+
+import (
+	"sort"
+	"task-manager/models"
+	"time"
+)
+
+// Reasons a task could not be given dates in a group schedule
+const (
+	UnscheduledCycle      = "dependency_cycle"
+	UnscheduledDependency = "blocker_unscheduled"
+	UnscheduledAssignee   = "assignee_missing"
+	UnscheduledNoTime     = "no_working_time"
+)
+
+// GroupSchedule plans a group's open tasks forward from a moment
+type GroupSchedule struct {
+	GroupID      int              `json:"group_id"`
+	From         time.Time        `json:"from"`
+	Finish       *time.Time       `json:"finish,omitempty"`
+	Tasks        []*ScheduledTask `json:"tasks"`
+	CriticalPath []int            `json:"critical_path"`
+	LateTasks    int              `json:"late_tasks"`
+	Unscheduled  int              `json:"unscheduled_tasks"`
+	Unestimated  int              `json:"unestimated_tasks"`
+	Timezone     string           `json:"timezone"`
+}
+
+// ScheduledTask is when a task can start and end. A task starts once the
+// task blocking it ends and its assignee has finished their earlier tasks;
+// what held it back last is its driver, which the critical path follows.
+type ScheduledTask struct {
+	TaskID          int        `json:"task_id"`
+	Key             string     `json:"key,omitempty"`
+	Title           string     `json:"title"`
+	UserID          int        `json:"user_id"`
+	EstimatedHours  float64    `json:"estimated_hours"`
+	DependsOn       int        `json:"depends_on,omitempty"`
+	ExternalBlocker int        `json:"external_blocker,omitempty"`
+	Start           *time.Time `json:"start,omitempty"`
+	End             *time.Time `json:"end,omitempty"`
+	Deadline        string     `json:"deadline,omitempty"`
+	Late            bool       `json:"late,omitempty"`
+	Critical        bool       `json:"critical,omitempty"`
+	Unscheduled     string     `json:"unscheduled,omitempty"`
+
+	driver int
+}
+
+// ScheduleGroup works out start and end dates for a group's open tasks from
+// now on. Tasks follow the task in the group blocking them, and each
+// assignee works through their tasks one at a time, earliest deadline
+// first, in their work times outside leave and holidays. A task ending
+// after its deadline is late. Unestimated tasks take no time.
+func (r *RedisManager) ScheduleGroup(groupID int, now time.Time) (*GroupSchedule, error) {
+	location := OrgSettings().Location()
+	now = now.In(location)
+
+	tasks, err := r.GetGroupTasks(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &GroupSchedule{
+		GroupID:      groupID,
+		From:         now,
+		Tasks:        []*ScheduledTask{},
+		CriticalPath: []int{},
+		Timezone:     location.String(),
+	}
+	planned := make(map[int]*ScheduledTask)
+	open := make(map[int]*models.Task)
+	for _, task := range tasks {
+		if !task.Closed() {
+			open[task.ID] = task
+		}
+	}
+
+	// Order tasks so each comes after the task blocking it, picking the
+	// earliest deadline among those ready
+	waiting := make(map[int][]*models.Task)
+	var ready []*models.Task
+	for _, task := range open {
+		item := &ScheduledTask{
+			TaskID:         task.ID,
+			Key:            task.Key,
+			Title:          task.Title,
+			UserID:         task.UserID,
+			EstimatedHours: task.EstimatedHours,
+			Deadline:       task.Deadline,
+		}
+		planned[task.ID] = item
+		schedule.Tasks = append(schedule.Tasks, item)
+		if task.EstimatedHours <= 0 {
+			schedule.Unestimated++
+		}
+
+		if task.BlockedBy != 0 && task.BlockedBy != task.ID {
+			if _, inGroup := open[task.BlockedBy]; inGroup {
+				item.DependsOn = task.BlockedBy
+				waiting[task.BlockedBy] = append(waiting[task.BlockedBy], task)
+				continue
+			}
+			if blocker, err := r.GetTask(task.BlockedBy); err == nil && !blocker.Closed() {
+				item.ExternalBlocker = blocker.ID
+			}
+		}
+		ready = append(ready, task)
+	}
+
+	users := make(map[int]*models.User)
+	free := make(map[int]time.Time)
+	lastTask := make(map[int]int)
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool {
+			return scheduleBefore(ready[i], ready[j])
+		})
+		task := ready[0]
+		ready = append(ready[1:], waiting[task.ID]...)
+		delete(waiting, task.ID)
+
+		item := planned[task.ID]
+		earliest := now
+		if item.DependsOn != 0 {
+			blocker := planned[item.DependsOn]
+			if blocker.End == nil {
+				item.Unscheduled = UnscheduledDependency
+				continue
+			}
+			if blocker.End.After(earliest) {
+				earliest, item.driver = *blocker.End, blocker.TaskID
+			}
+		}
+
+		user, ok := users[task.UserID]
+		if !ok {
+			user, _ = r.GetUser(task.UserID)
+			users[task.UserID] = user
+		}
+		if user == nil {
+			item.Unscheduled = UnscheduledAssignee
+			continue
+		}
+		if available, ok := free[user.ID]; ok && available.After(earliest) {
+			earliest, item.driver = available, lastTask[user.ID]
+		}
+
+		start, end, ok := r.workUntil(user, earliest, int(task.EstimatedHours*60))
+		if !ok {
+			item.Unscheduled = UnscheduledNoTime
+			continue
+		}
+		item.Start, item.End = &start, &end
+		free[user.ID], lastTask[user.ID] = end, task.ID
+		item.Late = deadlinePassed(task.Deadline, end)
+	}
+
+	// Whatever never became ready waits on a cycle of blockers
+	var last *ScheduledTask
+	for _, item := range schedule.Tasks {
+		switch {
+		case item.End == nil && item.Unscheduled == "":
+			item.Unscheduled = UnscheduledCycle
+			schedule.Unscheduled++
+		case item.End == nil:
+			schedule.Unscheduled++
+		default:
+			if item.Late {
+				schedule.LateTasks++
+			}
+			if last == nil || item.End.After(*last.End) {
+				last = item
+			}
+		}
+	}
+
+	// The critical path runs back from the last task to end through what
+	// held each task back
+	if last != nil {
+		schedule.Finish = last.End
+		for item := last; item != nil; item = planned[item.driver] {
+			item.Critical = true
+			schedule.CriticalPath = append([]int{item.TaskID}, schedule.CriticalPath...)
+		}
+	}
+
+	sort.Slice(schedule.Tasks, func(i, j int) bool {
+		a, b := schedule.Tasks[i], schedule.Tasks[j]
+		if (a.Start == nil) != (b.Start == nil) {
+			return a.Start != nil
+		}
+		if a.Start != nil && !a.Start.Equal(*b.Start) {
+			return a.Start.Before(*b.Start)
+		}
+		return a.TaskID < b.TaskID
+	})
+	return schedule, nil
+}
+
+// scheduleBefore orders tasks ready to be scheduled: earliest deadline
+// first, those without one last, then by ID
+func scheduleBefore(a, b *models.Task) bool {
+	if (a.Deadline == "") != (b.Deadline == "") {
+		return a.Deadline != ""
+	}
+	if a.Deadline != b.Deadline {
+		return a.Deadline < b.Deadline
+	}
+	return a.ID < b.ID
+}
+
+// workUntil returns when a user starting at from first works and when they
+// have worked minutes, skipping holidays and leave. It is false when that
+// does not fit within suggestionHorizon days.
+func (r *RedisManager) workUntil(user *models.User, from time.Time, minutes int) (time.Time, time.Time, bool) {
+	return r.workSpan(UserCalendar(user), from, minutes)
+}
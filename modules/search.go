@@ -0,0 +1,212 @@
+package modules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"task-manager/models"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// SearchScope restricts full-text search results to what the caller may see
+type SearchScope struct {
+	All      bool  // owner: no restriction
+	UserID   int   // caller's own user ID
+	GroupIDs []int // groups whose members, tasks and details are visible
+}
+
+// SearchTypes lists the entity types covered by full-text search
+var SearchTypes = []string{"tasks", "users", "groups"}
+
+const headlineOptions = "MaxWords=25, MinWords=8, StartSel=<b>, StopSel=</b>"
+
+// searchIndexes are GIN expression indexes backing full-text search; the 'simple'
+// configuration is used so non-English text (e.g. Persian) is tokenized without stemming
+var searchIndexes = []string{
+	`CREATE INDEX IF NOT EXISTS idx_tasks_fts ON tasks USING GIN (to_tsvector('simple', coalesce(title, '') || ' ' || coalesce(information, '')))`,
+	`CREATE INDEX IF NOT EXISTS idx_users_fts ON users USING GIN (to_tsvector('simple', coalesce(full_name, '') || ' ' || coalesce(email, '')))`,
+	`CREATE INDEX IF NOT EXISTS idx_groups_fts ON groups USING GIN (to_tsvector('simple', coalesce(name, '')))`,
+}
+
+func ensureSearchIndexes(db *gorm.DB) error {
+	for _, statement := range searchIndexes {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildPrefixTSQuery turns free text into a tsquery matching every term as a prefix
+func buildPrefixTSQuery(query string) string {
+	terms := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var parts []string
+	for _, term := range terms {
+		parts = append(parts, strings.ToLower(term)+":*")
+	}
+	return strings.Join(parts, " & ")
+}
+
+// Search runs a ranked full-text search over the requested entity types.
+// Results reflect the last Redis to PostgreSQL sync.
+func (p *PostgresManager) Search(query string, types []string, scope SearchScope, limit int) ([]*models.SearchResult, error) {
+	tsQuery := buildPrefixTSQuery(query)
+	if tsQuery == "" {
+		return nil, ErrEmptySearchQuery
+	}
+
+	var results []*models.SearchResult
+	for _, searchType := range types {
+		// A task key such as GASK-142 finds that task first
+		if searchType == "tasks" && IsTaskKey(strings.TrimSpace(query)) {
+			found, err := p.searchTaskKey(strings.ToUpper(strings.TrimSpace(query)), scope)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, found...)
+		}
+
+		var found []*models.SearchResult
+		var err error
+
+		switch searchType {
+		case "tasks":
+			found, err = p.searchTasks(tsQuery, scope, limit)
+		case "users":
+			found, err = p.searchUsers(tsQuery, scope, limit)
+		case "groups":
+			found, err = p.searchGroups(tsQuery, scope, limit)
+		default:
+			return nil, fmt.Errorf("unknown search type: %s", searchType)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	results = dedupeSearchResults(results)
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// taskKeyRank ranks an exact task key match above any full-text match
+const taskKeyRank = 1000
+
+// searchTaskKey finds the task with an exact key
+func (p *PostgresManager) searchTaskKey(key string, scope SearchScope) ([]*models.SearchResult, error) {
+	db := p.db.Table("tasks").
+		Select("'task' AS type, id, key, title, user_id, group_id, title AS snippet, ? AS rank", taskKeyRank).
+		Where("key = ?", key)
+	db = scopeTasks(db, scope)
+
+	var results []*models.SearchResult
+	err := db.Scan(&results).Error
+	return results, err
+}
+
+// scopeTasks limits a task query to the tasks the caller may see, as
+// CanViewTask does: those they are assigned or collaborate on, and for
+// group admins the tasks of their groups and those shared with them
+func scopeTasks(db *gorm.DB, scope SearchScope) *gorm.DB {
+	if scope.All {
+		return db
+	}
+	assigned := `user_id = ? OR EXISTS (
+		SELECT 1 FROM json_array_elements_text(coalesce(collaborators, '[]'::json)) AS collaborator
+		WHERE collaborator::int = ?)`
+	if len(scope.GroupIDs) > 0 {
+		// A task shared with one of the groups is found there too
+		return db.Where(assigned+` OR group_id IN ? OR EXISTS (
+			SELECT 1 FROM json_array_elements_text(coalesce(visible_to_groups, '[]'::json)) AS visible
+			WHERE visible::int IN ?)`, scope.UserID, scope.UserID, scope.GroupIDs, scope.GroupIDs)
+	}
+	return db.Where(assigned, scope.UserID, scope.UserID)
+}
+
+// dedupeSearchResults drops repeats of an entity, keeping its best-ranked result
+func dedupeSearchResults(results []*models.SearchResult) []*models.SearchResult {
+	seen := make(map[string]bool, len(results))
+	deduped := results[:0]
+	for _, result := range results {
+		id := fmt.Sprintf("%s:%d", result.Type, result.ID)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		deduped = append(deduped, result)
+	}
+	return deduped
+}
+
+func (p *PostgresManager) searchTasks(tsQuery string, scope SearchScope, limit int) ([]*models.SearchResult, error) {
+	document := "coalesce(title, '') || ' ' || coalesce(information, '')"
+
+	db := p.db.Table("tasks").
+		Select(fmt.Sprintf(`'task' AS type, id, key, title, user_id, group_id,
+			ts_headline('simple', %[1]s, to_tsquery('simple', ?), ?) AS snippet,
+			ts_rank(to_tsvector('simple', %[1]s), to_tsquery('simple', ?)) AS rank`, document),
+			tsQuery, headlineOptions, tsQuery).
+		Where(fmt.Sprintf("to_tsvector('simple', %s) @@ to_tsquery('simple', ?)", document), tsQuery)
+	db = scopeTasks(db, scope)
+
+	var results []*models.SearchResult
+	err := db.Order("rank DESC").Limit(limit).Scan(&results).Error
+	return results, err
+}
+
+func (p *PostgresManager) searchUsers(tsQuery string, scope SearchScope, limit int) ([]*models.SearchResult, error) {
+	document := "coalesce(full_name, '') || ' ' || coalesce(email, '')"
+
+	db := p.db.Table("users").
+		Select(fmt.Sprintf(`'user' AS type, id, full_name AS title, id AS user_id,
+			ts_headline('simple', %[1]s, to_tsquery('simple', ?), ?) AS snippet,
+			ts_rank(to_tsvector('simple', %[1]s), to_tsquery('simple', ?)) AS rank`, document),
+			tsQuery, headlineOptions, tsQuery).
+		Where(fmt.Sprintf("to_tsvector('simple', %s) @@ to_tsquery('simple', ?)", document), tsQuery)
+
+	if !scope.All {
+		if len(scope.GroupIDs) > 0 {
+			db = db.Where("id = ? OR id IN (SELECT user_id FROM user_groups WHERE group_id IN ?)", scope.UserID, scope.GroupIDs)
+		} else {
+			db = db.Where("id = ?", scope.UserID)
+		}
+	}
+
+	var results []*models.SearchResult
+	err := db.Order("rank DESC").Limit(limit).Scan(&results).Error
+	return results, err
+}
+
+func (p *PostgresManager) searchGroups(tsQuery string, scope SearchScope, limit int) ([]*models.SearchResult, error) {
+	if !scope.All && len(scope.GroupIDs) == 0 {
+		return nil, nil
+	}
+
+	db := p.db.Table("groups").
+		Select(`'group' AS type, id, name AS title, id AS group_id,
+			ts_headline('simple', name, to_tsquery('simple', ?), ?) AS snippet,
+			ts_rank(to_tsvector('simple', coalesce(name, '')), to_tsquery('simple', ?)) AS rank`,
+			tsQuery, headlineOptions, tsQuery).
+		Where("to_tsvector('simple', coalesce(name, '')) @@ to_tsquery('simple', ?)", tsQuery)
+
+	if !scope.All {
+		db = db.Where("id IN ?", scope.GroupIDs)
+	}
+
+	var results []*models.SearchResult
+	err := db.Order("rank DESC").Limit(limit).Scan(&results).Error
+	return results, err
+}
@@ -0,0 +1,206 @@
+package modules
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"task-manager/models"
+	"time"
+)
+
+// SeedOptions controls how much demo data SeedDemoData creates
+type SeedOptions struct {
+	Groups        int
+	UsersPerGroup int
+	TasksPerUser  int
+	Password      string
+	RandomSeed    int64
+}
+
+// SeedResult summarizes the records created by SeedDemoData
+type SeedResult struct {
+	Groups int
+	Users  int
+	Tasks  int
+	Leaves int
+}
+
+var (
+	seedFirstNames = []string{"Sara", "Ali", "Maryam", "Reza", "Emma", "Liam", "Neda", "Omid", "Olivia", "Noah", "Zahra", "Arash", "Mia", "Lucas", "Parisa", "Kian"}
+	seedLastNames  = []string{"Ahmadi", "Karimi", "Smith", "Johnson", "Rahimi", "Hosseini", "Brown", "Moradi", "Garcia", "Jafari", "Miller", "Sadeghi"}
+	seedTeams      = []string{"Engineering", "Design", "Marketing", "Sales", "Support", "Operations", "Finance", "Research"}
+	seedVerbs      = []string{"Review", "Draft", "Update", "Prepare", "Fix", "Plan", "Test", "Document", "Migrate", "Present"}
+	seedSubjects   = []string{"quarterly report", "onboarding checklist", "landing page copy", "API error handling", "release notes", "customer feedback", "sprint backlog", "budget forecast", "dashboard layout", "deployment scripts", "vendor contract", "team retrospective"}
+	seedLeaveTypes = []string{"vacation", "sick", "personal"}
+)
+
+// SeedDemoData fills Redis with realistic groups, users, tasks and leave requests for development and demos.
+// Records are marked dirty so the next sync copies them to PostgreSQL.
+func SeedDemoData(opts SeedOptions) (*SeedResult, error) {
+	rng := rand.New(rand.NewSource(opts.RandomSeed))
+	result := &SeedResult{}
+	now := time.Now()
+
+	// Group names must stay unique across repeated runs
+	usedNames := make(map[string]bool)
+	existingGroups, err := RedisClient.GetAllGroups()
+	if err != nil {
+		return result, err
+	}
+	for _, group := range existingGroups {
+		usedNames[strings.ToLower(group.Name)] = true
+	}
+
+	// Every seeded user shares the password, so it is hashed once
+	var hashed models.User
+	if err := SetPassword(&hashed, opts.Password); err != nil {
+		return result, err
+	}
+
+	for g := 0; g < opts.Groups; g++ {
+		groupID, err := RedisClient.GetNextGroupID()
+		if err != nil {
+			return result, err
+		}
+
+		groupName := seedTeams[g%len(seedTeams)]
+		for n := 2; usedNames[strings.ToLower(groupName)]; n++ {
+			groupName = fmt.Sprintf("%s %d", seedTeams[g%len(seedTeams)], n)
+		}
+		usedNames[strings.ToLower(groupName)] = true
+
+		var members []*models.User
+		for u := 0; u < opts.UsersPerGroup; u++ {
+			role := "user"
+			if u == 0 {
+				role = "group_admin"
+			}
+
+			user, err := seedUser(rng, role, groupID, hashed.Password, now)
+			if err != nil {
+				return result, err
+			}
+			members = append(members, user)
+			result.Users++
+		}
+
+		group := &models.Group{
+			ID:        groupID,
+			Name:      groupName,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if len(members) > 0 {
+			group.AdminID = members[0].ID
+		}
+		if err := RedisClient.SaveGroup(group); err != nil {
+			return result, err
+		}
+		result.Groups++
+
+		for _, member := range members {
+			for t := 0; t < opts.TasksPerUser; t++ {
+				if err := seedTask(rng, member, groupID, now); err != nil {
+					return result, err
+				}
+				result.Tasks++
+			}
+
+			// Roughly one in four members has an upcoming leave
+			if rng.Intn(4) == 0 {
+				if err := seedLeave(rng, member, now); err != nil {
+					return result, err
+				}
+				result.Leaves++
+			}
+		}
+	}
+
+	RedisClient.MarkDirty("users")
+	RedisClient.MarkDirty("groups")
+	RedisClient.MarkDirty("tasks")
+	if result.Leaves > 0 {
+		RedisClient.MarkDirty("leaves")
+	}
+
+	return result, nil
+}
+
+func seedUser(rng *rand.Rand, role string, groupID int, passwordHash string, now time.Time) (*models.User, error) {
+	userID, err := RedisClient.GetNextUserID()
+	if err != nil {
+		return nil, err
+	}
+
+	firstName := seedFirstNames[rng.Intn(len(seedFirstNames))]
+	lastName := seedLastNames[rng.Intn(len(seedLastNames))]
+
+	workTimes := make(models.WorkTimes)
+	for _, day := range []string{"saturday", "sunday", "monday", "tuesday", "wednesday"} {
+		workTimes[day] = []models.WorkInterval{{Start: "09:00", End: fmt.Sprintf("%02d:00", 15+rng.Intn(3))}}
+	}
+
+	user := &models.User{
+		ID:        userID,
+		FullName:  firstName + " " + lastName,
+		Role:      role,
+		GroupIDs:  models.IntSlice{groupID},
+		Number:    fmt.Sprintf("0912%07d", rng.Intn(10000000)),
+		Email:     fmt.Sprintf("%s.%s.%d@demo.gask.local", strings.ToLower(firstName), strings.ToLower(lastName), userID),
+		Password:  passwordHash,
+		WorkTimes: workTimes,
+		CreatedAt: now.AddDate(0, 0, -rng.Intn(90)),
+		UpdatedAt: now,
+	}
+
+	return user, RedisClient.SaveUser(user)
+}
+
+func seedTask(rng *rand.Rand, user *models.User, groupID int, now time.Time) error {
+	taskID, err := RedisClient.GetNextTaskID()
+	if err != nil {
+		return err
+	}
+
+	subject := seedSubjects[rng.Intn(len(seedSubjects))]
+	createdAt := now.AddDate(0, 0, -rng.Intn(30))
+
+	task := &models.Task{
+		ID:          taskID,
+		Title:       fmt.Sprintf("%s %s", seedVerbs[rng.Intn(len(seedVerbs))], subject),
+		Priority:    models.Priority(1 + rng.Intn(4)),
+		Deadline:    now.AddDate(0, 0, rng.Intn(42)-14).Format("2006-01-02"),
+		Information: fmt.Sprintf("Demo task about the %s.", subject),
+		Status:      rng.Intn(3) == 0,
+		UserID:      user.ID,
+		GroupID:     groupID,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}
+
+	return RedisClient.SaveTask(task)
+}
+
+func seedLeave(rng *rand.Rand, user *models.User, now time.Time) error {
+	leaveID, err := RedisClient.GetNextLeaveID()
+	if err != nil {
+		return err
+	}
+
+	start := now.AddDate(0, 0, 3+rng.Intn(30))
+	end := start.AddDate(0, 0, rng.Intn(5))
+
+	leave := &models.LeaveRequest{
+		ID:        leaveID,
+		UserID:    user.ID,
+		StartDate: start.Format(models.LeaveDateLayout),
+		EndDate:   end.Format(models.LeaveDateLayout),
+		Type:      seedLeaveTypes[rng.Intn(len(seedLeaveTypes))],
+		Reason:    "Demo leave request",
+		Status:    "pending",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	return RedisClient.SaveLeaveRequest(leave)
+}
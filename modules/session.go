@@ -0,0 +1,228 @@
+package modules
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"task-manager/config"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// SessionCookieName is the cookie holding the session token
+const SessionCookieName = "gask_session"
+
+// CSRFHeader carries the session's CSRF token on state-changing requests
+const CSRFHeader = "X-CSRF-Token"
+
+// Session is a browser sign-in backed by an HttpOnly cookie
+type Session struct {
+	UserID     int       `json:"user_id"`
+	CSRFToken  string    `json:"csrf_token"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+
+	token string
+}
+
+// Token returns the cookie value; it is only known right after creation
+func (s *Session) Token() string {
+	return s.token
+}
+
+// ID names the session in the sessions admin API without revealing its token
+func (s *Session) ID() string {
+	return hashToken(s.token)
+}
+
+// sessionKey stores sessions under a hash of the token so Redis contents
+// cannot be replayed as cookies
+func sessionKey(token string) string {
+	return fmt.Sprintf("session:%s", hashToken(token))
+}
+
+// hashToken is how bearer secrets are keyed in Redis
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateSession starts a session for the user that expires after ttl
+func (r *RedisManager) CreateSession(userID int, ttl time.Duration) (*Session, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	csrfToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &Session{
+		UserID:     userID,
+		CSRFToken:  csrfToken,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+		LastSeenAt: now,
+		token:      token,
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+
+	key := sessionKey(token)
+	indexKey := fmt.Sprintf("user:%d:sessions", userID)
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(r.ctx, key, data, ttl)
+		pipe.SAdd(r.ctx, indexKey, key)
+		pipe.Expire(r.ctx, indexKey, ttl)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.enforceSignInLimit(userID)
+	return session, nil
+}
+
+// GetSession looks up an unexpired session by its cookie token
+func (r *RedisManager) GetSession(token string) (*Session, error) {
+	data, err := r.client.Get(r.ctx, sessionKey(token)).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("session %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return nil, err
+	}
+	session.token = token
+	if session.LastSeenAt.IsZero() {
+		// Sessions from before idle tracking count from their start
+		session.LastSeenAt = session.CreatedAt
+	}
+	return &session, nil
+}
+
+// TouchSession records that a session was just used, at most once per
+// signInTouchInterval. A session idle for longer than SESSION_IDLE_TIMEOUT
+// is ended instead and errInvalidSession returned.
+func (r *RedisManager) TouchSession(session *Session) error {
+	now := time.Now()
+	if signInIdle(session.LastSeenAt, now) {
+		if err := r.DeleteSession(session); err != nil {
+			return err
+		}
+		r.recordRevocation(session.UserID, session.ID(), SignInCookie, RevokedIdle)
+		return errInvalidSession
+	}
+	if now.Sub(session.LastSeenAt) < signInTouchInterval {
+		return nil
+	}
+
+	remaining := time.Until(session.ExpiresAt)
+	if remaining <= 0 {
+		return nil
+	}
+	session.LastSeenAt = now
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	// XX so a session ended meanwhile is not brought back
+	return r.client.SetXX(r.ctx, sessionKey(session.token), data, remaining).Err()
+}
+
+// DeleteSession ends a single session
+func (r *RedisManager) DeleteSession(session *Session) error {
+	return r.deleteSessionKey(session.UserID, sessionKey(session.token))
+}
+
+func (r *RedisManager) deleteSessionKey(userID int, key string) error {
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(r.ctx, key)
+		pipe.SRem(r.ctx, fmt.Sprintf("user:%d:sessions", userID), key)
+		return nil
+	})
+	return err
+}
+
+// DeleteUserSessions signs a user out everywhere, e.g. after a password change
+func (r *RedisManager) DeleteUserSessions(userID int) error {
+	indexKey := fmt.Sprintf("user:%d:sessions", userID)
+	keys, err := r.client.SMembers(r.ctx, indexKey).Result()
+	if err != nil {
+		return err
+	}
+
+	return r.client.Del(r.ctx, append(keys, indexKey)...).Err()
+}
+
+// ValidCSRFToken reports whether the request carries the session's CSRF token
+func (s *Session) ValidCSRFToken(r *http.Request) bool {
+	token := r.Header.Get(CSRFHeader)
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.CSRFToken)) == 1
+}
+
+// isStateChanging reports whether a method can modify data and so needs CSRF protection
+func isStateChanging(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS":
+		return false
+	default:
+		return true
+	}
+}
+
+// SessionsEnabled reports whether cookie sign-in is turned on with SESSION_COOKIES
+func SessionsEnabled() bool {
+	return config.AppConfig != nil && config.AppConfig.SessionCookies
+}
+
+// SetSessionCookie sends the session token as an HttpOnly, SameSite cookie
+func SetSessionCookie(w http.ResponseWriter, session *Session) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    session.token,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   config.AppConfig.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearSessionCookie tells the browser to drop the session cookie
+func ClearSessionCookie(w http.ResponseWriter) {
+	secure := config.AppConfig != nil && config.AppConfig.CookieSecure
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
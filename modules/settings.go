@@ -0,0 +1,187 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"task-manager/config"
+	"task-manager/models"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const orgSettingsKey = "settings:organization"
+
+// settingsCacheTTL is how long settings are served from memory. A change is
+// seen at once on the replica that made it and within this time on others.
+const settingsCacheTTL = 30 * time.Second
+
+// Weekdays maps the lowercase day names used in work times and settings to
+// their time.Weekday
+var Weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// OrganizationSettings are the organization-wide defaults that reports,
+// reminders, digests and new users fall back to
+type OrganizationSettings struct {
+	Name            string           `json:"name"`
+	LogoURL         string           `json:"logo_url,omitempty"`
+	Timezone        string           `json:"timezone"`
+	WorkingHours    models.WorkTimes `json:"working_hours"`
+	WeekStart       string           `json:"week_start"`
+	FiscalYearStart int              `json:"fiscal_year_start"`
+	UpdatedAt       *time.Time       `json:"updated_at,omitempty"`
+}
+
+var settingsLog = Logger("settings")
+
+var settingsCache struct {
+	mu       sync.Mutex
+	settings *OrganizationSettings
+	loadedAt time.Time
+}
+
+// DefaultOrganizationSettings are used until the owner saves settings
+func DefaultOrganizationSettings() *OrganizationSettings {
+	name, timezone := "GASK", "UTC"
+	if config.AppConfig != nil {
+		name, timezone = config.AppConfig.AppName, config.AppConfig.Timezone
+	}
+
+	workingHours := make(models.WorkTimes)
+	for _, day := range []string{"saturday", "sunday", "monday", "tuesday", "wednesday"} {
+		workingHours[day] = []models.WorkInterval{{Start: "09:00", End: "17:00"}}
+	}
+
+	return &OrganizationSettings{
+		Name:            name,
+		Timezone:        timezone,
+		WorkingHours:    workingHours,
+		WeekStart:       "sunday",
+		FiscalYearStart: 1,
+	}
+}
+
+// OrgSettings returns the organization settings from the cache, reloading
+// them when it is older than settingsCacheTTL. It falls back to the
+// defaults when Redis is not available. The result is shared, so callers
+// must not modify it.
+func OrgSettings() *OrganizationSettings {
+	settingsCache.mu.Lock()
+	defer settingsCache.mu.Unlock()
+
+	if settingsCache.settings != nil && time.Since(settingsCache.loadedAt) < settingsCacheTTL {
+		return settingsCache.settings
+	}
+	if RedisClient == nil {
+		return DefaultOrganizationSettings()
+	}
+
+	settings, err := RedisClient.GetOrganizationSettings()
+	if err != nil {
+		settingsLog.Warn("⚠️ Failed to load organization settings", "error", err)
+		if settingsCache.settings != nil {
+			return settingsCache.settings
+		}
+		return DefaultOrganizationSettings()
+	}
+
+	settingsCache.settings = settings
+	settingsCache.loadedAt = time.Now()
+	return settings
+}
+
+// ClearSettingsCache drops the cached settings so the next OrgSettings call
+// reads them from Redis again
+func ClearSettingsCache() {
+	settingsCache.mu.Lock()
+	settingsCache.settings = nil
+	settingsCache.mu.Unlock()
+}
+
+// Location is the organization's timezone, or the server's if it cannot be loaded
+func (s *OrganizationSettings) Location() *time.Location {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
+
+// WeekStartDay is the weekday the organization's week starts on
+func (s *OrganizationSettings) WeekStartDay() time.Weekday {
+	return Weekdays[strings.ToLower(s.WeekStart)]
+}
+
+// FiscalQuarter labels the fiscal quarter t falls in, e.g. FY2027-Q1. A
+// fiscal year is named after the calendar year it ends in.
+func (s *OrganizationSettings) FiscalQuarter(t time.Time) string {
+	startMonth := s.FiscalYearStart
+	if startMonth < 1 || startMonth > 12 {
+		startMonth = 1
+	}
+
+	month := int(t.Month())
+	offset := (month - startMonth + 12) % 12
+	year := t.Year()
+	if startMonth != 1 && month >= startMonth {
+		year++
+	}
+	return fmt.Sprintf("FY%d-Q%d", year, offset/3+1)
+}
+
+// GetOrganizationSettings reads the saved settings, or the defaults if
+// none were saved
+func (r *RedisManager) GetOrganizationSettings() (*OrganizationSettings, error) {
+	data, err := r.client.Get(r.ctx, orgSettingsKey).Result()
+	if err == redis.Nil {
+		return DefaultOrganizationSettings(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseOrganizationSettings([]byte(data))
+}
+
+// ParseOrganizationSettings decodes settings over the defaults so settings
+// added later get a value. Working hours replace the default days rather
+// than being merged into them.
+func ParseOrganizationSettings(data []byte) (*OrganizationSettings, error) {
+	settings := DefaultOrganizationSettings()
+	defaultHours := settings.WorkingHours
+	settings.WorkingHours = nil
+	if err := json.Unmarshal(data, settings); err != nil {
+		return nil, err
+	}
+	if settings.WorkingHours == nil {
+		settings.WorkingHours = defaultHours
+	}
+	return settings, nil
+}
+
+// SaveOrganizationSettings stores the settings and refreshes this replica's cache
+func (r *RedisManager) SaveOrganizationSettings(settings *OrganizationSettings) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	if err := r.client.Set(r.ctx, orgSettingsKey, data, 0).Err(); err != nil {
+		return err
+	}
+
+	settingsCache.mu.Lock()
+	settingsCache.settings = settings
+	settingsCache.loadedAt = time.Now()
+	settingsCache.mu.Unlock()
+	return nil
+}
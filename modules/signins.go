@@ -0,0 +1,230 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"task-manager/config"
+	"time"
+)
+
+// signInTouchInterval is how often a sign-in in use has its last use
+// recorded, so busy clients do not write on every request
+const signInTouchInterval = time.Minute
+
+// Kinds of sign-in: a cookie session or a bearer token family
+const (
+	SignInCookie = "cookie"
+	SignInToken  = "token"
+)
+
+// Reasons a sign-in was ended by the server rather than by signing out
+const (
+	RevokedIdle       = "idle"
+	RevokedLimit      = "limit"
+	RevokedAdmin      = "admin"
+	RevokedOffboarded = "offboarded"
+)
+
+// revocationHistory is how many revocations are kept per user, for
+// revocationRetention
+const (
+	revocationHistory   = 20
+	revocationRetention = 30 * 24 * time.Hour
+)
+
+// SignIn is a cookie session or a token family as the sessions admin API
+// shows it. IdleExpiresAt is when it ends unless used again, set only with
+// SESSION_IDLE_TIMEOUT.
+type SignIn struct {
+	ID            string     `json:"id"`
+	Kind          string     `json:"kind"`
+	UserID        int        `json:"user_id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastSeenAt    time.Time  `json:"last_seen_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	IdleExpiresAt *time.Time `json:"idle_expires_at,omitempty"`
+}
+
+// SignInRevocation records a sign-in the server ended: after going idle,
+// to stay within MAX_SESSIONS_PER_USER, on an admin's request or when
+// the user was offboarded
+type SignInRevocation struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Reason    string    `json:"reason"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+func signInRevocationsKey(userID int) string {
+	return fmt.Sprintf("user:%d:session_revocations", userID)
+}
+
+// signInIdle reports whether a sign-in last used at lastSeen has been idle
+// for longer than SESSION_IDLE_TIMEOUT
+func signInIdle(lastSeen, now time.Time) bool {
+	if config.AppConfig == nil || config.AppConfig.SessionIdleTimeout == 0 {
+		return false
+	}
+	return now.Sub(lastSeen) > config.AppConfig.SessionIdleTimeout
+}
+
+func newSignIn(id, kind string, userID int, createdAt, lastSeenAt, expiresAt time.Time) SignIn {
+	signIn := SignIn{
+		ID:         id,
+		Kind:       kind,
+		UserID:     userID,
+		CreatedAt:  createdAt,
+		LastSeenAt: lastSeenAt,
+		ExpiresAt:  expiresAt,
+	}
+	if config.AppConfig != nil && config.AppConfig.SessionIdleTimeout > 0 {
+		idleAt := lastSeenAt.Add(config.AppConfig.SessionIdleTimeout)
+		signIn.IdleExpiresAt = &idleAt
+	}
+	return signIn
+}
+
+// ListUserSignIns returns a user's cookie sessions and token families,
+// oldest first, dropping ended ones from the indexes as it goes
+func (r *RedisManager) ListUserSignIns(userID int) ([]SignIn, error) {
+	now := time.Now()
+	signIns := []SignIn{}
+
+	sessionsKey := fmt.Sprintf("user:%d:sessions", userID)
+	keys, err := r.client.SMembers(r.ctx, sessionsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		data, err := r.client.Get(r.ctx, key).Result()
+		var session Session
+		if err != nil || json.Unmarshal([]byte(data), &session) != nil {
+			r.client.SRem(r.ctx, sessionsKey, key)
+			continue
+		}
+		if session.LastSeenAt.IsZero() {
+			session.LastSeenAt = session.CreatedAt
+		}
+		if signInIdle(session.LastSeenAt, now) {
+			continue
+		}
+		signIns = append(signIns, newSignIn(strings.TrimPrefix(key, "session:"), SignInCookie,
+			userID, session.CreatedAt, session.LastSeenAt, session.ExpiresAt))
+	}
+
+	familiesKey := fmt.Sprintf("user:%d:token_families", userID)
+	families, err := r.client.SMembers(r.ctx, familiesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, family := range families {
+		info, err := r.getTokenFamily(family)
+		if err != nil {
+			// Families from before sign-ins were tracked stay indexed so
+			// they can still be revoked with the user's other tokens
+			if revoked, _ := r.client.Exists(r.ctx, tokenFamilyRevokedKey(family)).Result(); revoked > 0 {
+				r.client.SRem(r.ctx, familiesKey, family)
+			}
+			continue
+		}
+		if signInIdle(info.LastSeenAt, now) {
+			continue
+		}
+		signIns = append(signIns, newSignIn(family, SignInToken,
+			userID, info.CreatedAt, info.LastSeenAt, info.ExpiresAt))
+	}
+
+	sort.Slice(signIns, func(i, j int) bool {
+		return signIns[i].CreatedAt.Before(signIns[j].CreatedAt)
+	})
+	return signIns, nil
+}
+
+// RevokeSignIn ends a cookie session or token family by its ID
+func (r *RedisManager) RevokeSignIn(id string) (*SignIn, error) {
+	if data, err := r.client.Get(r.ctx, "session:"+id).Result(); err == nil {
+		var session Session
+		if err := json.Unmarshal([]byte(data), &session); err != nil {
+			return nil, err
+		}
+		signIn := newSignIn(id, SignInCookie, session.UserID, session.CreatedAt, session.LastSeenAt, session.ExpiresAt)
+		return &signIn, r.revokeSignIn(signIn, RevokedAdmin)
+	}
+
+	info, err := r.getTokenFamily(id)
+	if err != nil {
+		return nil, fmt.Errorf("session %w", ErrNotFound)
+	}
+	signIn := newSignIn(id, SignInToken, info.UserID, info.CreatedAt, info.LastSeenAt, info.ExpiresAt)
+	return &signIn, r.revokeSignIn(signIn, RevokedAdmin)
+}
+
+func (r *RedisManager) revokeSignIn(signIn SignIn, reason string) error {
+	var err error
+	if signIn.Kind == SignInCookie {
+		err = r.deleteSessionKey(signIn.UserID, "session:"+signIn.ID)
+	} else {
+		err = r.RevokeTokenFamily(signIn.ID, config.AppConfig.RefreshTokenTTL)
+	}
+	if err != nil {
+		return err
+	}
+	r.recordRevocation(signIn.UserID, signIn.ID, signIn.Kind, reason)
+	return nil
+}
+
+// enforceSignInLimit signs out a user's oldest sessions and token families
+// until they have no more than MAX_SESSIONS_PER_USER. Failures are logged,
+// as the sign-in that triggered it has already succeeded.
+func (r *RedisManager) enforceSignInLimit(userID int) {
+	if config.AppConfig == nil || config.AppConfig.MaxSessionsPerUser == 0 {
+		return
+	}
+
+	signIns, err := r.ListUserSignIns(userID)
+	if err != nil {
+		securityLog.Warn("⚠️ Failed to check session limit", "user_id", userID, "error", err)
+		return
+	}
+	for _, signIn := range signIns[:max(0, len(signIns)-config.AppConfig.MaxSessionsPerUser)] {
+		if err := r.revokeSignIn(signIn, RevokedLimit); err != nil {
+			securityLog.Warn("⚠️ Failed to end session over the limit", "user_id", userID, "kind", signIn.Kind, "error", err)
+		}
+	}
+}
+
+// recordRevocation logs a sign-in the server ended and keeps it in the
+// user's recent revocations
+func (r *RedisManager) recordRevocation(userID int, id, kind, reason string) {
+	securityLog.Info("Session revoked", "user_id", userID, "kind", kind, "reason", reason)
+
+	data, err := json.Marshal(SignInRevocation{ID: id, Kind: kind, Reason: reason, RevokedAt: time.Now()})
+	if err != nil {
+		return
+	}
+	key := signInRevocationsKey(userID)
+	pipe := r.client.TxPipeline()
+	pipe.LPush(r.ctx, key, data)
+	pipe.LTrim(r.ctx, key, 0, revocationHistory-1)
+	pipe.Expire(r.ctx, key, revocationRetention)
+	pipe.Exec(r.ctx)
+}
+
+// GetSignInRevocations returns a user's recent revocations, newest first
+func (r *RedisManager) GetSignInRevocations(userID int) ([]SignInRevocation, error) {
+	items, err := r.client.LRange(r.ctx, signInRevocationsKey(userID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	revocations := []SignInRevocation{}
+	for _, item := range items {
+		var revocation SignInRevocation
+		if json.Unmarshal([]byte(item), &revocation) == nil {
+			revocations = append(revocations, revocation)
+		}
+	}
+	return revocations, nil
+}
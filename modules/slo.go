@@ -0,0 +1,496 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"task-manager/config"
+	"time"
+)
+
+// sloSlots is how many slots a window is split into. The window slides by
+// one slot at a time, which is also how often objectives are evaluated.
+const sloSlots = 10
+
+// sloMinRequests keeps a handful of slow requests in a quiet window from
+// counting as a breach
+const sloMinRequests = 20
+
+// sloBuckets are the upper bounds latencies are counted under, used to
+// estimate the observed quantile
+var sloBuckets = [...]time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+var sloLog = Logger("slo")
+
+// SLO is a latency objective: Quantile of the requests to an endpoint finish
+// within Target
+type SLO struct {
+	// Method is empty for any method
+	Method string
+	// Path matches request paths segment by segment, * matching any one segment
+	Path     string
+	Quantile float64
+	Target   time.Duration
+}
+
+// String formats the objective the way it is configured, e.g.
+// "GET /tasks/filter p95<200ms"
+func (s SLO) String() string {
+	spec := fmt.Sprintf("%s p%s<%s", s.Path, strconv.FormatFloat(s.Quantile*100, 'g', 6, 64), s.Target)
+	if s.Method != "" {
+		return s.Method + " " + spec
+	}
+	return spec
+}
+
+// Matches reports whether a request falls under the objective
+func (s SLO) Matches(method, path string) bool {
+	if s.Method != "" && s.Method != method {
+		return false
+	}
+	pattern := strings.Split(strings.Trim(s.Path, "/"), "/")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(pattern) != len(parts) {
+		return false
+	}
+	for i, segment := range pattern {
+		if segment != "*" && segment != parts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSLOs parses "[METHOD] path pNN<duration" entries separated by commas,
+// e.g. "GET /tasks/filter p95<200ms,/users/*/tasks p99.9<1s"
+func ParseSLOs(value string) ([]SLO, error) {
+	var slos []SLO
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		slo, err := parseSLO(entry)
+		if err != nil {
+			return nil, fmt.Errorf("SLO %q: %v", entry, err)
+		}
+		slos = append(slos, slo)
+	}
+	return slos, nil
+}
+
+func parseSLO(entry string) (SLO, error) {
+	var slo SLO
+
+	fields := strings.Fields(entry)
+	switch len(fields) {
+	case 2:
+	case 3:
+		slo.Method = strings.ToUpper(fields[0])
+		fields = fields[1:]
+	default:
+		return slo, fmt.Errorf("expected [METHOD] path pNN<duration")
+	}
+
+	slo.Path = fields[0]
+	if !strings.HasPrefix(slo.Path, "/") {
+		return slo, fmt.Errorf("path must start with /")
+	}
+
+	quantile, target, ok := strings.Cut(fields[1], "<")
+	if !ok || !strings.HasPrefix(quantile, "p") {
+		return slo, fmt.Errorf("invalid objective %q, expected e.g. p95<200ms", fields[1])
+	}
+	percentile, err := strconv.ParseFloat(quantile[1:], 64)
+	if err != nil || percentile <= 0 || percentile >= 100 {
+		return slo, fmt.Errorf("invalid quantile %q, must be between p0 and p100", quantile)
+	}
+	// Rounded so p99.9 reads back as 0.999
+	slo.Quantile = math.Round(percentile*1e4) / 1e6
+
+	slo.Target, err = time.ParseDuration(target)
+	if err != nil || slo.Target <= 0 {
+		return slo, fmt.Errorf("invalid target %q", target)
+	}
+	return slo, nil
+}
+
+// sloSlot counts the requests of one slot of the window
+type sloSlot struct {
+	index   int64
+	total   uint64
+	slow    uint64
+	buckets [len(sloBuckets) + 1]uint64
+}
+
+// sloState tracks one objective over the sliding window
+type sloState struct {
+	SLO
+
+	mu    sync.Mutex
+	slots [sloSlots]sloSlot
+
+	requests  uint64
+	breaching bool
+	breaches  uint64
+}
+
+// SLOStatus is an objective's standing over the current window
+type SLOStatus struct {
+	SLO          string  `json:"slo"`
+	Requests     uint64  `json:"requests"`
+	SlowRequests uint64  `json:"slow_requests"`
+	ObservedMs   float64 `json:"observed_ms"`
+	TargetMs     float64 `json:"target_ms"`
+	BurnRate     float64 `json:"burn_rate"`
+	Breaching    bool    `json:"breaching"`
+	Breaches     uint64  `json:"breaches_total"`
+
+	slo           SLO
+	requestsTotal uint64
+}
+
+func (s *sloState) observe(index int64, duration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slot := &s.slots[index%sloSlots]
+	if slot.index != index {
+		*slot = sloSlot{index: index}
+	}
+	slot.total++
+	if duration > s.Target {
+		slot.slow++
+	}
+	bucket := len(sloBuckets)
+	for i, max := range sloBuckets {
+		if duration <= max {
+			bucket = i
+			break
+		}
+	}
+	slot.buckets[bucket]++
+	s.requests++
+}
+
+// status sums the slots still inside the window ending at index. The burn
+// rate is the share of slow requests over the share the objective allows,
+// so above 1 the error budget runs out before the window does.
+func (s *sloState) status(index int64) *SLOStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var buckets [len(sloBuckets) + 1]uint64
+	status := &SLOStatus{
+		SLO:           s.SLO.String(),
+		TargetMs:      float64(s.Target.Microseconds()) / 1000,
+		Breaching:     s.breaching,
+		Breaches:      s.breaches,
+		slo:           s.SLO,
+		requestsTotal: s.requests,
+	}
+	for _, slot := range s.slots {
+		if slot.index <= index-sloSlots || slot.index > index {
+			continue
+		}
+		status.Requests += slot.total
+		status.SlowRequests += slot.slow
+		for i, count := range slot.buckets {
+			buckets[i] += count
+		}
+	}
+	if status.Requests == 0 {
+		return status
+	}
+
+	status.BurnRate = float64(status.SlowRequests) / float64(status.Requests) / (1 - s.Quantile)
+
+	// The observed quantile is the upper bound of the bucket it falls in
+	rank := uint64(s.Quantile * float64(status.Requests))
+	var seen uint64
+	for i, count := range buckets {
+		seen += count
+		if seen > rank {
+			if i < len(sloBuckets) {
+				status.ObservedMs = float64(sloBuckets[i].Milliseconds())
+			} else {
+				status.ObservedMs = float64(sloBuckets[len(sloBuckets)-1].Milliseconds())
+			}
+			break
+		}
+	}
+	return status
+}
+
+// SLOTracker times requests against the configured latency objectives and
+// alerts when one starts or stops breaching. Each replica tracks its own
+// requests.
+type SLOTracker struct {
+	objectives []*sloState
+	window     time.Duration
+	slot       time.Duration
+	webhook    string
+	client     *OutboundClient
+
+	stopChan chan bool
+	running  bool
+	wg       sync.WaitGroup
+}
+
+var SLOs *SLOTracker
+
+// InitSLOs loads objectives from SLOS. Without any, requests are not timed.
+func InitSLOs(cfg *config.Config) error {
+	if cfg == nil {
+		cfg = config.AppConfig
+	}
+
+	slos, err := ParseSLOs(cfg.SLOs)
+	if err != nil {
+		return err
+	}
+	if len(slos) == 0 {
+		return nil
+	}
+	if cfg.SLOWindow < sloSlots*time.Second {
+		return fmt.Errorf("SLO_WINDOW must be at least %ds", sloSlots)
+	}
+
+	tracker := &SLOTracker{
+		window:   cfg.SLOWindow,
+		slot:     cfg.SLOWindow / sloSlots,
+		webhook:  cfg.SLOAlertWebhook,
+		client:   NewOutboundClient("slo alert"),
+		stopChan: make(chan bool),
+	}
+	for _, slo := range slos {
+		tracker.objectives = append(tracker.objectives, &sloState{SLO: slo})
+	}
+	SLOs = tracker
+	return nil
+}
+
+// Enabled reports whether any objective is configured
+func (t *SLOTracker) Enabled() bool {
+	return t != nil && len(t.objectives) > 0
+}
+
+func (t *SLOTracker) slotIndex(now time.Time) int64 {
+	return now.UnixNano() / int64(t.slot)
+}
+
+// Observe counts a finished request against every objective it falls under
+func (t *SLOTracker) Observe(method, path string, duration time.Duration) {
+	if !t.Enabled() {
+		return
+	}
+	index := t.slotIndex(time.Now())
+	for _, objective := range t.objectives {
+		if objective.Matches(method, path) {
+			objective.observe(index, duration)
+		}
+	}
+}
+
+// Status returns every objective's standing over the current window
+func (t *SLOTracker) Status() []*SLOStatus {
+	if !t.Enabled() {
+		return nil
+	}
+	index := t.slotIndex(time.Now())
+	statuses := make([]*SLOStatus, len(t.objectives))
+	for i, objective := range t.objectives {
+		statuses[i] = objective.status(index)
+	}
+	return statuses
+}
+
+// Window is how far back objectives look
+func (t *SLOTracker) Window() time.Duration {
+	if t == nil {
+		return 0
+	}
+	return t.window
+}
+
+func (t *SLOTracker) Start() {
+	if !t.Enabled() || t.running {
+		return
+	}
+
+	t.running = true
+	t.wg.Add(1)
+	go t.loop()
+}
+
+func (t *SLOTracker) Stop() {
+	if t == nil || !t.running {
+		return
+	}
+
+	close(t.stopChan)
+	t.running = false
+}
+
+// Drain waits for an alert being sent after Stop
+func (t *SLOTracker) Drain(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return waitForGroup(ctx, &t.wg)
+}
+
+func (t *SLOTracker) loop() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(t.slot)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.evaluate()
+		case <-t.stopChan:
+			return
+		}
+	}
+}
+
+// evaluate flags objectives burning their error budget, counting a breach
+// each time one starts
+func (t *SLOTracker) evaluate() {
+	index := t.slotIndex(time.Now())
+	for _, objective := range t.objectives {
+		status := objective.status(index)
+		breaching := status.Requests >= sloMinRequests && status.BurnRate > 1
+
+		objective.mu.Lock()
+		changed := breaching != objective.breaching
+		objective.breaching = breaching
+		if changed && breaching {
+			objective.breaches++
+		}
+		status.Breaching = breaching
+		status.Breaches = objective.breaches
+		objective.mu.Unlock()
+
+		if !changed {
+			continue
+		}
+		if breaching {
+			sloLog.Warn("⚠️ Latency objective breached", "slo", status.SLO, "burn_rate", status.BurnRate, "observed_ms", status.ObservedMs)
+		} else {
+			sloLog.Info("✅ Latency objective recovered", "slo", status.SLO)
+		}
+		if t.webhook != "" {
+			if err := t.sendAlert(status); err != nil {
+				sloLog.Warn("⚠️ Failed to send SLO alert", "slo", status.SLO, "error", err)
+			}
+		}
+	}
+}
+
+func (t *SLOTracker) sendAlert(status *SLOStatus) error {
+	state := "resolved"
+	if status.Breaching {
+		state = "breaching"
+	}
+	// Objectives read "p95<200ms", so keep < unescaped
+	var payload bytes.Buffer
+	encoder := json.NewEncoder(&payload)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(map[string]interface{}{
+		"status":  state,
+		"window":  t.window.String(),
+		"sent_at": time.Now().UTC(),
+		"slo":     status,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := t.client.Post(t.webhook, "application/json", &payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// WriteMetrics writes the objectives' standing in the Prometheus text format
+func (t *SLOTracker) WriteMetrics(w io.Writer) {
+	statuses := t.Status()
+
+	metrics := []struct {
+		name, kind, help string
+		value            func(*SLOStatus) float64
+	}{
+		{"gask_slo_requests_total", "counter", "Requests timed against the objective.",
+			func(s *SLOStatus) float64 { return float64(s.requestsTotal) }},
+		{"gask_slo_window_requests", "gauge", "Requests in the current window.",
+			func(s *SLOStatus) float64 { return float64(s.Requests) }},
+		{"gask_slo_window_slow_requests", "gauge", "Requests over the target in the current window.",
+			func(s *SLOStatus) float64 { return float64(s.SlowRequests) }},
+		{"gask_slo_latency_seconds", "gauge", "Observed latency at the objective's quantile, to bucket precision.",
+			func(s *SLOStatus) float64 { return s.ObservedMs / 1000 }},
+		{"gask_slo_target_seconds", "gauge", "Latency the objective's quantile must stay within.",
+			func(s *SLOStatus) float64 { return s.TargetMs / 1000 }},
+		{"gask_slo_burn_rate", "gauge", "Slow request share over the share the objective allows.",
+			func(s *SLOStatus) float64 { return s.BurnRate }},
+		{"gask_slo_breaching", "gauge", "1 while the objective is burning its error budget.",
+			func(s *SLOStatus) float64 {
+				if s.Breaching {
+					return 1
+				}
+				return 0
+			}},
+		{"gask_slo_breaches_total", "counter", "Times the objective started breaching.",
+			func(s *SLOStatus) float64 { return float64(s.Breaches) }},
+	}
+
+	for _, metric := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+		for _, status := range statuses {
+			fmt.Fprintf(w, "%s{method=%q,path=%q,quantile=\"%s\"} %s\n", metric.name,
+				status.slo.Method, status.slo.Path, strconv.FormatFloat(status.slo.Quantile, 'f', -1, 64),
+				strconv.FormatFloat(metric.value(status), 'g', -1, 64))
+		}
+	}
+}
+
+// SLOMiddleware times each request for the latency objectives
+func SLOMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !SLOs.Enabled() || r.Method == "OPTIONS" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		SLOs.Observe(r.Method, r.URL.Path, time.Since(start))
+	})
+}
@@ -0,0 +1,128 @@
+package modules
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"task-manager/models"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// task:{id}:subtasks holds the tasks whose ParentTaskID is {id}
+func subtasksKey(taskID int) string {
+	return fmt.Sprintf("task:%d:subtasks", taskID)
+}
+
+// maxSubtaskDepth bounds the walk up a task's parents, so a hierarchy
+// damaged outside the API cannot loop forever
+const maxSubtaskDepth = 100
+
+// indexSubtask files a task under its parent, leaving the parent it had
+// before
+func (r *RedisManager) indexSubtask(pipe redis.Pipeliner, previous *taskPlacement, task *models.Task) {
+	if previous != nil && previous.ParentTaskID != 0 && previous.ParentTaskID != task.ParentTaskID {
+		pipe.SRem(r.ctx, subtasksKey(previous.ParentTaskID), task.ID)
+	}
+	if task.ParentTaskID != 0 {
+		pipe.SAdd(r.ctx, subtasksKey(task.ParentTaskID), task.ID)
+	}
+}
+
+// GetSubtasks reads a task's direct subtasks in ID order
+func (r *RedisManager) GetSubtasks(taskID int) ([]*models.Task, error) {
+	TaskWrites.Flush()
+
+	taskIDs, err := r.client.SMembers(r.ctx, subtasksKey(taskID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*models.Task, 0, len(taskIDs))
+	for _, taskIDStr := range taskIDs {
+		subtaskID, err := strconv.Atoi(taskIDStr)
+		if err != nil {
+			continue
+		}
+
+		task, err := r.GetTask(subtaskID)
+		if err == nil && task.ParentTaskID == taskID {
+			tasks = append(tasks, task)
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks, nil
+}
+
+// IsSubtaskOf reports whether taskID is ancestorID itself or sits anywhere
+// below it, which would make ancestorID a subtask of its own subtask
+func (r *RedisManager) IsSubtaskOf(taskID, ancestorID int) (bool, error) {
+	for depth := 0; taskID != 0 && depth < maxSubtaskDepth; depth++ {
+		if taskID == ancestorID {
+			return true, nil
+		}
+		task, err := r.GetTask(taskID)
+		if err != nil {
+			return false, err
+		}
+		taskID = task.ParentTaskID
+	}
+	return false, nil
+}
+
+// detachSubtasks makes a deleted task's subtasks top-level tasks
+func (r *RedisManager) detachSubtasks(taskID int) {
+	subtasks, err := r.GetSubtasks(taskID)
+	if err != nil {
+		return
+	}
+	for _, subtask := range subtasks {
+		subtask.ParentTaskID = 0
+		if err := r.SaveTask(subtask); err != nil {
+			redisLog.Warn("⚠️ Failed to detach subtask", "task_id", subtask.ID, "parent_task_id", taskID, "error", err)
+		}
+	}
+	r.client.Del(r.ctx, subtasksKey(taskID))
+}
+
+// SubtaskProgress rolls a task's subtasks up into how many are done. Total
+// leaves out cancelled subtasks, which never will be.
+type SubtaskProgress struct {
+	Total     int `json:"total"`
+	Done      int `json:"done"`
+	Cancelled int `json:"cancelled,omitempty"`
+}
+
+// RollUpSubtasks counts the subtasks by whether they are done
+func RollUpSubtasks(subtasks []*models.Task) *SubtaskProgress {
+	progress := &SubtaskProgress{}
+	for _, subtask := range subtasks {
+		switch subtask.CurrentState() {
+		case models.TaskCancelled:
+			progress.Cancelled++
+			continue
+		case models.TaskDone:
+			progress.Done++
+		}
+		progress.Total++
+	}
+	return progress
+}
+
+// GetSubtaskProgress rolls up a task's subtasks, or returns nil when it
+// has none
+func (r *RedisManager) GetSubtaskProgress(taskID int) (*SubtaskProgress, error) {
+	count, err := r.client.SCard(r.ctx, subtasksKey(taskID)).Result()
+	if err != nil || count == 0 {
+		return nil, err
+	}
+
+	subtasks, err := r.GetSubtasks(taskID)
+	if err != nil || len(subtasks) == 0 {
+		return nil, err
+	}
+	return RollUpSubtasks(subtasks), nil
+}
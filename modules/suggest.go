@@ -0,0 +1,192 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"task-manager/models"
+	"time"
+)
+
+// suggestionHorizon is how many days ahead SuggestDeadline plans before
+// giving up
+const suggestionHorizon = 180
+
+// ErrNoWorkingTime is returned when a user has no time to work within
+// suggestionHorizon, such as someone without work times
+var ErrNoWorkingTime = errors.New("no working time within the planning horizon")
+
+// DeadlineSuggestion is a proposed deadline and how it was worked out
+type DeadlineSuggestion struct {
+	Deadline      string          `json:"deadline"`
+	FinishesAt    time.Time       `json:"finishes_at"`
+	EffortMinutes int             `json:"effort_minutes"`
+	QueuedMinutes int             `json:"queued_minutes"`
+	QueuedTasks   int             `json:"queued_tasks"`
+	Unestimated   int             `json:"unestimated_tasks"`
+	Days          []SuggestionDay `json:"days"`
+	Reasons       []string        `json:"reasons"`
+	Clock         string          `json:"clock"`
+	Timezone      string          `json:"timezone"`
+}
+
+// SuggestionDay is one day of the plan: the minutes the user works, those
+// taken by tasks already queued and those left for the new task. Skipped
+// days say why no work was planned.
+type SuggestionDay struct {
+	Date          string `json:"date"`
+	WorkMinutes   int    `json:"work_minutes"`
+	QueuedMinutes int    `json:"queued_minutes"`
+	TaskMinutes   int    `json:"task_minutes"`
+	Skipped       string `json:"skipped,omitempty"`
+}
+
+// SuggestDeadline proposes when a user could finish effortMinutes of work
+// starting at now. The estimates of their open tasks are worked through
+// first. Under the wall clock only their work times count, skipping
+// holidays and leave; under the business clock the organization's working
+// hours and holidays count instead.
+func (r *RedisManager) SuggestDeadline(user *models.User, clock string, effortMinutes int, now time.Time) (*DeadlineSuggestion, error) {
+	location := OrgSettings().Location()
+	now = now.In(location)
+
+	tasks, err := r.GetUserTasks(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestion := &DeadlineSuggestion{
+		EffortMinutes: effortMinutes,
+		Days:          []SuggestionDay{},
+		Clock:         ClockWall,
+		Timezone:      location.String(),
+	}
+	calendar := UserCalendar(user)
+	if clock == ClockBusiness {
+		calendar, suggestion.Clock = BusinessCalendar(), ClockBusiness
+	}
+	for _, task := range tasks {
+		if task.Closed() {
+			continue
+		}
+		if task.EstimatedHours <= 0 {
+			suggestion.Unestimated++
+			continue
+		}
+		suggestion.QueuedTasks++
+		suggestion.QueuedMinutes += int(task.EstimatedHours * 60)
+	}
+
+	queued, effort := suggestion.QueuedMinutes, effortMinutes
+	var skipped []string
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	for offset := 0; offset < suggestionHorizon; offset++ {
+		date := day.AddDate(0, 0, offset)
+		from := date
+		if offset == 0 {
+			from = now
+		}
+
+		plan := SuggestionDay{Date: date.Format(models.LeaveDateLayout), Skipped: r.dayOff(calendar, date)}
+		if plan.Skipped == "" {
+			plan.WorkMinutes = workMinutesFrom(calendar.Hours, from)
+			if plan.WorkMinutes == 0 {
+				// Days off are left out of the plan unless something else
+				// explains them
+				continue
+			}
+		}
+		if plan.Skipped != "" {
+			if calendar.Hours.Hours(weekdayName(date)) > 0 {
+				skipped = append(skipped, fmt.Sprintf("%s (%s)", plan.Date, plan.Skipped))
+				suggestion.Days = append(suggestion.Days, plan)
+			}
+			continue
+		}
+
+		plan.QueuedMinutes = min(queued, plan.WorkMinutes)
+		queued -= plan.QueuedMinutes
+		plan.TaskMinutes = min(effort, plan.WorkMinutes-plan.QueuedMinutes)
+		effort -= plan.TaskMinutes
+		suggestion.Days = append(suggestion.Days, plan)
+
+		if effort == 0 {
+			suggestion.FinishesAt = finishTime(calendar.Hours, from, plan.QueuedMinutes+plan.TaskMinutes)
+			suggestion.Deadline = plan.Date
+			break
+		}
+	}
+	if suggestion.Deadline == "" {
+		return nil, ErrNoWorkingTime
+	}
+
+	suggestion.Reasons = deadlineReasons(user, calendar, suggestion, skipped)
+	return suggestion, nil
+}
+
+// workMinutesFrom returns how many minutes of from's day the user works
+// from then on
+func workMinutesFrom(workTimes models.WorkTimes, from time.Time) int {
+	minute := from.Hour()*60 + from.Minute()
+	total := 0
+	for _, interval := range workTimes[weekdayName(from)] {
+		start, end, ok := interval.Minutes()
+		if !ok || end <= minute {
+			continue
+		}
+		total += end - max(start, minute)
+	}
+	return total
+}
+
+// finishTime returns when worked minutes of work, started at from, are done
+func finishTime(workTimes models.WorkTimes, from time.Time, worked int) time.Time {
+	midnight := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	minute := from.Hour()*60 + from.Minute()
+	for _, interval := range workTimes[weekdayName(from)] {
+		start, end, ok := interval.Minutes()
+		if !ok || end <= minute {
+			continue
+		}
+		start = max(start, minute)
+		if worked <= end-start {
+			return midnight.Add(time.Duration(start+worked) * time.Minute)
+		}
+		worked -= end - start
+	}
+	return midnight.Add(24 * time.Hour)
+}
+
+func weekdayName(day time.Time) string {
+	return strings.ToLower(day.Weekday().String())
+}
+
+// deadlineReasons explains a suggestion in a few sentences clients can show
+func deadlineReasons(user *models.User, calendar *WorkCalendar, suggestion *DeadlineSuggestion, skipped []string) []string {
+	weekly := formatHours(calendar.WeeklyHours())
+	hours := fmt.Sprintf("%s works %s hours a week", user.FullName, weekly)
+	if calendar.UserID == 0 {
+		hours = fmt.Sprintf("business hours are %s hours a week", weekly)
+	}
+	reasons := []string{hours}
+
+	if suggestion.QueuedTasks > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d open tasks estimated at %s hours come first",
+			suggestion.QueuedTasks, formatHours(float64(suggestion.QueuedMinutes)/60)))
+	} else {
+		reasons = append(reasons, "no estimated open tasks come first")
+	}
+	if suggestion.Unestimated > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d open tasks have no estimate and are not counted", suggestion.Unestimated))
+	}
+	if len(skipped) > 0 {
+		reasons = append(reasons, "skipped working days: "+strings.Join(skipped, ", "))
+	}
+	reasons = append(reasons, fmt.Sprintf("%s hours of effort are done by %s",
+		formatHours(float64(suggestion.EffortMinutes)/60), suggestion.FinishesAt.Format("2006-01-02 15:04")))
+	return reasons
+}
+
+func formatHours(hours float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", hours), "0"), ".")
+}
@@ -1,36 +1,62 @@
 package modules
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"sync"
+	"task-manager/config"
 	"task-manager/models"
 	"time"
 )
 
+var syncLog = Logger("sync")
+
 type SyncService struct {
 	syncInterval time.Duration
-	stopChan     chan bool
 	running      bool
+	wg           sync.WaitGroup
+
+	// Consecutive failed cycles, alerted to the owner after alertAfter
+	failures     syncFailureStreak
+	alertAfter   int
+	alertWebhook string
+	client       *OutboundClient
 }
 
 var Syncer *SyncService
 
+// syncLockTTL bounds how long a crashed replica can block syncing on the others
+const syncLockTTL = 5 * time.Minute
+
 func InitSyncService() {
 	Syncer = &SyncService{
 		syncInterval: 15 * time.Minute,
-		stopChan:     make(chan bool, 1),
 		running:      false,
+		client:       NewOutboundClient("sync alert"),
+	}
+	if config.AppConfig != nil {
+		Syncer.syncInterval = config.AppConfig.SyncInterval
+		Syncer.alertAfter = config.AppConfig.SyncAlertAfter
+		Syncer.alertWebhook = config.AppConfig.SyncAlertWebhook
 	}
 }
 
+// Start schedules sync cycles with Jobs as the "sync" job, the first one
+// right away
 func (s *SyncService) Start() {
 	if s.running {
 		return
 	}
 
 	s.running = true
-	go s.syncLoop()
-	fmt.Println("🔄 Sync service started (15 minute interval)")
+	Jobs.Register(JobSpec{
+		Name:       "sync",
+		Schedule:   EverySchedule(s.syncInterval),
+		RunAtStart: true,
+		Run:        s.performSync,
+	})
+	fmt.Printf("🔄 Sync service started (%s interval)\n", s.syncInterval)
 }
 
 func (s *SyncService) Stop() {
@@ -38,33 +64,28 @@ func (s *SyncService) Stop() {
 		return
 	}
 
-	s.stopChan <- true
 	s.running = false
 	fmt.Println("⏹️ Sync service stopped")
 }
 
-func (s *SyncService) syncLoop() {
-	ticker := time.NewTicker(s.syncInterval)
-	defer ticker.Stop()
-
-	if err := s.performSync(); err != nil {
-		log.Printf("❌ Initial sync failed: %v", err)
-	}
+// Drain waits for sync alerts being sent to finish after Stop
+func (s *SyncService) Drain(ctx context.Context) error {
+	return waitForGroup(ctx, &s.wg)
+}
 
-	for {
-		select {
-		case <-ticker.C:
-			if err := s.performSync(); err != nil {
-				log.Printf("❌ Sync failed: %v", err)
-			}
-		case <-s.stopChan:
-			return
-		}
+// performSync runs one sync cycle; only one replica syncs at a time
+func (s *SyncService) performSync() error {
+	err := RedisClient.WithLock("sync", syncLockTTL, s.syncDirtyTypes)
+	if errors.Is(err, ErrLockNotAcquired) {
+		syncLog.Info("⏭️ Sync already running on another instance, skipped")
+		return nil
 	}
+	s.recordResult(err)
+	return err
 }
 
-func (s *SyncService) performSync() error {
-	log.Println("🔄 Starting sync from Redis to PostgreSQL...")
+func (s *SyncService) syncDirtyTypes() error {
+	syncLog.Info("🔄 Starting sync from Redis to PostgreSQL")
 	startTime := time.Now()
 
 	dirtyTypes, err := RedisClient.GetDirtyTypes()
@@ -73,7 +94,7 @@ func (s *SyncService) performSync() error {
 	}
 
 	if len(dirtyTypes) == 0 {
-		log.Println("✅ No changes detected, sync skipped")
+		syncLog.Info("✅ No changes detected, sync skipped")
 		return nil
 	}
 
@@ -82,7 +103,7 @@ func (s *SyncService) performSync() error {
 	if contains(dirtyTypes, "users") || len(dirtyTypes) == 0 {
 		count, err := s.syncUsers()
 		if err != nil {
-			return fmt.Errorf("failed to sync users: %v", err)
+			return &SyncError{Table: "users", Pending: dirtyTypes, Err: err}
 		}
 		syncStats["users"] = count
 	}
@@ -90,7 +111,7 @@ func (s *SyncService) performSync() error {
 	if contains(dirtyTypes, "groups") || len(dirtyTypes) == 0 {
 		count, err := s.syncGroups()
 		if err != nil {
-			return fmt.Errorf("failed to sync groups: %v", err)
+			return &SyncError{Table: "groups", Pending: dirtyTypes, Err: err}
 		}
 		syncStats["groups"] = count
 	}
@@ -98,26 +119,92 @@ func (s *SyncService) performSync() error {
 	if contains(dirtyTypes, "tasks") || len(dirtyTypes) == 0 {
 		count, err := s.syncTasks()
 		if err != nil {
-			return fmt.Errorf("failed to sync tasks: %v", err)
+			return &SyncError{Table: "tasks", Pending: dirtyTypes, Err: err}
 		}
 		syncStats["tasks"] = count
 	}
 
-	if err := s.syncCounters(); err != nil {
-		log.Printf("⚠️ Failed to sync counters: %v", err)
+	if contains(dirtyTypes, "leaves") {
+		count, err := s.syncLeaves()
+		if err != nil {
+			return &SyncError{Table: "leave_requests", Pending: dirtyTypes, Err: err}
+		}
+		syncStats["leaves"] = count
+	}
+
+	if contains(dirtyTypes, "reports") {
+		count, err := s.syncReportSubscriptions()
+		if err != nil {
+			return &SyncError{Table: "report_subscriptions", Pending: dirtyTypes, Err: err}
+		}
+		syncStats["reports"] = count
+	}
+
+	if contains(dirtyTypes, "clients") {
+		count, err := s.syncClients()
+		if err != nil {
+			return &SyncError{Table: "clients", Pending: dirtyTypes, Err: err}
+		}
+		syncStats["clients"] = count
+	}
+
+	if contains(dirtyTypes, "risks") {
+		count, err := s.syncRisks()
+		if err != nil {
+			return &SyncError{Table: "risks", Pending: dirtyTypes, Err: err}
+		}
+		syncStats["risks"] = count
+	}
+
+	if contains(dirtyTypes, "objectives") {
+		count, err := s.syncObjectives()
+		if err != nil {
+			return &SyncError{Table: "objectives", Pending: dirtyTypes, Err: err}
+		}
+		syncStats["objectives"] = count
+	}
+
+	if contains(dirtyTypes, "comments") {
+		count, err := s.syncComments()
+		if err != nil {
+			return &SyncError{Table: "comments", Pending: dirtyTypes, Err: err}
+		}
+		syncStats["comments"] = count
+	}
+
+	if contains(dirtyTypes, "attachments") {
+		count, err := s.syncAttachments()
+		if err != nil {
+			return &SyncError{Table: "attachments", Pending: dirtyTypes, Err: err}
+		}
+		syncStats["attachments"] = count
+	}
+
+	if err := s.ReconcileCounters(); err != nil {
+		syncLog.Warn("⚠️ Failed to sync counters", "error", err)
 	}
 
 	if err := RedisClient.ClearDirtyTypes(); err != nil {
-		log.Printf("⚠️ Failed to clear dirty types: %v", err)
+		syncLog.Warn("⚠️ Failed to clear dirty types", "error", err)
 	}
 
 	if err := RedisClient.SetLastSyncTime(); err != nil {
-		log.Printf("⚠️ Failed to set last sync time: %v", err)
+		syncLog.Warn("⚠️ Failed to set last sync time", "error", err)
 	}
 
 	duration := time.Since(startTime)
-	log.Printf("✅ Sync completed in %v - Users: %d, Groups: %d, Tasks: %d",
-		duration, syncStats["users"], syncStats["groups"], syncStats["tasks"])
+	syncLog.Info("✅ Sync completed",
+		"duration_ms", duration.Milliseconds(),
+		"users", syncStats["users"],
+		"groups", syncStats["groups"],
+		"tasks", syncStats["tasks"],
+		"leaves", syncStats["leaves"],
+		"reports", syncStats["reports"],
+		"clients", syncStats["clients"],
+		"risks", syncStats["risks"],
+		"objectives", syncStats["objectives"],
+		"comments", syncStats["comments"],
+		"attachments", syncStats["attachments"])
 
 	return nil
 }
@@ -148,7 +235,39 @@ func (s *SyncService) syncGroups() (int, error) {
 	return len(groups), nil
 }
 
+// syncTasks writes the tasks of the groups changed since the last sync,
+// or every task when no group was recorded
 func (s *SyncService) syncTasks() (int, error) {
+	groupIDs, err := RedisClient.GetDirtyTaskGroups()
+	if err != nil {
+		return 0, err
+	}
+	if len(groupIDs) == 0 {
+		return s.syncAllTasks()
+	}
+
+	var tasks []*models.Task
+	for _, groupID := range groupIDs {
+		err := RedisClient.EachGroupTask(groupID, func(task *models.Task) error {
+			tasks = append(tasks, task)
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := PostgresClient.SyncTasks(tasks); err != nil {
+		return 0, err
+	}
+	if err := RedisClient.ClearDirtyTaskGroups(groupIDs); err != nil {
+		syncLog.Warn("⚠️ Failed to clear dirty task groups", "error", err)
+	}
+
+	return len(tasks), nil
+}
+
+func (s *SyncService) syncAllTasks() (int, error) {
 	users, err := RedisClient.GetAllUsers()
 	if err != nil {
 		return 0, err
@@ -170,40 +289,149 @@ func (s *SyncService) syncTasks() (int, error) {
 	return len(allTasks), nil
 }
 
-func (s *SyncService) syncCounters() error {
-	maxUserID, err := PostgresClient.GetMaxUserID()
+func (s *SyncService) syncLeaves() (int, error) {
+	leaves, err := RedisClient.GetAllLeaveRequests()
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	if err := PostgresClient.SyncLeaveRequests(leaves); err != nil {
+		return 0, err
 	}
 
-	maxGroupID, err := PostgresClient.GetMaxGroupID()
+	return len(leaves), nil
+}
+
+func (s *SyncService) syncReportSubscriptions() (int, error) {
+	subs, err := RedisClient.GetAllReportSubscriptions()
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	if err := PostgresClient.SyncReportSubscriptions(subs); err != nil {
+		return 0, err
 	}
 
-	maxTaskID, err := PostgresClient.GetMaxTaskID()
+	return len(subs), nil
+}
+
+func (s *SyncService) syncClients() (int, error) {
+	clients, err := RedisClient.GetAllClients()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	currentUserID, _ := RedisClient.GetNextUserID()
-	if maxUserID >= currentUserID {
-		for i := currentUserID; i <= maxUserID; i++ {
-			RedisClient.GetNextUserID()
-		}
+	if err := PostgresClient.SyncClients(clients); err != nil {
+		return 0, err
+	}
+
+	return len(clients), nil
+}
+
+func (s *SyncService) syncRisks() (int, error) {
+	risks, err := RedisClient.GetAllRisks()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := PostgresClient.SyncRisks(risks); err != nil {
+		return 0, err
+	}
+
+	return len(risks), nil
+}
+
+func (s *SyncService) syncObjectives() (int, error) {
+	objectives, err := RedisClient.GetAllObjectives()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := PostgresClient.SyncObjectives(objectives); err != nil {
+		return 0, err
+	}
+
+	return len(objectives), nil
+}
+
+func (s *SyncService) syncComments() (int, error) {
+	comments, err := RedisClient.GetAllComments()
+	if err != nil {
+		return 0, err
+	}
+	deletedIDs, err := RedisClient.GetDeletedIDs("comments")
+	if err != nil {
+		return 0, err
+	}
+
+	if err := PostgresClient.SyncComments(comments, deletedIDs); err != nil {
+		return 0, err
+	}
+	if err := RedisClient.ClearDeletedIDs("comments", deletedIDs); err != nil {
+		syncLog.Warn("⚠️ Failed to clear deleted comments", "error", err)
+	}
+
+	return len(comments), nil
+}
+
+func (s *SyncService) syncAttachments() (int, error) {
+	attachments, err := RedisClient.GetAllAttachments()
+	if err != nil {
+		return 0, err
+	}
+	deletedIDs, err := RedisClient.GetDeletedIDs("attachments")
+	if err != nil {
+		return 0, err
 	}
 
-	currentGroupID, _ := RedisClient.GetNextGroupID()
-	if maxGroupID >= currentGroupID {
-		for i := currentGroupID; i <= maxGroupID; i++ {
-			RedisClient.GetNextGroupID()
+	if err := PostgresClient.SyncAttachments(attachments, deletedIDs); err != nil {
+		return 0, err
+	}
+	if err := RedisClient.ClearDeletedIDs("attachments", deletedIDs); err != nil {
+		syncLog.Warn("⚠️ Failed to clear deleted attachments", "error", err)
+	}
+
+	return len(attachments), nil
+}
+
+// ReconcileCounters raises every ID counter to the largest ID stored in
+// PostgreSQL, so IDs are not issued twice after Redis is flushed or
+// restored. Counters are only ever raised, so it is safe to run while
+// other replicas are creating records.
+func (s *SyncService) ReconcileCounters() error {
+	counters := []struct {
+		key   string
+		maxID func() (int, error)
+	}{
+		{"counter:user_id", PostgresClient.GetMaxUserID},
+		{"counter:group_id", PostgresClient.GetMaxGroupID},
+		{"counter:task_id", PostgresClient.GetMaxTaskID},
+		{"counter:leave_id", PostgresClient.GetMaxLeaveID},
+		{"counter:report_sub_id", PostgresClient.GetMaxReportSubscriptionID},
+		{"counter:client_id", PostgresClient.GetMaxClientID},
+		{"counter:risk_id", PostgresClient.GetMaxRiskID},
+		{"counter:objective_id", PostgresClient.GetMaxObjectiveID},
+		{"counter:comment_id", PostgresClient.GetMaxCommentID},
+		{"counter:attachment_id", PostgresClient.GetMaxAttachmentID},
+	}
+
+	for _, counter := range counters {
+		maxID, err := counter.maxID()
+		if err != nil {
+			return err
+		}
+		if err := RedisClient.RaiseCounter(counter.key, maxID); err != nil {
+			return err
 		}
 	}
 
-	currentTaskID, _ := RedisClient.GetNextTaskID()
-	if maxTaskID >= currentTaskID {
-		for i := currentTaskID; i <= maxTaskID; i++ {
-			RedisClient.GetNextTaskID()
+	taskKeySequences, err := PostgresClient.GetMaxTaskKeySequences()
+	if err != nil {
+		return err
+	}
+	for code, sequence := range taskKeySequences {
+		if err := RedisClient.EnsureTaskKeySequence(code, sequence); err != nil {
+			return err
 		}
 	}
 
@@ -211,12 +439,12 @@ func (s *SyncService) syncCounters() error {
 }
 
 func (s *SyncService) ForceSyncNow() error {
-	log.Println("🔄 Force sync requested...")
+	syncLog.Info("🔄 Force sync requested")
 	return s.performSync()
 }
 
 func (s *SyncService) SyncFromPostgresToRedis() error {
-	log.Println("🔄 Starting sync from PostgreSQL to Redis...")
+	syncLog.Info("🔄 Starting sync from PostgreSQL to Redis")
 	startTime := time.Now()
 
 	users, err := PostgresClient.GetAllUsers()
@@ -226,7 +454,7 @@ func (s *SyncService) SyncFromPostgresToRedis() error {
 
 	for _, user := range users {
 		if err := RedisClient.SaveUser(user); err != nil {
-			log.Printf("⚠️ Failed to save user %d to Redis: %v", user.ID, err)
+			syncLog.Warn("⚠️ Failed to save user to Redis", "user_id", user.ID, "error", err)
 		}
 	}
 
@@ -237,7 +465,7 @@ func (s *SyncService) SyncFromPostgresToRedis() error {
 
 	for _, group := range groups {
 		if err := RedisClient.SaveGroup(group); err != nil {
-			log.Printf("⚠️ Failed to save group %d to Redis: %v", group.ID, err)
+			syncLog.Warn("⚠️ Failed to save group to Redis", "group_id", group.ID, "error", err)
 		}
 	}
 
@@ -249,14 +477,98 @@ func (s *SyncService) SyncFromPostgresToRedis() error {
 
 		for _, task := range tasks {
 			if err := RedisClient.SaveTask(task); err != nil {
-				log.Printf("⚠️ Failed to save task %d to Redis: %v", task.ID, err)
+				syncLog.Warn("⚠️ Failed to save task to Redis", "task_id", task.ID, "error", err)
 			}
 		}
 	}
 
+	leaves, err := PostgresClient.GetAllLeaveRequests()
+	if err != nil {
+		return fmt.Errorf("failed to get leave requests from PostgreSQL: %v", err)
+	}
+
+	for _, leave := range leaves {
+		if err := RedisClient.SaveLeaveRequest(leave); err != nil {
+			syncLog.Warn("⚠️ Failed to save leave request to Redis", "leave_id", leave.ID, "error", err)
+		}
+	}
+
+	subs, err := PostgresClient.GetAllReportSubscriptions()
+	if err != nil {
+		return fmt.Errorf("failed to get report subscriptions from PostgreSQL: %v", err)
+	}
+
+	for _, sub := range subs {
+		if err := RedisClient.SaveReportSubscription(sub); err != nil {
+			syncLog.Warn("⚠️ Failed to save report subscription to Redis", "subscription_id", sub.ID, "error", err)
+		}
+	}
+
+	clients, err := PostgresClient.GetAllClients()
+	if err != nil {
+		return fmt.Errorf("failed to get clients from PostgreSQL: %v", err)
+	}
+
+	for _, client := range clients {
+		if err := RedisClient.SaveClient(client); err != nil {
+			syncLog.Warn("⚠️ Failed to save client to Redis", "client_id", client.ID, "error", err)
+		}
+	}
+
+	risks, err := PostgresClient.GetAllRisks()
+	if err != nil {
+		return fmt.Errorf("failed to get risks from PostgreSQL: %v", err)
+	}
+
+	for _, risk := range risks {
+		if err := RedisClient.SaveRisk(risk); err != nil {
+			syncLog.Warn("⚠️ Failed to save risk to Redis", "risk_id", risk.ID, "error", err)
+		}
+	}
+
+	objectives, err := PostgresClient.GetAllObjectives()
+	if err != nil {
+		return fmt.Errorf("failed to get objectives from PostgreSQL: %v", err)
+	}
+
+	for _, objective := range objectives {
+		if err := RedisClient.SaveObjective(objective); err != nil {
+			syncLog.Warn("⚠️ Failed to save objective to Redis", "objective_id", objective.ID, "error", err)
+		}
+	}
+
+	comments, err := PostgresClient.GetAllComments()
+	if err != nil {
+		return fmt.Errorf("failed to get comments from PostgreSQL: %v", err)
+	}
+
+	for _, comment := range comments {
+		if err := RedisClient.SaveComment(comment); err != nil {
+			syncLog.Warn("⚠️ Failed to save comment to Redis", "comment_id", comment.ID, "error", err)
+		}
+	}
+
+	attachments, err := PostgresClient.GetAllAttachments()
+	if err != nil {
+		return fmt.Errorf("failed to get attachments from PostgreSQL: %v", err)
+	}
+
+	for _, attachment := range attachments {
+		if err := RedisClient.SaveAttachment(attachment); err != nil {
+			syncLog.Warn("⚠️ Failed to save attachment to Redis", "attachment_id", attachment.ID, "error", err)
+		}
+	}
+
+	if err := s.ReconcileCounters(); err != nil {
+		return fmt.Errorf("failed to reconcile ID counters: %v", err)
+	}
+
 	duration := time.Since(startTime)
-	log.Printf("✅ Reverse sync completed in %v - Users: %d, Groups: %d",
-		duration, len(users), len(groups))
+	syncLog.Info("✅ Reverse sync completed",
+		"duration_ms", duration.Milliseconds(),
+		"users", len(users),
+		"groups", len(groups),
+		"leaves", len(leaves))
 
 	return nil
 }
@@ -286,6 +598,7 @@ func (s *SyncService) GetSyncStatus() map[string]interface{} {
 	dirtyTypes, _ := RedisClient.GetDirtyTypes()
 	status["dirty_types"] = dirtyTypes
 	status["pending_changes"] = len(dirtyTypes) > 0
+	status["failures"] = s.failures.status()
 
 	return status
 }
@@ -300,7 +613,7 @@ func contains(slice []string, item string) bool {
 }
 
 func (s *SyncService) EmergencyBackup() error {
-	log.Println("🆘 Performing emergency backup...")
+	syncLog.Warn("🆘 Performing emergency backup")
 
 	if err := s.ForceSyncNow(); err != nil {
 		return err
@@ -310,6 +623,6 @@ func (s *SyncService) EmergencyBackup() error {
 }
 
 func (s *SyncService) RestoreFromPostgreSQL() error {
-	log.Println("🔧 Restoring data from PostgreSQL...")
+	syncLog.Warn("🔧 Restoring data from PostgreSQL")
 	return s.SyncFromPostgresToRedis()
 }
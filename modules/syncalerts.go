@@ -0,0 +1,154 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"task-manager/config"
+	"time"
+)
+
+// SyncError is a sync cycle that failed on one table. Pending lists the
+// kinds of changes the cycle had to sync, none of which are marked synced.
+type SyncError struct {
+	Table   string
+	Pending []string
+	Err     error
+}
+
+func (e *SyncError) Error() string {
+	return fmt.Sprintf("failed to sync %s: %v", e.Table, e.Err)
+}
+
+func (e *SyncError) Unwrap() error {
+	return e.Err
+}
+
+// syncFailureStreak counts the cycles that failed since the last one that
+// succeeded on this instance
+type syncFailureStreak struct {
+	mu            sync.Mutex
+	count         int
+	since         time.Time
+	lastError     string
+	failedTable   string
+	pendingTables []string
+	alerted       bool
+}
+
+func (f *syncFailureStreak) status() map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	status := map[string]interface{}{"consecutive": f.count}
+	if f.count > 0 {
+		status["since"] = f.since
+		status["last_error"] = f.lastError
+		status["failed_table"] = f.failedTable
+		status["pending_tables"] = f.pendingTables
+		status["alerted"] = f.alerted
+	}
+	return status
+}
+
+// recordResult extends or ends the failure streak. The owner is alerted once
+// when the streak reaches alertAfter and again when a cycle succeeds after that.
+func (s *SyncService) recordResult(err error) {
+	f := &s.failures
+	f.mu.Lock()
+
+	if err == nil {
+		failures, since, alerted := f.count, f.since, f.alerted
+		f.count, f.lastError, f.failedTable, f.pendingTables, f.alerted = 0, "", "", nil, false
+		f.mu.Unlock()
+
+		if alerted {
+			syncLog.Info("✅ Sync recovered", "failures", failures)
+			s.alert("sync_recovered", map[string]interface{}{
+				"Failures": failures,
+				"Since":    since,
+			})
+		}
+		return
+	}
+
+	if f.count == 0 {
+		f.since = time.Now()
+	}
+	f.count++
+	f.lastError = err.Error()
+	f.failedTable, f.pendingTables = "", nil
+	var syncErr *SyncError
+	if errors.As(err, &syncErr) {
+		f.failedTable, f.pendingTables = syncErr.Table, syncErr.Pending
+	}
+
+	due := s.alertAfter > 0 && f.count >= s.alertAfter && !f.alerted
+	if due {
+		f.alerted = true
+	}
+	data := map[string]interface{}{
+		"Failures":      f.count,
+		"Since":         f.since,
+		"LastError":     f.lastError,
+		"FailedTable":   f.failedTable,
+		"PendingTables": f.pendingTables,
+	}
+	f.mu.Unlock()
+
+	if due {
+		syncLog.Error("🚨 Sync keeps failing, alerting the owner", "failures", data["Failures"], "error", err)
+		s.alert("sync_failing", data)
+	}
+}
+
+// alert emails the owner and posts to SYNC_ALERT_WEBHOOK when set. Both are
+// sent in the background and waited for by Drain.
+func (s *SyncService) alert(event string, data map[string]interface{}) {
+	if config.AppConfig != nil && config.AppConfig.OwnerEmail != "" {
+		Mailer.SendTemplateAsync(context.Background(), config.AppConfig.OwnerEmail, event, data)
+	}
+	if s.alertWebhook == "" {
+		return
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":          strings.Replace(event, "_", ".", 1),
+		"failures":       data["Failures"],
+		"since":          data["Since"],
+		"last_error":     data["LastError"],
+		"failed_table":   data["FailedTable"],
+		"pending_tables": data["PendingTables"],
+		"sent_at":        time.Now().UTC(),
+	})
+	if err != nil {
+		syncLog.Warn("⚠️ Failed to build sync alert", "error", err)
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.postAlert(payload); err != nil {
+			syncLog.Warn("⚠️ Failed to send sync alert", "event", event, "error", err)
+		}
+	}()
+}
+
+func (s *SyncService) postAlert(payload []byte) error {
+	resp, err := s.client.Post(s.alertWebhook, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package modules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"task-manager/models"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// GroupCodePattern is what a group code looks like: 2-10 uppercase letters
+// and digits, starting with a letter
+var GroupCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}$`)
+
+// taskKeyPattern matches a task key such as GASK-142
+var taskKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]{1,9}-[0-9]+$`)
+
+// IsTaskKey reports whether s looks like a task key, in any case
+func IsTaskKey(s string) bool {
+	return taskKeyPattern.MatchString(s)
+}
+
+func taskKeyIndexKey(key string) string {
+	return "task_key:" + key
+}
+
+// taskKeySequenceKey numbers keys per code rather than per group, so a code
+// given up by one group and taken by another never repeats a key
+func taskKeySequenceKey(code string) string {
+	return "counter:task_key:" + code
+}
+
+// AssignTaskKey gives a new task the next key of its group's code, e.g.
+// GASK-142. Tasks of groups without a code get no key. Keys never change
+// afterwards, even if the task moves or the group's code changes.
+func (r *RedisManager) AssignTaskKey(task *models.Task) error {
+	group, err := r.GetGroup(task.GroupID)
+	if err != nil || group.Code == "" {
+		return err
+	}
+
+	sequence, err := r.client.Incr(r.ctx, taskKeySequenceKey(group.Code)).Result()
+	if err != nil {
+		return err
+	}
+	task.Key = fmt.Sprintf("%s-%d", group.Code, sequence)
+	return nil
+}
+
+// GetTaskByKey resolves a task key, in any case, to its task
+func (r *RedisManager) GetTaskByKey(key string) (*models.Task, error) {
+	taskID, err := r.client.Get(r.ctx, taskKeyIndexKey(strings.ToUpper(key))).Int()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("task %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetTask(taskID)
+}
+
+// EnsureTaskKeySequence moves a code's sequence up to at least sequence, so
+// keys restored from PostgreSQL are not handed out again
+func (r *RedisManager) EnsureTaskKeySequence(code string, sequence int) error {
+	return r.RaiseCounter(taskKeySequenceKey(code), sequence)
+}
@@ -0,0 +1,96 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"task-manager/models"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// MoveTasks moves tasks from one group to another in a single transaction,
+// giving each assignee found in remap's keys to the user it maps to. If any
+// task is missing, has left the source group or changes during the move,
+// nothing moves.
+func (r *RedisManager) MoveTasks(sourceGroupID, targetGroupID int, taskIDs []int, remap map[int]int) ([]*models.Task, error) {
+	keys := make([]string, len(taskIDs))
+	for i, taskID := range taskIDs {
+		// Buffered writes land first, so the move starts from them
+		TaskWrites.settle(taskID)
+		keys[i] = fmt.Sprintf("task:%d", taskID)
+	}
+
+	var moved []*models.Task
+	err := r.client.Watch(r.ctx, func(tx *redis.Tx) error {
+		values, err := tx.MGet(r.ctx, keys...).Result()
+		if err != nil {
+			return err
+		}
+
+		moved = make([]*models.Task, len(values))
+		previous := make([]*taskPlacement, len(values))
+		now := time.Now()
+		for i, value := range values {
+			data, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("task %d %w", taskIDs[i], ErrNotFound)
+			}
+			var task models.Task
+			if err := json.Unmarshal([]byte(data), &task); err != nil {
+				return err
+			}
+			if task.GroupID != sourceGroupID {
+				return fmt.Errorf("%w: task %d is no longer in group %d", ErrConflict, task.ID, sourceGroupID)
+			}
+			previous[i] = placementOf(&task)
+
+			task.GroupID = targetGroupID
+			task.UserID = remapUser(remap, task.UserID)
+			collaborators := make([]int, len(task.Collaborators))
+			for j, userID := range task.Collaborators {
+				collaborators[j] = remapUser(remap, userID)
+			}
+			task.SetCollaborators(collaborators)
+			task.Version++
+			task.UpdatedAt = now
+			task.SyncState()
+			moved[i] = &task
+		}
+
+		_, err = tx.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+			for i, task := range moved {
+				data, err := json.Marshal(task)
+				if err != nil {
+					return err
+				}
+				pipe.Set(r.ctx, keys[i], data, 0)
+				r.indexTask(pipe, previous[i], task)
+			}
+			pipe.SAdd(r.ctx, dirtyTaskGroupsKey, sourceGroupID)
+			return nil
+		})
+		return err
+	}, keys...)
+	if err == redis.TxFailedErr {
+		return nil, fmt.Errorf("%w: tasks changed during the move", ErrConflict)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Reassigned tasks remind their new assignee
+	for _, task := range moved {
+		if err := r.ScheduleDeadlineReminder(task); err != nil {
+			remindersLog.Warn("⚠️ Failed to schedule deadline reminder", "task_id", task.ID, "error", err)
+		}
+	}
+	return moved, nil
+}
+
+func remapUser(remap map[int]int, userID int) int {
+	if to, ok := remap[userID]; ok {
+		return to
+	}
+	return userID
+}
@@ -0,0 +1,194 @@
+package modules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"task-manager/models"
+	"time"
+)
+
+// rankDigits are the digits of a rank in ascending byte order, so ranks
+// compare with plain string comparison
+const rankDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// maxRankLength is how long a rank may grow from repeated moves between the
+// same neighbours before the whole list is ranked afresh
+const maxRankLength = 24
+
+// TaskSorts lists the orders a task listing can be sorted in
+const TaskSorts = "manual, priority, deadline, created"
+
+// IsTaskSort reports whether by is one of TaskSorts
+func IsTaskSort(by string) bool {
+	for _, name := range strings.Split(TaskSorts, ", ") {
+		if by == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RankBetween returns a rank that sorts strictly after before and before
+// after. An empty before is the top of the list and an empty after the
+// bottom. Ranks never end in the lowest digit, so there is always room
+// above one.
+func RankBetween(before, after string) string {
+	var rank []byte
+	bounded := after != ""
+	for i := 0; ; i++ {
+		low := 0
+		if i < len(before) {
+			low = strings.IndexByte(rankDigits, before[i])
+		}
+		high := len(rankDigits)
+		if bounded && i < len(after) {
+			high = strings.IndexByte(rankDigits, after[i])
+		}
+
+		if high-low > 1 {
+			return string(append(rank, rankDigits[(low+high)/2]))
+		}
+		rank = append(rank, rankDigits[low])
+		if high > low {
+			// Anything after this digit sorts before after
+			bounded = false
+		}
+	}
+}
+
+// spreadRanks returns n ascending ranks spaced evenly over the range,
+// leaving room to move tasks between any two of them
+func spreadRanks(n int) []string {
+	width, space := 2, len(rankDigits)*len(rankDigits)
+	for space < (n+1)*len(rankDigits) {
+		width++
+		space *= len(rankDigits)
+	}
+
+	step := space / (n + 1)
+	ranks := make([]string, n)
+	for i := range ranks {
+		value := (i + 1) * step
+		digits := make([]byte, width)
+		for d := width - 1; d >= 0; d-- {
+			digits[d] = rankDigits[value%len(rankDigits)]
+			value /= len(rankDigits)
+		}
+		ranks[i] = strings.TrimRight(string(digits), rankDigits[:1])
+	}
+	return ranks
+}
+
+// SortTasks orders tasks in place. Manual order puts ranked tasks first by
+// rank and the rest after them in creation order; priority puts the most
+// urgent first and deadline the soonest due, with tasks without one last.
+// Ties keep creation order.
+func SortTasks(tasks []*models.Task, by string) {
+	less := func(a, b *models.Task) bool { return a.ID < b.ID }
+	switch by {
+	case "manual":
+		less = func(a, b *models.Task) bool {
+			if (a.Rank == "") != (b.Rank == "") {
+				return a.Rank != ""
+			}
+			if a.Rank != b.Rank {
+				return a.Rank < b.Rank
+			}
+			return a.ID < b.ID
+		}
+	case "priority":
+		less = func(a, b *models.Task) bool {
+			if a.Priority != b.Priority {
+				return a.Priority > b.Priority
+			}
+			return a.ID < b.ID
+		}
+	case "deadline":
+		less = func(a, b *models.Task) bool {
+			if (a.Deadline == "") != (b.Deadline == "") {
+				return a.Deadline != ""
+			}
+			if a.Deadline != b.Deadline {
+				return a.Deadline < b.Deadline
+			}
+			return a.ID < b.ID
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool { return less(tasks[i], tasks[j]) })
+}
+
+// RankTaskAfter moves a task in its assignee's list to just after another
+// of their tasks, or to the top when afterTaskID is 0. Tasks never moved
+// before are ranked in the order they already show in. It returns the moved
+// task and how many tasks were re-ranked.
+func (r *RedisManager) RankTaskAfter(userID, taskID, afterTaskID int) (*models.Task, int, error) {
+	var moved *models.Task
+	changed := 0
+	err := r.WithLock(fmt.Sprintf("rank:user:%d", userID), 10*time.Second, func() error {
+		tasks, err := r.GetUserTasks(userID)
+		if err != nil {
+			return err
+		}
+		SortTasks(tasks, "manual")
+
+		// Take the task out, then find where it goes back in
+		list := make([]*models.Task, 0, len(tasks))
+		for _, task := range tasks {
+			if task.ID == taskID {
+				moved = task
+			} else {
+				list = append(list, task)
+			}
+		}
+		if moved == nil {
+			return fmt.Errorf("task %w", ErrNotFound)
+		}
+		at := 0
+		if afterTaskID != 0 {
+			at = -1
+			for i, task := range list {
+				if task.ID == afterTaskID {
+					at = i + 1
+				}
+			}
+			if at < 0 {
+				return fmt.Errorf("%w: task %d is not in this list", ErrValidation, afterTaskID)
+			}
+		}
+		list = append(list[:at], append([]*models.Task{moved}, list[at:]...)...)
+
+		var before, after string
+		if at > 0 {
+			before = list[at-1].Rank
+		}
+		if at+1 < len(list) {
+			after = list[at+1].Rank
+		}
+		if (at == 0 || before != "") && (after != "" || at+1 == len(list)) && before != after {
+			if rank := RankBetween(before, after); len(rank) <= maxRankLength {
+				moved.Rank = rank
+				changed = 1
+				return r.SaveTaskBehind(moved)
+			}
+		}
+
+		// Unranked neighbours, a tie or a rank grown too long: rank the whole
+		// list afresh
+		for i, rank := range spreadRanks(len(list)) {
+			if list[i].Rank == rank {
+				continue
+			}
+			list[i].Rank = rank
+			if err := r.SaveTaskBehind(list[i]); err != nil {
+				return err
+			}
+			changed++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return moved, changed, nil
+}
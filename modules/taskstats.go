@@ -0,0 +1,355 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Task counts are kept in Redis hashes, updated with every task save and
+// delete, so stats never read the tasks themselves:
+//
+//	stats:tasks             total, done, cancelled and blocked over all tasks
+//	stats:group:{id}:tasks  the same, and user:{id} for each assignee
+//	stats:user:{id}:tasks   the same, priority:{n} and group:{id}
+//	stats:task_keys         every hash above, for reconciliation
+const (
+	globalTaskStatsKey = "stats:tasks"
+	taskStatsKeysKey   = "stats:task_keys"
+
+	// taskStatsBuiltKey marks a database whose counters have been built
+	taskStatsBuiltKey = "schema:task_stats"
+)
+
+var statsLog = Logger("stats")
+
+func groupTaskStatsKey(groupID int) string {
+	return fmt.Sprintf("stats:group:%d:tasks", groupID)
+}
+
+func userTaskStatsKey(userID int) string {
+	return fmt.Sprintf("stats:user:%d:tasks", userID)
+}
+
+// taskStatCounts maps counter hashes to the change of each of their fields
+type taskStatCounts map[string]map[string]int64
+
+// add counts a task placement sign times, +1 for a task saved and -1 for
+// the placement it leaves
+func (c taskStatCounts) add(placement *taskPlacement, sign int64) {
+	if placement == nil {
+		return
+	}
+	var done, cancelled, blocked int64
+	switch {
+	case placement.Status:
+		done = sign
+	case placement.State == models.TaskCancelled:
+		cancelled = sign
+	case placement.State == models.TaskBlocked:
+		blocked = sign
+	}
+
+	for key, fields := range map[string]map[string]int64{
+		globalTaskStatsKey: {"total": sign, "done": done, "cancelled": cancelled, "blocked": blocked},
+		groupTaskStatsKey(placement.GroupID): {
+			"total":                                  sign,
+			"done":                                   done,
+			"cancelled":                              cancelled,
+			"blocked":                                blocked,
+			fmt.Sprintf("user:%d", placement.UserID): sign,
+		},
+		userTaskStatsKey(placement.UserID): {
+			"total":     sign,
+			"done":      done,
+			"cancelled": cancelled,
+			"blocked":   blocked,
+			fmt.Sprintf("priority:%d", placement.Priority): sign,
+			fmt.Sprintf("group:%d", placement.GroupID):     sign,
+		},
+	} {
+		if c[key] == nil {
+			c[key] = make(map[string]int64)
+		}
+		for field, delta := range fields {
+			c[key][field] += delta
+		}
+	}
+}
+
+// countTask moves a task's counts from its previous placement to its new
+// one, either of which is nil for a task created or deleted
+func (r *RedisManager) countTask(pipe redis.Pipeliner, previous, current *taskPlacement) {
+	counts := taskStatCounts{}
+	counts.add(previous, -1)
+	counts.add(current, 1)
+
+	for key, fields := range counts {
+		changed := false
+		for field, delta := range fields {
+			if delta != 0 {
+				pipe.HIncrBy(r.ctx, key, field, delta)
+				changed = true
+			}
+		}
+		if changed {
+			pipe.SAdd(r.ctx, taskStatsKeysKey, key)
+		}
+	}
+}
+
+// TaskCounts are the counted tasks of everyone, a group or a user
+type TaskCounts struct {
+	Total     int64 `json:"total"`
+	Done      int64 `json:"done"`
+	Cancelled int64 `json:"cancelled"`
+	Blocked   int64 `json:"blocked"`
+
+	// ByUser is filled for groups, ByPriority and ByGroup for users
+	ByUser     map[int]int64 `json:"by_user,omitempty"`
+	ByPriority map[int]int64 `json:"by_priority,omitempty"`
+	ByGroup    map[int]int64 `json:"by_group,omitempty"`
+}
+
+// Pending counts the tasks still to be done, blocked ones included
+func (c *TaskCounts) Pending() int64 {
+	return c.Total - c.Done - c.Cancelled
+}
+
+// CompletionRate is the percentage of tasks done, leaving out cancelled ones
+func (c *TaskCounts) CompletionRate() float64 {
+	if c.Total-c.Cancelled <= 0 {
+		return 0
+	}
+	return float64(c.Done) / float64(c.Total-c.Cancelled) * 100
+}
+
+func parseTaskCounts(fields map[string]string) *TaskCounts {
+	counts := &TaskCounts{}
+	for field, value := range fields {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n == 0 {
+			continue
+		}
+
+		kind, idStr, _ := strings.Cut(field, ":")
+		id, _ := strconv.Atoi(idStr)
+		switch kind {
+		case "total":
+			counts.Total = n
+		case "done":
+			counts.Done = n
+		case "cancelled":
+			counts.Cancelled = n
+		case "blocked":
+			counts.Blocked = n
+		case "user":
+			if counts.ByUser == nil {
+				counts.ByUser = make(map[int]int64)
+			}
+			counts.ByUser[id] = n
+		case "priority":
+			if counts.ByPriority == nil {
+				counts.ByPriority = make(map[int]int64)
+			}
+			counts.ByPriority[id] = n
+		case "group":
+			if counts.ByGroup == nil {
+				counts.ByGroup = make(map[int]int64)
+			}
+			counts.ByGroup[id] = n
+		}
+	}
+	return counts
+}
+
+// GetGlobalTaskCounts reads the counts over all tasks
+func (r *RedisManager) GetGlobalTaskCounts() (*TaskCounts, error) {
+	TaskWrites.Flush()
+
+	fields, err := r.client.HGetAll(r.ctx, globalTaskStatsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseTaskCounts(fields), nil
+}
+
+// GetGroupTaskStats reads the counts of each group, with their assignees
+func (r *RedisManager) GetGroupTaskStats(groupIDs []int) (map[int]*TaskCounts, error) {
+	return r.getTaskStats(groupIDs, groupTaskStatsKey)
+}
+
+// GetUserTaskStats reads the counts of each user, by priority and group
+func (r *RedisManager) GetUserTaskStats(userIDs []int) (map[int]*TaskCounts, error) {
+	return r.getTaskStats(userIDs, userTaskStatsKey)
+}
+
+func (r *RedisManager) getTaskStats(ids []int, key func(int) string) (map[int]*TaskCounts, error) {
+	TaskWrites.Flush()
+
+	pipe := r.client.Pipeline()
+	cmds := make(map[int]*redis.StringStringMapCmd, len(ids))
+	for _, id := range ids {
+		cmds[id] = pipe.HGetAll(r.ctx, key(id))
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]*TaskCounts, len(ids))
+	for id, cmd := range cmds {
+		counts[id] = parseTaskCounts(cmd.Val())
+	}
+	return counts, nil
+}
+
+// ReconcileTaskStats recounts every task and replaces the counters, fixing
+// any drift left by saves that raced each other. It returns how many
+// counters changed.
+func (r *RedisManager) ReconcileTaskStats() (int, error) {
+	TaskWrites.Flush()
+
+	counts := taskStatCounts{}
+	err := r.eachTask("tasks:all", func(task *models.Task) error {
+		counts.add(placementOf(task), 1)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	existing, err := r.client.SMembers(r.ctx, taskStatsKeysKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	current := make(map[string]map[string]string, len(existing))
+	read := r.client.Pipeline()
+	cmds := make(map[string]*redis.StringStringMapCmd, len(existing))
+	for _, key := range existing {
+		cmds[key] = read.HGetAll(r.ctx, key)
+	}
+	if _, err := read.Exec(r.ctx); err != nil && err != redis.Nil {
+		return 0, err
+	}
+	for key, cmd := range cmds {
+		current[key] = cmd.Val()
+	}
+
+	pipe := r.client.TxPipeline()
+	changed := 0
+	for _, key := range existing {
+		if counts[key] == nil {
+			pipe.Del(r.ctx, key)
+			pipe.SRem(r.ctx, taskStatsKeysKey, key)
+			changed++
+		}
+	}
+	for key, fields := range counts {
+		values := make(map[string]interface{})
+		for field, n := range fields {
+			if n != 0 {
+				values[field] = n
+			}
+		}
+		if sameTaskStats(current[key], values) {
+			continue
+		}
+		pipe.Del(r.ctx, key)
+		if len(values) > 0 {
+			pipe.HSet(r.ctx, key, values)
+			pipe.SAdd(r.ctx, taskStatsKeysKey, key)
+		}
+		changed++
+	}
+	pipe.Set(r.ctx, taskStatsBuiltKey, time.Now().Unix(), 0)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
+func sameTaskStats(current map[string]string, values map[string]interface{}) bool {
+	nonZero := 0
+	for field, value := range current {
+		if value == "0" {
+			continue
+		}
+		nonZero++
+		if fmt.Sprint(values[field]) != value {
+			return false
+		}
+	}
+	return nonZero == len(values)
+}
+
+// EnsureTaskStats builds the counters once per Redis database, for data
+// saved before tasks were counted
+func (r *RedisManager) EnsureTaskStats() error {
+	built, err := r.client.Exists(r.ctx, taskStatsBuiltKey).Result()
+	if err != nil || built > 0 {
+		return err
+	}
+
+	if _, err := r.ReconcileTaskStats(); err != nil {
+		return err
+	}
+	statsLog.Info("📊 Built task counters")
+	return nil
+}
+
+// statsReconcileLockTTL bounds how long a crashed replica can block
+// reconciliation on the others
+const statsReconcileLockTTL = 5 * time.Minute
+
+// StatsReconciler recounts tasks on a schedule to correct counter drift
+type StatsReconciler struct {
+	interval time.Duration
+	running  bool
+}
+
+var StatsReconcile *StatsReconciler
+
+func InitStatsReconciler(interval time.Duration) {
+	StatsReconcile = &StatsReconciler{
+		interval: interval,
+	}
+}
+
+// Start registers the "stats-reconcile" job; with an interval of 0 it only
+// runs when triggered
+func (s *StatsReconciler) Start() {
+	if s.running {
+		return
+	}
+
+	s.running = true
+	Jobs.Register(JobSpec{
+		Name:     "stats-reconcile",
+		Schedule: EverySchedule(s.interval),
+		Run:      s.reconcile,
+	})
+}
+
+func (s *StatsReconciler) Stop() {
+	s.running = false
+}
+
+// reconcile recounts tasks on one replica at a time
+func (s *StatsReconciler) reconcile() error {
+	err := RedisClient.WithLock("stats:reconcile", statsReconcileLockTTL, func() error {
+		changed, err := RedisClient.ReconcileTaskStats()
+		if err == nil && changed > 0 {
+			statsLog.Warn("⚠️ Corrected drifted task counters", "counters", changed)
+		}
+		return err
+	})
+	if errors.Is(err, ErrLockNotAcquired) {
+		return nil
+	}
+	return err
+}
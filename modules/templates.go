@@ -0,0 +1,79 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"task-manager/models"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func taskTemplateKey(templateID int) string {
+	return fmt.Sprintf("task_template:%d", templateID)
+}
+
+// Task template operations
+func (r *RedisManager) SaveTaskTemplate(template *models.TaskTemplate) error {
+	templateJSON, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(r.ctx, taskTemplateKey(template.ID), templateJSON, 0)
+		pipe.SAdd(r.ctx, "task_templates:all", template.ID)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisManager) GetTaskTemplate(templateID int) (*models.TaskTemplate, error) {
+	templateJSON, err := r.client.Get(r.ctx, taskTemplateKey(templateID)).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("task template %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var template models.TaskTemplate
+	err = json.Unmarshal([]byte(templateJSON), &template)
+	return &template, err
+}
+
+func (r *RedisManager) GetAllTaskTemplates() ([]*models.TaskTemplate, error) {
+	templateIDs, err := r.client.SMembers(r.ctx, "task_templates:all").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make([]*models.TaskTemplate, 0, len(templateIDs))
+	for _, templateIDStr := range templateIDs {
+		templateID, err := strconv.Atoi(templateIDStr)
+		if err != nil {
+			continue
+		}
+
+		template, err := r.GetTaskTemplate(templateID)
+		if err == nil {
+			templates = append(templates, template)
+		}
+	}
+
+	return templates, nil
+}
+
+func (r *RedisManager) DeleteTaskTemplate(templateID int) error {
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(r.ctx, taskTemplateKey(templateID))
+		pipe.SRem(r.ctx, "task_templates:all", templateID)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisManager) GetNextTaskTemplateID() (int, error) {
+	id, err := r.client.Incr(r.ctx, "counter:task_template_id").Result()
+	return int(id), err
+}
@@ -0,0 +1,292 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"task-manager/config"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrTokenReused means a refresh token was presented after it had already
+// been rotated, so the token family has been revoked
+var ErrTokenReused = fmt.Errorf("%w: refresh token was already used", ErrForbidden)
+
+// errInvalidToken means a bearer or refresh token is unknown, expired or revoked
+var errInvalidToken = errors.New("invalid token")
+
+var securityLog = Logger("security")
+
+// TokenPair is an access token for API calls plus the refresh token that replaces it
+type TokenPair struct {
+	TokenType        string    `json:"token_type"`
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// tokenRecord is what Redis keeps for an access or refresh token. Every token
+// issued from one sign-in shares a family, so reuse of any old refresh token
+// can revoke all of them.
+type tokenRecord struct {
+	UserID int    `json:"user_id"`
+	Family string `json:"family"`
+}
+
+func accessTokenKey(token string) string {
+	return "token:access:" + hashToken(token)
+}
+
+func refreshTokenKey(token string) string {
+	return "token:refresh:" + hashToken(token)
+}
+
+func tokenFamilyRevokedKey(family string) string {
+	return fmt.Sprintf("token:family:%s:revoked", family)
+}
+
+// tokenFamily is what the sessions admin API shows of a token sign-in. It
+// lives as long as the family's newest refresh token.
+type tokenFamily struct {
+	UserID     int       `json:"user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func tokenFamilyKey(family string) string {
+	return fmt.Sprintf("token:family:%s", family)
+}
+
+// IssueTokens starts a new token family for a user who just signed in
+func (r *RedisManager) IssueTokens(userID int, accessTTL, refreshTTL time.Duration) (*TokenPair, error) {
+	family, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	pair, err := r.issueTokenPair(&tokenRecord{UserID: userID, Family: family}, accessTTL, refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+	r.enforceSignInLimit(userID)
+	return pair, nil
+}
+
+func (r *RedisManager) issueTokenPair(record *tokenRecord, accessTTL, refreshTTL time.Duration) (*TokenPair, error) {
+	accessToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each pair keeps the family alive for another refreshTTL
+	now := time.Now()
+	family := tokenFamily{UserID: record.UserID, CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(refreshTTL)}
+	if existing, err := r.getTokenFamily(record.Family); err == nil {
+		family.CreatedAt = existing.CreatedAt
+	}
+	familyData, err := json.Marshal(family)
+	if err != nil {
+		return nil, err
+	}
+
+	// Index the family so all of the user's tokens can be revoked together
+	indexKey := fmt.Sprintf("user:%d:token_families", record.UserID)
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(r.ctx, accessTokenKey(accessToken), data, accessTTL)
+		pipe.Set(r.ctx, refreshTokenKey(refreshToken), data, refreshTTL)
+		pipe.Set(r.ctx, tokenFamilyKey(record.Family), familyData, refreshTTL)
+		pipe.SAdd(r.ctx, indexKey, record.Family)
+		pipe.Expire(r.ctx, indexKey, refreshTTL)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		TokenType:        "Bearer",
+		AccessToken:      accessToken,
+		AccessExpiresAt:  now.Add(accessTTL),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: now.Add(refreshTTL),
+	}, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new pair in the same family.
+// Each refresh token works once; presenting a used one revokes the whole family
+// and returns ErrTokenReused along with the affected user ID.
+func (r *RedisManager) RotateRefreshToken(refreshToken string, accessTTL, refreshTTL time.Duration) (*TokenPair, int, error) {
+	record, err := r.getTokenRecord(refreshTokenKey(refreshToken))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Claim the token; only the first caller to claim it may rotate
+	usedKey := refreshTokenKey(refreshToken) + ":used"
+	claimed, err := r.client.SetNX(r.ctx, usedKey, 1, refreshTTL).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	if !claimed {
+		if err := r.RevokeTokenFamily(record.Family, refreshTTL); err != nil {
+			return nil, record.UserID, err
+		}
+		return nil, record.UserID, ErrTokenReused
+	}
+
+	pair, err := r.issueTokenPair(record, accessTTL, refreshTTL)
+	return pair, record.UserID, err
+}
+
+// AccessTokenUser returns the user an access token belongs to and records
+// that its sign-in was used
+func (r *RedisManager) AccessTokenUser(accessToken string) (int, error) {
+	record, err := r.getTokenRecord(accessTokenKey(accessToken))
+	if err != nil {
+		return 0, err
+	}
+	r.touchTokenFamily(record.Family)
+	return record.UserID, nil
+}
+
+// RevokeRefreshToken signs out the token family a refresh token belongs to
+func (r *RedisManager) RevokeRefreshToken(refreshToken string, ttl time.Duration) error {
+	record, err := r.getTokenRecord(refreshTokenKey(refreshToken))
+	if err != nil {
+		return err
+	}
+	return r.RevokeTokenFamily(record.Family, ttl)
+}
+
+// RevokeTokenFamily invalidates every access and refresh token of a family.
+// The marker only has to outlive the family's longest-lived refresh token.
+func (r *RedisManager) RevokeTokenFamily(family string, ttl time.Duration) error {
+	if err := r.client.Set(r.ctx, tokenFamilyRevokedKey(family), 1, ttl).Err(); err != nil {
+		return err
+	}
+	if info, err := r.getTokenFamily(family); err == nil {
+		r.client.SRem(r.ctx, fmt.Sprintf("user:%d:token_families", info.UserID), family)
+	}
+	return r.client.Del(r.ctx, tokenFamilyKey(family)).Err()
+}
+
+// RevokeUserTokens revokes every token family of a user
+func (r *RedisManager) RevokeUserTokens(userID int, ttl time.Duration) error {
+	indexKey := fmt.Sprintf("user:%d:token_families", userID)
+	families, err := r.client.SMembers(r.ctx, indexKey).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, family := range families {
+		if err := r.RevokeTokenFamily(family, ttl); err != nil {
+			return err
+		}
+	}
+	return r.client.Del(r.ctx, indexKey).Err()
+}
+
+// getTokenRecord loads a token and rejects it if its family was revoked
+func (r *RedisManager) getTokenRecord(key string) (*tokenRecord, error) {
+	data, err := r.client.Get(r.ctx, key).Result()
+	if err == redis.Nil {
+		return nil, errInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record tokenRecord
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return nil, err
+	}
+
+	revoked, err := r.client.Exists(r.ctx, tokenFamilyRevokedKey(record.Family)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if revoked > 0 {
+		return nil, errInvalidToken
+	}
+
+	// Families from before idle tracking have no info and never idle out
+	if family, err := r.getTokenFamily(record.Family); err == nil && signInIdle(family.LastSeenAt, time.Now()) {
+		if err := r.RevokeTokenFamily(record.Family, config.AppConfig.RefreshTokenTTL); err != nil {
+			return nil, err
+		}
+		r.recordRevocation(record.UserID, record.Family, SignInToken, RevokedIdle)
+		return nil, errInvalidToken
+	}
+	return &record, nil
+}
+
+func (r *RedisManager) getTokenFamily(family string) (*tokenFamily, error) {
+	data, err := r.client.Get(r.ctx, tokenFamilyKey(family)).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("token family %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var info tokenFamily
+	if err := json.Unmarshal([]byte(data), &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// touchTokenFamily records that a family's access token was just used, at
+// most once per signInTouchInterval
+func (r *RedisManager) touchTokenFamily(family string) {
+	info, err := r.getTokenFamily(family)
+	if err != nil || time.Since(info.LastSeenAt) < signInTouchInterval {
+		return
+	}
+	remaining := time.Until(info.ExpiresAt)
+	if remaining <= 0 {
+		return
+	}
+
+	info.LastSeenAt = time.Now()
+	if data, err := json.Marshal(info); err == nil {
+		r.client.SetXX(r.ctx, tokenFamilyKey(family), data, remaining)
+	}
+}
+
+// IsInvalidToken reports whether err means the presented token is not usable
+func IsInvalidToken(err error) bool {
+	return errors.Is(err, errInvalidToken)
+}
+
+// ReportTokenReuse logs refresh token reuse and notifies the owner and the affected user
+func ReportTokenReuse(ctx context.Context, userID int, ip string) {
+	securityLog.WarnContext(ctx, "🚨 Refresh token reuse detected, token family revoked",
+		"affected_user_id", userID, "ip", ip)
+	Events.Publish(ctx, "security.token_reuse", userID, 0, map[string]interface{}{
+		"ip": ip,
+	})
+}
+
+// SignOutUser ends a user's cookie sessions and revokes their tokens,
+// e.g. after a password change or deactivation
+func SignOutUser(userID int) error {
+	if err := RedisClient.DeleteUserSessions(userID); err != nil {
+		return err
+	}
+	return RedisClient.RevokeUserTokens(userID, config.AppConfig.RefreshTokenTTL)
+}
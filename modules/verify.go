@@ -0,0 +1,352 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"task-manager/models"
+	"time"
+)
+
+// IntegrityIssue is one inconsistency found by VerifyIntegrity
+type IntegrityIssue struct {
+	Check    string `json:"check"`
+	Entity   string `json:"entity"`
+	ID       int    `json:"id,omitempty"`
+	Detail   string `json:"detail"`
+	Fixable  bool   `json:"fixable"`
+	Fixed    bool   `json:"fixed,omitempty"`
+	FixError string `json:"fix_error,omitempty"`
+
+	fix func() error
+}
+
+// IntegrityReport is the result of cross-checking Redis and PostgreSQL
+type IntegrityReport struct {
+	CheckedAt time.Time         `json:"checked_at"`
+	Fix       bool              `json:"fix"`
+	Users     int               `json:"users"`
+	Groups    int               `json:"groups"`
+	Tasks     int               `json:"tasks"`
+	Issues    []*IntegrityIssue `json:"issues"`
+	Fixed     int               `json:"fixed"`
+}
+
+func (rep *IntegrityReport) add(check, entity string, id int, detail string, fix func() error) {
+	rep.Issues = append(rep.Issues, &IntegrityIssue{
+		Check:   check,
+		Entity:  entity,
+		ID:      id,
+		Detail:  detail,
+		Fixable: fix != nil,
+		fix:     fix,
+	})
+}
+
+// VerifyIntegrity looks for data that does not add up: index entries without
+// a record, tasks of missing users or groups, memberships of missing groups,
+// duplicate emails, ID counters behind the data, and records only in one of
+// Redis and PostgreSQL. With fix, the issues that can be repaired safely are.
+// Repairs are made in Redis and reach PostgreSQL with the next sync.
+func VerifyIntegrity(fix bool) (*IntegrityReport, error) {
+	report := &IntegrityReport{CheckedAt: time.Now(), Fix: fix, Issues: []*IntegrityIssue{}}
+	TaskWrites.Flush()
+
+	userIDs, err := RedisClient.indexIDs("users:all")
+	if err != nil {
+		return nil, err
+	}
+	groupIDs, err := RedisClient.indexIDs("groups:all")
+	if err != nil {
+		return nil, err
+	}
+	taskIDs, err := RedisClient.indexIDs("tasks:all")
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[int]*models.User, len(userIDs))
+	for _, id := range userIDs {
+		user, err := RedisClient.GetUser(id)
+		if err != nil {
+			report.addLoadError("users:all", "user", id, err)
+			continue
+		}
+		users[id] = user
+	}
+
+	groups := make(map[int]*models.Group, len(groupIDs))
+	for _, id := range groupIDs {
+		group, err := RedisClient.GetGroup(id)
+		if err != nil {
+			report.addLoadError("groups:all", "group", id, err)
+			continue
+		}
+		groups[id] = group
+	}
+
+	var tasks []*models.Task
+	for _, id := range taskIDs {
+		task, err := RedisClient.GetTask(id)
+		if err != nil {
+			report.addLoadError("tasks:all", "task", id, err)
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+
+	report.Users, report.Groups, report.Tasks = len(users), len(groups), len(tasks)
+
+	checkTaskOwners(report, tasks, users, groups)
+	checkUserGroups(report, userIDs, users, groups)
+	checkDuplicateEmails(report, userIDs, users)
+
+	if err := checkCounters(report, userIDs, groupIDs, taskIDs); err != nil {
+		return nil, err
+	}
+
+	if PostgresClient != nil {
+		pgChecks := []struct {
+			entity, dirtyType string
+			redisIDs          []int
+			postgresIDs       func() ([]int, error)
+		}{
+			{"user", "users", userIDs, PostgresClient.GetUserIDs},
+			{"group", "groups", groupIDs, PostgresClient.GetGroupIDs},
+			{"task", "tasks", taskIDs, PostgresClient.GetTaskIDs},
+		}
+		for _, pgCheck := range pgChecks {
+			postgresIDs, err := pgCheck.postgresIDs()
+			if err != nil {
+				return nil, err
+			}
+			checkStoresAgree(report, pgCheck.entity, pgCheck.dirtyType, pgCheck.redisIDs, postgresIDs)
+		}
+	}
+
+	if fix {
+		for _, issue := range report.Issues {
+			if issue.fix == nil {
+				continue
+			}
+			if err := issue.fix(); err != nil {
+				issue.FixError = err.Error()
+				continue
+			}
+			issue.Fixed = true
+			report.Fixed++
+		}
+	}
+
+	return report, nil
+}
+
+// addLoadError reports an index entry whose record is missing or unreadable.
+// Entries without a record are dropped from the index when fixing.
+func (rep *IntegrityReport) addLoadError(index, entity string, id int, err error) {
+	if errors.Is(err, ErrNotFound) {
+		rep.add("stale_index", entity, id, fmt.Sprintf("%s lists %s %d but the record is missing", index, entity, id), func() error {
+			return RedisClient.client.SRem(RedisClient.ctx, index, id).Err()
+		})
+		return
+	}
+	rep.add("unreadable_record", entity, id, err.Error(), nil)
+}
+
+// checkTaskOwners finds tasks whose user or group is gone. A task of a
+// missing user is handed to its group's admin when fixing.
+func checkTaskOwners(report *IntegrityReport, tasks []*models.Task, users map[int]*models.User, groups map[int]*models.Group) {
+	for _, task := range tasks {
+		group, groupExists := groups[task.GroupID]
+		if !groupExists {
+			report.add("orphan_task", "task", task.ID, fmt.Sprintf("group %d does not exist", task.GroupID), nil)
+		}
+		if _, ok := users[task.UserID]; ok {
+			continue
+		}
+
+		detail := fmt.Sprintf("user %d does not exist", task.UserID)
+		if !groupExists || users[group.AdminID] == nil {
+			report.add("orphan_task", "task", task.ID, detail, nil)
+			continue
+		}
+
+		task, adminID := task, group.AdminID
+		report.add("orphan_task", "task", task.ID, fmt.Sprintf("%s; reassign to group admin %d", detail, adminID), func() error {
+			RedisClient.client.SRem(RedisClient.ctx, fmt.Sprintf("user:%d:tasks", task.UserID), task.ID)
+			task.UserID = adminID
+			task.UpdatedAt = time.Now()
+			if err := RedisClient.SaveTask(task); err != nil {
+				return err
+			}
+			return RedisClient.MarkDirty("tasks")
+		})
+	}
+}
+
+// checkUserGroups finds memberships of groups that no longer exist, which
+// are removed when fixing
+func checkUserGroups(report *IntegrityReport, userIDs []int, users map[int]*models.User, groups map[int]*models.Group) {
+	for _, id := range userIDs {
+		user := users[id]
+		if user == nil {
+			continue
+		}
+
+		for _, groupID := range user.GroupIDs {
+			if _, ok := groups[groupID]; ok {
+				continue
+			}
+
+			user, groupID := user, groupID
+			report.add("missing_group", "user", user.ID, fmt.Sprintf("member of group %d, which does not exist", groupID), func() error {
+				kept := make(models.IntSlice, 0, len(user.GroupIDs))
+				for _, id := range user.GroupIDs {
+					if id != groupID {
+						kept = append(kept, id)
+					}
+				}
+				user.GroupIDs = kept
+				user.UpdatedAt = time.Now()
+				RedisClient.client.SRem(RedisClient.ctx, fmt.Sprintf("group:%d:users", groupID), user.ID)
+				if err := RedisClient.SaveUser(user); err != nil {
+					return err
+				}
+				return RedisClient.MarkDirty("users")
+			})
+		}
+	}
+}
+
+// checkDuplicateEmails finds accounts sharing an email in any case. They
+// are left for the owner to merge.
+func checkDuplicateEmails(report *IntegrityReport, userIDs []int, users map[int]*models.User) {
+	byEmail := make(map[string][]int)
+	var emails []string
+	for _, id := range userIDs {
+		user := users[id]
+		if user == nil {
+			continue
+		}
+		email := strings.ToLower(strings.TrimSpace(user.Email))
+		if len(byEmail[email]) == 0 {
+			emails = append(emails, email)
+		}
+		byEmail[email] = append(byEmail[email], id)
+	}
+
+	for _, email := range emails {
+		ids := byEmail[email]
+		if len(ids) < 2 {
+			continue
+		}
+
+		idStrings := make([]string, len(ids))
+		for i, id := range ids {
+			idStrings[i] = strconv.Itoa(id)
+		}
+		report.add("duplicate_email", "user", ids[0], fmt.Sprintf("users %s share %s; merge them with POST /admin/users/{id}/merge", strings.Join(idStrings, ", "), email), nil)
+	}
+}
+
+// checkCounters finds ID counters at or below an ID already in use, which
+// would hand that ID out again. Fixing raises them.
+func checkCounters(report *IntegrityReport, userIDs, groupIDs, taskIDs []int) error {
+	counters := []struct {
+		key      string
+		redisIDs []int
+		maxID    func() (int, error)
+	}{
+		{"counter:user_id", userIDs, nil},
+		{"counter:group_id", groupIDs, nil},
+		{"counter:task_id", taskIDs, nil},
+	}
+	if PostgresClient != nil {
+		counters[0].maxID = PostgresClient.GetMaxUserID
+		counters[1].maxID = PostgresClient.GetMaxGroupID
+		counters[2].maxID = PostgresClient.GetMaxTaskID
+	}
+
+	for _, counter := range counters {
+		highest := 0
+		if len(counter.redisIDs) > 0 {
+			highest = counter.redisIDs[len(counter.redisIDs)-1]
+		}
+		if counter.maxID != nil {
+			maxID, err := counter.maxID()
+			if err != nil {
+				return err
+			}
+			if maxID > highest {
+				highest = maxID
+			}
+		}
+
+		current, err := RedisClient.GetCounter(counter.key)
+		if err != nil {
+			return err
+		}
+		if current >= highest {
+			continue
+		}
+
+		key, floor := counter.key, highest
+		report.add("counter_drift", "counter", 0, fmt.Sprintf("%s is %d but ID %d is in use", key, current, highest), func() error {
+			return RedisClient.RaiseCounter(key, floor)
+		})
+	}
+	return nil
+}
+
+// checkStoresAgree finds records in only one store. Records missing from
+// PostgreSQL are synced when fixing. Records missing from Redis are
+// usually deleted ones, since deletions are not synced, so they are only
+// reported.
+func checkStoresAgree(report *IntegrityReport, entity, dirtyType string, redisIDs, postgresIDs []int) {
+	inRedis := make(map[int]bool, len(redisIDs))
+	for _, id := range redisIDs {
+		inRedis[id] = true
+	}
+	inPostgres := make(map[int]bool, len(postgresIDs))
+	for _, id := range postgresIDs {
+		inPostgres[id] = true
+	}
+
+	for _, id := range redisIDs {
+		if inPostgres[id] {
+			continue
+		}
+		report.add("missing_in_postgres", entity, id, fmt.Sprintf("%s %d is not in PostgreSQL yet", entity, id), func() error {
+			return RedisClient.MarkDirty(dirtyType)
+		})
+	}
+
+	sort.Ints(postgresIDs)
+	for _, id := range postgresIDs {
+		if inRedis[id] {
+			continue
+		}
+		report.add("missing_in_redis", entity, id, fmt.Sprintf("%s %d is only in PostgreSQL; it was deleted, or Redis lost it (restore with POST /admin/sync?action=restore)", entity, id), nil)
+	}
+}
+
+// indexIDs returns the IDs in an index set, sorted
+func (r *RedisManager) indexIDs(index string) ([]int, error) {
+	members, err := r.client.SMembers(r.ctx, index).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.Atoi(member)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
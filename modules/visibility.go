@@ -0,0 +1,72 @@
+package modules
+
+import (
+	"fmt"
+	"strconv"
+	"task-manager/models"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// group:{id}:visible_tasks holds the tasks of other groups shared with a
+// group, beside group:{id}:tasks for those it owns
+func groupVisibleTasksKey(groupID int) string {
+	return fmt.Sprintf("group:%d:visible_tasks", groupID)
+}
+
+// indexVisibleTask files a task under each group it is visible to, leaving
+// those it was visible to before
+func (r *RedisManager) indexVisibleTask(pipe redis.Pipeliner, previous *taskPlacement, task *models.Task) {
+	if previous != nil {
+		for _, groupID := range previous.VisibleToGroups {
+			if !containsID(task.VisibleToGroups, groupID) {
+				pipe.SRem(r.ctx, groupVisibleTasksKey(groupID), task.ID)
+			}
+		}
+	}
+	for _, groupID := range task.VisibleToGroups {
+		pipe.SAdd(r.ctx, groupVisibleTasksKey(groupID), task.ID)
+	}
+}
+
+// GetGroupVisibleTasks reads the tasks other groups have shared with a group
+func (r *RedisManager) GetGroupVisibleTasks(groupID int) ([]*models.Task, error) {
+	TaskWrites.Flush()
+
+	taskIDs, err := r.client.SMembers(r.ctx, groupVisibleTasksKey(groupID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*models.Task
+	for _, taskIDStr := range taskIDs {
+		taskID, err := strconv.Atoi(taskIDStr)
+		if err != nil {
+			continue
+		}
+
+		task, err := r.GetTask(taskID)
+		if err == nil {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks, nil
+}
+
+// CanViewTask checks whether the caller may read a task: anyone who may
+// modify it, or an admin of a group it is visible to. Visibility never
+// grants the right to change the task.
+func CanViewTask(authCtx *AuthContext, task *models.Task) bool {
+	if CanModifyTask(authCtx, task) {
+		return true
+	}
+	if authCtx.IsGroupAdmin {
+		for _, adminGroupID := range authCtx.AdminGroupIDs {
+			if task.VisibleTo(adminGroupID) {
+				return true
+			}
+		}
+	}
+	return false
+}
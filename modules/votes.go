@@ -0,0 +1,91 @@
+package modules
+
+import (
+	"fmt"
+	"sort"
+	"task-manager/models"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// taskVotesKey holds the IDs of the users who upvoted a task. Being a set,
+// it keeps one vote per user.
+func taskVotesKey(taskID int) string {
+	return fmt.Sprintf("task:%d:votes", taskID)
+}
+
+// BacklogTask is an open task with its vote count. Who voted is never
+// shown; Voted only tells the caller whether they did.
+type BacklogTask struct {
+	Task  *models.Task `json:"task"`
+	Votes int64        `json:"votes"`
+	Voted bool         `json:"voted"`
+}
+
+// VoteTask adds or withdraws a user's vote, returning whether anything
+// changed and the task's vote count
+func (r *RedisManager) VoteTask(taskID, userID int, vote bool) (bool, int64, error) {
+	key := taskVotesKey(taskID)
+	pipe := r.client.TxPipeline()
+	var changed *redis.IntCmd
+	if vote {
+		changed = pipe.SAdd(r.ctx, key, userID)
+	} else {
+		changed = pipe.SRem(r.ctx, key, userID)
+	}
+	count := pipe.SCard(r.ctx, key)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return false, 0, err
+	}
+	return changed.Val() > 0, count.Val(), nil
+}
+
+// DeleteTaskVotes drops a deleted task's votes
+func (r *RedisManager) DeleteTaskVotes(taskID int) error {
+	return r.client.Del(r.ctx, taskVotesKey(taskID)).Err()
+}
+
+// GetGroupBacklog returns the group's open tasks, most voted first, then by
+// priority and age. userID marks the tasks that user voted for.
+func (r *RedisManager) GetGroupBacklog(groupID, userID int) ([]*BacklogTask, error) {
+	tasks, err := r.GetGroupTasks(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var open []*models.Task
+	for _, task := range tasks {
+		if !task.Closed() {
+			open = append(open, task)
+		}
+	}
+
+	pipe := r.client.Pipeline()
+	counts := make([]*redis.IntCmd, len(open))
+	voted := make([]*redis.BoolCmd, len(open))
+	for i, task := range open {
+		counts[i] = pipe.SCard(r.ctx, taskVotesKey(task.ID))
+		voted[i] = pipe.SIsMember(r.ctx, taskVotesKey(task.ID), userID)
+	}
+	if len(open) > 0 {
+		if _, err := pipe.Exec(r.ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	backlog := make([]*BacklogTask, len(open))
+	for i, task := range open {
+		backlog[i] = &BacklogTask{Task: task, Votes: counts[i].Val(), Voted: voted[i].Val()}
+	}
+	sort.SliceStable(backlog, func(i, j int) bool {
+		a, b := backlog[i], backlog[j]
+		if a.Votes != b.Votes {
+			return a.Votes > b.Votes
+		}
+		if a.Task.Priority != b.Task.Priority {
+			return a.Task.Priority > b.Task.Priority
+		}
+		return a.Task.ID < b.Task.ID
+	})
+	return backlog, nil
+}
@@ -0,0 +1,138 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"task-manager/models"
+	"text/template"
+	"time"
+)
+
+// maxWebhookResponse caps how much of a receiver's response a test-fire shows
+const maxWebhookResponse = 4096
+
+// webhookHeaderPattern is what a settable header name looks like
+var webhookHeaderPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+
+// reservedWebhookHeaders are set by the HTTP client and cannot be overridden
+var reservedWebhookHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+}
+
+// webhookFuncs are available in webhook templates, e.g.
+// {"text": {{json .Title}}, "due": "{{date "2006-01-02" .PeriodEnd}}"}
+var webhookFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// ParseWebhookTemplate compiles a webhook payload template
+func ParseWebhookTemplate(text string) (*template.Template, error) {
+	return template.New("webhook").Funcs(webhookFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// CheckWebhookHeader returns why a custom header cannot be sent, or "" if it can
+func CheckWebhookHeader(name, value string) string {
+	if !webhookHeaderPattern.MatchString(name) {
+		return "invalid name"
+	}
+	if reservedWebhookHeaders[http.CanonicalHeaderKey(name)] {
+		return "set automatically"
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return "value contains a line break"
+	}
+	return ""
+}
+
+// RenderWebhook builds the body and headers a subscription's webhook is sent
+// with. Without a template the report is sent as JSON.
+func RenderWebhook(sub *models.ReportSubscription, report *models.Report) ([]byte, map[string]string, error) {
+	headers := map[string]string{"Content-Type": "application/json"}
+	for name, value := range sub.WebhookHeaders {
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+
+	if sub.WebhookTemplate == "" {
+		payload, err := json.Marshal(report)
+		return payload, headers, err
+	}
+
+	tmpl, err := ParseWebhookTemplate(sub.WebhookTemplate)
+	if err != nil {
+		return nil, headers, err
+	}
+
+	var payload bytes.Buffer
+	if err := tmpl.Execute(&payload, report); err != nil {
+		return nil, headers, fmt.Errorf("render webhook template: %w", err)
+	}
+	return payload.Bytes(), headers, nil
+}
+
+// postWebhook sends a rendered payload and returns the receiver's response
+func (s *ReportScheduler) postWebhook(url string, payload []byte, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	return s.client.Do(req)
+}
+
+// TestWebhook generates the subscription's report now, renders its webhook
+// and, unless dryRun, sends it. Failures are reported in the result rather
+// than as an error so the caller can show what was rendered.
+func (s *ReportScheduler) TestWebhook(sub *models.ReportSubscription, dryRun bool) (*models.WebhookTest, error) {
+	report, err := GenerateReport(sub, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.WebhookTest{}
+	payload, headers, err := RenderWebhook(sub, report)
+	result.Payload = string(payload)
+	result.Headers = headers
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	started := time.Now()
+	resp, err := s.postWebhook(sub.WebhookURL, payload, headers)
+	result.DurationMs = time.Since(started).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponse))
+	result.Sent = true
+	result.StatusCode = resp.StatusCode
+	result.Response = string(body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		result.Error = fmt.Sprintf("webhook returned %d", resp.StatusCode)
+	}
+	return result, nil
+}
@@ -0,0 +1,220 @@
+package modules
+
+import (
+	"sync"
+	"task-manager/models"
+	"time"
+)
+
+var writeBehindLog = Logger("writebehind")
+
+// TaskWriteBuffer holds task saves for a short window so that rapid saves of
+// the same task, such as dragging it across a board, reach Redis as one
+// write and one dirty mark. Reads stay consistent: GetTask returns a held
+// task and reads of many tasks write everything held first.
+//
+// A held save is written only if the task did not change in Redis since it
+// was read, so one made through another instance within the window wins and
+// the held one is dropped with a warning.
+type TaskWriteBuffer struct {
+	window time.Duration
+
+	mu       sync.Mutex
+	pending  map[int]*pendingTaskWrite
+	flushing map[int]chan struct{}
+
+	coalesced uint64
+	written   uint64
+	dropped   uint64
+}
+
+// pendingTaskWrite is the latest save of a task and the version it was read
+// at before the first of the saves it stands for
+type pendingTaskWrite struct {
+	task models.Task
+	base int
+}
+
+var TaskWrites *TaskWriteBuffer
+
+// InitTaskWriteBuffer holds saves for window, or writes them straight
+// through when window is zero
+func InitTaskWriteBuffer(window time.Duration) {
+	TaskWrites = &TaskWriteBuffer{
+		window:   window,
+		pending:  make(map[int]*pendingTaskWrite),
+		flushing: make(map[int]chan struct{}),
+	}
+}
+
+// SaveTaskBehind saves a task and marks tasks dirty for sync, holding the
+// write back when the buffer is enabled
+func (r *RedisManager) SaveTaskBehind(task *models.Task) error {
+	return TaskWrites.save(task, false)
+}
+
+// SaveTaskBehindIfVersion is SaveTaskBehind for a task still at the given version
+func (r *RedisManager) SaveTaskBehindIfVersion(task *models.Task, version int) error {
+	task.Version = version
+	return TaskWrites.save(task, true)
+}
+
+func (b *TaskWriteBuffer) enabled() bool {
+	return b != nil && b.window > 0
+}
+
+func (b *TaskWriteBuffer) save(task *models.Task, checkVersion bool) error {
+	// Settle the state now so the caller responds with what will be stored
+	task.SyncState()
+	if !b.enabled() {
+		if err := RedisClient.saveTask(task, checkVersion); err != nil {
+			return err
+		}
+		return RedisClient.MarkDirty("tasks")
+	}
+
+	for {
+		b.mu.Lock()
+		if done := b.flushing[task.ID]; done != nil {
+			b.mu.Unlock()
+			<-done
+			continue
+		}
+
+		if held := b.pending[task.ID]; held != nil {
+			if checkVersion && held.task.Version != task.Version {
+				b.mu.Unlock()
+				return ErrVersionConflict
+			}
+			task.Version = held.task.Version + 1
+			held.task = *task
+			b.coalesced++
+		} else {
+			held = &pendingTaskWrite{base: task.Version}
+			task.Version++
+			held.task = *task
+			b.pending[task.ID] = held
+
+			taskID := task.ID
+			time.AfterFunc(b.window, func() { b.flush(taskID) })
+		}
+		b.mu.Unlock()
+		return nil
+	}
+}
+
+// lookup returns the held save of a task. A task being written is waited
+// for, and then read from Redis like any other.
+func (b *TaskWriteBuffer) lookup(taskID int) (*models.Task, bool) {
+	if !b.enabled() {
+		return nil, false
+	}
+
+	b.mu.Lock()
+	if held := b.pending[taskID]; held != nil {
+		task := held.task
+		b.mu.Unlock()
+		return &task, true
+	}
+	done := b.flushing[taskID]
+	b.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+	return nil, false
+}
+
+// flush writes the held save of a task, if there is one
+func (b *TaskWriteBuffer) flush(taskID int) {
+	b.mu.Lock()
+	held := b.pending[taskID]
+	if held == nil {
+		b.mu.Unlock()
+		return
+	}
+	done := make(chan struct{})
+	delete(b.pending, taskID)
+	b.flushing[taskID] = done
+	b.mu.Unlock()
+
+	err := RedisClient.writeTask(&held.task, held.base, true)
+	if err == nil {
+		err = RedisClient.MarkDirty("tasks")
+	}
+
+	b.mu.Lock()
+	delete(b.flushing, taskID)
+	if err != nil {
+		b.dropped++
+	} else {
+		b.written++
+	}
+	b.mu.Unlock()
+	close(done)
+
+	if err != nil {
+		writeBehindLog.Warn("⚠️ Dropped buffered task save", "task_id", taskID, "version", held.task.Version, "error", err)
+	}
+}
+
+// settle writes the held save of a task and waits for one being written
+func (b *TaskWriteBuffer) settle(taskID int) {
+	if !b.enabled() {
+		return
+	}
+
+	b.flush(taskID)
+	b.mu.Lock()
+	done := b.flushing[taskID]
+	b.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// Flush writes every held save and waits for those being written
+func (b *TaskWriteBuffer) Flush() {
+	if !b.enabled() {
+		return
+	}
+
+	b.mu.Lock()
+	taskIDs := make([]int, 0, len(b.pending))
+	for taskID := range b.pending {
+		taskIDs = append(taskIDs, taskID)
+	}
+	b.mu.Unlock()
+
+	for _, taskID := range taskIDs {
+		b.settle(taskID)
+	}
+
+	b.mu.Lock()
+	inFlight := make([]chan struct{}, 0, len(b.flushing))
+	for _, done := range b.flushing {
+		inFlight = append(inFlight, done)
+	}
+	b.mu.Unlock()
+	for _, done := range inFlight {
+		<-done
+	}
+}
+
+// Status reports the buffer's counts for the admin status endpoint
+func (b *TaskWriteBuffer) Status() map[string]interface{} {
+	if !b.enabled() {
+		return map[string]interface{}{"enabled": false}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return map[string]interface{}{
+		"enabled":         true,
+		"window":          b.window.String(),
+		"pending":         len(b.pending),
+		"coalesced_total": b.coalesced,
+		"written_total":   b.written,
+		"dropped_total":   b.dropped,
+	}
+}
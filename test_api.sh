@@ -144,7 +144,7 @@ RESPONSE=$(curl -s -w "\n%{http_code}" -X POST \
     \"password\": \"alice123\",
     \"role\": \"group_admin\",
     \"group_ids\": [$GROUP_ENG_ID],
-    \"work_times\": {\"Monday\": 8, \"Tuesday\": 8, \"Wednesday\": 8, \"Thursday\": 8, \"Friday\": 8}
+    \"work_times\": {\"monday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"tuesday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"wednesday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"thursday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"friday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}]}
   }" \
   "$BASE_URL/users")
 BODY=$(echo "$RESPONSE" | head -n -1)
@@ -173,7 +173,7 @@ RESPONSE=$(curl -s -w "\n%{http_code}" -X POST \
     \"password\": \"bob123\",
     \"role\": \"group_admin\",
     \"group_ids\": [$GROUP_MKT_ID],
-    \"work_times\": {\"Monday\": 8, \"Tuesday\": 8, \"Wednesday\": 8, \"Thursday\": 8, \"Friday\": 6}
+    \"work_times\": {\"monday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"tuesday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"wednesday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"thursday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"friday\": [{\"start\": \"09:00\", \"end\": \"15:00\"}]}
   }" \
   "$BASE_URL/users")
 BODY=$(echo "$RESPONSE" | head -n -1)
@@ -210,7 +210,7 @@ RESPONSE=$(curl -s -w "\n%{http_code}" -X POST \
     \"password\": \"charlie123\",
     \"role\": \"user\",
     \"group_ids\": [$GROUP_ENG_ID],
-    \"work_times\": {\"Monday\": 8, \"Tuesday\": 8, \"Wednesday\": 8, \"Thursday\": 8, \"Friday\": 8}
+    \"work_times\": {\"monday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"tuesday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"wednesday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"thursday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"friday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}]}
   }" \
   "$BASE_URL/users")
 BODY=$(echo "$RESPONSE" | head -n -1)
@@ -229,7 +229,7 @@ RESPONSE=$(curl -s -w "\n%{http_code}" -X POST \
     \"password\": \"diana123\",
     \"role\": \"user\",
     \"group_ids\": [$GROUP_ENG_ID],
-    \"work_times\": {\"Monday\": 6, \"Tuesday\": 8, \"Wednesday\": 8, \"Thursday\": 8, \"Friday\": 8}
+    \"work_times\": {\"monday\": [{\"start\": \"09:00\", \"end\": \"15:00\"}], \"tuesday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"wednesday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"thursday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"friday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}]}
   }" \
   "$BASE_URL/users")
 BODY=$(echo "$RESPONSE" | head -n -1)
@@ -248,7 +248,7 @@ RESPONSE=$(curl -s -w "\n%{http_code}" -X POST \
     \"password\": \"eve123\",
     \"role\": \"user\",
     \"group_ids\": [$GROUP_MKT_ID],
-    \"work_times\": {\"Monday\": 8, \"Tuesday\": 8, \"Wednesday\": 8, \"Thursday\": 8, \"Friday\": 6}
+    \"work_times\": {\"monday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"tuesday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"wednesday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"thursday\": [{\"start\": \"09:00\", \"end\": \"17:00\"}], \"friday\": [{\"start\": \"09:00\", \"end\": \"15:00\"}]}
   }" \
   "$BASE_URL/users")
 BODY=$(echo "$RESPONSE" | head -n -1)
@@ -518,11 +518,11 @@ RESPONSE=$(curl -s -w "\n%{http_code}" -X PUT \
   -H "Content-Type: application/json" \
   -d "{
     \"work_times\": {
-      \"Monday\": 9,
-      \"Tuesday\": 9,
-      \"Wednesday\": 9,
-      \"Thursday\": 9,
-      \"Friday\": 6
+      \"monday\": [{\"start\": \"09:00\", \"end\": \"18:00\"}],
+      \"tuesday\": [{\"start\": \"09:00\", \"end\": \"18:00\"}],
+      \"wednesday\": [{\"start\": \"09:00\", \"end\": \"18:00\"}],
+      \"thursday\": [{\"start\": \"09:00\", \"end\": \"18:00\"}],
+      \"friday\": [{\"start\": \"09:00\", \"end\": \"15:00\"}]
     }
   }" \
   "$BASE_URL/users/$USER_ENG1_ID/worktimes")
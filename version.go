@@ -0,0 +1,46 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information, set at build time with
+// -ldflags "-X main.Version=2.1.0 -X main.Commit=abc1234 -X main.BuildDate=2026-10-16"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// buildInfo describes the running binary. Without a commit from the build
+// flags, the one Go recorded from the checkout is used.
+func buildInfo() map[string]string {
+	commit, date := Commit, BuildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && commit == "":
+				commit = setting.Value
+			case setting.Key == "vcs.time" && date == "":
+				date = setting.Value
+			}
+		}
+	}
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	if commit == "" {
+		commit = "unknown"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+
+	return map[string]string{
+		"version":    Version,
+		"commit":     commit,
+		"build_date": date,
+		"go":         runtime.Version(),
+	}
+}